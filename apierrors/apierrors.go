@@ -0,0 +1,213 @@
+package apierrors
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// A stable, machine-readable name for something that went wrong. Clients can switch on these; the messages
+// that go with them are for people and may change.
+type Code string
+
+const (
+	// the request itself is wrong
+	CodeInvalidRequest       Code = "INVALID_REQUEST"
+	CodeMissingParameter     Code = "MISSING_PARAMETER"
+	CodeInvalidParameter     Code = "INVALID_PARAMETER"
+	CodeInvalidAddress       Code = "INVALID_ADDRESS"
+	CodeInvalidPrivateKey    Code = "INVALID_PRIVATE_KEY"
+	CodeInvalidCursor        Code = "INVALID_CURSOR"
+	CodeInvalidSignInMessage Code = "INVALID_SIGN_IN_MESSAGE"
+	CodeInvalidSignature     Code = "INVALID_SIGNATURE"
+
+	// who is calling, and whether they may
+	CodeUnauthenticated    Code = "UNAUTHENTICATED"
+	CodeInvalidCredentials Code = "INVALID_CREDENTIALS"
+	CodeForbidden          Code = "FORBIDDEN"
+
+	// the thing asked about doesn't exist
+	CodeOrderNotFound           Code = "ORDER_NOT_FOUND"
+	CodeProductNotFound         Code = "PRODUCT_NOT_FOUND"
+	CodeDeliveryTokenNotFound   Code = "DELIVERY_TOKEN_NOT_FOUND"
+	CodeWebhookNotFound         Code = "WEBHOOK_NOT_FOUND"
+	CodeWebhookDeliveryNotFound Code = "WEBHOOK_DELIVERY_NOT_FOUND"
+	CodeApiKeyNotFound          Code = "API_KEY_NOT_FOUND"
+	CodeReconciliationNotFound  Code = "RECONCILIATION_NOT_FOUND"
+	CodeCustomerNotFound        Code = "CUSTOMER_NOT_FOUND"
+	CodeCustomerAddressNotFound Code = "CUSTOMER_ADDRESS_NOT_FOUND"
+	CodeVendorNotFound          Code = "VENDOR_NOT_FOUND"
+
+	// the request clashes with the state of things
+	CodeOrderAlreadyPaid         Code = "ORDER_ALREADY_PAID"
+	CodeOrderStatusConflict      Code = "ORDER_STATUS_CONFLICT"
+	CodeOrderOperationInProgress Code = "ORDER_OPERATION_IN_PROGRESS"
+	CodeProductAlreadyExists     Code = "PRODUCT_ALREADY_EXISTS"
+	CodeWebhookDeliveryNotDead   Code = "WEBHOOK_DELIVERY_NOT_DEAD"
+	CodeIdempotencyKeyInProgress Code = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	CodeIdempotencyKeyReused     Code = "IDEMPOTENCY_KEY_REUSED"
+	CodeReconciliationInProgress Code = "RECONCILIATION_IN_PROGRESS"
+	CodeSweepNotConfigured       Code = "SWEEP_NOT_CONFIGURED"
+	CodeEscrowNotExpired         Code = "ESCROW_NOT_EXPIRED"
+	CodeEscrowUnsupported        Code = "ESCROW_UNSUPPORTED"
+	CodeDeliveryEvidenceMissing  Code = "DELIVERY_EVIDENCE_MISSING"
+	CodeCustodyNotConfigured     Code = "CUSTODY_NOT_CONFIGURED"
+	CodeAddressNotVerified       Code = "ADDRESS_NOT_VERIFIED"
+	CodeAddressAlreadyClaimed    Code = "ADDRESS_ALREADY_CLAIMED"
+	CodeChallengeExpired         Code = "CHALLENGE_EXPIRED"
+	CodeVendorAlreadyExists      Code = "VENDOR_ALREADY_EXISTS"
+	CodeVendorNotReady           Code = "VENDOR_NOT_READY"
+	CodeContractAlreadyDeployed  Code = "CONTRACT_ALREADY_DEPLOYED"
+
+	// the blockchain said no, or isn't answering
+	CodeTxReverted        Code = "TX_REVERTED"
+	CodeInsufficientFunds Code = "INSUFFICIENT_FUNDS"
+	CodeChainError        Code = "CHAIN_ERROR"
+	CodeChainUnavailable  Code = "CHAIN_UNAVAILABLE"
+
+	// everything else
+	CodeInternal Code = "INTERNAL"
+)
+
+// What each code means, and the HTTP status that goes with it. The API docs list these too.
+var catalog = map[Code]struct {
+	status      int
+	description string
+}{
+	CodeInvalidRequest:       {400, "The request couldn't be understood, e.g. the body isn't valid JSON"},
+	CodeMissingParameter:     {400, "Required parameters are missing. details.fields lists them"},
+	CodeInvalidParameter:     {400, "A parameter has a value that isn't allowed, or several are wrong in different ways. details.fields names them"},
+	CodeInvalidAddress:       {400, "An address isn't 0x and 40 hex digits, has a bad EIP-55 checksum, or can't receive deliveries. details.fields names it"},
+	CodeInvalidPrivateKey:    {400, "The customer's private key isn't 32 bytes of hex, or isn't a valid secp256k1 key"},
+	CodeInvalidCursor:        {400, "The paging cursor is corrupt or belongs to a differently sorted or filtered list"},
+	CodeInvalidSignInMessage: {400, "The Sign-In with Ethereum message isn't a well formed EIP-4361 message"},
+	CodeInvalidSignature:     {400, "The signature isn't 65 bytes of hex, or wasn't made with the address's key"},
+
+	CodeUnauthenticated:    {401, "The endpoint needs credentials and none were sent"},
+	CodeInvalidCredentials: {401, "The API key, session token, or signed sign-in message is not valid"},
+	CodeForbidden:          {403, "The caller's role doesn't allow the request"},
+
+	CodeOrderNotFound:           {404, "The order doesn't exist, or belongs to somebody else"},
+	CodeProductNotFound:         {404, "The product isn't in the catalog"},
+	CodeDeliveryTokenNotFound:   {404, "The order's delivery token was never minted"},
+	CodeWebhookNotFound:         {404, "The webhook subscription doesn't exist"},
+	CodeWebhookDeliveryNotFound: {404, "The webhook delivery doesn't exist"},
+	CodeApiKeyNotFound:          {404, "The API key doesn't exist"},
+	CodeReconciliationNotFound:  {404, "No reconciliation has finished since the service started"},
+	CodeCustomerNotFound:        {404, "The customer doesn't exist"},
+	CodeCustomerAddressNotFound: {404, "The address isn't in the customer's address book"},
+	CodeVendorNotFound:          {404, "The vendor doesn't exist, or the caller can't act for it"},
+
+	CodeOrderAlreadyPaid:         {409, "The order has already been paid for. details.currentStatus says how far it has got"},
+	CodeOrderStatusConflict:      {409, "The order's status doesn't allow the request. details has the currentStatus and the requestedStatus"},
+	CodeOrderOperationInProgress: {409, "The order's last chain operation hasn't finished yet. Try again once it has"},
+	CodeProductAlreadyExists:     {409, "A product with that ID is already in the catalog"},
+	CodeWebhookDeliveryNotDead:   {409, "Only deliveries that were given up on can be retried"},
+	CodeIdempotencyKeyInProgress: {409, "A request with the same Idempotency-Key is still being handled, or only just finished"},
+	CodeIdempotencyKeyReused:     {422, "The Idempotency-Key was already used for a different request"},
+	CodeReconciliationInProgress: {409, "Another reconciliation is still running. Try again once it has finished"},
+	CodeSweepNotConfigured:       {409, "Sweeping is off because the service has no cold wallet to sweep to"},
+	CodeEscrowNotExpired:         {409, "The order's escrow can't be released or refunded until it expires. details.expiresAt says when"},
+	CodeEscrowUnsupported:        {409, "The vendor's delivery contract was built before escrow could expire"},
+	CodeDeliveryEvidenceMissing:  {409, "The vendor can only claim an expired escrow once evidence of delivery has been recorded"},
+	CodeCustodyNotConfigured:     {409, "The service has no HD wallet seed, so it can't hold keys for custodial customers or new vendors"},
+	CodeAddressNotVerified:       {409, "The customer hasn't proven they hold the address. They verify it by signing its challenge"},
+	CodeAddressAlreadyClaimed:    {409, "Another customer has already verified the address"},
+	CodeChallengeExpired:         {409, "The challenge to sign has expired. Adding the address again gives a new one"},
+	CodeVendorAlreadyExists:      {409, "A vendor with that ID already exists"},
+	CodeVendorNotReady:           {409, "The vendor's delivery contract hasn't been deployed yet, so it can't take orders"},
+	CodeContractAlreadyDeployed:  {409, "The vendor already has a delivery contract. Replacing it has to be asked for, and the default vendor's can't be"},
+
+	CodeTxReverted:        {400, "The contract rejected the transaction"},
+	CodeInsufficientFunds: {400, "The account signing the transaction can't cover its value and gas"},
+	CodeChainError:        {502, "The blockchain node refused the request for some other reason"},
+	CodeChainUnavailable:  {503, "The blockchain node can't be reached. Try again later"},
+
+	CodeInternal: {500, "Something broke on the server. The correlation ID will help track it down"},
+}
+
+// The HTTP status that goes with the code
+func (code Code) HttpStatus() int {
+	if entry, ok := catalog[code]; ok {
+		return entry.status
+	}
+	return 500
+}
+
+// What the code means
+func (code Code) Description() string {
+	return catalog[code].description
+}
+
+// Every code, in alphabetical order
+func AllCodes() []Code {
+	codes := []Code{}
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// An error that can be shown to a client. The message and details must never carry anything internal,
+// like database or node errors; keep those in Err, which is only logged.
+type Error struct {
+	Code    Code
+	Message string
+	// anything that helps the client act on the error, e.g. which field was wrong
+	Details map[string]interface{}
+	// what caused it, if anything
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Adds a detail to the error
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = map[string]interface{}{}
+	}
+	e.Details[key] = value
+	return e
+}
+
+// Builds an error with a formatted message
+func New(code Code, format string, args ...interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// Builds an error that was caused by another one. The cause is kept for logging but not shown to the client.
+func Wrap(cause error, code Code, format string, args ...interface{}) *Error {
+	err := New(code, format, args...)
+	err.Err = cause
+	return err
+}
+
+// Wraps an error the client can't do anything about
+func Internal(cause error) *Error {
+	return Wrap(cause, CodeInternal, "Something went wrong on our side")
+}
+
+// Returns the error as an *Error. Anything that isn't one already is treated as internal, so that
+// unexpected errors never leak their details.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return Internal(err)
+}
+
+// Returns the error's code, or CodeInternal if it isn't an *Error
+func CodeOf(err error) Code {
+	return From(err).Code
+}
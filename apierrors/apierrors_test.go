@@ -0,0 +1,77 @@
+package apierrors
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+)
+
+// Reads the value of every Code constant declared in the file
+func declaredCodes(t *testing.T, filename string) []Code {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes := []Code{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			if ident, ok := value.Type.(*ast.Ident); !ok || ident.Name != "Code" {
+				continue
+			}
+			for _, literal := range value.Values {
+				code, err := strconv.Unquote(literal.(*ast.BasicLit).Value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				codes = append(codes, Code(code))
+			}
+		}
+	}
+	return codes
+}
+
+func TestEveryCodeIsInTheCatalog(t *testing.T) {
+	declared := declaredCodes(t, "apierrors.go")
+	if len(declared) != len(catalog) {
+		t.Errorf("expected the %d declared codes to be the %d in the catalog", len(declared), len(catalog))
+	}
+
+	seen := map[Code]bool{}
+	for _, code := range declared {
+		if seen[code] {
+			t.Errorf("expected %s to be declared once", code)
+		}
+		seen[code] = true
+
+		entry, ok := catalog[code]
+		if !ok {
+			t.Errorf("expected %s to be in the catalog", code)
+			continue
+		}
+		if entry.status < 400 || entry.status > 599 || len(entry.description) == 0 {
+			t.Errorf("expected %s to have an error status and a description, got %+v", code, entry)
+		}
+	}
+	for code := range catalog {
+		if !seen[code] {
+			t.Errorf("expected %s in the catalog to be declared as a constant", code)
+		}
+	}
+}
+
+func TestUnknownCodesAreInternal(t *testing.T) {
+	if status := Code("NOT_A_CODE").HttpStatus(); status != 500 {
+		t.Errorf("expected 500, got %d", status)
+	}
+	if status := CodeIdempotencyKeyReused.HttpStatus(); status != 422 {
+		t.Errorf("expected 422, got %d", status)
+	}
+}
@@ -0,0 +1,169 @@
+package apitest
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// the most gas a block can hold
+var blockGasLimit uint64 = 30000000
+
+// A blockchain for tests: go-ethereum's simulated backend behind a JSON-RPC endpoint, so the service talks to
+// it the same way it talks to a real node. Every transaction is mined into a block of its own as soon as it
+// is sent.
+//
+// Blocks don't report a base fee, like the Quorum chain in genesis.json, so transactions are sent the
+// pre-London way.
+type Chain struct {
+	Backend *backends.SimulatedBackend
+	// where the JSON-RPC endpoint listens
+	Url string
+
+	server *httptest.Server
+}
+
+// Starts a chain whose genesis block gives the accounts their balances
+func NewChain(alloc core.GenesisAlloc) *Chain {
+	backend := backends.NewSimulatedBackend(alloc, blockGasLimit)
+
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName("eth", &ethService{backend: backend}); err != nil {
+		panic(err)
+	}
+	server := httptest.NewServer(rpcServer)
+
+	return &Chain{
+		Backend: backend,
+		Url:     server.URL,
+		server:  server,
+	}
+}
+
+// Stops the JSON-RPC endpoint and the chain
+func (chain *Chain) Close() {
+	chain.server.Close()
+	chain.Backend.Close()
+}
+
+// The parts of the eth JSON-RPC namespace that ethclient and the contract bindings use. The block a
+// request asks about is ignored; everything is answered from the latest block, which is also the pending one
+// since transactions are mined straight away.
+type ethService struct {
+	backend *backends.SimulatedBackend
+	// keeps one transaction's block from picking up another's
+	sendLock sync.Mutex
+}
+
+// The fields of eth_call and eth_estimateGas
+type callArgs struct {
+	From     *common.Address `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      *hexutil.Uint64 `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     *hexutil.Bytes  `json:"data"`
+}
+
+func (args *callArgs) message() ethereum.CallMsg {
+	msg := ethereum.CallMsg{To: args.To}
+	if args.From != nil {
+		msg.From = *args.From
+	}
+	if args.Gas != nil {
+		msg.Gas = uint64(*args.Gas)
+	}
+	if args.GasPrice != nil {
+		msg.GasPrice = args.GasPrice.ToInt()
+	}
+	if args.Value != nil {
+		msg.Value = args.Value.ToInt()
+	}
+	if args.Data != nil {
+		msg.Data = *args.Data
+	}
+	return msg
+}
+
+func (svc *ethService) ChainId(ctx context.Context) *hexutil.Big {
+	return (*hexutil.Big)(svc.backend.Blockchain().Config().ChainID)
+}
+
+func (svc *ethService) BlockNumber(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(svc.backend.Blockchain().CurrentBlock().NumberU64())
+}
+
+func (svc *ethService) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (*types.Header, error) {
+	var blockNumber *big.Int
+	if number >= 0 {
+		blockNumber = big.NewInt(number.Int64())
+	}
+	header, err := svc.backend.HeaderByNumber(ctx, blockNumber)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	header = types.CopyHeader(header)
+	header.BaseFee = nil
+	return header, nil
+}
+
+func (svc *ethService) GetBalance(ctx context.Context, address common.Address, block rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	balance, err := svc.backend.BalanceAt(ctx, address, nil)
+	return (*hexutil.Big)(balance), err
+}
+
+func (svc *ethService) GetTransactionCount(ctx context.Context, address common.Address, block rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	nonce, err := svc.backend.PendingNonceAt(ctx, address)
+	return hexutil.Uint64(nonce), err
+}
+
+func (svc *ethService) GetCode(ctx context.Context, address common.Address, block rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	return svc.backend.CodeAt(ctx, address, nil)
+}
+
+func (svc *ethService) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	price, err := svc.backend.SuggestGasPrice(ctx)
+	return (*hexutil.Big)(price), err
+}
+
+func (svc *ethService) EstimateGas(ctx context.Context, args callArgs, block *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	gas, err := svc.backend.EstimateGas(ctx, args.message())
+	return hexutil.Uint64(gas), err
+}
+
+func (svc *ethService) Call(ctx context.Context, args callArgs, block rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	return svc.backend.CallContract(ctx, args.message(), nil)
+}
+
+func (svc *ethService) SendRawTransaction(ctx context.Context, input hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+
+	svc.sendLock.Lock()
+	defer svc.sendLock.Unlock()
+	if err := svc.backend.SendTransaction(ctx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	svc.backend.Commit()
+	return tx.Hash(), nil
+}
+
+func (svc *ethService) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, err := svc.backend.TransactionReceipt(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	return receipt, err
+}
@@ -0,0 +1,83 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/products"
+	"github.com/bdunton9323/blockchain-playground/vendors"
+)
+
+// Orders that can be written but not read back, like a database replica that has fallen behind
+type unreadableOrders struct {
+	orders.OrderRepository
+}
+
+func (repo *unreadableOrders) GetOrder(ctx context.Context, orderId string) (*orders.Order, error) {
+	return nil, errors.New("the database went away")
+}
+
+// Places an order over HTTP with the Idempotency-Key
+func placeOrderWithKey(t *testing.T, server *Server, buyerAddress string, idempotencyKey string) (*http.Response, map[string]interface{}) {
+	query := url.Values{}
+	query.Set("itemId", "item-1")
+	query.Set("buyerAddress", buyerAddress)
+	req, err := http.NewRequest(http.MethodPost, server.Url+"/api/v1/order?"+query.Encode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-API-Key", AdminKey)
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body := map[string]interface{}{}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return resp, body
+}
+
+func TestRetriedOrderIsNotMintedTwiceWhenItCantBeReadBack(t *testing.T) {
+	server := NewServer(t)
+	err := server.Products.CreateProduct(&products.Product{
+		ProductId: "item-1", Name: "Socks", Price: 50000, ShippingPrice: 1000, VendorId: vendors.DefaultVendorId,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Service.Orders = &unreadableOrders{OrderRepository: server.Orders}
+	buyer := AddressOf(t, server.BuyerKeys[0]).Hex()
+
+	first, created := placeOrderWithKey(t, server, buyer, "order-1")
+	if first.StatusCode != http.StatusOK {
+		t.Fatalf("expected the minted order to be reported, got %d: %v", first.StatusCode, created)
+	}
+	if created["status"] != string(orders.StatusMinted) {
+		t.Errorf("expected the order to be minted, got %v", created)
+	}
+
+	second, retried := placeOrderWithKey(t, server, buyer, "order-1")
+	if second.StatusCode != http.StatusOK || second.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected the retry to replay the first response, got %d", second.StatusCode)
+	}
+	if retried["orderId"] != created["orderId"] {
+		t.Errorf("expected the retry to be for order [%v], got [%v]", created["orderId"], retried["orderId"])
+	}
+
+	placed, _, err := server.Orders.ListOrders(context.Background(), &orders.OrderQuery{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(placed) != 1 {
+		t.Errorf("expected one order to be placed and minted, got %d", len(placed))
+	}
+}
@@ -0,0 +1,1356 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/customers"
+	"github.com/bdunton9323/blockchain-playground/idempotency"
+	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/products"
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/bdunton9323/blockchain-playground/vendors"
+	"github.com/bdunton9323/blockchain-playground/webhooks"
+	"github.com/google/uuid"
+)
+
+// Keeps orders, their history, outbox entries and delivery evidence in memory, with the same rules the MariaDB
+// repository enforces
+type OrderRepository struct {
+	// queues the webhook deliveries for every status change, as the MariaDB repository does. Optional.
+	Webhooks *WebhookRepository
+
+	mutex    sync.Mutex
+	orders   map[string]*orders.Order
+	history  map[string][]*orders.StatusChange
+	entries  map[int64]*orders.OutboxEntry
+	leases   map[int64]time.Time
+	evidence map[string]*orders.DeliveryEvidence
+	nextId   int64
+}
+
+func NewOrderRepository() *OrderRepository {
+	return &OrderRepository{
+		orders:   map[string]*orders.Order{},
+		history:  map[string][]*orders.StatusChange{},
+		entries:  map[int64]*orders.OutboxEntry{},
+		leases:   map[int64]time.Time{},
+		evidence: map[string]*orders.DeliveryEvidence{},
+	}
+}
+
+func (repo *OrderRepository) GetOrder(ctx context.Context, orderId string) (*orders.Order, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	order, found := repo.orders[orderId]
+	if !found {
+		return nil, nil
+	}
+	copied := *order
+	return &copied, nil
+}
+
+func (repo *OrderRepository) CreateOrder(ctx context.Context, order *orders.Order, actorAddress string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	return repo.insertOrder(ctx, order, actorAddress)
+}
+
+func (repo *OrderRepository) TransitionOrder(
+	ctx context.Context,
+	orderId string,
+	status orders.OrderStatus,
+	actorAddress string,
+	txHash string,
+) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	return repo.transition(ctx, orderId, status, actorAddress, txHash)
+}
+
+func (repo *OrderRepository) GetStatusHistory(ctx context.Context, orderId string) ([]*orders.StatusChange, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	history := []*orders.StatusChange{}
+	for _, change := range repo.history[orderId] {
+		copied := *change
+		history = append(history, &copied)
+	}
+	return history, nil
+}
+
+func (repo *OrderRepository) ListOrders(ctx context.Context, query *orders.OrderQuery) ([]*orders.Order, *orders.OrderCursor, error) {
+	sortBy := query.SortBy
+	if sortBy == "" {
+		sortBy = orders.SortByCreatedAt
+	}
+	if err := query.CheckCursor(); err != nil {
+		return nil, nil, err
+	}
+
+	repo.mutex.Lock()
+	matching := []*orders.Order{}
+	for _, order := range repo.orders {
+		if matches(query, order) {
+			copied := *order
+			matching = append(matching, &copied)
+		}
+	}
+	repo.mutex.Unlock()
+
+	// the order ID breaks ties, as it does in the database
+	before := func(a *orders.Order, b *orders.Order) bool {
+		if compared := compareSortValues(orders.NewOrderCursor(a, query), orders.NewOrderCursor(b, query)); compared != 0 {
+			return compared < 0
+		}
+		return a.OrderId < b.OrderId
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		if query.Descending {
+			return before(matching[j], matching[i])
+		}
+		return before(matching[i], matching[j])
+	})
+
+	page := []*orders.Order{}
+	for _, order := range matching {
+		if query.After != nil {
+			cursor := orders.NewOrderCursor(order, query)
+			compared := compareSortValues(cursor, query.After)
+			if compared == 0 {
+				compared = compareStrings(order.OrderId, query.After.OrderId)
+			}
+			if query.Descending {
+				compared = -compared
+			}
+			if compared <= 0 {
+				continue
+			}
+		}
+		page = append(page, order)
+	}
+
+	if len(page) <= query.Limit {
+		return page, nil, nil
+	}
+	page = page[:query.Limit]
+	return page, orders.NewOrderCursor(page[len(page)-1], query), nil
+}
+
+func (repo *OrderRepository) RepairOrder(ctx context.Context, repair *orders.Repair) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	order, found := repo.orders[repair.OrderId]
+	if !found {
+		return errors.New(fmt.Sprintf("order [%s] does not exist", repair.OrderId))
+	} else if order.Status != repair.FromStatus {
+		return orders.ErrOrderChanged
+	}
+
+	if repair.TokenId != 0 {
+		order.TokenAddress = repair.TokenAddress
+		order.TokenId = repair.TokenId
+	}
+	if repair.ToStatus == repair.FromStatus {
+		return nil
+	}
+	order.Status = repair.ToStatus
+	repo.recordChange(ctx, &orders.StatusChange{
+		OrderId:      repair.OrderId,
+		FromStatus:   repair.FromStatus,
+		ToStatus:     repair.ToStatus,
+		ActorAddress: repair.ActorAddress,
+	})
+	return nil
+}
+
+func (repo *OrderRepository) CreateOrderWithMint(ctx context.Context, order *orders.Order, actorAddress string) (*orders.OutboxEntry, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if err := repo.insertOrder(ctx, order, actorAddress); err != nil {
+		return nil, err
+	}
+	return repo.insertOutboxEntry(ctx, order.OrderId, orders.OperationMint, actorAddress), nil
+}
+
+func (repo *OrderRepository) EnqueueOperation(
+	ctx context.Context,
+	orderId string,
+	op orders.Operation,
+	actorAddress string,
+) (*orders.OutboxEntry, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if _, found := repo.orders[orderId]; !found {
+		return nil, errors.New(fmt.Sprintf("order [%s] does not exist", orderId))
+	}
+	for _, entry := range repo.entries {
+		if entry.OrderId == orderId && !entry.IsDone() {
+			return nil, orders.ErrOperationInProgress
+		}
+	}
+	return repo.insertOutboxEntry(ctx, orderId, op, actorAddress), nil
+}
+
+func (repo *OrderRepository) GetOutboxEntry(ctx context.Context, id int64) (*orders.OutboxEntry, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	entry, found := repo.entries[id]
+	if !found {
+		return nil, nil
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+func (repo *OrderRepository) ListDueOutboxEntries(ctx context.Context, limit int) ([]*orders.OutboxEntry, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	now := time.Now().UTC()
+	due := []*orders.OutboxEntry{}
+	for _, entry := range repo.entries {
+		if !entry.IsDone() && !entry.NextAttemptAt.After(now) && !repo.leases[entry.Id].After(now) {
+			copied := *entry
+			due = append(due, &copied)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Id < due[j].Id })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (repo *OrderRepository) CountUnfinishedOutboxEntries(ctx context.Context) (int, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	count := 0
+	for _, entry := range repo.entries {
+		if !entry.IsDone() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (repo *OrderRepository) HasUnfinishedOutboxEntry(ctx context.Context, orderId string) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	for _, entry := range repo.entries {
+		if entry.OrderId == orderId && !entry.IsDone() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (repo *OrderRepository) ClaimOutboxEntry(ctx context.Context, id int64) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	now := time.Now().UTC()
+	entry, found := repo.entries[id]
+	if !found || entry.IsDone() || repo.leases[id].After(now) {
+		return false, nil
+	}
+	repo.leases[id] = now.Add(orders.OutboxLease)
+	return true, nil
+}
+
+func (repo *OrderRepository) ReleaseOutboxEntry(ctx context.Context, id int64) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delete(repo.leases, id)
+	return nil
+}
+
+func (repo *OrderRepository) RecordSubmission(ctx context.Context, id int64, txHash string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if entry, found := repo.entries[id]; found {
+		entry.Status = orders.OutboxSubmitted
+		entry.TxHash = txHash
+	}
+	return nil
+}
+
+func (repo *OrderRepository) CompleteOutboxEntry(
+	ctx context.Context,
+	entry *orders.OutboxEntry,
+	tokenAddress string,
+	tokenId int64,
+) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	lastError := ""
+
+	err := repo.transition(ctx, entry.OrderId, entry.Operation.ResultingStatus(), entry.ActorAddress, entry.TxHash)
+	var transitionErr *orders.InvalidTransitionError
+	if errors.As(err, &transitionErr) {
+		lastError = err.Error()
+	} else if err != nil {
+		return err
+	}
+
+	if entry.Operation == orders.OperationMint {
+		order := repo.orders[entry.OrderId]
+		order.TokenAddress = tokenAddress
+		order.TokenId = tokenId
+	}
+
+	stored := repo.entries[entry.Id]
+	stored.Status = orders.OutboxCompleted
+	stored.LastError = lastError
+	delete(repo.leases, entry.Id)
+	entry.Status = orders.OutboxCompleted
+	entry.LastError = lastError
+	return nil
+}
+
+func (repo *OrderRepository) FailOutboxEntry(
+	ctx context.Context,
+	entry *orders.OutboxEntry,
+	cause error,
+	final bool,
+	nextAttempt time.Time,
+) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	status := entry.Status
+	if final {
+		status = orders.OutboxFailed
+		if entry.Operation == orders.OperationMint {
+			if err := repo.transition(ctx, entry.OrderId, orders.StatusFailed, entry.ActorAddress, entry.TxHash); err != nil {
+				return err
+			}
+		}
+	}
+
+	stored := repo.entries[entry.Id]
+	stored.Status = status
+	stored.Attempts++
+	stored.LastError = cause.Error()
+	stored.NextAttemptAt = nextAttempt.UTC()
+	delete(repo.leases, entry.Id)
+
+	entry.Status = status
+	entry.Attempts++
+	entry.LastError = cause.Error()
+	entry.NextAttemptAt = nextAttempt
+	return nil
+}
+
+func (repo *OrderRepository) RecordDeliveryEvidence(ctx context.Context, evidence *orders.DeliveryEvidence) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if evidence.RecordedAt.IsZero() {
+		evidence.RecordedAt = time.Now().UTC()
+	}
+	copied := *evidence
+	repo.evidence[evidence.OrderId] = &copied
+	return nil
+}
+
+func (repo *OrderRepository) GetDeliveryEvidence(ctx context.Context, orderId string) (*orders.DeliveryEvidence, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	evidence, found := repo.evidence[orderId]
+	if !found {
+		return nil, nil
+	}
+	copied := *evidence
+	return &copied, nil
+}
+
+// Returns every outbox entry of the order, oldest first
+func (repo *OrderRepository) OutboxEntries(orderId string) []*orders.OutboxEntry {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	entries := []*orders.OutboxEntry{}
+	for _, entry := range repo.entries {
+		if entry.OrderId == orderId {
+			copied := *entry
+			entries = append(entries, &copied)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Id < entries[j].Id })
+	return entries
+}
+
+// Must be called with the mutex held
+func (repo *OrderRepository) insertOrder(ctx context.Context, order *orders.Order, actorAddress string) error {
+	if _, found := repo.orders[order.OrderId]; found {
+		return errors.New(fmt.Sprintf("order [%s] already exists", order.OrderId))
+	}
+	if order.Status == "" {
+		order.Status = orders.StatusCreated
+	}
+	if order.CreatedAt.IsZero() {
+		order.CreatedAt = time.Now().UTC()
+	}
+	copied := *order
+	repo.orders[order.OrderId] = &copied
+	repo.recordChange(ctx, &orders.StatusChange{
+		OrderId:      order.OrderId,
+		ToStatus:     order.Status,
+		ActorAddress: actorAddress,
+	})
+	return nil
+}
+
+// Must be called with the mutex held
+func (repo *OrderRepository) transition(ctx context.Context, orderId string, status orders.OrderStatus, actorAddress string, txHash string) error {
+	order, found := repo.orders[orderId]
+	if !found {
+		return errors.New(fmt.Sprintf("order [%s] does not exist", orderId))
+	}
+	if err := order.ValidateTransition(status); err != nil {
+		return err
+	}
+
+	from := order.Status
+	order.Status = status
+	repo.recordChange(ctx, &orders.StatusChange{
+		OrderId:      orderId,
+		FromStatus:   from,
+		ToStatus:     status,
+		ActorAddress: actorAddress,
+		TxHash:       txHash,
+	})
+	return nil
+}
+
+// Must be called with the mutex held
+func (repo *OrderRepository) recordChange(ctx context.Context, change *orders.StatusChange) {
+	change.ChangedAt = time.Now().UTC()
+	repo.history[change.OrderId] = append(repo.history[change.OrderId], change)
+
+	if repo.Webhooks != nil {
+		repo.Webhooks.enqueue(ctx, &webhooks.Event{
+			Type:         webhooks.EventTypeForStatus(string(change.ToStatus)),
+			OrderId:      change.OrderId,
+			FromStatus:   string(change.FromStatus),
+			ToStatus:     string(change.ToStatus),
+			ActorAddress: change.ActorAddress,
+			TxHash:       change.TxHash,
+		})
+	}
+}
+
+// Must be called with the mutex held. New entries start out leased to whoever created them.
+func (repo *OrderRepository) insertOutboxEntry(ctx context.Context, orderId string, op orders.Operation, actorAddress string) *orders.OutboxEntry {
+	now := time.Now().UTC()
+	repo.nextId++
+	entry := &orders.OutboxEntry{
+		Id:            repo.nextId,
+		OrderId:       orderId,
+		Operation:     op,
+		Status:        orders.OutboxPending,
+		ActorAddress:  actorAddress,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		TraceParent:   tracing.TraceParent(ctx),
+	}
+	repo.entries[entry.Id] = entry
+	repo.leases[entry.Id] = now.Add(orders.OutboxLease)
+
+	copied := *entry
+	return &copied
+}
+
+// Whether the order passes the query's filters
+func matches(query *orders.OrderQuery, order *orders.Order) bool {
+	if len(query.BuyerAddress) != 0 && order.BuyerAddress != query.BuyerAddress {
+		return false
+	}
+	if len(query.BuyerAddresses) != 0 && !contains(query.BuyerAddresses, order.BuyerAddress) {
+		return false
+	}
+	if len(query.TokenAddress) != 0 && order.TokenAddress != query.TokenAddress {
+		return false
+	}
+	if len(query.ItemId) != 0 && order.ItemId != query.ItemId {
+		return false
+	}
+	if len(query.VendorId) != 0 && order.VendorId != query.VendorId {
+		return false
+	}
+	if len(query.Statuses) != 0 {
+		statuses := []string{}
+		for _, status := range query.Statuses {
+			statuses = append(statuses, string(status))
+		}
+		if !contains(statuses, string(order.Status)) {
+			return false
+		}
+	}
+	if !query.CreatedAfter.IsZero() && order.CreatedAt.Before(query.CreatedAfter) {
+		return false
+	}
+	if !query.CreatedBefore.IsZero() && !order.CreatedAt.Before(query.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// Compares the sort values of two cursors made with the same sort field: negative if a comes first
+func compareSortValues(a *orders.OrderCursor, b *orders.OrderCursor) int {
+	if a.SortBy == orders.SortByPrice {
+		priceA, _ := strconv.ParseInt(a.SortValue, 10, 64)
+		priceB, _ := strconv.ParseInt(b.SortValue, 10, 64)
+		return compareInts(priceA, priceB)
+	}
+	timeA, _ := time.Parse(time.RFC3339Nano, a.SortValue)
+	timeB, _ := time.Parse(time.RFC3339Nano, b.SortValue)
+	return compareInts(timeA.UnixNano(), timeB.UnixNano())
+}
+
+func compareInts(a int64, b int64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+func compareStrings(a string, b string) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Keeps the product catalog in memory
+type ProductRepository struct {
+	mutex    sync.Mutex
+	products map[string]*products.Product
+}
+
+func NewProductRepository() *ProductRepository {
+	return &ProductRepository{products: map[string]*products.Product{}}
+}
+
+func (repo *ProductRepository) GetProduct(productId string) (*products.Product, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	product, found := repo.products[productId]
+	if !found {
+		return nil, nil
+	}
+	copied := *product
+	return &copied, nil
+}
+
+func (repo *ProductRepository) ListProducts(vendorId string) ([]*products.Product, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	listed := []*products.Product{}
+	for _, product := range repo.products {
+		if len(vendorId) == 0 || product.VendorId == vendorId {
+			copied := *product
+			listed = append(listed, &copied)
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool {
+		if listed[i].Name != listed[j].Name {
+			return listed[i].Name < listed[j].Name
+		}
+		return listed[i].ProductId < listed[j].ProductId
+	})
+	return listed, nil
+}
+
+func (repo *ProductRepository) CreateProduct(product *products.Product) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if _, found := repo.products[product.ProductId]; found {
+		return errors.New(fmt.Sprintf("product [%s] already exists", product.ProductId))
+	}
+	copied := *product
+	repo.products[product.ProductId] = &copied
+	return nil
+}
+
+func (repo *ProductRepository) UpdateProduct(product *products.Product) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	existing, found := repo.products[product.ProductId]
+	if !found {
+		return false, nil
+	}
+	existing.Name = product.Name
+	existing.Description = product.Description
+	existing.Price = product.Price
+	existing.ShippingPrice = product.ShippingPrice
+	return true, nil
+}
+
+func (repo *ProductRepository) DeleteProduct(productId string) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	_, found := repo.products[productId]
+	delete(repo.products, productId)
+	return found, nil
+}
+
+// Keeps customers and their address books in memory
+type CustomerRepository struct {
+	mutex     sync.Mutex
+	customers map[string]*customers.Customer
+	// in the order they were added
+	addresses []*customers.WalletAddress
+}
+
+func NewCustomerRepository() *CustomerRepository {
+	return &CustomerRepository{customers: map[string]*customers.Customer{}}
+}
+
+func (repo *CustomerRepository) CreateCustomer(customer *customers.Customer) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if _, found := repo.customers[customer.CustomerId]; found {
+		return errors.New(fmt.Sprintf("customer [%s] already exists", customer.CustomerId))
+	}
+	for _, existing := range repo.customers {
+		if customer.Custodial() && existing.Custodial() && existing.AddressIndex == customer.AddressIndex {
+			return customers.ErrAddressIndexTaken
+		}
+	}
+
+	if customer.CreatedAt.IsZero() {
+		customer.CreatedAt = time.Now().UTC()
+	}
+	customer.UpdatedAt = customer.CreatedAt
+	copied := *customer
+	repo.customers[customer.CustomerId] = &copied
+
+	if customer.Custodial() {
+		verifiedAt := customer.CreatedAt
+		repo.addresses = append(repo.addresses, &customers.WalletAddress{
+			CustomerId: customer.CustomerId,
+			Address:    customer.Address,
+			Label:      "deposit",
+			VerifiedAt: &verifiedAt,
+			CreatedAt:  customer.CreatedAt,
+		})
+	}
+	return nil
+}
+
+func (repo *CustomerRepository) UpdateCustomer(customer *customers.Customer) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	existing, found := repo.customers[customer.CustomerId]
+	if !found {
+		return false, nil
+	}
+	customer.UpdatedAt = time.Now().UTC()
+	existing.Name = customer.Name
+	existing.Email = customer.Email
+	existing.ShippingAddress = customer.ShippingAddress
+	existing.UpdatedAt = customer.UpdatedAt
+	return true, nil
+}
+
+func (repo *CustomerRepository) GetCustomer(customerId string) (*customers.Customer, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	customer, found := repo.customers[customerId]
+	if !found {
+		return nil, nil
+	}
+	copied := *customer
+	return &copied, nil
+}
+
+func (repo *CustomerRepository) GetCustomerByAddress(address string) (*customers.Customer, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	for _, customer := range repo.customers {
+		if customer.Custodial() && customer.Address == address {
+			copied := *customer
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (repo *CustomerRepository) ListCustomers() ([]*customers.Customer, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	listed := []*customers.Customer{}
+	for _, customer := range repo.customers {
+		copied := *customer
+		listed = append(listed, &copied)
+	}
+	sort.Slice(listed, func(i, j int) bool {
+		if !listed[i].CreatedAt.Equal(listed[j].CreatedAt) {
+			return listed[i].CreatedAt.Before(listed[j].CreatedAt)
+		}
+		return listed[i].CustomerId < listed[j].CustomerId
+	})
+	return listed, nil
+}
+
+func (repo *CustomerRepository) NextAddressIndex() (uint32, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	var next uint32
+	for _, customer := range repo.customers {
+		if customer.Custodial() && customer.AddressIndex >= next {
+			next = customer.AddressIndex + 1
+		}
+	}
+	return next, nil
+}
+
+func (repo *CustomerRepository) SaveAddress(address *customers.WalletAddress) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if address.CreatedAt.IsZero() {
+		address.CreatedAt = time.Now().UTC()
+	}
+	copied := *address
+	for i, existing := range repo.addresses {
+		if existing.CustomerId == address.CustomerId && existing.Address == address.Address {
+			// replacing an address keeps when it was first added
+			copied.CreatedAt = existing.CreatedAt
+			repo.addresses[i] = &copied
+			return nil
+		}
+	}
+	repo.addresses = append(repo.addresses, &copied)
+	return nil
+}
+
+func (repo *CustomerRepository) GetAddress(customerId string, address string) (*customers.WalletAddress, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	for _, existing := range repo.addresses {
+		if existing.CustomerId == customerId && existing.Address == address {
+			copied := *existing
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (repo *CustomerRepository) ListAddresses(customerId string) ([]*customers.WalletAddress, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	listed := []*customers.WalletAddress{}
+	for _, existing := range repo.addresses {
+		if existing.CustomerId == customerId {
+			copied := *existing
+			listed = append(listed, &copied)
+		}
+	}
+	return listed, nil
+}
+
+func (repo *CustomerRepository) VerifyAddress(customerId string, address string, verifiedAt time.Time) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	for _, existing := range repo.addresses {
+		if existing.Address == address && existing.CustomerId != customerId && existing.Verified() {
+			return customers.ErrAddressClaimed
+		}
+	}
+	for _, existing := range repo.addresses {
+		if existing.CustomerId == customerId && existing.Address == address {
+			verified := verifiedAt
+			existing.VerifiedAt = &verified
+			existing.Challenge = ""
+			existing.ChallengeExpiresAt = time.Time{}
+		}
+	}
+	return nil
+}
+
+func (repo *CustomerRepository) RemoveAddress(customerId string, address string) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	for i, existing := range repo.addresses {
+		if existing.CustomerId == customerId && existing.Address == address {
+			repo.addresses = append(repo.addresses[:i], repo.addresses[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Keeps the marketplace's vendors in memory
+type VendorRepository struct {
+	mutex   sync.Mutex
+	vendors map[string]*vendors.Vendor
+}
+
+func NewVendorRepository() *VendorRepository {
+	return &VendorRepository{vendors: map[string]*vendors.Vendor{}}
+}
+
+func (repo *VendorRepository) CreateVendor(vendor *vendors.Vendor) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if _, found := repo.vendors[vendor.VendorId]; found {
+		return errors.New(fmt.Sprintf("vendor [%s] already exists", vendor.VendorId))
+	}
+	for _, existing := range repo.vendors {
+		if len(vendor.DerivationPath) != 0 && len(existing.DerivationPath) != 0 && existing.AddressIndex == vendor.AddressIndex {
+			return vendors.ErrAddressIndexTaken
+		}
+	}
+
+	if vendor.CreatedAt.IsZero() {
+		vendor.CreatedAt = time.Now().UTC()
+	}
+	vendor.UpdatedAt = vendor.CreatedAt
+	copied := *vendor
+	repo.vendors[vendor.VendorId] = &copied
+	return nil
+}
+
+func (repo *VendorRepository) UpdateVendor(vendor *vendors.Vendor) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	existing, found := repo.vendors[vendor.VendorId]
+	if !found {
+		return false, nil
+	}
+	vendor.UpdatedAt = time.Now().UTC()
+	existing.Name = vendor.Name
+	existing.ColdWallet = vendor.ColdWallet
+	existing.UpdatedAt = vendor.UpdatedAt
+	return true, nil
+}
+
+func (repo *VendorRepository) SetContractAddress(vendorId string, contractAddress string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if existing, found := repo.vendors[vendorId]; found {
+		existing.ContractAddress = contractAddress
+		existing.UpdatedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+func (repo *VendorRepository) GetVendor(vendorId string) (*vendors.Vendor, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	vendor, found := repo.vendors[vendorId]
+	if !found {
+		return nil, nil
+	}
+	copied := *vendor
+	return &copied, nil
+}
+
+func (repo *VendorRepository) ListVendors() ([]*vendors.Vendor, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	listed := []*vendors.Vendor{}
+	for _, vendor := range repo.vendors {
+		copied := *vendor
+		listed = append(listed, &copied)
+	}
+	sort.Slice(listed, func(i, j int) bool {
+		if !listed[i].CreatedAt.Equal(listed[j].CreatedAt) {
+			return listed[i].CreatedAt.Before(listed[j].CreatedAt)
+		}
+		return listed[i].VendorId < listed[j].VendorId
+	})
+	return listed, nil
+}
+
+func (repo *VendorRepository) NextAddressIndex() (uint32, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	var next uint32
+	for _, vendor := range repo.vendors {
+		if len(vendor.DerivationPath) != 0 && vendor.AddressIndex >= next {
+			next = vendor.AddressIndex + 1
+		}
+	}
+	return next, nil
+}
+
+// Keeps API keys, sign-in nonces and sessions in memory
+type AuthRepository struct {
+	mutex    sync.Mutex
+	keys     map[string]*auth.ApiKey
+	nonces   map[string]time.Time
+	sessions map[string]*auth.Session
+}
+
+func NewAuthRepository() *AuthRepository {
+	return &AuthRepository{
+		keys:     map[string]*auth.ApiKey{},
+		nonces:   map[string]time.Time{},
+		sessions: map[string]*auth.Session{},
+	}
+}
+
+func (repo *AuthRepository) CreateApiKey(key *auth.ApiKey) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now().UTC()
+	}
+	copied := *key
+	repo.keys[key.KeyId] = &copied
+	return nil
+}
+
+func (repo *AuthRepository) GetApiKeyByHash(keyHash string) (*auth.ApiKey, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	for _, key := range repo.keys {
+		if key.KeyHash == keyHash && key.RevokedAt == nil {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (repo *AuthRepository) GetApiKey(keyId string) (*auth.ApiKey, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	key, found := repo.keys[keyId]
+	if !found {
+		return nil, nil
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (repo *AuthRepository) ListApiKeys() ([]*auth.ApiKey, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	listed := []*auth.ApiKey{}
+	for _, key := range repo.keys {
+		copied := *key
+		listed = append(listed, &copied)
+	}
+	sort.Slice(listed, func(i, j int) bool {
+		if !listed[i].CreatedAt.Equal(listed[j].CreatedAt) {
+			return listed[i].CreatedAt.Before(listed[j].CreatedAt)
+		}
+		return listed[i].KeyId < listed[j].KeyId
+	})
+	return listed, nil
+}
+
+func (repo *AuthRepository) RevokeApiKey(keyId string) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	key, found := repo.keys[keyId]
+	if !found {
+		return false, nil
+	}
+	if key.RevokedAt == nil {
+		now := time.Now().UTC()
+		key.RevokedAt = &now
+	}
+	return true, nil
+}
+
+func (repo *AuthRepository) CreateNonce(nonce string, ttl time.Duration) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.nonces[nonce] = time.Now().UTC().Add(ttl)
+	return nil
+}
+
+func (repo *AuthRepository) ConsumeNonce(nonce string) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	expiresAt, found := repo.nonces[nonce]
+	delete(repo.nonces, nonce)
+	return found && expiresAt.After(time.Now().UTC()), nil
+}
+
+func (repo *AuthRepository) CreateSession(session *auth.Session) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	copied := *session
+	repo.sessions[session.TokenHash] = &copied
+	return nil
+}
+
+func (repo *AuthRepository) GetSession(tokenHash string) (*auth.Session, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	session, found := repo.sessions[tokenHash]
+	if !found || !session.ExpiresAt.After(time.Now().UTC()) {
+		return nil, nil
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (repo *AuthRepository) DeleteSession(tokenHash string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delete(repo.sessions, tokenHash)
+	return nil
+}
+
+// Keeps webhook subscriptions and their deliveries in memory
+type WebhookRepository struct {
+	mutex         sync.Mutex
+	subscriptions map[string]*webhooks.Subscription
+	deliveries    map[int64]*webhooks.Delivery
+	leases        map[int64]time.Time
+	nextId        int64
+}
+
+func NewWebhookRepository() *WebhookRepository {
+	return &WebhookRepository{
+		subscriptions: map[string]*webhooks.Subscription{},
+		deliveries:    map[int64]*webhooks.Delivery{},
+		leases:        map[int64]time.Time{},
+	}
+}
+
+func (repo *WebhookRepository) CreateSubscription(sub *webhooks.Subscription) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	now := time.Now().UTC()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+	copied := *sub
+	repo.subscriptions[sub.SubscriptionId] = &copied
+	return nil
+}
+
+func (repo *WebhookRepository) GetSubscription(subscriptionId string) (*webhooks.Subscription, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	sub, found := repo.subscriptions[subscriptionId]
+	if !found {
+		return nil, nil
+	}
+	copied := *sub
+	return &copied, nil
+}
+
+func (repo *WebhookRepository) ListSubscriptions() ([]*webhooks.Subscription, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	listed := []*webhooks.Subscription{}
+	for _, sub := range repo.subscriptions {
+		copied := *sub
+		listed = append(listed, &copied)
+	}
+	sort.Slice(listed, func(i, j int) bool {
+		if !listed[i].CreatedAt.Equal(listed[j].CreatedAt) {
+			return listed[i].CreatedAt.Before(listed[j].CreatedAt)
+		}
+		return listed[i].SubscriptionId < listed[j].SubscriptionId
+	})
+	return listed, nil
+}
+
+func (repo *WebhookRepository) UpdateSubscription(sub *webhooks.Subscription) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	existing, found := repo.subscriptions[sub.SubscriptionId]
+	if !found {
+		return false, nil
+	}
+	sub.UpdatedAt = time.Now().UTC()
+	existing.Url = sub.Url
+	existing.Secret = sub.Secret
+	existing.Events = sub.Events
+	existing.Active = sub.Active
+	existing.UpdatedAt = sub.UpdatedAt
+	return true, nil
+}
+
+func (repo *WebhookRepository) DeleteSubscription(subscriptionId string) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	_, found := repo.subscriptions[subscriptionId]
+	delete(repo.subscriptions, subscriptionId)
+	for id, delivery := range repo.deliveries {
+		if delivery.SubscriptionId == subscriptionId {
+			delete(repo.deliveries, id)
+		}
+	}
+	return found, nil
+}
+
+func (repo *WebhookRepository) ListDeliveries(subscriptionId string, status webhooks.DeliveryStatus, limit int) ([]*webhooks.Delivery, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	listed := []*webhooks.Delivery{}
+	for _, delivery := range repo.deliveries {
+		if delivery.SubscriptionId == subscriptionId && (status == "" || delivery.Status == status) {
+			copied := *delivery
+			listed = append(listed, &copied)
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool { return listed[i].Id > listed[j].Id })
+	if len(listed) > limit {
+		listed = listed[:limit]
+	}
+	return listed, nil
+}
+
+func (repo *WebhookRepository) GetDelivery(id int64) (*webhooks.Delivery, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delivery, found := repo.deliveries[id]
+	if !found {
+		return nil, nil
+	}
+	copied := *delivery
+	return &copied, nil
+}
+
+func (repo *WebhookRepository) ListDueDeliveries(limit int) ([]*webhooks.Delivery, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	now := time.Now().UTC()
+	due := []*webhooks.Delivery{}
+	for _, delivery := range repo.deliveries {
+		sub := repo.subscriptions[delivery.SubscriptionId]
+		if delivery.Status == webhooks.DeliveryPending && !delivery.NextAttemptAt.After(now) &&
+			!repo.leases[delivery.Id].After(now) && sub != nil && sub.Active {
+			copied := *delivery
+			due = append(due, &copied)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Id < due[j].Id })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (repo *WebhookRepository) ClaimDelivery(id int64) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	now := time.Now().UTC()
+	delivery, found := repo.deliveries[id]
+	if !found || delivery.Status != webhooks.DeliveryPending || repo.leases[id].After(now) {
+		return false, nil
+	}
+	repo.leases[id] = now.Add(webhooks.DeliveryLease)
+	return true, nil
+}
+
+func (repo *WebhookRepository) CompleteDelivery(delivery *webhooks.Delivery, responseCode int) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if stored, found := repo.deliveries[delivery.Id]; found {
+		stored.Status = webhooks.DeliveryDelivered
+		stored.Attempts++
+		stored.LastError = ""
+		stored.ResponseCode = responseCode
+		stored.UpdatedAt = time.Now().UTC()
+	}
+	delete(repo.leases, delivery.Id)
+
+	delivery.Status = webhooks.DeliveryDelivered
+	delivery.Attempts++
+	delivery.LastError = ""
+	delivery.ResponseCode = responseCode
+	return nil
+}
+
+func (repo *WebhookRepository) FailDelivery(
+	delivery *webhooks.Delivery,
+	responseCode int,
+	cause error,
+	final bool,
+	nextAttempt time.Time,
+) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	status := webhooks.DeliveryPending
+	if final {
+		status = webhooks.DeliveryDead
+	}
+	if stored, found := repo.deliveries[delivery.Id]; found {
+		stored.Status = status
+		stored.Attempts++
+		stored.LastError = cause.Error()
+		stored.ResponseCode = responseCode
+		stored.NextAttemptAt = nextAttempt.UTC()
+		stored.UpdatedAt = time.Now().UTC()
+	}
+	delete(repo.leases, delivery.Id)
+
+	delivery.Status = status
+	delivery.Attempts++
+	delivery.LastError = cause.Error()
+	delivery.ResponseCode = responseCode
+	delivery.NextAttemptAt = nextAttempt
+	return nil
+}
+
+func (repo *WebhookRepository) RetryDelivery(id int64) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delivery, found := repo.deliveries[id]
+	if !found || delivery.Status != webhooks.DeliveryDead {
+		return false, nil
+	}
+	now := time.Now().UTC()
+	delivery.Status = webhooks.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.UpdatedAt = now
+	return true, nil
+}
+
+// Adds a delivery as if an event had queued it, and returns its ID
+func (repo *WebhookRepository) AddDelivery(delivery *webhooks.Delivery) int64 {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.nextId++
+	copied := *delivery
+	copied.Id = repo.nextId
+	if copied.CreatedAt.IsZero() {
+		copied.CreatedAt = time.Now().UTC()
+		copied.UpdatedAt = copied.CreatedAt
+	}
+	repo.deliveries[copied.Id] = &copied
+	return copied.Id
+}
+
+// Queues a delivery of the event for every active subscription that wants it, like webhooks.EnqueueEvent
+func (repo *WebhookRepository) enqueue(ctx context.Context, event *webhooks.Event) {
+	if len(event.EventId) == 0 {
+		event.EventId = uuid.New().String()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+	payload, _ := json.Marshal(event)
+
+	traceParent := tracing.TraceParent(ctx)
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	now := time.Now().UTC()
+	for _, sub := range repo.subscriptions {
+		if !sub.Active || !wants(sub, event.Type) {
+			continue
+		}
+		repo.nextId++
+		repo.deliveries[repo.nextId] = &webhooks.Delivery{
+			Id:             repo.nextId,
+			SubscriptionId: sub.SubscriptionId,
+			EventId:        event.EventId,
+			EventType:      event.Type,
+			OrderId:        event.OrderId,
+			Payload:        string(payload),
+			Status:         webhooks.DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+			TraceParent:    traceParent,
+		}
+	}
+}
+
+func wants(sub *webhooks.Subscription, eventType webhooks.EventType) bool {
+	for _, wanted := range sub.Events {
+		if wanted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Keeps idempotency keys in memory
+type KeyRepository struct {
+	mutex sync.Mutex
+	keys  map[string]*idempotency.KeyRecord
+}
+
+func NewKeyRepository() *KeyRepository {
+	return &KeyRepository{keys: map[string]*idempotency.KeyRecord{}}
+}
+
+func (repo *KeyRepository) Reserve(key string, fingerprint string, retention time.Duration) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	now := time.Now().UTC()
+	if existing, found := repo.keys[key]; found && existing.ExpiresAt.After(now) {
+		return false, nil
+	}
+	repo.keys[key] = &idempotency.KeyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		Status:      idempotency.KeyInProgress,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(retention),
+	}
+	return true, nil
+}
+
+func (repo *KeyRepository) Get(key string) (*idempotency.KeyRecord, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	record, found := repo.keys[key]
+	if !found || !record.ExpiresAt.After(time.Now().UTC()) {
+		return nil, nil
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (repo *KeyRepository) Complete(key string, code int, contentType string, body []byte) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if record, found := repo.keys[key]; found {
+		record.Status = idempotency.KeyCompleted
+		record.ResponseCode = code
+		record.ResponseContentType = contentType
+		record.ResponseBody = body
+	}
+	return nil
+}
+
+func (repo *KeyRepository) Release(key string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delete(repo.keys, key)
+	return nil
+}
+
+func (repo *KeyRepository) DeleteExpired() (int64, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	var deleted int64
+	now := time.Now().UTC()
+	for key, record := range repo.keys {
+		if !record.ExpiresAt.After(now) {
+			delete(repo.keys, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,258 @@
+// apitest runs the whole REST API in a test, on a simulated blockchain and with every repository kept in memory, so
+// clients and end-to-end flows can be tested without a node or a database.
+package apitest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/config"
+	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/controllers"
+	"github.com/bdunton9323/blockchain-playground/customers"
+	"github.com/bdunton9323/blockchain-playground/outbox"
+	"github.com/bdunton9323/blockchain-playground/service"
+	"github.com/bdunton9323/blockchain-playground/tracking"
+	"github.com/bdunton9323/blockchain-playground/vendors"
+	"github.com/bdunton9323/blockchain-playground/wallet"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+// the key that authenticates as the marketplace's admin
+var AdminKey = "apitest-admin-key"
+
+// the domain sign-in messages and address challenges have to name
+var SiweDomain = "apitest.local"
+
+// how many of the HD wallet's vendor and customer addresses start out with ether
+var FundedWalletAddresses uint32 = 4
+
+// how many buyers with wallets of their own there are
+var buyerCount = 3
+
+// what every funded account starts with: 1000 ether
+var startingBalance = new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+
+// The API, served over HTTP and wired up the way main does it
+type Server struct {
+	// the API lives at Url + "/api/v1"
+	Url   string
+	Chain *Chain
+
+	Orders    *OrderRepository
+	Products  *ProductRepository
+	Customers *CustomerRepository
+	Vendors   *VendorRepository
+	Auth      *AuthRepository
+	Webhooks  *WebhookRepository
+	Keys      *KeyRepository
+
+	Service        *service.OrderService
+	Dispatcher     *outbox.Dispatcher
+	VendorRegistry *vendors.Registry
+	Wallet         *wallet.HDWallet
+	// the default vendor's private key, as hex
+	VendorKey string
+	// private keys, as hex, of buyers who have wallets of their own. Each starts with 1000 ether.
+	BuyerKeys []string
+
+	escrowMutex sync.Mutex
+	escrow      map[string]bool
+}
+
+// Starts a chain and serves the API on it. Everything is shut down when the test finishes.
+//
+// Escrow expires as soon as an order is paid for, so the release and refund flows can be tried straight away.
+func NewServer(t testing.TB) *Server {
+	gin.SetMode(gin.TestMode)
+
+	// Every server gets wallet addresses of its own. The executor remembers the nonces it handed out per address,
+	// so addresses shared between servers would be sent with nonces from another chain.
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		t.Fatal(err)
+	}
+	hdWallet, err := wallet.NewHDWallet(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vendorKey := newKey(t)
+	buyerKeys := []*ecdsa.PrivateKey{}
+	alloc := core.GenesisAlloc{crypto.PubkeyToAddress(vendorKey.PublicKey): {Balance: startingBalance}}
+	for i := 0; i < buyerCount; i++ {
+		key := newKey(t)
+		buyerKeys = append(buyerKeys, key)
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{Balance: startingBalance}
+	}
+	for i := uint32(0); i < FundedWalletAddresses; i++ {
+		for _, path := range []accounts.DerivationPath{wallet.VendorPath(i), wallet.AddressPath(i)} {
+			address, err := hdWallet.Address(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			alloc[address] = core.GenesisAccount{Balance: startingBalance}
+		}
+	}
+
+	chain := NewChain(alloc)
+	t.Cleanup(chain.Close)
+
+	server := &Server{
+		Chain:     chain,
+		Orders:    NewOrderRepository(),
+		Products:  NewProductRepository(),
+		Customers: NewCustomerRepository(),
+		Vendors:   NewVendorRepository(),
+		Auth:      NewAuthRepository(),
+		Webhooks:  NewWebhookRepository(),
+		Keys:      NewKeyRepository(),
+		Wallet:    hdWallet,
+		VendorKey: keyHex(vendorKey),
+		escrow:    map[string]bool{},
+	}
+	server.Orders.Webhooks = server.Webhooks
+	for _, key := range buyerKeys {
+		server.BuyerKeys = append(server.BuyerKeys, keyHex(key))
+	}
+
+	// the default vendor's row is there from the migrations
+	err = server.Vendors.CreateVendor(&vendors.Vendor{VendorId: vendors.DefaultVendorId, Name: "Default vendor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaultExecutor, err := contract.NewDeliveryContractExecutor(chain.Url, server.VendorKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(defaultExecutor.Client.Close)
+
+	customerRegistry := customers.NewRegistry(server.Customers, hdWallet)
+	customerRegistry.Domain = SiweDomain
+
+	server.VendorRegistry = vendors.NewRegistry(server.Vendors, hdWallet, chain.Url, defaultExecutor)
+	server.VendorRegistry.OnReady = server.vendorReady
+	t.Cleanup(server.VendorRegistry.Close)
+
+	tracker := tracking.NewHub()
+	server.Dispatcher = outbox.NewDispatcher(server.Orders, server.VendorRegistry)
+	server.Dispatcher.Tracker = tracker
+
+	follower := tracking.NewFollower(tracker, server.Orders, defaultExecutor)
+	t.Cleanup(follower.Stop)
+
+	server.Service = &service.OrderService{
+		Orders:          server.Orders,
+		Products:        server.Products,
+		Vendors:         server.VendorRegistry,
+		Dispatcher:      server.Dispatcher,
+		Tracker:         tracker,
+		Follower:        follower,
+		EscrowSupported: server.escrowSupported,
+		Customers:       customerRegistry,
+	}
+	if err = server.VendorRegistry.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	chainId, err := defaultExecutor.Client.ChainID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the settings main runs with unless it is told otherwise
+	defaults := config.Defaults()
+	verifier := &auth.Verifier{
+		Repository:        server.Auth,
+		BootstrapAdminKey: AdminKey,
+	}
+	router := &controllers.ApiRouter{
+		OrderController: &controllers.OrderController{
+			Service: server.Service,
+		},
+		ProductController: &controllers.ProductController{
+			ProductRepository: server.Products,
+			Vendors:           server.VendorRegistry,
+		},
+		AuthController: &controllers.AuthController{
+			Repository: server.Auth,
+			Domain:     SiweDomain,
+			ChainId:    chainId.Int64(),
+			NonceTtl:   time.Duration(defaults.Auth.NonceTtl),
+			SessionTtl: time.Duration(defaults.Auth.SessionTtl),
+			Vendors:    server.VendorRegistry,
+		},
+		WebhookController: &controllers.WebhookController{
+			WebhookRepository: server.Webhooks,
+		},
+		CustomerController: &controllers.CustomerController{
+			Registry: customerRegistry,
+			Service:  server.Service,
+		},
+		VendorController: &controllers.VendorController{
+			Registry: server.VendorRegistry,
+		},
+		Authenticator: &controllers.Authenticator{
+			Verifier: verifier,
+			Vendors:  server.VendorRegistry,
+		},
+		Idempotency: &controllers.IdempotencyMiddleware{
+			KeyRepository: server.Keys,
+			Retention:     time.Duration(defaults.Server.IdempotencyRetention),
+		},
+	}
+
+	httpServer := httptest.NewServer(router.Server().Handler)
+	t.Cleanup(httpServer.Close)
+	server.Url = httpServer.URL
+	return server
+}
+
+// Like main, makes sure the vendor's contract can settle escrow. The window is shrunk to nothing.
+func (server *Server) vendorReady(vendor *vendors.Vendor, executor *contract.DeliveryContractExecutor) {
+	supported := executor.SetEscrowWindow(context.Background(), 0) == nil
+
+	server.escrowMutex.Lock()
+	defer server.escrowMutex.Unlock()
+	server.escrow[vendor.VendorId] = supported
+}
+
+func (server *Server) escrowSupported(vendorId string) bool {
+	server.escrowMutex.Lock()
+	defer server.escrowMutex.Unlock()
+	return server.escrow[vendorId]
+}
+
+func newKey(t testing.TB) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// The form keys are configured and sent to the API in
+func keyHex(key *ecdsa.PrivateKey) string {
+	return hex.EncodeToString(crypto.FromECDSA(key))
+}
+
+// Returns the address of a private key given as hex
+func AddressOf(t testing.TB, keyHex string) common.Address {
+	address, err := contract.AddressFromPrivateKey(keyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *address
+}
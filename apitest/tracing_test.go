@@ -0,0 +1,227 @@
+package apitest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/products"
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/bdunton9323/blockchain-playground/vendors"
+	"github.com/bdunton9323/blockchain-playground/webhooks"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The provider can only be set up once per test binary, and the router picks it up when it is built, so this has
+// to happen before any server is started
+var exporter = tracing.SetupInMemory()
+
+// the trace of a service upstream of ours
+var upstreamTraceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+var upstreamSpanId = "00f067aa0ba902b7"
+
+// Returns the first span with the name whose parent is the given span
+func childSpan(t *testing.T, name string, parent trace.SpanID) *tracetest.SpanStub {
+	t.Helper()
+	span := findChildSpan(name, parent)
+	if span == nil {
+		t.Fatalf("expected a %s span under span [%s]", name, parent)
+	}
+	return span
+}
+
+// Like childSpan, but returns nil if the span hasn't ended yet
+func findChildSpan(name string, parent trace.SpanID) *tracetest.SpanStub {
+	for _, span := range exporter.GetSpans() {
+		if span.Name == name && span.Parent.SpanID() == parent {
+			copied := span
+			return &copied
+		}
+	}
+	return nil
+}
+
+// Waits for the condition to hold, for up to a few seconds
+func eventually(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+// Whether the traceparent belongs to the trace
+func inTrace(traceParent string, traceId string) bool {
+	return strings.HasPrefix(traceParent, "00-"+traceId+"-")
+}
+
+// Places an order over HTTP as part of the upstream service's trace, and returns the order's ID
+func placeTracedOrder(t *testing.T, server *Server, buyerAddress string) string {
+	query := url.Values{}
+	query.Set("itemId", "item-1")
+	query.Set("buyerAddress", buyerAddress)
+	req, err := http.NewRequest(http.MethodPost, server.Url+"/api/v1/order?"+query.Encode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-API-Key", AdminKey)
+	req.Header.Set("traceparent", "00-"+upstreamTraceId+"-"+upstreamSpanId+"-01")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the order to be minted, got %d", resp.StatusCode)
+	}
+
+	var created struct {
+		OrderId string `json:"orderId"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	return created.OrderId
+}
+
+func TestOrderRequestIsTracedEndToEnd(t *testing.T) {
+	server := NewServer(t)
+	err := server.Products.CreateProduct(&products.Product{
+		ProductId: "item-1", Name: "Socks", Price: 50000, ShippingPrice: 1000, VendorId: vendors.DefaultVendorId,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a subscriber who will be told about the order
+	var mu sync.Mutex
+	traceParents := []string{}
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		traceParents = append(traceParents, r.Header.Get("traceparent"))
+	}))
+	t.Cleanup(subscriber.Close)
+	err = server.Webhooks.CreateSubscription(&webhooks.Subscription{
+		SubscriptionId: "sub-1",
+		Url:            subscriber.URL,
+		Secret:         "a-very-secret-key",
+		Events:         []webhooks.EventType{webhooks.EventOrderCreated, webhooks.EventOrderMinted},
+		Active:         true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exporter.Reset()
+	orderId := placeTracedOrder(t, server, AddressOf(t, server.BuyerKeys[0]).Hex())
+
+	// HTTP -> service -> outbox -> contract -> node, all in the upstream service's trace
+	var request *tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.SpanKind == trace.SpanKindServer && span.Parent.SpanID().String() == upstreamSpanId {
+			copied := span
+			request = &copied
+		}
+	}
+	if request == nil {
+		t.Fatal("expected the request's span to be a child of the upstream span")
+	}
+	if request.SpanContext.TraceID().String() != upstreamTraceId {
+		t.Errorf("expected the request to join trace [%s], got [%s]", upstreamTraceId, request.SpanContext.TraceID())
+	}
+	service := childSpan(t, "service.CreateOrder", request.SpanContext.SpanID())
+	process := childSpan(t, "outbox.Process", service.SpanContext.SpanID())
+	mint := childSpan(t, "contract.SubmitMint", process.SpanContext.SpanID())
+	childSpan(t, "ethereum JSON-RPC", mint.SpanContext.SpanID())
+
+	// the work that was written down carries the trace along with it
+	entries := server.Orders.OutboxEntries(orderId)
+	if len(entries) != 1 || !inTrace(entries[0].TraceParent, upstreamTraceId) {
+		t.Errorf("expected the mint's outbox entry to be in trace [%s], got %+v", upstreamTraceId, entries)
+	}
+	deliveries, err := server.Webhooks.ListDeliveries("sub-1", webhooks.DeliveryPending, 10)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(deliveries) != 2 {
+		t.Fatalf("expected deliveries for the created and minted events, got %d", len(deliveries))
+	}
+	for _, delivery := range deliveries {
+		if !inTrace(delivery.TraceParent, upstreamTraceId) {
+			t.Errorf("expected the %s delivery to be in trace [%s], got [%s]", delivery.EventType, upstreamTraceId, delivery.TraceParent)
+		}
+	}
+
+	// and the subscriber is told about it as part of the same trace
+	sender := webhooks.NewSender(server.Webhooks)
+	sender.PollInterval = 10 * time.Millisecond
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go sender.Run(ctx)
+	eventually(t, "the webhooks to be sent", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(traceParents) == 2
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	for _, traceParent := range traceParents {
+		if !inTrace(traceParent, upstreamTraceId) {
+			t.Errorf("expected the subscriber to be sent a traceparent in trace [%s], got [%s]", upstreamTraceId, traceParent)
+		}
+	}
+}
+
+func TestDispatcherRetryJoinsTheTraceOfTheRequest(t *testing.T) {
+	server := NewServer(t)
+	exporter.Reset()
+
+	// an order whose mint was recorded by a request that then went away
+	ctx, request := tracing.Start(context.Background(), "request")
+	order := &orders.Order{
+		OrderId:       "3f6f1a6e-5d1f-4bb5-9c53-9fb39c4dbb0e",
+		ItemId:        "item-1",
+		ItemName:      "Socks",
+		Price:         50000,
+		DeliveryPrice: 1000,
+		BuyerAddress:  AddressOf(t, server.BuyerKeys[0]).Hex(),
+		VendorId:      vendors.DefaultVendorId,
+	}
+	entry, err := server.Orders.CreateOrderWithMint(ctx, order, AddressOf(t, server.VendorKey).Hex())
+	request.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = server.Orders.ReleaseOutboxEntry(context.Background(), entry.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	server.Dispatcher.PollInterval = 10 * time.Millisecond
+	runCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go server.Dispatcher.Run(runCtx)
+	// spans are only recorded once they end, which is after the entry is completed
+	eventually(t, "the mint to be retried", func() bool {
+		return findChildSpan("outbox.Process", request.SpanContext().SpanID()) != nil
+	})
+	if entries := server.Orders.OutboxEntries(order.OrderId); entries[0].Status != orders.OutboxCompleted {
+		t.Errorf("expected the retry to complete the mint, got %s", entries[0].Status)
+	}
+
+	process := childSpan(t, "outbox.Process", request.SpanContext().SpanID())
+	if process.SpanContext.TraceID() != request.SpanContext().TraceID() {
+		t.Errorf("expected the retry to be in trace [%s], got [%s]", request.SpanContext().TraceID(), process.SpanContext.TraceID())
+	}
+	childSpan(t, "contract.SubmitMint", process.SpanContext.SpanID())
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// A DTO object representing a row in the api_keys table. The key itself is never stored.
+type ApiKey struct {
+	KeyId   string
+	Name    string
+	KeyHash string
+	Role    Role
+	// the vendor the key acts for. Empty for the marketplace's own staff, who can act for any vendor.
+	VendorId  string
+	CreatedAt time.Time
+	// nil while the key is still usable
+	RevokedAt *time.Time
+}
+
+// A DTO object representing a row in the sessions table
+type Session struct {
+	TokenHash string
+	Address   string
+	Role      Role
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type AuthRepository interface {
+	CreateApiKey(key *ApiKey) error
+	// Returns the unrevoked key with the given hash, or nil
+	GetApiKeyByHash(keyHash string) (*ApiKey, error)
+	// Returns the key with the given ID, revoked or not, or nil
+	GetApiKey(keyId string) (*ApiKey, error)
+	ListApiKeys() ([]*ApiKey, error)
+	// Returns false if the key doesn't exist
+	RevokeApiKey(keyId string) (bool, error)
+
+	CreateNonce(nonce string, ttl time.Duration) error
+	// Uses up the nonce. Returns false if it doesn't exist, has expired, or was already used.
+	ConsumeNonce(nonce string) (bool, error)
+
+	CreateSession(session *Session) error
+	// Returns the unexpired session with the given token hash, or nil
+	GetSession(tokenHash string) (*Session, error)
+	DeleteSession(tokenHash string) error
+}
+
+type MariaDBAuthRepository struct {
+	AuthRepository
+
+	conn *sql.DB
+}
+
+var apiKeysTable = "api_keys"
+var noncesTable = "auth_nonces"
+var sessionsTable = "sessions"
+var apiKeyFields = "key_id, name, key_hash, role, vendor_id, created_at, revoked_at"
+var sessionFields = "token_hash, address, role, created_at, expires_at"
+
+// Construct a new repository that uses the given connection pool to MariaDB
+func NewMariaDBAuthRepository(db *sql.DB) *MariaDBAuthRepository {
+	return &MariaDBAuthRepository{conn: db}
+}
+
+// Writes the given API key to the database
+func (repo *MariaDBAuthRepository) CreateApiKey(key *ApiKey) error {
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now().UTC()
+	}
+	query := fmt.Sprintf("insert into %s (key_id, name, key_hash, role, vendor_id, created_at) values (?, ?, ?, ?, ?, ?)", apiKeysTable)
+	vendorId := sql.NullString{String: key.VendorId, Valid: len(key.VendorId) != 0}
+	_, err := repo.exec(query, key.KeyId, key.Name, key.KeyHash, key.Role, vendorId, key.CreatedAt)
+	return err
+}
+
+// Returns the unrevoked API key with the given hash. If not found, then nil.
+func (repo *MariaDBAuthRepository) GetApiKeyByHash(keyHash string) (*ApiKey, error) {
+	query := fmt.Sprintf("select %s from %s where key_hash = ? and revoked_at is null", apiKeyFields, apiKeysTable)
+	keys, err := repo.queryApiKeys(query, keyHash)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	return keys[0], nil
+}
+
+// Returns the API key with the given ID, whether or not it has been revoked. If not found, then nil.
+func (repo *MariaDBAuthRepository) GetApiKey(keyId string) (*ApiKey, error) {
+	keys, err := repo.queryApiKeys(fmt.Sprintf("select %s from %s where key_id = ?", apiKeyFields, apiKeysTable), keyId)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	return keys[0], nil
+}
+
+// Returns every API key, including revoked ones, oldest first
+func (repo *MariaDBAuthRepository) ListApiKeys() ([]*ApiKey, error) {
+	query := fmt.Sprintf("select %s from %s order by created_at", apiKeyFields, apiKeysTable)
+	return repo.queryApiKeys(query)
+}
+
+// Stops the key from working. Returns false if the key doesn't exist.
+func (repo *MariaDBAuthRepository) RevokeApiKey(keyId string) (bool, error) {
+	query := fmt.Sprintf("update %s set revoked_at = coalesce(revoked_at, ?) where key_id = ?", apiKeysTable)
+	result, err := repo.exec(query, time.Now().UTC(), keyId)
+	if err != nil {
+		return false, err
+	}
+
+	// an already revoked key doesn't count as changed, so look it up to tell the difference
+	changed, err := result.RowsAffected()
+	if err != nil || changed > 0 {
+		return changed > 0, err
+	}
+	key, err := repo.GetApiKey(keyId)
+	return key != nil, err
+}
+
+// Stores a nonce that can be used once to sign in
+func (repo *MariaDBAuthRepository) CreateNonce(nonce string, ttl time.Duration) error {
+	query := fmt.Sprintf("insert into %s (nonce, expires_at) values (?, ?)", noncesTable)
+	_, err := repo.exec(query, nonce, time.Now().UTC().Add(ttl))
+	return err
+}
+
+// Uses up the nonce so that a signed message can't be replayed
+func (repo *MariaDBAuthRepository) ConsumeNonce(nonce string) (bool, error) {
+	now := time.Now().UTC()
+
+	// throw away the ones nobody used while we're here
+	_, err := repo.exec(fmt.Sprintf("delete from %s where expires_at <= ?", noncesTable), now)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := repo.exec(fmt.Sprintf("delete from %s where nonce = ?", noncesTable), nonce)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := result.RowsAffected()
+	return deleted == 1, err
+}
+
+// Writes the given session to the database
+func (repo *MariaDBAuthRepository) CreateSession(session *Session) error {
+	query := fmt.Sprintf("insert into %s (%s) values (?, ?, ?, ?, ?)", sessionsTable, sessionFields)
+	_, err := repo.exec(query, session.TokenHash, session.Address, session.Role, session.CreatedAt, session.ExpiresAt)
+	return err
+}
+
+// Returns the unexpired session with the given token hash. If not found, then nil.
+func (repo *MariaDBAuthRepository) GetSession(tokenHash string) (*Session, error) {
+	query := fmt.Sprintf("select %s from %s where token_hash = ? and expires_at > ?", sessionFields, sessionsTable)
+	log.Debugf("running query [%s]", query)
+
+	results, err := repo.conn.Query(query, tokenHash, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	if !results.Next() {
+		return nil, nil
+	}
+
+	var session Session
+	err = results.Scan(&session.TokenHash, &session.Address, &session.Role, &session.CreatedAt, &session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Ends the session
+func (repo *MariaDBAuthRepository) DeleteSession(tokenHash string) error {
+	_, err := repo.exec(fmt.Sprintf("delete from %s where token_hash = ?", sessionsTable), tokenHash)
+	return err
+}
+
+func (repo *MariaDBAuthRepository) queryApiKeys(query string, args ...interface{}) ([]*ApiKey, error) {
+	log.Debugf("running query [%s]", query)
+	results, err := repo.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	keys := []*ApiKey{}
+	for results.Next() {
+		var key ApiKey
+		var vendorId sql.NullString
+		var revokedAt sql.NullTime
+		err = results.Scan(&key.KeyId, &key.Name, &key.KeyHash, &key.Role, &vendorId, &key.CreatedAt, &revokedAt)
+		if err != nil {
+			return nil, err
+		}
+		key.VendorId = vendorId.String
+		if revokedAt.Valid {
+			key.RevokedAt = &revokedAt.Time
+		}
+		keys = append(keys, &key)
+	}
+	return keys, results.Err()
+}
+
+// Runs the given statement against the database
+func (repo *MariaDBAuthRepository) exec(query string, args ...interface{}) (sql.Result, error) {
+	log.Debugf("running query [%s]", query)
+	return repo.conn.Exec(query, args...)
+}
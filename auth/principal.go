@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// What a caller is allowed to do
+type Role string
+
+const (
+	// back-office staff who run the shop; allowed to do anything
+	RoleVendorAdmin Role = "vendor_admin"
+	// somebody who orders things. They only get to see and act on their own orders.
+	RoleCustomer Role = "customer"
+	// hands over packages, so can look up orders and record deliveries
+	RoleCourier Role = "courier"
+	// can look at everything but change nothing
+	RoleAuditor Role = "auditor"
+)
+
+// All of the known roles
+var AllRoles = []Role{RoleVendorAdmin, RoleCustomer, RoleCourier, RoleAuditor}
+
+// Parses a role name, ignoring case. Returns false if the role is not known.
+func ParseRole(role string) (Role, bool) {
+	for _, r := range AllRoles {
+		if strings.EqualFold(string(r), role) {
+			return r, true
+		}
+	}
+	return "", false
+}
+
+// The authenticated caller of an API
+type Principal struct {
+	// identifies the caller in logs; the API key's ID or the customer's address
+	Subject string
+	Role    Role
+	// the ethereum address the caller proved they control, as a checksummed hex string.
+	// Only set for customers.
+	Address string
+	// The vendor the principal acts for. Empty for the marketplace's own staff and customers, who aren't tied
+	// to any one vendor.
+	VendorId string
+}
+
+// Whether the principal has any of the given roles
+func (principal *Principal) HasRole(roles ...Role) bool {
+	for _, role := range roles {
+		if principal.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether the principal is restricted to the orders that can be delivered to them
+func (principal *Principal) IsCustomer() bool {
+	return principal.Role == RoleCustomer
+}
+
+// Whether the principal is tied to one vendor, and so may only see and act on that vendor's orders and catalog
+func (principal *Principal) ScopedToVendor() bool {
+	return len(principal.VendorId) != 0
+}
+
+// Whether the principal may see and act on the vendor's orders and catalog. A nil principal is a trusted caller
+// inside the service and can act for anybody.
+func (principal *Principal) CanActFor(vendorId string) bool {
+	return principal == nil || !principal.ScopedToVendor() || principal.VendorId == vendorId
+}
+
+// Generates a random secret, hex encoded, with the given number of bytes of entropy
+func NewSecret(bytes int) (string, error) {
+	secret := make([]byte, bytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// Secrets are only ever stored hashed, so a leaked table doesn't leak working credentials
+func HashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
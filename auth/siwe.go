@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// A Sign-In with Ethereum message, as described by EIP-4361 (https://eips.ethereum.org/EIPS/eip-4361).
+// The customer's wallet signs this to prove they control the address in it.
+type SiweMessage struct {
+	Domain         string
+	Address        string
+	Statement      string
+	Uri            string
+	Version        string
+	ChainId        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime time.Time
+	NotBefore      time.Time
+	RequestId      string
+	Resources      []string
+}
+
+var siwePreambleSuffix = " wants you to sign in with your Ethereum account:"
+
+// Parses the plain text form of a Sign-In with Ethereum message
+func ParseSiweMessage(message string) (*SiweMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	if len(lines) < 2 || !strings.HasSuffix(lines[0], siwePreambleSuffix) {
+		return nil, errors.New("not a Sign-In with Ethereum message")
+	}
+
+	parsed := &SiweMessage{
+		Domain:  strings.TrimSuffix(lines[0], siwePreambleSuffix),
+		Address: strings.TrimSpace(lines[1]),
+	}
+
+	statement := []string{}
+	inResources := false
+	for _, line := range lines[2:] {
+		if inResources && strings.HasPrefix(line, "- ") {
+			parsed.Resources = append(parsed.Resources, strings.TrimPrefix(line, "- "))
+			continue
+		}
+		inResources = false
+
+		name, value, isField := strings.Cut(line, ": ")
+		if line == "Resources:" {
+			inResources = true
+			continue
+		} else if !isField {
+			if len(strings.TrimSpace(line)) != 0 {
+				statement = append(statement, line)
+			}
+			continue
+		}
+
+		var err error
+		switch name {
+		case "URI":
+			parsed.Uri = value
+		case "Version":
+			parsed.Version = value
+		case "Chain ID":
+			parsed.ChainId, err = strconv.ParseInt(value, 10, 64)
+		case "Nonce":
+			parsed.Nonce = value
+		case "Issued At":
+			parsed.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			parsed.ExpirationTime, err = time.Parse(time.RFC3339, value)
+		case "Not Before":
+			parsed.NotBefore, err = time.Parse(time.RFC3339, value)
+		case "Request ID":
+			parsed.RequestId = value
+		default:
+			statement = append(statement, line)
+		}
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid %s: %v", name, err))
+		}
+	}
+	parsed.Statement = strings.Join(statement, "\n")
+
+	if !common.IsHexAddress(parsed.Address) || common.HexToAddress(parsed.Address).Hex() != parsed.Address {
+		return nil, errors.New("the address must be an EIP-55 checksummed ethereum address")
+	}
+	if parsed.Version != "1" {
+		return nil, errors.New("only version 1 messages are supported")
+	}
+	if len(parsed.Nonce) < 8 {
+		return nil, errors.New("the nonce is missing or too short")
+	}
+	if parsed.IssuedAt.IsZero() {
+		return nil, errors.New("the message must say when it was issued")
+	}
+	return parsed, nil
+}
+
+// Checks that the message is meant for us and is currently valid. Checking the nonce is up to the caller.
+func (msg *SiweMessage) Validate(domain string, chainId int64, now time.Time) error {
+	if msg.Domain != domain {
+		return errors.New(fmt.Sprintf("the message is for [%s], not [%s]", msg.Domain, domain))
+	}
+	if msg.ChainId != chainId {
+		return errors.New(fmt.Sprintf("the message is for chain [%d], not [%d]", msg.ChainId, chainId))
+	}
+	if !msg.ExpirationTime.IsZero() && !now.Before(msg.ExpirationTime) {
+		return errors.New("the message has expired")
+	}
+	if !msg.NotBefore.IsZero() && now.Before(msg.NotBefore) {
+		return errors.New("the message is not valid yet")
+	}
+	return nil
+}
+
+// Checks that the signature over the raw message was made by the key behind the message's address.
+// The signature is expected in the form produced by personal_sign: 65 hex-encoded bytes.
+func VerifySiweSignature(message string, address string, signature string) error {
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return errors.New("the signature must be 65 hex-encoded bytes")
+	}
+
+	// wallets produce a recovery ID of 27 or 28, but the crypto library expects 0 or 1
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	publicKey, err := crypto.SigToPub(accounts.TextHash([]byte(message)), sig)
+	if err != nil {
+		return errors.New("the signature is invalid")
+	}
+
+	if crypto.PubkeyToAddress(*publicKey) != common.HexToAddress(address) {
+		return errors.New("the message was not signed by its address")
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// well-known development keys, so the addresses are stable
+var signerKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+var otherKey = "59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+
+var issuedAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func addressOf(t *testing.T, keyHex string) string {
+	key, err := crypto.HexToECDSA(keyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crypto.PubkeyToAddress(key.PublicKey).Hex()
+}
+
+// Writes the message out the way a wallet shows it to the customer
+func formatSiweMessage(msg *SiweMessage) string {
+	lines := []string{
+		msg.Domain + siwePreambleSuffix,
+		msg.Address,
+		"",
+		msg.Statement,
+		"",
+		"URI: " + msg.Uri,
+		"Version: " + msg.Version,
+		fmt.Sprintf("Chain ID: %d", msg.ChainId),
+		"Nonce: " + msg.Nonce,
+		"Issued At: " + msg.IssuedAt.Format(time.RFC3339),
+	}
+	if !msg.ExpirationTime.IsZero() {
+		lines = append(lines, "Expiration Time: "+msg.ExpirationTime.Format(time.RFC3339))
+	}
+	if !msg.NotBefore.IsZero() {
+		lines = append(lines, "Not Before: "+msg.NotBefore.Format(time.RFC3339))
+	}
+	if len(msg.RequestId) != 0 {
+		lines = append(lines, "Request ID: "+msg.RequestId)
+	}
+	if len(msg.Resources) != 0 {
+		lines = append(lines, "Resources:")
+		for _, resource := range msg.Resources {
+			lines = append(lines, "- "+resource)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// A message from the signer's address that is good for an hour after it was issued
+func validSiweMessage(t *testing.T) *SiweMessage {
+	return &SiweMessage{
+		Domain:         "shop.example.com",
+		Address:        addressOf(t, signerKey),
+		Statement:      "Sign in to the Vendor API",
+		Uri:            "https://shop.example.com/login",
+		Version:        "1",
+		ChainId:        1337,
+		Nonce:          "0123456789abcdef",
+		IssuedAt:       issuedAt,
+		ExpirationTime: issuedAt.Add(time.Hour),
+	}
+}
+
+// What personal_sign produces for the message
+func personalSign(t *testing.T, keyHex string, message string) string {
+	key, err := crypto.HexToECDSA(keyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(signature)
+}
+
+func TestParseSiweMessage(t *testing.T) {
+	msg := validSiweMessage(t)
+	msg.NotBefore = issuedAt.Add(time.Minute)
+	msg.RequestId = "request-1"
+	msg.Resources = []string{"https://shop.example.com/orders", "ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq"}
+
+	// wallets on Windows send CRLF
+	text := strings.ReplaceAll(formatSiweMessage(msg), "\n", "\r\n")
+	parsed, err := ParseSiweMessage(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Domain != msg.Domain || parsed.Address != msg.Address || parsed.Statement != msg.Statement ||
+		parsed.Uri != msg.Uri || parsed.Version != msg.Version || parsed.ChainId != msg.ChainId ||
+		parsed.Nonce != msg.Nonce || parsed.RequestId != msg.RequestId {
+		t.Errorf("expected %+v, got %+v", msg, parsed)
+	}
+	if !parsed.IssuedAt.Equal(msg.IssuedAt) || !parsed.ExpirationTime.Equal(msg.ExpirationTime) || !parsed.NotBefore.Equal(msg.NotBefore) {
+		t.Errorf("expected the times of %+v, got %+v", msg, parsed)
+	}
+	if strings.Join(parsed.Resources, ",") != strings.Join(msg.Resources, ",") {
+		t.Errorf("expected resources %v, got %v", msg.Resources, parsed.Resources)
+	}
+}
+
+func TestParseSiweMessageErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(text string) string
+	}{
+		{"without the preamble", func(text string) string {
+			return strings.Replace(text, siwePreambleSuffix, " would like you to log in:", 1)
+		}},
+		{"with only the preamble", func(text string) string {
+			return strings.SplitN(text, "\n", 2)[0]
+		}},
+		{"with a lowercase address", func(text string) string {
+			return strings.Replace(text, "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", "0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266", 1)
+		}},
+		{"with a bad checksum", func(text string) string {
+			return strings.Replace(text, "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", "0xF39Fd6e51aad88F6F4ce6aB8827279cffFb92266", 1)
+		}},
+		{"with a short address", func(text string) string {
+			return strings.Replace(text, "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb922", 1)
+		}},
+		{"of another version", func(text string) string {
+			return strings.Replace(text, "Version: 1", "Version: 2", 1)
+		}},
+		{"with a short nonce", func(text string) string {
+			return strings.Replace(text, "Nonce: 0123456789abcdef", "Nonce: 0123", 1)
+		}},
+		{"without a nonce", func(text string) string {
+			return strings.Replace(text, "Nonce: 0123456789abcdef\n", "", 1)
+		}},
+		{"without an issue time", func(text string) string {
+			return strings.Replace(text, "Issued At: 2024-03-01T12:00:00Z\n", "", 1)
+		}},
+		{"with a bad issue time", func(text string) string {
+			return strings.Replace(text, "Issued At: 2024-03-01T12:00:00Z", "Issued At: yesterday", 1)
+		}},
+		{"with a bad expiration time", func(text string) string {
+			return strings.Replace(text, "Expiration Time: 2024-03-01T13:00:00Z", "Expiration Time: 2024-03-01", 1)
+		}},
+		{"with a bad chain ID", func(text string) string {
+			return strings.Replace(text, "Chain ID: 1337", "Chain ID: mainnet", 1)
+		}},
+	}
+
+	text := formatSiweMessage(validSiweMessage(t))
+	if _, err := ParseSiweMessage(text); err != nil {
+		t.Fatalf("expected the unchanged message to parse, got %v", err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changed := test.change(text)
+			if changed == text {
+				t.Fatal("the test didn't change the message")
+			}
+			if parsed, err := ParseSiweMessage(changed); err == nil {
+				t.Errorf("expected the message to be refused, got %+v", parsed)
+			}
+		})
+	}
+}
+
+func TestValidateSiweMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(msg *SiweMessage)
+		domain  string
+		chainId int64
+		now     time.Time
+		valid   bool
+	}{
+		{"while it is current", nil, "shop.example.com", 1337, issuedAt.Add(time.Minute), true},
+		{"for another domain", nil, "evil.example.com", 1337, issuedAt.Add(time.Minute), false},
+		{"for another chain", nil, "shop.example.com", 1, issuedAt.Add(time.Minute), false},
+		{"once it has expired", nil, "shop.example.com", 1337, issuedAt.Add(2 * time.Hour), false},
+		{"as it expires", nil, "shop.example.com", 1337, issuedAt.Add(time.Hour), false},
+		{"that never expires", func(msg *SiweMessage) {
+			msg.ExpirationTime = time.Time{}
+		}, "shop.example.com", 1337, issuedAt.Add(24 * 365 * time.Hour), true},
+		{"before it is valid", func(msg *SiweMessage) {
+			msg.NotBefore = issuedAt.Add(10 * time.Minute)
+		}, "shop.example.com", 1337, issuedAt.Add(time.Minute), false},
+		{"once it is valid", func(msg *SiweMessage) {
+			msg.NotBefore = issuedAt.Add(10 * time.Minute)
+		}, "shop.example.com", 1337, issuedAt.Add(10 * time.Minute), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := validSiweMessage(t)
+			if test.change != nil {
+				test.change(msg)
+			}
+			err := msg.Validate(test.domain, test.chainId, test.now)
+			if test.valid && err != nil {
+				t.Errorf("expected the message to be valid, got %v", err)
+			} else if !test.valid && err == nil {
+				t.Error("expected the message to be refused")
+			}
+		})
+	}
+}
+
+func TestVerifySiweSignature(t *testing.T) {
+	text := formatSiweMessage(validSiweMessage(t))
+	address := addressOf(t, signerKey)
+	signature := personalSign(t, signerKey, text)
+
+	// the same signature with a recovery ID of 0 or 1, as some hardware wallets produce
+	raw, _ := hexutil.Decode(signature)
+	raw[crypto.RecoveryIDOffset] -= 27
+	unadjusted := hexutil.Encode(raw)
+
+	tests := []struct {
+		name      string
+		message   string
+		signature string
+		valid     bool
+	}{
+		{"by the address", text, signature, true},
+		{"by the address without the wallet's recovery ID", text, unadjusted, true},
+		{"by a different key", text, personalSign(t, otherKey, text), false},
+		{"over a different message", text + "\nRequest ID: 1", signature, false},
+		{"that isn't hex", text, "not-a-signature", false},
+		{"without the 0x", text, strings.TrimPrefix(signature, "0x"), false},
+		{"that is too short", text, signature[:len(signature)-2], false},
+		{"that is too long", text, signature + "00", false},
+		{"that is empty", text, "", false},
+		{"with a bad recovery ID", text, signature[:len(signature)-2] + "05", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifySiweSignature(test.message, address, test.signature)
+			if test.valid && err != nil {
+				t.Errorf("expected the signature to be accepted, got %v", err)
+			} else if !test.valid && err == nil {
+				t.Error("expected the signature to be refused")
+			}
+		})
+	}
+}
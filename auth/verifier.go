@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"crypto/subtle"
+)
+
+// Works out who a caller is from the credentials they present. Every API uses this, so a caller is the same
+// principal whichever way they come in.
+//
+// Back-office systems authenticate with an API key. Customers sign in with their wallet and present the
+// resulting session token.
+type Verifier struct {
+	// where API keys and sessions are stored
+	Repository AuthRepository
+	// an API key with the vendor admin role that isn't stored anywhere, for bootstrapping. Optional.
+	BootstrapAdminKey string
+}
+
+// Returns the principal the API key belongs to, or nil if the key is unknown or revoked
+func (verifier *Verifier) VerifyApiKey(apiKey string) (*Principal, error) {
+	if len(verifier.BootstrapAdminKey) != 0 &&
+		subtle.ConstantTimeCompare([]byte(apiKey), []byte(verifier.BootstrapAdminKey)) == 1 {
+		return &Principal{
+			Subject: "bootstrap",
+			Role:    RoleVendorAdmin,
+		}, nil
+	}
+
+	key, err := verifier.Repository.GetApiKeyByHash(HashSecret(apiKey))
+	if err != nil || key == nil {
+		return nil, err
+	}
+	return &Principal{
+		Subject:  key.KeyId,
+		Role:     key.Role,
+		VendorId: key.VendorId,
+	}, nil
+}
+
+// Returns the principal who owns the session, or nil if the session is unknown or expired
+func (verifier *Verifier) VerifySessionToken(token string) (*Principal, error) {
+	session, err := verifier.Repository.GetSession(HashSecret(token))
+	if err != nil || session == nil {
+		return nil, err
+	}
+	return &Principal{
+		Subject: session.Address,
+		Role:    session.Role,
+		Address: session.Address,
+	}, nil
+}
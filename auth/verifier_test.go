@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// Knows one API key per role and one customer session, and nothing else
+type fakeAuthRepository struct {
+	AuthRepository
+
+	keys     map[string]*ApiKey
+	sessions map[string]*Session
+}
+
+func newFakeAuthRepository() *fakeAuthRepository {
+	return &fakeAuthRepository{
+		keys: map[string]*ApiKey{
+			HashSecret("courier-key"): {KeyId: "key-courier", Role: RoleCourier},
+			HashSecret("vendor-key"):  {KeyId: "key-vendor", Role: RoleVendorAdmin, VendorId: "acme"},
+		},
+		sessions: map[string]*Session{
+			HashSecret("session-token"): {
+				Address:   "0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+				Role:      RoleCustomer,
+				ExpiresAt: time.Now().Add(time.Hour),
+			},
+		},
+	}
+}
+
+func (repo *fakeAuthRepository) GetApiKeyByHash(keyHash string) (*ApiKey, error) {
+	return repo.keys[keyHash], nil
+}
+
+func (repo *fakeAuthRepository) GetSession(tokenHash string) (*Session, error) {
+	return repo.sessions[tokenHash], nil
+}
+
+func TestVerifyApiKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		bootstrap string
+		apiKey    string
+		// nil if the key should be refused
+		expected *Principal
+	}{
+		{"matching the bootstrap key", "bootstrap-key", "bootstrap-key", &Principal{Subject: "bootstrap", Role: RoleVendorAdmin}},
+		{"that is a prefix of the bootstrap key", "bootstrap-key", "bootstrap", nil},
+		{"that differs from the bootstrap key in case", "bootstrap-key", "BOOTSTRAP-KEY", nil},
+		{"that is empty without a bootstrap key", "", "", nil},
+		{"that is stored", "bootstrap-key", "courier-key", &Principal{Subject: "key-courier", Role: RoleCourier}},
+		{"that is stored for a vendor", "", "vendor-key", &Principal{Subject: "key-vendor", Role: RoleVendorAdmin, VendorId: "acme"}},
+		{"that is unknown", "bootstrap-key", "unknown-key", nil},
+		// the hash is what is stored, not the key
+		{"that is the stored hash", "", HashSecret("courier-key"), nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier := &Verifier{Repository: newFakeAuthRepository(), BootstrapAdminKey: test.bootstrap}
+			principal, err := verifier.VerifyApiKey(test.apiKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expected == nil {
+				if principal != nil {
+					t.Errorf("expected the key to be refused, got %+v", principal)
+				}
+			} else if principal == nil || *principal != *test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, principal)
+			}
+		})
+	}
+}
+
+func TestVerifySessionToken(t *testing.T) {
+	verifier := &Verifier{Repository: newFakeAuthRepository(), BootstrapAdminKey: "bootstrap-key"}
+
+	principal, err := verifier.VerifySessionToken("session-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Principal{
+		Subject: "0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		Role:    RoleCustomer,
+		Address: "0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+	}
+	if principal == nil || *principal != expected {
+		t.Errorf("expected %+v, got %+v", expected, principal)
+	}
+
+	// a session token isn't an API key, and the bootstrap key isn't a session
+	for _, token := range []string{"unknown-token", "bootstrap-key", "courier-key"} {
+		if principal, err = verifier.VerifySessionToken(token); err != nil || principal != nil {
+			t.Errorf("expected [%s] to be refused, got %+v %v", token, principal, err)
+		}
+	}
+}
+
+func TestCanActFor(t *testing.T) {
+	var trusted *Principal
+	marketplace := &Principal{Subject: "key-1", Role: RoleVendorAdmin}
+	vendor := &Principal{Subject: "key-2", Role: RoleVendorAdmin, VendorId: "acme"}
+
+	if !trusted.CanActFor("acme") || !marketplace.CanActFor("acme") || !marketplace.CanActFor("") {
+		t.Error("expected the service itself and the marketplace to act for any vendor")
+	}
+	if !vendor.CanActFor("acme") {
+		t.Error("expected a vendor's key to act for the vendor")
+	}
+	if vendor.CanActFor("globex") || vendor.CanActFor("") {
+		t.Error("expected a vendor's key to act for nobody but the vendor")
+	}
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// What goes into a Sign-In with Ethereum message
+type NonceResponse struct {
+	Nonce   string `json:"nonce"`
+	Domain  string `json:"domain"`
+	ChainId int64  `json:"chainId"`
+}
+
+// A customer session
+type SessionResponse struct {
+	// send this as SessionToken
+	Token     string    `json:"token"`
+	Address   string    `json:"address"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Who the API thinks the caller is
+type PrincipalResponse struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+	Address string `json:"address,omitempty"`
+	// the only vendor the caller can act for, if they are tied to one
+	VendorId string `json:"vendorId,omitempty"`
+}
+
+// An API key to issue
+type ApiKeyRequest struct {
+	Name string `json:"name"`
+	// 'vendor_admin', 'courier', or 'auditor'
+	Role string `json:"role"`
+	// ties the key to one vendor. Leave it empty for a marketplace key.
+	VendorId string `json:"vendorId,omitempty"`
+}
+
+// An API key. The key itself is only sent back when it is created.
+type ApiKeyResponse struct {
+	KeyId     string     `json:"keyId"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	VendorId  string     `json:"vendorId,omitempty"`
+	Key       string     `json:"key,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Gets a nonce to put in a Sign-In with Ethereum message
+func (_client *Client) CreateNonce(ctx context.Context) (*NonceResponse, error) {
+	response := &NonceResponse{}
+	if _, err := _client.do(ctx, http.MethodPost, "/auth/nonce", nil, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Starts a customer session from a signed EIP-4361 message. The client uses the session from then on.
+func (_client *Client) SignInWithEthereum(ctx context.Context, message string, signature string) (*SessionResponse, error) {
+	body := struct {
+		Message   string `json:"message"`
+		Signature string `json:"signature"`
+	}{message, signature}
+
+	response := &SessionResponse{}
+	if _, err := _client.do(ctx, http.MethodPost, "/auth/siwe", nil, body, response); err != nil {
+		return nil, err
+	}
+	_client.SessionToken = response.Token
+	return response, nil
+}
+
+// Ends the client's customer session
+func (_client *Client) SignOut(ctx context.Context) error {
+	if _, err := _client.do(ctx, http.MethodPost, "/auth/logout", nil, nil, nil); err != nil {
+		return err
+	}
+	_client.SessionToken = ""
+	return nil
+}
+
+// Finds out who the client is authenticated as
+func (_client *Client) WhoAmI(ctx context.Context) (*PrincipalResponse, error) {
+	response := &PrincipalResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/auth/me", nil, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Issues an API key for a back-office system
+func (_client *Client) CreateApiKey(ctx context.Context, req *ApiKeyRequest) (*ApiKeyResponse, error) {
+	response := &ApiKeyResponse{}
+	if _, err := _client.do(ctx, http.MethodPost, "/apikeys", nil, req, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Lists the API keys that have been issued, including revoked ones
+func (_client *Client) ListApiKeys(ctx context.Context) ([]ApiKeyResponse, error) {
+	response := []ApiKeyResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/apikeys", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Revokes an API key
+func (_client *Client) RevokeApiKey(ctx context.Context, keyId string) error {
+	_, err := _client.do(ctx, http.MethodDelete, "/apikeys/"+segment(keyId), nil, nil, nil)
+	return err
+}
@@ -0,0 +1,269 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/google/uuid"
+)
+
+// the header write requests carry so that retrying them is safe
+var IdempotencyKeyHeader = "Idempotency-Key"
+
+// the header that ties a request to the server's logs
+var CorrelationIdHeader = "X-Correlation-ID"
+
+// the header that picks the vendor a request is for
+var VendorIdHeader = "X-Vendor-Id"
+
+// A client for the Vendor API. The request and response types mirror the ones in the swagger docs; keep them
+// in step when the API changes.
+//
+// Requests that fail for reasons that might go away on their own (the server or the blockchain node being
+// unreachable, or an identical request still being handled) are retried with exponential backoff. Write
+// requests always carry an Idempotency-Key, so a retry never places a second order or sends a second payment.
+// Every method stops as soon as its context is canceled.
+type Client struct {
+	// where the API lives, including the /api/v1 prefix
+	BaseUrl string
+	// authenticates as a back-office system. Takes precedence over SessionToken.
+	ApiKey string
+	// authenticates as a customer, from signing in with Ethereum
+	SessionToken string
+	// Narrows every request down to one vendor in the marketplace. Optional; a vendor's own API keys are always
+	// narrowed down to that vendor.
+	VendorId string
+
+	HttpClient *http.Client
+	// how many times to try a request before giving up on it
+	MaxAttempts int
+	// the delay before the first retry. It doubles with each attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Constructs a new client with reasonable defaults. baseUrl is where the API lives, e.g.
+// http://localhost:8080/api/v1
+func NewClient(baseUrl string) *Client {
+	return &Client{
+		BaseUrl:        strings.TrimSuffix(baseUrl, "/"),
+		HttpClient:     &http.Client{Timeout: 2 * time.Minute},
+		MaxAttempts:    4,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+	}
+}
+
+// An error response from the API
+type Error struct {
+	// the HTTP status of the response
+	StatusCode int
+	// identifies what went wrong. Switch on this rather than the message.
+	Code apierrors.Code `json:"code"`
+	// describes what went wrong, for people
+	Message string `json:"error"`
+	// anything that helps act on the error, e.g. which fields were wrong
+	Details map[string]interface{} `json:"details,omitempty"`
+	// quote this when asking about the error
+	CorrelationId string `json:"correlationId"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d): %s [correlation ID %s]", e.Code, e.StatusCode, e.Message, e.CorrelationId)
+}
+
+// Returns the code of an error from the API, or an empty code if the error didn't come from the API
+func CodeOf(err error) apierrors.Code {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+type idempotencyKey struct{}
+
+// Returns a context that makes write requests use the given Idempotency-Key rather than a fresh one. Use it to
+// retry a request yourself after the client has given up on it, e.g. after a restart.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// What a response said besides its body
+type responseInfo struct {
+	statusCode int
+	// true if the server returned the response it saved for an earlier request with the same Idempotency-Key
+	replayed bool
+}
+
+// Sends a request and decodes the response into out, which may be nil. body, if not nil, is sent as JSON.
+func (_client *Client) do(
+	ctx context.Context,
+	method string,
+	path string,
+	query url.Values,
+	body interface{},
+	out interface{},
+) (*responseInfo, error) {
+	target := _client.BaseUrl + path
+	if len(query) != 0 {
+		target += "?" + query.Encode()
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	// the same key goes with every attempt, so the server knows they are all the same request
+	key := ""
+	if method != http.MethodGet {
+		key, _ = ctx.Value(idempotencyKey{}).(string)
+		if len(key) == 0 {
+			key = uuid.New().String()
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < _client.MaxAttempts; attempt++ {
+		if attempt != 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(_client.backoff(attempt - 1)):
+			}
+		}
+
+		info, err := _client.attempt(ctx, method, target, payload, key, out)
+		if err == nil {
+			return info, nil
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		} else if !retryable(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Makes one attempt at a request
+func (_client *Client) attempt(
+	ctx context.Context,
+	method string,
+	target string,
+	payload []byte,
+	key string,
+	out interface{},
+) (*responseInfo, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(key) != 0 {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	if len(_client.ApiKey) != 0 {
+		req.Header.Set("X-API-Key", _client.ApiKey)
+	} else if len(_client.SessionToken) != 0 {
+		req.Header.Set("Authorization", "Bearer "+_client.SessionToken)
+	}
+	if len(_client.VendorId) != 0 {
+		req.Header.Set(VendorIdHeader, _client.VendorId)
+	}
+
+	resp, err := _client.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, readError(resp)
+	}
+
+	info := &responseInfo{
+		statusCode: resp.StatusCode,
+		replayed:   resp.Header.Get("Idempotent-Replayed") == "true",
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, errors.New(fmt.Sprintf("could not read the response: %v", err))
+		}
+	}
+	return info, nil
+}
+
+// Reads an error response. Responses that didn't come from the API itself, like a proxy's error page,
+// still become an *Error so callers only have one kind of error to deal with.
+func readError(resp *http.Response) error {
+	apiErr := &Error{}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if json.Unmarshal(body, apiErr) != nil || len(apiErr.Code) == 0 {
+		apiErr = &Error{
+			Code:    apierrors.CodeInternal,
+			Message: strings.TrimSpace(string(body)),
+		}
+	}
+	apiErr.StatusCode = resp.StatusCode
+	if len(apiErr.CorrelationId) == 0 {
+		apiErr.CorrelationId = resp.Header.Get(CorrelationIdHeader)
+	}
+	return apiErr
+}
+
+// Whether trying the request again might get a different answer
+func retryable(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		// the request didn't get an answer at all
+		return true
+	}
+	switch apiErr.Code {
+	case apierrors.CodeChainUnavailable, apierrors.CodeIdempotencyKeyInProgress:
+		return true
+	case apierrors.CodeInternal:
+		// a proxy in front of the API that couldn't reach it
+		return apiErr.StatusCode == http.StatusBadGateway || apiErr.StatusCode == http.StatusServiceUnavailable ||
+			apiErr.StatusCode == http.StatusGatewayTimeout
+	}
+	return false
+}
+
+// How long to wait before the next attempt, given how many retries have been made already
+func (_client *Client) backoff(retries int) time.Duration {
+	delay := _client.InitialBackoff
+	for i := 0; i < retries && delay < _client.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > _client.MaxBackoff {
+		delay = _client.MaxBackoff
+	}
+	return delay
+}
+
+// Escapes a value for use as a path segment
+func segment(value string) string {
+	return url.PathEscape(value)
+}
@@ -0,0 +1,732 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/apitest"
+	"github.com/bdunton9323/blockchain-playground/webhooks"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Serves the API and returns a client that authenticates as the marketplace's admin
+func newTestClient(t *testing.T) (*Client, *apitest.Server) {
+	server := apitest.NewServer(t)
+	return clientFor(server, apitest.AdminKey), server
+}
+
+// A client for the server that authenticates with the API key, if there is one. Failed requests are retried
+// quickly, so the tests don't sit through the backoff.
+func clientFor(server *apitest.Server, apiKey string) *Client {
+	client := NewClient(server.Url + "/api/v1")
+	client.ApiKey = apiKey
+	client.InitialBackoff = 10 * time.Millisecond
+	client.MaxBackoff = 10 * time.Millisecond
+	return client
+}
+
+// Adds a product sold by the default vendor
+func createProduct(t *testing.T, client *Client, productId string, price int64) {
+	_, err := client.CreateProduct(context.Background(), &ProductRequest{
+		ProductId:     productId,
+		Name:          "Product " + productId,
+		Description:   "Something to sell",
+		Price:         price,
+		ShippingPrice: 1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Places an order and makes sure its token was minted
+func createOrder(t *testing.T, client *Client, itemId string, buyerAddress string) *CreateOrderResponse {
+	order, err := client.CreateOrder(context.Background(), itemId, buyerAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Pending || order.Status != "minted" || order.TokenId == "" {
+		t.Fatalf("expected the order's token to be minted, got %+v", order)
+	}
+	return order
+}
+
+// Fails the test unless err came from the API with the given status and code
+func expectError(t *testing.T, err error, status int, code apierrors.Code) *Error {
+	t.Helper()
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an API error with code %s, got %v", code, err)
+	}
+	if apiErr.StatusCode != status || apiErr.Code != code {
+		t.Fatalf("expected %d %s, got %d %s: %s", status, code, apiErr.StatusCode, apiErr.Code, apiErr.Message)
+	}
+	if CodeOf(err) != code {
+		t.Errorf("expected CodeOf to give %s, got %s", code, CodeOf(err))
+	}
+	if len(apiErr.CorrelationId) == 0 {
+		t.Error("expected the error to carry a correlation ID")
+	}
+	return apiErr
+}
+
+// What personal_sign produces for the message
+func personalSign(t *testing.T, keyHex string, message string) string {
+	key, err := crypto.HexToECDSA(keyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(signature)
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+	buyerKey := server.BuyerKeys[0]
+	buyer := apitest.AddressOf(t, buyerKey).Hex()
+	vendor := apitest.AddressOf(t, server.VendorKey).Hex()
+
+	createProduct(t, client, "item-1", 50000)
+	order := createOrder(t, client, "item-1", buyer)
+	if order.ContractAddress == "" {
+		t.Error("expected the order to name the vendor's contract")
+	}
+
+	owner, err := client.GetTokenOwner(ctx, order.OrderId)
+	if err != nil {
+		t.Fatal(err)
+	} else if owner.Owner != vendor {
+		t.Errorf("expected the vendor [%s] to hold the new token, got [%s]", vendor, owner.Owner)
+	}
+
+	pending, err := client.PayForOrder(ctx, order.OrderId, buyerKey)
+	if err != nil {
+		t.Fatal(err)
+	} else if pending {
+		t.Error("expected the payment to be mined straight away")
+	}
+
+	_, err = client.PayForOrder(ctx, order.OrderId, buyerKey)
+	expectError(t, err, http.StatusConflict, apierrors.CodeOrderAlreadyPaid)
+
+	delivered, err := client.DeliverOrder(ctx, order.OrderId, buyerKey)
+	if err != nil {
+		t.Fatal(err)
+	} else if delivered.Status != "delivered" || delivered.Pending {
+		t.Errorf("expected the order to be delivered, got %+v", delivered)
+	}
+
+	owner, err = client.GetTokenOwner(ctx, order.OrderId)
+	if err != nil {
+		t.Fatal(err)
+	} else if owner.Owner != buyer {
+		t.Errorf("expected the buyer [%s] to hold the token once it was delivered, got [%s]", buyer, owner.Owner)
+	}
+
+	burned, err := client.BurnToken(ctx, order.OrderId)
+	if err != nil {
+		t.Fatal(err)
+	} else if burned.Status != "burned" {
+		t.Errorf("expected the token to be burned, got %+v", burned)
+	}
+
+	history, err := client.GetOrderHistory(ctx, order.OrderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := []string{}
+	for _, change := range history.History {
+		statuses = append(statuses, change.ToStatus)
+	}
+	if history.Status != "burned" || strings.Join(statuses, ",") != "created,minted,paid,delivered,burned" {
+		t.Errorf("expected the order to have gone from created to burned, got %s via %v", history.Status, statuses)
+	}
+	for _, change := range history.History[1:] {
+		if len(change.TxHash) == 0 {
+			t.Errorf("expected the change to %s to name its transaction", change.ToStatus)
+		}
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	createProduct(t, client, "item-1", 50000)
+	order := createOrder(t, client, "item-1", apitest.AddressOf(t, server.BuyerKeys[0]).Hex())
+
+	canceled, err := client.CancelOrder(ctx, order.OrderId)
+	if err != nil {
+		t.Fatal(err)
+	} else if canceled.Status != "canceled" {
+		t.Errorf("expected the order to be canceled, got %+v", canceled)
+	}
+
+	_, err = client.PayForOrder(ctx, order.OrderId, server.BuyerKeys[0])
+	apiErr := expectError(t, err, http.StatusConflict, apierrors.CodeOrderStatusConflict)
+	if apiErr.Details["currentStatus"] != "canceled" {
+		t.Errorf("expected the error to say the order is canceled, got %v", apiErr.Details)
+	}
+}
+
+func TestEscrow(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+	buyerKey := server.BuyerKeys[0]
+	buyer := apitest.AddressOf(t, buyerKey).Hex()
+	createProduct(t, client, "item-1", 50000)
+
+	// paid for, but the customer never accepts delivery
+	placePaidOrder := func() string {
+		order := createOrder(t, client, "item-1", buyer)
+		if _, err := client.PayForOrder(ctx, order.OrderId, buyerKey); err != nil {
+			t.Fatal(err)
+		}
+		return order.OrderId
+	}
+
+	released := placePaidOrder()
+	_, err := client.ReleaseEscrow(ctx, released)
+	expectError(t, err, http.StatusConflict, apierrors.CodeDeliveryEvidenceMissing)
+
+	evidence, err := client.RecordDeliveryEvidence(ctx, released, "courier-scan-1234")
+	if err != nil {
+		t.Fatal(err)
+	} else if evidence.OrderId != released || evidence.Reference != "courier-scan-1234" || len(evidence.Hash) == 0 {
+		t.Errorf("expected the evidence to be recorded, got %+v", evidence)
+	}
+
+	status, err := client.ReleaseEscrow(ctx, released)
+	if err != nil {
+		t.Fatal(err)
+	} else if status.Status != "released" {
+		t.Errorf("expected the escrow to be released, got %+v", status)
+	}
+
+	// the vendor refunds one, and the customer reclaims another themselves
+	for _, customerKey := range []string{"", buyerKey} {
+		refunded := placePaidOrder()
+		status, err = client.RefundEscrow(ctx, refunded, customerKey)
+		if err != nil {
+			t.Fatal(err)
+		} else if status.Status != "refunded" {
+			t.Errorf("expected the escrow to be refunded, got %+v", status)
+		}
+	}
+}
+
+func TestListOrders(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+	first := apitest.AddressOf(t, server.BuyerKeys[0]).Hex()
+	second := apitest.AddressOf(t, server.BuyerKeys[1]).Hex()
+
+	createProduct(t, client, "cheap", 1000)
+	createProduct(t, client, "dear", 9000)
+	createOrder(t, client, "cheap", first)
+	createOrder(t, client, "dear", first)
+	createOrder(t, client, "dear", second)
+
+	page, err := client.ListOrders(ctx, &ListOrdersRequest{BuyerAddress: first})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(page.Orders) != 2 || page.NextCursor != "" {
+		t.Errorf("expected both of the first buyer's orders on one page, got %+v", page)
+	}
+
+	// cheapest first, a page at a time
+	prices := []int64{}
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		page, err = client.ListOrders(ctx, &ListOrdersRequest{SortBy: "price", Ascending: true, Limit: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, order := range page.Orders {
+			prices = append(prices, order.Price)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if fmt.Sprint(prices) != "[1000 9000 9000]" {
+		t.Errorf("expected every order once, cheapest first, got %v", prices)
+	}
+
+	page, err = client.ListOrders(ctx, &ListOrdersRequest{ItemId: "dear", Statuses: []string{"minted"}, VendorId: "default"})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(page.Orders) != 2 {
+		t.Errorf("expected two minted orders for the dear item, got %d", len(page.Orders))
+	}
+
+	_, err = client.ListOrders(ctx, &ListOrdersRequest{Cursor: "not-a-cursor"})
+	expectError(t, err, http.StatusBadRequest, apierrors.CodeInvalidCursor)
+
+	// a cursor only carries on the list it came from
+	page, err = client.ListOrders(ctx, &ListOrdersRequest{SortBy: "price", Ascending: true, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.ListOrders(ctx, &ListOrdersRequest{SortBy: "price", Limit: 1, Cursor: page.NextCursor})
+	expectError(t, err, http.StatusBadRequest, apierrors.CodeInvalidCursor)
+	_, err = client.ListOrders(ctx, &ListOrdersRequest{
+		SortBy: "price", Ascending: true, ItemId: "dear", Limit: 1, Cursor: page.NextCursor,
+	})
+	expectError(t, err, http.StatusBadRequest, apierrors.CodeInvalidCursor)
+}
+
+func TestProducts(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	created, err := client.CreateProduct(ctx, &ProductRequest{ProductId: "lamp", Name: "Lamp", Price: 300, ShippingPrice: 20})
+	if err != nil {
+		t.Fatal(err)
+	} else if created.ProductId != "lamp" || created.VendorId != "default" {
+		t.Errorf("expected the lamp to be sold by the default vendor, got %+v", created)
+	}
+
+	_, err = client.CreateProduct(ctx, &ProductRequest{ProductId: "lamp", Name: "Lamp", Price: 300})
+	expectError(t, err, http.StatusConflict, apierrors.CodeProductAlreadyExists)
+
+	updated, err := client.UpdateProduct(ctx, "lamp", &ProductRequest{Name: "Desk lamp", Price: 350, ShippingPrice: 20})
+	if err != nil {
+		t.Fatal(err)
+	} else if updated.Name != "Desk lamp" || updated.Price != 350 {
+		t.Errorf("expected the lamp to be renamed and repriced, got %+v", updated)
+	}
+
+	fetched, err := client.GetProduct(ctx, "lamp")
+	if err != nil {
+		t.Fatal(err)
+	} else if *fetched != *updated {
+		t.Errorf("expected %+v, got %+v", updated, fetched)
+	}
+
+	listed, err := client.ListProducts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(listed) != 1 || listed[0].ProductId != "lamp" {
+		t.Errorf("expected the catalog to hold the lamp, got %+v", listed)
+	}
+
+	if err = client.DeleteProduct(ctx, "lamp"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetProduct(ctx, "lamp")
+	expectError(t, err, http.StatusNotFound, apierrors.CodeProductNotFound)
+	err = client.DeleteProduct(ctx, "lamp")
+	expectError(t, err, http.StatusNotFound, apierrors.CodeProductNotFound)
+}
+
+func TestCustomers(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+	walletKey := server.BuyerKeys[0]
+	walletAddress := apitest.AddressOf(t, walletKey).Hex()
+
+	custodial, err := client.CreateCustomer(ctx, &CustomerRequest{Name: "Ada", Custodial: true})
+	if err != nil {
+		t.Fatal(err)
+	} else if !custodial.Custodial || len(custodial.Address) == 0 {
+		t.Fatalf("expected the customer to get a deposit address, got %+v", custodial)
+	}
+
+	updated, err := client.UpdateCustomer(ctx, custodial.CustomerId, &CustomerRequest{
+		Name:  "Ada Lovelace",
+		Email: "ada@example.com",
+		ShippingAddress: &ShippingAddress{
+			Line1:      "12 St James's Square",
+			City:       "London",
+			PostalCode: "SW1Y 4JH",
+			Country:    "GB",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if updated.Name != "Ada Lovelace" || updated.ShippingAddress == nil || updated.Address != custodial.Address {
+		t.Errorf("expected the profile to change and the deposit address to stay, got %+v", updated)
+	}
+
+	// customers with wallets of their own prove they hold each address's key
+	own, err := client.CreateCustomer(ctx, &CustomerRequest{Name: "Grace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := client.AddAddress(ctx, own.CustomerId, walletAddress, "hardware wallet")
+	if err != nil {
+		t.Fatal(err)
+	} else if added.Verified || len(added.Challenge) == 0 {
+		t.Fatalf("expected an unverified address with a challenge, got %+v", added)
+	}
+
+	_, err = client.VerifyAddress(ctx, own.CustomerId, walletAddress, personalSign(t, server.BuyerKeys[1], added.Challenge))
+	expectError(t, err, http.StatusBadRequest, apierrors.CodeInvalidSignature)
+
+	verified, err := client.VerifyAddress(ctx, own.CustomerId, walletAddress, personalSign(t, walletKey, added.Challenge))
+	if err != nil {
+		t.Fatal(err)
+	} else if !verified.Verified {
+		t.Errorf("expected the address to be verified, got %+v", verified)
+	}
+
+	fetched, err := client.GetCustomer(ctx, own.CustomerId)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(fetched.Addresses) != 1 || !fetched.Addresses[0].Verified {
+		t.Errorf("expected the verified address in the address book, got %+v", fetched.Addresses)
+	}
+
+	listed, err := client.ListCustomers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(listed) != 2 {
+		t.Errorf("expected two customers, got %d", len(listed))
+	}
+
+	// orders go to the customer's addresses, and the service signs for custodial customers
+	createProduct(t, client, "item-1", 50000)
+	for _, customerId := range []string{custodial.CustomerId, own.CustomerId} {
+		order, err := client.CreateOrderForCustomer(ctx, "item-1", customerId, "")
+		if err != nil {
+			t.Fatal(err)
+		} else if order.Status != "minted" {
+			t.Errorf("expected the customer's order to be minted, got %+v", order)
+		}
+	}
+	orders, err := client.ListCustomerOrders(ctx, custodial.CustomerId, &ListOrdersRequest{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(orders.Orders) != 1 || orders.Orders[0].BuyerAddress != custodial.Address {
+		t.Fatalf("expected one order for the deposit address, got %+v", orders.Orders)
+	}
+	if _, err = client.PayForOrder(ctx, orders.Orders[0].OrderId, ""); err != nil {
+		t.Errorf("expected the service to pay for the custodial customer: %v", err)
+	}
+
+	if err = client.RemoveAddress(ctx, own.CustomerId, walletAddress); err != nil {
+		t.Fatal(err)
+	}
+	err = client.RemoveAddress(ctx, own.CustomerId, walletAddress)
+	expectError(t, err, http.StatusNotFound, apierrors.CodeCustomerAddressNotFound)
+	_, err = client.GetCustomer(ctx, "nobody")
+	expectError(t, err, http.StatusNotFound, apierrors.CodeCustomerNotFound)
+}
+
+func TestVendors(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	created, err := client.CreateVendor(ctx, &VendorRequest{VendorId: "acme", Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	} else if created.Ready || len(created.Address) == 0 {
+		t.Errorf("expected a vendor with a key but no contract, got %+v", created)
+	}
+
+	_, err = client.CreateVendor(ctx, &VendorRequest{VendorId: "acme", Name: "Acme again"})
+	expectError(t, err, http.StatusConflict, apierrors.CodeVendorAlreadyExists)
+
+	updated, err := client.UpdateVendor(ctx, "acme", &VendorRequest{Name: "Acme Corporation"})
+	if err != nil {
+		t.Fatal(err)
+	} else if updated.Name != "Acme Corporation" {
+		t.Errorf("expected the vendor to be renamed, got %+v", updated)
+	}
+
+	// products can be listed before the contract is there, but not ordered
+	_, err = client.CreateProduct(ctx, &ProductRequest{ProductId: "anvil", Name: "Anvil", Price: 700, VendorId: "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CreateOrder(ctx, "anvil", created.Address)
+	expectError(t, err, http.StatusConflict, apierrors.CodeVendorNotReady)
+
+	deployed, err := client.DeployVendorContract(ctx, "acme", false)
+	if err != nil {
+		t.Fatal(err)
+	} else if !deployed.Ready || len(deployed.ContractAddress) == 0 {
+		t.Fatalf("expected the vendor's contract to be deployed, got %+v", deployed)
+	}
+
+	_, err = client.DeployVendorContract(ctx, "acme", false)
+	apiErr := expectError(t, err, http.StatusConflict, apierrors.CodeContractAlreadyDeployed)
+	if apiErr.Details["contractAddress"] != deployed.ContractAddress {
+		t.Errorf("expected the error to name the deployed contract, got %v", apiErr.Details)
+	}
+
+	replaced, err := client.DeployVendorContract(ctx, "acme", true)
+	if err != nil {
+		t.Fatal(err)
+	} else if replaced.ContractAddress == deployed.ContractAddress {
+		t.Error("expected the replacement contract to have a new address")
+	}
+
+	// a marketplace admin acts for the vendor by naming them
+	order, err := client.CreateOrder(ctx, "anvil", "0x7E0C39B48D52ADBc8660c1B03288Ef189787A133")
+	if err != nil {
+		t.Fatal(err)
+	} else if order.ContractAddress != replaced.ContractAddress {
+		t.Errorf("expected the order to be minted in the new contract [%s], got [%s]", replaced.ContractAddress, order.ContractAddress)
+	}
+
+	fetched, err := client.GetVendor(ctx, "acme")
+	if err != nil {
+		t.Fatal(err)
+	} else if fetched.ContractAddress != replaced.ContractAddress {
+		t.Errorf("expected the vendor to have the new contract, got %+v", fetched)
+	}
+
+	listed, err := client.ListVendors(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(listed) != 2 || listed[0].VendorId != "default" || listed[1].VendorId != "acme" {
+		t.Errorf("expected the default vendor and acme, got %+v", listed)
+	}
+
+	_, err = client.GetVendor(ctx, "nobody")
+	expectError(t, err, http.StatusNotFound, apierrors.CodeVendorNotFound)
+}
+
+func TestWebhooks(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	created, err := client.CreateWebhook(ctx, &WebhookRequest{
+		Url:    "https://example.com/hooks",
+		Events: []string{"order.created", "order.minted"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if !created.Active || len(created.Secret) == 0 {
+		t.Errorf("expected an active subscription with a secret, got %+v", created)
+	}
+
+	// placing an order queues a delivery for each of its events
+	createProduct(t, client, "item-1", 50000)
+	order := createOrder(t, client, "item-1", apitest.AddressOf(t, server.BuyerKeys[0]).Hex())
+	deliveries, err := client.ListWebhookDeliveries(ctx, created.WebhookId, "pending", 10)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(deliveries) != 2 || deliveries[0].EventType != "order.minted" || deliveries[0].OrderId != order.OrderId {
+		t.Errorf("expected the minted and created events, newest first, got %+v", deliveries)
+	}
+
+	dead := server.Webhooks.AddDelivery(&webhooks.Delivery{
+		SubscriptionId: created.WebhookId,
+		EventId:        "event-1",
+		EventType:      webhooks.EventOrderPaid,
+		OrderId:        order.OrderId,
+		Status:         webhooks.DeliveryDead,
+		Attempts:       12,
+	})
+	retried, err := client.RetryWebhookDelivery(ctx, created.WebhookId, dead)
+	if err != nil {
+		t.Fatal(err)
+	} else if retried.Status != "pending" || retried.Attempts != 0 {
+		t.Errorf("expected the delivery to be queued again, got %+v", retried)
+	}
+	_, err = client.RetryWebhookDelivery(ctx, created.WebhookId, dead)
+	expectError(t, err, http.StatusConflict, apierrors.CodeWebhookDeliveryNotDead)
+
+	paused := false
+	updated, err := client.UpdateWebhook(ctx, created.WebhookId, &WebhookRequest{
+		Url:    "https://example.com/other",
+		Events: []string{"order.paid"},
+		Active: &paused,
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if updated.Active || updated.Url != "https://example.com/other" {
+		t.Errorf("expected the subscription to be paused and moved, got %+v", updated)
+	}
+
+	fetched, err := client.GetWebhook(ctx, created.WebhookId)
+	if err != nil {
+		t.Fatal(err)
+	} else if fetched.Url != updated.Url || len(fetched.Secret) != 0 {
+		t.Errorf("expected the updated subscription without its secret, got %+v", fetched)
+	}
+
+	listed, err := client.ListWebhooks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(listed) != 1 {
+		t.Errorf("expected one subscription, got %d", len(listed))
+	}
+
+	if err = client.DeleteWebhook(ctx, created.WebhookId); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetWebhook(ctx, created.WebhookId)
+	expectError(t, err, http.StatusNotFound, apierrors.CodeWebhookNotFound)
+}
+
+func TestApiKeys(t *testing.T) {
+	admin, server := newTestClient(t)
+	ctx := context.Background()
+
+	issued, err := admin.CreateApiKey(ctx, &ApiKeyRequest{Name: "warehouse", Role: "courier", VendorId: "default"})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(issued.Key) == 0 {
+		t.Fatalf("expected the new key to be sent back, got %+v", issued)
+	}
+
+	courier := clientFor(server, issued.Key)
+	me, err := courier.WhoAmI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if me.Role != "courier" || me.VendorId != "default" {
+		t.Errorf("expected a courier for the default vendor, got %+v", me)
+	}
+
+	// couriers can't change the catalog
+	_, err = courier.CreateProduct(ctx, &ProductRequest{ProductId: "lamp", Name: "Lamp", Price: 300})
+	expectError(t, err, http.StatusForbidden, apierrors.CodeForbidden)
+
+	keys, err := admin.ListApiKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(keys) != 1 || keys[0].KeyId != issued.KeyId || len(keys[0].Key) != 0 {
+		t.Errorf("expected the courier's key without the key itself, got %+v", keys)
+	}
+
+	if err = admin.RevokeApiKey(ctx, issued.KeyId); err != nil {
+		t.Fatal(err)
+	}
+	_, err = courier.WhoAmI(ctx)
+	expectError(t, err, http.StatusUnauthorized, apierrors.CodeInvalidCredentials)
+
+	err = admin.RevokeApiKey(ctx, "nonexistent")
+	expectError(t, err, http.StatusNotFound, apierrors.CodeApiKeyNotFound)
+}
+
+func TestSignInWithEthereum(t *testing.T) {
+	admin, server := newTestClient(t)
+	ctx := context.Background()
+	customerKey := server.BuyerKeys[0]
+	customerAddress := apitest.AddressOf(t, customerKey).Hex()
+
+	createProduct(t, admin, "item-1", 50000)
+	mine := createOrder(t, admin, "item-1", customerAddress)
+	createOrder(t, admin, "item-1", apitest.AddressOf(t, server.BuyerKeys[1]).Hex())
+
+	customer := clientFor(server, "")
+	nonce, err := customer.CreateNonce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if nonce.Domain != apitest.SiweDomain || nonce.ChainId != 1337 {
+		t.Errorf("expected a nonce for %s on chain 1337, got %+v", apitest.SiweDomain, nonce)
+	}
+
+	message := fmt.Sprintf("%s wants you to sign in with your Ethereum account:\n%s\n\nSign in to the Vendor API\n\n"+
+		"URI: https://%s\nVersion: 1\nChain ID: %d\nNonce: %s\nIssued At: %s",
+		nonce.Domain, customerAddress, nonce.Domain, nonce.ChainId, nonce.Nonce, time.Now().UTC().Format(time.RFC3339))
+
+	_, err = customer.SignInWithEthereum(ctx, message, personalSign(t, server.BuyerKeys[1], message))
+	expectError(t, err, http.StatusUnauthorized, apierrors.CodeInvalidCredentials)
+
+	session, err := customer.SignInWithEthereum(ctx, message, personalSign(t, customerKey, message))
+	if err != nil {
+		t.Fatal(err)
+	} else if session.Address != customerAddress || customer.SessionToken != session.Token {
+		t.Fatalf("expected a session for [%s], got %+v", customerAddress, session)
+	}
+
+	// the nonce only works once
+	_, err = clientFor(server, "").SignInWithEthereum(ctx, message, personalSign(t, customerKey, message))
+	expectError(t, err, http.StatusUnauthorized, apierrors.CodeInvalidCredentials)
+
+	me, err := customer.WhoAmI(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if me.Role != "customer" || me.Address != customerAddress {
+		t.Errorf("expected to be the customer, got %+v", me)
+	}
+
+	// customers only see their own orders
+	page, err := customer.ListOrders(ctx, &ListOrdersRequest{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(page.Orders) != 1 || page.Orders[0].OrderId != mine.OrderId {
+		t.Errorf("expected only the customer's own order, got %+v", page.Orders)
+	}
+
+	if err = customer.SignOut(ctx); err != nil {
+		t.Fatal(err)
+	} else if customer.SessionToken != "" {
+		t.Error("expected the client to forget the session")
+	}
+	_, err = customer.WhoAmI(ctx)
+	expectError(t, err, http.StatusUnauthorized, apierrors.CodeUnauthenticated)
+}
+
+func TestErrorDecoding(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+	createProduct(t, client, "item-1", 50000)
+
+	t.Run("validation errors name the fields", func(t *testing.T) {
+		_, err := client.CreateOrder(ctx, "item-1", "not-an-address")
+		apiErr := expectError(t, err, http.StatusBadRequest, apierrors.CodeInvalidAddress)
+		if fmt.Sprint(apiErr.Details["fields"]) != "[buyerAddress]" {
+			t.Errorf("expected the error to name buyerAddress, got %v", apiErr.Details)
+		}
+	})
+
+	t.Run("unknown orders", func(t *testing.T) {
+		_, err := client.GetOrderHistory(ctx, "7b2c1d4e-0000-4000-8000-000000000000")
+		expectError(t, err, http.StatusNotFound, apierrors.CodeOrderNotFound)
+	})
+
+	t.Run("bad private keys", func(t *testing.T) {
+		order := createOrder(t, client, "item-1", apitest.AddressOf(t, server.BuyerKeys[0]).Hex())
+		_, err := client.PayForOrder(ctx, order.OrderId, "not-a-key")
+		expectError(t, err, http.StatusBadRequest, apierrors.CodeInvalidPrivateKey)
+	})
+
+	t.Run("tokens burned too early", func(t *testing.T) {
+		// the token can't be burned before it is delivered, which the contract enforces too
+		order := createOrder(t, client, "item-1", apitest.AddressOf(t, server.BuyerKeys[0]).Hex())
+		_, err := client.BurnToken(ctx, order.OrderId)
+		expectError(t, err, http.StatusConflict, apierrors.CodeOrderStatusConflict)
+	})
+
+	t.Run("missing credentials", func(t *testing.T) {
+		_, err := clientFor(server, "").ListOrders(ctx, &ListOrdersRequest{})
+		expectError(t, err, http.StatusUnauthorized, apierrors.CodeUnauthenticated)
+	})
+
+	t.Run("reused idempotency keys", func(t *testing.T) {
+		keyed := WithIdempotencyKey(ctx, "reused-key")
+		if _, err := client.CreateVendor(keyed, &VendorRequest{VendorId: "first", Name: "First"}); err != nil {
+			t.Fatal(err)
+		}
+		_, err := client.CreateVendor(keyed, &VendorRequest{VendorId: "second", Name: "Second"})
+		expectError(t, err, http.StatusUnprocessableEntity, apierrors.CodeIdempotencyKeyReused)
+	})
+
+	t.Run("errors that didn't come from the API", func(t *testing.T) {
+		unreachable := NewClient("http://127.0.0.1:1/api/v1")
+		unreachable.MaxAttempts = 1
+		_, err := unreachable.ListProducts(ctx)
+		if err == nil || CodeOf(err) != "" {
+			t.Errorf("expected a transport error without a code, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// A customer's account, for opening or changing it
+type CustomerRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	// nil if the customer hasn't given one
+	ShippingAddress *ShippingAddress `json:"shippingAddress,omitempty"`
+	// give the customer a deposit address from the service's HD wallet, for customers without a wallet.
+	// Only when opening the account.
+	Custodial bool `json:"custodial"`
+}
+
+// A postal address
+type ShippingAddress struct {
+	Name       string `json:"name,omitempty"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postalCode"`
+	// ISO 3166-1 alpha-2, e.g. GB
+	Country string `json:"country"`
+}
+
+// A customer's account
+type CustomerResponse struct {
+	CustomerId      string           `json:"customerId"`
+	Name            string           `json:"name"`
+	Email           string           `json:"email,omitempty"`
+	ShippingAddress *ShippingAddress `json:"shippingAddress,omitempty"`
+	Custodial       bool             `json:"custodial"`
+	// a custodial customer's deposit address, where their orders are delivered and where to send the ether that
+	// pays for them
+	Address        string `json:"address,omitempty"`
+	DerivationPath string `json:"derivationPath,omitempty"`
+	// the customer's address book. Empty in lists of customers.
+	Addresses []AddressResponse `json:"addresses"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// An address in a customer's address book
+type AddressResponse struct {
+	Address  string `json:"address"`
+	Label    string `json:"label,omitempty"`
+	Verified bool   `json:"verified"`
+	// what the customer signs with the address's key to verify it, while it is unverified
+	Challenge          string     `json:"challenge,omitempty"`
+	ChallengeExpiresAt *time.Time `json:"challengeExpiresAt,omitempty"`
+	VerifiedAt         *time.Time `json:"verifiedAt,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+}
+
+// Opens an account for a customer
+func (_client *Client) CreateCustomer(ctx context.Context, req *CustomerRequest) (*CustomerResponse, error) {
+	response := &CustomerResponse{}
+	if _, err := _client.do(ctx, http.MethodPost, "/customers", nil, req, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Replaces a customer's name, email and shipping address
+func (_client *Client) UpdateCustomer(ctx context.Context, customerId string, req *CustomerRequest) (*CustomerResponse, error) {
+	response := &CustomerResponse{}
+	if _, err := _client.do(ctx, http.MethodPut, "/customers/"+segment(customerId), nil, req, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Lists the customers, in the order their accounts were opened
+func (_client *Client) ListCustomers(ctx context.Context) ([]CustomerResponse, error) {
+	response := []CustomerResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/customers", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Looks up a customer and their address book
+func (_client *Client) GetCustomer(ctx context.Context, customerId string) (*CustomerResponse, error) {
+	response := &CustomerResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/customers/"+segment(customerId), nil, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Adds an address to the customer's address book. Sign the challenge in the response with the address's key
+// and pass the signature to VerifyAddress.
+func (_client *Client) AddAddress(ctx context.Context, customerId string, address string, label string) (*AddressResponse, error) {
+	body := struct {
+		Address string `json:"address"`
+		Label   string `json:"label"`
+	}{address, label}
+
+	response := &AddressResponse{}
+	if _, err := _client.do(ctx, http.MethodPost, "/customers/"+segment(customerId)+"/addresses", nil, body, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Proves the customer holds the address's key with their signature over its challenge
+func (_client *Client) VerifyAddress(ctx context.Context, customerId string, address string, signature string) (*AddressResponse, error) {
+	body := struct {
+		Signature string `json:"signature"`
+	}{signature}
+
+	response := &AddressResponse{}
+	path := "/customers/" + segment(customerId) + "/addresses/" + segment(address) + "/verify"
+	if _, err := _client.do(ctx, http.MethodPost, path, nil, body, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Takes an address out of the customer's address book
+func (_client *Client) RemoveAddress(ctx context.Context, customerId string, address string) error {
+	_, err := _client.do(ctx, http.MethodDelete, "/customers/"+segment(customerId)+"/addresses/"+segment(address), nil, nil, nil)
+	return err
+}
+
+// Searches the orders delivered to the customer's verified addresses. BuyerAddress narrows it down to one of them.
+func (_client *Client) ListCustomerOrders(ctx context.Context, customerId string, req *ListOrdersRequest) (*OrderListResponse, error) {
+	response := &OrderListResponse{}
+	path := "/customers/" + segment(customerId) + "/orders"
+	if _, err := _client.do(ctx, http.MethodGet, path, orderListQuery(req), nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
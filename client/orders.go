@@ -0,0 +1,273 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Response to placing an order
+type CreateOrderResponse struct {
+	// The ID of the delivery token. A tokenId is unique within a given contract. The API sends it
+	// in a field called "message".
+	TokenId string `json:"message,omitempty"`
+	// the address of the contract that manages the token
+	ContractAddress string `json:"contractAddress"`
+	// the ID of the order that was created
+	OrderId string `json:"orderId"`
+	// where the order has got to
+	Status string `json:"status"`
+	// true if the order was recorded but its token is still being minted
+	Pending bool `json:"-"`
+}
+
+// The status of an order after a change to it
+type OrderStatusResponse struct {
+	Status string `json:"status"`
+	// true if the transaction was sent but hasn't been mined yet, in which case the status is unchanged
+	Pending bool `json:"-"`
+}
+
+// Who holds an order's delivery token
+type TokenOwnerResponse struct {
+	Owner string `json:"owner"`
+}
+
+// One step in an order's history
+type StatusChangeResponse struct {
+	FromStatus   string    `json:"fromStatus,omitempty"`
+	ToStatus     string    `json:"toStatus"`
+	ActorAddress string    `json:"actorAddress"`
+	TxHash       string    `json:"txHash,omitempty"`
+	ChangedAt    time.Time `json:"changedAt"`
+}
+
+// An order and every status it has moved through, oldest first
+type OrderHistoryResponse struct {
+	OrderId string                 `json:"orderId"`
+	Status  string                 `json:"status"`
+	History []StatusChangeResponse `json:"history"`
+}
+
+// Proof that an order's package was dropped off
+type DeliveryEvidenceResponse struct {
+	OrderId   string `json:"orderId"`
+	Reference string `json:"reference"`
+	// the keccak256 hash of the reference, which goes on chain when the vendor claims the escrow
+	Hash       string    `json:"hash"`
+	RecordedBy string    `json:"recordedBy"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// An order, as it appears in search results. Prices are in wei.
+type OrderResponse struct {
+	OrderId       string    `json:"orderId"`
+	ItemId        string    `json:"itemId"`
+	ItemName      string    `json:"itemName"`
+	Price         int64     `json:"price"`
+	DeliveryPrice int64     `json:"deliveryPrice"`
+	BuyerAddress  string    `json:"buyerAddress"`
+	TokenAddress  string    `json:"tokenAddress,omitempty"`
+	TokenId       int64     `json:"tokenId"`
+	Status        string    `json:"status"`
+	VendorId      string    `json:"vendorId"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// One page of orders
+type OrderListResponse struct {
+	Orders []OrderResponse `json:"orders"`
+	// pass this as the Cursor of the next ListOrdersRequest. Empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// A search for orders. Every field is optional, and the filters are combined with AND.
+type ListOrdersRequest struct {
+	BuyerAddress  string
+	TokenAddress  string
+	Statuses      []string
+	ItemId        string
+	VendorId      string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// 'createdAt' or 'price'. Defaults to 'createdAt'.
+	SortBy string
+	// newest or most expensive first, unless this is set
+	Ascending bool
+	// how many orders to return. The server's default applies if this is zero.
+	Limit int
+	// the NextCursor from the previous page
+	Cursor string
+}
+
+// Places an order for the item, to be delivered to the buyer
+func (_client *Client) CreateOrder(ctx context.Context, itemId string, buyerAddress string) (*CreateOrderResponse, error) {
+	query := url.Values{}
+	query.Set("itemId", itemId)
+	query.Set("buyerAddress", buyerAddress)
+
+	response := &CreateOrderResponse{}
+	info, err := _client.do(ctx, http.MethodPost, "/order", query, nil, response)
+	if err != nil {
+		return nil, err
+	}
+	response.Pending = info.statusCode == http.StatusAccepted
+	return response, nil
+}
+
+// Places an order for the item against a customer's account. buyerAddress picks one of the addresses the customer
+// has verified; leave it empty for a custodial customer's deposit address, or the first address anyone else verified.
+func (_client *Client) CreateOrderForCustomer(ctx context.Context, itemId string, customerId string, buyerAddress string) (*CreateOrderResponse, error) {
+	query := url.Values{}
+	query.Set("itemId", itemId)
+	query.Set("customerId", customerId)
+	setIfPresent(query, "buyerAddress", buyerAddress)
+
+	response := &CreateOrderResponse{}
+	info, err := _client.do(ctx, http.MethodPost, "/order", query, nil, response)
+	if err != nil {
+		return nil, err
+	}
+	response.Pending = info.statusCode == http.StatusAccepted
+	return response, nil
+}
+
+// Pays for the order out of the customer's account. customerKey is the customer's private key, as hex, or
+// empty for custodial customers, whose key the service holds. Returns true if the payment was sent but hasn't
+// been mined yet.
+func (_client *Client) PayForOrder(ctx context.Context, orderId string, customerKey string) (bool, error) {
+	query := url.Values{}
+	setIfPresent(query, "customerKey", customerKey)
+
+	var response string
+	info, err := _client.do(ctx, http.MethodPost, "/payment/order/"+segment(orderId), query, nil, &response)
+	if err != nil {
+		return false, err
+	}
+	return info.statusCode == http.StatusAccepted, nil
+}
+
+// Accepts delivery of the order on behalf of the customer, whose private key signs for it. Leave the key empty
+// for custodial customers.
+func (_client *Client) DeliverOrder(ctx context.Context, orderId string, customerKey string) (*OrderStatusResponse, error) {
+	query := url.Values{}
+	setIfPresent(query, "customerKey", customerKey)
+	return _client.updateOrderStatus(ctx, orderId, "delivered", query)
+}
+
+// Destroys the order's delivery token. The contract only allows this after delivery.
+func (_client *Client) BurnToken(ctx context.Context, orderId string) (*OrderStatusResponse, error) {
+	return _client.updateOrderStatus(ctx, orderId, "burned", nil)
+}
+
+// Calls off an order that hasn't been paid for yet
+func (_client *Client) CancelOrder(ctx context.Context, orderId string) (*OrderStatusResponse, error) {
+	return _client.updateOrderStatus(ctx, orderId, "canceled", nil)
+}
+
+// Records where the proof that the order's package was dropped off is kept, so the vendor can claim the
+// escrow if the customer never accepts delivery
+func (_client *Client) RecordDeliveryEvidence(ctx context.Context, orderId string, reference string) (*DeliveryEvidenceResponse, error) {
+	body := struct {
+		Reference string `json:"reference"`
+	}{reference}
+
+	response := &DeliveryEvidenceResponse{}
+	if _, err := _client.do(ctx, http.MethodPost, "/order/"+segment(orderId)+"/evidence", nil, body, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Claims the vendor's payment from the order's escrow once it has expired. The order needs evidence of delivery.
+func (_client *Client) ReleaseEscrow(ctx context.Context, orderId string) (*OrderStatusResponse, error) {
+	return _client.updateOrderStatus(ctx, orderId, "released", nil)
+}
+
+// Gives the customer their money back from the order's escrow once it has expired. If customerKey is given,
+// the customer signs the refund; otherwise the vendor does.
+func (_client *Client) RefundEscrow(ctx context.Context, orderId string, customerKey string) (*OrderStatusResponse, error) {
+	query := url.Values{}
+	setIfPresent(query, "customerKey", customerKey)
+	return _client.updateOrderStatus(ctx, orderId, "refunded", query)
+}
+
+// Finds out who holds the order's delivery token, according to the contract
+func (_client *Client) GetTokenOwner(ctx context.Context, orderId string) (*TokenOwnerResponse, error) {
+	response := &TokenOwnerResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/order/"+segment(orderId)+"/owner", nil, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Lists every status the order has moved through
+func (_client *Client) GetOrderHistory(ctx context.Context, orderId string) (*OrderHistoryResponse, error) {
+	response := &OrderHistoryResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/order/"+segment(orderId)+"/history", nil, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Searches the orders. Customers only see their own.
+func (_client *Client) ListOrders(ctx context.Context, req *ListOrdersRequest) (*OrderListResponse, error) {
+	response := &OrderListResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/orders", orderListQuery(req), nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// The query string for a search for orders
+func orderListQuery(req *ListOrdersRequest) url.Values {
+	query := url.Values{}
+	setIfPresent(query, "buyerAddress", req.BuyerAddress)
+	setIfPresent(query, "tokenAddress", req.TokenAddress)
+	setIfPresent(query, "status", strings.Join(req.Statuses, ","))
+	setIfPresent(query, "itemId", req.ItemId)
+	setIfPresent(query, "vendorId", req.VendorId)
+	setIfPresent(query, "sortBy", req.SortBy)
+	setIfPresent(query, "cursor", req.Cursor)
+	if !req.CreatedAfter.IsZero() {
+		query.Set("createdAfter", req.CreatedAfter.Format(time.RFC3339))
+	}
+	if !req.CreatedBefore.IsZero() {
+		query.Set("createdBefore", req.CreatedBefore.Format(time.RFC3339))
+	}
+	if req.Ascending {
+		query.Set("sortOrder", "asc")
+	}
+	if req.Limit != 0 {
+		query.Set("limit", strconv.Itoa(req.Limit))
+	}
+	return query
+}
+
+func (_client *Client) updateOrderStatus(
+	ctx context.Context,
+	orderId string,
+	status string,
+	query url.Values,
+) (*OrderStatusResponse, error) {
+	body := struct {
+		Status string `json:"status"`
+	}{status}
+
+	response := &OrderStatusResponse{}
+	info, err := _client.do(ctx, http.MethodPost, "/order/"+segment(orderId), query, body, response)
+	if err != nil {
+		return nil, err
+	}
+	response.Pending = info.statusCode == http.StatusAccepted
+	return response, nil
+}
+
+func setIfPresent(query url.Values, key string, value string) {
+	if len(value) != 0 {
+		query.Set(key, value)
+	}
+}
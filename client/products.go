@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// A product in the catalog, for adding or updating it. Prices are in wei.
+type ProductRequest struct {
+	// only used when adding a product
+	ProductId     string `json:"productId"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Price         int64  `json:"price"`
+	ShippingPrice int64  `json:"shippingPrice"`
+	// who sells the product. Only used when adding a product with a marketplace key.
+	VendorId string `json:"vendorId,omitempty"`
+}
+
+// A product in the catalog. Prices are in wei.
+type ProductResponse struct {
+	ProductId     string `json:"productId"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Price         int64  `json:"price"`
+	ShippingPrice int64  `json:"shippingPrice"`
+	VendorId      string `json:"vendorId"`
+}
+
+// Lists everything the vendor sells
+func (_client *Client) ListProducts(ctx context.Context) ([]ProductResponse, error) {
+	response := []ProductResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/products", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Looks up a product
+func (_client *Client) GetProduct(ctx context.Context, productId string) (*ProductResponse, error) {
+	response := &ProductResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/products/"+segment(productId), nil, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Adds a product to the catalog
+func (_client *Client) CreateProduct(ctx context.Context, req *ProductRequest) (*ProductResponse, error) {
+	response := &ProductResponse{}
+	if _, err := _client.do(ctx, http.MethodPost, "/products", nil, req, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Changes the details of a product. Orders that were already placed keep the old price.
+func (_client *Client) UpdateProduct(ctx context.Context, productId string, req *ProductRequest) (*ProductResponse, error) {
+	response := &ProductResponse{}
+	if _, err := _client.do(ctx, http.MethodPut, "/products/"+segment(productId), nil, req, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Takes a product out of the catalog
+func (_client *Client) DeleteProduct(ctx context.Context, productId string) error {
+	_, err := _client.do(ctx, http.MethodDelete, "/products/"+segment(productId), nil, nil, nil)
+	return err
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// A vendor in the marketplace, for adding or changing them
+type VendorRequest struct {
+	// only used when adding a vendor. One is generated if it is left empty.
+	VendorId string `json:"vendorId,omitempty"`
+	Name     string `json:"name"`
+	// where the vendor's takings are swept to. Optional.
+	ColdWallet string `json:"coldWallet,omitempty"`
+}
+
+// A vendor in the marketplace
+type VendorResponse struct {
+	VendorId string `json:"vendorId"`
+	Name     string `json:"name"`
+	// the address the vendor signs with. It pays the gas for the vendor's contract and orders.
+	Address        string `json:"address,omitempty"`
+	DerivationPath string `json:"derivationPath,omitempty"`
+	// empty until the vendor's contract is deployed
+	ContractAddress string `json:"contractAddress,omitempty"`
+	// whether the vendor can take orders
+	Ready      bool      `json:"ready"`
+	ColdWallet string    `json:"coldWallet,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// Adds a vendor to the marketplace. They can't take orders until DeployVendorContract has been called.
+func (_client *Client) CreateVendor(ctx context.Context, req *VendorRequest) (*VendorResponse, error) {
+	response := &VendorResponse{}
+	if _, err := _client.do(ctx, http.MethodPost, "/vendors", nil, req, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Replaces a vendor's name and cold wallet
+func (_client *Client) UpdateVendor(ctx context.Context, vendorId string, req *VendorRequest) (*VendorResponse, error) {
+	response := &VendorResponse{}
+	if _, err := _client.do(ctx, http.MethodPut, "/vendors/"+segment(vendorId), nil, req, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Lists the vendors, oldest first
+func (_client *Client) ListVendors(ctx context.Context) ([]VendorResponse, error) {
+	response := []VendorResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/vendors", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Looks up a vendor
+func (_client *Client) GetVendor(ctx context.Context, vendorId string) (*VendorResponse, error) {
+	response := &VendorResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/vendors/"+segment(vendorId), nil, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Deploys the vendor's delivery contract and waits for it to be mined. The vendor's address needs ether for the gas.
+// A vendor who already has a contract only gets a new one if replace is set; their orders on the old one can't be
+// taken any further.
+func (_client *Client) DeployVendorContract(ctx context.Context, vendorId string, replace bool) (*VendorResponse, error) {
+	query := url.Values{}
+	if replace {
+		query.Set("replace", "true")
+	}
+	response := &VendorResponse{}
+	if _, err := _client.do(ctx, http.MethodPost, "/vendors/"+segment(vendorId)+"/contract", query, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// A webhook subscription to create or update
+type WebhookRequest struct {
+	Url string `json:"url"`
+	// the event types to send, e.g. 'order.paid'
+	Events []string `json:"events"`
+	// signs the deliveries. Made up by the server when creating, and left as it is when updating, if empty.
+	Secret string `json:"secret,omitempty"`
+	// whether to send events. Defaults to true when creating, and is left as it is when updating, if nil.
+	Active *bool `json:"active,omitempty"`
+}
+
+// A webhook subscription. The secret is only sent back when it is created or changed.
+type WebhookResponse struct {
+	WebhookId string    `json:"webhookId"`
+	Url       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// One event sent, or to be sent, to a subscriber
+type WebhookDeliveryResponse struct {
+	DeliveryId    int64     `json:"deliveryId"`
+	EventId       string    `json:"eventId"`
+	EventType     string    `json:"eventType"`
+	OrderId       string    `json:"orderId"`
+	Payload       string    `json:"payload"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"lastError,omitempty"`
+	ResponseCode  int       `json:"responseCode"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// Lists the webhook subscriptions
+func (_client *Client) ListWebhooks(ctx context.Context) ([]WebhookResponse, error) {
+	response := []WebhookResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/webhooks", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Looks up a webhook subscription
+func (_client *Client) GetWebhook(ctx context.Context, webhookId string) (*WebhookResponse, error) {
+	response := &WebhookResponse{}
+	if _, err := _client.do(ctx, http.MethodGet, "/webhooks/"+segment(webhookId), nil, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Subscribes a URL to order events
+func (_client *Client) CreateWebhook(ctx context.Context, req *WebhookRequest) (*WebhookResponse, error) {
+	response := &WebhookResponse{}
+	if _, err := _client.do(ctx, http.MethodPost, "/webhooks", nil, req, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Changes a webhook subscription
+func (_client *Client) UpdateWebhook(ctx context.Context, webhookId string, req *WebhookRequest) (*WebhookResponse, error) {
+	response := &WebhookResponse{}
+	if _, err := _client.do(ctx, http.MethodPut, "/webhooks/"+segment(webhookId), nil, req, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Removes a webhook subscription, along with its deliveries
+func (_client *Client) DeleteWebhook(ctx context.Context, webhookId string) error {
+	_, err := _client.do(ctx, http.MethodDelete, "/webhooks/"+segment(webhookId), nil, nil, nil)
+	return err
+}
+
+// Lists a subscription's deliveries, newest first. status ('pending', 'delivered', or 'dead') and limit
+// are optional.
+func (_client *Client) ListWebhookDeliveries(
+	ctx context.Context,
+	webhookId string,
+	status string,
+	limit int,
+) ([]WebhookDeliveryResponse, error) {
+	query := url.Values{}
+	setIfPresent(query, "status", status)
+	if limit != 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	response := []WebhookDeliveryResponse{}
+	path := "/webhooks/" + segment(webhookId) + "/deliveries"
+	if _, err := _client.do(ctx, http.MethodGet, path, query, nil, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Puts a delivery that was given up on back in the queue
+func (_client *Client) RetryWebhookDelivery(
+	ctx context.Context,
+	webhookId string,
+	deliveryId int64,
+) (*WebhookDeliveryResponse, error) {
+	response := &WebhookDeliveryResponse{}
+	path := "/webhooks/" + segment(webhookId) + "/deliveries/" + strconv.FormatInt(deliveryId, 10) + "/retry"
+	if _, err := _client.do(ctx, http.MethodPost, path, nil, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/validation"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+type deployResult struct {
+	ContractAddress string `json:"contractAddress"`
+	VendorAddress   string `json:"vendorAddress"`
+}
+
+func (result *deployResult) table() ([]string, [][]string) {
+	return []string{"CONTRACT", "VENDOR"}, [][]string{{result.ContractAddress, result.VendorAddress}}
+}
+
+type balanceResult struct {
+	Address string `json:"address"`
+	// in wei. A string, since balances don't fit in a JSON number.
+	Balance string `json:"balance"`
+}
+
+func (result *balanceResult) table() ([]string, [][]string) {
+	return []string{"ADDRESS", "BALANCE (WEI)"}, [][]string{{result.Address, result.Balance}}
+}
+
+// Deploys a fresh delivery contract owned by the vendor. Pass its address to the service with -contractAddress.
+func deployContract(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("contract deploy", flag.ExitOnError)
+	parse(flags, args, 0)
+
+	if !g.direct {
+		return errors.New("the API doesn't deploy contracts; use -direct")
+	} else if len(g.vendorKey) == 0 {
+		return errors.New("-vendorKey (or DLVCTL_VENDOR_KEY) is required to deploy a contract")
+	}
+
+	// an executor without a contract address deploys a new contract
+	noContract := ""
+	executor, err := contract.NewDeliveryContractExecutor(g.nodeUrl, strings.TrimPrefix(g.vendorKey, "0x"), &noContract)
+	if err != nil {
+		return err
+	}
+	return show(g, &deployResult{
+		ContractAddress: executor.ContractAddress.Hex(),
+		VendorAddress:   executor.VendorAddress.Hex(),
+	})
+}
+
+// Shows how much ether an account holds. This asks the node, since the API doesn't know about balances.
+func accountBalance(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("account balance", flag.ExitOnError)
+	address := parse(flags, args, 1)[0]
+
+	v := &validation.Validator{}
+	address = v.Address("address", address)
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	node, err := ethclient.DialContext(ctx, g.nodeUrl)
+	if err != nil {
+		return err
+	}
+	defer node.Close()
+
+	balance, err := node.BalanceAt(ctx, common.HexToAddress(address), nil)
+	if err != nil {
+		return err
+	}
+	return show(g, &balanceResult{Address: address, Balance: balance.String()})
+}
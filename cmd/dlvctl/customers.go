@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bdunton9323/blockchain-playground/client"
+	"github.com/bdunton9323/blockchain-playground/validation"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type customerResult client.CustomerResponse
+
+func (result *customerResult) table() ([]string, [][]string) {
+	return customerTable([]client.CustomerResponse{client.CustomerResponse(*result)})
+}
+
+type customerList []client.CustomerResponse
+
+func (list *customerList) table() ([]string, [][]string) {
+	return customerTable(*list)
+}
+
+func customerTable(customers []client.CustomerResponse) ([]string, [][]string) {
+	rows := [][]string{}
+	for _, customer := range customers {
+		rows = append(rows, []string{
+			customer.CustomerId,
+			customer.Name,
+			customer.Email,
+			strconv.FormatBool(customer.Custodial),
+			customer.Address,
+		})
+	}
+	return []string{"CUSTOMER", "NAME", "EMAIL", "CUSTODIAL", "DEPOSIT ADDRESS"}, rows
+}
+
+type addressResult client.AddressResponse
+
+func (result *addressResult) table() ([]string, [][]string) {
+	return []string{"ADDRESS", "LABEL", "VERIFIED", "CHALLENGE"},
+		[][]string{{result.Address, result.Label, strconv.FormatBool(result.Verified), strconv.Quote(result.Challenge)}}
+}
+
+// Opens a customer account
+func createCustomer(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("customer create", flag.ExitOnError)
+	name := flags.String("name", "", "Who the customer is")
+	email := flags.String("email", "", "The customer's email address")
+	custodial := flags.Bool("custodial", false, "Give the customer a deposit address from the service's HD wallet")
+	parse(flags, args, 0)
+
+	if g.direct {
+		return errors.New("only the API knows about customer accounts")
+	}
+	customer, err := g.client().CreateCustomer(ctx, &client.CustomerRequest{
+		Name:      *name,
+		Email:     *email,
+		Custodial: *custodial,
+	})
+	if err != nil {
+		return err
+	}
+	return show(g, (*customerResult)(customer))
+}
+
+// Lists the customer accounts
+func listCustomers(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("customer list", flag.ExitOnError)
+	parse(flags, args, 0)
+
+	if g.direct {
+		return errors.New("only the API knows about customer accounts")
+	}
+	customers, err := g.client().ListCustomers(ctx)
+	if err != nil {
+		return err
+	}
+	return show(g, (*customerList)(&customers))
+}
+
+// Adds an address to a customer's address book and shows the challenge to sign
+func addCustomerAddress(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("customer address", flag.ExitOnError)
+	label := flags.String("label", "", "What the customer calls the address")
+	positional := parse(flags, args, 2)
+
+	if g.direct {
+		return errors.New("only the API knows about customer accounts")
+	}
+	address, err := g.client().AddAddress(ctx, positional[0], positional[1], *label)
+	if err != nil {
+		return err
+	}
+	return show(g, (*addressResult)(address))
+}
+
+// Verifies one of a customer's addresses by signing its challenge with the address's key
+func verifyCustomerAddress(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("customer verify", flag.ExitOnError)
+	customerKey := flags.String("customerKey", os.Getenv("DLVCTL_CUSTOMER_KEY"), "The private key of the address to verify")
+	customerId := parse(flags, args, 1)[0]
+
+	if g.direct {
+		return errors.New("only the API knows about customer accounts")
+	}
+
+	v := &validation.Validator{}
+	key := v.PrivateKey("customerKey", *customerKey)
+	if err := v.Err(); err != nil {
+		return err
+	}
+	privateKey, err := crypto.HexToECDSA(key)
+	if err != nil {
+		return err
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+
+	customer, err := g.client().GetCustomer(ctx, customerId)
+	if err != nil {
+		return err
+	}
+	challenge := ""
+	for _, entry := range customer.Addresses {
+		if strings.EqualFold(entry.Address, address) {
+			challenge = entry.Challenge
+		}
+	}
+	if len(challenge) == 0 {
+		return errors.New(fmt.Sprintf("%s has no challenge to sign; add it with 'customer address' first", address))
+	}
+
+	// what personal_sign does
+	signature, err := crypto.Sign(accounts.TextHash([]byte(challenge)), privateKey)
+	if err != nil {
+		return err
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+
+	verified, err := g.client().VerifyAddress(ctx, customerId, address, hexutil.Encode(signature))
+	if err != nil {
+		return err
+	}
+	return show(g, (*addressResult)(verified))
+}
+
+// Lists the orders placed for a customer's account
+func listCustomerOrders(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("customer orders", flag.ExitOnError)
+	buyer := flags.String("buyer", "", "Only orders for this one of the customer's addresses")
+	statuses := flags.String("status", "", "Only orders in these statuses, comma separated")
+	limit := flags.Int("limit", 0, "How many orders to show")
+	cursor := flags.String("cursor", "", "The nextCursor from the previous page")
+	customerId := parse(flags, args, 1)[0]
+
+	if g.direct {
+		return errors.New("only the API knows about customer accounts")
+	}
+
+	req := &client.ListOrdersRequest{
+		BuyerAddress: *buyer,
+		Limit:        *limit,
+		Cursor:       *cursor,
+	}
+	if len(*statuses) != 0 {
+		req.Statuses = strings.Split(*statuses, ",")
+	}
+	response, err := g.client().ListCustomerOrders(ctx, customerId, req)
+	if err != nil {
+		return err
+	}
+	return show(g, (*orderList)(response))
+}
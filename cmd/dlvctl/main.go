@@ -0,0 +1,196 @@
+// dlvctl drives the delivery flow from the command line, either through the Vendor API or straight against the
+// delivery contract.
+//
+//	dlvctl [global flags] order create -item 7 -buyer 0x7E0C39B48D52ADBc8660c1B03288Ef189787A133
+//	dlvctl [global flags] order create -item 7 -customer <customerId> [-buyer <address>]
+//	dlvctl [global flags] order pay <orderId> -customerKey <key>
+//	dlvctl [global flags] order deliver <orderId> -customerKey <key>
+//	dlvctl [global flags] order burn <orderId>
+//	dlvctl [global flags] order owner <orderId>
+//	dlvctl [global flags] order list [-buyer <address>] [-status minted,paid]
+//	dlvctl [global flags] contract deploy
+//	dlvctl [global flags] account balance <address>
+//	dlvctl [global flags] customer create -name <name> [-email <email>] [-custodial]
+//	dlvctl [global flags] customer list
+//	dlvctl [global flags] customer address <customerId> <address> [-label <label>]
+//	dlvctl [global flags] customer verify <customerId> -customerKey <key>
+//	dlvctl [global flags] customer orders <customerId>
+//	dlvctl [global flags] vendor create -name <name> [-id <vendorId>] [-coldWallet <address>]
+//	dlvctl [global flags] vendor list
+//	dlvctl [global flags] vendor deploy [-replace] <vendorId>
+//	dlvctl wallet init <seedFile> -passphrase <passphrase> [-mnemonic <words>]
+//
+// Run dlvctl -h for the global flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/bdunton9323/blockchain-playground/client"
+	"github.com/bdunton9323/blockchain-playground/contract"
+	log "github.com/sirupsen/logrus"
+)
+
+// Settings shared by every command
+type globals struct {
+	// talk to the contract rather than the API
+	direct bool
+	// how to print results: 'table' or 'json'
+	output string
+
+	apiUrl       string
+	apiKey       string
+	sessionToken string
+	// narrows API requests down to one vendor
+	vendorId string
+
+	nodeUrl         string
+	vendorKey       string
+	contractAddress string
+}
+
+// A subcommand. It gets the settings and whatever is left of the command line after its name.
+type command func(ctx context.Context, g *globals, args []string) error
+
+var commands = map[string]map[string]command{
+	"order": {
+		"create":  createOrder,
+		"pay":     payForOrder,
+		"deliver": deliverOrder,
+		"burn":    burnToken,
+		"owner":   getTokenOwner,
+		"list":    listOrders,
+	},
+	"contract": {
+		"deploy": deployContract,
+	},
+	"account": {
+		"balance": accountBalance,
+	},
+	"customer": {
+		"create":  createCustomer,
+		"list":    listCustomers,
+		"address": addCustomerAddress,
+		"verify":  verifyCustomerAddress,
+		"orders":  listCustomerOrders,
+	},
+	"vendor": {
+		"create": createVendor,
+		"list":   listVendors,
+		"deploy": deployVendorContract,
+	},
+	"wallet": {
+		"init": initWallet,
+	},
+}
+
+func main() {
+	g := &globals{}
+	flag.BoolVar(&g.direct, "direct", false, "Send transactions to the contract directly instead of going through the API")
+	flag.StringVar(&g.output, "o", "table", "How to print results: 'table' or 'json'")
+	flag.StringVar(&g.apiUrl, "api", envOr("DLVCTL_API", "http://localhost:8080/api/v1"), "The Vendor API's base URL")
+	flag.StringVar(&g.apiKey, "apiKey", os.Getenv("DLVCTL_API_KEY"), "An API key for the Vendor API")
+	flag.StringVar(&g.sessionToken, "token", os.Getenv("DLVCTL_TOKEN"), "A Sign-In with Ethereum session token, instead of an API key")
+	flag.StringVar(&g.vendorId, "vendor", os.Getenv("DLVCTL_VENDOR"), "The vendor to act for, with a marketplace API key")
+	flag.StringVar(&g.nodeUrl, "node", envOr("DLVCTL_NODE", "http://172.13.3.1:8545"), "The ethereum node to use with -direct and for balances")
+	flag.StringVar(&g.vendorKey, "vendorKey", os.Getenv("DLVCTL_VENDOR_KEY"), "The vendor's private key, for -direct")
+	flag.StringVar(&g.contractAddress, "contract", os.Getenv("DLVCTL_CONTRACT"), "The delivery contract's address, for -direct")
+	verbose := flag.Bool("v", false, "Log what is happening on the chain")
+	flag.Usage = usage
+	flag.Parse()
+
+	// the contract executor is chatty, which is good for a server and noisy for a command line tool
+	log.SetOutput(os.Stderr)
+	if !*verbose {
+		log.SetLevel(log.WarnLevel)
+	}
+
+	if g.output != "table" && g.output != "json" {
+		fail(errors.New("-o must be 'table' or 'json'"))
+	}
+
+	args := flag.Args()
+	if len(args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	run, ok := commands[args[0]][args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command [%s %s]\n\n", args[0], args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	// Ctrl-C stops waiting for the API or the node
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, g, args[2:]); err != nil {
+		fail(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: dlvctl [global flags] <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  order create -item <itemId> -buyer <address>     place an order and mint its token")
+	fmt.Fprintln(os.Stderr, "  order create -item <itemId> -customer <id>       place an order for a customer account (API only)")
+	fmt.Fprintln(os.Stderr, "  order pay <orderId> -customerKey <key>           pay for an order from the customer's account")
+	fmt.Fprintln(os.Stderr, "  order deliver <orderId> -customerKey <key>       accept delivery on behalf of the customer")
+	fmt.Fprintln(os.Stderr, "  order burn <orderId>                             destroy a delivered order's token")
+	fmt.Fprintln(os.Stderr, "  order owner <orderId>                            show who holds an order's token")
+	fmt.Fprintln(os.Stderr, "  order list [-buyer <address>] [-status <list>]   search the orders (API only)")
+	fmt.Fprintln(os.Stderr, "  contract deploy                                  deploy a new delivery contract (-direct only)")
+	fmt.Fprintln(os.Stderr, "  account balance <address>                        show an account's balance in wei (asks the node)")
+	fmt.Fprintln(os.Stderr, "  customer create -name <name> [-custodial]        open a customer account (API only)")
+	fmt.Fprintln(os.Stderr, "  customer list                                    list the customer accounts (API only)")
+	fmt.Fprintln(os.Stderr, "  customer address <customerId> <address>          add an address to a customer's address book (API only)")
+	fmt.Fprintln(os.Stderr, "  customer verify <customerId> -customerKey <key>  sign an address's challenge to verify it (API only)")
+	fmt.Fprintln(os.Stderr, "  customer orders <customerId>                     list a customer's orders (API only)")
+	fmt.Fprintln(os.Stderr, "  vendor create -name <name> [-id <vendorId>]      add a vendor to the marketplace (API only)")
+	fmt.Fprintln(os.Stderr, "  vendor list                                      list the vendors (API only)")
+	fmt.Fprintln(os.Stderr, "  vendor deploy [-replace] <vendorId>              deploy a vendor's contract once their address has ether (API only)")
+	fmt.Fprintln(os.Stderr, "  wallet init <seedFile> -passphrase <passphrase>  write the HD wallet seed for custodial customers and vendors")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Run a command with -h for its flags. Global flags:")
+	flag.PrintDefaults()
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "dlvctl: %v\n", err)
+	os.Exit(1)
+}
+
+func envOr(name string, fallback string) string {
+	if value := os.Getenv(name); len(value) != 0 {
+		return value
+	}
+	return fallback
+}
+
+// Builds a client for the Vendor API
+func (g *globals) client() *client.Client {
+	api := client.NewClient(g.apiUrl)
+	api.ApiKey = g.apiKey
+	api.SessionToken = g.sessionToken
+	api.VendorId = g.vendorId
+	return api
+}
+
+// Connects to the delivery contract as the vendor. A contract address is required, since without one the
+// executor would deploy a fresh contract.
+func (g *globals) executor() (*contract.DeliveryContractExecutor, error) {
+	if len(g.vendorKey) == 0 {
+		return nil, errors.New("-vendorKey (or DLVCTL_VENDOR_KEY) is required with -direct")
+	} else if len(g.contractAddress) == 0 {
+		return nil, errors.New("-contract (or DLVCTL_CONTRACT) is required with -direct")
+	}
+	return contract.NewDeliveryContractExecutor(g.nodeUrl, strings.TrimPrefix(g.vendorKey, "0x"), &g.contractAddress)
+}
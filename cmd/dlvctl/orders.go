@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/client"
+	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/validation"
+	"github.com/google/uuid"
+)
+
+// What became of an order after a command changed it
+type orderResult struct {
+	OrderId         string `json:"orderId"`
+	Status          string `json:"status,omitempty"`
+	TokenId         string `json:"tokenId,omitempty"`
+	ContractAddress string `json:"contractAddress,omitempty"`
+	TxHash          string `json:"txHash,omitempty"`
+	// true if the transaction was sent but hasn't been mined yet
+	Pending bool `json:"pending"`
+}
+
+func (result *orderResult) table() ([]string, [][]string) {
+	return []string{"ORDER", "STATUS", "TOKEN", "CONTRACT", "TX", "PENDING"},
+		[][]string{{result.OrderId, result.Status, result.TokenId, result.ContractAddress, result.TxHash,
+			strconv.FormatBool(result.Pending)}}
+}
+
+type ownerResult struct {
+	OrderId string `json:"orderId"`
+	Owner   string `json:"owner"`
+}
+
+func (result *ownerResult) table() ([]string, [][]string) {
+	return []string{"ORDER", "OWNER"}, [][]string{{result.OrderId, result.Owner}}
+}
+
+type orderList client.OrderListResponse
+
+func (list *orderList) table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, order := range list.Orders {
+		rows = append(rows, []string{
+			order.OrderId,
+			order.ItemName,
+			order.Status,
+			order.BuyerAddress,
+			strconv.FormatInt(order.TokenId, 10),
+			strconv.FormatInt(order.Price, 10),
+			order.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return []string{"ORDER", "ITEM", "STATUS", "BUYER", "TOKEN", "PRICE", "CREATED"}, rows
+}
+
+func createOrder(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("order create", flag.ExitOnError)
+	itemId := flags.String("item", "", "The ID of the product to order")
+	buyer := flags.String("buyer", "", "The address of the customer who can accept the delivery")
+	customerId := flags.String("customer", "", "A customer account to order for; -buyer then picks one of its verified addresses (API only)")
+	price := flags.Int64("price", 0, "With -direct, the price of the goods in wei")
+	deliveryPrice := flags.Int64("deliveryPrice", 0, "With -direct, the price of shipping in wei")
+	parse(flags, args, 0)
+
+	if !g.direct {
+		var response *client.CreateOrderResponse
+		var err error
+		if len(*customerId) != 0 {
+			response, err = g.client().CreateOrderForCustomer(ctx, *itemId, *customerId, *buyer)
+		} else {
+			response, err = g.client().CreateOrder(ctx, *itemId, *buyer)
+		}
+		if err != nil {
+			return err
+		}
+		return show(g, &orderResult{
+			OrderId:         response.OrderId,
+			Status:          response.Status,
+			TokenId:         response.TokenId,
+			ContractAddress: response.ContractAddress,
+			Pending:         response.Pending,
+		})
+	}
+
+	if len(*customerId) != 0 {
+		return errors.New("only the API knows about customer accounts; use -buyer with -direct")
+	}
+
+	// there's no catalog on the chain, so the prices have to be given
+	v := &validation.Validator{}
+	buyerAddress := v.Address("buyer", *buyer)
+	if *price <= 0 {
+		v.Add("price", apierrors.CodeInvalidParameter, "-price is required with -direct")
+	}
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	executor, err := g.executor()
+	if err != nil {
+		return err
+	}
+	orderId := uuid.New().String()
+	tokenId, contractAddress, txHash, err := executor.MintNFT(ctx, &contract.Purchase{
+		OrderId:          orderId,
+		PurchasePrice:    big.NewInt(*price),
+		DeliveryPrice:    big.NewInt(*deliveryPrice),
+		RecipientAddress: buyerAddress,
+	})
+	if err != nil {
+		return err
+	}
+	return show(g, &orderResult{
+		OrderId:         orderId,
+		Status:          "minted",
+		TokenId:         tokenId.String(),
+		ContractAddress: contractAddress,
+		TxHash:          txHash,
+	})
+}
+
+func payForOrder(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("order pay", flag.ExitOnError)
+	customerKey := flags.String("customerKey", os.Getenv("DLVCTL_CUSTOMER_KEY"), "The customer's private key. Leave it out for custodial customers")
+	price := flags.Int64("price", 0, "With -direct, the price of the goods in wei")
+	orderId := parse(flags, args, 1)[0]
+
+	if !g.direct {
+		pending, err := g.client().PayForOrder(ctx, orderId, *customerKey)
+		if err != nil {
+			return err
+		}
+		status := "paid"
+		if pending {
+			status = ""
+		}
+		return show(g, &orderResult{OrderId: orderId, Status: status, Pending: pending})
+	}
+
+	if *price <= 0 {
+		return errors.New("-price is required with -direct")
+	}
+	executor, tokenId, err := g.tokenFor(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	txHash, err := executor.PayForGoods(ctx, tokenId, strings.TrimPrefix(*customerKey, "0x"), *price)
+	if err != nil {
+		return err
+	}
+	return show(g, &orderResult{OrderId: orderId, Status: "paid", TokenId: strconv.FormatInt(tokenId, 10), TxHash: txHash})
+}
+
+func deliverOrder(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("order deliver", flag.ExitOnError)
+	customerKey := flags.String("customerKey", os.Getenv("DLVCTL_CUSTOMER_KEY"), "The customer's private key. Leave it out for custodial customers")
+	deliveryPrice := flags.Int64("deliveryPrice", 0, "With -direct, the price of shipping in wei")
+	orderId := parse(flags, args, 1)[0]
+
+	if !g.direct {
+		response, err := g.client().DeliverOrder(ctx, orderId, *customerKey)
+		if err != nil {
+			return err
+		}
+		return show(g, &orderResult{OrderId: orderId, Status: response.Status, Pending: response.Pending})
+	}
+
+	executor, tokenId, err := g.tokenFor(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	txHash, err := executor.DeliverOrder(ctx, tokenId, strings.TrimPrefix(*customerKey, "0x"), *deliveryPrice)
+	if err != nil {
+		return err
+	}
+	return show(g, &orderResult{OrderId: orderId, Status: "delivered", TokenId: strconv.FormatInt(tokenId, 10), TxHash: txHash})
+}
+
+func burnToken(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("order burn", flag.ExitOnError)
+	orderId := parse(flags, args, 1)[0]
+
+	if !g.direct {
+		response, err := g.client().BurnToken(ctx, orderId)
+		if err != nil {
+			return err
+		}
+		return show(g, &orderResult{OrderId: orderId, Status: response.Status, Pending: response.Pending})
+	}
+
+	executor, err := g.executor()
+	if err != nil {
+		return err
+	}
+	txHash, err := executor.BurnDeliveryToken(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	return show(g, &orderResult{OrderId: orderId, Status: "burned", TxHash: txHash})
+}
+
+func getTokenOwner(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("order owner", flag.ExitOnError)
+	orderId := parse(flags, args, 1)[0]
+
+	if !g.direct {
+		response, err := g.client().GetTokenOwner(ctx, orderId)
+		if err != nil {
+			return err
+		}
+		return show(g, &ownerResult{OrderId: orderId, Owner: response.Owner})
+	}
+
+	executor, tokenId, err := g.tokenFor(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	owner, err := executor.GetOwner(ctx, tokenId)
+	if err != nil {
+		return err
+	}
+	return show(g, &ownerResult{OrderId: orderId, Owner: owner})
+}
+
+func listOrders(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("order list", flag.ExitOnError)
+	buyer := flags.String("buyer", "", "Only orders that can be delivered to this address")
+	statuses := flags.String("status", "", "Only orders in these statuses, comma separated")
+	itemId := flags.String("item", "", "Only orders for this product")
+	sortBy := flags.String("sortBy", "", "'createdAt' or 'price'")
+	ascending := flags.Bool("asc", false, "Oldest or cheapest first")
+	limit := flags.Int("limit", 0, "How many orders to show")
+	cursor := flags.String("cursor", "", "The nextCursor from the previous page")
+	parse(flags, args, 0)
+
+	if g.direct {
+		return errors.New("the contract doesn't keep a list of orders; leave out -direct to ask the API")
+	}
+
+	req := &client.ListOrdersRequest{
+		BuyerAddress: *buyer,
+		ItemId:       *itemId,
+		SortBy:       *sortBy,
+		Ascending:    *ascending,
+		Limit:        *limit,
+		Cursor:       *cursor,
+	}
+	if len(*statuses) != 0 {
+		req.Statuses = strings.Split(*statuses, ",")
+	}
+	response, err := g.client().ListOrders(ctx, req)
+	if err != nil {
+		return err
+	}
+	return show(g, (*orderList)(response))
+}
+
+// Connects to the contract and looks up the token that was minted for the order
+func (g *globals) tokenFor(ctx context.Context, orderId string) (*contract.DeliveryContractExecutor, int64, error) {
+	executor, err := g.executor()
+	if err != nil {
+		return nil, 0, err
+	}
+	tokenId, err := executor.GetTokenIdForOrder(ctx, orderId)
+	if err != nil {
+		return nil, 0, err
+	} else if tokenId == 0 {
+		return nil, 0, errors.New("the contract has no token for order [" + orderId + "]")
+	}
+	return executor, tokenId, nil
+}
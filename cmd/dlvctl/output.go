@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Something a command prints. JSON output is the value itself; table output comes from this.
+type tabular interface {
+	// the column headings and the rows under them
+	table() ([]string, [][]string)
+}
+
+// Prints a command's result in the format asked for
+func show(g *globals, result tabular) error {
+	if g.output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	headers, rows := result.table()
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
+
+// Parses a command's flags and returns its arguments, which must number exactly want. Flags may come before
+// or after the arguments, so both 'order pay <id> -customerKey <key>' and 'order pay -customerKey <key> <id>' work.
+func parse(flags *flag.FlagSet, args []string, want int) []string {
+	positional := []string{}
+	for {
+		// flag.ExitOnError means a bad flag never gets this far
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) != want {
+		fmt.Fprintf(os.Stderr, "%s takes %d argument(s), got %d\n", flags.Name(), want, len(positional))
+		flags.Usage()
+		os.Exit(2)
+	}
+	if want == 0 {
+		return nil
+	}
+	return positional
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strconv"
+
+	"github.com/bdunton9323/blockchain-playground/client"
+)
+
+type vendorResult client.VendorResponse
+
+func (result *vendorResult) table() ([]string, [][]string) {
+	return vendorTable([]client.VendorResponse{client.VendorResponse(*result)})
+}
+
+type vendorList []client.VendorResponse
+
+func (list *vendorList) table() ([]string, [][]string) {
+	return vendorTable(*list)
+}
+
+func vendorTable(vendors []client.VendorResponse) ([]string, [][]string) {
+	rows := [][]string{}
+	for _, vendor := range vendors {
+		rows = append(rows, []string{
+			vendor.VendorId,
+			vendor.Name,
+			vendor.Address,
+			vendor.ContractAddress,
+			strconv.FormatBool(vendor.Ready),
+		})
+	}
+	return []string{"VENDOR", "NAME", "ADDRESS", "CONTRACT", "READY"}, rows
+}
+
+// Adds a vendor to the marketplace
+func createVendor(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("vendor create", flag.ExitOnError)
+	vendorId := flags.String("id", "", "The vendor's ID. One is generated if it is left out")
+	name := flags.String("name", "", "Who the vendor is")
+	coldWallet := flags.String("coldWallet", "", "Where the vendor's takings are swept to")
+	parse(flags, args, 0)
+
+	if g.direct {
+		return errors.New("only the API knows about vendors")
+	}
+	vendor, err := g.client().CreateVendor(ctx, &client.VendorRequest{
+		VendorId:   *vendorId,
+		Name:       *name,
+		ColdWallet: *coldWallet,
+	})
+	if err != nil {
+		return err
+	}
+	return show(g, (*vendorResult)(vendor))
+}
+
+// Lists the vendors in the marketplace
+func listVendors(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("vendor list", flag.ExitOnError)
+	parse(flags, args, 0)
+
+	if g.direct {
+		return errors.New("only the API knows about vendors")
+	}
+	vendors, err := g.client().ListVendors(ctx)
+	if err != nil {
+		return err
+	}
+	return show(g, (*vendorList)(&vendors))
+}
+
+// Deploys a vendor's delivery contract, once their address has the ether to pay for it
+func deployVendorContract(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("vendor deploy", flag.ExitOnError)
+	replace := flags.Bool("replace", false, "Deploy a new contract even though the vendor has one. Their orders on the old one can't be taken any further")
+	vendorId := parse(flags, args, 1)[0]
+
+	if g.direct {
+		return errors.New("with -direct, use 'contract deploy' with the vendor's key")
+	}
+	vendor, err := g.client().DeployVendorContract(ctx, vendorId, *replace)
+	if err != nil {
+		return err
+	}
+	return show(g, (*vendorResult)(vendor))
+}
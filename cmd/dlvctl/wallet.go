@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bdunton9323/blockchain-playground/wallet"
+)
+
+type walletResult struct {
+	SeedFile string `json:"seedFile"`
+	// the address of the first custodial customer, to check a restored seed against
+	FirstAddress string `json:"firstAddress"`
+}
+
+func (result *walletResult) table() ([]string, [][]string) {
+	return []string{"SEED FILE", "FIRST ADDRESS"}, [][]string{{result.SeedFile, result.FirstAddress}}
+}
+
+// Writes the encrypted seed that the service derives custodial customers' keys from. Without -mnemonic a new
+// one is made up and printed, once; it is the only way to get the keys back if the seed file is lost.
+func initWallet(ctx context.Context, g *globals, args []string) error {
+	flags := flag.NewFlagSet("wallet init", flag.ExitOnError)
+	mnemonic := flags.String("mnemonic", "", "Restore the wallet from these words instead of making up new ones")
+	passphrase := flags.String("passphrase", os.Getenv("DLVCTL_WALLET_PASSPHRASE"), "What to encrypt the seed with; the service needs it too")
+	force := flags.Bool("force", false, "Overwrite the seed file if it already exists")
+	seedFile := parse(flags, args, 1)[0]
+
+	if len(*passphrase) == 0 {
+		return errors.New("-passphrase (or DLVCTL_WALLET_PASSPHRASE) is required")
+	}
+	// overwriting a seed loses every key derived from it
+	if _, err := os.Stat(seedFile); err == nil && !*force {
+		return errors.New(fmt.Sprintf("%s already exists; use -force to overwrite it", seedFile))
+	}
+
+	words := strings.Join(strings.Fields(*mnemonic), " ")
+	if len(words) == 0 {
+		var err error
+		if words, err = wallet.NewMnemonic(); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Write these words down and keep them somewhere safe. They won't be shown again:")
+		fmt.Fprintf(os.Stderr, "\n  %s\n\n", words)
+	}
+
+	if err := wallet.WriteSeedFile(seedFile, words, *passphrase); err != nil {
+		return err
+	}
+
+	hdWallet, err := wallet.LoadHDWallet(seedFile, *passphrase)
+	if err != nil {
+		return err
+	}
+	first, err := hdWallet.Address(wallet.AddressPath(0))
+	if err != nil {
+		return err
+	}
+	return show(g, &walletResult{SeedFile: seedFile, FirstAddress: first.Hex()})
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/validation"
+	"gopkg.in/yaml.v3"
+)
+
+// Everything the service needs to know about the environment it runs in.
+//
+// Each setting can come from a YAML or TOML file, an environment variable or a flag, and each of those overrides
+// the one before it. The environment variable is DELIVERY_ followed by the setting's path in upper snake case,
+// e.g. DELIVERY_DATABASE_HOST for database.host. Secrets can be read from a file instead, by giving its path in
+// the setting of the same name with File on the end (database.passwordFile, DELIVERY_DATABASE_PASSWORD_FILE,
+// -dbPasswordFile), which is how container orchestrators usually hand them over.
+type Config struct {
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Chain    ChainConfig    `yaml:"chain" toml:"chain"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	Tracing  TracingConfig  `yaml:"tracing" toml:"tracing"`
+	// comparing the database with the chain on a schedule
+	Reconciler ReconcilerConfig `yaml:"reconciler" toml:"reconciler"`
+	// moving the vendor's takings to cold storage
+	Treasury TreasuryConfig `yaml:"treasury" toml:"treasury"`
+	// settling payments whose customer never accepts delivery
+	Escrow EscrowConfig `yaml:"escrow" toml:"escrow"`
+	// holding the keys of customers who don't have wallets
+	Wallet WalletConfig `yaml:"wallet" toml:"wallet"`
+
+	// set by -printConfig: show the effective configuration and stop
+	PrintAndExit bool `yaml:"-" toml:"-"`
+}
+
+type ServerConfig struct {
+	HttpAddress string `yaml:"httpAddress" toml:"httpAddress" flag:"httpAddress" usage:"The address to serve the REST API on"`
+	GrpcAddress string `yaml:"grpcAddress" toml:"grpcAddress" flag:"grpcAddress" usage:"The address to serve the gRPC API on"`
+	SiweDomain  string `yaml:"siweDomain" toml:"siweDomain" flag:"siweDomain" usage:"The domain that customers' Sign-In with Ethereum messages must be addressed to, and that address challenges name"`
+	// how long to let requests and chain transactions in flight finish when asked to stop
+	ShutdownTimeout Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout" flag:"shutdownTimeout" usage:"How long to let in-flight work finish on SIGTERM before exiting, e.g. 30s"`
+	// how long a retry with the same Idempotency-Key gets the original response back
+	IdempotencyRetention Duration `yaml:"idempotencyRetention" toml:"idempotencyRetention" flag:"idempotencyRetention" usage:"How long to keep the response to a request with an Idempotency-Key for retries, e.g. 24h"`
+	IdempotencyCleanup   Duration `yaml:"idempotencyCleanup" toml:"idempotencyCleanup" flag:"idempotencyCleanup" usage:"How often to delete Idempotency-Keys that have expired, e.g. 1h"`
+}
+
+type DatabaseConfig struct {
+	// host:port
+	Host         string `yaml:"host" toml:"host" flag:"dbHost" usage:"The MariaDB server, as host:port"`
+	Name         string `yaml:"name" toml:"name" flag:"dbName" usage:"The database that holds the orders"`
+	User         string `yaml:"user" toml:"user" flag:"dbUser" usage:"The database user"`
+	Password     string `yaml:"password" toml:"password" flag:"dbPassword" usage:"The database user's password" secret:"true"`
+	PasswordFile string `yaml:"passwordFile" toml:"passwordFile" flag:"dbPasswordFile" usage:"A file holding the database password"`
+}
+
+type ChainConfig struct {
+	// the URL of the ethereum node (I used Quorum running locally)
+	NodeUrl         string `yaml:"nodeUrl" toml:"nodeUrl" flag:"nodeUrl" usage:"The URL of the ethereum node"`
+	PrivateKey      string `yaml:"privateKey" toml:"privateKey" flag:"privatekey" usage:"The private key of the microservice (i.e. the vendor)" secret:"true"`
+	PrivateKeyFile  string `yaml:"privateKeyFile" toml:"privateKeyFile" flag:"privatekeyFile" usage:"A file holding the vendor's private key"`
+	ContractAddress string `yaml:"contractAddress" toml:"contractAddress" flag:"contractAddress" usage:"The address of an existing delivery contract. If omitted, will deploy a new one"`
+	// how long to wait for a transaction to be mined before leaving it to the outbox
+	MiningTimeout Duration `yaml:"miningTimeout" toml:"miningTimeout" flag:"miningTimeout" usage:"How long to wait for a transaction to be mined, e.g. 30s"`
+	// in wei. A string because balances don't fit in an int64.
+	MinVendorBalance string `yaml:"minVendorBalance" toml:"minVendorBalance" flag:"minVendorBalance" usage:"The vendor balance, in wei, below which the service reports itself as not ready"`
+}
+
+type AuthConfig struct {
+	AdminApiKey     string `yaml:"adminApiKey" toml:"adminApiKey" flag:"adminApiKey" usage:"An API key with the vendor admin role, for creating the real API keys. Optional" secret:"true"`
+	AdminApiKeyFile string `yaml:"adminApiKeyFile" toml:"adminApiKeyFile" flag:"adminApiKeyFile" usage:"A file holding the admin API key"`
+	// how long a customer has to sign the message with a nonce in it
+	NonceTtl Duration `yaml:"nonceTtl" toml:"nonceTtl" flag:"nonceTtl" usage:"How long a Sign-In with Ethereum nonce can be used for, e.g. 10m"`
+	// a signed message that expires sooner ends the session sooner
+	SessionTtl Duration `yaml:"sessionTtl" toml:"sessionTtl" flag:"sessionTtl" usage:"How long a customer stays signed in, e.g. 24h"`
+}
+
+type TracingConfig struct {
+	OtlpEndpoint string `yaml:"otlpEndpoint" toml:"otlpEndpoint" flag:"otlpEndpoint" usage:"The host:port of an OpenTelemetry collector to send traces to over OTLP/gRPC. Optional"`
+	OtlpInsecure bool   `yaml:"otlpInsecure" toml:"otlpInsecure" flag:"otlpInsecure" usage:"Talk to the OpenTelemetry collector without TLS"`
+}
+
+type ReconcilerConfig struct {
+	// 0 turns the schedule off; reconciliations can still be asked for through the API
+	Interval Duration `yaml:"interval" toml:"interval" flag:"reconcileInterval" usage:"How often to compare the orders with the chain, e.g. 1h. 0 turns it off"`
+	Repair   bool     `yaml:"repair" toml:"repair" flag:"reconcileRepair" usage:"Repair the orders that scheduled reconciliations find the chain has moved on"`
+}
+
+type TreasuryConfig struct {
+	// sweeping is off without one
+	ColdWallet string `yaml:"coldWallet" toml:"coldWallet" flag:"coldWallet" usage:"The address to sweep the vendor's funds to. Optional"`
+	// in wei, as strings for the same reason as chain.minVendorBalance
+	SweepThreshold string   `yaml:"sweepThreshold" toml:"sweepThreshold" flag:"sweepThreshold" usage:"The vendor balance, in wei, above which funds are swept"`
+	SweepKeep      string   `yaml:"sweepKeep" toml:"sweepKeep" flag:"sweepKeep" usage:"How much a sweep leaves in the vendor's account for gas, in wei"`
+	SweepInterval  Duration `yaml:"sweepInterval" toml:"sweepInterval" flag:"sweepInterval" usage:"How often to sweep, e.g. 24h. 0 only sweeps when asked through the API"`
+}
+
+type EscrowConfig struct {
+	// set on the contract at startup if it differs. Orders that are already paid keep the expiry they were given.
+	Window Duration `yaml:"window" toml:"window" flag:"escrowWindow" usage:"How long a payment stays in escrow before it can be settled without the customer, e.g. 720h"`
+	// 0 turns the schedule off; orders can still be released or refunded one at a time through the API
+	SettleInterval Duration `yaml:"settleInterval" toml:"settleInterval" flag:"escrowSettleInterval" usage:"How often to release or refund escrow that has expired, e.g. 1h. 0 turns it off"`
+}
+
+type WalletConfig struct {
+	// custodial customers are off without one. dlvctl wallet init writes it.
+	SeedFile       string `yaml:"seedFile" toml:"seedFile" flag:"walletSeedFile" usage:"The encrypted HD wallet seed that custodial customers' keys are derived from. Optional"`
+	Passphrase     string `yaml:"passphrase" toml:"passphrase" flag:"walletPassphrase" usage:"The passphrase the seed file is encrypted with" secret:"true"`
+	PassphraseFile string `yaml:"passphraseFile" toml:"passphraseFile" flag:"walletPassphraseFile" usage:"A file holding the wallet passphrase"`
+}
+
+// A time.Duration that is written as text, like 30s or 2m, in files and environment variables
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// What the service runs with when nothing says otherwise. These suit the local setup in docker-compose.yml.
+func Defaults() *Config {
+	return &Config{
+		Server: ServerConfig{
+			HttpAddress:          ":8080",
+			GrpcAddress:          ":9090",
+			SiweDomain:           "localhost:8080",
+			ShutdownTimeout:      Duration(30 * time.Second),
+			IdempotencyRetention: Duration(24 * time.Hour),
+			IdempotencyCleanup:   Duration(time.Hour),
+		},
+		Database: DatabaseConfig{
+			Host:     "127.0.0.1:3306",
+			Name:     "orderdb",
+			User:     "db_user",
+			Password: "mysqlPassword",
+		},
+		Chain: ChainConfig{
+			NodeUrl:          "http://172.13.3.1:8545",
+			MiningTimeout:    Duration(30 * time.Second),
+			MinVendorBalance: "0",
+		},
+		Auth: AuthConfig{
+			NonceTtl:   Duration(10 * time.Minute),
+			SessionTtl: Duration(24 * time.Hour),
+		},
+		Reconciler: ReconcilerConfig{
+			Interval: Duration(time.Hour),
+		},
+		// 1 and 0.1 ether
+		Treasury: TreasuryConfig{
+			SweepThreshold: "1000000000000000000",
+			SweepKeep:      "100000000000000000",
+		},
+		Escrow: EscrowConfig{
+			Window:         Duration(30 * 24 * time.Hour),
+			SettleInterval: Duration(time.Hour),
+		},
+	}
+}
+
+// The vendor balance below which the service isn't ready. Only call this on a validated configuration.
+func (cfg *Config) MinVendorBalanceWei() *big.Int {
+	balance, _ := new(big.Int).SetString(cfg.Chain.MinVendorBalance, 10)
+	return balance
+}
+
+// The vendor balance above which funds are swept. Only call this on a validated configuration.
+func (cfg *Config) SweepThresholdWei() *big.Int {
+	threshold, _ := new(big.Int).SetString(cfg.Treasury.SweepThreshold, 10)
+	return threshold
+}
+
+// What a sweep leaves behind. Only call this on a validated configuration.
+func (cfg *Config) SweepKeepWei() *big.Int {
+	keep, _ := new(big.Int).SetString(cfg.Treasury.SweepKeep, 10)
+	return keep
+}
+
+// Checks every setting and reports everything that is wrong at once. The vendor's private key is put in the
+// form the contract executor wants.
+func (cfg *Config) Validate() error {
+	v := &validation.Validator{}
+
+	address(v, "server.httpAddress", cfg.Server.HttpAddress)
+	address(v, "server.grpcAddress", cfg.Server.GrpcAddress)
+	v.Required("server.siweDomain", cfg.Server.SiweDomain)
+	if cfg.Server.ShutdownTimeout <= 0 {
+		v.Add("server.shutdownTimeout", apierrors.CodeInvalidParameter, "server.shutdownTimeout must be more than zero")
+	}
+	if cfg.Server.IdempotencyRetention <= 0 {
+		v.Add("server.idempotencyRetention", apierrors.CodeInvalidParameter, "server.idempotencyRetention must be more than zero")
+	}
+	if cfg.Server.IdempotencyCleanup <= 0 {
+		v.Add("server.idempotencyCleanup", apierrors.CodeInvalidParameter, "server.idempotencyCleanup must be more than zero")
+	}
+
+	if v.Required("database.host", cfg.Database.Host) {
+		address(v, "database.host", cfg.Database.Host)
+	}
+	v.Required("database.name", cfg.Database.Name)
+	v.Required("database.user", cfg.Database.User)
+
+	if v.Required("chain.nodeUrl", cfg.Chain.NodeUrl) {
+		nodeUrl, err := url.Parse(cfg.Chain.NodeUrl)
+		if err != nil || (nodeUrl.Scheme != "http" && nodeUrl.Scheme != "https" && nodeUrl.Scheme != "ws" && nodeUrl.Scheme != "wss") {
+			v.Add("chain.nodeUrl", apierrors.CodeInvalidParameter, "chain.nodeUrl must be an http(s) or ws(s) URL")
+		}
+	}
+	cfg.Chain.PrivateKey = v.PrivateKey("chain.privateKey", cfg.Chain.PrivateKey)
+	if len(cfg.Chain.ContractAddress) != 0 {
+		v.Address("chain.contractAddress", cfg.Chain.ContractAddress)
+	}
+	if cfg.Chain.MiningTimeout <= 0 {
+		v.Add("chain.miningTimeout", apierrors.CodeInvalidParameter, "chain.miningTimeout must be more than zero")
+	}
+	wei(v, "chain.minVendorBalance", cfg.Chain.MinVendorBalance)
+
+	if cfg.Auth.NonceTtl <= 0 {
+		v.Add("auth.nonceTtl", apierrors.CodeInvalidParameter, "auth.nonceTtl must be more than zero")
+	}
+	if cfg.Auth.SessionTtl <= 0 {
+		v.Add("auth.sessionTtl", apierrors.CodeInvalidParameter, "auth.sessionTtl must be more than zero")
+	}
+
+	if cfg.Reconciler.Interval < 0 {
+		v.Add("reconciler.interval", apierrors.CodeInvalidParameter, "reconciler.interval can't be negative")
+	}
+
+	if len(cfg.Treasury.ColdWallet) != 0 {
+		cfg.Treasury.ColdWallet = v.Address("treasury.coldWallet", cfg.Treasury.ColdWallet)
+	}
+	threshold := wei(v, "treasury.sweepThreshold", cfg.Treasury.SweepThreshold)
+	keep := wei(v, "treasury.sweepKeep", cfg.Treasury.SweepKeep)
+	if threshold != nil && keep != nil && keep.Cmp(threshold) > 0 {
+		v.Add("treasury.sweepKeep", apierrors.CodeInvalidParameter, "treasury.sweepKeep can't be more than treasury.sweepThreshold")
+	}
+	if cfg.Treasury.SweepInterval < 0 {
+		v.Add("treasury.sweepInterval", apierrors.CodeInvalidParameter, "treasury.sweepInterval can't be negative")
+	}
+
+	if cfg.Escrow.Window < Duration(time.Second) {
+		v.Add("escrow.window", apierrors.CodeInvalidParameter, "escrow.window must be at least a second")
+	}
+	if cfg.Escrow.SettleInterval < 0 {
+		v.Add("escrow.settleInterval", apierrors.CodeInvalidParameter, "escrow.settleInterval can't be negative")
+	}
+
+	if len(cfg.Wallet.SeedFile) != 0 {
+		v.Required("wallet.passphrase", cfg.Wallet.Passphrase)
+	}
+
+	if len(cfg.Tracing.OtlpEndpoint) != 0 {
+		address(v, "tracing.otlpEndpoint", cfg.Tracing.OtlpEndpoint)
+	}
+
+	return v.Err()
+}
+
+// Checks that the value is a whole, non-negative number of wei. Returns it, or nil if it isn't one.
+func wei(v *validation.Validator, field string, value string) *big.Int {
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok || amount.Sign() < 0 {
+		v.Add(field, apierrors.CodeInvalidParameter, "%s must be a whole number of wei, not [%s]", field, value)
+		return nil
+	}
+	return amount
+}
+
+// Checks that the value is a host:port, where the host may be left out to mean every interface
+func address(v *validation.Validator, field string, value string) {
+	if !v.Required(field, value) {
+		return
+	}
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		v.Add(field, apierrors.CodeInvalidParameter, "%s must be host:port or :port", field)
+	}
+}
+
+// The configuration as YAML, with the secrets blanked out, for the logs
+func (cfg *Config) Redacted() string {
+	redacted := *cfg
+	redact(reflect.ValueOf(&redacted).Elem())
+
+	out, err := yaml.Marshal(&redacted)
+	if err != nil {
+		return fmt.Sprintf("could not print the configuration: %v", err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func redact(section reflect.Value) {
+	for i := 0; i < section.NumField(); i++ {
+		field := section.Field(i)
+		if field.Kind() == reflect.Struct {
+			redact(field)
+		} else if section.Type().Field(i).Tag.Get("secret") == "true" && field.Len() != 0 {
+			field.SetString("[redacted]")
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// environment variables that set configuration start with this
+var EnvPrefix = "DELIVERY_"
+
+// One setting, wherever it lives in the Config
+type setting struct {
+	// e.g. database.host
+	path   string
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// Builds the configuration from the defaults, then the file named by -config (or DELIVERY_CONFIG), then the
+// environment, then the rest of the command line, and validates the result. args are the command line
+// arguments without the program name.
+//
+// Returns flag.ErrHelp if -h was given, after the usage has been printed.
+func Load(args []string) (*Config, error) {
+	cfg := Defaults()
+	settings := settingsOf(cfg)
+
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv(EnvPrefix+"CONFIG"), "A YAML or TOML file to read the configuration from")
+	printConfig := flags.Bool("printConfig", false, "Print the effective configuration, with secrets hidden, and exit")
+
+	// flags are only applied once the file and the environment have been read, so remember what was given
+	given := map[string]string{}
+	for _, s := range settings {
+		flags.Var(&flagValue{setting: s, given: given}, s.flag, s.usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if len(*configFile) != 0 {
+		if err := readFile(cfg, *configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if raw, ok := os.LookupEnv(s.env); ok {
+			if err := set(s.value, raw); err != nil {
+				return nil, errors.New(fmt.Sprintf("%s: %v", s.env, err))
+			}
+		}
+	}
+	for _, s := range settings {
+		if raw, ok := given[s.flag]; ok {
+			if err := set(s.value, raw); err != nil {
+				return nil, errors.New(fmt.Sprintf("-%s: %v", s.flag, err))
+			}
+		}
+	}
+
+	if err := readSecretFiles(settings); err != nil {
+		return nil, err
+	}
+
+	cfg.PrintAndExit = *printConfig
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid configuration: %v", err))
+	}
+	return cfg, nil
+}
+
+// Reads a YAML or TOML file over the configuration, depending on its extension. Settings the file doesn't
+// mention keep their values; settings the service doesn't know about are an error, since they are probably
+// typos.
+func readFile(cfg *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.New(fmt.Sprintf("could not read the configuration file: %v", err))
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(file)
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+	case ".toml":
+		err = toml.NewDecoder(file).DisallowUnknownFields().Decode(cfg)
+	default:
+		return errors.New(fmt.Sprintf("the configuration file [%s] must end in .yaml, .yml or .toml", path))
+	}
+	if err != nil {
+		return errors.New(fmt.Sprintf("could not parse the configuration file [%s]: %v", path, err))
+	}
+	return nil
+}
+
+// Replaces each secret with the contents of its file, if one was given
+func readSecretFiles(settings []*setting) error {
+	byPath := map[string]*setting{}
+	for _, s := range settings {
+		byPath[s.path] = s
+	}
+
+	for _, s := range settings {
+		if !s.secret {
+			continue
+		}
+		path := byPath[s.path+"File"].value.String()
+		if len(path) == 0 {
+			continue
+		}
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return errors.New(fmt.Sprintf("could not read %sFile: %v", s.path, err))
+		}
+		// editors like to end files with a newline
+		s.value.SetString(strings.TrimSpace(string(contents)))
+	}
+	return nil
+}
+
+// Lists every setting in the configuration
+func settingsOf(cfg *Config) []*setting {
+	settings := []*setting{}
+	root := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		sectionField := root.Type().Field(i)
+		if sectionField.Type.Kind() != reflect.Struct {
+			continue
+		}
+		section := root.Field(i)
+		sectionName := sectionField.Tag.Get("yaml")
+
+		for j := 0; j < section.NumField(); j++ {
+			field := section.Type().Field(j)
+			name := field.Tag.Get("yaml")
+			settings = append(settings, &setting{
+				path:   sectionName + "." + name,
+				env:    EnvPrefix + snakeCase(sectionName) + "_" + snakeCase(name),
+				flag:   field.Tag.Get("flag"),
+				usage:  field.Tag.Get("usage"),
+				secret: field.Tag.Get("secret") == "true",
+				value:  section.Field(j),
+			})
+		}
+	}
+	return settings
+}
+
+// Sets a setting from its text form
+func set(value reflect.Value, raw string) error {
+	if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(raw))
+	}
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New(fmt.Sprintf("[%s] is not true or false", raw))
+		}
+		value.SetBool(parsed)
+	default:
+		return errors.New(fmt.Sprintf("settings of type %s are not supported", value.Type()))
+	}
+	return nil
+}
+
+// otlpEndpoint -> OTLP_ENDPOINT
+func snakeCase(name string) string {
+	var out strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) && i != 0 {
+			out.WriteRune('_')
+		}
+		out.WriteRune(unicode.ToUpper(r))
+	}
+	return out.String()
+}
+
+// A command line flag for a setting. It only records the value it was given, so that it can be applied after
+// the file and the environment.
+type flagValue struct {
+	setting *setting
+	given   map[string]string
+}
+
+func (f *flagValue) String() string {
+	if f.setting == nil || f.setting.secret {
+		return ""
+	}
+	if marshaler, ok := f.setting.value.Interface().(encoding.TextMarshaler); ok {
+		text, _ := marshaler.MarshalText()
+		return string(text)
+	}
+	return fmt.Sprint(f.setting.value.Interface())
+}
+
+func (f *flagValue) Set(raw string) error {
+	// make sure it parses now, so mistakes are reported next to the flag
+	if err := set(reflect.New(f.setting.value.Type()).Elem(), raw); err != nil {
+		return err
+	}
+	f.given[f.setting.flag] = raw
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.setting != nil && f.setting.value.Kind() == reflect.Bool
+}
@@ -17,10 +17,17 @@ import "../node_modules/@openzeppelin/contracts/utils/Counters.sol";
  * the shipping price. The customer now owns the token and can burn it if they desire.
  * 
  * This contract manages the whole collection of delivery tokens that the vendor has minted.
+ *
+ * So that money can't sit in escrow forever when the customer never buys the token, the escrow
+ * expires a while after payment. After that, the vendor can release it to themselves by handing
+ * the token to the customer along with evidence that the package was dropped off, or the escrow
+ * can be refunded to the customer as long as the vendor still has the token.
  */
 contract DeliveryContract is ERC721, ERC721Burnable {
     event NftBought(address _seller, address _buyer, uint256 _price);
     event NFTMinted(uint256 _tokenId);
+    event EscrowReleased(uint256 _tokenId, bytes32 _evidence, uint256 _amount);
+    event EscrowRefunded(uint256 _tokenId, address _recipient, uint256 _amount);
 
     using Counters for Counters.Counter;
     Counters.Counter private _tokenIdCounter;
@@ -38,11 +45,25 @@ contract DeliveryContract is ERC721, ERC721Burnable {
     mapping(uint256 => Order) private orderByTokenId;
     mapping(string => uint256) private tokenIdByOrderId;
     mapping(uint256 => bool) private paidByTokenId;
+    mapping(uint256 => uint256) private escrowExpiresAtByTokenId;
+    mapping(uint256 => bool) private settledByTokenId;
+
+    // how long a payment stays in escrow before it can be settled without the customer, in seconds
+    uint256 public escrowWindow = 30 days;
 
     constructor() ERC721("DeliveryToken", "DLV") public {
         vendor = msg.sender;
     }
 
+    /**
+     * Changes how long escrow lasts for orders that are paid from now on. Orders that are
+     * already paid keep the expiry they were given.
+     */
+    function setEscrowWindow(uint256 window) public {
+        require(msg.sender == vendor, "Only the vendor can change the escrow window");
+        escrowWindow = window;
+    }
+
     /**
      * Minting this token represents the customer purchasing something from the vendor for 
      * delivery.
@@ -90,6 +111,75 @@ contract DeliveryContract is ERC721, ERC721Burnable {
         require(msg.value == order.orderPrice, "Must pay for the item in full");
 
         paidByTokenId[tokenId] = true;
+        escrowExpiresAtByTokenId[tokenId] = block.timestamp + escrowWindow;
+    }
+
+    /**
+     * When the escrow for the order can be settled without the customer, as a unix timestamp.
+     * Zero if the order hasn't been paid for.
+     */
+    function escrowExpiresAt(uint256 tokenId) public view returns (uint256) {
+        return escrowExpiresAtByTokenId[tokenId];
+    }
+
+    /**
+     * Whether the escrow was released or refunded after it expired
+     */
+    function isSettled(uint256 tokenId) public view returns (bool) {
+        return settledByTokenId[tokenId];
+    }
+
+    /**
+     * The vendor claims the escrow for an order whose customer never bought the token, once the
+     * escrow has expired. The token goes to the customer, as if they had accepted delivery, and
+     * evidence identifies the proof of delivery the vendor holds (e.g. a hash of the courier's
+     * drop-off record). The customer doesn't pay shipping.
+     */
+    function releaseEscrow(uint256 tokenId, bytes32 evidence) public {
+        require(msg.sender == vendor, "Only the vendor can release the escrow");
+        require(evidence != bytes32(0), "Releasing the escrow needs evidence of delivery");
+
+        Order memory order = orderByTokenId[tokenId];
+        requireExpiredEscrow(tokenId);
+
+        settledByTokenId[tokenId] = true;
+        _transfer(vendor, order.allowedRecipient, tokenId);
+
+        address payable tokenOwner = payable(vendor);
+        tokenOwner.transfer(order.orderPrice);
+
+        emit EscrowReleased(tokenId, evidence, order.orderPrice);
+    }
+
+    /**
+     * Gives the customer their money back for an order that was never delivered, once the escrow
+     * has expired. Either the vendor or the customer can ask for it. The token stays with the
+     * vendor and can no longer be bought.
+     */
+    function refundEscrow(uint256 tokenId) public {
+        Order memory order = orderByTokenId[tokenId];
+        require(msg.sender == vendor || msg.sender == order.allowedRecipient,
+            "Only the vendor or the recipient can refund the escrow");
+        requireExpiredEscrow(tokenId);
+
+        settledByTokenId[tokenId] = true;
+
+        address payable recipient = payable(order.allowedRecipient);
+        recipient.transfer(order.orderPrice);
+
+        emit EscrowRefunded(tokenId, order.allowedRecipient, order.orderPrice);
+    }
+
+    /**
+     * The checks that releasing and refunding have in common: the money is still in escrow and
+     * its time is up
+     */
+    function requireExpiredEscrow(uint256 tokenId) private view {
+        require(_exists(tokenId), "That token does not exist");
+        require(paidByTokenId[tokenId] == true, "The order has not been paid for");
+        require(settledByTokenId[tokenId] == false, "The escrow was settled already");
+        require(ownerOf(tokenId) == vendor, "The order was delivered already");
+        require(block.timestamp >= escrowExpiresAtByTokenId[tokenId], "The escrow has not expired yet");
     }
 
     /**
@@ -105,6 +195,7 @@ contract DeliveryContract is ERC721, ERC721Burnable {
     function buy(uint256 tokenId) external payable {
         require(_exists(tokenId), "That token does not exist");
         require(paidByTokenId[tokenId] == true, "Order must be paid in full before delivery");
+        require(settledByTokenId[tokenId] == false, "The escrow was settled already");
 
         Order memory order = orderByTokenId[tokenId];
         require(msg.value == order.deliveryPrice, "Must pay the shipping costs to accept delivery");
@@ -114,7 +205,7 @@ contract DeliveryContract is ERC721, ERC721Burnable {
 
         // send the shipping cost plus delivery cost to the seller
         // if the state machine is working, there is guaranteed to be enough money in the contract
-        address payable tokenOwner = address(uint256(vendor));
+        address payable tokenOwner = payable(vendor);
         tokenOwner.transfer(msg.value + order.orderPrice);
 
         emit NftBought(vendor, msg.sender, msg.value);
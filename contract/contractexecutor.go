@@ -6,15 +6,32 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/bdunton9323/blockchain-playground/metrics"
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+// Returned when a transaction was mined but the contract rejected it
+var ErrTransactionReverted = errors.New("transaction reverted")
+
+// Returned when a transaction has not been mined within the allotted time. It may still be mined later.
+var ErrTransactionNotMined = errors.New("transaction was not mined")
+
+// How long to wait for a transaction to be mined before giving up on it
+var MiningTimeout = 30 * time.Second
+
 // instance variables needed by the contract executor
 type DeliveryContractExecutor struct {
 	Client           *ethclient.Client
@@ -35,7 +52,7 @@ func NewDeliveryContractExecutor(
 	contractAddress *string,
 ) (*DeliveryContractExecutor, error) {
 
-	client, err := ethclient.Dial("http://172.13.3.1:8545")
+	client, err := dial(nodeUrl)
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("Could not connect to ethereum node: %v", err))
 	}
@@ -65,7 +82,7 @@ func NewDeliveryContractExecutor(
 		executor.ContractAddress = &addr
 		executor.ContractInstance = contractInstance
 	} else {
-		newAddr, contract, err := executor.deployContract()
+		newAddr, contract, err := executor.deployContract(context.Background())
 		if err != nil {
 			return nil, err
 		}
@@ -80,22 +97,40 @@ func NewDeliveryContractExecutor(
 	return &executor, nil
 }
 
-func (_exec *DeliveryContractExecutor) deployContract() (*common.Address, *DeliveryContract, error) {
-	nonce, err := _exec.getNonce(_exec.ServerPrivateKey)
+// Connects to the node. Every JSON-RPC call is traced as part of whatever span its context carries.
+func dial(nodeUrl string) (*ethclient.Client, error) {
+	if !strings.HasPrefix(nodeUrl, "http") {
+		// websockets and IPC don't go through an http.Client
+		return ethclient.Dial(nodeUrl)
+	}
+	transport := otelhttp.NewTransport(http.DefaultTransport,
+		otelhttp.WithSpanNameFormatter(func(_ string, _ *http.Request) string { return "ethereum JSON-RPC" }))
+	rpcClient, err := rpc.DialHTTPWithClient(nodeUrl, &http.Client{Transport: transport})
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
+	return ethclient.NewClient(rpcClient), nil
+}
 
-	txOpts := bind.NewKeyedTransactor(_exec.ServerPrivateKey)
-	txOpts.Nonce = nonce
-
-	contractAddress, tx, tokenContract, err := DeployDeliveryContract(txOpts, _exec.Client)
+func (_exec *DeliveryContractExecutor) deployContract(ctx context.Context) (_ *common.Address, _ *DeliveryContract, err error) {
+	ctx, span := tracing.Start(ctx, "contract.Deploy")
+	defer tracing.End(span, &err)
+	defer observe("deploy", time.Now(), &err)
+
+	var contractAddress common.Address
+	var tokenContract *DeliveryContract
+	tx, err := _exec.transact(ctx, _exec.ServerPrivateKey, func(txOpts *bind.TransactOpts) (tx *types.Transaction, err error) {
+		contractAddress, tx, tokenContract, err = DeployDeliveryContract(txOpts, _exec.Client)
+		return tx, err
+	})
 	if err != nil {
 		return nil, nil, errors.New(fmt.Sprintf("Error deploying token contract: %v", err))
 	}
 	log.Infof("Tx sent with ID [%s] to create contract", tx.Hash().Hex())
 
-	err = _exec.waitForMining(tx.Hash(), 30)
+	span.SetAttributes(tracing.TxHash.String(tx.Hash().Hex()))
+
+	err = _exec.waitForMining(ctx, tx.Hash())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -103,98 +138,139 @@ func (_exec *DeliveryContractExecutor) deployContract() (*common.Address, *Deliv
 	return &contractAddress, tokenContract, nil
 }
 
-// Creates a new token in the delivery contract
-// returns (tokenId, contract address, error)
-func (_exec *DeliveryContractExecutor) MintNFT(purchase *Purchase) (*big.Int, string, error) {
-	nonce, err := _exec.getNonce(_exec.ServerPrivateKey)
+// Creates a new token in the delivery contract and waits for it to be mined
+// returns (tokenId, contract address, transaction hash, error)
+func (_exec *DeliveryContractExecutor) MintNFT(ctx context.Context, purchase *Purchase) (*big.Int, string, string, error) {
+	txHash, err := _exec.SubmitMint(ctx, purchase)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	err = _exec.WaitForTransaction(ctx, txHash)
 	if err != nil {
-		return nil, "", err
+		return nil, "", "", err
 	}
 
-	txOpts := bind.NewKeyedTransactor(_exec.ServerPrivateKey)
-	txOpts.Nonce = nonce
+	tokenId, err := _exec.ContractInstance.GetTokenIdForOrder(&bind.CallOpts{Context: ctx}, purchase.OrderId)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return tokenId, _exec.ContractAddress.Hex(), txHash, nil
+}
+
+// Sends the transaction that mints a new token without waiting for it to be mined.
+// Returns the hash of the transaction.
+func (_exec *DeliveryContractExecutor) SubmitMint(ctx context.Context, purchase *Purchase) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "contract.SubmitMint", tracing.OrderId.String(purchase.OrderId))
+	defer tracing.End(span, &err)
+	defer observe("mint", time.Now(), &err)
+
 	recipientAddress := common.HexToAddress(purchase.RecipientAddress)
 
 	log.Infof("Minting a token token to be delivered to [%v] for a cost of [%v]",
 		recipientAddress.Hex(),
 		purchase.PurchasePrice.Int64()+purchase.DeliveryPrice.Int64())
 
-	tx, err := _exec.ContractInstance.MintToken(
-		txOpts,
-		recipientAddress,
-		purchase.DeliveryPrice,
-		purchase.PurchasePrice,
-		purchase.OrderId)
+	tx, err := _exec.transact(ctx, _exec.ServerPrivateKey, func(txOpts *bind.TransactOpts) (*types.Transaction, error) {
+		return _exec.ContractInstance.MintToken(
+			txOpts,
+			recipientAddress,
+			purchase.DeliveryPrice,
+			purchase.PurchasePrice,
+			purchase.OrderId)
+	})
 
 	if err != nil {
-		return nil, "", err
+		return "", classifySubmitError(err)
 	}
 
-	err = _exec.waitForMining(tx.Hash(), 30)
-	if err != nil {
-		return nil, "", err
-	}
+	span.SetAttributes(tracing.TxHash.String(tx.Hash().Hex()))
+	log.Infof("Tx sent with ID [%s] to mint a token for order [%s]", tx.Hash().Hex(), purchase.OrderId)
+	return tx.Hash().Hex(), nil
+}
 
-	tokenId, err := _exec.ContractInstance.GetTokenIdForOrder(nil, purchase.OrderId)
+// Deposit ether from the customer into the contract and wait for it to be mined.
+// Returns the hash of the transaction.
+func (_exec *DeliveryContractExecutor) PayForGoods(
+	ctx context.Context,
+	tokenId int64,
+	buyerPrivateKey string,
+	price int64,
+) (string, error) {
+	txHash, err := _exec.SubmitPayment(ctx, tokenId, buyerPrivateKey, price)
 	if err != nil {
-		return nil, "", err
+		return "", err
 	}
-
-	return tokenId, _exec.ContractAddress.Hex(), nil
+	return txHash, _exec.WaitForTransaction(ctx, txHash)
 }
 
-// Deposit ether from the customer into the contract
-func (_exec *DeliveryContractExecutor) PayForGoods(
+// Sends the transaction that deposits the customer's ether into the contract without waiting
+// for it to be mined. Returns the hash of the transaction.
+func (_exec *DeliveryContractExecutor) SubmitPayment(
+	ctx context.Context,
 	tokenId int64,
 	buyerPrivateKey string,
 	price int64,
-) error {
+) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "contract.SubmitPayment", tracing.TokenId.Int64(tokenId))
+	defer tracing.End(span, &err)
+	defer observe("pay", time.Now(), &err)
+
 	privKey, err := crypto.HexToECDSA(buyerPrivateKey)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	txOpts, buyerAddress, err := _exec.buildTxOptsForPrivateKey(privKey)
-
-	// the amount of Ether being sent in the request, in wei
-	txOpts.Value = big.NewInt(price)
+	_exec.printBalance(ctx, "customer", _exec.getAddressFromKey(privKey))
+	_exec.printBalance(ctx, "vendor", _exec.VendorAddress)
+	_exec.printBalance(ctx, "contract", _exec.ContractAddress)
 
-	_exec.printBalance("customer", buyerAddress)
-	_exec.printBalance("vendor", _exec.VendorAddress)
-	_exec.printBalance("contract", _exec.ContractAddress)
-
-	tx, err := _exec.ContractInstance.PayForGoods(txOpts, big.NewInt(tokenId))
+	tx, err := _exec.transact(ctx, privKey, func(txOpts *bind.TransactOpts) (*types.Transaction, error) {
+		// the amount of Ether being sent in the request, in wei
+		txOpts.Value = big.NewInt(price)
+		return _exec.ContractInstance.PayForGoods(txOpts, big.NewInt(tokenId))
+	})
 	if err != nil {
-		return errors.New(fmt.Sprintf("Error paying for delivery: %v", err))
+		return "", fmt.Errorf("Error paying for delivery: %w", classifySubmitError(err))
 	}
+	span.SetAttributes(tracing.TxHash.String(tx.Hash().Hex()))
 	log.Infof("Tx sent with ID [%s] to pay [%d] for the order", tx.Hash().Hex(), price)
-	err = _exec.waitForMining(tx.Hash(), 30)
+	return tx.Hash().Hex(), nil
+}
+
+// The customer buys the token from the vendor, which accepts delivery and releases the escrowed
+// payment to the vendor. Waits for it to be mined and returns the hash of the transaction.
+func (_exec *DeliveryContractExecutor) DeliverOrder(
+	ctx context.Context,
+	tokenId int64,
+	buyerPrivateKey string,
+	deliveryPrice int64,
+) (string, error) {
+	txHash, err := _exec.SubmitDelivery(ctx, tokenId, buyerPrivateKey, deliveryPrice)
 	if err != nil {
-		return err
+		return "", err
 	}
-
-	_exec.printBalance("customer", buyerAddress)
-	_exec.printBalance("vendor", _exec.VendorAddress)
-	_exec.printBalance("contract", _exec.ContractAddress)
-	return nil
+	return txHash, _exec.WaitForTransaction(ctx, txHash)
 }
 
-func (_exec *DeliveryContractExecutor) DeliverOrder(
+// Sends the transaction that buys the token from the vendor without waiting for it to be mined.
+// Returns the hash of the transaction.
+func (_exec *DeliveryContractExecutor) SubmitDelivery(
+	ctx context.Context,
 	tokenId int64,
 	buyerPrivateKey string,
 	deliveryPrice int64,
-) error {
+) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "contract.SubmitDelivery", tracing.TokenId.Int64(tokenId))
+	defer tracing.End(span, &err)
+	defer observe("buy", time.Now(), &err)
 
 	privKey, err := crypto.HexToECDSA(buyerPrivateKey)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	txOpts, buyerAddress, err := _exec.buildTxOptsForPrivateKey(privKey)
-
-	// the amount of Ether being sent in the request, in wei
-	txOpts.Value = big.NewInt(deliveryPrice)
-
 	// I'm using Quorum, which is configured to be gasless, so this doesn't work
 	// gasPrice, err := client.SuggestGasPrice(context.Background())
 	// if err != nil {
@@ -203,29 +279,61 @@ func (_exec *DeliveryContractExecutor) DeliverOrder(
 	// txOpts.GasLimit = uint64(300000) // in gas units
 	// txOpts.GasPrice = gasPrice
 
-	_exec.printBalance("customer", buyerAddress)
-	_exec.printBalance("vendor", _exec.VendorAddress)
-	_exec.printBalance("contract", _exec.ContractAddress)
+	_exec.printBalance(ctx, "customer", _exec.getAddressFromKey(privKey))
+	_exec.printBalance(ctx, "vendor", _exec.VendorAddress)
+	_exec.printBalance(ctx, "contract", _exec.ContractAddress)
 
-	tx, err := _exec.ContractInstance.Buy(txOpts, big.NewInt(tokenId))
+	tx, err := _exec.transact(ctx, privKey, func(txOpts *bind.TransactOpts) (*types.Transaction, error) {
+		// the amount of Ether being sent in the request, in wei
+		txOpts.Value = big.NewInt(deliveryPrice)
+		return _exec.ContractInstance.Buy(txOpts, big.NewInt(tokenId))
+	})
 	if err != nil {
-		return errors.New(fmt.Sprintf("Error paying for delivery: %v", err))
+		return "", fmt.Errorf("Error paying for delivery: %w", classifySubmitError(err))
 	}
+	span.SetAttributes(tracing.TxHash.String(tx.Hash().Hex()))
 	log.Infof("Tx sent with ID [%s] to buy token [%d]", tx.Hash().Hex(), tokenId)
-	err = _exec.waitForMining(tx.Hash(), 30)
+	return tx.Hash().Hex(), nil
+}
+
+// the gas a plain transfer of ether to an account costs
+var TransferGas uint64 = 21000
+
+// Sends ether from the vendor's account to another account at the given gas price, without waiting for it to
+// be mined. Returns the hash of the transaction.
+func (_exec *DeliveryContractExecutor) SubmitTransfer(
+	ctx context.Context,
+	to common.Address,
+	amount *big.Int,
+	gasPrice *big.Int,
+) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "contract.SubmitTransfer")
+	defer tracing.End(span, &err)
+	defer observe("transfer", time.Now(), &err)
+
+	signed, err := _exec.transact(ctx, _exec.ServerPrivateKey, func(txOpts *bind.TransactOpts) (*types.Transaction, error) {
+		tx := types.NewTransaction(txOpts.Nonce.Uint64(), to, amount, TransferGas, gasPrice, nil)
+		signed, err := txOpts.Signer(txOpts.From, tx)
+		if err != nil {
+			return nil, err
+		}
+		return signed, _exec.Client.SendTransaction(ctx, signed)
+	})
 	if err != nil {
-		return err
+		return "", classifySubmitError(err)
 	}
 
-	_exec.printBalance("customer", buyerAddress)
-	_exec.printBalance("vendor", _exec.VendorAddress)
-	_exec.printBalance("contract", _exec.ContractAddress)
-	return nil
+	span.SetAttributes(tracing.TxHash.String(signed.Hash().Hex()))
+	log.Infof("Tx sent with ID [%s] to transfer [%s] wei to [%s]", signed.Hash().Hex(), amount, to.Hex())
+	return signed.Hash().Hex(), nil
 }
 
 // Returns address of the the token's current owner
-func (_exec *DeliveryContractExecutor) GetOwner(tokenId int64) (string, error) {
-	ownerAddress, err := _exec.ContractInstance.OwnerOf(nil, big.NewInt(tokenId))
+func (_exec *DeliveryContractExecutor) GetOwner(ctx context.Context, tokenId int64) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "contract.GetOwner", tracing.TokenId.Int64(tokenId))
+	defer tracing.End(span, &err)
+
+	ownerAddress, err := _exec.ContractInstance.OwnerOf(&bind.CallOpts{Context: ctx}, big.NewInt(tokenId))
 
 	if err != nil {
 		return "", err
@@ -234,11 +342,46 @@ func (_exec *DeliveryContractExecutor) GetOwner(tokenId int64) (string, error) {
 	return owner, nil
 }
 
+// Returns the ID of the token minted for the order, or zero if there isn't one (either it was never
+// minted or it has been burned)
+func (_exec *DeliveryContractExecutor) GetTokenIdForOrder(ctx context.Context, orderId string) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "contract.GetTokenIdForOrder", tracing.OrderId.String(orderId))
+	defer tracing.End(span, &err)
+
+	tokenId, err := _exec.ContractInstance.GetTokenIdForOrder(&bind.CallOpts{Context: ctx}, orderId)
+	if err != nil {
+		return 0, err
+	}
+	return tokenId.Int64(), nil
+}
+
+// Returns the IDs of every delivery token that exists, i.e. has been minted and not burned
+func (_exec *DeliveryContractExecutor) ListTokenIds(ctx context.Context) (_ []int64, err error) {
+	ctx, span := tracing.Start(ctx, "contract.ListTokenIds")
+	defer tracing.End(span, &err)
+
+	opts := &bind.CallOpts{Context: ctx}
+	supply, err := _exec.ContractInstance.TotalSupply(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenIds := []int64{}
+	for i := int64(0); i < supply.Int64(); i++ {
+		tokenId, err := _exec.ContractInstance.TokenByIndex(opts, big.NewInt(i))
+		if err != nil {
+			return nil, err
+		}
+		tokenIds = append(tokenIds, tokenId.Int64())
+	}
+	return tokenIds, nil
+}
+
 // Checks whether the token has been transferred to the customer
-func (_exec *DeliveryContractExecutor) IsDelivered(tokenId int64) (bool, error) {
+func (_exec *DeliveryContractExecutor) IsDelivered(ctx context.Context, tokenId int64) (bool, error) {
 	// the order has been delivered if the token does not reside at the vendor's address
 
-	address, err := _exec.GetOwner(tokenId)
+	address, err := _exec.GetOwner(ctx, tokenId)
 	if err != nil {
 		return false, errors.New(fmt.Sprintf("Token [%d] does not exist or has been burned", tokenId))
 	}
@@ -246,66 +389,88 @@ func (_exec *DeliveryContractExecutor) IsDelivered(tokenId int64) (bool, error)
 	return address != _exec.getAddressFromKey(_exec.ServerPrivateKey).Hex(), nil
 }
 
-// Destroys the token
-func (_exec *DeliveryContractExecutor) BurnDeliveryToken(orderId string) error {
-
-	nonce, err := _exec.getNonce(_exec.ServerPrivateKey)
+// Destroys the token and waits for it to be mined. Returns the hash of the transaction.
+func (_exec *DeliveryContractExecutor) BurnDeliveryToken(ctx context.Context, orderId string) (string, error) {
+	txHash, err := _exec.SubmitBurn(ctx, orderId)
 	if err != nil {
-		return err
+		return "", err
 	}
+	return txHash, _exec.WaitForTransaction(ctx, txHash)
+}
 
-	txOpts := bind.NewKeyedTransactor(_exec.ServerPrivateKey)
-	txOpts.Nonce = nonce
+// Sends the transaction that destroys the token without waiting for it to be mined.
+// Returns the hash of the transaction.
+func (_exec *DeliveryContractExecutor) SubmitBurn(ctx context.Context, orderId string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "contract.SubmitBurn", tracing.OrderId.String(orderId))
+	defer tracing.End(span, &err)
+	defer observe("burn", time.Now(), &err)
 
-	_, err = _exec.ContractInstance.BurnTokenByOrderId(txOpts, orderId)
+	tx, err := _exec.transact(ctx, _exec.ServerPrivateKey, func(txOpts *bind.TransactOpts) (*types.Transaction, error) {
+		return _exec.ContractInstance.BurnTokenByOrderId(txOpts, orderId)
+	})
 
 	if err != nil {
 		log.Errorf("Failed to burn token: %v", err)
-		return err
+		return "", classifySubmitError(err)
 	}
 
-	return nil
+	span.SetAttributes(tracing.TxHash.String(tx.Hash().Hex()))
+	log.Infof("Tx sent with ID [%s] to burn the token for order [%s]", tx.Hash().Hex(), orderId)
+	return tx.Hash().Hex(), nil
 }
 
-func (_exec *DeliveryContractExecutor) buildTxOptsForPrivateKey(
-	privateKey *ecdsa.PrivateKey,
-) (*bind.TransactOpts, *common.Address, error) {
-	nonce, err := _exec.getNonce(privateKey)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	txOpts := bind.NewKeyedTransactor(privateKey)
-	txOpts.Nonce = nonce
-
-	return txOpts, _exec.getAddressFromKey(privateKey), nil
+// Waits for a previously submitted transaction to be mined. Returns ErrTransactionReverted if it was
+// mined but failed, or ErrTransactionNotMined if it still hasn't been mined after the timeout or the context
+// is canceled.
+func (_exec *DeliveryContractExecutor) WaitForTransaction(ctx context.Context, txHash string) error {
+	return _exec.waitForMining(ctx, common.HexToHash(txHash))
 }
 
-// Gets a nonce to use for the next transaction
-func (_exec *DeliveryContractExecutor) getNonce(privateKey *ecdsa.PrivateKey) (*big.Int, error) {
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New(fmt.Sprintf("Error casting public key to ECDSA"))
+// Counts how many blocks have been built on top of the transaction, including the one it is in.
+// Returns 0 if it hasn't been mined yet.
+func (_exec *DeliveryContractExecutor) GetConfirmations(ctx context.Context, txHash string) (_ uint64, err error) {
+	ctx, span := tracing.Start(ctx, "contract.GetConfirmations", tracing.TxHash.String(txHash))
+	defer tracing.End(span, &err)
+
+	receipt, err := _exec.Client.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if errors.Is(err, ethereum.NotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	} else if receipt.BlockNumber == nil {
+		return 0, nil
 	}
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := _exec.Client.PendingNonceAt(context.Background(), fromAddress)
+
+	latest, err := _exec.Client.BlockNumber(ctx)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-
-	return big.NewInt(int64(nonce)), nil
+	if latest < receipt.BlockNumber.Uint64() {
+		// the node we asked is behind the one that gave us the receipt
+		return 1, nil
+	}
+	return latest - receipt.BlockNumber.Uint64() + 1, nil
 }
 
-func (_exec *DeliveryContractExecutor) printBalance(label string, address *common.Address) {
-	balance, err := _exec.Client.BalanceAt(context.Background(), *address, nil)
+func (_exec *DeliveryContractExecutor) printBalance(ctx context.Context, label string, address *common.Address) {
+	balance, err := _exec.Client.BalanceAt(ctx, *address, nil)
 	if err != nil {
-		log.Info("Could not get balance for [%s]: [%v]", label, err.Error())
+		log.Infof("Could not get balance for [%s]: [%v]", label, err.Error())
 		return
 	}
 	log.Infof("%s has a balance of [%d]", label, balance)
 }
 
+// Converts a hex-encoded private key (without the 0x prefix) to its ethereum address
+func AddressFromPrivateKey(privateKey string) (*common.Address, error) {
+	privKey, err := crypto.HexToECDSA(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	address := crypto.PubkeyToAddress(privKey.PublicKey)
+	return &address, nil
+}
+
 // Converts a private key to the address
 func (_exec *DeliveryContractExecutor) getAddressFromKey(privateKey *ecdsa.PrivateKey) *common.Address {
 	publicKey := privateKey.Public()
@@ -317,26 +482,60 @@ func (_exec *DeliveryContractExecutor) getAddressFromKey(privateKey *ecdsa.Priva
 	return &address
 }
 
+// Records how long sending a transaction took and whether the contract rejected it. err is a pointer so that
+// this can be deferred and see the error that was finally returned.
+func observe(operation string, started time.Time, err *error) {
+	metrics.ObserveContractOperation(operation, started, *err, errors.Is(*err, ErrTransactionReverted))
+}
+
+// The node simulates a transaction before accepting it, so a transaction that the contract would reject
+// usually fails right away rather than being mined and reverted. Those failures are marked with
+// ErrTransactionReverted so callers know that sending it again won't help.
+func classifySubmitError(err error) error {
+	if strings.Contains(strings.ToLower(err.Error()), "revert") {
+		return fmt.Errorf("%w: %v", ErrTransactionReverted, err)
+	}
+	return err
+}
+
 // When a transaction is sent to the blockchain, it is pending until it actually gets incorporated into a block.
 // By watching the transaction receipt, we can be sure the result of the transaction will be visible in the
 // next call.
-func (_exec *DeliveryContractExecutor) waitForMining(txHash common.Hash, maxWaitSeconds int) error {
+func (_exec *DeliveryContractExecutor) waitForMining(ctx context.Context, txHash common.Hash) (err error) {
+	ctx, span := tracing.Start(ctx, "contract.WaitForMining", tracing.TxHash.String(txHash.Hex()))
+	defer tracing.End(span, &err)
+
+	var receipt *types.Receipt
 	isMined := false
 	startTime := time.Now()
-	for !isMined && int(time.Since(startTime).Seconds()) < maxWaitSeconds {
-		receipt, err := _exec.Client.TransactionReceipt(context.Background(), txHash)
+	for !isMined && time.Since(startTime) < MiningTimeout && ctx.Err() == nil {
+		var err error
+		receipt, err = _exec.Client.TransactionReceipt(ctx, txHash)
 
 		isMined = err == nil && receipt != nil && receipt.BlockNumber != nil && receipt.BlockNumber.Uint64() > 0
 
 		if !isMined {
-			time.Sleep(2 * time.Second)
+			select {
+			case <-ctx.Done():
+			case <-time.After(2 * time.Second):
+			}
 		}
 	}
 
-	if !isMined {
-		return errors.New(fmt.Sprintf("Transaction [%s] was not mined after 30 seconds", txHash.Hex()))
+	if !isMined && ctx.Err() != nil {
+		// whoever was waiting went away; the transaction may still be mined
+		return fmt.Errorf("%w: [%s] stopped waiting: %v", ErrTransactionNotMined, txHash.Hex(), ctx.Err())
+	} else if !isMined {
+		metrics.ObserveMining(startTime, metrics.OutcomeTimeout)
+		return fmt.Errorf("%w: [%s] after %v", ErrTransactionNotMined, txHash.Hex(), MiningTimeout)
+	}
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		metrics.ObserveMining(startTime, metrics.OutcomeReverted)
+		return fmt.Errorf("%w: [%s]", ErrTransactionReverted, txHash.Hex())
 	}
 
+	metrics.ObserveMining(startTime, metrics.OutcomeMined)
 	log.Infof("Tx [%s] was mined", txHash.Hex())
 	return nil
 }
@@ -30,8 +30,8 @@ var (
 
 // DeliveryContractMetaData contains all meta data concerning the DeliveryContract contract.
 var DeliveryContractMetaData = &bind.MetaData{
-	ABI: "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"approved\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"Approval\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"operator\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bool\",\"name\":\"approved\",\"type\":\"bool\"}],\"name\":\"ApprovalForAll\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"_tokenId\",\"type\":\"uint256\"}],\"name\":\"NFTMinted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"_seller\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"_buyer\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"_price\",\"type\":\"uint256\"}],\"name\":\"NftBought\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"Transfer\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"approve\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"balanceOf\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"baseURI\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"burn\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"orderId\",\"type\":\"string\"}],\"name\":\"burnTokenByOrderId\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"buy\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"getApproved\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"orderId\",\"type\":\"string\"}],\"name\":\"getTokenIdForOrder\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"operator\",\"type\":\"address\"}],\"name\":\"isApprovedForAll\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"allowedPurchaser\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"deliveryPrice\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"orderPrice\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"orderId\",\"type\":\"string\"}],\"name\":\"mintToken\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"name\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"ownerOf\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"payForGoods\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"safeTransferFrom\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"},{\"internalType\":\"bytes\",\"name\":\"_data\",\"type\":\"bytes\"}],\"name\":\"safeTransferFrom\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"operator\",\"type\":\"address\"},{\"internalType\":\"bool\",\"name\":\"approved\",\"type\":\"bool\"}],\"name\":\"setApprovalForAll\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes4\",\"name\":\"interfaceId\",\"type\":\"bytes4\"}],\"name\":\"supportsInterface\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"symbol\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"index\",\"type\":\"uint256\"}],\"name\":\"tokenByIndex\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"index\",\"type\":\"uint256\"}],\"name\":\"tokenOfOwnerByIndex\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"tokenURI\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"totalSupply\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"transferFrom\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
-	Bin: "0x60806040523480156200001157600080fd5b506040518060400160405280600d81526020017f44656c6976657279546f6b656e000000000000000000000000000000000000008152506040518060400160405280600381526020017f444c560000000000000000000000000000000000000000000000000000000000815250620000966301ffc9a760e01b6200015960201b60201c565b8160069080519060200190620000ae92919062000262565b508060079080519060200190620000c792919062000262565b50620000e06380ac58cd60e01b6200015960201b60201c565b620000f8635b5e139f60e01b6200015960201b60201c565b6200011063780e9d6360e01b6200015960201b60201c565b505033600b60006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555062000311565b63ffffffff60e01b817bffffffffffffffffffffffffffffffffffffffffffffffffffffffff19161415620001f6576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252601c8152602001807f4552433136353a20696e76616c696420696e746572666163652069640000000081525060200191505060405180910390fd5b6001600080837bffffffffffffffffffffffffffffffffffffffffffffffffffffffff19167bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916815260200190815260200160002060006101000a81548160ff02191690831515021790555050565b828054600181600116156101000203166002900490600052602060002090601f016020900481019282601f10620002a557805160ff1916838001178555620002d6565b82800160010185558215620002d6579182015b82811115620002d5578251825591602001919060010190620002b8565b5b509050620002e59190620002e9565b5090565b6200030e91905b808211156200030a576000816000905550600101620002f0565b5090565b90565b613f5c80620003216000396000f3fe6080604052600436106101405760003560e01c80636556e748116100b6578063b88d4fde1161006f578063b88d4fde1461094b578063c87b56dd14610a5d578063d96a094a14610b11578063e26d15e414610b3f578063e985e9c514610b6d578063f700812414610bf657610140565b80636556e748146105b25780636c0360eb1461067a57806370a082311461070a57806387c6649c1461076f57806395d89b411461085e578063a22cb465146108ee57610140565b806323b872dd1161010857806323b872dd146103485780632f745c59146103c357806342842e0e1461043257806342966c68146104ad5780634f6ccce7146104e85780636352211e1461053757610140565b806301ffc9a71461014557806306fdde03146101b7578063081812fc14610247578063095ea7b3146102c257806318160ddd1461031d575b600080fd5b34801561015157600080fd5b5061019d6004803603602081101561016857600080fd5b8101908080357bffffffffffffffffffffffffffffffffffffffffffffffffffffffff19169060200190929190505050610cd2565b604051808215151515815260200191505060405180910390f35b3480156101c357600080fd5b506101cc610d39565b6040518080602001828103825283818151815260200191508051906020019080838360005b8381101561020c5780820151818401526020810190506101f1565b50505050905090810190601f1680156102395780820380516001836020036101000a031916815260200191505b509250505060405180910390f35b34801561025357600080fd5b506102806004803603602081101561026a57600080fd5b8101908080359060200190929190505050610ddb565b604051808273ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200191505060405180910390f35b3480156102ce57600080fd5b5061031b600480360360408110156102e557600080fd5b81019080803573ffffffffffffffffffffffffffffffffffffffff16906020019092919080359060200190929190505050610e76565b005b34801561032957600080fd5b50610332610fba565b6040518082815260200191505060405180910390f35b34801561035457600080fd5b506103c16004803603606081101561036b57600080fd5b81019080803573ffffffffffffffffffffffffffffffffffffffff169060200190929190803573ffffffffffffffffffffffffffffffffffffffff16906020019092919080359060200190929190505050610fcb565b005b3480156103cf57600080fd5b5061041c600480360360408110156103e657600080fd5b81019080803573ffffffffffffffffffffffffffffffffffffffff16906020019092919080359060200190929190505050611041565b6040518082815260200191505060405180910390f35b34801561043e57600080fd5b506104ab6004803603606081101561045557600080fd5b81019080803573ffffffffffffffffffffffffffffffffffffffff169060200190929190803573ffffffffffffffffffffffffffffffffffffffff1690602001909291908035906020019092919050505061109c565b005b3480156104b957600080fd5b506104e6600480360360208110156104d057600080fd5b81019080803590602001909291905050506110bc565b005b3480156104f457600080fd5b506105216004803603602081101561050b57600080fd5b810190808035906020019092919050505061112e565b6040518082815260200191505060405180910390f35b34801561054357600080fd5b506105706004803603602081101561055a57600080fd5b8101908080359060200190929190505050611151565b604051808273ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200191505060405180910390f35b3480156105be57600080fd5b50610678600480360360208110156105d557600080fd5b81019080803590602001906401000000008111156105f257600080fd5b82018360208201111561060457600080fd5b8035906020019184600183028401116401000000008311171561062657600080fd5b91908080601f016020809104026020016040519081016040528093929190818152602001838380828437600081840152601f19601f820116905080830192505050505050509192919290505050611188565b005b34801561068657600080fd5b5061068f6113e3565b6040518080602001828103825283818151815260200191508051906020019080838360005b838110156106cf5780820151818401526020810190506106b4565b50505050905090810190601f1680156106fc5780820380516001836020036101000a031916815260200191505b509250505060405180910390f35b34801561071657600080fd5b506107596004803603602081101561072d57600080fd5b81019080803573ffffffffffffffffffffffffffffffffffffffff169060200190929190505050611485565b6040518082815260200191505060405180910390f35b61085c6004803603608081101561078557600080fd5b81019080803573ffffffffffffffffffffffffffffffffffffffff1690602001909291908035906020019092919080359060200190929190803590602001906401000000008111156107d657600080fd5b8201836020820111156107e857600080fd5b8035906020019184600183028401116401000000008311171561080a57600080fd5b91908080601f016020809104026020016040519081016040528093929190818152602001838380828437600081840152601f19601f82011690508083019250505050505050919291929050505061155a565b005b34801561086a57600080fd5b50610873611732565b6040518080602001828103825283818151815260200191508051906020019080838360005b838110156108b3578082015181840152602081019050610898565b50505050905090810190601f1680156108e05780820380516001836020036101000a031916815260200191505b509250505060405180910390f35b3480156108fa57600080fd5b506109496004803603604081101561091157600080fd5b81019080803573ffffffffffffffffffffffffffffffffffffffff1690602001909291908035151590602001909291905050506117d4565b005b34801561095757600080fd5b50610a5b6004803603608081101561096e57600080fd5b81019080803573ffffffffffffffffffffffffffffffffffffffff169060200190929190803573ffffffffffffffffffffffffffffffffffffffff16906020019092919080359060200190929190803590602001906401000000008111156109d557600080fd5b8201836020820111156109e757600080fd5b80359060200191846001830284011164010000000083111715610a0957600080fd5b91908080601f016020809104026020016040519081016040528093929190818152602001838380828437600081840152601f19601f82011690508083019250505050505050919291929050505061198c565b005b348015610a6957600080fd5b50610a9660048036036020811015610a8057600080fd5b8101908080359060200190929190505050611a04565b6040518080602001828103825283818151815260200191508051906020019080838360005b83811015610ad6578082015181840152602081019050610abb565b50505050905090810190601f168015610b035780820380516001836020036101000a031916815260200191505b509250505060405180910390f35b610b3d60048036036020811015610b2757600080fd5b8101908080359060200190929190505050611cd5565b005b610b6b60048036036020811015610b5557600080fd5b8101908080359060200190929190505050612038565b005b348015610b7957600080fd5b50610bdc60048036036040811015610b9057600080fd5b81019080803573ffffffffffffffffffffffffffffffffffffffff169060200190929190803573ffffffffffffffffffffffffffffffffffffffff169060200190929190505050612310565b604051808215151515815260200191505060405180910390f35b348015610c0257600080fd5b50610cbc60048036036020811015610c1957600080fd5b8101908080359060200190640100000000811115610c3657600080fd5b820183602082011115610c4857600080fd5b80359060200191846001830284011164010000000083111715610c6a57600080fd5b91908080601f016020809104026020016040519081016040528093929190818152602001838380828437600081840152601f19601f8201169050808301925050505050505091929192905050506123a4565b6040518082815260200191505060405180910390f35b6000806000837bffffffffffffffffffffffffffffffffffffffffffffffffffffffff19167bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916815260200190815260200160002060009054906101000a900460ff169050919050565b606060068054600181600116156101000203166002900480601f016020809104026020016040519081016040528092919081815260200182805460018160011615610100020316600290048015610dd15780601f10610da657610100808354040283529160200191610dd1565b820191906000526020600020905b815481529060010190602001808311610db457829003601f168201915b5050505050905090565b6000610de682612417565b610e3b576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252602c815260200180613dd3602c913960400191505060405180910390fd5b6004600083815260200190815260200160002060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169050919050565b6000610e8182611151565b90508073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff161415610f08576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526021815260200180613ea56021913960400191505060405180910390fd5b8073ffffffffffffffffffffffffffffffffffffffff16610f27612434565b73ffffffffffffffffffffffffffffffffffffffff161480610f565750610f5581610f50612434565b612310565b5b610fab576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526038815260200180613cd46038913960400191505060405180910390fd5b610fb5838361243c565b505050565b6000610fc660026124f5565b905090565b610fdc610fd6612434565b8261250a565b611031576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526031815260200180613ec66031913960400191505060405180910390fd5b61103c8383836125fe565b505050565b600061109482600160008673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002061284190919063ffffffff16565b905092915050565b6110b78383836040518060200160405280600081525061198c565b505050565b6110cd6110c7612434565b8261250a565b611122576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526030815260200180613ef76030913960400191505060405180910390fd5b61112b8161285b565b50565b60008061114583600261299590919063ffffffff16565b50905080915050919050565b600061118182604051806060016040528060298152602001613d5e6029913960026129c49092919063ffffffff16565b9050919050565b6000600d826040518082805190602001908083835b602083106111c0578051825260208201915060208101905060208303925061119d565b6001836020036101000a0380198251168184511680821785525050505050509050019150509081526020016040518091039020549050600081141561126d576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004018080602001828103825260198152602001807f5468617420746f6b656e20646f6573206e6f742065786973740000000000000081525060200191505060405180910390fd5b600b60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff166112af82611151565b73ffffffffffffffffffffffffffffffffffffffff16141561131c576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252602b815260200180613e7a602b913960400191505060405180910390fd5b600d826040518082805190602001908083835b60208310611352578051825260208201915060208101905060208303925061132f565b6001836020036101000a038019825116818451168082178552505050505050905001915050908152602001604051809103902060009055600c600082815260200190815260200160002060008082016000905560018201600090556002820160006101000a81549073ffffffffffffffffffffffffffffffffffffffff021916905550506113df8161285b565b5050565b606060098054600181600116156101000203166002900480601f01602080910402602001604051908101604052809291908181526020018280546001816001161561010002031660029004801561147b5780601f106114505761010080835404028352916020019161147b565b820191906000526020600020905b81548152906001019060200180831161145e57829003601f168201915b5050505050905090565b60008073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff16141561150c576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252602a815260200180613d0c602a913960400191505060405180910390fd5b611553600160008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681526020019081526020016000206129e3565b9050919050565b611564600a6129f8565b6000611570600a612a0e565b905080600d836040518082805190602001908083835b602083106115a95780518252602082019150602081019050602083039250611586565b6001836020036101000a0380198251168184511680821785525050505050509050019150509081526020016040518091039020819055506115e8613b37565b60405180606001604052808681526020018581526020018773ffffffffffffffffffffffffffffffffffffffff16815250905080600c6000848152602001908152602001600020600082015181600001556020820151816001015560408201518160020160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055509050506000600e600084815260200190815260200160002060006101000a81548160ff0219169083151502179055506116e5600b60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1683612a1c565b6116f3816040015183610e76565b7fd9dc24857f317ed9abbbb42e920ede0104231eb1d3d70236a74887ffaf159868826040518082815260200191505060405180910390a1505050505050565b606060078054600181600116156101000203166002900480601f0160208091040260200160405190810160405280929190818152602001828054600181600116156101000203166002900480156117ca5780601f1061179f576101008083540402835291602001916117ca565b820191906000526020600020905b8154815290600101906020018083116117ad57829003601f168201915b5050505050905090565b6117dc612434565b73ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff16141561187d576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004018080602001828103825260198152602001807f4552433732313a20617070726f766520746f2063616c6c65720000000000000081525060200191505060405180910390fd5b806005600061188a612434565b73ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060006101000a81548160ff0219169083151502179055508173ffffffffffffffffffffffffffffffffffffffff16611937612434565b73ffffffffffffffffffffffffffffffffffffffff167f17307eab39ab6107e8899845ad3d59bd9653f200f220920489ca2b5937696c3183604051808215151515815260200191505060405180910390a35050565b61199d611997612434565b8361250a565b6119f2576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526031815260200180613ec66031913960400191505060405180910390fd5b6119fe84848484612a3a565b50505050565b6060611a0f82612417565b611a64576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252602f815260200180613e4b602f913960400191505060405180910390fd5b6060600860008481526020019081526020016000208054600181600116156101000203166002900480601f016020809104026020016040519081016040528092919081815260200182805460018160011615610100020316600290048015611b0d5780601f10611ae257610100808354040283529160200191611b0d565b820191906000526020600020905b815481529060010190602001808311611af057829003601f168201915b505050505090506060611b1e6113e3565b9050600081511415611b34578192505050611cd0565b600082511115611c055780826040516020018083805190602001908083835b60208310611b765780518252602082019150602081019050602083039250611b53565b6001836020036101000a03801982511681845116808217855250505050505090500182805190602001908083835b60208310611bc75780518252602082019150602081019050602083039250611ba4565b6001836020036101000a0380198251168184511680821785525050505050509050019250505060405160208183030381529060405292505050611cd0565b80611c0f85612aac565b6040516020018083805190602001908083835b60208310611c455780518252602082019150602081019050602083039250611c22565b6001836020036101000a03801982511681845116808217855250505050505090500182805190602001908083835b60208310611c965780518252602082019150602081019050602083039250611c73565b6001836020036101000a03801982511681845116808217855250505050505090500192505050604051602081830303815290604052925050505b919050565b611cde81612417565b611d50576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004018080602001828103825260198152602001807f5468617420746f6b656e20646f6573206e6f742065786973740000000000000081525060200191505060405180910390fd5b60011515600e600083815260200190815260200160002060009054906101000a900460ff16151514611dcd576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252602a815260200180613d87602a913960400191505060405180910390fd5b611dd5613b37565b600c600083815260200190815260200160002060405180606001604052908160008201548152602001600182015481526020016002820160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681525050905080600001513414611ebb576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252602e815260200180613bfe602e913960400191505060405180910390fd5b611ee8600b60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16338461109c565b6000600b60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1690508073ffffffffffffffffffffffffffffffffffffffff166108fc836020015134019081150290604051600060405180830381858888f19350505050158015611f71573d6000803e3d6000fd5b507f608f6ac9327c2bf4d3c77adf447d2c448ba7b0971e0aaa9aa03f7ac29d874a44600b60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff163334604051808473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681526020018373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001828152602001935050505060405180910390a1505050565b61204181612417565b6120b3576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004018080602001828103825260198152602001807f5468617420746f6b656e20646f6573206e6f742065786973740000000000000081525060200191505060405180910390fd5b60001515600e600083815260200190815260200160002060009054906101000a900460ff1615151461214d576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252601f8152602001807f54686973206f7264657220776173207061696420666f7220616c72656164790081525060200191505060405180910390fd5b612155613b37565b600c600083815260200190815260200160002060405180606001604052908160008201548152602001600182015481526020016002820160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815250509050806040015173ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff1614612267576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526028815260200180613d366028913960400191505060405180910390fd5b806020015134146122e0576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252601d8152602001807f4d7573742070617920666f7220746865206974656d20696e2066756c6c00000081525060200191505060405180910390fd5b6001600e600084815260200190815260200160002060006101000a81548160ff0219169083151502179055505050565b6000600560008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060009054906101000a900460ff16905092915050565b6000600d826040518082805190602001908083835b602083106123dc57805182526020820191506020810190506020830392506123b9565b6001836020036101000a0380198251168184511680821785525050505050509050019150509081526020016040518091039020549050919050565b600061242d826002612bf390919063ffffffff16565b9050919050565b600033905090565b816004600083815260200190815260200160002060006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550808273ffffffffffffffffffffffffffffffffffffffff166124af83611151565b73ffffffffffffffffffffffffffffffffffffffff167f8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b92560405160405180910390a45050565b600061250382600001612c0d565b9050919050565b600061251582612417565b61256a576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252602c815260200180613ca8602c913960400191505060405180910390fd5b600061257583611151565b90508073ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff1614806125e457508373ffffffffffffffffffffffffffffffffffffffff166125cc84610ddb565b73ffffffffffffffffffffffffffffffffffffffff16145b806125f557506125f48185612310565b5b91505092915050565b8273ffffffffffffffffffffffffffffffffffffffff1661261e82611151565b73ffffffffffffffffffffffffffffffffffffffff161461268a576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526029815260200180613dff6029913960400191505060405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff161415612710576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526024815260200180613c5e6024913960400191505060405180910390fd5b61271b838383612c1e565b61272660008261243c565b61277781600160008673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020612daf90919063ffffffff16565b506127c981600160008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020612dc990919063ffffffff16565b506127e081836002612de39092919063ffffffff16565b50808273ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef60405160405180910390a4505050565b60006128508360000183612e18565b60001c905092915050565b600061286682611151565b905061287481600084612c1e565b61287f60008361243c565b600060086000848152602001908152602001600020805460018160011615610100020316600290049050146128ce576008600083815260200190815260200160002060006128cd9190613b6e565b5b61291f82600160008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020612daf90919063ffffffff16565b50612934826002612e9b90919063ffffffff16565b5081600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef60405160405180910390a45050565b6000806000806129a88660000186612eb5565b915091508160001c8160001c8090509350935050509250929050565b60006129d7846000018460001b84612f4e565b60001c90509392505050565b60006129f182600001613044565b9050919050565b6001816000016000828254019250508190555050565b600081600001549050919050565b612a36828260405180602001604052806000815250613055565b5050565b612a458484846125fe565b612a51848484846130c6565b612aa6576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526032815260200180613c2c6032913960400191505060405180910390fd5b50505050565b60606000821415612af4576040518060400160405280600181526020017f30000000000000000000000000000000000000000000000000000000000000008152509050612bee565b600082905060005b60008214612b1e578080600101915050600a8281612b1657fe5b049150612afc565b60608167ffffffffffffffff81118015612b3757600080fd5b506040519080825280601f01601f191660200182016040528015612b6a5781602001600182028036833780820191505090505b50905060006001830390508593505b60008414612be657600a8481612b8b57fe5b0660300160f81b82828060019003935081518110612ba557fe5b60200101907effffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916908160001a905350600a8481612bde57fe5b049350612b79565b819450505050505b919050565b6000612c05836000018360001b61330b565b905092915050565b600081600001805490509050919050565b612c2983838361332e565b600073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff1614158015612c935750600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1614155b15612cf857612ca2828261250a565b612cf7576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526023815260200180613e286023913960400191505060405180910390fd5b5b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff161415612daa57612d37838261250a565b612da9576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252601f8152602001807f6e6f7420617070726f76656420746f206275726e207468697320746f6b656e0081525060200191505060405180910390fd5b5b505050565b6000612dc1836000018360001b613333565b905092915050565b6000612ddb836000018360001b61341b565b905092915050565b6000612e0f846000018460001b8473ffffffffffffffffffffffffffffffffffffffff1660001b61348b565b90509392505050565b600081836000018054905011612e79576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526022815260200180613bdc6022913960400191505060405180910390fd5b826000018281548110612e8857fe5b9060005260206000200154905092915050565b6000612ead836000018360001b613567565b905092915050565b60008082846000018054905011612f17576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526022815260200180613db16022913960400191505060405180910390fd5b6000846000018481548110612f2857fe5b906000526020600020906002020190508060000154816001015492509250509250929050565b60008084600101600085815260200190815260200160002054905060008114158390613015576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004018080602001828103825283818151815260200191508051906020019080838360005b83811015612fda578082015181840152602081019050612fbf565b50505050905090810190601f1680156130075780820380516001836020036101000a031916815260200191505b509250505060405180910390fd5b5084600001600182038154811061302857fe5b9060005260206000209060020201600101549150509392505050565b600081600001805490509050919050565b61305f8383613680565b61306c60008484846130c6565b6130c1576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526032815260200180613c2c6032913960400191505060405180910390fd5b505050565b60006130e78473ffffffffffffffffffffffffffffffffffffffff16613874565b6130f45760019050613303565b606061328a63150b7a0260e01b613109612434565b888787604051602401808573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681526020018473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200183815260200180602001828103825283818151815260200191508051906020019080838360005b838110156131b957808201518184015260208101905061319e565b50505050905090810190601f1680156131e65780820380516001836020036101000a031916815260200191505b5095505050505050604051602081830303815290604052907bffffffffffffffffffffffffffffffffffffffffffffffffffffffff19166020820180517bffffffffffffffffffffffffffffffffffffffffffffffffffffffff8381831617835250505050604051806060016040528060328152602001613c2c603291398773ffffffffffffffffffffffffffffffffffffffff166138879092919063ffffffff16565b905060008180602001905160208110156132a357600080fd5b8101908080519060200190929190505050905063150b7a0260e01b7bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916817bffffffffffffffffffffffffffffffffffffffffffffffffffffffff191614925050505b949350505050565b600080836001016000848152602001908152602001600020541415905092915050565b505050565b6000808360010160008481526020019081526020016000205490506000811461340f576000600182039050600060018660000180549050039050600086600001828154811061337e57fe5b906000526020600020015490508087600001848154811061339b57fe5b90600052602060002001819055506001830187600101600083815260200190815260200160002081905550866000018054806133d357fe5b60019003818190600052602060002001600090559055866001016000878152602001908152602001600020600090556001945050505050613415565b60009150505b92915050565b6000613427838361389f565b613480578260000182908060018154018082558091505060019003906000526020600020016000909190919091505582600001805490508360010160008481526020019081526020016000208190555060019050613485565b600090505b92915050565b600080846001016000858152602001908152602001600020549050600081141561353257846000016040518060400160405280868152602001858152509080600181540180825580915050600190039060005260206000209060020201600090919091909150600082015181600001556020820151816001015550508460000180549050856001016000868152602001908152602001600020819055506001915050613560565b8285600001600183038154811061354557fe5b90600052602060002090600202016001018190555060009150505b9392505050565b6000808360010160008481526020019081526020016000205490506000811461367457600060018203905060006001866000018054905003905060008660000182815481106135b257fe5b90600052602060002090600202019050808760000184815481106135d257fe5b906000526020600020906002020160008201548160000155600182015481600101559050506001830187600101600083600001548152602001908152602001600020819055508660000180548061362557fe5b600190038181906000526020600020906002020160008082016000905560018201600090555050905586600101600087815260200190815260200160002060009055600194505050505061367a565b60009150505b92915050565b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff161415613723576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004018080602001828103825260208152602001807f4552433732313a206d696e7420746f20746865207a65726f206164647265737381525060200191505060405180910390fd5b61372c81612417565b1561379f576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252601c8152602001807f4552433732313a20746f6b656e20616c7265616479206d696e7465640000000081525060200191505060405180910390fd5b6137ab60008383612c1e565b6137fc81600160008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020612dc990919063ffffffff16565b5061381381836002612de39092919063ffffffff16565b50808273ffffffffffffffffffffffffffffffffffffffff16600073ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef60405160405180910390a45050565b600080823b905060008111915050919050565b606061389684846000856138c2565b90509392505050565b600080836001016000848152602001908152602001600020541415905092915050565b60608247101561391d576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401808060200182810382526026815260200180613c826026913960400191505060405180910390fd5b61392685613874565b613998576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252601d8152602001807f416464726573733a2063616c6c20746f206e6f6e2d636f6e747261637400000081525060200191505060405180910390fd5b600060608673ffffffffffffffffffffffffffffffffffffffff1685876040518082805190602001908083835b602083106139e857805182526020820191506020810190506020830392506139c5565b6001836020036101000a03801982511681845116808217855250505050505090500191505060006040518083038185875af1925050503d8060008114613a4a576040519150601f19603f3d011682016040523d82523d6000602084013e613a4f565b606091505b5091509150613a5f828286613a6b565b92505050949350505050565b60608315613a7b57829050613b30565b600083511115613a8e5782518084602001fd5b816040517f08c379a00000000000000000000000000000000000000000000000000000000081526004018080602001828103825283818151815260200191508051906020019080838360005b83811015613af5578082015181840152602081019050613ada565b50505050905090810190601f168015613b225780820380516001836020036101000a031916815260200191505b509250505060405180910390fd5b9392505050565b60405180606001604052806000815260200160008152602001600073ffffffffffffffffffffffffffffffffffffffff1681525090565b50805460018160011615610100020316600290046000825580601f10613b945750613bb3565b601f016020900490600052602060002090810190613bb29190613bb6565b5b50565b613bd891905b80821115613bd4576000816000905550600101613bbc565b5090565b9056fe456e756d657261626c655365743a20696e646578206f7574206f6620626f756e64734d7573742070617920746865207368697070696e6720636f73747320746f206163636570742064656c69766572794552433732313a207472616e7366657220746f206e6f6e20455243373231526563656976657220696d706c656d656e7465724552433732313a207472616e7366657220746f20746865207a65726f2061646472657373416464726573733a20696e73756666696369656e742062616c616e636520666f722063616c6c4552433732313a206f70657261746f7220717565727920666f72206e6f6e6578697374656e7420746f6b656e4552433732313a20617070726f76652063616c6c6572206973206e6f74206f776e6572206e6f7220617070726f76656420666f7220616c6c4552433732313a2062616c616e636520717565727920666f7220746865207a65726f20616464726573734f6e6c792074686520726563697069656e742063616e2070617920666f7220746865206f726465724552433732313a206f776e657220717565727920666f72206e6f6e6578697374656e7420746f6b656e4f72646572206d757374206265207061696420696e2066756c6c206265666f72652064656c6976657279456e756d657261626c654d61703a20696e646578206f7574206f6620626f756e64734552433732313a20617070726f76656420717565727920666f72206e6f6e6578697374656e7420746f6b656e4552433732313a207472616e73666572206f6620746f6b656e2074686174206973206e6f74206f776e6e6f7420617070726f76656420746f207472616e73666572207468697320746f6b656e4552433732314d657461646174613a2055524920717565727920666f72206e6f6e6578697374656e7420746f6b656e54686520746f6b656e2063616e206f6e6c79206265206275726e65642061667465722064656c69766572794552433732313a20617070726f76616c20746f2063757272656e74206f776e65724552433732313a207472616e736665722063616c6c6572206973206e6f74206f776e6572206e6f7220617070726f7665644552433732314275726e61626c653a2063616c6c6572206973206e6f74206f776e6572206e6f7220617070726f766564a2646970667358221220aaacd1c0adaef8c4cdc72d6e87c77231b65d701212694be51b605efa903d67c664736f6c63430006080033",
+	ABI: "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"approved\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"Approval\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"operator\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"bool\",\"name\":\"approved\",\"type\":\"bool\"}],\"name\":\"ApprovalForAll\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"_tokenId\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"_recipient\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"_amount\",\"type\":\"uint256\"}],\"name\":\"EscrowRefunded\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"_tokenId\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"_evidence\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"_amount\",\"type\":\"uint256\"}],\"name\":\"EscrowReleased\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"_tokenId\",\"type\":\"uint256\"}],\"name\":\"NFTMinted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"_seller\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"_buyer\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"_price\",\"type\":\"uint256\"}],\"name\":\"NftBought\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"Transfer\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"approve\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"balanceOf\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"baseURI\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"burn\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"orderId\",\"type\":\"string\"}],\"name\":\"burnTokenByOrderId\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"buy\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"escrowExpiresAt\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"escrowWindow\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"getApproved\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"orderId\",\"type\":\"string\"}],\"name\":\"getTokenIdForOrder\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"operator\",\"type\":\"address\"}],\"name\":\"isApprovedForAll\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"isSettled\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"allowedPurchaser\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"deliveryPrice\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"orderPrice\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"orderId\",\"type\":\"string\"}],\"name\":\"mintToken\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"name\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"ownerOf\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"payForGoods\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"refundEscrow\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"},{\"internalType\":\"bytes32\",\"name\":\"evidence\",\"type\":\"bytes32\"}],\"name\":\"releaseEscrow\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"safeTransferFrom\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"},{\"internalType\":\"bytes\",\"name\":\"_data\",\"type\":\"bytes\"}],\"name\":\"safeTransferFrom\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"operator\",\"type\":\"address\"},{\"internalType\":\"bool\",\"name\":\"approved\",\"type\":\"bool\"}],\"name\":\"setApprovalForAll\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"window\",\"type\":\"uint256\"}],\"name\":\"setEscrowWindow\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes4\",\"name\":\"interfaceId\",\"type\":\"bytes4\"}],\"name\":\"supportsInterface\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"symbol\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"index\",\"type\":\"uint256\"}],\"name\":\"tokenByIndex\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"index\",\"type\":\"uint256\"}],\"name\":\"tokenOfOwnerByIndex\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"tokenURI\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"totalSupply\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"transferFrom\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	Bin: "0x608060405262278d006014553480156200001857600080fd5b506040518060400160405280600d81526020017f44656c6976657279546f6b656e000000000000000000000000000000000000008152506040518060400160405280600381526020017f444c5600000000000000000000000000000000000000000000000000000000008152506200009d6301ffc9a760e01b6200015260201b60201c565b8160099081620000ae9190620004a3565b5080600a9081620000c09190620004a3565b50620000d96380ac58cd60e01b6200015260201b60201c565b620000f1635b5e139f60e01b6200015260201b60201c565b6200010963780e9d6360e01b6200015260201b60201c565b505033600e60006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055506200060d565b63ffffffff60e01b817bffffffffffffffffffffffffffffffffffffffffffffffffffffffff191603620001bd576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401620001b490620005eb565b60405180910390fd5b6001600080837bffffffffffffffffffffffffffffffffffffffffffffffffffffffff19167bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916815260200190815260200160002060006101000a81548160ff02191690831515021790555050565b600081519050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b60006002820490506001821680620002ab57607f821691505b602082108103620002c157620002c062000263565b5b50919050565b60008190508160005260206000209050919050565b60006020601f8301049050919050565b600082821b905092915050565b6000600883026200032b7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff82620002ec565b620003378683620002ec565b95508019841693508086168417925050509392505050565b6000819050919050565b6000819050919050565b6000620003846200037e62000378846200034f565b62000359565b6200034f565b9050919050565b6000819050919050565b620003a08362000363565b620003b8620003af826200038b565b848454620002f9565b825550505050565b600090565b620003cf620003c0565b620003dc81848462000395565b505050565b5b818110156200040457620003f8600082620003c5565b600181019050620003e2565b5050565b601f82111562000453576200041d81620002c7565b6200042884620002dc565b8101602085101562000438578190505b620004506200044785620002dc565b830182620003e1565b50505b505050565b600082821c905092915050565b6000620004786000198460080262000458565b1980831691505092915050565b600062000493838362000465565b9150826002028217905092915050565b620004ae8262000229565b67ffffffffffffffff811115620004ca57620004c962000234565b5b620004d6825462000292565b620004e382828562000408565b600060209050601f8311600181146200051b576000841562000506578287015190505b62000512858262000485565b86555062000582565b601f1984166200052b86620002c7565b60005b8281101562000555578489015182556001820191506020850194506020810190506200052e565b8683101562000575578489015162000571601f89168262000465565b8355505b6001600288020188555050505b505050505050565b600082825260208201905092915050565b7f4552433136353a20696e76616c696420696e7465726661636520696400000000600082015250565b6000620005d3601c836200058a565b9150620005e0826200059b565b602082019050919050565b600060208201905081810360008301526200060681620005c4565b9050919050565b615085806200061d6000396000f3fe6080604052600436106101c25760003560e01c80636c0360eb116100f7578063a22cb46511610095578063d96a094a11610064578063d96a094a14610679578063e26d15e414610695578063e985e9c5146106b1578063f7008124146106ee576101c2565b8063a22cb465146105ad578063b88d4fde146105d6578063c87b56dd146105ff578063cc5ca0901461063c576101c2565b806387c6649c116100d157806387c6649c1461051457806395d89b4114610530578063a0968d691461055b578063a0a9b5da14610584576101c2565b80636c0360eb1461046f57806370a082311461049a578063785ecb37146104d7576101c2565b8063356f9dfd1161016457806349311aee1161013e57806349311aee146103a15780634f6ccce7146103cc5780636352211e146104095780636556e74814610446576101c2565b8063356f9dfd1461032657806342842e0e1461034f57806342966c6814610378576101c2565b8063095ea7b3116101a0578063095ea7b31461026c57806318160ddd1461029557806323b872dd146102c05780632f745c59146102e9576101c2565b806301ffc9a7146101c757806306fdde0314610204578063081812fc1461022f575b600080fd5b3480156101d357600080fd5b506101ee60048036038101906101e991906132f7565b61072b565b6040516101fb919061333f565b60405180910390f35b34801561021057600080fd5b50610219610792565b60405161022691906133ea565b60405180910390f35b34801561023b57600080fd5b5061025660048036038101906102519190613442565b610824565b60405161026391906134b0565b60405180910390f35b34801561027857600080fd5b50610293600480360381019061028e91906134f7565b6108a9565b005b3480156102a157600080fd5b506102aa6109c0565b6040516102b79190613546565b60405180910390f35b3480156102cc57600080fd5b506102e760048036038101906102e29190613561565b6109cd565b005b3480156102f557600080fd5b50610310600480360381019061030b91906134f7565b610a2d565b60405161031d9190613546565b60405180910390f35b34801561033257600080fd5b5061034d60048036038101906103489190613442565b610ad2565b005b34801561035b57600080fd5b5061037660048036038101906103719190613561565b610cf9565b005b34801561038457600080fd5b5061039f600480360381019061039a9190613442565b610d19565b005b3480156103ad57600080fd5b506103b6610d75565b6040516103c39190613546565b60405180910390f35b3480156103d857600080fd5b506103f360048036038101906103ee9190613442565b610d7b565b6040516104009190613546565b60405180910390f35b34801561041557600080fd5b50610430600480360381019061042b9190613442565b610dea565b60405161043d91906134b0565b60405180910390f35b34801561045257600080fd5b5061046d600480360381019061046891906136e9565b610e9b565b005b34801561047b57600080fd5b50610484611015565b60405161049191906133ea565b60405180910390f35b3480156104a657600080fd5b506104c160048036038101906104bc9190613732565b6110a7565b6040516104ce9190613546565b60405180910390f35b3480156104e357600080fd5b506104fe60048036038101906104f99190613442565b61115e565b60405161050b919061333f565b60405180910390f35b61052e6004803603810190610529919061375f565b611188565b005b34801561053c57600080fd5b5061054561130f565b60405161055291906133ea565b60405180910390f35b34801561056757600080fd5b50610582600480360381019061057d9190613818565b6113a1565b005b34801561059057600080fd5b506105ab60048036038101906105a69190613442565b61161f565b005b3480156105b957600080fd5b506105d460048036038101906105cf9190613884565b6116b9565b005b3480156105e257600080fd5b506105fd60048036038101906105f89190613965565b611839565b005b34801561060b57600080fd5b5061062660048036038101906106219190613442565b61189b565b60405161063391906133ea565b60405180910390f35b34801561064857600080fd5b50610663600480360381019061065e9190613442565b611a0d565b6040516106709190613546565b60405180910390f35b610693600480360381019061068e9190613442565b611a2a565b005b6106af60048036038101906106aa9190613442565b611d1e565b005b3480156106bd57600080fd5b506106d860048036038101906106d391906139e8565b611f66565b6040516106e5919061333f565b60405180910390f35b3480156106fa57600080fd5b50610715600480360381019061071091906136e9565b611ffa565b6040516107229190613546565b60405180910390f35b6000806000837bffffffffffffffffffffffffffffffffffffffffffffffffffffffff19167bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916815260200190815260200160002060009054906101000a900460ff169050919050565b6060600980546107a190613a57565b80601f01602080910402602001604051908101604052809291908181526020018280546107cd90613a57565b801561081a5780601f106107ef5761010080835404028352916020019161081a565b820191906000526020600020905b8154815290600101906020018083116107fd57829003601f168201915b5050505050905090565b600061082f82612022565b61086e576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161086590613afa565b60405180910390fd5b6003600083815260200190815260200160002060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169050919050565b60006108b482610dea565b90508073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff1603610924576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161091b90613b8c565b60405180910390fd5b8073ffffffffffffffffffffffffffffffffffffffff1661094361208e565b73ffffffffffffffffffffffffffffffffffffffff16148061097257506109718161096c61208e565b611f66565b5b6109b1576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016109a890613c1e565b60405180910390fd5b6109bb8383612096565b505050565b6000600780549050905090565b6109de6109d861208e565b8261214f565b610a1d576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610a1490613cb0565b60405180910390fd5b610a2883838361222d565b505050565b6000610a38836110a7565b8210610a79576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610a7090613d42565b60405180910390fd5b600560008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600083815260200190815260200160002054905092915050565b6000600f600083815260200190815260200160002060405180606001604052908160008201548152602001600182015481526020016002820160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815250509050600e60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff161480610be95750806040015173ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff16145b610c28576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610c1f90613dd4565b60405180910390fd5b610c318261249c565b60016013600084815260200190815260200160002060006101000a81548160ff0219169083151502179055506000816040015190508073ffffffffffffffffffffffffffffffffffffffff166108fc83602001519081150290604051600060405180830381858888f19350505050158015610cb0573d6000803e3d6000fd5b507feac97bc1917fcedc984e3d0671d4e83b359890323d5d1c2de32b28d17c356ced8383604001518460200151604051610cec93929190613df4565b60405180910390a1505050565b610d1483838360405180602001604052806000815250611839565b505050565b610d2a610d2461208e565b8261214f565b610d69576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610d6090613e9d565b60405180910390fd5b610d72816126a3565b50565b60145481565b60006007805490508210610dc4576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610dbb90613f2f565b60405180910390fd5b60078281548110610dd857610dd7613f4f565b5b90600052602060002001549050919050565b6000806001600084815260200190815260200160002060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169050600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff1603610e92576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610e8990613ff0565b60405180910390fd5b80915050919050565b6000601082604051610ead919061404c565b908152602001604051809103902054905060008103610f01576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610ef8906140af565b60405180910390fd5b600e60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16610f4382610dea565b73ffffffffffffffffffffffffffffffffffffffff1603610f99576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610f9090614141565b60405180910390fd5b601082604051610fa9919061404c565b908152602001604051809103902060009055600f600082815260200190815260200160002060008082016000905560018201600090556002820160006101000a81549073ffffffffffffffffffffffffffffffffffffffff02191690555050611011816126a3565b5050565b6060600c805461102490613a57565b80601f016020809104026020016040519081016040528092919081815260200182805461105090613a57565b801561109d5780601f106110725761010080835404028352916020019161109d565b820191906000526020600020905b81548152906001019060200180831161108057829003601f168201915b5050505050905090565b60008073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1603611117576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161110e906141d3565b60405180910390fd5b600260008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020549050919050565b60006013600083815260200190815260200160002060009054906101000a900460ff169050919050565b611192600d61280e565b600061119e600d61282d565b9050806010836040516111b1919061404c565b908152602001604051809103902081905550600060405180606001604052808681526020018581526020018773ffffffffffffffffffffffffffffffffffffffff16815250905080600f6000848152602001908152602001600020600082015181600001556020820151816001015560408201518160020160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555090505060006011600084815260200190815260200160002060006101000a81548160ff0219169083151502179055506112c2600e60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff168361283b565b6112d08160400151836108a9565b7fd9dc24857f317ed9abbbb42e920ede0104231eb1d3d70236a74887ffaf159868826040516112ff9190613546565b60405180910390a1505050505050565b6060600a805461131e90613a57565b80601f016020809104026020016040519081016040528092919081815260200182805461134a90613a57565b80156113975780601f1061136c57610100808354040283529160200191611397565b820191906000526020600020905b81548152906001019060200180831161137a57829003601f168201915b5050505050905090565b600e60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff1614611431576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161142890614265565b60405180910390fd5b6000801b8103611476576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161146d906142f7565b60405180910390fd5b6000600f600084815260200190815260200160002060405180606001604052908160008201548152602001600182015481526020016002820160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681525050905061150b8361249c565b60016013600085815260200190815260200160002060006101000a81548160ff021916908315150217905550611568600e60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1682604001518561222d565b6000600e60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1690508073ffffffffffffffffffffffffffffffffffffffff166108fc83602001519081150290604051600060405180830381858888f193505050501580156115d9573d6000803e3d6000fd5b507facb46c189dba65e49e2e6d449aa3672d58da985c726c29b54d4329f92ad6aa468484846020015160405161161193929190614326565b60405180910390a150505050565b600e60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff16146116af576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016116a6906143cf565b60405180910390fd5b8060148190555050565b6116c161208e565b73ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff160361172e576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016117259061443b565b60405180910390fd5b806004600061173b61208e565b73ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060006101000a81548160ff0219169083151502179055508173ffffffffffffffffffffffffffffffffffffffff166117e861208e565b73ffffffffffffffffffffffffffffffffffffffff167f17307eab39ab6107e8899845ad3d59bd9653f200f220920489ca2b5937696c318360405161182d919061333f565b60405180910390a35050565b61184a61184461208e565b8361214f565b611889576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161188090613cb0565b60405180910390fd5b61189584848484612859565b50505050565b60606118a682612022565b6118e5576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016118dc906144cd565b60405180910390fd5b6000600b6000848152602001908152602001600020805461190590613a57565b80601f016020809104026020016040519081016040528092919081815260200182805461193190613a57565b801561197e5780601f106119535761010080835404028352916020019161197e565b820191906000526020600020905b81548152906001019060200180831161196157829003601f168201915b50505050509050600061198f611015565b905060008151036119a4578192505050611a08565b6000825111156119d95780826040516020016119c19291906144ed565b60405160208183030381529060405292505050611a08565b806119e3856128b5565b6040516020016119f49291906144ed565b604051602081830303815290604052925050505b919050565b600060126000838152602001908152602001600020549050919050565b611a3381612022565b611a72576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611a69906140af565b60405180910390fd5b600115156011600083815260200190815260200160002060009054906101000a900460ff16151514611ad9576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611ad090614583565b60405180910390fd5b600015156013600083815260200190815260200160002060009054906101000a900460ff16151514611b40576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611b37906145ef565b60405180910390fd5b6000600f600083815260200190815260200160002060405180606001604052908160008201548152602001600182015481526020016002820160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681525050905080600001513414611c12576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611c0990614681565b60405180910390fd5b611c3f600e60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff163384610cf9565b6000600e60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1690508073ffffffffffffffffffffffffffffffffffffffff166108fc836020015134611c9091906146d0565b9081150290604051600060405180830381858888f19350505050158015611cbb573d6000803e3d6000fd5b507f608f6ac9327c2bf4d3c77adf447d2c448ba7b0971e0aaa9aa03f7ac29d874a44600e60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff163334604051611d1193929190614704565b60405180910390a1505050565b611d2781612022565b611d66576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611d5d906140af565b60405180910390fd5b600015156011600083815260200190815260200160002060009054906101000a900460ff16151514611dcd576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611dc490614787565b60405180910390fd5b6000600f600083815260200190815260200160002060405180606001604052908160008201548152602001600182015481526020016002820160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815250509050806040015173ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff1614611ecb576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611ec290614819565b60405180910390fd5b80602001513414611f11576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611f0890614885565b60405180910390fd5b60016011600084815260200190815260200160002060006101000a81548160ff02191690831515021790555060145442611f4b91906146d0565b60126000848152602001908152602001600020819055505050565b6000600460008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060009054906101000a900460ff16905092915050565b600060108260405161200c919061404c565b9081526020016040518091039020549050919050565b60008073ffffffffffffffffffffffffffffffffffffffff166001600084815260200190815260200160002060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1614159050919050565b600033905090565b816003600083815260200190815260200160002060006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550808273ffffffffffffffffffffffffffffffffffffffff1661210983610dea565b73ffffffffffffffffffffffffffffffffffffffff167f8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b92560405160405180910390a45050565b600061215a82612022565b612199576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161219090614917565b60405180910390fd5b60006121a483610dea565b90508073ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff16148061221357508373ffffffffffffffffffffffffffffffffffffffff166121fb84610824565b73ffffffffffffffffffffffffffffffffffffffff16145b8061222457506122238185611f66565b5b91505092915050565b8273ffffffffffffffffffffffffffffffffffffffff1661224d82610dea565b73ffffffffffffffffffffffffffffffffffffffff16146122a3576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161229a906149a9565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1603612312576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161230990614a3b565b60405180910390fd5b61231d838383612a1d565b612328600082612096565b6123328382612b64565b61233c8282612d08565b6001600260008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600082825461238c9190614a5b565b925050819055506001600260008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008282546123e391906146d0565b92505081905550816001600083815260200190815260200160002060006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550808273ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef60405160405180910390a4505050565b6124a581612022565b6124e4576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016124db906140af565b60405180910390fd5b600115156011600083815260200190815260200160002060009054906101000a900460ff1615151461254b576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161254290614adb565b60405180910390fd5b600015156013600083815260200190815260200160002060009054906101000a900460ff161515146125b2576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016125a9906145ef565b60405180910390fd5b600e60009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff166125f482610dea565b73ffffffffffffffffffffffffffffffffffffffff161461264a576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161264190614b47565b60405180910390fd5b60126000828152602001908152602001600020544210156126a0576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161269790614bb3565b60405180910390fd5b50565b60006126ae82610dea565b90506126bc81600084612a1d565b6126c7600083612096565b6000600b600084815260200190815260200160002080546126e790613a57565b90501461270e57600b6000838152602001908152602001600020600061270d919061322e565b5b6127188183612b64565b61272182612dbe565b6001600260008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008282546127719190614a5b565b925050819055506001600083815260200190815260200160002060006101000a81549073ffffffffffffffffffffffffffffffffffffffff021916905581600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef60405160405180910390a45050565b600181600001600082825461282391906146d0565b9250508190555050565b600081600001549050919050565b612855828260405180602001604052806000815250612e8f565b5050565b61286484848461222d565b61287084848484612eea565b6128af576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016128a690614c45565b60405180910390fd5b50505050565b6060600082036128fc576040518060400160405280600181526020017f30000000000000000000000000000000000000000000000000000000000000008152509050612a18565b600082905060005b6000821461292e57808061291790614c65565b915050600a826129279190614cdc565b9150612904565b60008167ffffffffffffffff81111561294a576129496135be565b5b6040519080825280601f01601f19166020018201604052801561297c5781602001600182028036833780820191505090505b50905060008290508593505b60008414612a1057808061299b90614d0d565b915050600a846129ab9190614d36565b60306129b791906146d0565b60f81b8282815181106129cd576129cc613f4f565b5b60200101907effffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916908160001a905350600a84612a099190614cdc565b9350612988565b819450505050505b919050565b612a28838383612ff9565b600073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff1614158015612a925750600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1614155b15612ae157612aa1828261214f565b612ae0576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401612ad790614dd9565b60405180910390fd5b5b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1603612b5f57612b1f838261214f565b612b5e576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401612b5590614e45565b60405180910390fd5b5b505050565b60006001600260008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002054612bb29190614a5b565b9050600060066000848152602001908152602001600020549050818114612c97576000600560008673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600084815260200190815260200160002054905080600560008773ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600084815260200190815260200160002081905550816006600083815260200190815260200160002081905550505b6006600084815260200190815260200160002060009055600560008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008381526020019081526020016000206000905550505050565b6000600260008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002054905081600560008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600083815260200190815260200160002081905550806006600084815260200190815260200160002081905550505050565b60006001600780549050612dd29190614a5b565b9050600060086000848152602001908152602001600020549050600060078381548110612e0257612e01613f4f565b5b906000526020600020015490508060078381548110612e2457612e23613f4f565b5b906000526020600020018190555081600860008381526020019081526020016000208190555060086000858152602001908152602001600020600090556007805480612e7357612e72614e65565b5b6001900381819060005260206000200160009055905550505050565b612e998383612ffe565b612ea66000848484612eea565b612ee5576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401612edc90614c45565b60405180910390fd5b505050565b6000612f0b8473ffffffffffffffffffffffffffffffffffffffff1661321b565b612f185760019050612ff1565b60008473ffffffffffffffffffffffffffffffffffffffff1663150b7a02612f3e61208e565b8887876040518563ffffffff1660e01b8152600401612f609493929190614ee9565b6020604051808303816000875af1158015612f7f573d6000803e3d6000fd5b505050506040513d601f19601f82011682018060405250810190612fa39190614f4a565b905063150b7a0260e01b7bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916817bffffffffffffffffffffffffffffffffffffffffffffffffffffffff1916149150505b949350505050565b505050565b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff160361306d576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161306490614fc3565b60405180910390fd5b61307681612022565b156130b6576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016130ad9061502f565b60405180910390fd5b6130c260008383612a1d565b6130cc8282612d08565b600780549050600860008381526020019081526020016000208190555060078190806001815401808255809150506001900390600052602060002001600090919091909150556001600260008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600082825461316291906146d0565b92505081905550816001600083815260200190815260200160002060006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550808273ffffffffffffffffffffffffffffffffffffffff16600073ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef60405160405180910390a45050565b600080823b905060008111915050919050565b50805461323a90613a57565b6000825580601f1061324c575061326b565b601f01602090049060005260206000209081019061326a919061326e565b5b50565b5b8082111561328757600081600090555060010161326f565b5090565b6000604051905090565b600080fd5b600080fd5b60007fffffffff0000000000000000000000000000000000000000000000000000000082169050919050565b6132d48161329f565b81146132df57600080fd5b50565b6000813590506132f1816132cb565b92915050565b60006020828403121561330d5761330c613295565b5b600061331b848285016132e2565b91505092915050565b60008115159050919050565b61333981613324565b82525050565b60006020820190506133546000830184613330565b92915050565b600081519050919050565b600082825260208201905092915050565b60005b83811015613394578082015181840152602081019050613379565b60008484015250505050565b6000601f19601f8301169050919050565b60006133bc8261335a565b6133c68185613365565b93506133d6818560208601613376565b6133df816133a0565b840191505092915050565b6000602082019050818103600083015261340481846133b1565b905092915050565b6000819050919050565b61341f8161340c565b811461342a57600080fd5b50565b60008135905061343c81613416565b92915050565b60006020828403121561345857613457613295565b5b60006134668482850161342d565b91505092915050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b600061349a8261346f565b9050919050565b6134aa8161348f565b82525050565b60006020820190506134c560008301846134a1565b92915050565b6134d48161348f565b81146134df57600080fd5b50565b6000813590506134f1816134cb565b92915050565b6000806040838503121561350e5761350d613295565b5b600061351c858286016134e2565b925050602061352d8582860161342d565b9150509250929050565b6135408161340c565b82525050565b600060208201905061355b6000830184613537565b92915050565b60008060006060848603121561357a57613579613295565b5b6000613588868287016134e2565b9350506020613599868287016134e2565b92505060406135aa8682870161342d565b9150509250925092565b600080fd5b600080fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b6135f6826133a0565b810181811067ffffffffffffffff82111715613615576136146135be565b5b80604052505050565b600061362861328b565b905061363482826135ed565b919050565b600067ffffffffffffffff821115613654576136536135be565b5b61365d826133a0565b9050602081019050919050565b82818337600083830152505050565b600061368c61368784613639565b61361e565b9050828152602081018484840111156136a8576136a76135b9565b5b6136b384828561366a565b509392505050565b600082601f8301126136d0576136cf6135b4565b5b81356136e0848260208601613679565b91505092915050565b6000602082840312156136ff576136fe613295565b5b600082013567ffffffffffffffff81111561371d5761371c61329a565b5b613729848285016136bb565b91505092915050565b60006020828403121561374857613747613295565b5b6000613756848285016134e2565b91505092915050565b6000806000806080858703121561377957613778613295565b5b6000613787878288016134e2565b94505060206137988782880161342d565b93505060406137a98782880161342d565b925050606085013567ffffffffffffffff8111156137ca576137c961329a565b5b6137d6878288016136bb565b91505092959194509250565b6000819050919050565b6137f5816137e2565b811461380057600080fd5b50565b600081359050613812816137ec565b92915050565b6000806040838503121561382f5761382e613295565b5b600061383d8582860161342d565b925050602061384e85828601613803565b9150509250929050565b61386181613324565b811461386c57600080fd5b50565b60008135905061387e81613858565b92915050565b6000806040838503121561389b5761389a613295565b5b60006138a9858286016134e2565b92505060206138ba8582860161386f565b9150509250929050565b600067ffffffffffffffff8211156138df576138de6135be565b5b6138e8826133a0565b9050602081019050919050565b6000613908613903846138c4565b61361e565b905082815260208101848484011115613924576139236135b9565b5b61392f84828561366a565b509392505050565b600082601f83011261394c5761394b6135b4565b5b813561395c8482602086016138f5565b91505092915050565b6000806000806080858703121561397f5761397e613295565b5b600061398d878288016134e2565b945050602061399e878288016134e2565b93505060406139af8782880161342d565b925050606085013567ffffffffffffffff8111156139d0576139cf61329a565b5b6139dc87828801613937565b91505092959194509250565b600080604083850312156139ff576139fe613295565b5b6000613a0d858286016134e2565b9250506020613a1e858286016134e2565b9150509250929050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b60006002820490506001821680613a6f57607f821691505b602082108103613a8257613a81613a28565b5b50919050565b7f4552433732313a20617070726f76656420717565727920666f72206e6f6e657860008201527f697374656e7420746f6b656e0000000000000000000000000000000000000000602082015250565b6000613ae4602c83613365565b9150613aef82613a88565b604082019050919050565b60006020820190508181036000830152613b1381613ad7565b9050919050565b7f4552433732313a20617070726f76616c20746f2063757272656e74206f776e6560008201527f7200000000000000000000000000000000000000000000000000000000000000602082015250565b6000613b76602183613365565b9150613b8182613b1a565b604082019050919050565b60006020820190508181036000830152613ba581613b69565b9050919050565b7f4552433732313a20617070726f76652063616c6c6572206973206e6f74206f7760008201527f6e6572206e6f7220617070726f76656420666f7220616c6c0000000000000000602082015250565b6000613c08603883613365565b9150613c1382613bac565b604082019050919050565b60006020820190508181036000830152613c3781613bfb565b9050919050565b7f4552433732313a207472616e736665722063616c6c6572206973206e6f74206f60008201527f776e6572206e6f7220617070726f766564000000000000000000000000000000602082015250565b6000613c9a603183613365565b9150613ca582613c3e565b604082019050919050565b60006020820190508181036000830152613cc981613c8d565b9050919050565b7f455243373231456e756d657261626c653a206f776e657220696e646578206f7560008201527f74206f6620626f756e6473000000000000000000000000000000000000000000602082015250565b6000613d2c602b83613365565b9150613d3782613cd0565b604082019050919050565b60006020820190508181036000830152613d5b81613d1f565b9050919050565b7f4f6e6c79207468652076656e646f72206f722074686520726563697069656e7460008201527f2063616e20726566756e642074686520657363726f7700000000000000000000602082015250565b6000613dbe603683613365565b9150613dc982613d62565b604082019050919050565b60006020820190508181036000830152613ded81613db1565b9050919050565b6000606082019050613e096000830186613537565b613e1660208301856134a1565b613e236040830184613537565b949350505050565b7f4552433732314275726e61626c653a2063616c6c6572206973206e6f74206f7760008201527f6e6572206e6f7220617070726f76656400000000000000000000000000000000602082015250565b6000613e87603083613365565b9150613e9282613e2b565b604082019050919050565b60006020820190508181036000830152613eb681613e7a565b9050919050565b7f455243373231456e756d657261626c653a20676c6f62616c20696e646578206f60008201527f7574206f6620626f756e64730000000000000000000000000000000000000000602082015250565b6000613f19602c83613365565b9150613f2482613ebd565b604082019050919050565b60006020820190508181036000830152613f4881613f0c565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603260045260246000fd5b7f4552433732313a206f776e657220717565727920666f72206e6f6e657869737460008201527f656e7420746f6b656e0000000000000000000000000000000000000000000000602082015250565b6000613fda602983613365565b9150613fe582613f7e565b604082019050919050565b6000602082019050818103600083015261400981613fcd565b9050919050565b600081905092915050565b60006140268261335a565b6140308185614010565b9350614040818560208601613376565b80840191505092915050565b6000614058828461401b565b915081905092915050565b7f5468617420746f6b656e20646f6573206e6f7420657869737400000000000000600082015250565b6000614099601983613365565b91506140a482614063565b602082019050919050565b600060208201905081810360008301526140c88161408c565b9050919050565b7f54686520746f6b656e2063616e206f6e6c79206265206275726e65642061667460008201527f65722064656c6976657279000000000000000000000000000000000000000000602082015250565b600061412b602b83613365565b9150614136826140cf565b604082019050919050565b6000602082019050818103600083015261415a8161411e565b9050919050565b7f4552433732313a2062616c616e636520717565727920666f7220746865207a6560008201527f726f206164647265737300000000000000000000000000000000000000000000602082015250565b60006141bd602a83613365565b91506141c882614161565b604082019050919050565b600060208201905081810360008301526141ec816141b0565b9050919050565b7f4f6e6c79207468652076656e646f722063616e2072656c65617365207468652060008201527f657363726f770000000000000000000000000000000000000000000000000000602082015250565b600061424f602683613365565b915061425a826141f3565b604082019050919050565b6000602082019050818103600083015261427e81614242565b9050919050565b7f52656c656173696e672074686520657363726f77206e6565647320657669646560008201527f6e6365206f662064656c69766572790000000000000000000000000000000000602082015250565b60006142e1602f83613365565b91506142ec82614285565b604082019050919050565b60006020820190508181036000830152614310816142d4565b9050919050565b614320816137e2565b82525050565b600060608201905061433b6000830186613537565b6143486020830185614317565b6143556040830184613537565b949350505050565b7f4f6e6c79207468652076656e646f722063616e206368616e676520746865206560008201527f7363726f772077696e646f770000000000000000000000000000000000000000602082015250565b60006143b9602c83613365565b91506143c48261435d565b604082019050919050565b600060208201905081810360008301526143e8816143ac565b9050919050565b7f4552433732313a20617070726f766520746f2063616c6c657200000000000000600082015250565b6000614425601983613365565b9150614430826143ef565b602082019050919050565b6000602082019050818103600083015261445481614418565b9050919050565b7f4552433732314d657461646174613a2055524920717565727920666f72206e6f60008201527f6e6578697374656e7420746f6b656e0000000000000000000000000000000000602082015250565b60006144b7602f83613365565b91506144c28261445b565b604082019050919050565b600060208201905081810360008301526144e6816144aa565b9050919050565b60006144f9828561401b565b9150614505828461401b565b91508190509392505050565b7f4f72646572206d757374206265207061696420696e2066756c6c206265666f7260008201527f652064656c697665727900000000000000000000000000000000000000000000602082015250565b600061456d602a83613365565b915061457882614511565b604082019050919050565b6000602082019050818103600083015261459c81614560565b9050919050565b7f54686520657363726f772077617320736574746c656420616c72656164790000600082015250565b60006145d9601e83613365565b91506145e4826145a3565b602082019050919050565b60006020820190508181036000830152614608816145cc565b9050919050565b7f4d7573742070617920746865207368697070696e6720636f73747320746f206160008201527f63636570742064656c6976657279000000000000000000000000000000000000602082015250565b600061466b602e83613365565b91506146768261460f565b604082019050919050565b6000602082019050818103600083015261469a8161465e565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b60006146db8261340c565b91506146e68361340c565b92508282019050808211156146fe576146fd6146a1565b5b92915050565b600060608201905061471960008301866134a1565b61472660208301856134a1565b6147336040830184613537565b949350505050565b7f54686973206f7264657220776173207061696420666f7220616c726561647900600082015250565b6000614771601f83613365565b915061477c8261473b565b602082019050919050565b600060208201905081810360008301526147a081614764565b9050919050565b7f4f6e6c792074686520726563697069656e742063616e2070617920666f72207460008201527f6865206f72646572000000000000000000000000000000000000000000000000602082015250565b6000614803602883613365565b915061480e826147a7565b604082019050919050565b60006020820190508181036000830152614832816147f6565b9050919050565b7f4d7573742070617920666f7220746865206974656d20696e2066756c6c000000600082015250565b600061486f601d83613365565b915061487a82614839565b602082019050919050565b6000602082019050818103600083015261489e81614862565b9050919050565b7f4552433732313a206f70657261746f7220717565727920666f72206e6f6e657860008201527f697374656e7420746f6b656e0000000000000000000000000000000000000000602082015250565b6000614901602c83613365565b915061490c826148a5565b604082019050919050565b60006020820190508181036000830152614930816148f4565b9050919050565b7f4552433732313a207472616e73666572206f6620746f6b656e2074686174206960008201527f73206e6f74206f776e0000000000000000000000000000000000000000000000602082015250565b6000614993602983613365565b915061499e82614937565b604082019050919050565b600060208201905081810360008301526149c281614986565b9050919050565b7f4552433732313a207472616e7366657220746f20746865207a65726f2061646460008201527f7265737300000000000000000000000000000000000000000000000000000000602082015250565b6000614a25602483613365565b9150614a30826149c9565b604082019050919050565b60006020820190508181036000830152614a5481614a18565b9050919050565b6000614a668261340c565b9150614a718361340c565b9250828203905081811115614a8957614a886146a1565b5b92915050565b7f546865206f7264657220686173206e6f74206265656e207061696420666f7200600082015250565b6000614ac5601f83613365565b9150614ad082614a8f565b602082019050919050565b60006020820190508181036000830152614af481614ab8565b9050919050565b7f546865206f72646572207761732064656c69766572656420616c726561647900600082015250565b6000614b31601f83613365565b9150614b3c82614afb565b602082019050919050565b60006020820190508181036000830152614b6081614b24565b9050919050565b7f54686520657363726f7720686173206e6f742065787069726564207965740000600082015250565b6000614b9d601e83613365565b9150614ba882614b67565b602082019050919050565b60006020820190508181036000830152614bcc81614b90565b9050919050565b7f4552433732313a207472616e7366657220746f206e6f6e20455243373231526560008201527f63656976657220696d706c656d656e7465720000000000000000000000000000602082015250565b6000614c2f603283613365565b9150614c3a82614bd3565b604082019050919050565b60006020820190508181036000830152614c5e81614c22565b9050919050565b6000614c708261340c565b91507fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8203614ca257614ca16146a1565b5b600182019050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601260045260246000fd5b6000614ce78261340c565b9150614cf28361340c565b925082614d0257614d01614cad565b5b828204905092915050565b6000614d188261340c565b915060008203614d2b57614d2a6146a1565b5b600182039050919050565b6000614d418261340c565b9150614d4c8361340c565b925082614d5c57614d5b614cad565b5b828206905092915050565b7f6e6f7420617070726f76656420746f207472616e73666572207468697320746f60008201527f6b656e0000000000000000000000000000000000000000000000000000000000602082015250565b6000614dc3602383613365565b9150614dce82614d67565b604082019050919050565b60006020820190508181036000830152614df281614db6565b9050919050565b7f6e6f7420617070726f76656420746f206275726e207468697320746f6b656e00600082015250565b6000614e2f601f83613365565b9150614e3a82614df9565b602082019050919050565b60006020820190508181036000830152614e5e81614e22565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603160045260246000fd5b600081519050919050565b600082825260208201905092915050565b6000614ebb82614e94565b614ec58185614e9f565b9350614ed5818560208601613376565b614ede816133a0565b840191505092915050565b6000608082019050614efe60008301876134a1565b614f0b60208301866134a1565b614f186040830185613537565b8181036060830152614f2a8184614eb0565b905095945050505050565b600081519050614f44816132cb565b92915050565b600060208284031215614f6057614f5f613295565b5b6000614f6e84828501614f35565b91505092915050565b7f4552433732313a206d696e7420746f20746865207a65726f2061646472657373600082015250565b6000614fad602083613365565b9150614fb882614f77565b602082019050919050565b60006020820190508181036000830152614fdc81614fa0565b9050919050565b7f4552433732313a20746f6b656e20616c7265616479206d696e74656400000000600082015250565b6000615019601c83613365565b915061502482614fe3565b602082019050919050565b600060208201905081810360008301526150488161500c565b905091905056fea2646970667358221220479e0b9b7a4a0c188ddbedca0fc716478d56ca81c3a8993162b81ce65e3a517964736f6c63430008150033",
 }
 
 // DeliveryContractABI is the input ABI used to generate the binding from.
@@ -263,6 +263,68 @@ func (_DeliveryContract *DeliveryContractCallerSession) BaseURI() (string, error
 	return _DeliveryContract.Contract.BaseURI(&_DeliveryContract.CallOpts)
 }
 
+// EscrowExpiresAt is a free data retrieval call binding the contract method 0xcc5ca090.
+//
+// Solidity: function escrowExpiresAt(uint256 tokenId) view returns(uint256)
+func (_DeliveryContract *DeliveryContractCaller) EscrowExpiresAt(opts *bind.CallOpts, tokenId *big.Int) (*big.Int, error) {
+	var out []interface{}
+	err := _DeliveryContract.contract.Call(opts, &out, "escrowExpiresAt", tokenId)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// EscrowExpiresAt is a free data retrieval call binding the contract method 0xcc5ca090.
+//
+// Solidity: function escrowExpiresAt(uint256 tokenId) view returns(uint256)
+func (_DeliveryContract *DeliveryContractSession) EscrowExpiresAt(tokenId *big.Int) (*big.Int, error) {
+	return _DeliveryContract.Contract.EscrowExpiresAt(&_DeliveryContract.CallOpts, tokenId)
+}
+
+// EscrowExpiresAt is a free data retrieval call binding the contract method 0xcc5ca090.
+//
+// Solidity: function escrowExpiresAt(uint256 tokenId) view returns(uint256)
+func (_DeliveryContract *DeliveryContractCallerSession) EscrowExpiresAt(tokenId *big.Int) (*big.Int, error) {
+	return _DeliveryContract.Contract.EscrowExpiresAt(&_DeliveryContract.CallOpts, tokenId)
+}
+
+// EscrowWindow is a free data retrieval call binding the contract method 0x49311aee.
+//
+// Solidity: function escrowWindow() view returns(uint256)
+func (_DeliveryContract *DeliveryContractCaller) EscrowWindow(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _DeliveryContract.contract.Call(opts, &out, "escrowWindow")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// EscrowWindow is a free data retrieval call binding the contract method 0x49311aee.
+//
+// Solidity: function escrowWindow() view returns(uint256)
+func (_DeliveryContract *DeliveryContractSession) EscrowWindow() (*big.Int, error) {
+	return _DeliveryContract.Contract.EscrowWindow(&_DeliveryContract.CallOpts)
+}
+
+// EscrowWindow is a free data retrieval call binding the contract method 0x49311aee.
+//
+// Solidity: function escrowWindow() view returns(uint256)
+func (_DeliveryContract *DeliveryContractCallerSession) EscrowWindow() (*big.Int, error) {
+	return _DeliveryContract.Contract.EscrowWindow(&_DeliveryContract.CallOpts)
+}
+
 // GetApproved is a free data retrieval call binding the contract method 0x081812fc.
 //
 // Solidity: function getApproved(uint256 tokenId) view returns(address)
@@ -356,6 +418,37 @@ func (_DeliveryContract *DeliveryContractCallerSession) IsApprovedForAll(owner c
 	return _DeliveryContract.Contract.IsApprovedForAll(&_DeliveryContract.CallOpts, owner, operator)
 }
 
+// IsSettled is a free data retrieval call binding the contract method 0x785ecb37.
+//
+// Solidity: function isSettled(uint256 tokenId) view returns(bool)
+func (_DeliveryContract *DeliveryContractCaller) IsSettled(opts *bind.CallOpts, tokenId *big.Int) (bool, error) {
+	var out []interface{}
+	err := _DeliveryContract.contract.Call(opts, &out, "isSettled", tokenId)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// IsSettled is a free data retrieval call binding the contract method 0x785ecb37.
+//
+// Solidity: function isSettled(uint256 tokenId) view returns(bool)
+func (_DeliveryContract *DeliveryContractSession) IsSettled(tokenId *big.Int) (bool, error) {
+	return _DeliveryContract.Contract.IsSettled(&_DeliveryContract.CallOpts, tokenId)
+}
+
+// IsSettled is a free data retrieval call binding the contract method 0x785ecb37.
+//
+// Solidity: function isSettled(uint256 tokenId) view returns(bool)
+func (_DeliveryContract *DeliveryContractCallerSession) IsSettled(tokenId *big.Int) (bool, error) {
+	return _DeliveryContract.Contract.IsSettled(&_DeliveryContract.CallOpts, tokenId)
+}
+
 // Name is a free data retrieval call binding the contract method 0x06fdde03.
 //
 // Solidity: function name() view returns(string)
@@ -730,6 +823,48 @@ func (_DeliveryContract *DeliveryContractTransactorSession) PayForGoods(tokenId
 	return _DeliveryContract.Contract.PayForGoods(&_DeliveryContract.TransactOpts, tokenId)
 }
 
+// RefundEscrow is a paid mutator transaction binding the contract method 0x356f9dfd.
+//
+// Solidity: function refundEscrow(uint256 tokenId) returns()
+func (_DeliveryContract *DeliveryContractTransactor) RefundEscrow(opts *bind.TransactOpts, tokenId *big.Int) (*types.Transaction, error) {
+	return _DeliveryContract.contract.Transact(opts, "refundEscrow", tokenId)
+}
+
+// RefundEscrow is a paid mutator transaction binding the contract method 0x356f9dfd.
+//
+// Solidity: function refundEscrow(uint256 tokenId) returns()
+func (_DeliveryContract *DeliveryContractSession) RefundEscrow(tokenId *big.Int) (*types.Transaction, error) {
+	return _DeliveryContract.Contract.RefundEscrow(&_DeliveryContract.TransactOpts, tokenId)
+}
+
+// RefundEscrow is a paid mutator transaction binding the contract method 0x356f9dfd.
+//
+// Solidity: function refundEscrow(uint256 tokenId) returns()
+func (_DeliveryContract *DeliveryContractTransactorSession) RefundEscrow(tokenId *big.Int) (*types.Transaction, error) {
+	return _DeliveryContract.Contract.RefundEscrow(&_DeliveryContract.TransactOpts, tokenId)
+}
+
+// ReleaseEscrow is a paid mutator transaction binding the contract method 0xa0968d69.
+//
+// Solidity: function releaseEscrow(uint256 tokenId, bytes32 evidence) returns()
+func (_DeliveryContract *DeliveryContractTransactor) ReleaseEscrow(opts *bind.TransactOpts, tokenId *big.Int, evidence [32]byte) (*types.Transaction, error) {
+	return _DeliveryContract.contract.Transact(opts, "releaseEscrow", tokenId, evidence)
+}
+
+// ReleaseEscrow is a paid mutator transaction binding the contract method 0xa0968d69.
+//
+// Solidity: function releaseEscrow(uint256 tokenId, bytes32 evidence) returns()
+func (_DeliveryContract *DeliveryContractSession) ReleaseEscrow(tokenId *big.Int, evidence [32]byte) (*types.Transaction, error) {
+	return _DeliveryContract.Contract.ReleaseEscrow(&_DeliveryContract.TransactOpts, tokenId, evidence)
+}
+
+// ReleaseEscrow is a paid mutator transaction binding the contract method 0xa0968d69.
+//
+// Solidity: function releaseEscrow(uint256 tokenId, bytes32 evidence) returns()
+func (_DeliveryContract *DeliveryContractTransactorSession) ReleaseEscrow(tokenId *big.Int, evidence [32]byte) (*types.Transaction, error) {
+	return _DeliveryContract.Contract.ReleaseEscrow(&_DeliveryContract.TransactOpts, tokenId, evidence)
+}
+
 // SafeTransferFrom is a paid mutator transaction binding the contract method 0x42842e0e.
 //
 // Solidity: function safeTransferFrom(address from, address to, uint256 tokenId) returns()
@@ -793,6 +928,27 @@ func (_DeliveryContract *DeliveryContractTransactorSession) SetApprovalForAll(op
 	return _DeliveryContract.Contract.SetApprovalForAll(&_DeliveryContract.TransactOpts, operator, approved)
 }
 
+// SetEscrowWindow is a paid mutator transaction binding the contract method 0xa0a9b5da.
+//
+// Solidity: function setEscrowWindow(uint256 window) returns()
+func (_DeliveryContract *DeliveryContractTransactor) SetEscrowWindow(opts *bind.TransactOpts, window *big.Int) (*types.Transaction, error) {
+	return _DeliveryContract.contract.Transact(opts, "setEscrowWindow", window)
+}
+
+// SetEscrowWindow is a paid mutator transaction binding the contract method 0xa0a9b5da.
+//
+// Solidity: function setEscrowWindow(uint256 window) returns()
+func (_DeliveryContract *DeliveryContractSession) SetEscrowWindow(window *big.Int) (*types.Transaction, error) {
+	return _DeliveryContract.Contract.SetEscrowWindow(&_DeliveryContract.TransactOpts, window)
+}
+
+// SetEscrowWindow is a paid mutator transaction binding the contract method 0xa0a9b5da.
+//
+// Solidity: function setEscrowWindow(uint256 window) returns()
+func (_DeliveryContract *DeliveryContractTransactorSession) SetEscrowWindow(window *big.Int) (*types.Transaction, error) {
+	return _DeliveryContract.Contract.SetEscrowWindow(&_DeliveryContract.TransactOpts, window)
+}
+
 // TransferFrom is a paid mutator transaction binding the contract method 0x23b872dd.
 //
 // Solidity: function transferFrom(address from, address to, uint256 tokenId) returns()
@@ -1130,6 +1286,278 @@ func (_DeliveryContract *DeliveryContractFilterer) ParseApprovalForAll(log types
 	return event, nil
 }
 
+// DeliveryContractEscrowRefundedIterator is returned from FilterEscrowRefunded and is used to iterate over the raw logs and unpacked data for EscrowRefunded events raised by the DeliveryContract contract.
+type DeliveryContractEscrowRefundedIterator struct {
+	Event *DeliveryContractEscrowRefunded // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *DeliveryContractEscrowRefundedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(DeliveryContractEscrowRefunded)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(DeliveryContractEscrowRefunded)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *DeliveryContractEscrowRefundedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *DeliveryContractEscrowRefundedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// DeliveryContractEscrowRefunded represents a EscrowRefunded event raised by the DeliveryContract contract.
+type DeliveryContractEscrowRefunded struct {
+	TokenId   *big.Int
+	Recipient common.Address
+	Amount    *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterEscrowRefunded is a free log retrieval operation binding the contract event 0xeac97bc1917fcedc984e3d0671d4e83b359890323d5d1c2de32b28d17c356ced.
+//
+// Solidity: event EscrowRefunded(uint256 _tokenId, address _recipient, uint256 _amount)
+func (_DeliveryContract *DeliveryContractFilterer) FilterEscrowRefunded(opts *bind.FilterOpts) (*DeliveryContractEscrowRefundedIterator, error) {
+
+	logs, sub, err := _DeliveryContract.contract.FilterLogs(opts, "EscrowRefunded")
+	if err != nil {
+		return nil, err
+	}
+	return &DeliveryContractEscrowRefundedIterator{contract: _DeliveryContract.contract, event: "EscrowRefunded", logs: logs, sub: sub}, nil
+}
+
+// WatchEscrowRefunded is a free log subscription operation binding the contract event 0xeac97bc1917fcedc984e3d0671d4e83b359890323d5d1c2de32b28d17c356ced.
+//
+// Solidity: event EscrowRefunded(uint256 _tokenId, address _recipient, uint256 _amount)
+func (_DeliveryContract *DeliveryContractFilterer) WatchEscrowRefunded(opts *bind.WatchOpts, sink chan<- *DeliveryContractEscrowRefunded) (event.Subscription, error) {
+
+	logs, sub, err := _DeliveryContract.contract.WatchLogs(opts, "EscrowRefunded")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(DeliveryContractEscrowRefunded)
+				if err := _DeliveryContract.contract.UnpackLog(event, "EscrowRefunded", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseEscrowRefunded is a log parse operation binding the contract event 0xeac97bc1917fcedc984e3d0671d4e83b359890323d5d1c2de32b28d17c356ced.
+//
+// Solidity: event EscrowRefunded(uint256 _tokenId, address _recipient, uint256 _amount)
+func (_DeliveryContract *DeliveryContractFilterer) ParseEscrowRefunded(log types.Log) (*DeliveryContractEscrowRefunded, error) {
+	event := new(DeliveryContractEscrowRefunded)
+	if err := _DeliveryContract.contract.UnpackLog(event, "EscrowRefunded", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// DeliveryContractEscrowReleasedIterator is returned from FilterEscrowReleased and is used to iterate over the raw logs and unpacked data for EscrowReleased events raised by the DeliveryContract contract.
+type DeliveryContractEscrowReleasedIterator struct {
+	Event *DeliveryContractEscrowReleased // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *DeliveryContractEscrowReleasedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(DeliveryContractEscrowReleased)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(DeliveryContractEscrowReleased)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *DeliveryContractEscrowReleasedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *DeliveryContractEscrowReleasedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// DeliveryContractEscrowReleased represents a EscrowReleased event raised by the DeliveryContract contract.
+type DeliveryContractEscrowReleased struct {
+	TokenId  *big.Int
+	Evidence [32]byte
+	Amount   *big.Int
+	Raw      types.Log // Blockchain specific contextual infos
+}
+
+// FilterEscrowReleased is a free log retrieval operation binding the contract event 0xacb46c189dba65e49e2e6d449aa3672d58da985c726c29b54d4329f92ad6aa46.
+//
+// Solidity: event EscrowReleased(uint256 _tokenId, bytes32 _evidence, uint256 _amount)
+func (_DeliveryContract *DeliveryContractFilterer) FilterEscrowReleased(opts *bind.FilterOpts) (*DeliveryContractEscrowReleasedIterator, error) {
+
+	logs, sub, err := _DeliveryContract.contract.FilterLogs(opts, "EscrowReleased")
+	if err != nil {
+		return nil, err
+	}
+	return &DeliveryContractEscrowReleasedIterator{contract: _DeliveryContract.contract, event: "EscrowReleased", logs: logs, sub: sub}, nil
+}
+
+// WatchEscrowReleased is a free log subscription operation binding the contract event 0xacb46c189dba65e49e2e6d449aa3672d58da985c726c29b54d4329f92ad6aa46.
+//
+// Solidity: event EscrowReleased(uint256 _tokenId, bytes32 _evidence, uint256 _amount)
+func (_DeliveryContract *DeliveryContractFilterer) WatchEscrowReleased(opts *bind.WatchOpts, sink chan<- *DeliveryContractEscrowReleased) (event.Subscription, error) {
+
+	logs, sub, err := _DeliveryContract.contract.WatchLogs(opts, "EscrowReleased")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(DeliveryContractEscrowReleased)
+				if err := _DeliveryContract.contract.UnpackLog(event, "EscrowReleased", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseEscrowReleased is a log parse operation binding the contract event 0xacb46c189dba65e49e2e6d449aa3672d58da985c726c29b54d4329f92ad6aa46.
+//
+// Solidity: event EscrowReleased(uint256 _tokenId, bytes32 _evidence, uint256 _amount)
+func (_DeliveryContract *DeliveryContractFilterer) ParseEscrowReleased(log types.Log) (*DeliveryContractEscrowReleased, error) {
+	event := new(DeliveryContractEscrowReleased)
+	if err := _DeliveryContract.contract.UnpackLog(event, "EscrowReleased", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
 // DeliveryContractNFTMintedIterator is returned from FilterNFTMinted and is used to iterate over the raw logs and unpacked data for NFTMinted events raised by the DeliveryContract contract.
 type DeliveryContractNFTMintedIterator struct {
 	Event *DeliveryContractNFTMinted // Event containing the contract specifics and raw log
@@ -0,0 +1,114 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	log "github.com/sirupsen/logrus"
+)
+
+// Returned when the deployed contract was built before escrow expiry existed
+var ErrEscrowUnsupported = errors.New("the delivery contract does not support escrow expiry")
+
+// Returns how long payments stay in escrow for orders paid from now on. Returns ErrEscrowUnsupported if the
+// contract doesn't know about escrow expiry.
+func (_exec *DeliveryContractExecutor) EscrowWindow(ctx context.Context) (_ time.Duration, err error) {
+	ctx, span := tracing.Start(ctx, "contract.EscrowWindow")
+	defer tracing.End(span, &err)
+
+	window, err := _exec.ContractInstance.EscrowWindow(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrEscrowUnsupported, err)
+	}
+	return time.Duration(window.Int64()) * time.Second, nil
+}
+
+// Changes how long payments stay in escrow for orders paid from now on, and waits for it to be mined
+func (_exec *DeliveryContractExecutor) SetEscrowWindow(ctx context.Context, window time.Duration) (err error) {
+	ctx, span := tracing.Start(ctx, "contract.SetEscrowWindow")
+	defer tracing.End(span, &err)
+	defer observe("set_escrow_window", time.Now(), &err)
+
+	tx, err := _exec.transact(ctx, _exec.ServerPrivateKey, func(txOpts *bind.TransactOpts) (*types.Transaction, error) {
+		return _exec.ContractInstance.SetEscrowWindow(txOpts, big.NewInt(int64(window/time.Second)))
+	})
+	if err != nil {
+		return classifySubmitError(err)
+	}
+	log.Infof("Tx sent with ID [%s] to set the escrow window to %v", tx.Hash().Hex(), window)
+	return _exec.waitForMining(ctx, tx.Hash())
+}
+
+// Returns when the escrow for the token can be settled without the customer, or the zero time if the
+// order hasn't been paid for
+func (_exec *DeliveryContractExecutor) EscrowExpiresAt(ctx context.Context, tokenId int64) (_ time.Time, err error) {
+	ctx, span := tracing.Start(ctx, "contract.EscrowExpiresAt", tracing.TokenId.Int64(tokenId))
+	defer tracing.End(span, &err)
+
+	expiresAt, err := _exec.ContractInstance.EscrowExpiresAt(&bind.CallOpts{Context: ctx}, big.NewInt(tokenId))
+	if err != nil {
+		return time.Time{}, err
+	} else if expiresAt.Sign() == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(expiresAt.Int64(), 0).UTC(), nil
+}
+
+// Whether the escrow for the token was released or refunded after it expired
+func (_exec *DeliveryContractExecutor) IsEscrowSettled(ctx context.Context, tokenId int64) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "contract.IsEscrowSettled", tracing.TokenId.Int64(tokenId))
+	defer tracing.End(span, &err)
+
+	return _exec.ContractInstance.IsSettled(&bind.CallOpts{Context: ctx}, big.NewInt(tokenId))
+}
+
+// Sends the transaction that pays the expired escrow to the vendor and hands the token to the customer,
+// without waiting for it to be mined. evidence identifies the proof of delivery. Returns the hash of the
+// transaction.
+func (_exec *DeliveryContractExecutor) SubmitRelease(ctx context.Context, tokenId int64, evidence common.Hash) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "contract.SubmitRelease", tracing.TokenId.Int64(tokenId))
+	defer tracing.End(span, &err)
+	defer observe("release", time.Now(), &err)
+
+	tx, err := _exec.transact(ctx, _exec.ServerPrivateKey, func(txOpts *bind.TransactOpts) (*types.Transaction, error) {
+		return _exec.ContractInstance.ReleaseEscrow(txOpts, big.NewInt(tokenId), evidence)
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error releasing the escrow: %w", classifySubmitError(err))
+	}
+	span.SetAttributes(tracing.TxHash.String(tx.Hash().Hex()))
+	log.Infof("Tx sent with ID [%s] to release the escrow for token [%d]", tx.Hash().Hex(), tokenId)
+	return tx.Hash().Hex(), nil
+}
+
+// Sends the transaction that refunds the expired escrow to the customer, without waiting for it to be
+// mined. The vendor signs it unless customerKey is given. Returns the hash of the transaction.
+func (_exec *DeliveryContractExecutor) SubmitRefund(ctx context.Context, tokenId int64, customerKey string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "contract.SubmitRefund", tracing.TokenId.Int64(tokenId))
+	defer tracing.End(span, &err)
+	defer observe("refund", time.Now(), &err)
+
+	privKey := _exec.ServerPrivateKey
+	if len(customerKey) != 0 {
+		if privKey, err = crypto.HexToECDSA(customerKey); err != nil {
+			return "", err
+		}
+	}
+	tx, err := _exec.transact(ctx, privKey, func(txOpts *bind.TransactOpts) (*types.Transaction, error) {
+		return _exec.ContractInstance.RefundEscrow(txOpts, big.NewInt(tokenId))
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error refunding the escrow: %w", classifySubmitError(err))
+	}
+	span.SetAttributes(tracing.TxHash.String(tx.Hash().Hex()))
+	log.Infof("Tx sent with ID [%s] to refund the escrow for token [%d]", tx.Hash().Hex(), tokenId)
+	return tx.Hash().Hex(), nil
+}
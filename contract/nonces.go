@@ -0,0 +1,75 @@
+package contract
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// An address the service sends transactions from
+type sender struct {
+	sync.Mutex
+	// the nonce after the last transaction this process sent, and when it was sent. The node's pending count
+	// can lag behind a transaction it has only just accepted, so for a while this is trusted over the node.
+	next   uint64
+	sentAt time.Time
+}
+
+var sendersLock sync.Mutex
+
+// every address that has sent a transaction. Shared by all executors, since a customer's key can pay
+// on more than one vendor's contract.
+var senders = map[common.Address]*sender{}
+
+func senderFor(address common.Address) *sender {
+	sendersLock.Lock()
+	defer sendersLock.Unlock()
+
+	s, ok := senders[address]
+	if !ok {
+		s = &sender{}
+		senders[address] = s
+	}
+	return s
+}
+
+// Sends one transaction signed with the key. The nonce is picked and the transaction is sent while holding
+// the address's lock, so transactions from the same address never share a nonce and reach the node in the
+// order they were numbered. send builds and sends the transaction with the options it is given.
+func (_exec *DeliveryContractExecutor) transact(
+	ctx context.Context,
+	privateKey *ecdsa.PrivateKey,
+	send func(txOpts *bind.TransactOpts) (*types.Transaction, error),
+) (*types.Transaction, error) {
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	s := senderFor(address)
+	s.Lock()
+	defer s.Unlock()
+
+	nonce, err := _exec.Client.PendingNonceAt(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if s.next > nonce && time.Since(s.sentAt) < MiningTimeout {
+		nonce = s.next
+	}
+
+	txOpts := bind.NewKeyedTransactor(privateKey)
+	txOpts.Nonce = new(big.Int).SetUint64(nonce)
+	txOpts.Context = ctx
+
+	tx, err := send(txOpts)
+	if err != nil {
+		return nil, err
+	}
+	s.next = nonce + 1
+	s.sentAt = time.Now()
+	return tx, nil
+}
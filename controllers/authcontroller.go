@@ -0,0 +1,403 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/vendors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Signs customers in with their wallets and manages API keys for back-office systems
+type AuthController struct {
+	// where API keys, nonces and sessions are stored
+	Repository auth.AuthRepository
+	// the domain that sign-in messages must be addressed to, e.g. "shop.example.com"
+	Domain string
+	// the chain that sign-in messages must be for
+	ChainId int64
+	// how long a sign-in nonce can be used for
+	NonceTtl time.Duration
+	// how long a customer stays signed in, unless the signed message expires sooner
+	SessionTtl time.Duration
+	// checks that the vendor a key is issued for exists
+	Vendors *vendors.Registry
+}
+
+// A single-use value to put in a Sign-In with Ethereum message
+type NonceResponse struct {
+	// put this in the message's Nonce field
+	Nonce string `json:"nonce"`
+	// the domain the message must be addressed to
+	Domain string `json:"domain"`
+	// the chain ID the message must be for
+	ChainId int64 `json:"chainId"`
+}
+
+// The request body for signing in with Ethereum
+type SiweRequest struct {
+	// the EIP-4361 message, exactly as it was signed
+	Message string `json:"message"`
+	// the signature over the message produced by personal_sign, hex encoded
+	Signature string `json:"signature"`
+}
+
+// A customer's session
+type SessionResponse struct {
+	// send this as a bearer token: "Authorization: Bearer <token>"
+	Token string `json:"token"`
+	// the address the customer proved they control
+	Address string `json:"address" format:"address"`
+	// when the session ends
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Who the caller is
+type PrincipalResponse struct {
+	// the API key ID or the customer's address
+	Subject string `json:"subject"`
+	// one of ('vendor_admin', 'customer', 'courier', 'auditor')
+	Role string `json:"role"`
+	// the ethereum address the caller proved they control. Only set for customers.
+	Address string `json:"address,omitempty" format:"address"`
+	// the only vendor the caller can act for. Empty if they can act for any vendor.
+	VendorId string `json:"vendorId,omitempty"`
+}
+
+// The request body for creating an API key
+type ApiKeyRequest struct {
+	// a label for whoever will use the key
+	Name string `json:"name"`
+	// one of ('vendor_admin', 'courier', 'auditor')
+	Role string `json:"role"`
+	// the only vendor the key can act for. Leave it out for a marketplace key that can act for any vendor.
+	// A vendor's own keys can only issue keys for that vendor.
+	VendorId string `json:"vendorId"`
+}
+
+// An API key. The key itself is only included when it is created.
+type ApiKeyResponse struct {
+	// identifies the key
+	KeyId string `json:"keyId"`
+	// a label for whoever uses the key
+	Name string `json:"name"`
+	// the role the key grants
+	Role string `json:"role"`
+	// the only vendor the key can act for. Empty for marketplace keys.
+	VendorId string `json:"vendorId,omitempty"`
+	// the secret to send in the X-API-Key header. It cannot be retrieved again.
+	Key string `json:"key,omitempty"`
+	// when the key was created
+	CreatedAt time.Time `json:"createdAt"`
+	// when the key was revoked, if it has been
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// CreateNonce godoc
+// @Summary      Start signing in with Ethereum
+// @Description  Returns a single-use nonce to include in an EIP-4361 (Sign-In with Ethereum) message
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  NonceResponse
+// @Failure      500  {object}  ApiError
+// @Router       /auth/nonce [post]
+func (_ctrl *AuthController) CreateNonce(ctx *gin.Context) {
+	nonce, err := auth.NewSecret(16)
+	if err == nil {
+		err = _ctrl.Repository.CreateNonce(nonce, _ctrl.NonceTtl)
+	}
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, NonceResponse{
+		Nonce:   nonce,
+		Domain:  _ctrl.Domain,
+		ChainId: _ctrl.ChainId,
+	})
+}
+
+// SignInWithEthereum godoc
+// @Summary      Sign in with Ethereum
+// @Description  Verifies a signed EIP-4361 message and starts a customer session for the address that signed it.
+// @Description  The customer can then see and act on the orders that are to be delivered to that address.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  SiweRequest  true  "The signed message"
+// @Success      200  {object}  SessionResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /auth/siwe [post]
+func (_ctrl *AuthController) SignInWithEthereum(ctx *gin.Context) {
+	var req SiweRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, invalidRequest(err))
+		return
+	}
+
+	// the messages from checking the sign-in message are safe to pass on; they only describe the message
+	message, err := auth.ParseSiweMessage(req.Message)
+	if err != nil {
+		errorResponse(ctx, apierrors.Wrap(err, apierrors.CodeInvalidSignInMessage, err.Error()))
+		return
+	}
+
+	now := time.Now()
+	if err = message.Validate(_ctrl.Domain, _ctrl.ChainId, now); err != nil {
+		errorResponse(ctx, apierrors.Wrap(err, apierrors.CodeInvalidCredentials, err.Error()))
+		return
+	}
+	if err = auth.VerifySiweSignature(req.Message, message.Address, req.Signature); err != nil {
+		errorResponse(ctx, apierrors.Wrap(err, apierrors.CodeInvalidCredentials, err.Error()))
+		return
+	}
+
+	// only use up the nonce once we know the message is good, so a bad attempt doesn't burn it
+	fresh, err := _ctrl.Repository.ConsumeNonce(message.Nonce)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	} else if !fresh {
+		errorResponse(ctx, apierrors.New(apierrors.CodeInvalidCredentials, "The nonce is unknown, expired, or already used"))
+		return
+	}
+
+	token, err := auth.NewSecret(32)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	expiresAt := now.Add(_ctrl.SessionTtl)
+	if !message.ExpirationTime.IsZero() && message.ExpirationTime.Before(expiresAt) {
+		expiresAt = message.ExpirationTime
+	}
+
+	err = _ctrl.Repository.CreateSession(&auth.Session{
+		TokenHash: auth.HashSecret(token),
+		Address:   message.Address,
+		Role:      auth.RoleCustomer,
+		CreatedAt: now.UTC(),
+		ExpiresAt: expiresAt.UTC(),
+	})
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	log.Infof("Customer [%s] signed in", message.Address)
+	ctx.JSON(200, SessionResponse{
+		Token:     token,
+		Address:   message.Address,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// SignOut godoc
+// @Summary      Sign out
+// @Description  Ends the caller's customer session
+// @Tags         auth
+// @Security     BearerAuth
+// @Success      204
+// @Failure      401  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /auth/logout [post]
+func (_ctrl *AuthController) SignOut(ctx *gin.Context) {
+	token, ok := bearerToken(ctx)
+	if !ok {
+		errorResponse(ctx, apierrors.New(apierrors.CodeUnauthenticated, "Authentication required"))
+		return
+	}
+
+	if err := _ctrl.Repository.DeleteSession(auth.HashSecret(token)); err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// WhoAmI godoc
+// @Summary      Who am I
+// @Description  Describes the authenticated caller
+// @Tags         auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Success      200  {object}  PrincipalResponse
+// @Failure      401  {object}  ApiError
+// @Router       /auth/me [get]
+func (_ctrl *AuthController) WhoAmI(ctx *gin.Context) {
+	principal := principalFrom(ctx)
+	ctx.JSON(200, PrincipalResponse{
+		Subject:  principal.Subject,
+		Role:     string(principal.Role),
+		Address:  principal.Address,
+		VendorId: principal.VendorId,
+	})
+}
+
+// CreateApiKey godoc
+// @Summary      Create API key
+// @Description  Issues an API key for a back-office system. The key is only shown once. A vendor's keys can only
+// @Description  issue keys for that vendor.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body  ApiKeyRequest  true  "Who the key is for and what it may do"
+// @Param        X-Vendor-Id  header  string  false  "the vendor to issue the key for, if the body doesn't say"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      201  {object}  ApiKeyResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /apikeys [post]
+func (_ctrl *AuthController) CreateApiKey(ctx *gin.Context) {
+	var req ApiKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, invalidRequest(err))
+		return
+	}
+
+	role, ok := auth.ParseRole(req.Role)
+	if len(req.Name) == 0 {
+		errorResponse(ctx, missingParameters("name"))
+		return
+	} else if !ok || role == auth.RoleCustomer {
+		// customers are tied to an address, so they have to sign in with their wallet
+		errorResponse(ctx, invalidParameter("role", "Invalid role. Expected 'vendor_admin', 'courier', or 'auditor'"))
+		return
+	}
+
+	// a vendor can't hand out keys that reach beyond their own orders
+	vendorId := req.VendorId
+	if len(vendorId) == 0 {
+		vendorId = vendorFrom(ctx)
+	}
+	if !principalFrom(ctx).CanActFor(vendorId) {
+		errorResponse(ctx, apierrors.New(apierrors.CodeForbidden, "Keys can only be issued for vendor [%s]", principalFrom(ctx).VendorId))
+		return
+	} else if len(vendorId) != 0 {
+		if _, err := _ctrl.Vendors.GetVendor(vendorId); err != nil {
+			errorResponse(ctx, err)
+			return
+		}
+	}
+
+	secret, err := auth.NewSecret(32)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	key := &auth.ApiKey{
+		KeyId:    uuid.New().String(),
+		Name:     req.Name,
+		KeyHash:  auth.HashSecret(secret),
+		Role:     role,
+		VendorId: vendorId,
+	}
+	if err = _ctrl.Repository.CreateApiKey(key); err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	log.Infof("API key [%s] created for [%s] with role [%s] by [%s]", key.KeyId, key.Name, key.Role, principalFrom(ctx).Subject)
+	response := toApiKeyResponse(key)
+	response.Key = secret
+	ctx.JSON(201, response)
+}
+
+// ListApiKeys godoc
+// @Summary      List API keys
+// @Description  Lists every API key, including revoked ones. The keys themselves are not included. A vendor's keys,
+// @Description  or the X-Vendor-Id header, only list that vendor's keys.
+// @Tags         auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        X-Vendor-Id  header  string  false  "only the vendor's keys"
+// @Success      200  {array}   ApiKeyResponse
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /apikeys [get]
+func (_ctrl *AuthController) ListApiKeys(ctx *gin.Context) {
+	keys, err := _ctrl.Repository.ListApiKeys()
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	vendorId := vendorFrom(ctx)
+	response := []ApiKeyResponse{}
+	for _, key := range keys {
+		if len(vendorId) != 0 && key.VendorId != vendorId {
+			continue
+		}
+		response = append(response, toApiKeyResponse(key))
+	}
+	ctx.JSON(200, response)
+}
+
+// RevokeApiKey godoc
+// @Summary      Revoke API key
+// @Description  Stops an API key from working
+// @Tags         auth
+// @Security     ApiKeyAuth
+// @Param        keyId  path  string  true  "the ID of the key"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      204
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /apikeys/{keyId} [delete]
+func (_ctrl *AuthController) RevokeApiKey(ctx *gin.Context) {
+	keyId := ctx.Param("keyId")
+	// another vendor's keys, and the marketplace's, look like they don't exist to a vendor
+	key, err := _ctrl.Repository.GetApiKey(keyId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	} else if key == nil || (principalFrom(ctx).ScopedToVendor() && key.VendorId != principalFrom(ctx).VendorId) {
+		errorResponse(ctx, apiKeyNotFound(keyId))
+		return
+	}
+
+	found, err := _ctrl.Repository.RevokeApiKey(keyId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	} else if !found {
+		errorResponse(ctx, apiKeyNotFound(keyId))
+		return
+	}
+
+	log.Infof("API key [%s] revoked by [%s]", keyId, principalFrom(ctx).Subject)
+	ctx.Status(204)
+}
+
+func toApiKeyResponse(key *auth.ApiKey) ApiKeyResponse {
+	return ApiKeyResponse{
+		KeyId:     key.KeyId,
+		Name:      key.Name,
+		Role:      string(key.Role),
+		VendorId:  key.VendorId,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+
+func apiKeyNotFound(keyId string) error {
+	return apierrors.New(apierrors.CodeApiKeyNotFound, "API key [%s] does not exist", keyId).With("keyId", keyId)
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+// a well-known development key, so the address is stable
+var customerKey = "59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+var customerAddress = "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+
+// Keeps sign-in nonces and sessions in memory
+type fakeSignInRepository struct {
+	auth.AuthRepository
+
+	mu       sync.Mutex
+	nonces   map[string]time.Time
+	sessions map[string]*auth.Session
+}
+
+func (repo *fakeSignInRepository) CreateNonce(nonce string, ttl time.Duration) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.nonces[nonce] = time.Now().Add(ttl)
+	return nil
+}
+
+func (repo *fakeSignInRepository) ConsumeNonce(nonce string) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	expiresAt, found := repo.nonces[nonce]
+	delete(repo.nonces, nonce)
+	return found && time.Now().Before(expiresAt), nil
+}
+
+func (repo *fakeSignInRepository) CreateSession(session *auth.Session) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.sessions[session.TokenHash] = session
+	return nil
+}
+
+func (repo *fakeSignInRepository) GetSession(tokenHash string) (*auth.Session, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return repo.sessions[tokenHash], nil
+}
+
+func (repo *fakeSignInRepository) GetApiKeyByHash(keyHash string) (*auth.ApiKey, error) {
+	return nil, nil
+}
+
+// Serves the sign-in endpoints for shop.example.com on chain 1337
+func newSignInTestServer(t *testing.T) (*httptest.Server, *fakeSignInRepository) {
+	gin.SetMode(gin.TestMode)
+
+	repo := &fakeSignInRepository{nonces: map[string]time.Time{}, sessions: map[string]*auth.Session{}}
+	router := &ApiRouter{
+		AuthController: &AuthController{
+			Repository: repo,
+			Domain:     "shop.example.com",
+			ChainId:    1337,
+			NonceTtl:   10 * time.Minute,
+			SessionTtl: time.Hour,
+		},
+		Authenticator: &Authenticator{Verifier: &auth.Verifier{Repository: repo}},
+	}
+	server := httptest.NewServer(router.Server().Handler)
+	t.Cleanup(server.Close)
+	return server, repo
+}
+
+func postJson(t *testing.T, server *httptest.Server, path string, body interface{}) *http.Response {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(string(encoded)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func requestNonce(t *testing.T, server *httptest.Server) string {
+	resp := postJson(t, server, "/api/v1/auth/nonce", nil)
+	var nonce NonceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&nonce); err != nil {
+		t.Fatal(err)
+	}
+	return nonce.Nonce
+}
+
+// A sign-in message for the customer's address that is good for the next hour
+func signInMessage(domain string, chainId int64, nonce string) string {
+	now := time.Now().UTC()
+	return fmt.Sprintf("%s wants you to sign in with your Ethereum account:\n%s\n\nSign in to the Vendor API\n\n"+
+		"URI: https://%s/login\nVersion: 1\nChain ID: %d\nNonce: %s\nIssued At: %s\nExpiration Time: %s",
+		domain, customerAddress, domain, chainId, nonce, now.Format(time.RFC3339), now.Add(time.Hour).Format(time.RFC3339))
+}
+
+// What personal_sign produces for the message
+func signMessage(t *testing.T, keyHex string, message string) string {
+	key, err := crypto.HexToECDSA(keyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(signature)
+}
+
+func TestSignInWithEthereum(t *testing.T) {
+	server, _ := newSignInTestServer(t)
+
+	message := signInMessage("shop.example.com", 1337, requestNonce(t, server))
+	resp := postJson(t, server, "/api/v1/auth/siwe", SiweRequest{Message: message, Signature: signMessage(t, customerKey, message)})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %+v", resp.StatusCode, decodeApiError(t, resp))
+	}
+	var session SessionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		t.Fatal(err)
+	}
+	if session.Address != customerAddress || len(session.Token) == 0 {
+		t.Fatalf("expected a session for [%s], got %+v", customerAddress, session)
+	}
+
+	// the session signs the customer in
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/auth/me", nil)
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+	me, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer me.Body.Close()
+	var principal PrincipalResponse
+	if err = json.NewDecoder(me.Body).Decode(&principal); err != nil {
+		t.Fatal(err)
+	}
+	if principal.Role != string(auth.RoleCustomer) || principal.Address != customerAddress {
+		t.Errorf("expected to be signed in as customer [%s], got %+v", customerAddress, principal)
+	}
+
+	// a signed message can only be used once
+	replayed := postJson(t, server, "/api/v1/auth/siwe", SiweRequest{Message: message, Signature: signMessage(t, customerKey, message)})
+	if replayed.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected the replay to get 401, got %d", replayed.StatusCode)
+	} else if apiErr := decodeApiError(t, replayed); apiErr.Code != apierrors.CodeInvalidCredentials {
+		t.Errorf("expected %s, got %s", apierrors.CodeInvalidCredentials, apiErr.Code)
+	}
+}
+
+func TestSignInWithEthereumErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		// makes the message and its signature, given a nonce the server handed out
+		request func(t *testing.T, nonce string) SiweRequest
+		status  int
+		code    apierrors.Code
+	}{
+		{"for another domain", func(t *testing.T, nonce string) SiweRequest {
+			message := signInMessage("evil.example.com", 1337, nonce)
+			return SiweRequest{Message: message, Signature: signMessage(t, customerKey, message)}
+		}, 401, apierrors.CodeInvalidCredentials},
+		{"for another chain", func(t *testing.T, nonce string) SiweRequest {
+			message := signInMessage("shop.example.com", 1, nonce)
+			return SiweRequest{Message: message, Signature: signMessage(t, customerKey, message)}
+		}, 401, apierrors.CodeInvalidCredentials},
+		{"with an unknown nonce", func(t *testing.T, nonce string) SiweRequest {
+			message := signInMessage("shop.example.com", 1337, "0123456789abcdef")
+			return SiweRequest{Message: message, Signature: signMessage(t, customerKey, message)}
+		}, 401, apierrors.CodeInvalidCredentials},
+		{"signed by another key", func(t *testing.T, nonce string) SiweRequest {
+			message := signInMessage("shop.example.com", 1337, nonce)
+			return SiweRequest{Message: message, Signature: signMessage(t, "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80", message)}
+		}, 401, apierrors.CodeInvalidCredentials},
+		{"with a malformed signature", func(t *testing.T, nonce string) SiweRequest {
+			return SiweRequest{Message: signInMessage("shop.example.com", 1337, nonce), Signature: "0x1234"}
+		}, 401, apierrors.CodeInvalidCredentials},
+		{"that isn't a sign-in message", func(t *testing.T, nonce string) SiweRequest {
+			return SiweRequest{Message: "let me in", Signature: signMessage(t, customerKey, "let me in")}
+		}, 400, apierrors.CodeInvalidSignInMessage},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, repo := newSignInTestServer(t)
+			nonce := requestNonce(t, server)
+
+			resp := postJson(t, server, "/api/v1/auth/siwe", test.request(t, nonce))
+			if resp.StatusCode != test.status {
+				t.Fatalf("expected %d, got %d", test.status, resp.StatusCode)
+			}
+			if apiErr := decodeApiError(t, resp); apiErr.Code != test.code {
+				t.Errorf("expected %s, got %s", test.code, apiErr.Code)
+			}
+			if len(repo.sessions) != 0 {
+				t.Error("expected no session to be started")
+			}
+			// a bad attempt mustn't burn the customer's nonce
+			if _, kept := repo.nonces[nonce]; !kept {
+				t.Error("expected the nonce to still be usable")
+			}
+		})
+	}
+}
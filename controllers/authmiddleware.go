@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/vendors"
+	"github.com/gin-gonic/gin"
+)
+
+// the header back-office systems put their API key in
+var apiKeyHeader = "X-API-Key"
+
+// where the authenticated principal is kept in the gin context
+var principalContextKey = "principal"
+
+// the header marketplace staff pick the vendor they are working for with
+var vendorHeader = "X-Vendor-Id"
+
+// where the vendor the request is for is kept in the gin context
+var vendorContextKey = "vendorId"
+
+// Works out who is calling the API, and whether they are allowed to.
+//
+// Back-office systems authenticate with an API key in the X-API-Key header. Customers sign in with
+// their wallet (see AuthController) and send the resulting session token as a bearer token.
+//
+// A vendor's API keys only ever act for that vendor. Everyone else can narrow a request down to one vendor
+// with the X-Vendor-Id header.
+type Authenticator struct {
+	// checks the credentials
+	Verifier *auth.Verifier
+	// checks that the vendor in the X-Vendor-Id header exists
+	Vendors *vendors.Registry
+}
+
+// The gin middleware that identifies the caller. Requests without credentials are let through
+// anonymously so that Require can decide what to do with them; bad credentials are rejected.
+func (_auth *Authenticator) Authenticate(ctx *gin.Context) {
+	if apiKey := ctx.GetHeader(apiKeyHeader); len(apiKey) != 0 {
+		principal, err := _auth.Verifier.VerifyApiKey(apiKey)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		} else if principal == nil {
+			abortWithError(ctx, apierrors.New(apierrors.CodeInvalidCredentials, "Invalid API key"))
+			return
+		}
+		ctx.Set(principalContextKey, principal)
+	} else if token, ok := bearerToken(ctx); ok {
+		principal, err := _auth.Verifier.VerifySessionToken(token)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		} else if principal == nil {
+			abortWithError(ctx, apierrors.New(apierrors.CodeInvalidCredentials, "Invalid or expired session"))
+			return
+		}
+		ctx.Set(principalContextKey, principal)
+	}
+
+	if !_auth.resolveVendor(ctx) {
+		return
+	}
+	ctx.Next()
+}
+
+// Works out which vendor the request is for: the API key's vendor, or whichever one the caller picked with
+// the X-Vendor-Id header. Returns false if the request was rejected.
+func (_auth *Authenticator) resolveVendor(ctx *gin.Context) bool {
+	principal := principalFrom(ctx)
+	vendorId := ctx.GetHeader(vendorHeader)
+	if len(vendorId) == 0 {
+		if principal != nil && principal.ScopedToVendor() {
+			ctx.Set(vendorContextKey, principal.VendorId)
+		}
+		return true
+	}
+
+	if !principal.CanActFor(vendorId) {
+		abortWithError(ctx, apierrors.New(apierrors.CodeForbidden, "The API key can only act for vendor [%s]", principal.VendorId))
+		return false
+	}
+	if _, err := _auth.Vendors.GetVendor(vendorId); err != nil {
+		abortWithError(ctx, err)
+		return false
+	}
+	ctx.Set(vendorContextKey, vendorId)
+	return true
+}
+
+// A gin middleware for the parts of the API that belong to the marketplace as a whole, which a vendor's
+// API keys can't use
+func (_auth *Authenticator) Marketplace(ctx *gin.Context) {
+	if principal := principalFrom(ctx); principal != nil && principal.ScopedToVendor() {
+		abortWithError(ctx, apierrors.New(apierrors.CodeForbidden, "Only the marketplace can do that, not a vendor"))
+		return
+	}
+	ctx.Next()
+}
+
+// Builds a gin middleware that only lets through callers with one of the given roles
+func (_auth *Authenticator) Require(roles ...auth.Role) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal := principalFrom(ctx)
+		if principal == nil {
+			abortWithError(ctx, apierrors.New(apierrors.CodeUnauthenticated, "Authentication required"))
+			return
+		} else if !principal.HasRole(roles...) {
+			abortWithError(ctx, apierrors.New(apierrors.CodeForbidden, "Not allowed"))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// Pulls the token out of an "Authorization: Bearer <token>" header. Browsers can't set headers on
+// EventSources and WebSockets, so reads may pass it in an access_token query parameter instead.
+func bearerToken(ctx *gin.Context) (string, bool) {
+	scheme, token, found := strings.Cut(ctx.GetHeader("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") && len(token) != 0 {
+		return token, true
+	}
+	if ctx.Request.Method == http.MethodGet {
+		if token = ctx.Query("access_token"); len(token) != 0 {
+			return token, true
+		}
+	}
+	return "", false
+}
+
+// Returns the authenticated caller, or nil if the request is anonymous
+func principalFrom(ctx *gin.Context) *auth.Principal {
+	value, exists := ctx.Get(principalContextKey)
+	if !exists {
+		return nil
+	}
+	return value.(*auth.Principal)
+}
+
+// Returns the vendor the request is for, or empty if it is for every vendor the caller can act for
+func vendorFrom(ctx *gin.Context) string {
+	return ctx.GetString(vendorContextKey)
+}
+
+// Returns the vendor the request is for, or the default vendor if it doesn't say
+func vendorOrDefault(ctx *gin.Context) string {
+	if vendorId := vendorFrom(ctx); len(vendorId) != 0 {
+		return vendorId
+	}
+	return vendors.DefaultVendorId
+}
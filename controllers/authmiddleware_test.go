@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// The routes anybody may call. Everything else must turn away callers without credentials.
+var publicRoutes = map[string]bool{
+	"GET /api/v1/products":            true,
+	"GET /api/v1/products/:productId": true,
+	"POST /api/v1/auth/nonce":         true,
+	"POST /api/v1/auth/siwe":          true,
+	"POST /api/v1/auth/logout":        true,
+	"GET /healthz":                    true,
+	"GET /readyz":                     true,
+	"GET /metrics":                    true,
+	"GET /swagger/*any":               true,
+}
+
+// Every combination of roles a route could require
+func roleSets() [][]auth.Role {
+	sets := [][]auth.Role{}
+	for mask := 1; mask < 1<<len(auth.AllRoles); mask++ {
+		set := []auth.Role{}
+		for i, role := range auth.AllRoles {
+			if mask&(1<<i) != 0 {
+				set = append(set, role)
+			}
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+func TestRequireRejectsRolesThatAreNotListed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, roles := range roleSets() {
+		router := gin.New()
+		// stands in for Authenticate: the caller is whoever the header says
+		router.Use(func(ctx *gin.Context) {
+			if role := ctx.GetHeader("X-Test-Role"); len(role) != 0 {
+				ctx.Set(principalContextKey, &auth.Principal{Subject: "test", Role: auth.Role(role)})
+			}
+		})
+		authenticator := &Authenticator{}
+		router.GET("/test", authenticator.Require(roles...), func(ctx *gin.Context) {
+			ctx.Status(http.StatusNoContent)
+		})
+
+		allowed := map[auth.Role]bool{}
+		for _, role := range roles {
+			allowed[role] = true
+		}
+		callers := append([]auth.Role{"", "superuser"}, auth.AllRoles...)
+		for _, role := range callers {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if len(role) != 0 {
+				req.Header.Set("X-Test-Role", string(role))
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			expected := http.StatusForbidden
+			if len(role) == 0 {
+				expected = http.StatusUnauthorized
+			} else if allowed[role] {
+				expected = http.StatusNoContent
+			}
+			if recorder.Code != expected {
+				t.Errorf("expected a route for %v to give [%s] %d, got %d", roles, role, expected, recorder.Code)
+			}
+		}
+	}
+}
+
+func TestEveryRouteRequiresCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// none of the controllers are needed, because nothing should get as far as them
+	apiRouter := &ApiRouter{
+		Authenticator: &Authenticator{Verifier: &auth.Verifier{
+			Repository:        &fakeAuthRepository{},
+			BootstrapAdminKey: testAdminKey,
+		}},
+		Idempotency: &IdempotencyMiddleware{KeyRepository: newFakeKeyRepository(), Retention: time.Hour},
+	}
+	engine := apiRouter.Server().Handler.(*gin.Engine)
+
+	found := map[string]bool{}
+	for _, route := range engine.Routes() {
+		name := route.Method + " " + route.Path
+		found[name] = true
+		if publicRoutes[name] {
+			continue
+		}
+
+		// fill in the path parameters with something plausible
+		segments := strings.Split(route.Path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+				segments[i] = "1"
+			}
+		}
+		req := httptest.NewRequest(route.Method, strings.Join(segments, "/"), strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusUnauthorized || !strings.Contains(recorder.Body.String(), string(apierrors.CodeUnauthenticated)) {
+			t.Errorf("expected %s to need credentials, got %d: %s", name, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	// so the list above can't go stale
+	for name := range publicRoutes {
+		if !found[name] {
+			t.Errorf("expected a public route %s", name)
+		}
+	}
+}
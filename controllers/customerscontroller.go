@@ -0,0 +1,419 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/customers"
+	"github.com/bdunton9323/blockchain-playground/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Manages customers' accounts, their address books, and the customers whose keys the service holds
+type CustomerController struct {
+	Registry *customers.Registry
+	// looks up the customers' orders
+	Service *service.OrderService
+}
+
+// The request body for opening or changing a customer's account
+type CustomerRequest struct {
+	// who the customer is
+	Name string `json:"name"`
+	// Optional
+	Email string `json:"email" example:"alice@example.com"`
+	// where the customer's packages go. Optional.
+	ShippingAddress *ShippingAddressBody `json:"shippingAddress,omitempty"`
+	// Give the customer their own deposit address from the service's HD wallet, and have the service sign for them.
+	// For customers without a wallet. Only when opening the account.
+	Custodial bool `json:"custodial"`
+}
+
+// A postal address
+type ShippingAddressBody struct {
+	// who the package is for, if not the customer
+	Name       string `json:"name,omitempty"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postalCode"`
+	// ISO 3166-1 alpha-2
+	Country string `json:"country" example:"GB"`
+}
+
+// A customer's account
+type CustomerResponse struct {
+	CustomerId      string               `json:"customerId"`
+	Name            string               `json:"name"`
+	Email           string               `json:"email,omitempty"`
+	ShippingAddress *ShippingAddressBody `json:"shippingAddress,omitempty"`
+	// whether the service holds the customer's keys
+	Custodial bool `json:"custodial"`
+	// A custodial customer's deposit address. Their orders are delivered to it unless they say otherwise; send ether
+	// here so the service can pay for them.
+	Address string `json:"address,omitempty" format:"address"`
+	// where the deposit address's key is in the service's HD wallet
+	DerivationPath string `json:"derivationPath,omitempty" example:"m/44'/60'/0'/0/0"`
+	// the customer's address book
+	Addresses []AddressResponse `json:"addresses"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// The request body for adding an address to a customer's address book
+type AddressRequest struct {
+	Address string `json:"address" format:"address"`
+	// what the customer calls it. Optional.
+	Label string `json:"label" example:"hardware wallet"`
+}
+
+// The request body for verifying an address
+type VerifyAddressRequest struct {
+	// the address's challenge, signed by its key with personal_sign
+	Signature string `json:"signature"`
+}
+
+// An address in a customer's address book
+type AddressResponse struct {
+	Address  string `json:"address" format:"address"`
+	Label    string `json:"label,omitempty"`
+	Verified bool   `json:"verified"`
+	// What the customer has to sign with the address's key to verify it. Only while it is unverified.
+	Challenge          string     `json:"challenge,omitempty"`
+	ChallengeExpiresAt *time.Time `json:"challengeExpiresAt,omitempty"`
+	VerifiedAt         *time.Time `json:"verifiedAt,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+}
+
+// CreateCustomer godoc
+// @Summary      Open a customer account
+// @Description  Opens an account that orders can be placed against. Customers with wallets add their addresses to it and verify them.
+// @Description  Custodial customers, who don't have wallets, get their own deposit address derived from the service's HD wallet
+// @Description  instead, and the service signs their payments and deliveries.
+// @Tags         customer
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body  CustomerRequest  true  "the customer"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      201  {object}  CustomerResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /customers [post]
+func (_ctrl *CustomerController) CreateCustomer(ctx *gin.Context) {
+	var req CustomerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, invalidRequest(err))
+		return
+	}
+
+	customer, err := _ctrl.Registry.CreateCustomer(toCustomerInput(&req))
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	_ctrl.customerResponse(ctx, 201, customer)
+}
+
+// UpdateCustomer godoc
+// @Summary      Change a customer's account
+// @Description  Replaces the customer's name, email and shipping address. Leaving the shipping address out removes it.
+// @Tags         customer
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        customerId  path  string  true  "the ID of the customer"
+// @Param        request  body  CustomerRequest  true  "the customer's details; custodial is ignored"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      200  {object}  CustomerResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /customers/{customerId} [put]
+func (_ctrl *CustomerController) UpdateCustomer(ctx *gin.Context) {
+	var req CustomerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, invalidRequest(err))
+		return
+	}
+
+	customer, err := _ctrl.Registry.UpdateCustomer(ctx.Param("customerId"), toCustomerInput(&req))
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	_ctrl.customerResponse(ctx, 200, customer)
+}
+
+// ListCustomers godoc
+// @Summary      List customers
+// @Description  Lists every customer account, in the order they were opened. Address books are left out; get a customer for theirs.
+// @Tags         customer
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array}   CustomerResponse
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /customers [get]
+func (_ctrl *CustomerController) ListCustomers(ctx *gin.Context) {
+	all, err := _ctrl.Registry.ListCustomers()
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	response := []CustomerResponse{}
+	for _, customer := range all {
+		response = append(response, toCustomerResponse(customer, nil))
+	}
+	ctx.JSON(200, response)
+}
+
+// GetCustomer godoc
+// @Summary      Get a customer
+// @Description  Looks up a customer's account and address book
+// @Tags         customer
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        customerId  path  string  true  "the ID of the customer"
+// @Success      200  {object}  CustomerResponse
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /customers/{customerId} [get]
+func (_ctrl *CustomerController) GetCustomer(ctx *gin.Context) {
+	customer, err := _ctrl.Registry.GetCustomer(ctx.Param("customerId"))
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	_ctrl.customerResponse(ctx, 200, customer)
+}
+
+// AddAddress godoc
+// @Summary      Add an address to a customer's address book
+// @Description  Adds an ethereum address to the customer's address book. Orders can't be delivered to it until the customer
+// @Description  proves they hold its key by signing the challenge in the response. Adding an unverified address again gives it a
+// @Description  new challenge.
+// @Tags         customer
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        customerId  path  string  true  "the ID of the customer"
+// @Param        request  body  AddressRequest  true  "the address"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      200  {object}  AddressResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /customers/{customerId}/addresses [post]
+func (_ctrl *CustomerController) AddAddress(ctx *gin.Context) {
+	var req AddressRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, invalidRequest(err))
+		return
+	}
+
+	address, err := _ctrl.Registry.AddAddress(ctx.Param("customerId"), req.Address, req.Label)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(200, toAddressResponse(address))
+}
+
+// VerifyAddress godoc
+// @Summary      Verify an address
+// @Description  Checks the customer's signature over the address's challenge. If the address's key made it, orders can be
+// @Description  delivered to the address. An address can only be verified by one customer.
+// @Tags         customer
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        customerId  path  string  true  "the ID of the customer"
+// @Param        address     path  string  true  "the address to verify"
+// @Param        request  body  VerifyAddressRequest  true  "the signed challenge"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      200  {object}  AddressResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /customers/{customerId}/addresses/{address}/verify [post]
+func (_ctrl *CustomerController) VerifyAddress(ctx *gin.Context) {
+	var req VerifyAddressRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, invalidRequest(err))
+		return
+	}
+
+	address, err := _ctrl.Registry.VerifyAddress(ctx.Param("customerId"), ctx.Param("address"), req.Signature)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(200, toAddressResponse(address))
+}
+
+// RemoveAddress godoc
+// @Summary      Remove an address from a customer's address book
+// @Description  Orders already placed for the address aren't affected
+// @Tags         customer
+// @Security     ApiKeyAuth
+// @Param        customerId  path  string  true  "the ID of the customer"
+// @Param        address     path  string  true  "the address to remove"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      204
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /customers/{customerId}/addresses/{address} [delete]
+func (_ctrl *CustomerController) RemoveAddress(ctx *gin.Context) {
+	if err := _ctrl.Registry.RemoveAddress(ctx.Param("customerId"), ctx.Param("address")); err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// ListCustomerOrders godoc
+// @Summary      List a customer's orders
+// @Description  Searches the orders delivered to the addresses the customer has verified. Takes the same filters and paging as
+// @Description  /orders; buyerAddress narrows it down to one of the customer's addresses.
+// @Tags         customer
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        customerId     path   string  true   "the ID of the customer"
+// @Param        buyerAddress   query  string  false  "only orders for this one of the customer's addresses"
+// @Param        status         query  string  false  "only orders in these statuses, comma separated (e.g. 'minted,paid')"
+// @Param        itemId         query  string  false  "only orders for this product"
+// @Param        createdAfter   query  string  false  "only orders placed at or after this time (RFC 3339)"
+// @Param        createdBefore  query  string  false  "only orders placed before this time (RFC 3339)"
+// @Param        sortBy         query  string  false  "the field to sort by. One of ('createdAt', 'price'). Defaults to 'createdAt'"
+// @Param        sortOrder      query  string  false  "One of ('asc', 'desc'). Defaults to 'desc'"
+// @Param        limit          query  int     false  "the maximum number of orders to return (1-200). Defaults to 50"
+// @Param        cursor         query  string  false  "the nextCursor from the previous page"
+// @Success      200  {object}  OrderListResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /customers/{customerId}/orders [get]
+func (_ctrl *CustomerController) ListCustomerOrders(ctx *gin.Context) {
+	query, err := parseOrderQuery(ctx)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	page, err := _ctrl.Service.ListCustomerOrders(ctx.Request.Context(), principalFrom(ctx), ctx.Param("customerId"), query)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	response := OrderListResponse{
+		Orders: []OrderResponse{},
+	}
+	for _, order := range page.Orders {
+		response.Orders = append(response.Orders, toOrderResponse(order))
+	}
+	if page.Next != nil {
+		response.NextCursor = page.Next.Encode()
+	}
+	ctx.JSON(200, response)
+}
+
+// Writes the customer, along with their address book
+func (_ctrl *CustomerController) customerResponse(ctx *gin.Context, status int, customer *customers.Customer) {
+	addresses, err := _ctrl.Registry.ListAddresses(customer.CustomerId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(status, toCustomerResponse(customer, addresses))
+}
+
+func toCustomerInput(req *CustomerRequest) *customers.CustomerInput {
+	input := &customers.CustomerInput{
+		Name:      req.Name,
+		Email:     req.Email,
+		Custodial: req.Custodial,
+	}
+	if ship := req.ShippingAddress; ship != nil {
+		input.ShippingAddress = customers.ShippingAddress{
+			Name:       ship.Name,
+			Line1:      ship.Line1,
+			Line2:      ship.Line2,
+			City:       ship.City,
+			Region:     ship.Region,
+			PostalCode: ship.PostalCode,
+			Country:    ship.Country,
+		}
+	}
+	return input
+}
+
+func toCustomerResponse(customer *customers.Customer, addresses []*customers.WalletAddress) CustomerResponse {
+	response := CustomerResponse{
+		CustomerId:     customer.CustomerId,
+		Name:           customer.Name,
+		Email:          customer.Email,
+		Custodial:      customer.Custodial(),
+		Address:        customer.Address,
+		DerivationPath: customer.DerivationPath,
+		Addresses:      []AddressResponse{},
+		CreatedAt:      customer.CreatedAt,
+		UpdatedAt:      customer.UpdatedAt,
+	}
+	if ship := customer.ShippingAddress; !ship.IsEmpty() {
+		response.ShippingAddress = &ShippingAddressBody{
+			Name:       ship.Name,
+			Line1:      ship.Line1,
+			Line2:      ship.Line2,
+			City:       ship.City,
+			Region:     ship.Region,
+			PostalCode: ship.PostalCode,
+			Country:    ship.Country,
+		}
+	}
+	for _, address := range addresses {
+		response.Addresses = append(response.Addresses, toAddressResponse(address))
+	}
+	return response
+}
+
+func toAddressResponse(address *customers.WalletAddress) AddressResponse {
+	response := AddressResponse{
+		Address:    address.Address,
+		Label:      address.Label,
+		Verified:   address.Verified(),
+		Challenge:  address.Challenge,
+		VerifiedAt: address.VerifiedAt,
+		CreatedAt:  address.CreatedAt,
+	}
+	if !address.Verified() && !address.ChallengeExpiresAt.IsZero() {
+		response.ChallengeExpiresAt = &address.ChallengeExpiresAt
+	}
+	return response
+}
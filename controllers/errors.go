@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// the header that ties a request to its log lines. Clients may send their own; otherwise one is made up.
+var correlationIdHeader = "X-Correlation-ID"
+
+// where the request's correlation ID is kept in the gin context
+var correlationIdContextKey = "correlationId"
+
+// what a client-supplied correlation ID may look like, so it's safe to log and echo back
+var correlationIdPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+// Error response from the API
+type ApiError struct {
+	// identifies what went wrong; see the list of error codes in the API description
+	Code apierrors.Code `json:"code" example:"ORDER_NOT_FOUND" enums:"ADDRESS_ALREADY_CLAIMED,ADDRESS_NOT_VERIFIED,API_KEY_NOT_FOUND,CHAIN_ERROR,CHAIN_UNAVAILABLE,CHALLENGE_EXPIRED,CONTRACT_ALREADY_DEPLOYED,CUSTODY_NOT_CONFIGURED,CUSTOMER_ADDRESS_NOT_FOUND,CUSTOMER_NOT_FOUND,DELIVERY_EVIDENCE_MISSING,DELIVERY_TOKEN_NOT_FOUND,ESCROW_NOT_EXPIRED,ESCROW_UNSUPPORTED,FORBIDDEN,IDEMPOTENCY_KEY_IN_PROGRESS,IDEMPOTENCY_KEY_REUSED,INSUFFICIENT_FUNDS,INTERNAL,INVALID_ADDRESS,INVALID_CREDENTIALS,INVALID_CURSOR,INVALID_PARAMETER,INVALID_PRIVATE_KEY,INVALID_REQUEST,INVALID_SIGNATURE,INVALID_SIGN_IN_MESSAGE,MISSING_PARAMETER,ORDER_ALREADY_PAID,ORDER_NOT_FOUND,ORDER_OPERATION_IN_PROGRESS,ORDER_STATUS_CONFLICT,PRODUCT_ALREADY_EXISTS,PRODUCT_NOT_FOUND,RECONCILIATION_IN_PROGRESS,RECONCILIATION_NOT_FOUND,SWEEP_NOT_CONFIGURED,TX_REVERTED,UNAUTHENTICATED,VENDOR_ALREADY_EXISTS,VENDOR_NOT_FOUND,VENDOR_NOT_READY,WEBHOOK_DELIVERY_NOT_DEAD,WEBHOOK_DELIVERY_NOT_FOUND,WEBHOOK_NOT_FOUND"`
+	// describes what went wrong, for people. Don't parse it; it may change.
+	Error string `json:"error" example:"Order ID [1234] does not exist"`
+	// anything that helps act on the error, e.g. which field was wrong
+	Details map[string]interface{} `json:"details,omitempty"`
+	// quote this when asking about the error; it ties the request to the server's logs
+	CorrelationId string `json:"correlationId" example:"3f2b8c1e-5d4a-4e2f-9b7c-1a2b3c4d5e6f"`
+}
+
+// The gin middleware that gives each request a correlation ID and sends it back in the response headers
+func CorrelationId(ctx *gin.Context) {
+	id := ctx.GetHeader(correlationIdHeader)
+	if !correlationIdPattern.MatchString(id) {
+		id = uuid.New().String()
+	}
+	ctx.Set(correlationIdContextKey, id)
+	ctx.Header(correlationIdHeader, id)
+	ctx.Next()
+}
+
+// Returns the request's correlation ID
+func correlationIdFrom(ctx *gin.Context) string {
+	return ctx.GetString(correlationIdContextKey)
+}
+
+// Responds with the error. Errors that aren't *apierrors.Error are reported as internal errors,
+// so nothing from the database or the blockchain node ends up in front of the client.
+func errorResponse(ctx *gin.Context, err error) {
+	apiErr := apierrors.From(err)
+	status := apiErr.Code.HttpStatus()
+
+	if status >= 500 {
+		log.Errorf("[%s] %s %s failed with %s: %v",
+			correlationIdFrom(ctx), ctx.Request.Method, ctx.Request.URL.Path, apiErr.Code, apiErr.Err)
+	}
+
+	ctx.JSON(status, ApiError{
+		Code:          apiErr.Code,
+		Error:         apiErr.Message,
+		Details:       apiErr.Details,
+		CorrelationId: correlationIdFrom(ctx),
+	})
+}
+
+// Responds with the error and stops any later handlers from running
+func abortWithError(ctx *gin.Context, err error) {
+	errorResponse(ctx, err)
+	ctx.Abort()
+}
+
+// Describes a request body that couldn't be read
+func invalidRequest(err error) *apierrors.Error {
+	return apierrors.Wrap(err, apierrors.CodeInvalidRequest, "The request body is not valid JSON of the expected shape")
+}
+
+// Describes a parameter with a value that isn't allowed
+func invalidParameter(field string, format string, args ...interface{}) *apierrors.Error {
+	return apierrors.New(apierrors.CodeInvalidParameter, format, args...).With("fields", []string{field})
+}
+
+// Describes required parameters that weren't sent
+func missingParameters(fields ...string) *apierrors.Error {
+	return apierrors.New(apierrors.CodeMissingParameter, "Missing %s", strings.Join(fields, ", ")).
+		With("fields", fields)
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/docs"
+)
+
+// a row of the table of error codes in the API description
+var codeRowPattern = regexp.MustCompile(`^// @description\s+(\| ([A-Z_]+) \| \d+ \| .* \|)$`)
+
+// The rows the API description should have, one for each code in the catalog
+func expectedCodeRows() map[apierrors.Code]string {
+	rows := map[apierrors.Code]string{}
+	for _, code := range apierrors.AllCodes() {
+		rows[code] = fmt.Sprintf("| %s | %d | %s |", code, code.HttpStatus(), code.Description())
+	}
+	return rows
+}
+
+func TestErrorCodeTableMatchesTheCatalog(t *testing.T) {
+	source, err := os.ReadFile("router.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := expectedCodeRows()
+	documented := []string{}
+	for _, line := range strings.Split(string(source), "\n") {
+		match := codeRowPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		code := apierrors.Code(match[2])
+		documented = append(documented, match[2])
+		if row, ok := expected[code]; !ok {
+			t.Errorf("expected %s in router.go to be in the apierrors catalog", code)
+		} else if match[1] != row {
+			t.Errorf("expected router.go to say [%s], got [%s]", row, match[1])
+		}
+		delete(expected, code)
+	}
+	for code := range expected {
+		t.Errorf("expected %s to be in the table in router.go", code)
+	}
+
+	// in the same order as the enum, so the two are easy to compare by eye
+	if !reflect.DeepEqual(documented, apiErrorEnums(t)) {
+		t.Errorf("expected the table to be in alphabetical order, got %v", documented)
+	}
+}
+
+func TestApiErrorEnumsMatchTheCatalog(t *testing.T) {
+	expected := []string{}
+	for _, code := range apierrors.AllCodes() {
+		expected = append(expected, string(code))
+	}
+	if enums := apiErrorEnums(t); !reflect.DeepEqual(enums, expected) {
+		t.Errorf("expected the enums tag on ApiError.Code to be %v, got %v", expected, enums)
+	}
+}
+
+func TestGeneratedDocsAreUpToDate(t *testing.T) {
+	// swag copies the description over when the docs are rebuilt with rebuild_docs.sh
+	for _, row := range expectedCodeRows() {
+		if !strings.Contains(docs.SwaggerInfo.Description, row+"\n") {
+			t.Errorf("expected the generated docs to say [%s]; rebuild them", row)
+		}
+	}
+}
+
+// The codes listed in the enums tag on ApiError.Code
+func apiErrorEnums(t *testing.T) []string {
+	field, ok := reflect.TypeOf(ApiError{}).FieldByName("Code")
+	if !ok {
+		t.Fatal("expected ApiError to have a Code")
+	}
+	return strings.Split(field.Tag.Get("enums"), ",")
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"github.com/bdunton9323/blockchain-playground/health"
+	"github.com/gin-gonic/gin"
+)
+
+// Tells an orchestrator whether the service is alive, and whether it can reach what it depends on
+type HealthController struct {
+	Checker *health.Checker
+}
+
+// Liveness. Says nothing about the database or the node, so that a dependency going away doesn't get the
+// process restarted when waiting it out would do.
+func (_ctrl *HealthController) Healthz(ctx *gin.Context) {
+	ctx.JSON(200, health.Report{
+		Status: health.StatusOk,
+		Checks: []health.Result{},
+	})
+}
+
+// Readiness. Runs every dependency check and responds with 503 if any of them failed, so that traffic
+// is sent elsewhere until they pass again.
+func (_ctrl *HealthController) Readyz(ctx *gin.Context) {
+	report := _ctrl.Checker.Run(ctx.Request.Context())
+
+	code := 200
+	if report.Status != health.StatusOk {
+		code = 503
+	}
+	ctx.JSON(code, report)
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/idempotency"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// the request header clients use to make a write safe to retry
+var idempotencyKeyHeader = "Idempotency-Key"
+
+// set on responses that were replayed from an earlier request rather than freshly produced
+var idempotentReplayHeader = "Idempotent-Replayed"
+
+// the longest key a client may send. Keys are stored prefixed with the caller's subject, which
+// has to fit in the same column.
+var maxIdempotencyKeyLength = 200
+
+// Makes write endpoints safe to retry. When a request carries an Idempotency-Key header, the response
+// to the first request with that key is stored, and any retry within the retention window gets that
+// same response back instead of being handled again. Reusing a key for a different request is rejected.
+type IdempotencyMiddleware struct {
+	// where keys and their responses are kept
+	KeyRepository idempotency.KeyRepository
+	// how long a key is remembered
+	Retention time.Duration
+}
+
+// A response writer that keeps a copy of everything written to it
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (writer *recordingWriter) Write(data []byte) (int, error) {
+	writer.body.Write(data)
+	return writer.ResponseWriter.Write(data)
+}
+
+func (writer *recordingWriter) WriteString(data string) (int, error) {
+	writer.body.WriteString(data)
+	return writer.ResponseWriter.WriteString(data)
+}
+
+// The gin middleware function
+func (_mw *IdempotencyMiddleware) Handle(ctx *gin.Context) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if len(key) == 0 {
+		ctx.Next()
+		return
+	} else if len(key) > maxIdempotencyKeyLength {
+		abortWithError(ctx, invalidParameter(idempotencyKeyHeader, "Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength))
+		return
+	}
+
+	// keys belong to whoever sent them, so one caller can never be handed another caller's response
+	if principal := principalFrom(ctx); principal != nil {
+		key = principal.Subject + ":" + key
+	} else {
+		key = "anonymous:" + key
+	}
+
+	fingerprint, err := fingerprintRequest(ctx)
+	if err != nil {
+		abortWithError(ctx, invalidRequest(err))
+		return
+	}
+
+	reserved, err := _mw.KeyRepository.Reserve(key, fingerprint, _mw.Retention)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	} else if !reserved {
+		_mw.replay(ctx, key, fingerprint)
+		return
+	}
+
+	// if the handler panics, forget the key so that it isn't stuck in progress
+	defer func() {
+		if r := recover(); r != nil {
+			_mw.KeyRepository.Release(key)
+			panic(r)
+		}
+	}()
+
+	writer := &recordingWriter{ResponseWriter: ctx.Writer}
+	ctx.Writer = writer
+	ctx.Next()
+
+	// Server errors are not remembered, so that the client can retry once whatever went wrong is fixed.
+	// Chain operations that were already recorded are finished by the outbox either way.
+	if writer.Status() >= 500 {
+		err = _mw.KeyRepository.Release(key)
+	} else {
+		err = _mw.KeyRepository.Complete(key, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes())
+	}
+	if err != nil {
+		log.Errorf("Could not store the response for idempotency key [%s]: %v", key, err)
+	}
+}
+
+// Responds to a request whose key has been seen before
+func (_mw *IdempotencyMiddleware) replay(ctx *gin.Context, key string, fingerprint string) {
+	record, err := _mw.KeyRepository.Get(key)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	} else if record == nil {
+		// it expired or was released in the meantime
+		abortWithError(ctx, apierrors.New(apierrors.CodeIdempotencyKeyInProgress, "A request with this Idempotency-Key just finished; retry the request"))
+		return
+	}
+
+	if record.Fingerprint != fingerprint {
+		abortWithError(ctx, apierrors.New(apierrors.CodeIdempotencyKeyReused, "This Idempotency-Key was already used for a different request"))
+		return
+	} else if record.Status != idempotency.KeyCompleted {
+		abortWithError(ctx, apierrors.New(apierrors.CodeIdempotencyKeyInProgress, "A request with this Idempotency-Key is still in progress"))
+		return
+	}
+
+	log.Infof("Replaying the stored response for idempotency key [%s]", key)
+	ctx.Header(idempotentReplayHeader, "true")
+	ctx.Data(record.ResponseCode, record.ResponseContentType, record.ResponseBody)
+	ctx.Abort()
+}
+
+// Hashes everything that makes up the request, so that reusing a key for a different request can be caught
+func fingerprintRequest(ctx *gin.Context) (string, error) {
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return "", err
+	}
+	// put the body back for the handler
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(ctx.Request.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(ctx.Request.URL.Path))
+	hash.Write([]byte{0})
+	hash.Write([]byte(ctx.Request.URL.Query().Encode()))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/tracking"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+// Clients authenticate with a token rather than cookies, so a page on another origin
+// can't borrow the customer's session. That makes it safe to accept any origin.
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// how long a WebSocket write may take before the client is considered gone
+var webSocketWriteTimeout = 10 * time.Second
+
+// StreamOrderEvents godoc
+// @Summary      Stream order updates
+// @Description  Pushes the order's updates as Server-Sent Events until the client disconnects. The first event describes
+// @Description  where the order is now; after that come status changes, transactions as they are sent, and confirmation
+// @Description  counts as blocks are built on top of them. Each event's name is the update's type and its data is the update.
+// @Description  Browsers can't set headers on an EventSource, so the session token may be passed as access_token instead.
+// @Tags         order
+// @Produce      text/event-stream
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        orderId       path   string  true   "the ID of the order to watch"
+// @Param        access_token  query  string  false  "the session token, for clients that can't send an Authorization header"
+// @Success      200  {object}  tracking.Update
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /order/{orderId}/events [get]
+func (_ctrl *OrderController) StreamOrderEvents(ctx *gin.Context) {
+	order, ok := _ctrl.findWatchableOrder(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	// stop proxies from holding events back
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(200)
+
+	err := _ctrl.Service.Follow(ctx.Request.Context(), order, &sseSink{ctx: ctx})
+	if err != nil {
+		log.Warnf("Stopped streaming order [%s]: %v", order.OrderId, err)
+	}
+}
+
+// StreamOrderWebSocket godoc
+// @Summary      Stream order updates over a WebSocket
+// @Description  The same updates as the Server-Sent Events stream, sent as JSON text messages over a WebSocket.
+// @Description  Nothing needs to be sent to the server; closing the socket stops the stream.
+// @Tags         order
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        orderId       path   string  true   "the ID of the order to watch"
+// @Param        access_token  query  string  false  "the session token, for clients that can't send an Authorization header"
+// @Success      101  {object}  tracking.Update
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /order/{orderId}/ws [get]
+func (_ctrl *OrderController) StreamOrderWebSocket(ctx *gin.Context) {
+	order, ok := _ctrl.findWatchableOrder(ctx)
+	if !ok {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// the upgrader has already responded
+		log.Warnf("Could not open a WebSocket for order [%s]: %v", order.OrderId, err)
+		return
+	}
+	defer conn.Close()
+
+	// Nothing is expected from the client, but reading is how we find out that it went away
+	watchCtx, stop := context.WithCancel(ctx.Request.Context())
+	defer stop()
+	go func() {
+		defer stop()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	err = _ctrl.Service.Follow(watchCtx, order, &webSocketSink{conn: conn})
+	if err != nil {
+		log.Warnf("Stopped streaming order [%s]: %v", order.OrderId, err)
+		return
+	}
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(webSocketWriteTimeout))
+}
+
+// Looks up the order named in the path and makes sure the caller may see it. Writes the response
+// and returns false if not.
+func (_ctrl *OrderController) findWatchableOrder(ctx *gin.Context) (*orders.Order, bool) {
+	order, err := _ctrl.Service.GetOrder(ctx.Request.Context(), principalFrom(ctx), ctx.Param("orderId"))
+	if err != nil {
+		errorResponse(ctx, err)
+		return nil, false
+	}
+	return order, true
+}
+
+// Writes updates as Server-Sent Events
+type sseSink struct {
+	ctx *gin.Context
+}
+
+func (sink *sseSink) Send(update *tracking.Update) error {
+	sink.ctx.SSEvent(string(update.Type), update)
+	sink.ctx.Writer.Flush()
+	return nil
+}
+
+func (sink *sseSink) Heartbeat() error {
+	// lines starting with a colon are comments, which clients ignore
+	if _, err := sink.ctx.Writer.WriteString(": keep-alive\n\n"); err != nil {
+		return err
+	}
+	sink.ctx.Writer.Flush()
+	return nil
+}
+
+// Writes updates as JSON messages on a WebSocket
+type webSocketSink struct {
+	conn *websocket.Conn
+}
+
+func (sink *webSocketSink) Send(update *tracking.Update) error {
+	message, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	sink.conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+	return sink.conn.WriteMessage(websocket.TextMessage, message)
+}
+
+func (sink *webSocketSink) Heartbeat() error {
+	return sink.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(webSocketWriteTimeout))
+}
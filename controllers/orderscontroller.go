@@ -1,30 +1,23 @@
 package controllers
 
 import (
-	"fmt"
-	"math/big"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/apierrors"
 	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/service"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	log "github.com/sirupsen/logrus"
 )
 
 // The controller itself
 type OrderController struct {
-	// the URL of the ethereum node to connect to
-	NodeUrl string
-	// the private key of the server's ethereum address
-	ServerPrivateKey string
-	// the persistence layer for the orders
-	OrderRepository *orders.MariaDBOrderRepository
-	// executes operations on the smart delivery contract
-	ContractExecutor *contract.DeliveryContractExecutor
+	// the order flows, shared with the gRPC API
+	Service *service.OrderService
 }
 
-// The request body for updating the status of an order. One of "delivered" or "burned".
+// The request body for updating the status of an order. One of "delivered", "burned", "canceled", "released", or "refunded".
 type OrderUpdateRequest struct {
 	// indicates the desired new status of the order
 	Status string `json:"status"`
@@ -39,11 +32,14 @@ type OrderStatusResponse struct {
 // Metadata about the order that was placed
 type CreateOrderResponse struct {
 	// The ID of the delivery token. A tokenId is unique within a given contract.
-	TokenId string `json:"message"`
+	TokenId string `json:"message,omitempty"`
 	// The address of the contract that manages this token
 	ContractAddress string `json:"contractAddress"`
 	// The unique ID of the order
 	OrderId string `json:"orderId"`
+	// The status of the order. If the token is still being minted, this is 'created' and the
+	// token ID is omitted; check back on the order later.
+	Status string `json:"status"`
 }
 
 // Indicates the address of the owner of the delivery token
@@ -52,14 +48,83 @@ type TokenOwnerResponse struct {
 	Owner string `json:"owner" format:"address"`
 }
 
-// Error response from the API
-type ApiError struct {
-	Error string `json:"error"`
+// A single change in the status of an order
+type StatusChangeResponse struct {
+	// the status the order moved out of. Empty when the order was first created.
+	FromStatus string `json:"fromStatus,omitempty"`
+	// the status the order moved into
+	ToStatus string `json:"toStatus"`
+	// the ethereum address of whoever caused the change
+	ActorAddress string `json:"actorAddress" format:"address"`
+	// the hash of the transaction that caused the change, if it happened on chain
+	TxHash string `json:"txHash,omitempty"`
+	// when the change happened
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// The status of an order along with every change it has gone through
+type OrderHistoryResponse struct {
+	// the unique ID of the order
+	OrderId string `json:"orderId"`
+	// the current status of the order
+	Status string `json:"status"`
+	// every status change, oldest first
+	History []StatusChangeResponse `json:"history"`
+}
+
+// Summary of an order as stored by the vendor
+type OrderResponse struct {
+	// the unique ID of the order
+	OrderId string `json:"orderId"`
+	// the ID of the product that was ordered
+	ItemId string `json:"itemId"`
+	// the name of the product that was ordered
+	ItemName string `json:"itemName"`
+	// the price of the goods, in wei
+	Price int64 `json:"price"`
+	// the price of shipping, in wei
+	DeliveryPrice int64 `json:"deliveryPrice"`
+	// the customer who is allowed to accept delivery
+	BuyerAddress string `json:"buyerAddress" format:"address"`
+	// the address of the contract that manages the delivery token
+	TokenAddress string `json:"tokenAddress,omitempty" format:"address"`
+	// the ID of the delivery token. Zero if it was never minted.
+	TokenId int64 `json:"tokenId"`
+	// the current status of the order
+	Status string `json:"status"`
+	// the vendor who sells the item
+	VendorId string `json:"vendorId"`
+	// when the order was placed
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Where the proof that an order's package was dropped off is kept
+type DeliveryEvidenceRequest struct {
+	// e.g. the courier's drop-off record or a photo's URL, up to 512 characters
+	Reference string `json:"reference" example:"courier-scan-0042"`
 }
 
-// since I don't have a database of products, just hard code the amounts
-var productPrice int64 = 500
-var deliveryPrice int64 = 75
+// Proof that an order's package was dropped off
+type DeliveryEvidenceResponse struct {
+	// the unique ID of the order
+	OrderId string `json:"orderId"`
+	// where the proof is kept
+	Reference string `json:"reference"`
+	// the keccak256 hash of the reference, which goes on chain when the vendor claims the escrow
+	Hash string `json:"hash"`
+	// the API key or address that recorded it
+	RecordedBy string `json:"recordedBy"`
+	// when it was recorded
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// One page of orders
+type OrderListResponse struct {
+	// the orders on this page
+	Orders []OrderResponse `json:"orders"`
+	// pass this as the cursor to get the next page. Omitted on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
 
 // CreateOrder godoc
 // @Summary      Create order
@@ -67,64 +132,48 @@ var deliveryPrice int64 = 75
 // @Tags         order
 // @Accept       json
 // @Produce      json
-// @Param        itemId        query  string  true  "The item to order"
-// @Param        buyerAddress  query  string  true  "the Ethereum address of the user who can accept the delivery"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        itemId        query  string  true  "The ID of the product to order"
+// @Param        buyerAddress  query  string  false "the Ethereum address of the user who can accept the delivery. Mixed case addresses must have a valid EIP-55 checksum; the zero address and the vendor's own address are refused. Required unless customerId is given, in which case it picks one of the customer's verified addresses"
+// @Param        customerId    query  string  false "a customer account to order for. The order goes to a custodial customer's deposit address, or the first address anyone else verified, unless buyerAddress says otherwise"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
 // @Success      200  {object}  CreateOrderResponse
+// @Success      202  {object}  CreateOrderResponse  "The order was recorded but the token is still being minted"
 // @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
 // @Failure      404  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
 // @Failure      500  {object}  ApiError
 // @Router       /order [post]
 func (_ctrl *OrderController) CreateOrder(ctx *gin.Context) {
-
-	if !validateArgs(ctx, "itemId", "buyerAddress") {
-		return
-	}
-
-	itemId := ctx.Query("itemId")
-	orderId := uuid.New().String()
-
-	// the customer who is allowed to receive the shipment
-	userAddress := ctx.Query("buyerAddress")
-
-	purchase := &contract.Purchase{
-		OrderId:          orderId,
-		PurchasePrice:    big.NewInt(productPrice),
-		DeliveryPrice:    big.NewInt(deliveryPrice),
-		RecipientAddress: userAddress,
-	}
-
-	tokenId, address, err := _ctrl.ContractExecutor.MintNFT(purchase)
-
+	// the service checks the arguments
+	result, err := _ctrl.Service.CreateOrder(ctx.Request.Context(), principalFrom(ctx), &service.CreateOrderInput{
+		ItemId: ctx.Query("itemId"),
+		// the customer who is allowed to receive the shipment
+		BuyerAddress: ctx.Query("buyerAddress"),
+		CustomerId:   ctx.Query("customerId"),
+	})
 	if err != nil {
-		ctx.JSON(500, ApiError{
-			Error: err.Error(),
+		errorResponse(ctx, err)
+		return
+	} else if result.Pending {
+		// the dispatcher will keep trying in the background
+		ctx.JSON(202, CreateOrderResponse{
+			ContractAddress: result.ContractAddress,
+			OrderId:         result.Order.OrderId,
+			Status:          string(result.Order.Status),
 		})
 		return
 	}
 
-	order := &orders.Order{
-		OrderId:       orderId,
-		ItemId:        itemId,
-		ItemName:      "socks",
-		Price:         productPrice,
-		DeliveryPrice: deliveryPrice,
-		TokenAddress:  address,
-		TokenId:       tokenId.Int64(),
-		Delivered:     false,
-	}
-
-	err = _ctrl.OrderRepository.CreateOrder(order)
-	if err != nil {
-		ctx.JSON(500, ApiError{
-			Error: "Error writing order to database",
-		})
-	} else {
-		ctx.JSON(200, CreateOrderResponse{
-			TokenId:         tokenId.String(),
-			ContractAddress: address,
-			OrderId:         order.OrderId,
-		})
-	}
+	ctx.JSON(200, CreateOrderResponse{
+		TokenId:         strconv.FormatInt(result.Order.TokenId, 10),
+		ContractAddress: result.Order.TokenAddress,
+		OrderId:         result.Order.OrderId,
+		Status:          string(result.Order.Status),
+	})
 }
 
 // PayForOrder   godoc
@@ -132,41 +181,34 @@ func (_ctrl *OrderController) CreateOrder(ctx *gin.Context) {
 // @Tags         order
 // @Accept       json
 // @Produce      json
-// @Param        customerKey    query  string             false "If this is a delivery, the delivery recipient's private key (not a good idea in real life!)"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        customerKey    query  string             false "the customer's private key as 64 hex digits (not a good idea in real life!). Leave it out for custodial customers; the service signs for them"
 // @Param        orderId        path   string             true  "the ID of the order being updated"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
 // @Success      200  {string}  string    "ok"
+// @Success      202  {string}  string    "submitted"
 // @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
 // @Failure      404  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
 // @Failure      500  {object}  ApiError
 // @Router       /payment/order/{orderId} [post]
 func (_ctrl *OrderController) PayForOrder(ctx *gin.Context) {
-	if !validateArgs(ctx, "customerKey") {
-		return
-	}
-
 	// The customer is signing for the order, and they have a different key than
-	// the one loaded into the server. This would be a terrible idea in real life,
-	// but it demonstrates the functionality of the contract.
-	customerPrivateKey := ctx.Query("customerKey")
-
-	orderId := ctx.Param("orderId")
-	order, err := _ctrl.OrderRepository.GetOrder(orderId)
+	// the one loaded into the server.
+	result, err := _ctrl.Service.PayForOrder(ctx.Request.Context(), principalFrom(ctx), &service.CustomerOperationInput{
+		OrderId:     ctx.Param("orderId"),
+		CustomerKey: ctx.Query("customerKey"),
+	})
 	if err != nil {
-		ctx.JSON(400, ApiError{
-			Error: err.Error(),
-		})
-		return
-	} else if order == nil || order.Delivered {
-		orderNotFoundResponse(ctx, orderId)
+		errorResponse(ctx, err)
 		return
-	}
-
-	log.Infof("Paying [%d] ether for order [%v]", orderId)
-	err = _ctrl.ContractExecutor.PayForGoods(order.TokenId, customerPrivateKey, order.Price)
-	if err != nil {
-		ctx.JSON(400, ApiError{
-			Error: err.Error(),
-		})
+	} else if result.Pending {
+		// the payment was sent; the dispatcher will record it once it is mined
+		ctx.JSON(202, "submitted")
 		return
 	}
 
@@ -175,31 +217,94 @@ func (_ctrl *OrderController) PayForOrder(ctx *gin.Context) {
 
 // DeliverOrder  godoc
 // @Summary      Update order status
-// @Description  This action changes the status of an order, either by accepting delivery or canceling it
+// @Description  This action changes the status of an order, either by accepting delivery, burning the token, or canceling it.
+// @Description  Once a paid order's escrow has expired without the customer accepting delivery, the vendor can claim it ('released'),
+// @Description  which needs evidence of delivery, or it can be refunded to the customer ('refunded'). Customers sign their own refunds.
 // @Tags         order
 // @Accept       json
 // @Produce      json
-// @Param        request        body   OrderUpdateRequest true  "Indicates the status of the order. One of ('canceled', 'burned')"
-// @Param        customerKey    query  string             false "If this is a delivery, the delivery recipient's private key (not a good idea in real life!)"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        request        body   OrderUpdateRequest true  "Indicates the status of the order. One of ('delivered', 'burned', 'canceled', 'released', 'refunded')"
+// @Param        customerKey    query  string             false "If this is a delivery or a customer's refund, the delivery recipient's private key as 64 hex digits (not a good idea in real life!). Deliveries to custodial customers don't need it"
 // @Param        orderId        path   string             true  "the ID of the order being updated"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
 // @Success      200  {object}  OrderStatusResponse
+// @Success      202  {object}  OrderStatusResponse  "The transaction was sent but has not been mined yet. The status is unchanged."
 // @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
 // @Failure      404  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
 // @Failure      500  {object}  ApiError
 // @Router       /order/{orderId} [post]
 func (_ctrl *OrderController) UpdateOrderStatus(ctx *gin.Context) {
 	var req OrderUpdateRequest
-	ctx.BindJSON(&req)
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, invalidRequest(err))
+		return
+	}
 
 	if strings.EqualFold(req.Status, "delivered") {
 		_ctrl.deliverOrder(ctx)
 	} else if strings.EqualFold(req.Status, "burned") {
 		_ctrl.burnToken(ctx)
+	} else if strings.EqualFold(req.Status, "canceled") {
+		_ctrl.cancelOrder(ctx)
+	} else if strings.EqualFold(req.Status, "released") {
+		_ctrl.releaseEscrow(ctx)
+	} else if strings.EqualFold(req.Status, "refunded") {
+		_ctrl.refundEscrow(ctx)
 	} else {
-		ctx.JSON(400, ApiError{
-			Error: "Invalid status. Expected 'delivered' or 'burned'",
-		})
+		errorResponse(ctx, invalidParameter("status",
+			"Invalid status. Expected 'delivered', 'burned', 'canceled', 'released', or 'refunded'"))
+	}
+}
+
+// RecordDeliveryEvidence godoc
+// @Summary      Record evidence of delivery
+// @Description  Records where the proof that a paid order's package was dropped off is kept, replacing any recorded before. If the customer
+// @Description  never accepts delivery, the vendor needs this to claim the escrow once it expires; without it, the customer is refunded.
+// @Tags         order
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        request        body   DeliveryEvidenceRequest true  "where the proof is kept"
+// @Param        orderId        path   string                  true  "the ID of the order that was dropped off"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      200  {object}  DeliveryEvidenceResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /order/{orderId}/evidence [post]
+func (_ctrl *OrderController) RecordDeliveryEvidence(ctx *gin.Context) {
+	var req DeliveryEvidenceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, invalidRequest(err))
+		return
+	}
+
+	evidence, err := _ctrl.Service.RecordDeliveryEvidence(ctx.Request.Context(), principalFrom(ctx), &service.EvidenceInput{
+		OrderId:   ctx.Param("orderId"),
+		Reference: req.Reference,
+	})
+	if err != nil {
+		errorResponse(ctx, err)
+		return
 	}
+	ctx.JSON(200, DeliveryEvidenceResponse{
+		OrderId:    evidence.OrderId,
+		Reference:  evidence.Reference,
+		Hash:       evidence.Hash,
+		RecordedBy: evidence.RecordedBy,
+		RecordedAt: evidence.RecordedAt,
+	})
 }
 
 // Determines who currently owns the deliver token - the vendor or the customer.
@@ -211,27 +316,22 @@ func (_ctrl *OrderController) UpdateOrderStatus(ctx *gin.Context) {
 // @Tags         order
 // @Accept       json
 // @Produce      json
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Param        orderId        path   string    true  "the ID of the order to look up"
 // @Success      200  {object}  TokenOwnerResponse
 // @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
 // @Failure      404  {object}  ApiError
 // @Failure      500  {object}  ApiError
 // @Router       /order/{orderId}/owner [get]
 func (_ctrl *OrderController) GetDeliveryTokenOwner(ctx *gin.Context) {
 
-	orderId := ctx.Param("orderId")
-	order, _ := _ctrl.OrderRepository.GetOrder(orderId)
-	if order == nil {
-		orderNotFoundResponse(ctx, orderId)
-		return
-	}
-
-	owner, err := _ctrl.ContractExecutor.GetOwner(order.TokenId)
+	owner, err := _ctrl.Service.GetTokenOwner(ctx.Request.Context(), principalFrom(ctx), ctx.Param("orderId"))
 
 	if err != nil {
-		ctx.JSON(500, ApiError{
-			Error: err.Error(),
-		})
+		errorResponse(ctx, err)
 	} else {
 		ctx.JSON(200, TokenOwnerResponse{
 			Owner: owner,
@@ -239,116 +339,243 @@ func (_ctrl *OrderController) GetDeliveryTokenOwner(ctx *gin.Context) {
 	}
 }
 
-// Delivers the order to the customer. This is represented by transferring the token from the vendor to
-// the customer, and transferring Ether from the customer to the vendor to pay for shipping.
-func (_ctrl *OrderController) deliverOrder(ctx *gin.Context) {
-	if !validateArgs(ctx, "customerKey") {
+// GetOrderHistory godoc
+// @Summary      Get the status history of an order
+// @Description  Lists every status the order has moved through, along with who moved it and the transaction that did it.
+// @Tags         order
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        orderId        path   string    true  "the ID of the order to look up"
+// @Success      200  {object}  OrderHistoryResponse
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /order/{orderId}/history [get]
+func (_ctrl *OrderController) GetOrderHistory(ctx *gin.Context) {
+	history, err := _ctrl.Service.GetOrderHistory(ctx.Request.Context(), principalFrom(ctx), ctx.Param("orderId"))
+	if err != nil {
+		errorResponse(ctx, err)
 		return
 	}
 
-	// The customer is signing for the order, and they have a different key than
-	// the one loaded into the server. This would be a terrible idea in real life,
-	// but it demonstrates the functionality of the contract.
-	customerPrivateKey := ctx.Query("customerKey")
-
-	orderId := ctx.Param("orderId")
-	log.Infof("Delivering order [%v]", orderId)
+	response := OrderHistoryResponse{
+		OrderId: history.Order.OrderId,
+		Status:  string(history.Order.Status),
+		History: []StatusChangeResponse{},
+	}
+	for _, change := range history.Changes {
+		response.History = append(response.History, StatusChangeResponse{
+			FromStatus:   string(change.FromStatus),
+			ToStatus:     string(change.ToStatus),
+			ActorAddress: change.ActorAddress,
+			TxHash:       change.TxHash,
+			ChangedAt:    change.ChangedAt,
+		})
+	}
+	ctx.JSON(200, response)
+}
 
-	order, err := _ctrl.OrderRepository.GetOrder(orderId)
+// ListOrders godoc
+// @Summary      List orders
+// @Description  Searches the vendor's orders. All filters are optional and are combined with AND.
+// @Description  Results are paged; pass the nextCursor from one page to get the next one.
+// @Tags         order
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Param        buyerAddress   query  string  false  "only orders that can be delivered to this ethereum address"
+// @Param        tokenAddress   query  string  false  "only orders whose token is managed by this contract address"
+// @Param        status         query  string  false  "only orders in these statuses, comma separated (e.g. 'minted,paid')"
+// @Param        itemId         query  string  false  "only orders for this product"
+// @Param        vendorId       query  string  false  "only the vendor's orders. A vendor's API keys only ever see their own"
+// @Param        X-Vendor-Id    header string  false  "only the vendor's orders, if vendorId isn't given"
+// @Param        createdAfter   query  string  false  "only orders placed at or after this time (RFC 3339)"
+// @Param        createdBefore  query  string  false  "only orders placed before this time (RFC 3339)"
+// @Param        sortBy         query  string  false  "the field to sort by. One of ('createdAt', 'price'). Defaults to 'createdAt'"
+// @Param        sortOrder      query  string  false  "One of ('asc', 'desc'). Defaults to 'desc'"
+// @Param        limit          query  int     false  "the maximum number of orders to return (1-200). Defaults to 50"
+// @Param        cursor         query  string  false  "the nextCursor from the previous page"
+// @Success      200  {object}  OrderListResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /orders [get]
+func (_ctrl *OrderController) ListOrders(ctx *gin.Context) {
+	query, err := parseOrderQuery(ctx)
 	if err != nil {
-		ctx.JSON(500, ApiError{
-			Error: err.Error(),
-		})
-		return
-	} else if order == nil || order.Delivered {
-		orderNotFoundResponse(ctx, orderId)
+		errorResponse(ctx, err)
 		return
 	}
 
-	// buy the token from the vendor, thereby accepting delivery of the package
-	err = _ctrl.ContractExecutor.DeliverOrder(
-		order.TokenId,
-		customerPrivateKey,
-		deliveryPrice)
-
+	page, err := _ctrl.Service.ListOrders(ctx.Request.Context(), principalFrom(ctx), query)
 	if err != nil {
-		ctx.JSON(500, ApiError{
-			Error: err.Error(),
-		})
+		errorResponse(ctx, err)
 		return
 	}
 
-	_ctrl.OrderRepository.MarkOrderDelivered(orderId)
+	response := OrderListResponse{
+		Orders: []OrderResponse{},
+	}
+	for _, order := range page.Orders {
+		response.Orders = append(response.Orders, toOrderResponse(order))
+	}
+	if page.Next != nil {
+		response.NextCursor = page.Next.Encode()
+	}
+	ctx.JSON(200, response)
+}
 
-	ctx.JSON(200, OrderStatusResponse{
-		Status: "delivered",
+// Delivers the order to the customer. This is represented by transferring the token from the vendor to
+// the customer, and transferring Ether from the customer to the vendor to pay for shipping.
+func (_ctrl *OrderController) deliverOrder(ctx *gin.Context) {
+	// The customer is signing for the order, and they have a different key than
+	// the one loaded into the server.
+	result, err := _ctrl.Service.DeliverOrder(ctx.Request.Context(), principalFrom(ctx), &service.CustomerOperationInput{
+		OrderId:     ctx.Param("orderId"),
+		CustomerKey: ctx.Query("customerKey"),
 	})
-
-	return
+	operationResponse(ctx, result, err)
 }
 
-// Cancels a pending order. Behind the scenes this burns the token that represents the delivery.
+// Destroys the token that represents the delivery. The contract only allows this after delivery.
 func (_ctrl *OrderController) burnToken(ctx *gin.Context) {
-	orderId := ctx.Param("orderId")
-	order, _ := _ctrl.OrderRepository.GetOrder(orderId)
-	if order == nil {
-		orderNotFoundResponse(ctx, orderId)
-		return
-	}
-
-	_, err := _ctrl.ContractExecutor.IsDelivered(order.TokenId)
-	if err != nil {
-		// either the token ID is invalid or it was already burned
-		ctx.JSON(400, ApiError{
-			Error: err.Error(),
-		})
-		return
-	}
+	result, err := _ctrl.Service.BurnToken(ctx.Request.Context(), principalFrom(ctx), ctx.Param("orderId"))
+	operationResponse(ctx, result, err)
+}
 
-	// An error from here could indicate that the token was already burned,
-	// did not exist, or that an unapproved user attempted to burn it. This isn't
-	// robust enough to differentiate, but it's almost certainly a client error.
-	err = _ctrl.ContractExecutor.BurnDeliveryToken(order.OrderId)
+// Calls off an order that has not been paid for yet. Nothing happens on chain; the token
+// (if it was minted) stays with the vendor.
+func (_ctrl *OrderController) cancelOrder(ctx *gin.Context) {
+	order, err := _ctrl.Service.CancelOrder(ctx.Request.Context(), principalFrom(ctx), ctx.Param("orderId"))
 	if err != nil {
-		ctx.JSON(400, ApiError{
-			Error: err.Error(),
-		})
+		errorResponse(ctx, err)
 		return
 	}
 
 	ctx.JSON(200, OrderStatusResponse{
-		Status: "burned",
+		Status: string(order.Status),
 	})
 }
 
-// Ensures all of the query parameters are present in the request
-func validateArgs(ctx *gin.Context, args ...string) bool {
-	var sb strings.Builder
-
-	valid := true
-	first := true
-	for _, arg := range args {
-		if len(ctx.Query(arg)) == 0 {
-			valid = false
-			if !first {
-				sb.WriteString(",")
+// Claims an expired escrow for the vendor, on the strength of the evidence of delivery
+func (_ctrl *OrderController) releaseEscrow(ctx *gin.Context) {
+	result, err := _ctrl.Service.ReleaseEscrow(ctx.Request.Context(), principalFrom(ctx), ctx.Param("orderId"))
+	operationResponse(ctx, result, err)
+}
+
+// Gives the customer their money back from an expired escrow. The customer signs it if their key is given.
+func (_ctrl *OrderController) refundEscrow(ctx *gin.Context) {
+	result, err := _ctrl.Service.RefundEscrow(ctx.Request.Context(), principalFrom(ctx), &service.CustomerOperationInput{
+		OrderId:     ctx.Param("orderId"),
+		CustomerKey: ctx.Query("customerKey"),
+	})
+	operationResponse(ctx, result, err)
+}
+
+// Reads the filters, sorting and paging for listing orders out of the query string
+func parseOrderQuery(ctx *gin.Context) (*orders.OrderQuery, error) {
+	// the service checks the addresses
+	query := &orders.OrderQuery{
+		BuyerAddress: ctx.Query("buyerAddress"),
+		TokenAddress: ctx.Query("tokenAddress"),
+		ItemId:       ctx.Query("itemId"),
+		VendorId:     ctx.Query("vendorId"),
+		SortBy:       orders.SortByCreatedAt,
+		Descending:   true,
+	}
+
+	if len(query.VendorId) == 0 {
+		query.VendorId = vendorFrom(ctx)
+	}
+
+	if statuses := ctx.Query("status"); len(statuses) != 0 {
+		for _, name := range strings.Split(statuses, ",") {
+			status, ok := orders.ParseOrderStatus(strings.TrimSpace(name))
+			if !ok {
+				return nil, invalidParameter("status", "Unknown status [%s]", name)
 			}
-			sb.WriteString(arg)
-			first = false
+			query.Statuses = append(query.Statuses, status)
 		}
 	}
 
-	if !valid {
-		ctx.JSON(400, ApiError{
-			Error: sb.String(),
-		})
-		return false
+	var err error
+	if after := ctx.Query("createdAfter"); len(after) != 0 {
+		if query.CreatedAfter, err = time.Parse(time.RFC3339, after); err != nil {
+			return nil, invalidParameter("createdAfter", "createdAfter must be an RFC 3339 timestamp")
+		}
+	}
+	if before := ctx.Query("createdBefore"); len(before) != 0 {
+		if query.CreatedBefore, err = time.Parse(time.RFC3339, before); err != nil {
+			return nil, invalidParameter("createdBefore", "createdBefore must be an RFC 3339 timestamp")
+		}
+	}
+
+	switch sortBy := ctx.Query("sortBy"); sortBy {
+	case "", string(orders.SortByCreatedAt):
+	case string(orders.SortByPrice):
+		query.SortBy = orders.SortByPrice
+	default:
+		return nil, invalidParameter("sortBy", "Cannot sort by [%s]. Expected 'createdAt' or 'price'", sortBy)
 	}
-	return true
+
+	switch sortOrder := strings.ToLower(ctx.Query("sortOrder")); sortOrder {
+	case "", "desc":
+	case "asc":
+		query.Descending = false
+	default:
+		return nil, invalidParameter("sortOrder", "sortOrder must be 'asc' or 'desc'")
+	}
+
+	// the service checks the limit is in range
+	if limit := ctx.Query("limit"); len(limit) != 0 {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit == 0 {
+			return nil, invalidParameter("limit", "limit must be between 1 and %d", service.MaxOrderPageSize)
+		}
+	}
+
+	if cursor := ctx.Query("cursor"); len(cursor) != 0 {
+		if query.After, err = orders.DecodeOrderCursor(cursor); err != nil {
+			return nil, apierrors.Wrap(err, apierrors.CodeInvalidCursor, "The cursor is not valid")
+		}
+	}
+
+	return query, nil
 }
 
-func orderNotFoundResponse(ctx *gin.Context, orderId string) {
-	ctx.JSON(404, ApiError{
-		Error: fmt.Sprintf("Order ID [%s] does not exist", orderId),
+func toOrderResponse(order *orders.Order) OrderResponse {
+	return OrderResponse{
+		OrderId:       order.OrderId,
+		ItemId:        order.ItemId,
+		ItemName:      order.ItemName,
+		Price:         order.Price,
+		DeliveryPrice: order.DeliveryPrice,
+		BuyerAddress:  order.BuyerAddress,
+		TokenAddress:  order.TokenAddress,
+		TokenId:       order.TokenId,
+		Status:        string(order.Status),
+		VendorId:      order.VendorId,
+		CreatedAt:     order.CreatedAt,
+	}
+}
+
+// Responds with the outcome of a chain operation that changes the order's status. If the operation
+// is still in flight, the order's status is unchanged and the response says so with a 202.
+func operationResponse(ctx *gin.Context, result *service.OperationResult, err error) {
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	code := 200
+	if result.Pending {
+		code = 202
+	}
+	ctx.JSON(code, OrderStatusResponse{
+		Status: string(result.Order.Status),
 	})
 }
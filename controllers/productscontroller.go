@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/products"
+	"github.com/bdunton9323/blockchain-playground/vendors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Manages the catalog of products that can be ordered
+type ProductController struct {
+	// the persistence layer for the catalog
+	ProductRepository products.ProductRepository
+	// checks that the vendor a product is added for exists
+	Vendors *vendors.Registry
+}
+
+// The request body for creating or updating a product
+type ProductRequest struct {
+	// The unique ID of the product. Optional when creating; one is generated if omitted.
+	// Ignored when updating, since the ID comes from the path.
+	ProductId string `json:"productId"`
+	// The display name of the product
+	Name string `json:"name"`
+	// A longer description of the product
+	Description string `json:"description"`
+	// The price of the goods, in wei
+	Price int64 `json:"price"`
+	// The price of shipping the goods, in wei
+	ShippingPrice int64 `json:"shippingPrice"`
+	// The vendor who sells the product. Only when creating, and only for the marketplace's own keys; a vendor's
+	// keys always add to their own catalog. Defaults to the X-Vendor-Id header, or else the default vendor.
+	VendorId string `json:"vendorId"`
+}
+
+// A product in the catalog
+type ProductResponse struct {
+	// The unique ID of the product. This is the itemId used when placing an order.
+	ProductId string `json:"productId"`
+	// The display name of the product
+	Name string `json:"name"`
+	// A longer description of the product
+	Description string `json:"description"`
+	// The price of the goods, in wei
+	Price int64 `json:"price"`
+	// The price of shipping the goods, in wei
+	ShippingPrice int64 `json:"shippingPrice"`
+	// The vendor who sells the product
+	VendorId string `json:"vendorId"`
+}
+
+// ListProducts godoc
+// @Summary      List products
+// @Description  Lists every product in the catalog, or only one vendor's. A vendor's API keys only see their own.
+// @Tags         product
+// @Produce      json
+// @Param        vendorId     query   string  false  "only the vendor's products"
+// @Param        X-Vendor-Id  header  string  false  "only the vendor's products, if vendorId isn't given"
+// @Success      200  {array}   ProductResponse
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /products [get]
+func (_ctrl *ProductController) ListProducts(ctx *gin.Context) {
+	vendorId := vendorFrom(ctx)
+	if requested := ctx.Query("vendorId"); len(requested) != 0 {
+		if len(vendorId) != 0 && requested != vendorId {
+			errorResponse(ctx, apierrors.New(apierrors.CodeForbidden, "Only vendor [%s]'s products can be listed", vendorId))
+			return
+		}
+		vendorId = requested
+	}
+
+	catalog, err := _ctrl.ProductRepository.ListProducts(vendorId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	response := []ProductResponse{}
+	for _, product := range catalog {
+		response = append(response, toProductResponse(product))
+	}
+	ctx.JSON(200, response)
+}
+
+// GetProduct godoc
+// @Summary      Get product
+// @Description  Looks up a single product in the catalog
+// @Tags         product
+// @Produce      json
+// @Param        productId  path  string  true  "the ID of the product"
+// @Success      200  {object}  ProductResponse
+// @Failure      404  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /products/{productId} [get]
+func (_ctrl *ProductController) GetProduct(ctx *gin.Context) {
+	productId := ctx.Param("productId")
+	product, err := _ctrl.ProductRepository.GetProduct(productId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	} else if product == nil {
+		productNotFoundResponse(ctx, productId)
+		return
+	}
+
+	ctx.JSON(200, toProductResponse(product))
+}
+
+// CreateProduct godoc
+// @Summary      Create product
+// @Description  Adds a product to a vendor's catalog. A vendor's API keys add to their own.
+// @Tags         product
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body  ProductRequest  true  "The product to add"
+// @Param        X-Vendor-Id  header  string  false  "the vendor to add the product for, if the body doesn't say"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      201  {object}  ProductResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /products [post]
+func (_ctrl *ProductController) CreateProduct(ctx *gin.Context) {
+	var req ProductRequest
+	if !bindProductRequest(ctx, &req) {
+		return
+	}
+
+	vendorId := vendorFrom(ctx)
+	if len(req.VendorId) != 0 && len(vendorId) != 0 && req.VendorId != vendorId {
+		errorResponse(ctx, apierrors.New(apierrors.CodeForbidden, "Products can only be added for vendor [%s]", vendorId))
+		return
+	} else if len(req.VendorId) != 0 {
+		vendor, err := _ctrl.Vendors.GetVendor(req.VendorId)
+		if err != nil {
+			errorResponse(ctx, err)
+			return
+		}
+		vendorId = vendor.VendorId
+	} else if len(vendorId) == 0 {
+		vendorId = vendors.DefaultVendorId
+	}
+
+	if len(req.ProductId) == 0 {
+		req.ProductId = uuid.New().String()
+	}
+
+	existing, err := _ctrl.ProductRepository.GetProduct(req.ProductId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	} else if existing != nil {
+		errorResponse(ctx, apierrors.New(apierrors.CodeProductAlreadyExists, "Product ID [%s] already exists", req.ProductId).With("productId", req.ProductId))
+		return
+	}
+
+	product := toProduct(req.ProductId, &req)
+	product.VendorId = vendorId
+	err = _ctrl.ProductRepository.CreateProduct(product)
+	if err != nil {
+		errorResponse(ctx, apierrors.Internal(err))
+		return
+	}
+
+	ctx.JSON(201, toProductResponse(product))
+}
+
+// UpdateProduct godoc
+// @Summary      Update product
+// @Description  Replaces the details of a product in the catalog. Existing orders keep the prices they were placed with.
+// @Tags         product
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        productId  path  string          true  "the ID of the product"
+// @Param        request    body  ProductRequest  true  "The new details of the product"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      200  {object}  ProductResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /products/{productId} [put]
+func (_ctrl *ProductController) UpdateProduct(ctx *gin.Context) {
+	var req ProductRequest
+	if !bindProductRequest(ctx, &req) {
+		return
+	}
+
+	productId := ctx.Param("productId")
+	existing, ok := _ctrl.ownProduct(ctx, productId)
+	if !ok {
+		return
+	}
+
+	product := toProduct(productId, &req)
+	product.VendorId = existing.VendorId
+	found, err := _ctrl.ProductRepository.UpdateProduct(product)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	} else if !found {
+		productNotFoundResponse(ctx, productId)
+		return
+	}
+
+	ctx.JSON(200, toProductResponse(product))
+}
+
+// DeleteProduct godoc
+// @Summary      Delete product
+// @Description  Removes a product from the catalog. Existing orders for the product are unaffected.
+// @Tags         product
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        productId  path  string  true  "the ID of the product"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      204
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /products/{productId} [delete]
+func (_ctrl *ProductController) DeleteProduct(ctx *gin.Context) {
+	productId := ctx.Param("productId")
+	if _, ok := _ctrl.ownProduct(ctx, productId); !ok {
+		return
+	}
+
+	found, err := _ctrl.ProductRepository.DeleteProduct(productId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	} else if !found {
+		productNotFoundResponse(ctx, productId)
+		return
+	}
+
+	ctx.Status(204)
+}
+
+// Looks up a product the caller is about to change. Another vendor's products look like they don't exist.
+// Returns false if the request was rejected.
+func (_ctrl *ProductController) ownProduct(ctx *gin.Context, productId string) (*products.Product, bool) {
+	product, err := _ctrl.ProductRepository.GetProduct(productId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return nil, false
+	} else if product == nil || !principalFrom(ctx).CanActFor(product.VendorId) {
+		productNotFoundResponse(ctx, productId)
+		return nil, false
+	}
+	return product, true
+}
+
+// Reads the product out of the request body and makes sure it makes sense
+func bindProductRequest(ctx *gin.Context, req *ProductRequest) bool {
+	err := ctx.ShouldBindJSON(req)
+	if err != nil {
+		errorResponse(ctx, invalidRequest(err))
+		return false
+	}
+
+	if len(req.Name) == 0 {
+		errorResponse(ctx, missingParameters("name"))
+		return false
+	} else if req.Price < 0 || req.ShippingPrice < 0 {
+		errorResponse(ctx, invalidParameter("price", "Prices must not be negative"))
+		return false
+	}
+	return true
+}
+
+func toProduct(productId string, req *ProductRequest) *products.Product {
+	return &products.Product{
+		ProductId:     productId,
+		Name:          req.Name,
+		Description:   req.Description,
+		Price:         req.Price,
+		ShippingPrice: req.ShippingPrice,
+	}
+}
+
+func toProductResponse(product *products.Product) ProductResponse {
+	return ProductResponse{
+		ProductId:     product.ProductId,
+		Name:          product.Name,
+		Description:   product.Description,
+		Price:         product.Price,
+		ShippingPrice: product.ShippingPrice,
+		VendorId:      product.VendorId,
+	}
+}
+
+func productNotFoundResponse(ctx *gin.Context, productId string) {
+	errorResponse(ctx, apierrors.New(apierrors.CodeProductNotFound, "Product ID [%s] does not exist", productId).With("productId", productId))
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/reconcile"
+	"github.com/gin-gonic/gin"
+)
+
+// Compares the orders in the database with the chain on request. Every vendor is reconciled on their own,
+// against their own contract; requests are for the caller's vendor, or the default vendor.
+type ReconciliationController struct {
+	// looks up the reconciler for the vendor's orders
+	Reconciler func(vendorId string) (*reconcile.Reconciler, error)
+}
+
+// RunReconciliation godoc
+// @Summary      Reconcile orders with the chain
+// @Description  Compares every order with what the delivery contract says about it, and lists the tokens that no order accounts for.
+// @Description  The chain is taken to be right. With repair=true, orders the chain has moved on (e.g. a token the customer
+// @Description  bought but the database still has as paid) are brought up to date; anything else is only reported. Orders with a
+// @Description  chain operation still in flight are skipped. This walks every order, so it can take a while.
+// @Tags         reconciliation
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        repair  query  bool  false  "whether to repair the database"
+// @Param        X-Vendor-Id  header  string  false  "the vendor whose orders to reconcile. Defaults to the default vendor"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      200  {object}  reconcile.Report
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /reconciliation [post]
+func (_ctrl *ReconciliationController) RunReconciliation(ctx *gin.Context) {
+	repair := false
+	if value := ctx.Query("repair"); len(value) != 0 {
+		var err error
+		if repair, err = strconv.ParseBool(value); err != nil {
+			errorResponse(ctx, invalidParameter("repair", "repair must be true or false"))
+			return
+		}
+	}
+
+	reconciler, err := _ctrl.Reconciler(vendorOrDefault(ctx))
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	report, err := reconciler.Reconcile(ctx.Request.Context(), repair)
+	if errors.Is(err, reconcile.ErrAlreadyRunning) {
+		errorResponse(ctx, apierrors.Wrap(err, apierrors.CodeReconciliationInProgress, "A reconciliation is already running"))
+		return
+	} else if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(200, report)
+}
+
+// GetReconciliation godoc
+// @Summary      Get the last reconciliation
+// @Description  Returns the report from the last reconciliation that finished, whether it was scheduled or asked for
+// @Tags         reconciliation
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        X-Vendor-Id  header  string  false  "the vendor whose orders were reconciled. Defaults to the default vendor"
+// @Success      200  {object}  reconcile.Report
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Router       /reconciliation [get]
+func (_ctrl *ReconciliationController) GetReconciliation(ctx *gin.Context) {
+	reconciler, err := _ctrl.Reconciler(vendorOrDefault(ctx))
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	report := reconciler.LastReport()
+	if report == nil {
+		errorResponse(ctx, apierrors.New(apierrors.CodeReconciliationNotFound, "No reconciliation has finished yet"))
+		return
+	}
+	ctx.JSON(200, report)
+}
@@ -1,50 +1,337 @@
 package controllers
 
 import (
+	"net/http"
+
+	"github.com/bdunton9323/blockchain-playground/auth"
 	_ "github.com/bdunton9323/blockchain-playground/docs"
+	"github.com/bdunton9323/blockchain-playground/metrics"
+	"github.com/bdunton9323/blockchain-playground/tracing"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"     // swagger embed files
 	ginSwagger "github.com/swaggo/gin-swagger" // gin-swagger middleware
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 // @title           Vendor API
 // @version         1.0
 // @description     These APIs allow the client to order items from the vendor
+// @description
+// @description     Errors come back as an ApiError. Its `code` is one of these, and won't change between versions:
+// @description
+// @description     | Code | HTTP status | Meaning |
+// @description     | --- | --- | --- |
+// @description     | ADDRESS_ALREADY_CLAIMED | 409 | Another customer has already verified the address |
+// @description     | ADDRESS_NOT_VERIFIED | 409 | The customer hasn't proven they hold the address. They verify it by signing its challenge |
+// @description     | API_KEY_NOT_FOUND | 404 | The API key doesn't exist |
+// @description     | CHAIN_ERROR | 502 | The blockchain node refused the request for some other reason |
+// @description     | CHAIN_UNAVAILABLE | 503 | The blockchain node can't be reached. Try again later |
+// @description     | CHALLENGE_EXPIRED | 409 | The challenge to sign has expired. Adding the address again gives a new one |
+// @description     | CONTRACT_ALREADY_DEPLOYED | 409 | The vendor already has a delivery contract. Replacing it has to be asked for, and the default vendor's can't be |
+// @description     | CUSTODY_NOT_CONFIGURED | 409 | The service has no HD wallet seed, so it can't hold keys for custodial customers or new vendors |
+// @description     | CUSTOMER_ADDRESS_NOT_FOUND | 404 | The address isn't in the customer's address book |
+// @description     | CUSTOMER_NOT_FOUND | 404 | The customer doesn't exist |
+// @description     | DELIVERY_EVIDENCE_MISSING | 409 | The vendor can only claim an expired escrow once evidence of delivery has been recorded |
+// @description     | DELIVERY_TOKEN_NOT_FOUND | 404 | The order's delivery token was never minted |
+// @description     | ESCROW_NOT_EXPIRED | 409 | The order's escrow can't be released or refunded until it expires. details.expiresAt says when |
+// @description     | ESCROW_UNSUPPORTED | 409 | The vendor's delivery contract was built before escrow could expire |
+// @description     | FORBIDDEN | 403 | The caller's role doesn't allow the request |
+// @description     | IDEMPOTENCY_KEY_IN_PROGRESS | 409 | A request with the same Idempotency-Key is still being handled, or only just finished |
+// @description     | IDEMPOTENCY_KEY_REUSED | 422 | The Idempotency-Key was already used for a different request |
+// @description     | INSUFFICIENT_FUNDS | 400 | The account signing the transaction can't cover its value and gas |
+// @description     | INTERNAL | 500 | Something broke on the server. The correlation ID will help track it down |
+// @description     | INVALID_ADDRESS | 400 | An address isn't 0x and 40 hex digits, has a bad EIP-55 checksum, or can't receive deliveries. details.fields names it |
+// @description     | INVALID_CREDENTIALS | 401 | The API key, session token, or signed sign-in message is not valid |
+// @description     | INVALID_CURSOR | 400 | The paging cursor is corrupt or belongs to a differently sorted or filtered list |
+// @description     | INVALID_PARAMETER | 400 | A parameter has a value that isn't allowed, or several are wrong in different ways. details.fields names them |
+// @description     | INVALID_PRIVATE_KEY | 400 | The customer's private key isn't 32 bytes of hex, or isn't a valid secp256k1 key |
+// @description     | INVALID_REQUEST | 400 | The request couldn't be understood, e.g. the body isn't valid JSON |
+// @description     | INVALID_SIGNATURE | 400 | The signature isn't 65 bytes of hex, or wasn't made with the address's key |
+// @description     | INVALID_SIGN_IN_MESSAGE | 400 | The Sign-In with Ethereum message isn't a well formed EIP-4361 message |
+// @description     | MISSING_PARAMETER | 400 | Required parameters are missing. details.fields lists them |
+// @description     | ORDER_ALREADY_PAID | 409 | The order has already been paid for. details.currentStatus says how far it has got |
+// @description     | ORDER_NOT_FOUND | 404 | The order doesn't exist, or belongs to somebody else |
+// @description     | ORDER_OPERATION_IN_PROGRESS | 409 | The order's last chain operation hasn't finished yet. Try again once it has |
+// @description     | ORDER_STATUS_CONFLICT | 409 | The order's status doesn't allow the request. details has the currentStatus and the requestedStatus |
+// @description     | PRODUCT_ALREADY_EXISTS | 409 | A product with that ID is already in the catalog |
+// @description     | PRODUCT_NOT_FOUND | 404 | The product isn't in the catalog |
+// @description     | RECONCILIATION_IN_PROGRESS | 409 | Another reconciliation is still running. Try again once it has finished |
+// @description     | RECONCILIATION_NOT_FOUND | 404 | No reconciliation has finished since the service started |
+// @description     | SWEEP_NOT_CONFIGURED | 409 | Sweeping is off because the service has no cold wallet to sweep to |
+// @description     | TX_REVERTED | 400 | The contract rejected the transaction |
+// @description     | UNAUTHENTICATED | 401 | The endpoint needs credentials and none were sent |
+// @description     | VENDOR_ALREADY_EXISTS | 409 | A vendor with that ID already exists |
+// @description     | VENDOR_NOT_FOUND | 404 | The vendor doesn't exist, or the caller can't act for it |
+// @description     | VENDOR_NOT_READY | 409 | The vendor's delivery contract hasn't been deployed yet, so it can't take orders |
+// @description     | WEBHOOK_DELIVERY_NOT_DEAD | 409 | Only deliveries that were given up on can be retried |
+// @description     | WEBHOOK_DELIVERY_NOT_FOUND | 404 | The webhook delivery doesn't exist |
+// @description     | WEBHOOK_NOT_FOUND | 404 | The webhook subscription doesn't exist |
+// @description
+// @description     Every response carries an X-Correlation-ID header, which is also in the error body. Send your own to tie requests together.
 // @license.name    MIT
 // @license.url     https://github.com/bdunton9323/blockchain-playground/blob/main/LICENSE
 // @host            localhost:8080
 // @BasePath        /api/v1
+//
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
+// @description                 An API key issued to a back-office system
+//
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 "Bearer " followed by the session token from signing in with Ethereum
 type ApiRouter struct {
-	orderController *OrderController
-}
+	// where to listen, e.g. :8080
+	Address string
 
-// Constructs a new API router that dispatches to the given controller
-func NewApiRouter(orderController *OrderController) *ApiRouter {
-	return &ApiRouter{
-		orderController: orderController,
-	}
+	OrderController   *OrderController
+	ProductController *ProductController
+	AuthController    *AuthController
+	WebhookController *WebhookController
+	HealthController  *HealthController
+	// the vendor's balances and sweeps
+	TreasuryController *TreasuryController
+	// compares the database with the chain
+	ReconciliationController *ReconciliationController
+	// customers' accounts and address books
+	CustomerController *CustomerController
+	// the vendors in the marketplace
+	VendorController *VendorController
+	// identifies callers and enforces their roles
+	Authenticator *Authenticator
+	// makes write endpoints safe to retry
+	Idempotency *IdempotencyMiddleware
 }
 
-// Starts listening for HTTP requests
-func (_apiRouter *ApiRouter) Start() {
+// Builds the HTTP server for the API. Start it with ListenAndServe and stop it with Shutdown, which lets the
+// requests in flight finish.
+func (_apiRouter *ApiRouter) Server() *http.Server {
 	router := gin.Default()
+	// a traceparent header from upstream makes our spans part of the caller's trace
+	router.Use(otelgin.Middleware(tracing.ServiceName), metrics.Middleware, CorrelationId, _apiRouter.Authenticator.Authenticate)
+
+	// write endpoints can be retried safely by sending an Idempotency-Key header
+	idempotent := _apiRouter.Idempotency.Handle
+
+	// who may call what
+	require := _apiRouter.Authenticator.Require
+	anyone := require(auth.AllRoles...)
+	admin := require(auth.RoleVendorAdmin)
+	orderReaders := require(auth.RoleVendorAdmin, auth.RoleCustomer, auth.RoleCourier, auth.RoleAuditor)
+	orderWriters := require(auth.RoleVendorAdmin, auth.RoleCustomer, auth.RoleCourier)
+	buyers := require(auth.RoleVendorAdmin, auth.RoleCustomer)
+	finance := require(auth.RoleVendorAdmin, auth.RoleAuditor)
+	// the parts of the API that are shared by every vendor, which only the marketplace's own keys can use
+	marketplace := _apiRouter.Authenticator.Marketplace
+
+	router.POST("/api/v1/order", buyers, idempotent, func(ctx *gin.Context) {
+		_apiRouter.OrderController.CreateOrder(ctx)
+	})
+
+	router.GET("/api/v1/orders", orderReaders, func(ctx *gin.Context) {
+		_apiRouter.OrderController.ListOrders(ctx)
+	})
+
+	// which status changes each role may make is checked by the controller
+	router.POST("/api/v1/order/:orderId", orderWriters, idempotent, func(ctx *gin.Context) {
+		_apiRouter.OrderController.UpdateOrderStatus(ctx)
+	})
+
+	router.POST("/api/v1/payment/order/:orderId", buyers, idempotent, func(ctx *gin.Context) {
+		_apiRouter.OrderController.PayForOrder(ctx)
+	})
+
+	router.GET("/api/v1/order/:orderId/owner", orderReaders, func(ctx *gin.Context) {
+		_apiRouter.OrderController.GetDeliveryTokenOwner(ctx)
+	})
+
+	router.POST("/api/v1/order/:orderId/evidence", orderWriters, idempotent, func(ctx *gin.Context) {
+		_apiRouter.OrderController.RecordDeliveryEvidence(ctx)
+	})
+
+	router.GET("/api/v1/order/:orderId/history", orderReaders, func(ctx *gin.Context) {
+		_apiRouter.OrderController.GetOrderHistory(ctx)
+	})
+
+	// live updates, for clients that would otherwise poll
+	router.GET("/api/v1/order/:orderId/events", orderReaders, func(ctx *gin.Context) {
+		_apiRouter.OrderController.StreamOrderEvents(ctx)
+	})
+
+	router.GET("/api/v1/order/:orderId/ws", orderReaders, func(ctx *gin.Context) {
+		_apiRouter.OrderController.StreamOrderWebSocket(ctx)
+	})
+
+	// the catalog is public, but only the vendor can change it
+	router.GET("/api/v1/products", func(ctx *gin.Context) {
+		_apiRouter.ProductController.ListProducts(ctx)
+	})
+
+	router.POST("/api/v1/products", admin, idempotent, func(ctx *gin.Context) {
+		_apiRouter.ProductController.CreateProduct(ctx)
+	})
+
+	router.GET("/api/v1/products/:productId", func(ctx *gin.Context) {
+		_apiRouter.ProductController.GetProduct(ctx)
+	})
+
+	router.PUT("/api/v1/products/:productId", admin, idempotent, func(ctx *gin.Context) {
+		_apiRouter.ProductController.UpdateProduct(ctx)
+	})
+
+	router.DELETE("/api/v1/products/:productId", admin, idempotent, func(ctx *gin.Context) {
+		_apiRouter.ProductController.DeleteProduct(ctx)
+	})
+
+	router.POST("/api/v1/auth/nonce", func(ctx *gin.Context) {
+		_apiRouter.AuthController.CreateNonce(ctx)
+	})
+
+	router.POST("/api/v1/auth/siwe", func(ctx *gin.Context) {
+		_apiRouter.AuthController.SignInWithEthereum(ctx)
+	})
+
+	router.POST("/api/v1/auth/logout", func(ctx *gin.Context) {
+		_apiRouter.AuthController.SignOut(ctx)
+	})
+
+	router.GET("/api/v1/auth/me", anyone, func(ctx *gin.Context) {
+		_apiRouter.AuthController.WhoAmI(ctx)
+	})
+
+	// a retry gets the same key back, so the saved response holds the key until the Idempotency-Key expires
+	router.POST("/api/v1/apikeys", admin, idempotent, func(ctx *gin.Context) {
+		_apiRouter.AuthController.CreateApiKey(ctx)
+	})
+
+	router.GET("/api/v1/apikeys", admin, func(ctx *gin.Context) {
+		_apiRouter.AuthController.ListApiKeys(ctx)
+	})
+
+	router.DELETE("/api/v1/apikeys/:keyId", admin, idempotent, func(ctx *gin.Context) {
+		_apiRouter.AuthController.RevokeApiKey(ctx)
+	})
 
-	router.POST("/api/v1/order", func(ctx *gin.Context) {
-		_apiRouter.orderController.CreateOrder(ctx)
+	// webhooks and customers belong to the marketplace as a whole
+	router.GET("/api/v1/webhooks", admin, marketplace, func(ctx *gin.Context) {
+		_apiRouter.WebhookController.ListWebhooks(ctx)
 	})
 
-	router.POST("/api/v1/order/:orderId", func(ctx *gin.Context) {
-		_apiRouter.orderController.UpdateOrderStatus(ctx)
+	router.POST("/api/v1/webhooks", admin, marketplace, idempotent, func(ctx *gin.Context) {
+		_apiRouter.WebhookController.CreateWebhook(ctx)
 	})
 
-	router.POST("/api/v1/payment/order/:orderId", func(ctx *gin.Context) {
-		_apiRouter.orderController.PayForOrder(ctx)
+	router.GET("/api/v1/webhooks/:webhookId", admin, marketplace, func(ctx *gin.Context) {
+		_apiRouter.WebhookController.GetWebhook(ctx)
 	})
 
-	router.GET("/api/v1/order/:orderId/owner", func(ctx *gin.Context) {
-		_apiRouter.orderController.GetDeliveryTokenOwner(ctx)
+	router.PUT("/api/v1/webhooks/:webhookId", admin, marketplace, idempotent, func(ctx *gin.Context) {
+		_apiRouter.WebhookController.UpdateWebhook(ctx)
 	})
 
+	router.DELETE("/api/v1/webhooks/:webhookId", admin, marketplace, idempotent, func(ctx *gin.Context) {
+		_apiRouter.WebhookController.DeleteWebhook(ctx)
+	})
+
+	// the dead-letter view: ?status=dead
+	router.GET("/api/v1/webhooks/:webhookId/deliveries", admin, marketplace, func(ctx *gin.Context) {
+		_apiRouter.WebhookController.ListWebhookDeliveries(ctx)
+	})
+
+	router.POST("/api/v1/webhooks/:webhookId/deliveries/:deliveryId/retry", admin, marketplace, idempotent, func(ctx *gin.Context) {
+		_apiRouter.WebhookController.RetryWebhookDelivery(ctx)
+	})
+
+	router.POST("/api/v1/reconciliation", admin, idempotent, func(ctx *gin.Context) {
+		_apiRouter.ReconciliationController.RunReconciliation(ctx)
+	})
+
+	router.GET("/api/v1/reconciliation", admin, func(ctx *gin.Context) {
+		_apiRouter.ReconciliationController.GetReconciliation(ctx)
+	})
+
+	router.GET("/api/v1/treasury", finance, func(ctx *gin.Context) {
+		_apiRouter.TreasuryController.GetBalances(ctx)
+	})
+
+	router.GET("/api/v1/treasury/customers/:address", finance, func(ctx *gin.Context) {
+		_apiRouter.TreasuryController.GetCustomerBalance(ctx)
+	})
+
+	router.POST("/api/v1/treasury/sweep", admin, idempotent, func(ctx *gin.Context) {
+		_apiRouter.TreasuryController.Sweep(ctx)
+	})
+
+	router.POST("/api/v1/customers", admin, marketplace, idempotent, func(ctx *gin.Context) {
+		_apiRouter.CustomerController.CreateCustomer(ctx)
+	})
+
+	router.GET("/api/v1/customers", finance, marketplace, func(ctx *gin.Context) {
+		_apiRouter.CustomerController.ListCustomers(ctx)
+	})
+
+	router.GET("/api/v1/customers/:customerId", finance, marketplace, func(ctx *gin.Context) {
+		_apiRouter.CustomerController.GetCustomer(ctx)
+	})
+
+	router.PUT("/api/v1/customers/:customerId", admin, marketplace, idempotent, func(ctx *gin.Context) {
+		_apiRouter.CustomerController.UpdateCustomer(ctx)
+	})
+
+	router.GET("/api/v1/customers/:customerId/orders", finance, marketplace, func(ctx *gin.Context) {
+		_apiRouter.CustomerController.ListCustomerOrders(ctx)
+	})
+
+	router.POST("/api/v1/customers/:customerId/addresses", admin, marketplace, idempotent, func(ctx *gin.Context) {
+		_apiRouter.CustomerController.AddAddress(ctx)
+	})
+
+	router.POST("/api/v1/customers/:customerId/addresses/:address/verify", admin, marketplace, idempotent, func(ctx *gin.Context) {
+		_apiRouter.CustomerController.VerifyAddress(ctx)
+	})
+
+	router.DELETE("/api/v1/customers/:customerId/addresses/:address", admin, marketplace, idempotent, func(ctx *gin.Context) {
+		_apiRouter.CustomerController.RemoveAddress(ctx)
+	})
+
+	router.POST("/api/v1/vendors", admin, marketplace, idempotent, func(ctx *gin.Context) {
+		_apiRouter.VendorController.CreateVendor(ctx)
+	})
+
+	router.GET("/api/v1/vendors", finance, marketplace, func(ctx *gin.Context) {
+		_apiRouter.VendorController.ListVendors(ctx)
+	})
+
+	// a vendor can look up and deploy their own contract
+	router.GET("/api/v1/vendors/:vendorId", finance, func(ctx *gin.Context) {
+		_apiRouter.VendorController.GetVendor(ctx)
+	})
+
+	router.PUT("/api/v1/vendors/:vendorId", admin, marketplace, idempotent, func(ctx *gin.Context) {
+		_apiRouter.VendorController.UpdateVendor(ctx)
+	})
+
+	router.POST("/api/v1/vendors/:vendorId/contract", admin, idempotent, func(ctx *gin.Context) {
+		_apiRouter.VendorController.DeployContract(ctx)
+	})
+
+	// for the orchestrator, so they live outside the API and don't need credentials
+	router.GET("/healthz", func(ctx *gin.Context) {
+		_apiRouter.HealthController.Healthz(ctx)
+	})
+	router.GET("/readyz", func(ctx *gin.Context) {
+		_apiRouter.HealthController.Readyz(ctx)
+	})
+
+	// for Prometheus to scrape
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	router.Run(":8080")
+	return &http.Server{
+		Addr:    _apiRouter.Address,
+		Handler: router,
+	}
 }
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/treasury"
+	"github.com/bdunton9323/blockchain-playground/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// Reports where the vendor's money is, and moves it to cold storage. Requests are for the caller's vendor, or the
+// default vendor.
+type TreasuryController struct {
+	// looks up the treasury that looks after the vendor's money
+	Treasury func(vendorId string) (*treasury.Treasury, error)
+}
+
+// GetBalances godoc
+// @Summary      Get the vendor's balances
+// @Description  Reports the vendor's balance, what the delivery contract holds in escrow, and which customers' paid but undelivered
+// @Description  orders that escrow is for. Amounts are in wei, as strings. unaccounted is what the contract holds beyond the orders
+// @Description  the database has as paid; anything but 0 is worth a reconciliation.
+// @Tags         treasury
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        X-Vendor-Id  header  string  false  "the vendor whose balances to report. Defaults to the default vendor"
+// @Success      200  {object}  treasury.BalanceReport
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /treasury [get]
+func (_ctrl *TreasuryController) GetBalances(ctx *gin.Context) {
+	vendorTreasury, err := _ctrl.Treasury(vendorOrDefault(ctx))
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	report, err := vendorTreasury.Balances(ctx.Request.Context())
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(200, report)
+}
+
+// GetCustomerBalance godoc
+// @Summary      Get a customer's balance
+// @Description  Reports a customer's balance and what they have in escrow, in wei
+// @Tags         treasury
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        address  path  string  true  "the customer's address"
+// @Param        X-Vendor-Id  header  string  false  "the vendor whose escrow to count. Defaults to the default vendor"
+// @Success      200  {object}  treasury.CustomerBalance
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /treasury/customers/{address} [get]
+func (_ctrl *TreasuryController) GetCustomerBalance(ctx *gin.Context) {
+	address, err := validation.ParseAddress(ctx.Param("address"))
+	if err != nil {
+		errorResponse(ctx, apierrors.Wrap(err, apierrors.CodeInvalidAddress, "address %s", err.Error()).
+			With("fields", []string{"address"}))
+		return
+	}
+
+	vendorTreasury, err := _ctrl.Treasury(vendorOrDefault(ctx))
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	customer, err := vendorTreasury.CustomerBalance(ctx.Request.Context(), address)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(200, customer)
+}
+
+// Sweep godoc
+// @Summary      Sweep the vendor's funds
+// @Description  If the vendor's balance is above the configured threshold, sends everything but the amount to keep (and the gas)
+// @Description  to the cold wallet, and waits for it to be mined. swept is false, with the reason, if the balance isn't high enough.
+// @Tags         treasury
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        X-Vendor-Id  header  string  false  "the vendor whose funds to sweep. Defaults to the default vendor"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      200  {object}  treasury.SweepResult
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /treasury/sweep [post]
+func (_ctrl *TreasuryController) Sweep(ctx *gin.Context) {
+	vendorTreasury, err := _ctrl.Treasury(vendorOrDefault(ctx))
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	result, err := vendorTreasury.Sweep(ctx.Request.Context())
+	if errors.Is(err, treasury.ErrSweepingDisabled) {
+		errorResponse(ctx, apierrors.Wrap(err, apierrors.CodeSweepNotConfigured, "Sweeping is off: no cold wallet is configured"))
+		return
+	} else if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(200, result)
+}
@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/vendors"
+	"github.com/gin-gonic/gin"
+)
+
+// Manages the vendors who sell through the marketplace
+type VendorController struct {
+	Registry *vendors.Registry
+}
+
+// The request body for adding or changing a vendor
+type VendorRequest struct {
+	// Only when adding the vendor, and optional; one is generated if it is left out. Lower case letters, digits and
+	// dashes.
+	VendorId string `json:"vendorId" example:"acme"`
+	// who the vendor is
+	Name string `json:"name"`
+	// where the vendor's takings are swept to. Optional.
+	ColdWallet string `json:"coldWallet,omitempty" format:"address"`
+}
+
+// A vendor in the marketplace
+type VendorResponse struct {
+	VendorId string `json:"vendorId"`
+	Name     string `json:"name"`
+	// The address the vendor signs their tokens and contract with. It needs ether before the contract can be
+	// deployed, and for every order after that.
+	Address string `json:"address,omitempty" format:"address"`
+	// where the vendor's key is in the service's HD wallet. Empty for the default vendor, whose key is configured.
+	DerivationPath string `json:"derivationPath,omitempty" example:"m/44'/60'/1'/0/0"`
+	// the vendor's delivery contract. Empty until it has been deployed.
+	ContractAddress string `json:"contractAddress,omitempty" format:"address"`
+	// whether the vendor's contract is deployed, so they can take orders
+	Ready bool `json:"ready"`
+	// where the vendor's takings are swept to
+	ColdWallet string    `json:"coldWallet,omitempty" format:"address"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// CreateVendor godoc
+// @Summary      Add a vendor
+// @Description  Adds a vendor to the marketplace, with a signing key of their own derived from the service's HD wallet. The vendor
+// @Description  can't take orders until their delivery contract is deployed, which their address needs ether for.
+// @Tags         vendor
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body  VendorRequest  true  "the vendor"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      201  {object}  VendorResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      409  {object}  ApiError  "The vendor already exists, or no HD wallet seed is configured"
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /vendors [post]
+func (_ctrl *VendorController) CreateVendor(ctx *gin.Context) {
+	var req VendorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, invalidRequest(err))
+		return
+	}
+
+	vendor, err := _ctrl.Registry.CreateVendor(toVendorInput(&req))
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(201, toVendorResponse(vendor))
+}
+
+// ListVendors godoc
+// @Summary      List vendors
+// @Description  Lists every vendor in the marketplace, oldest first
+// @Tags         vendor
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array}   VendorResponse
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /vendors [get]
+func (_ctrl *VendorController) ListVendors(ctx *gin.Context) {
+	list, err := _ctrl.Registry.ListVendors()
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	response := []VendorResponse{}
+	for _, vendor := range list {
+		response = append(response, toVendorResponse(vendor))
+	}
+	ctx.JSON(200, response)
+}
+
+// GetVendor godoc
+// @Summary      Get a vendor
+// @Description  Looks up a vendor. A vendor's API keys can only look up their own.
+// @Tags         vendor
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        vendorId  path  string  true  "the ID of the vendor"
+// @Success      200  {object}  VendorResponse
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /vendors/{vendorId} [get]
+func (_ctrl *VendorController) GetVendor(ctx *gin.Context) {
+	vendor, ok := _ctrl.vendor(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(200, toVendorResponse(vendor))
+}
+
+// UpdateVendor godoc
+// @Summary      Change a vendor
+// @Description  Replaces the vendor's name and cold wallet. Leaving the cold wallet out stops their takings being swept. Their key
+// @Description  never changes.
+// @Tags         vendor
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        vendorId  path  string         true  "the ID of the vendor"
+// @Param        request   body  VendorRequest  true  "the vendor's details; vendorId is ignored"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      200  {object}  VendorResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /vendors/{vendorId} [put]
+func (_ctrl *VendorController) UpdateVendor(ctx *gin.Context) {
+	var req VendorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, invalidRequest(err))
+		return
+	}
+
+	vendor, err := _ctrl.Registry.UpdateVendor(ctx.Param("vendorId"), toVendorInput(&req))
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(200, toVendorResponse(vendor))
+}
+
+// DeployVendorContract godoc
+// @Summary      Deploy a vendor's contract
+// @Description  Deploys the vendor's delivery contract, signed with their key, and waits for it to be mined. Send ether to the vendor's
+// @Description  address for the gas first. Once it is deployed the vendor can take orders. A vendor who already has a contract only
+// @Description  gets a new one with replace=true. Their orders on the old contract stay there and can't be taken any further.
+// @Tags         vendor
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        vendorId  path   string  true   "the ID of the vendor"
+// @Param        replace   query  bool    false  "deploy a new contract even though the vendor has one"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      200  {object}  VendorResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      409  {object}  ApiError  "The vendor already has a contract, or no HD wallet seed is configured"
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Failure      502  {object}  ApiError  "The contract couldn't be deployed, e.g. because the vendor's address can't pay for the gas"
+// @Router       /vendors/{vendorId}/contract [post]
+func (_ctrl *VendorController) DeployContract(ctx *gin.Context) {
+	replace := false
+	if value := ctx.Query("replace"); len(value) != 0 {
+		var err error
+		if replace, err = strconv.ParseBool(value); err != nil {
+			errorResponse(ctx, invalidParameter("replace", "replace must be true or false"))
+			return
+		}
+	}
+	if _, ok := _ctrl.vendor(ctx); !ok {
+		return
+	}
+
+	vendor, err := _ctrl.Registry.DeployContract(ctx.Param("vendorId"), replace)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(200, toVendorResponse(vendor))
+}
+
+// Looks up the vendor in the path. Other vendors look like they don't exist to a vendor's own keys.
+// Returns false if the request was rejected.
+func (_ctrl *VendorController) vendor(ctx *gin.Context) (*vendors.Vendor, bool) {
+	vendorId := ctx.Param("vendorId")
+	vendor, err := _ctrl.Registry.GetVendor(vendorId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return nil, false
+	} else if !principalFrom(ctx).CanActFor(vendor.VendorId) {
+		errorResponse(ctx, apierrors.New(apierrors.CodeVendorNotFound, "Vendor ID [%s] does not exist", vendorId).
+			With("vendorId", vendorId))
+		return nil, false
+	}
+	return vendor, true
+}
+
+func toVendorInput(req *VendorRequest) *vendors.VendorInput {
+	return &vendors.VendorInput{
+		VendorId:   req.VendorId,
+		Name:       req.Name,
+		ColdWallet: req.ColdWallet,
+	}
+}
+
+func toVendorResponse(vendor *vendors.Vendor) VendorResponse {
+	return VendorResponse{
+		VendorId:        vendor.VendorId,
+		Name:            vendor.Name,
+		Address:         vendor.Address,
+		DerivationPath:  vendor.DerivationPath,
+		ContractAddress: vendor.ContractAddress,
+		Ready:           vendor.Ready(),
+		ColdWallet:      vendor.ColdWallet,
+		CreatedAt:       vendor.CreatedAt,
+		UpdatedAt:       vendor.UpdatedAt,
+	}
+}
@@ -0,0 +1,452 @@
+package controllers
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/webhooks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Manages the subscriptions of systems that want to be told when orders change
+type WebhookController struct {
+	// where subscriptions and their deliveries are stored
+	WebhookRepository webhooks.WebhookRepository
+}
+
+// The request body for creating or updating a webhook subscription
+type WebhookRequest struct {
+	// where events are POSTed to. Must be an http or https URL.
+	Url string `json:"url"`
+	// the events to send, e.g. ["order.minted", "order.paid"]
+	Events []string `json:"events"`
+	// The key used to sign payloads. Optional; one is generated when creating if omitted, and the
+	// existing one is kept when updating if omitted.
+	Secret string `json:"secret,omitempty"`
+	// whether to send events. Defaults to true when creating.
+	Active *bool `json:"active,omitempty"`
+}
+
+// A webhook subscription. The secret is only included when it is created or changed.
+type WebhookResponse struct {
+	// identifies the subscription
+	WebhookId string `json:"webhookId"`
+	// where events are POSTed to
+	Url string `json:"url"`
+	// the events that are sent
+	Events []string `json:"events"`
+	// the key that payloads are signed with. Keep it safe; it cannot be retrieved again.
+	Secret string `json:"secret,omitempty"`
+	// whether events are being sent
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// One event sent to a subscriber
+type WebhookDeliveryResponse struct {
+	// identifies the delivery
+	DeliveryId int64 `json:"deliveryId"`
+	// identifies the event; it is the same across retries
+	EventId   string `json:"eventId"`
+	EventType string `json:"eventType"`
+	OrderId   string `json:"orderId"`
+	// the request body that is sent
+	Payload string `json:"payload"`
+	// one of ('pending', 'delivered', 'dead')
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	// why the last attempt failed
+	LastError string `json:"lastError,omitempty"`
+	// the HTTP status the subscriber last responded with, or 0 if it couldn't be reached
+	ResponseCode  int       `json:"responseCode"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// the most deliveries returned at once
+var maxDeliveryPageSize = 100
+
+// ListWebhooks godoc
+// @Summary      List webhooks
+// @Description  Lists every webhook subscription
+// @Tags         webhook
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array}   WebhookResponse
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /webhooks [get]
+func (_ctrl *WebhookController) ListWebhooks(ctx *gin.Context) {
+	subs, err := _ctrl.WebhookRepository.ListSubscriptions()
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	response := []WebhookResponse{}
+	for _, sub := range subs {
+		response = append(response, toWebhookResponse(sub))
+	}
+	ctx.JSON(200, response)
+}
+
+// GetWebhook godoc
+// @Summary      Get webhook
+// @Description  Looks up a single webhook subscription
+// @Tags         webhook
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        webhookId  path  string  true  "the ID of the subscription"
+// @Success      200  {object}  WebhookResponse
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /webhooks/{webhookId} [get]
+func (_ctrl *WebhookController) GetWebhook(ctx *gin.Context) {
+	sub, ok := _ctrl.findSubscription(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(200, toWebhookResponse(sub))
+}
+
+// CreateWebhook godoc
+// @Summary      Create webhook
+// @Description  Subscribes a URL to order events. Each event is POSTed as JSON with an X-Webhook-Signature
+// @Description  header of the form "t=<unix time>,v1=<hex HMAC-SHA256 of '<unix time>.<body>' keyed with the secret>".
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request  body  WebhookRequest  true  "Where to send events and which ones"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      201  {object}  WebhookResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /webhooks [post]
+func (_ctrl *WebhookController) CreateWebhook(ctx *gin.Context) {
+	req, sub, ok := bindWebhookRequest(ctx)
+	if !ok {
+		return
+	}
+
+	sub.SubscriptionId = uuid.New().String()
+	sub.Active = req.Active == nil || *req.Active
+	if len(sub.Secret) == 0 {
+		secret, err := auth.NewSecret(32)
+		if err != nil {
+			errorResponse(ctx, err)
+			return
+		}
+		sub.Secret = secret
+	}
+
+	if err := _ctrl.WebhookRepository.CreateSubscription(sub); err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	log.Infof("Webhook [%s] created for [%s]", sub.SubscriptionId, sub.Url)
+	response := toWebhookResponse(sub)
+	response.Secret = sub.Secret
+	ctx.JSON(201, response)
+}
+
+// UpdateWebhook godoc
+// @Summary      Update webhook
+// @Description  Changes where events are sent, which ones, the signing secret, or pauses and resumes the subscription.
+// @Description  Deliveries queued while a subscription is paused are sent once it is resumed.
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        webhookId  path  string          true  "the ID of the subscription"
+// @Param        request    body  WebhookRequest  true  "The new details of the subscription"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      200  {object}  WebhookResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /webhooks/{webhookId} [put]
+func (_ctrl *WebhookController) UpdateWebhook(ctx *gin.Context) {
+	existing, ok := _ctrl.findSubscription(ctx)
+	if !ok {
+		return
+	}
+	req, sub, ok := bindWebhookRequest(ctx)
+	if !ok {
+		return
+	}
+
+	sub.SubscriptionId = existing.SubscriptionId
+	sub.CreatedAt = existing.CreatedAt
+	sub.Active = existing.Active
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	rotated := len(sub.Secret) != 0
+	if !rotated {
+		sub.Secret = existing.Secret
+	}
+
+	found, err := _ctrl.WebhookRepository.UpdateSubscription(sub)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	} else if !found {
+		webhookNotFoundResponse(ctx, sub.SubscriptionId)
+		return
+	}
+
+	response := toWebhookResponse(sub)
+	if rotated {
+		response.Secret = sub.Secret
+	}
+	ctx.JSON(200, response)
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete webhook
+// @Description  Stops sending events to the subscriber and throws away its queued and past deliveries
+// @Tags         webhook
+// @Security     ApiKeyAuth
+// @Param        webhookId  path  string  true  "the ID of the subscription"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      204
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /webhooks/{webhookId} [delete]
+func (_ctrl *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	webhookId := ctx.Param("webhookId")
+	found, err := _ctrl.WebhookRepository.DeleteSubscription(webhookId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	} else if !found {
+		webhookNotFoundResponse(ctx, webhookId)
+		return
+	}
+
+	log.Infof("Webhook [%s] deleted", webhookId)
+	ctx.Status(204)
+}
+
+// ListWebhookDeliveries godoc
+// @Summary      List webhook deliveries
+// @Description  Lists the subscription's most recent deliveries. Ask for status 'dead' to see the ones that were given up on.
+// @Tags         webhook
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        webhookId  path   string  true   "the ID of the subscription"
+// @Param        status     query  string  false  "only deliveries in this status. One of ('pending', 'delivered', 'dead')"
+// @Param        limit      query  int     false  "the maximum number of deliveries to return (1-100). Defaults to 100"
+// @Success      200  {array}   WebhookDeliveryResponse
+// @Failure      400  {object}  ApiError
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      500  {object}  ApiError
+// @Router       /webhooks/{webhookId}/deliveries [get]
+func (_ctrl *WebhookController) ListWebhookDeliveries(ctx *gin.Context) {
+	var status webhooks.DeliveryStatus
+	if name := ctx.Query("status"); len(name) != 0 {
+		var ok bool
+		if status, ok = webhooks.ParseDeliveryStatus(name); !ok {
+			errorResponse(ctx, invalidParameter("status", "Invalid status. Expected 'pending', 'delivered', or 'dead'"))
+			return
+		}
+	}
+
+	limit := maxDeliveryPageSize
+	if value := ctx.Query("limit"); len(value) != 0 {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxDeliveryPageSize {
+			errorResponse(ctx, invalidParameter("limit", "limit must be between 1 and %d", maxDeliveryPageSize))
+			return
+		}
+	}
+
+	sub, ok := _ctrl.findSubscription(ctx)
+	if !ok {
+		return
+	}
+
+	deliveries, err := _ctrl.WebhookRepository.ListDeliveries(sub.SubscriptionId, status, limit)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	response := []WebhookDeliveryResponse{}
+	for _, delivery := range deliveries {
+		response = append(response, toWebhookDeliveryResponse(delivery))
+	}
+	ctx.JSON(200, response)
+}
+
+// RetryWebhookDelivery godoc
+// @Summary      Retry a dead webhook delivery
+// @Description  Puts a delivery that was given up on back in the queue, with a fresh set of attempts
+// @Tags         webhook
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        webhookId   path  string  true  "the ID of the subscription"
+// @Param        deliveryId  path  int     true  "the ID of the delivery"
+// @Param        Idempotency-Key  header  string  false  "makes the request safe to retry; a retry with the same key returns the original response"
+// @Success      200  {object}  WebhookDeliveryResponse
+// @Failure      401  {object}  ApiError
+// @Failure      403  {object}  ApiError
+// @Failure      404  {object}  ApiError
+// @Failure      409  {object}  ApiError
+// @Failure      422  {object}  ApiError  "The Idempotency-Key was already used for a different request"
+// @Failure      500  {object}  ApiError
+// @Router       /webhooks/{webhookId}/deliveries/{deliveryId}/retry [post]
+func (_ctrl *WebhookController) RetryWebhookDelivery(ctx *gin.Context) {
+	webhookId := ctx.Param("webhookId")
+	deliveryId, err := strconv.ParseInt(ctx.Param("deliveryId"), 10, 64)
+	if err != nil {
+		deliveryNotFoundResponse(ctx, ctx.Param("deliveryId"))
+		return
+	}
+
+	delivery, err := _ctrl.WebhookRepository.GetDelivery(deliveryId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	} else if delivery == nil || delivery.SubscriptionId != webhookId {
+		deliveryNotFoundResponse(ctx, ctx.Param("deliveryId"))
+		return
+	}
+
+	retried, err := _ctrl.WebhookRepository.RetryDelivery(deliveryId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	} else if !retried {
+		errorResponse(ctx, apierrors.New(apierrors.CodeWebhookDeliveryNotDead, "Delivery [%d] is %s; only dead deliveries can be retried", deliveryId, delivery.Status).
+			With("status", delivery.Status))
+		return
+	}
+
+	delivery, err = _ctrl.WebhookRepository.GetDelivery(deliveryId)
+	if err != nil || delivery == nil {
+		errorResponse(ctx, apierrors.Internal(err))
+		return
+	}
+	log.Infof("Webhook delivery [%d] requeued by [%s]", deliveryId, principalFrom(ctx).Subject)
+	ctx.JSON(200, toWebhookDeliveryResponse(delivery))
+}
+
+// Looks up the subscription named in the path. Writes the response and returns false if it can't be found.
+func (_ctrl *WebhookController) findSubscription(ctx *gin.Context) (*webhooks.Subscription, bool) {
+	webhookId := ctx.Param("webhookId")
+	sub, err := _ctrl.WebhookRepository.GetSubscription(webhookId)
+	if err != nil {
+		errorResponse(ctx, err)
+		return nil, false
+	} else if sub == nil {
+		webhookNotFoundResponse(ctx, webhookId)
+		return nil, false
+	}
+	return sub, true
+}
+
+// Reads and validates the request body. Writes a 400 response and returns false if it is invalid.
+func bindWebhookRequest(ctx *gin.Context) (*WebhookRequest, *webhooks.Subscription, bool) {
+	var req WebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, invalidRequest(err))
+		return nil, nil, false
+	}
+
+	sub := &webhooks.Subscription{
+		Url:    req.Url,
+		Secret: req.Secret,
+	}
+
+	err := validateWebhookUrl(req.Url)
+	if err == nil && len(req.Events) == 0 {
+		err = invalidParameter("events", "events must name at least one event")
+	}
+	for _, name := range req.Events {
+		eventType, ok := webhooks.ParseEventType(name)
+		if !ok && err == nil {
+			err = invalidParameter("events", "Unknown event [%s]", name)
+		}
+		sub.Events = append(sub.Events, eventType)
+	}
+	if err == nil && len(req.Secret) != 0 && len(req.Secret) < 16 {
+		err = invalidParameter("secret", "secret must be at least 16 characters")
+	}
+
+	if err != nil {
+		errorResponse(ctx, err)
+		return nil, nil, false
+	}
+	return &req, sub, true
+}
+
+func validateWebhookUrl(rawUrl string) *apierrors.Error {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || len(parsed.Host) == 0 {
+		return invalidParameter("url", "url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+func toWebhookResponse(sub *webhooks.Subscription) WebhookResponse {
+	events := []string{}
+	for _, event := range sub.Events {
+		events = append(events, string(event))
+	}
+	return WebhookResponse{
+		WebhookId: sub.SubscriptionId,
+		Url:       sub.Url,
+		Events:    events,
+		Active:    sub.Active,
+		CreatedAt: sub.CreatedAt,
+		UpdatedAt: sub.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(delivery *webhooks.Delivery) WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		DeliveryId:    delivery.Id,
+		EventId:       delivery.EventId,
+		EventType:     string(delivery.EventType),
+		OrderId:       delivery.OrderId,
+		Payload:       delivery.Payload,
+		Status:        string(delivery.Status),
+		Attempts:      delivery.Attempts,
+		LastError:     delivery.LastError,
+		ResponseCode:  delivery.ResponseCode,
+		NextAttemptAt: delivery.NextAttemptAt,
+		CreatedAt:     delivery.CreatedAt,
+	}
+}
+
+func webhookNotFoundResponse(ctx *gin.Context, webhookId string) {
+	errorResponse(ctx, apierrors.New(apierrors.CodeWebhookNotFound, "Webhook [%s] does not exist", webhookId).With("webhookId", webhookId))
+}
+
+func deliveryNotFoundResponse(ctx *gin.Context, deliveryId string) {
+	errorResponse(ctx, apierrors.New(apierrors.CodeWebhookDeliveryNotFound, "Delivery [%s] does not exist", deliveryId).With("deliveryId", deliveryId))
+}
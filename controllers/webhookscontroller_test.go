@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/idempotency"
+	"github.com/bdunton9323/blockchain-playground/webhooks"
+	"github.com/gin-gonic/gin"
+)
+
+// the key every test request is sent with
+var testAdminKey = "test-admin-key"
+
+// Enough of a webhook repository for the delivery endpoints
+type fakeWebhookRepository struct {
+	webhooks.WebhookRepository
+
+	mu            sync.Mutex
+	subscriptions map[string]*webhooks.Subscription
+	deliveries    map[int64]*webhooks.Delivery
+	retries       int
+}
+
+func (repo *fakeWebhookRepository) GetSubscription(subscriptionId string) (*webhooks.Subscription, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return repo.subscriptions[subscriptionId], nil
+}
+
+func (repo *fakeWebhookRepository) GetDelivery(id int64) (*webhooks.Delivery, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	delivery, found := repo.deliveries[id]
+	if !found {
+		return nil, nil
+	}
+	copied := *delivery
+	return &copied, nil
+}
+
+func (repo *fakeWebhookRepository) RetryDelivery(id int64) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.retries++
+	delivery := repo.deliveries[id]
+	if delivery == nil || delivery.Status != webhooks.DeliveryDead {
+		return false, nil
+	}
+	delivery.Status = webhooks.DeliveryPending
+	delivery.Attempts = 0
+	delivery.LastError = ""
+	delivery.NextAttemptAt = time.Now().UTC()
+	return true, nil
+}
+
+// Knows no API keys or sessions, so only the bootstrap key gets in
+type fakeAuthRepository struct {
+	auth.AuthRepository
+}
+
+func (repo *fakeAuthRepository) GetApiKeyByHash(keyHash string) (*auth.ApiKey, error) {
+	return nil, nil
+}
+
+func (repo *fakeAuthRepository) GetSession(tokenHash string) (*auth.Session, error) {
+	return nil, nil
+}
+
+// Keeps idempotency keys in memory
+type fakeKeyRepository struct {
+	mu   sync.Mutex
+	keys map[string]*idempotency.KeyRecord
+}
+
+func newFakeKeyRepository() *fakeKeyRepository {
+	return &fakeKeyRepository{keys: map[string]*idempotency.KeyRecord{}}
+}
+
+func (repo *fakeKeyRepository) Reserve(key string, fingerprint string, retention time.Duration) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, found := repo.keys[key]; found {
+		return false, nil
+	}
+	repo.keys[key] = &idempotency.KeyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		Status:      idempotency.KeyInProgress,
+		CreatedAt:   time.Now(),
+		ExpiresAt:   time.Now().Add(retention),
+	}
+	return true, nil
+}
+
+func (repo *fakeKeyRepository) Get(key string) (*idempotency.KeyRecord, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return repo.keys[key], nil
+}
+
+func (repo *fakeKeyRepository) Complete(key string, code int, contentType string, body []byte) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	record := repo.keys[key]
+	record.Status = idempotency.KeyCompleted
+	record.ResponseCode = code
+	record.ResponseContentType = contentType
+	record.ResponseBody = body
+	return nil
+}
+
+func (repo *fakeKeyRepository) Release(key string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	delete(repo.keys, key)
+	return nil
+}
+
+func (repo *fakeKeyRepository) DeleteExpired() (int64, error) {
+	return 0, nil
+}
+
+// Serves the API with a webhook subscription that has one dead delivery and one that was delivered
+func newWebhookTestServer(t *testing.T) (*httptest.Server, *fakeWebhookRepository) {
+	gin.SetMode(gin.TestMode)
+
+	repo := &fakeWebhookRepository{
+		subscriptions: map[string]*webhooks.Subscription{
+			"sub-1": {SubscriptionId: "sub-1", Url: "http://example.com/hook", Active: true},
+			"sub-2": {SubscriptionId: "sub-2", Url: "http://example.com/other", Active: true},
+		},
+		deliveries: map[int64]*webhooks.Delivery{
+			1: {
+				Id:             1,
+				SubscriptionId: "sub-1",
+				EventId:        "event-1",
+				EventType:      webhooks.EventOrderMinted,
+				OrderId:        "order-1",
+				Status:         webhooks.DeliveryDead,
+				Attempts:       12,
+				LastError:      "subscriber responded with 503",
+				ResponseCode:   503,
+			},
+			2: {
+				Id:             2,
+				SubscriptionId: "sub-1",
+				EventId:        "event-2",
+				EventType:      webhooks.EventOrderPaid,
+				OrderId:        "order-1",
+				Status:         webhooks.DeliveryDelivered,
+				Attempts:       1,
+				ResponseCode:   200,
+			},
+		},
+	}
+
+	router := &ApiRouter{
+		WebhookController: &WebhookController{WebhookRepository: repo},
+		Authenticator: &Authenticator{Verifier: &auth.Verifier{
+			Repository:        &fakeAuthRepository{},
+			BootstrapAdminKey: testAdminKey,
+		}},
+		Idempotency: &IdempotencyMiddleware{KeyRepository: newFakeKeyRepository(), Retention: time.Hour},
+	}
+	server := httptest.NewServer(router.Server().Handler)
+	t.Cleanup(server.Close)
+	return server, repo
+}
+
+func postRetry(t *testing.T, server *httptest.Server, path string, apiKey string, idempotencyKey string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, server.URL+path, strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apiKey) != 0 {
+		req.Header.Set(apiKeyHeader, apiKey)
+	}
+	if len(idempotencyKey) != 0 {
+		req.Header.Set(idempotencyKeyHeader, idempotencyKey)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decodeApiError(t *testing.T, resp *http.Response) ApiError {
+	var apiErr ApiError
+	if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+		t.Fatalf("could not decode the error response: %v", err)
+	}
+	return apiErr
+}
+
+func TestRetryWebhookDeliveryRequeuesDeadDelivery(t *testing.T) {
+	server, repo := newWebhookTestServer(t)
+
+	resp := postRetry(t, server, "/api/v1/webhooks/sub-1/deliveries/1/retry", testAdminKey, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var delivery WebhookDeliveryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&delivery); err != nil {
+		t.Fatal(err)
+	}
+	if delivery.DeliveryId != 1 || delivery.Status != string(webhooks.DeliveryPending) || delivery.Attempts != 0 {
+		t.Errorf("expected delivery 1 to be pending with no attempts, got %+v", delivery)
+	}
+
+	stored, _ := repo.GetDelivery(1)
+	if stored.Status != webhooks.DeliveryPending {
+		t.Errorf("expected the stored delivery to be pending, got %s", stored.Status)
+	}
+}
+
+func TestRetryWebhookDeliveryErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		apiKey string
+		status int
+		code   apierrors.Code
+	}{
+		{"without credentials", "/api/v1/webhooks/sub-1/deliveries/1/retry", "", 401, apierrors.CodeUnauthenticated},
+		{"with a bad key", "/api/v1/webhooks/sub-1/deliveries/1/retry", "wrong", 401, apierrors.CodeInvalidCredentials},
+		{"of a delivery that isn't dead", "/api/v1/webhooks/sub-1/deliveries/2/retry", testAdminKey, 409, apierrors.CodeWebhookDeliveryNotDead},
+		{"of an unknown delivery", "/api/v1/webhooks/sub-1/deliveries/99/retry", testAdminKey, 404, apierrors.CodeWebhookDeliveryNotFound},
+		{"of a malformed delivery ID", "/api/v1/webhooks/sub-1/deliveries/abc/retry", testAdminKey, 404, apierrors.CodeWebhookDeliveryNotFound},
+		{"through another subscription", "/api/v1/webhooks/sub-2/deliveries/1/retry", testAdminKey, 404, apierrors.CodeWebhookDeliveryNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, repo := newWebhookTestServer(t)
+
+			resp := postRetry(t, server, test.path, test.apiKey, "")
+			if resp.StatusCode != test.status {
+				t.Fatalf("expected %d, got %d", test.status, resp.StatusCode)
+			}
+			if apiErr := decodeApiError(t, resp); apiErr.Code != test.code {
+				t.Errorf("expected %s, got %s", test.code, apiErr.Code)
+			}
+
+			stored, _ := repo.GetDelivery(1)
+			if stored.Status != webhooks.DeliveryDead {
+				t.Errorf("expected delivery 1 to still be dead, got %s", stored.Status)
+			}
+		})
+	}
+}
+
+func TestRetryWebhookDeliveryIsIdempotent(t *testing.T) {
+	server, repo := newWebhookTestServer(t)
+
+	first := postRetry(t, server, "/api/v1/webhooks/sub-1/deliveries/1/retry", testAdminKey, "retry-1")
+	second := postRetry(t, server, "/api/v1/webhooks/sub-1/deliveries/1/retry", testAdminKey, "retry-1")
+	if first.StatusCode != http.StatusOK || second.StatusCode != http.StatusOK {
+		t.Fatalf("expected both attempts to get 200, got %d and %d", first.StatusCode, second.StatusCode)
+	}
+	if repo.retries != 1 {
+		t.Errorf("expected the delivery to be retried once, got %d", repo.retries)
+	}
+
+	// without the key, a second retry finds the delivery already back in the queue
+	third := postRetry(t, server, "/api/v1/webhooks/sub-1/deliveries/1/retry", testAdminKey, "")
+	if third.StatusCode != http.StatusConflict {
+		t.Errorf("expected 409, got %d", third.StatusCode)
+	}
+}
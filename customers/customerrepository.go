@@ -0,0 +1,407 @@
+package customers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+)
+
+// A DTO object representing a row in the database. A customer's account, which their orders can be placed against.
+type Customer struct {
+	CustomerId string
+	Name       string
+	// empty if the customer didn't give one
+	Email string
+	// where the customer's packages go. Empty if they haven't given one.
+	ShippingAddress ShippingAddress
+
+	// Only custodial customers, whose keys the service holds, have these. The rest are empty.
+	//
+	// the customer's deposit address, as a checksummed hex string. Their orders are delivered to it.
+	Address string
+	// where the address's key is in the HD wallet, e.g. m/44'/60'/0'/0/7
+	DerivationPath string
+	// the last part of the derivation path. Every custodial customer has their own.
+	AddressIndex uint32
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Whether the service holds the customer's keys
+func (customer *Customer) Custodial() bool {
+	return len(customer.DerivationPath) != 0
+}
+
+// A postal address. Every field is empty if the customer hasn't given one.
+type ShippingAddress struct {
+	// who the package is for, if not the customer themselves
+	Name       string
+	Line1      string
+	Line2      string
+	City       string
+	Region     string
+	PostalCode string
+	// the ISO 3166-1 alpha-2 code, e.g. GB
+	Country string
+}
+
+// Whether any of the address has been filled in
+func (address *ShippingAddress) IsEmpty() bool {
+	return *address == ShippingAddress{}
+}
+
+// A DTO object representing a row in the customer_addresses table. One of the ethereum addresses in a customer's
+// address book. Orders can only be delivered to the ones the customer has proven they hold the key to.
+type WalletAddress struct {
+	CustomerId string
+	// checksummed hex
+	Address string
+	// what the customer calls it, e.g. "hardware wallet"
+	Label string
+	// the message the customer has to sign to verify the address. Empty once it is verified.
+	Challenge          string
+	ChallengeExpiresAt time.Time
+	// nil until the customer proves they hold the key
+	VerifiedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// Whether the customer has proven they hold the address's key
+func (address *WalletAddress) Verified() bool {
+	return address.VerifiedAt != nil
+}
+
+type CustomerRepository interface {
+	// Writes the customer. A custodial customer's deposit address goes into their address book as verified.
+	// Returns ErrAddressIndexTaken if another customer already has the address index.
+	CreateCustomer(customer *Customer) error
+	// Changes the customer's name, email and shipping address. Returns false if the customer doesn't exist.
+	UpdateCustomer(customer *Customer) (bool, error)
+	// Returns nil if the customer doesn't exist
+	GetCustomer(customerId string) (*Customer, error)
+	// Returns the custodial customer with the deposit address, or nil if there isn't one
+	GetCustomerByAddress(address string) (*Customer, error)
+	// Returns every customer, oldest first
+	ListCustomers() ([]*Customer, error)
+	// The address index after the highest one in use, i.e. the one the next custodial customer should get
+	NextAddressIndex() (uint32, error)
+
+	// Adds the address to the customer's address book, or replaces it if it is already there
+	SaveAddress(address *WalletAddress) error
+	// Returns nil if the address isn't in the customer's address book
+	GetAddress(customerId string, address string) (*WalletAddress, error)
+	// Returns the customer's address book, in the order the addresses were added
+	ListAddresses(customerId string) ([]*WalletAddress, error)
+	// Marks the address as verified. Returns ErrAddressClaimed if another customer has already verified it.
+	VerifyAddress(customerId string, address string, verifiedAt time.Time) error
+	// Takes the address out of the customer's address book. Returns false if it wasn't in it.
+	RemoveAddress(customerId string, address string) (bool, error)
+}
+
+// Returned when two customers are created at once and end up with the same address index
+var ErrAddressIndexTaken = errors.New("the address index is already taken")
+
+// Returned when a customer verifies an address that another customer already has
+var ErrAddressClaimed = errors.New("another customer has already verified the address")
+
+type MariaDBCustomerRepository struct {
+	CustomerRepository
+
+	conn *sql.DB
+}
+
+var customersTable = "customers"
+var addressesTable = "customer_addresses"
+var allFields = "customer_id, name, email, " +
+	"ship_to_name, ship_to_line1, ship_to_line2, ship_to_city, ship_to_region, ship_to_postal_code, ship_to_country, " +
+	"address, derivation_path, address_index, created_at, updated_at"
+var addressFields = "customer_id, address, label, challenge, challenge_expires_at, verified_at, created_at"
+
+// the MySQL error number for a duplicate key
+var duplicateEntryError uint16 = 1062
+
+// Construct a new repository that uses the given connection pool to MariaDB
+func NewMariaDBCustomerRepository(db *sql.DB) *MariaDBCustomerRepository {
+	return &MariaDBCustomerRepository{conn: db}
+}
+
+// Writes the given customer to the database, along with their deposit address if they are custodial
+func (repo *MariaDBCustomerRepository) CreateCustomer(customer *Customer) error {
+	if customer.CreatedAt.IsZero() {
+		customer.CreatedAt = time.Now().UTC()
+	}
+	customer.UpdatedAt = customer.CreatedAt
+
+	tx, err := repo.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf("insert into %s (%s) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", customersTable, allFields)
+	log.Debugf("running query [%s]", query)
+	ship := customer.ShippingAddress
+	_, err = tx.Exec(query,
+		customer.CustomerId,
+		customer.Name,
+		customer.Email,
+		ship.Name, ship.Line1, ship.Line2, ship.City, ship.Region, ship.PostalCode, ship.Country,
+		nullIfEmpty(customer.Address),
+		nullIfEmpty(customer.DerivationPath),
+		sql.NullInt64{Int64: int64(customer.AddressIndex), Valid: customer.Custodial()},
+		customer.CreatedAt,
+		customer.UpdatedAt)
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == duplicateEntryError {
+		return ErrAddressIndexTaken
+	} else if err != nil {
+		return err
+	}
+
+	if customer.Custodial() {
+		// the service holds the key, so there is nothing to prove
+		query = fmt.Sprintf("insert into %s (%s) values (?, ?, ?, null, null, ?, ?)", addressesTable, addressFields)
+		log.Debugf("running query [%s]", query)
+		_, err = tx.Exec(query, customer.CustomerId, customer.Address, "deposit", customer.CreatedAt, customer.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Changes the customer's profile. The deposit address of a custodial customer never changes.
+func (repo *MariaDBCustomerRepository) UpdateCustomer(customer *Customer) (bool, error) {
+	customer.UpdatedAt = time.Now().UTC()
+
+	query := fmt.Sprintf("update %s set name = ?, email = ?, ship_to_name = ?, ship_to_line1 = ?, ship_to_line2 = ?, "+
+		"ship_to_city = ?, ship_to_region = ?, ship_to_postal_code = ?, ship_to_country = ?, updated_at = ? "+
+		"where customer_id = ?", customersTable)
+	log.Debugf("running query [%s]", query)
+	ship := customer.ShippingAddress
+	result, err := repo.conn.Exec(query,
+		customer.Name,
+		customer.Email,
+		ship.Name, ship.Line1, ship.Line2, ship.City, ship.Region, ship.PostalCode, ship.Country,
+		customer.UpdatedAt,
+		customer.CustomerId)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// Returns the customer with the given ID. If not found, then nil.
+func (repo *MariaDBCustomerRepository) GetCustomer(customerId string) (*Customer, error) {
+	return repo.getOne(fmt.Sprintf("select %s from %s where customer_id = ?", allFields, customersTable), customerId)
+}
+
+// Returns the custodial customer with the given deposit address. If not found, then nil.
+func (repo *MariaDBCustomerRepository) GetCustomerByAddress(address string) (*Customer, error) {
+	return repo.getOne(fmt.Sprintf("select %s from %s where address = ?", allFields, customersTable), address)
+}
+
+// Returns every customer, in the order they were created
+func (repo *MariaDBCustomerRepository) ListCustomers() ([]*Customer, error) {
+	query := fmt.Sprintf("select %s from %s order by created_at, customer_id", allFields, customersTable)
+	log.Debugf("running query [%s]", query)
+
+	results, err := repo.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	customers := []*Customer{}
+	for results.Next() {
+		customer, err := scanCustomer(results)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, customer)
+	}
+	return customers, results.Err()
+}
+
+// Returns one more than the highest address index in use, or 0 if there are no custodial customers yet
+func (repo *MariaDBCustomerRepository) NextAddressIndex() (uint32, error) {
+	query := fmt.Sprintf("select coalesce(max(address_index) + 1, 0) from %s", customersTable)
+
+	var index uint32
+	err := repo.conn.QueryRow(query).Scan(&index)
+	return index, err
+}
+
+// Writes the address to the customer's address book, replacing what was there before
+func (repo *MariaDBCustomerRepository) SaveAddress(address *WalletAddress) error {
+	if address.CreatedAt.IsZero() {
+		address.CreatedAt = time.Now().UTC()
+	}
+
+	query := fmt.Sprintf("insert into %s (%s) values (?, ?, ?, ?, ?, ?, ?) "+
+		"on duplicate key update label = values(label), challenge = values(challenge), "+
+		"challenge_expires_at = values(challenge_expires_at), verified_at = values(verified_at)",
+		addressesTable, addressFields)
+	log.Debugf("running query [%s]", query)
+
+	var verifiedAt sql.NullTime
+	if address.VerifiedAt != nil {
+		verifiedAt = sql.NullTime{Time: *address.VerifiedAt, Valid: true}
+	}
+	var expiresAt sql.NullTime
+	if !address.ChallengeExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: address.ChallengeExpiresAt, Valid: true}
+	}
+	_, err := repo.conn.Exec(query,
+		address.CustomerId,
+		address.Address,
+		address.Label,
+		nullIfEmpty(address.Challenge),
+		expiresAt,
+		verifiedAt,
+		address.CreatedAt)
+	return err
+}
+
+// Returns the address from the customer's address book. If it isn't there, then nil.
+func (repo *MariaDBCustomerRepository) GetAddress(customerId string, address string) (*WalletAddress, error) {
+	addresses, err := repo.queryAddresses(
+		fmt.Sprintf("select %s from %s where customer_id = ? and address = ?", addressFields, addressesTable),
+		customerId, address)
+	if err != nil || len(addresses) == 0 {
+		return nil, err
+	}
+	return addresses[0], nil
+}
+
+// Returns the customer's address book, oldest first
+func (repo *MariaDBCustomerRepository) ListAddresses(customerId string) ([]*WalletAddress, error) {
+	return repo.queryAddresses(
+		fmt.Sprintf("select %s from %s where customer_id = ? order by created_at, address", addressFields, addressesTable),
+		customerId)
+}
+
+// Marks the address as verified, as long as no other customer has verified it. Whoever gets there first keeps it.
+func (repo *MariaDBCustomerRepository) VerifyAddress(customerId string, address string, verifiedAt time.Time) error {
+	tx, err := repo.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// locks every customer's entry for the address, so two customers can't verify it at once
+	query := fmt.Sprintf("select count(*) from %s where address = ? and customer_id <> ? and verified_at is not null for update",
+		addressesTable)
+	log.Debugf("running query [%s]", query)
+	var claimed int
+	if err = tx.QueryRow(query, address, customerId).Scan(&claimed); err != nil {
+		return err
+	} else if claimed != 0 {
+		return ErrAddressClaimed
+	}
+
+	query = fmt.Sprintf("update %s set verified_at = ?, challenge = null, challenge_expires_at = null "+
+		"where customer_id = ? and address = ?", addressesTable)
+	log.Debugf("running query [%s]", query)
+	if _, err = tx.Exec(query, verifiedAt, customerId, address); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Deletes the address from the customer's address book
+func (repo *MariaDBCustomerRepository) RemoveAddress(customerId string, address string) (bool, error) {
+	query := fmt.Sprintf("delete from %s where customer_id = ? and address = ?", addressesTable)
+	log.Debugf("running query [%s]", query)
+	result, err := repo.conn.Exec(query, customerId, address)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+func (repo *MariaDBCustomerRepository) getOne(query string, args ...interface{}) (*Customer, error) {
+	log.Debugf("running query [%s]", query)
+	results, err := repo.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	if !results.Next() {
+		return nil, nil
+	}
+	return scanCustomer(results)
+}
+
+func (repo *MariaDBCustomerRepository) queryAddresses(query string, args ...interface{}) ([]*WalletAddress, error) {
+	log.Debugf("running query [%s]", query)
+	results, err := repo.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	addresses := []*WalletAddress{}
+	for results.Next() {
+		var address WalletAddress
+		var challenge sql.NullString
+		var expiresAt, verifiedAt sql.NullTime
+		err = results.Scan(
+			&address.CustomerId,
+			&address.Address,
+			&address.Label,
+			&challenge,
+			&expiresAt,
+			&verifiedAt,
+			&address.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		address.Challenge = challenge.String
+		address.ChallengeExpiresAt = expiresAt.Time
+		if verifiedAt.Valid {
+			address.VerifiedAt = &verifiedAt.Time
+		}
+		addresses = append(addresses, &address)
+	}
+	return addresses, results.Err()
+}
+
+func scanCustomer(results *sql.Rows) (*Customer, error) {
+	var customer Customer
+	var address, derivationPath sql.NullString
+	var addressIndex sql.NullInt64
+	ship := &customer.ShippingAddress
+	err := results.Scan(
+		&customer.CustomerId,
+		&customer.Name,
+		&customer.Email,
+		&ship.Name, &ship.Line1, &ship.Line2, &ship.City, &ship.Region, &ship.PostalCode, &ship.Country,
+		&address,
+		&derivationPath,
+		&addressIndex,
+		&customer.CreatedAt,
+		&customer.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	customer.Address = address.String
+	customer.DerivationPath = derivationPath.String
+	customer.AddressIndex = uint32(addressIndex.Int64)
+	return &customer, nil
+}
+
+// Stores empty strings as null, so they don't collide in unique indexes
+func nullIfEmpty(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: len(value) != 0}
+}
@@ -0,0 +1,449 @@
+package customers
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/validation"
+	"github.com/bdunton9323/blockchain-playground/wallet"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Returned when a custodial customer is registered but the service has no HD wallet to derive their address from
+var ErrCustodyDisabled = errors.New("custodial customers are off because no HD wallet seed is configured")
+
+// how many times to look for a free address index when customers are registered at the same time
+var maxRegisterAttempts = 5
+
+// the longest values the database holds
+var maxNameLength = 128
+var maxEmailLength = 256
+var maxLabelLength = 64
+
+var countryPattern = regexp.MustCompile("^[A-Z]{2}$")
+
+// What a customer's account says about them
+type CustomerInput struct {
+	Name  string
+	Email string
+	// leave it empty if the customer hasn't given one
+	ShippingAddress ShippingAddress
+	// give the customer a deposit address from the service's HD wallet and sign for them. Only when creating.
+	Custodial bool
+}
+
+// Looks after customers' accounts and their address books. Customers can bring their own wallets, whose
+// addresses they verify by signing a challenge, or be custodial, in which case they get their own deposit
+// address from the service's HD wallet and the service signs for them.
+//
+// Errors the caller can do something about are *apierrors.Error; anything else is internal.
+type Registry struct {
+	repository CustomerRepository
+	// nil if custody is off. Existing customers can still be looked up, but nobody can sign for them.
+	wallet *wallet.HDWallet
+
+	// named in the challenges customers sign, so a signature can't be replayed against another service
+	Domain string
+	// how long a customer has to sign the challenge for an address they add
+	ChallengeTtl time.Duration
+}
+
+// Constructs a new registry with reasonable defaults. hdWallet can be nil, which turns custody off.
+func NewRegistry(repository CustomerRepository, hdWallet *wallet.HDWallet) *Registry {
+	return &Registry{
+		repository:   repository,
+		wallet:       hdWallet,
+		Domain:       "localhost:8080",
+		ChallengeTtl: 15 * time.Minute,
+	}
+}
+
+// Whether the service holds the seed, so it can register custodial customers and sign for them
+func (_reg *Registry) Enabled() bool {
+	return _reg.wallet != nil
+}
+
+// Opens an account for a customer. Custodial customers get the next free address in the wallet.
+func (_reg *Registry) CreateCustomer(input *CustomerInput) (*Customer, error) {
+	if err := validateCustomer(input); err != nil {
+		return nil, err
+	}
+
+	customer := &Customer{
+		Name:            input.Name,
+		Email:           input.Email,
+		ShippingAddress: input.ShippingAddress,
+	}
+	if !input.Custodial {
+		customer.CustomerId = uuid.New().String()
+		if err := _reg.repository.CreateCustomer(customer); err != nil {
+			return nil, err
+		}
+		log.Infof("Opened an account for customer [%s]", customer.CustomerId)
+		return customer, nil
+	}
+
+	if _reg.wallet == nil {
+		return nil, apierrors.Wrap(ErrCustodyDisabled, apierrors.CodeCustodyNotConfigured,
+			"Custodial customers are off: no HD wallet seed is configured")
+	}
+
+	for attempt := 0; attempt < maxRegisterAttempts; attempt++ {
+		index, err := _reg.repository.NextAddressIndex()
+		if err != nil {
+			return nil, err
+		}
+
+		path := wallet.AddressPath(index)
+		address, err := _reg.wallet.Address(path)
+		if err != nil {
+			return nil, err
+		}
+
+		customer.CustomerId = uuid.New().String()
+		customer.Address = address.Hex()
+		customer.DerivationPath = path.String()
+		customer.AddressIndex = index
+		err = _reg.repository.CreateCustomer(customer)
+		if errors.Is(err, ErrAddressIndexTaken) {
+			// somebody else registered a customer in the meantime
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		log.Infof("Registered customer [%s] with deposit address [%s] at %s", customer.CustomerId, customer.Address, customer.DerivationPath)
+		return customer, nil
+	}
+	return nil, errors.New(fmt.Sprintf("could not find a free address index after %d attempts", maxRegisterAttempts))
+}
+
+// Changes the customer's name, email and shipping address. Whether they are custodial can't be changed.
+func (_reg *Registry) UpdateCustomer(customerId string, input *CustomerInput) (*Customer, error) {
+	if err := validateCustomer(input); err != nil {
+		return nil, err
+	}
+
+	customer, err := _reg.GetCustomer(customerId)
+	if err != nil {
+		return nil, err
+	}
+	customer.Name = input.Name
+	customer.Email = input.Email
+	customer.ShippingAddress = input.ShippingAddress
+
+	updated, err := _reg.repository.UpdateCustomer(customer)
+	if err != nil {
+		return nil, err
+	} else if !updated {
+		return nil, customerNotFound(customerId)
+	}
+	return customer, nil
+}
+
+// Returns the customer with the given ID
+func (_reg *Registry) GetCustomer(customerId string) (*Customer, error) {
+	customer, err := _reg.repository.GetCustomer(customerId)
+	if err != nil {
+		return nil, err
+	} else if customer == nil {
+		return nil, customerNotFound(customerId)
+	}
+	return customer, nil
+}
+
+// Returns every customer, oldest first
+func (_reg *Registry) ListCustomers() ([]*Customer, error) {
+	return _reg.repository.ListCustomers()
+}
+
+// Returns the customer's address book, oldest first
+func (_reg *Registry) ListAddresses(customerId string) ([]*WalletAddress, error) {
+	if _, err := _reg.GetCustomer(customerId); err != nil {
+		return nil, err
+	}
+	return _reg.repository.ListAddresses(customerId)
+}
+
+// Adds an address to the customer's address book. It can't be delivered to until the customer signs the
+// challenge that comes back with it, using the address's key. Adding an address again gives it a new challenge.
+func (_reg *Registry) AddAddress(customerId string, address string, label string) (*WalletAddress, error) {
+	v := &validation.Validator{}
+	address = v.Address("address", address)
+	if len(label) > maxLabelLength {
+		v.Add("label", apierrors.CodeInvalidParameter, "label can be at most %d characters", maxLabelLength)
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := _reg.GetCustomer(customerId); err != nil {
+		return nil, err
+	}
+
+	existing, err := _reg.repository.GetAddress(customerId, address)
+	if err != nil {
+		return nil, err
+	} else if existing != nil && existing.Verified() {
+		// there is nothing left to prove, but the label can still change
+		existing.Label = label
+		return existing, _reg.repository.SaveAddress(existing)
+	}
+
+	nonce, err := auth.NewSecret(16)
+	if err != nil {
+		return nil, err
+	}
+	entry := &WalletAddress{
+		CustomerId:         customerId,
+		Address:            address,
+		Label:              label,
+		ChallengeExpiresAt: time.Now().Add(_reg.ChallengeTtl).UTC().Truncate(time.Millisecond),
+	}
+	if existing != nil {
+		entry.CreatedAt = existing.CreatedAt
+	}
+	entry.Challenge = fmt.Sprintf(
+		"%s asks you to prove that you hold this address, to add it to customer account %s:\n%s\n\nNonce: %s\nExpires: %s",
+		_reg.Domain, customerId, address, nonce, entry.ChallengeExpiresAt.Format(time.RFC3339))
+
+	if err = _reg.repository.SaveAddress(entry); err != nil {
+		return nil, err
+	}
+	log.Infof("Added address [%s] to customer [%s], waiting for it to be verified", address, customerId)
+	return entry, nil
+}
+
+// Checks the customer's signature over the address's challenge, and marks the address as verified if it was
+// made with the address's key. The signature is what personal_sign produces.
+func (_reg *Registry) VerifyAddress(customerId string, address string, signature string) (*WalletAddress, error) {
+	v := &validation.Validator{}
+	address = v.Address("address", address)
+	v.Required("signature", signature)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	entry, err := _reg.getAddress(customerId, address)
+	if err != nil {
+		return nil, err
+	} else if entry.Verified() {
+		return entry, nil
+	} else if time.Now().After(entry.ChallengeExpiresAt) {
+		return nil, apierrors.New(apierrors.CodeChallengeExpired,
+			"The challenge for address [%s] has expired; add the address again to get a new one", address).
+			With("address", address)
+	}
+
+	if err = auth.VerifySiweSignature(entry.Challenge, address, signature); err != nil {
+		return nil, apierrors.Wrap(err, apierrors.CodeInvalidSignature, "The signature doesn't verify: %s", err.Error()).
+			With("fields", []string{"signature"})
+	}
+
+	verifiedAt := time.Now().UTC()
+	err = _reg.repository.VerifyAddress(customerId, address, verifiedAt)
+	if errors.Is(err, ErrAddressClaimed) {
+		return nil, apierrors.Wrap(err, apierrors.CodeAddressAlreadyClaimed,
+			"Address [%s] belongs to another customer", address).With("address", address)
+	} else if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Customer [%s] verified address [%s]", customerId, address)
+	entry.VerifiedAt = &verifiedAt
+	entry.Challenge = ""
+	entry.ChallengeExpiresAt = time.Time{}
+	return entry, nil
+}
+
+// Takes an address out of the customer's address book. Orders already placed for it aren't affected.
+func (_reg *Registry) RemoveAddress(customerId string, address string) error {
+	v := &validation.Validator{}
+	address = v.Address("address", address)
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	customer, err := _reg.GetCustomer(customerId)
+	if err != nil {
+		return err
+	} else if customer.Address == address {
+		return apierrors.New(apierrors.CodeInvalidParameter, "A custodial customer's deposit address can't be removed").
+			With("fields", []string{"address"})
+	}
+
+	removed, err := _reg.repository.RemoveAddress(customerId, address)
+	if err != nil {
+		return err
+	} else if !removed {
+		return addressNotFound(customerId, address)
+	}
+	return nil
+}
+
+// Works out where to deliver an order for the customer. requested picks one of their verified addresses; if
+// it is empty, custodial customers get their deposit address and everyone else the first address they verified.
+func (_reg *Registry) ResolveRecipient(customerId string, requested string) (string, error) {
+	customer, err := _reg.GetCustomer(customerId)
+	if err != nil {
+		return "", err
+	}
+
+	if len(requested) != 0 {
+		entry, err := _reg.getAddress(customerId, requested)
+		if err != nil {
+			return "", err
+		} else if !entry.Verified() {
+			return "", apierrors.New(apierrors.CodeAddressNotVerified,
+				"Customer ID [%s] hasn't verified address [%s]", customerId, requested).
+				With("address", requested)
+		}
+		return entry.Address, nil
+	}
+
+	if customer.Custodial() {
+		return customer.Address, nil
+	}
+	verified, err := _reg.VerifiedAddresses(customerId)
+	if err != nil {
+		return "", err
+	} else if len(verified) == 0 {
+		return "", apierrors.New(apierrors.CodeAddressNotVerified,
+			"Customer ID [%s] has no verified addresses to deliver to", customerId).
+			With("customerId", customerId)
+	}
+	return verified[0], nil
+}
+
+// The addresses the customer has proven they hold, oldest first
+func (_reg *Registry) VerifiedAddresses(customerId string) ([]string, error) {
+	addresses, err := _reg.repository.ListAddresses(customerId)
+	if err != nil {
+		return nil, err
+	}
+
+	verified := []string{}
+	for _, address := range addresses {
+		if address.Verified() {
+			verified = append(verified, address.Address)
+		}
+	}
+	return verified, nil
+}
+
+// Derives the private key of the custodial customer with the address, as hex. Returns false if the address
+// doesn't belong to a custodial customer, or custody is off.
+func (_reg *Registry) PrivateKeyFor(address string) (string, bool, error) {
+	if _reg.wallet == nil {
+		return "", false, nil
+	}
+
+	customer, err := _reg.repository.GetCustomerByAddress(address)
+	if err != nil || customer == nil {
+		return "", false, err
+	}
+
+	path, err := accounts.ParseDerivationPath(customer.DerivationPath)
+	if err != nil {
+		return "", false, errors.New(fmt.Sprintf("customer [%s] has a bad derivation path: %v", customer.CustomerId, err))
+	}
+
+	// a different seed would derive a different key, and sign for an address nobody has
+	derived, err := _reg.wallet.Address(path)
+	if err != nil {
+		return "", false, err
+	} else if derived.Hex() != customer.Address {
+		return "", false, errors.New(fmt.Sprintf(
+			"customer [%s] is at [%s] but the seed derives [%s] there; is it the right seed?",
+			customer.CustomerId, customer.Address, derived.Hex()))
+	}
+
+	key, err := _reg.wallet.PrivateKeyHex(path)
+	if err != nil {
+		return "", false, err
+	}
+	return key, true, nil
+}
+
+// Looks up an address in the customer's address book, which has to exist
+func (_reg *Registry) getAddress(customerId string, address string) (*WalletAddress, error) {
+	if _, err := _reg.GetCustomer(customerId); err != nil {
+		return nil, err
+	}
+
+	// addresses are stored checksummed
+	parsed, err := validation.ParseAddress(address)
+	if err != nil {
+		return nil, addressNotFound(customerId, address)
+	}
+	entry, err := _reg.repository.GetAddress(customerId, parsed.Hex())
+	if err != nil {
+		return nil, err
+	} else if entry == nil {
+		return nil, addressNotFound(customerId, address)
+	}
+	return entry, nil
+}
+
+// Checks everything about a customer's profile at once
+func validateCustomer(input *CustomerInput) error {
+	v := &validation.Validator{}
+	if v.Required("name", input.Name) && len(input.Name) > maxNameLength {
+		v.Add("name", apierrors.CodeInvalidParameter, "name can be at most %d characters", maxNameLength)
+	}
+	if len(input.Email) > maxEmailLength {
+		v.Add("email", apierrors.CodeInvalidParameter, "email can be at most %d characters", maxEmailLength)
+	} else if len(input.Email) != 0 {
+		if parsed, err := mail.ParseAddress(input.Email); err != nil || parsed.Address != input.Email {
+			v.Add("email", apierrors.CodeInvalidParameter, "email must be a bare email address, like alice@example.com")
+		}
+	}
+
+	ship := &input.ShippingAddress
+	ship.Country = strings.ToUpper(ship.Country)
+	if !ship.IsEmpty() {
+		v.Required("shippingAddress.line1", ship.Line1)
+		v.Required("shippingAddress.city", ship.City)
+		v.Required("shippingAddress.postalCode", ship.PostalCode)
+		if v.Required("shippingAddress.country", ship.Country) && !countryPattern.MatchString(ship.Country) {
+			v.Add("shippingAddress.country", apierrors.CodeInvalidParameter,
+				"shippingAddress.country must be a two letter ISO 3166-1 code, like GB")
+		}
+		for _, field := range []struct {
+			name  string
+			value string
+			max   int
+		}{
+			{"shippingAddress.name", ship.Name, 128},
+			{"shippingAddress.line1", ship.Line1, 256},
+			{"shippingAddress.line2", ship.Line2, 256},
+			{"shippingAddress.city", ship.City, 128},
+			{"shippingAddress.region", ship.Region, 128},
+			{"shippingAddress.postalCode", ship.PostalCode, 32},
+		} {
+			if len(field.value) > field.max {
+				v.Add(field.name, apierrors.CodeInvalidParameter, "%s can be at most %d characters", field.name, field.max)
+			}
+		}
+	}
+	return v.Err()
+}
+
+func customerNotFound(customerId string) *apierrors.Error {
+	return apierrors.New(apierrors.CodeCustomerNotFound, "Customer ID [%s] does not exist", customerId).
+		With("customerId", customerId)
+}
+
+func addressNotFound(customerId string, address string) *apierrors.Error {
+	return apierrors.New(apierrors.CodeCustomerAddressNotFound,
+		"Address [%s] isn't in the address book of customer ID [%s]", address, customerId).
+		With("address", address)
+}
@@ -0,0 +1,313 @@
+package customers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// well-known development keys and the addresses they control
+var aliceKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+var aliceAddress = "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+var bobKey = "59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+var bobAddress = "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+
+// Keeps customers and their address books in memory. Only what the registry's address book needs is implemented.
+type fakeCustomerRepository struct {
+	CustomerRepository
+
+	customers map[string]*Customer
+	// customer ID, then address
+	addresses map[string]map[string]*WalletAddress
+	// customer ID, then the addresses in the order they were added
+	added map[string][]string
+}
+
+func newFakeCustomerRepository(customerIds ...string) *fakeCustomerRepository {
+	repo := &fakeCustomerRepository{
+		customers: map[string]*Customer{},
+		addresses: map[string]map[string]*WalletAddress{},
+		added:     map[string][]string{},
+	}
+	for _, customerId := range customerIds {
+		repo.customers[customerId] = &Customer{CustomerId: customerId, Name: customerId}
+		repo.addresses[customerId] = map[string]*WalletAddress{}
+	}
+	return repo
+}
+
+func (repo *fakeCustomerRepository) GetCustomer(customerId string) (*Customer, error) {
+	customer, found := repo.customers[customerId]
+	if !found {
+		return nil, nil
+	}
+	copied := *customer
+	return &copied, nil
+}
+
+func (repo *fakeCustomerRepository) SaveAddress(address *WalletAddress) error {
+	if _, found := repo.addresses[address.CustomerId][address.Address]; !found {
+		repo.added[address.CustomerId] = append(repo.added[address.CustomerId], address.Address)
+	}
+	copied := *address
+	repo.addresses[address.CustomerId][address.Address] = &copied
+	return nil
+}
+
+func (repo *fakeCustomerRepository) GetAddress(customerId string, address string) (*WalletAddress, error) {
+	entry, found := repo.addresses[customerId][address]
+	if !found {
+		return nil, nil
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+func (repo *fakeCustomerRepository) ListAddresses(customerId string) ([]*WalletAddress, error) {
+	addresses := []*WalletAddress{}
+	for _, address := range repo.added[customerId] {
+		copied := *repo.addresses[customerId][address]
+		addresses = append(addresses, &copied)
+	}
+	return addresses, nil
+}
+
+func (repo *fakeCustomerRepository) VerifyAddress(customerId string, address string, verifiedAt time.Time) error {
+	for otherId, book := range repo.addresses {
+		if entry, found := book[address]; found && otherId != customerId && entry.Verified() {
+			return ErrAddressClaimed
+		}
+	}
+	entry := repo.addresses[customerId][address]
+	entry.VerifiedAt = &verifiedAt
+	entry.Challenge = ""
+	entry.ChallengeExpiresAt = time.Time{}
+	return nil
+}
+
+// Signs the message the way personal_sign does
+func personalSign(t *testing.T, keyHex string, message string) string {
+	key, err := crypto.HexToECDSA(keyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(signature)
+}
+
+// Adds the address to the customer's address book and signs its challenge with the key
+func addAndVerify(t *testing.T, registry *Registry, customerId string, address string, keyHex string) (*WalletAddress, error) {
+	added, err := registry.AddAddress(customerId, address, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry.VerifyAddress(customerId, address, personalSign(t, keyHex, added.Challenge))
+}
+
+// Fails the test unless err is an API error with the code
+func expectCode(t *testing.T, err error, code apierrors.Code) *apierrors.Error {
+	t.Helper()
+	var apiErr *apierrors.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != code {
+		t.Fatalf("expected %s, got %v", code, err)
+	}
+	return apiErr
+}
+
+func TestAddAndVerifyAddress(t *testing.T) {
+	repo := newFakeCustomerRepository("alice")
+	registry := NewRegistry(repo, nil)
+	registry.Domain = "shop.example.com"
+
+	// lower case is fine, but it is stored checksummed
+	added, err := registry.AddAddress("alice", "0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266", "laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.Address != aliceAddress || added.Verified() || len(added.Challenge) == 0 {
+		t.Errorf("expected an unverified address with a challenge, got %+v", added)
+	}
+	if expiresIn := time.Until(added.ChallengeExpiresAt); expiresIn <= 14*time.Minute || expiresIn > 15*time.Minute {
+		t.Errorf("expected the challenge to last 15 minutes, got %s", expiresIn)
+	}
+
+	verified, err := registry.VerifyAddress("alice", aliceAddress, personalSign(t, aliceKey, added.Challenge))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verified.Verified() || len(verified.Challenge) != 0 {
+		t.Errorf("expected the address to be verified, got %+v", verified)
+	}
+	if stored, _ := repo.GetAddress("alice", aliceAddress); !stored.Verified() {
+		t.Errorf("expected the verification to be saved, got %+v", stored)
+	}
+
+	// verifying it again does no harm
+	if again, err := registry.VerifyAddress("alice", aliceAddress, "0x00"); err != nil || !again.Verified() {
+		t.Errorf("expected the address to stay verified, got %+v, %v", again, err)
+	}
+}
+
+func TestVerifyAddressWithAnExpiredChallenge(t *testing.T) {
+	repo := newFakeCustomerRepository("alice")
+	registry := NewRegistry(repo, nil)
+	registry.ChallengeTtl = -time.Minute
+
+	_, err := addAndVerify(t, registry, "alice", aliceAddress, aliceKey)
+	apiErr := expectCode(t, err, apierrors.CodeChallengeExpired)
+	if apiErr.Details["address"] != aliceAddress {
+		t.Errorf("expected the details to name the address, got %v", apiErr.Details)
+	}
+	if stored, _ := repo.GetAddress("alice", aliceAddress); stored.Verified() {
+		t.Error("expected the address to stay unverified")
+	}
+
+	// adding it again gives a new challenge
+	registry.ChallengeTtl = time.Minute
+	if verified, err := addAndVerify(t, registry, "alice", aliceAddress, aliceKey); err != nil || !verified.Verified() {
+		t.Errorf("expected a new challenge to verify the address, got %+v, %v", verified, err)
+	}
+}
+
+func TestVerifyAddressWithABadSignature(t *testing.T) {
+	tests := []struct {
+		name string
+		// signs the challenge
+		sign func(t *testing.T, challenge string) string
+		code apierrors.Code
+	}{
+		{"from the wrong key", func(t *testing.T, challenge string) string {
+			return personalSign(t, bobKey, challenge)
+		}, apierrors.CodeInvalidSignature},
+		{"over a different message", func(t *testing.T, challenge string) string {
+			return personalSign(t, aliceKey, challenge+" ")
+		}, apierrors.CodeInvalidSignature},
+		{"that is malformed", func(t *testing.T, challenge string) string {
+			return "0x1234"
+		}, apierrors.CodeInvalidSignature},
+		{"that is missing", func(t *testing.T, challenge string) string {
+			return ""
+		}, apierrors.CodeMissingParameter},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newFakeCustomerRepository("alice")
+			registry := NewRegistry(repo, nil)
+			added, err := registry.AddAddress("alice", aliceAddress, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = registry.VerifyAddress("alice", aliceAddress, test.sign(t, added.Challenge))
+			expectCode(t, err, test.code)
+			if stored, _ := repo.GetAddress("alice", aliceAddress); stored.Verified() {
+				t.Error("expected the address to stay unverified")
+			}
+		})
+	}
+}
+
+func TestReAddingAVerifiedAddressKeepsItVerified(t *testing.T) {
+	repo := newFakeCustomerRepository("alice")
+	registry := NewRegistry(repo, nil)
+	if _, err := addAndVerify(t, registry, "alice", aliceAddress, aliceKey); err != nil {
+		t.Fatal(err)
+	}
+
+	readded, err := registry.AddAddress("alice", aliceAddress, "hardware wallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !readded.Verified() || len(readded.Challenge) != 0 {
+		t.Errorf("expected the address to stay verified without a new challenge, got %+v", readded)
+	}
+	stored, _ := repo.GetAddress("alice", aliceAddress)
+	if !stored.Verified() || stored.Label != "hardware wallet" {
+		t.Errorf("expected the label to change and the address to stay verified, got %+v", stored)
+	}
+	if recipient, err := registry.ResolveRecipient("alice", aliceAddress); err != nil || recipient != aliceAddress {
+		t.Errorf("expected the address to still be deliverable, got [%s], %v", recipient, err)
+	}
+}
+
+func TestAddressClaimedByAnotherCustomer(t *testing.T) {
+	repo := newFakeCustomerRepository("alice", "mallory")
+	registry := NewRegistry(repo, nil)
+	if _, err := addAndVerify(t, registry, "alice", aliceAddress, aliceKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// mallory can add it, and somehow has the key, but the address is already alice's
+	_, err := addAndVerify(t, registry, "mallory", aliceAddress, aliceKey)
+	apiErr := expectCode(t, err, apierrors.CodeAddressAlreadyClaimed)
+	if !errors.Is(apiErr, ErrAddressClaimed) || apiErr.Details["address"] != aliceAddress {
+		t.Errorf("expected the error to wrap ErrAddressClaimed and name the address, got %+v", apiErr)
+	}
+	if stored, _ := repo.GetAddress("mallory", aliceAddress); stored.Verified() {
+		t.Error("expected mallory's entry to stay unverified")
+	}
+	if _, err = registry.ResolveRecipient("mallory", aliceAddress); err == nil {
+		t.Error("expected mallory not to be able to deliver to alice's address")
+	}
+}
+
+func TestResolveRecipient(t *testing.T) {
+	repo := newFakeCustomerRepository("alice", "bob", "carol")
+	registry := NewRegistry(repo, nil)
+	if _, err := registry.AddAddress("alice", bobAddress, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := addAndVerify(t, registry, "alice", aliceAddress, aliceKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.AddAddress("bob", bobAddress, ""); err != nil {
+		t.Fatal(err)
+	}
+	repo.customers["carol"].Address = "0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"
+	repo.customers["carol"].DerivationPath = "m/44'/60'/0'/0/2"
+
+	tests := []struct {
+		name       string
+		customerId string
+		requested  string
+		expected   string
+		code       apierrors.Code
+	}{
+		{"a verified address", "alice", aliceAddress, aliceAddress, ""},
+		{"a verified address in lower case", "alice", "0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266", aliceAddress, ""},
+		{"an unverified address", "alice", bobAddress, "", apierrors.CodeAddressNotVerified},
+		{"an address that isn't in the address book", "bob", aliceAddress, "", apierrors.CodeCustomerAddressNotFound},
+		{"something that isn't an address", "alice", "0x1234", "", apierrors.CodeCustomerAddressNotFound},
+		// the unverified address was added first
+		{"nothing, with a verified address", "alice", "", aliceAddress, ""},
+		{"nothing, with only an unverified address", "bob", "", "", apierrors.CodeAddressNotVerified},
+		{"nothing, for a custodial customer", "carol", "", "0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC", ""},
+		{"anything, for a customer who doesn't exist", "dave", "", "", apierrors.CodeCustomerNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recipient, err := registry.ResolveRecipient(test.customerId, test.requested)
+			if len(test.code) != 0 {
+				expectCode(t, err, test.code)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recipient != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, recipient)
+			}
+		})
+	}
+}
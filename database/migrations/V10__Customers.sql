@@ -0,0 +1,11 @@
+create table if not exists orderdb.customers (
+    customer_id varchar(64) not null,
+    name varchar(128) not null,
+    address varchar(64) not null,
+    derivation_path varchar(64) not null,
+    address_index int unsigned not null,
+    created_at datetime(3) not null,
+    primary key (customer_id),
+    unique index customers_address (address),
+    unique index customers_address_index (address_index)
+)
@@ -0,0 +1,33 @@
+-- customers no longer need to be custodial; the deposit address and its derivation path are only set for those who are
+alter table orderdb.customers modify column address varchar(64) null;
+alter table orderdb.customers modify column derivation_path varchar(64) null;
+alter table orderdb.customers modify column address_index int unsigned null;
+
+alter table orderdb.customers add column email varchar(256) not null default '';
+alter table orderdb.customers add column ship_to_name varchar(128) not null default '';
+alter table orderdb.customers add column ship_to_line1 varchar(256) not null default '';
+alter table orderdb.customers add column ship_to_line2 varchar(256) not null default '';
+alter table orderdb.customers add column ship_to_city varchar(128) not null default '';
+alter table orderdb.customers add column ship_to_region varchar(128) not null default '';
+alter table orderdb.customers add column ship_to_postal_code varchar(32) not null default '';
+alter table orderdb.customers add column ship_to_country varchar(2) not null default '';
+alter table orderdb.customers add column updated_at datetime(3) not null default current_timestamp(3);
+
+-- the wallets a customer has, and whether they have proven they hold the keys to them
+create table if not exists orderdb.customer_addresses (
+    customer_id varchar(64) not null,
+    address varchar(64) not null,
+    label varchar(64) not null,
+    -- the message the customer has to sign, while the address is waiting to be verified
+    challenge varchar(512),
+    challenge_expires_at datetime(3),
+    verified_at datetime(3),
+    created_at datetime(3) not null,
+    primary key (customer_id, address),
+    index customer_addresses_address (address),
+    foreign key (customer_id) references orderdb.customers (customer_id)
+);
+
+-- custodial customers hold the keys to their deposit addresses through the service
+insert into orderdb.customer_addresses (customer_id, address, label, verified_at, created_at)
+    select customer_id, address, 'deposit', created_at, created_at from orderdb.customers where address is not null;
@@ -0,0 +1,34 @@
+-- the sellers in the marketplace. Each signs with its own key and has its own delivery contract.
+create table if not exists orderdb.vendors (
+    vendor_id varchar(64) not null,
+    name varchar(128) not null,
+    -- where the vendor's signing key is in the HD wallet. Null for the default vendor, whose key is configured.
+    address varchar(64),
+    derivation_path varchar(64),
+    address_index int unsigned,
+    -- null until the vendor's contract has been deployed
+    contract_address varchar(64),
+    -- where the vendor's takings are swept to, if anywhere
+    cold_wallet varchar(64),
+    created_at datetime(3) not null,
+    updated_at datetime(3) not null,
+    primary key (vendor_id),
+    unique index vendors_address_index (address_index)
+);
+
+-- the vendor the service was configured with before there could be more than one
+insert into orderdb.vendors (vendor_id, name, created_at, updated_at)
+    values ('default', 'Default vendor', current_timestamp(3), current_timestamp(3));
+
+-- everything that existed before belongs to the default vendor
+alter table orderdb.products add column vendor_id varchar(64) not null default 'default';
+alter table orderdb.products add foreign key (vendor_id) references orderdb.vendors (vendor_id);
+create index products_vendor_id on orderdb.products (vendor_id, name, product_id);
+
+alter table orderdb.orders add column vendor_id varchar(64) not null default 'default';
+alter table orderdb.orders add foreign key (vendor_id) references orderdb.vendors (vendor_id);
+create index orders_vendor_id on orderdb.orders (vendor_id, created_at, order_id);
+
+-- keys without a vendor belong to the marketplace's own staff, who can act for any vendor
+alter table orderdb.api_keys add column vendor_id varchar(64);
+alter table orderdb.api_keys add foreign key (vendor_id) references orderdb.vendors (vendor_id);
@@ -0,0 +1,3 @@
+-- the W3C traceparent of the request that queued the work, so that whoever picks it up later carries on the same trace
+alter table orderdb.outbox add column trace_parent varchar(64) not null default '';
+alter table orderdb.webhook_deliveries add column trace_parent varchar(64) not null default '';
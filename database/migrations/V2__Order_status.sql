@@ -0,0 +1,18 @@
+alter table orderdb.orders add column status varchar(16) not null default 'created';
+
+update orderdb.orders set status = case when delivered then 'delivered' else 'minted' end;
+
+alter table orderdb.orders drop column delivered;
+
+create table if not exists orderdb.order_status_history (
+    id bigint not null auto_increment,
+    order_id varchar(64) not null,
+    from_status varchar(16),
+    to_status varchar(16) not null,
+    actor_address varchar(64) not null,
+    tx_hash varchar(66) not null,
+    changed_at datetime(3) not null default current_timestamp(3),
+    primary key (id),
+    index order_status_history_order_id (order_id),
+    foreign key (order_id) references orderdb.orders (order_id)
+)
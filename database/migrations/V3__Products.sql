@@ -0,0 +1,12 @@
+create table if not exists orderdb.products (
+    product_id varchar(64) not null,
+    name varchar(64) not null,
+    description varchar(512) not null default '',
+    price bigint not null,
+    shipping_price bigint not null,
+    primary key (product_id)
+);
+
+-- the item used in the README's demonstration flow
+insert into orderdb.products (product_id, name, description, price, shipping_price)
+values ('7', 'socks', 'A comfortable pair of socks', 500, 75);
@@ -0,0 +1,16 @@
+alter table orderdb.orders add column buyer_address varchar(64) not null default '';
+
+alter table orderdb.orders add column created_at datetime(3) not null default current_timestamp(3);
+
+-- orders that existed before this column was added get the time of their first recorded status
+update orderdb.orders o
+    set o.created_at = (
+        select min(h.changed_at) from orderdb.order_status_history h where h.order_id = o.order_id)
+    where exists (select 1 from orderdb.order_status_history h where h.order_id = o.order_id);
+
+create index orders_buyer_address on orderdb.orders (buyer_address, created_at, order_id);
+create index orders_token_address on orderdb.orders (token_address, created_at, order_id);
+create index orders_status on orderdb.orders (status, created_at, order_id);
+create index orders_item_id on orderdb.orders (item_id, created_at, order_id);
+create index orders_created_at on orderdb.orders (created_at, order_id);
+create index orders_price on orderdb.orders (price, order_id);
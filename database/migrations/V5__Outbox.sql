@@ -0,0 +1,18 @@
+create table if not exists orderdb.outbox (
+    id bigint not null auto_increment,
+    order_id varchar(64) not null,
+    operation varchar(16) not null,
+    status varchar(16) not null,
+    actor_address varchar(64) not null,
+    tx_hash varchar(66) not null,
+    attempts int not null,
+    last_error varchar(1024) not null,
+    next_attempt_at datetime(3) not null,
+    locked_until datetime(3),
+    created_at datetime(3) not null,
+    updated_at datetime(3) not null,
+    primary key (id),
+    index outbox_due (status, next_attempt_at),
+    index outbox_order_id (order_id),
+    foreign key (order_id) references orderdb.orders (order_id)
+)
@@ -0,0 +1,12 @@
+create table if not exists orderdb.idempotency_keys (
+    idempotency_key varchar(255) not null,
+    fingerprint char(64) not null,
+    status varchar(16) not null,
+    response_code int,
+    response_content_type varchar(128),
+    response_body mediumtext,
+    created_at datetime(3) not null,
+    expires_at datetime(3) not null,
+    primary key (idempotency_key),
+    index idempotency_keys_expires_at (expires_at)
+)
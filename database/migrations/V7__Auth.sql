@@ -0,0 +1,26 @@
+create table if not exists orderdb.api_keys (
+    key_id varchar(64) not null,
+    name varchar(128) not null,
+    key_hash char(64) not null,
+    role varchar(32) not null,
+    created_at datetime(3) not null,
+    revoked_at datetime(3),
+    primary key (key_id),
+    unique index api_keys_key_hash (key_hash)
+);
+
+create table if not exists orderdb.auth_nonces (
+    nonce varchar(64) not null,
+    expires_at datetime(3) not null,
+    primary key (nonce)
+);
+
+create table if not exists orderdb.sessions (
+    token_hash char(64) not null,
+    address varchar(64) not null,
+    role varchar(32) not null,
+    created_at datetime(3) not null,
+    expires_at datetime(3) not null,
+    primary key (token_hash),
+    index sessions_expires_at (expires_at)
+)
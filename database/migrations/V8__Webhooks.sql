@@ -0,0 +1,31 @@
+create table if not exists orderdb.webhook_subscriptions (
+    subscription_id varchar(64) not null,
+    url varchar(2048) not null,
+    secret varchar(128) not null,
+    events varchar(512) not null,
+    active boolean not null,
+    created_at datetime(3) not null,
+    updated_at datetime(3) not null,
+    primary key (subscription_id)
+);
+
+create table if not exists orderdb.webhook_deliveries (
+    id bigint not null auto_increment,
+    subscription_id varchar(64) not null,
+    event_id varchar(64) not null,
+    event_type varchar(32) not null,
+    order_id varchar(64) not null,
+    payload text not null,
+    status varchar(16) not null,
+    attempts int not null,
+    last_error varchar(1024) not null,
+    response_code int not null,
+    next_attempt_at datetime(3) not null,
+    locked_until datetime(3),
+    created_at datetime(3) not null,
+    updated_at datetime(3) not null,
+    primary key (id),
+    index webhook_deliveries_due (status, next_attempt_at),
+    index webhook_deliveries_subscription (subscription_id, status),
+    foreign key (subscription_id) references orderdb.webhook_subscriptions (subscription_id) on delete cascade
+)
@@ -0,0 +1,9 @@
+create table if not exists orderdb.delivery_evidence (
+    order_id varchar(64) not null,
+    reference varchar(512) not null,
+    evidence_hash char(66) not null,
+    recorded_by varchar(64) not null,
+    recorded_at datetime(3) not null,
+    primary key (order_id),
+    foreign key (order_id) references orderdb.orders (order_id)
+)
@@ -0,0 +1,363 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.7
+// source: deliverypb/delivery.proto
+
+package deliverypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// DeliveryServiceClient is the client API for DeliveryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DeliveryServiceClient interface {
+	// Places an order and mints its delivery token
+	CreateOrder(ctx context.Context, in *CreateOrderRequest, opts ...grpc.CallOption) (*CreateOrderResponse, error)
+	// Pays ether from the customer to the delivery contract for the price of the goods
+	PayForOrder(ctx context.Context, in *PayForOrderRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// Accepts delivery: the customer buys the token from the vendor for the price of shipping
+	DeliverOrder(ctx context.Context, in *DeliverOrderRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// Destroys the delivery token once the order has been delivered
+	BurnToken(ctx context.Context, in *BurnTokenRequest, opts ...grpc.CallOption) (*OperationResponse, error)
+	// Looks up who owns the delivery token on chain - the vendor or the customer
+	GetTokenOwner(ctx context.Context, in *GetTokenOwnerRequest, opts ...grpc.CallOption) (*GetTokenOwnerResponse, error)
+	// Searches the vendor's orders
+	ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error)
+	// Streams where the order is now, and then every change as it happens
+	WatchOrder(ctx context.Context, in *WatchOrderRequest, opts ...grpc.CallOption) (DeliveryService_WatchOrderClient, error)
+}
+
+type deliveryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDeliveryServiceClient(cc grpc.ClientConnInterface) DeliveryServiceClient {
+	return &deliveryServiceClient{cc}
+}
+
+func (c *deliveryServiceClient) CreateOrder(ctx context.Context, in *CreateOrderRequest, opts ...grpc.CallOption) (*CreateOrderResponse, error) {
+	out := new(CreateOrderResponse)
+	err := c.cc.Invoke(ctx, "/blockchainplayground.delivery.v1.DeliveryService/CreateOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deliveryServiceClient) PayForOrder(ctx context.Context, in *PayForOrderRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, "/blockchainplayground.delivery.v1.DeliveryService/PayForOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deliveryServiceClient) DeliverOrder(ctx context.Context, in *DeliverOrderRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, "/blockchainplayground.delivery.v1.DeliveryService/DeliverOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deliveryServiceClient) BurnToken(ctx context.Context, in *BurnTokenRequest, opts ...grpc.CallOption) (*OperationResponse, error) {
+	out := new(OperationResponse)
+	err := c.cc.Invoke(ctx, "/blockchainplayground.delivery.v1.DeliveryService/BurnToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deliveryServiceClient) GetTokenOwner(ctx context.Context, in *GetTokenOwnerRequest, opts ...grpc.CallOption) (*GetTokenOwnerResponse, error) {
+	out := new(GetTokenOwnerResponse)
+	err := c.cc.Invoke(ctx, "/blockchainplayground.delivery.v1.DeliveryService/GetTokenOwner", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deliveryServiceClient) ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error) {
+	out := new(ListOrdersResponse)
+	err := c.cc.Invoke(ctx, "/blockchainplayground.delivery.v1.DeliveryService/ListOrders", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deliveryServiceClient) WatchOrder(ctx context.Context, in *WatchOrderRequest, opts ...grpc.CallOption) (DeliveryService_WatchOrderClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DeliveryService_ServiceDesc.Streams[0], "/blockchainplayground.delivery.v1.DeliveryService/WatchOrder", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &deliveryServiceWatchOrderClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DeliveryService_WatchOrderClient interface {
+	Recv() (*OrderUpdate, error)
+	grpc.ClientStream
+}
+
+type deliveryServiceWatchOrderClient struct {
+	grpc.ClientStream
+}
+
+func (x *deliveryServiceWatchOrderClient) Recv() (*OrderUpdate, error) {
+	m := new(OrderUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DeliveryServiceServer is the server API for DeliveryService service.
+// All implementations must embed UnimplementedDeliveryServiceServer
+// for forward compatibility
+type DeliveryServiceServer interface {
+	// Places an order and mints its delivery token
+	CreateOrder(context.Context, *CreateOrderRequest) (*CreateOrderResponse, error)
+	// Pays ether from the customer to the delivery contract for the price of the goods
+	PayForOrder(context.Context, *PayForOrderRequest) (*OperationResponse, error)
+	// Accepts delivery: the customer buys the token from the vendor for the price of shipping
+	DeliverOrder(context.Context, *DeliverOrderRequest) (*OperationResponse, error)
+	// Destroys the delivery token once the order has been delivered
+	BurnToken(context.Context, *BurnTokenRequest) (*OperationResponse, error)
+	// Looks up who owns the delivery token on chain - the vendor or the customer
+	GetTokenOwner(context.Context, *GetTokenOwnerRequest) (*GetTokenOwnerResponse, error)
+	// Searches the vendor's orders
+	ListOrders(context.Context, *ListOrdersRequest) (*ListOrdersResponse, error)
+	// Streams where the order is now, and then every change as it happens
+	WatchOrder(*WatchOrderRequest, DeliveryService_WatchOrderServer) error
+	mustEmbedUnimplementedDeliveryServiceServer()
+}
+
+// UnimplementedDeliveryServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedDeliveryServiceServer struct {
+}
+
+func (UnimplementedDeliveryServiceServer) CreateOrder(context.Context, *CreateOrderRequest) (*CreateOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateOrder not implemented")
+}
+func (UnimplementedDeliveryServiceServer) PayForOrder(context.Context, *PayForOrderRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PayForOrder not implemented")
+}
+func (UnimplementedDeliveryServiceServer) DeliverOrder(context.Context, *DeliverOrderRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeliverOrder not implemented")
+}
+func (UnimplementedDeliveryServiceServer) BurnToken(context.Context, *BurnTokenRequest) (*OperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BurnToken not implemented")
+}
+func (UnimplementedDeliveryServiceServer) GetTokenOwner(context.Context, *GetTokenOwnerRequest) (*GetTokenOwnerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTokenOwner not implemented")
+}
+func (UnimplementedDeliveryServiceServer) ListOrders(context.Context, *ListOrdersRequest) (*ListOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOrders not implemented")
+}
+func (UnimplementedDeliveryServiceServer) WatchOrder(*WatchOrderRequest, DeliveryService_WatchOrderServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrder not implemented")
+}
+func (UnimplementedDeliveryServiceServer) mustEmbedUnimplementedDeliveryServiceServer() {}
+
+// UnsafeDeliveryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DeliveryServiceServer will
+// result in compilation errors.
+type UnsafeDeliveryServiceServer interface {
+	mustEmbedUnimplementedDeliveryServiceServer()
+}
+
+func RegisterDeliveryServiceServer(s grpc.ServiceRegistrar, srv DeliveryServiceServer) {
+	s.RegisterService(&DeliveryService_ServiceDesc, srv)
+}
+
+func _DeliveryService_CreateOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeliveryServiceServer).CreateOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchainplayground.delivery.v1.DeliveryService/CreateOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeliveryServiceServer).CreateOrder(ctx, req.(*CreateOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeliveryService_PayForOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PayForOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeliveryServiceServer).PayForOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchainplayground.delivery.v1.DeliveryService/PayForOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeliveryServiceServer).PayForOrder(ctx, req.(*PayForOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeliveryService_DeliverOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeliverOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeliveryServiceServer).DeliverOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchainplayground.delivery.v1.DeliveryService/DeliverOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeliveryServiceServer).DeliverOrder(ctx, req.(*DeliverOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeliveryService_BurnToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BurnTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeliveryServiceServer).BurnToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchainplayground.delivery.v1.DeliveryService/BurnToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeliveryServiceServer).BurnToken(ctx, req.(*BurnTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeliveryService_GetTokenOwner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTokenOwnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeliveryServiceServer).GetTokenOwner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchainplayground.delivery.v1.DeliveryService/GetTokenOwner",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeliveryServiceServer).GetTokenOwner(ctx, req.(*GetTokenOwnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeliveryService_ListOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeliveryServiceServer).ListOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchainplayground.delivery.v1.DeliveryService/ListOrders",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeliveryServiceServer).ListOrders(ctx, req.(*ListOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeliveryService_WatchOrder_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrderRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DeliveryServiceServer).WatchOrder(m, &deliveryServiceWatchOrderServer{stream})
+}
+
+type DeliveryService_WatchOrderServer interface {
+	Send(*OrderUpdate) error
+	grpc.ServerStream
+}
+
+type deliveryServiceWatchOrderServer struct {
+	grpc.ServerStream
+}
+
+func (x *deliveryServiceWatchOrderServer) Send(m *OrderUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// DeliveryService_ServiceDesc is the grpc.ServiceDesc for DeliveryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DeliveryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "blockchainplayground.delivery.v1.DeliveryService",
+	HandlerType: (*DeliveryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateOrder",
+			Handler:    _DeliveryService_CreateOrder_Handler,
+		},
+		{
+			MethodName: "PayForOrder",
+			Handler:    _DeliveryService_PayForOrder_Handler,
+		},
+		{
+			MethodName: "DeliverOrder",
+			Handler:    _DeliveryService_DeliverOrder_Handler,
+		},
+		{
+			MethodName: "BurnToken",
+			Handler:    _DeliveryService_BurnToken_Handler,
+		},
+		{
+			MethodName: "GetTokenOwner",
+			Handler:    _DeliveryService_GetTokenOwner_Handler,
+		},
+		{
+			MethodName: "ListOrders",
+			Handler:    _DeliveryService_ListOrders_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchOrder",
+			Handler:       _DeliveryService_WatchOrder_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "deliverypb/delivery.proto",
+}
@@ -20,50 +20,47 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/order": {
-            "post": {
-                "description": "Places an order that can later be delivered",
-                "consumes": [
-                    "application/json"
+        "/apikeys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Lists every API key, including revoked ones. The keys themselves are not included. A vendor's keys,\nor the X-Vendor-Id header, only list that vendor's keys.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "order"
+                    "auth"
                 ],
-                "summary": "Create order",
+                "summary": "List API keys",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "The item to order",
-                        "name": "itemId",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "the Ethereum address of the user who can accept the delivery",
-                        "name": "buyerAddress",
-                        "in": "query",
-                        "required": true
+                        "description": "only the vendor's keys",
+                        "name": "X-Vendor-Id",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controllers.CreateOrderResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.ApiKeyResponse"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/controllers.ApiError"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/controllers.ApiError"
                         }
@@ -75,11 +72,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/order/{orderId}": {
+            },
             "post": {
-                "description": "This action changes the status of an order, either by accepting delivery or canceling it",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Issues an API key for a back-office system. The key is only shown once. A vendor's keys can only\nissue keys for that vendor.",
                 "consumes": [
                     "application/json"
                 ],
@@ -87,38 +87,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "order"
+                    "auth"
                 ],
-                "summary": "Update order status",
+                "summary": "Create API key",
                 "parameters": [
                     {
-                        "description": "Indicates the status of the order. One of ('canceled', 'burned')",
+                        "description": "Who the key is for and what it may do",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/controllers.OrderUpdateRequest"
+                            "$ref": "#/definitions/controllers.ApiKeyRequest"
                         }
                     },
                     {
                         "type": "string",
-                        "description": "If this is a delivery, the delivery recipient's private key (not a good idea in real life!)",
-                        "name": "customerKey",
-                        "in": "query"
+                        "description": "the vendor to issue the key for, if the body doesn't say",
+                        "name": "X-Vendor-Id",
+                        "in": "header"
                     },
                     {
                         "type": "string",
-                        "description": "the ID of the order being updated",
-                        "name": "orderId",
-                        "in": "path",
-                        "required": true
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/controllers.OrderStatusResponse"
+                            "$ref": "#/definitions/controllers.ApiKeyResponse"
                         }
                     },
                     "400": {
@@ -127,12 +126,30 @@ const docTemplate = `{
                             "$ref": "#/definitions/controllers.ApiError"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/controllers.ApiError"
                         }
                     },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -142,9 +159,164 @@ const docTemplate = `{
                 }
             }
         },
-        "/order/{orderId}/owner": {
+        "/apikeys/{keyId}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stops an API key from working",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Revoke API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the key",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ends the caller's customer session",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Sign out",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/me": {
             "get": {
-                "description": "Determines who currently owns the deliver token - the vendor or the customer.\nThis looks up the contract in the blockchain rather than reading the status from the database.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Describes the authenticated caller",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Who am I",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PrincipalResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/nonce": {
+            "post": {
+                "description": "Returns a single-use nonce to include in an EIP-4361 (Sign-In with Ethereum) message",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Start signing in with Ethereum",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.NonceResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/siwe": {
+            "post": {
+                "description": "Verifies a signed EIP-4361 message and starts a customer session for the address that signed it.\nThe customer can then see and act on the orders that are to be delivered to that address.",
                 "consumes": [
                     "application/json"
                 ],
@@ -152,23 +324,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "order"
+                    "auth"
                 ],
-                "summary": "Get the current owner of the delivery contract token",
+                "summary": "Sign in with Ethereum",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "the ID of the order to look up",
-                        "name": "orderId",
-                        "in": "path",
-                        "required": true
+                        "description": "The signed message",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SiweRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controllers.TokenOwnerResponse"
+                            "$ref": "#/definitions/controllers.SessionResponse"
                         }
                     },
                     "400": {
@@ -177,8 +351,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/controllers.ApiError"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/controllers.ApiError"
                         }
@@ -192,8 +366,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/payment/order/{orderId}": {
+        "/customers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists every customer account, in the order they were opened. Address books are left out; get a customer for theirs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customer"
+                ],
+                "summary": "List customers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.CustomerResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            },
             "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Opens an account that orders can be placed against. Customers with wallets add their addresses to it and verify them.\nCustodial customers, who don't have wallets, get their own deposit address derived from the service's HD wallet\ninstead, and the service signs their payments and deliveries.",
                 "consumes": [
                     "application/json"
                 ],
@@ -201,29 +425,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "order"
+                    "customer"
                 ],
-                "summary": "Pays ether from the customer to the delivery contract for the price of the goods",
+                "summary": "Open a customer account",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "If this is a delivery, the delivery recipient's private key (not a good idea in real life!)",
-                        "name": "customerKey",
-                        "in": "query"
+                        "description": "the customer",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CustomerRequest"
+                        }
                     },
                     {
                         "type": "string",
-                        "description": "the ID of the order being updated",
-                        "name": "orderId",
-                        "in": "path",
-                        "required": true
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "ok",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.CustomerResponse"
                         }
                     },
                     "400": {
@@ -232,8 +458,26 @@ const docTemplate = `{
                             "$ref": "#/definitions/controllers.ApiError"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
                         "schema": {
                             "$ref": "#/definitions/controllers.ApiError"
                         }
@@ -246,61 +490,3903 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "controllers.ApiError": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                }
-            }
+        },
+        "/customers/{customerId}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Looks up a customer's account and address book",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customer"
+                ],
+                "summary": "Get a customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the customer",
+                        "name": "customerId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CustomerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the customer's name, email and shipping address. Leaving the shipping address out removes it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customer"
+                ],
+                "summary": "Change a customer's account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the customer",
+                        "name": "customerId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the customer's details; custodial is ignored",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CustomerRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CustomerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{customerId}/addresses": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds an ethereum address to the customer's address book. Orders can't be delivered to it until the customer\nproves they hold its key by signing the challenge in the response. Adding an unverified address again gives it a\nnew challenge.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customer"
+                ],
+                "summary": "Add an address to a customer's address book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the customer",
+                        "name": "customerId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the address",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AddressRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AddressResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{customerId}/addresses/{address}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Orders already placed for the address aren't affected",
+                "tags": [
+                    "customer"
+                ],
+                "summary": "Remove an address from a customer's address book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the customer",
+                        "name": "customerId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the address to remove",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{customerId}/addresses/{address}/verify": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Checks the customer's signature over the address's challenge. If the address's key made it, orders can be\ndelivered to the address. An address can only be verified by one customer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customer"
+                ],
+                "summary": "Verify an address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the customer",
+                        "name": "customerId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the address to verify",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the signed challenge",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.VerifyAddressRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AddressResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{customerId}/orders": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Searches the orders delivered to the addresses the customer has verified. Takes the same filters and paging as\n/orders; buyerAddress narrows it down to one of the customer's addresses.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customer"
+                ],
+                "summary": "List a customer's orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the customer",
+                        "name": "customerId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "only orders for this one of the customer's addresses",
+                        "name": "buyerAddress",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only orders in these statuses, comma separated (e.g. 'minted,paid')",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only orders for this product",
+                        "name": "itemId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only orders placed at or after this time (RFC 3339)",
+                        "name": "createdAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only orders placed before this time (RFC 3339)",
+                        "name": "createdBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the field to sort by. One of ('createdAt', 'price'). Defaults to 'createdAt'",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "One of ('asc', 'desc'). Defaults to 'desc'",
+                        "name": "sortOrder",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "the maximum number of orders to return (1-200). Defaults to 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the nextCursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OrderListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/order": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Places an order that can later be delivered",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Create order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the product to order",
+                        "name": "itemId",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the Ethereum address of the user who can accept the delivery. Mixed case addresses must have a valid EIP-55 checksum; the zero address and the vendor's own address are refused. Required unless customerId is given, in which case it picks one of the customer's verified addresses",
+                        "name": "buyerAddress",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "a customer account to order for. The order goes to a custodial customer's deposit address, or the first address anyone else verified, unless buyerAddress says otherwise",
+                        "name": "customerId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateOrderResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "The order was recorded but the token is still being minted",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateOrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{orderId}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "This action changes the status of an order, either by accepting delivery, burning the token, or canceling it.\nOnce a paid order's escrow has expired without the customer accepting delivery, the vendor can claim it ('released'),\nwhich needs evidence of delivery, or it can be refunded to the customer ('refunded'). Customers sign their own refunds.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Update order status",
+                "parameters": [
+                    {
+                        "description": "Indicates the status of the order. One of ('delivered', 'burned', 'canceled', 'released', 'refunded')",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OrderUpdateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "If this is a delivery or a customer's refund, the delivery recipient's private key as 64 hex digits (not a good idea in real life!). Deliveries to custodial customers don't need it",
+                        "name": "customerKey",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the ID of the order being updated",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OrderStatusResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "The transaction was sent but has not been mined yet. The status is unchanged.",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OrderStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{orderId}/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Pushes the order's updates as Server-Sent Events until the client disconnects. The first event describes\nwhere the order is now; after that come status changes, transactions as they are sent, and confirmation\ncounts as blocks are built on top of them. Each event's name is the update's type and its data is the update.\nBrowsers can't set headers on an EventSource, so the session token may be passed as access_token instead.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Stream order updates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the order to watch",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the session token, for clients that can't send an Authorization header",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tracking.Update"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{orderId}/evidence": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Records where the proof that a paid order's package was dropped off is kept, replacing any recorded before. If the customer\nnever accepts delivery, the vendor needs this to claim the escrow once it expires; without it, the customer is refunded.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Record evidence of delivery",
+                "parameters": [
+                    {
+                        "description": "where the proof is kept",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.DeliveryEvidenceRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "the ID of the order that was dropped off",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.DeliveryEvidenceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{orderId}/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists every status the order has moved through, along with who moved it and the transaction that did it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Get the status history of an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the order to look up",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OrderHistoryResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{orderId}/owner": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Determines who currently owns the deliver token - the vendor or the customer.\nThis looks up the contract in the blockchain rather than reading the status from the database.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Get the current owner of the delivery contract token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the order to look up",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TokenOwnerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{orderId}/ws": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The same updates as the Server-Sent Events stream, sent as JSON text messages over a WebSocket.\nNothing needs to be sent to the server; closing the socket stops the stream.",
+                "tags": [
+                    "order"
+                ],
+                "summary": "Stream order updates over a WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the order to watch",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the session token, for clients that can't send an Authorization header",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/tracking.Update"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Searches the vendor's orders. All filters are optional and are combined with AND.\nResults are paged; pass the nextCursor from one page to get the next one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "List orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only orders that can be delivered to this ethereum address",
+                        "name": "buyerAddress",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only orders whose token is managed by this contract address",
+                        "name": "tokenAddress",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only orders in these statuses, comma separated (e.g. 'minted,paid')",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only orders for this product",
+                        "name": "itemId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the vendor's orders. A vendor's API keys only ever see their own",
+                        "name": "vendorId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the vendor's orders, if vendorId isn't given",
+                        "name": "X-Vendor-Id",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only orders placed at or after this time (RFC 3339)",
+                        "name": "createdAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only orders placed before this time (RFC 3339)",
+                        "name": "createdBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the field to sort by. One of ('createdAt', 'price'). Defaults to 'createdAt'",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "One of ('asc', 'desc'). Defaults to 'desc'",
+                        "name": "sortOrder",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "the maximum number of orders to return (1-200). Defaults to 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the nextCursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OrderListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/order/{orderId}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "order"
+                ],
+                "summary": "Pays ether from the customer to the delivery contract for the price of the goods",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the customer's private key as 64 hex digits (not a good idea in real life!). Leave it out for custodial customers; the service signs for them",
+                        "name": "customerKey",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the ID of the order being updated",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "202": {
+                        "description": "submitted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "description": "Lists every product in the catalog, or only one vendor's. A vendor's API keys only see their own.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "product"
+                ],
+                "summary": "List products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only the vendor's products",
+                        "name": "vendorId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the vendor's products, if vendorId isn't given",
+                        "name": "X-Vendor-Id",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.ProductResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a product to a vendor's catalog. A vendor's API keys add to their own.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "product"
+                ],
+                "summary": "Create product",
+                "parameters": [
+                    {
+                        "description": "The product to add",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ProductRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "the vendor to add the product for, if the body doesn't say",
+                        "name": "X-Vendor-Id",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{productId}": {
+            "get": {
+                "description": "Looks up a single product in the catalog",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "product"
+                ],
+                "summary": "Get product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the product",
+                        "name": "productId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ProductResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the details of a product in the catalog. Existing orders keep the prices they were placed with.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "product"
+                ],
+                "summary": "Update product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the product",
+                        "name": "productId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The new details of the product",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ProductRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes a product from the catalog. Existing orders for the product are unaffected.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "product"
+                ],
+                "summary": "Delete product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the product",
+                        "name": "productId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/reconciliation": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the report from the last reconciliation that finished, whether it was scheduled or asked for",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reconciliation"
+                ],
+                "summary": "Get the last reconciliation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the vendor whose orders were reconciled. Defaults to the default vendor",
+                        "name": "X-Vendor-Id",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reconcile.Report"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Compares every order with what the delivery contract says about it, and lists the tokens that no order accounts for.\nThe chain is taken to be right. With repair=true, orders the chain has moved on (e.g. a token the customer\nbought but the database still has as paid) are brought up to date; anything else is only reported. Orders with a\nchain operation still in flight are skipped. This walks every order, so it can take a while.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reconciliation"
+                ],
+                "summary": "Reconcile orders with the chain",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "whether to repair the database",
+                        "name": "repair",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the vendor whose orders to reconcile. Defaults to the default vendor",
+                        "name": "X-Vendor-Id",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reconcile.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/treasury": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reports the vendor's balance, what the delivery contract holds in escrow, and which customers' paid but undelivered\norders that escrow is for. Amounts are in wei, as strings. unaccounted is what the contract holds beyond the orders\nthe database has as paid; anything but 0 is worth a reconciliation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "treasury"
+                ],
+                "summary": "Get the vendor's balances",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the vendor whose balances to report. Defaults to the default vendor",
+                        "name": "X-Vendor-Id",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/treasury.BalanceReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/treasury/customers/{address}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reports a customer's balance and what they have in escrow, in wei",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "treasury"
+                ],
+                "summary": "Get a customer's balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the customer's address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the vendor whose escrow to count. Defaults to the default vendor",
+                        "name": "X-Vendor-Id",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/treasury.CustomerBalance"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/treasury/sweep": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "If the vendor's balance is above the configured threshold, sends everything but the amount to keep (and the gas)\nto the cold wallet, and waits for it to be mined. swept is false, with the reason, if the balance isn't high enough.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "treasury"
+                ],
+                "summary": "Sweep the vendor's funds",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the vendor whose funds to sweep. Defaults to the default vendor",
+                        "name": "X-Vendor-Id",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/treasury.SweepResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists every vendor in the marketplace, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vendor"
+                ],
+                "summary": "List vendors",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.VendorResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a vendor to the marketplace, with a signing key of their own derived from the service's HD wallet. The vendor\ncan't take orders until their delivery contract is deployed, which their address needs ether for.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vendor"
+                ],
+                "summary": "Add a vendor",
+                "parameters": [
+                    {
+                        "description": "the vendor",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.VendorRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.VendorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "The vendor already exists, or no HD wallet seed is configured",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{vendorId}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Looks up a vendor. A vendor's API keys can only look up their own.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vendor"
+                ],
+                "summary": "Get a vendor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the vendor",
+                        "name": "vendorId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.VendorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the vendor's name and cold wallet. Leaving the cold wallet out stops their takings being swept. Their key\nnever changes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vendor"
+                ],
+                "summary": "Change a vendor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the vendor",
+                        "name": "vendorId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the vendor's details; vendorId is ignored",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.VendorRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.VendorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/vendors/{vendorId}/contract": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deploys the vendor's delivery contract, signed with their key, and waits for it to be mined. Send ether to the vendor's\naddress for the gas first. Once it is deployed the vendor can take orders. A vendor who already has a contract only\ngets a new one with replace=true. Their orders on the old contract stay there and can't be taken any further.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vendor"
+                ],
+                "summary": "Deploy a vendor's contract",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the vendor",
+                        "name": "vendorId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "deploy a new contract even though the vendor has one",
+                        "name": "replace",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.VendorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "The vendor already has a contract, or no HD wallet seed is configured",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "502": {
+                        "description": "The contract couldn't be deployed, e.g. because the vendor's address can't pay for the gas",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists every webhook subscription",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.WebhookResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Subscribes a URL to order events. Each event is POSTed as JSON with an X-Webhook-Signature\nheader of the form \"t=\u003cunix time\u003e,v1=\u003chex HMAC-SHA256 of '\u003cunix time\u003e.\u003cbody\u003e' keyed with the secret\u003e\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Create webhook",
+                "parameters": [
+                    {
+                        "description": "Where to send events and which ones",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.WebhookRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{webhookId}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Looks up a single webhook subscription",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Get webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the subscription",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.WebhookResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Changes where events are sent, which ones, the signing secret, or pauses and resumes the subscription.\nDeliveries queued while a subscription is paused are sent once it is resumed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Update webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the subscription",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The new details of the subscription",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.WebhookRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stops sending events to the subscriber and throws away its queued and past deliveries",
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Delete webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the subscription",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{webhookId}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the subscription's most recent deliveries. Ask for status 'dead' to see the ones that were given up on.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the subscription",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "only deliveries in this status. One of ('pending', 'delivered', 'dead')",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "the maximum number of deliveries to return (1-100). Defaults to 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.WebhookDeliveryResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{webhookId}/deliveries/{deliveryId}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Puts a delivery that was given up on back in the queue, with a fresh set of attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Retry a dead webhook delivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the ID of the subscription",
+                        "name": "webhookId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "the ID of the delivery",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "makes the request safe to retry; a retry with the same key returns the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.WebhookDeliveryResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "The Idempotency-Key was already used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ApiError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controllers.AddressRequest": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "format": "address"
+                },
+                "label": {
+                    "description": "what the customer calls it. Optional.",
+                    "type": "string",
+                    "example": "hardware wallet"
+                }
+            }
+        },
+        "controllers.AddressResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "format": "address"
+                },
+                "challenge": {
+                    "description": "What the customer has to sign with the address's key to verify it. Only while it is unverified.",
+                    "type": "string"
+                },
+                "challengeExpiresAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "verified": {
+                    "type": "boolean"
+                },
+                "verifiedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ApiError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "identifies what went wrong; see the list of error codes in the API description",
+                    "type": "string",
+                    "enum": [
+                        "ADDRESS_ALREADY_CLAIMED",
+                        "ADDRESS_NOT_VERIFIED",
+                        "API_KEY_NOT_FOUND",
+                        "CHAIN_ERROR",
+                        "CHAIN_UNAVAILABLE",
+                        "CHALLENGE_EXPIRED",
+                        "CONTRACT_ALREADY_DEPLOYED",
+                        "CUSTODY_NOT_CONFIGURED",
+                        "CUSTOMER_ADDRESS_NOT_FOUND",
+                        "CUSTOMER_NOT_FOUND",
+                        "DELIVERY_EVIDENCE_MISSING",
+                        "DELIVERY_TOKEN_NOT_FOUND",
+                        "ESCROW_NOT_EXPIRED",
+                        "ESCROW_UNSUPPORTED",
+                        "FORBIDDEN",
+                        "IDEMPOTENCY_KEY_IN_PROGRESS",
+                        "IDEMPOTENCY_KEY_REUSED",
+                        "INSUFFICIENT_FUNDS",
+                        "INTERNAL",
+                        "INVALID_ADDRESS",
+                        "INVALID_CREDENTIALS",
+                        "INVALID_CURSOR",
+                        "INVALID_PARAMETER",
+                        "INVALID_PRIVATE_KEY",
+                        "INVALID_REQUEST",
+                        "INVALID_SIGNATURE",
+                        "INVALID_SIGN_IN_MESSAGE",
+                        "MISSING_PARAMETER",
+                        "ORDER_ALREADY_PAID",
+                        "ORDER_NOT_FOUND",
+                        "ORDER_OPERATION_IN_PROGRESS",
+                        "ORDER_STATUS_CONFLICT",
+                        "PRODUCT_ALREADY_EXISTS",
+                        "PRODUCT_NOT_FOUND",
+                        "RECONCILIATION_IN_PROGRESS",
+                        "RECONCILIATION_NOT_FOUND",
+                        "SWEEP_NOT_CONFIGURED",
+                        "TX_REVERTED",
+                        "UNAUTHENTICATED",
+                        "VENDOR_ALREADY_EXISTS",
+                        "VENDOR_NOT_FOUND",
+                        "VENDOR_NOT_READY",
+                        "WEBHOOK_DELIVERY_NOT_DEAD",
+                        "WEBHOOK_DELIVERY_NOT_FOUND",
+                        "WEBHOOK_NOT_FOUND"
+                    ],
+                    "example": "ORDER_NOT_FOUND"
+                },
+                "correlationId": {
+                    "description": "quote this when asking about the error; it ties the request to the server's logs",
+                    "type": "string",
+                    "example": "3f2b8c1e-5d4a-4e2f-9b7c-1a2b3c4d5e6f"
+                },
+                "details": {
+                    "description": "anything that helps act on the error, e.g. which field was wrong",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "error": {
+                    "description": "describes what went wrong, for people. Don't parse it; it may change.",
+                    "type": "string",
+                    "example": "Order ID [1234] does not exist"
+                }
+            }
+        },
+        "controllers.ApiKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "a label for whoever will use the key",
+                    "type": "string"
+                },
+                "role": {
+                    "description": "one of ('vendor_admin', 'courier', 'auditor')",
+                    "type": "string"
+                },
+                "vendorId": {
+                    "description": "the only vendor the key can act for. Leave it out for a marketplace key that can act for any vendor.\nA vendor's own keys can only issue keys for that vendor.",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ApiKeyResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "when the key was created",
+                    "type": "string"
+                },
+                "key": {
+                    "description": "the secret to send in the X-API-Key header. It cannot be retrieved again.",
+                    "type": "string"
+                },
+                "keyId": {
+                    "description": "identifies the key",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "a label for whoever uses the key",
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "description": "when the key was revoked, if it has been",
+                    "type": "string"
+                },
+                "role": {
+                    "description": "the role the key grants",
+                    "type": "string"
+                },
+                "vendorId": {
+                    "description": "the only vendor the key can act for. Empty for marketplace keys.",
+                    "type": "string"
+                }
+            }
         },
         "controllers.CreateOrderResponse": {
             "type": "object",
             "properties": {
                 "contractAddress": {
-                    "description": "The address of the contract that manages this token",
+                    "description": "The address of the contract that manages this token",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "The ID of the delivery token. A tokenId is unique within a given contract.",
+                    "type": "string"
+                },
+                "orderId": {
+                    "description": "The unique ID of the order",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "The status of the order. If the token is still being minted, this is 'created' and the\ntoken ID is omitted; check back on the order later.",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.CustomerRequest": {
+            "type": "object",
+            "properties": {
+                "custodial": {
+                    "description": "Give the customer their own deposit address from the service's HD wallet, and have the service sign for them.\nFor customers without a wallet. Only when opening the account.",
+                    "type": "boolean"
+                },
+                "email": {
+                    "description": "Optional",
+                    "type": "string",
+                    "example": "alice@example.com"
+                },
+                "name": {
+                    "description": "who the customer is",
+                    "type": "string"
+                },
+                "shippingAddress": {
+                    "description": "where the customer's packages go. Optional.",
+                    "$ref": "#/definitions/controllers.ShippingAddressBody"
+                }
+            }
+        },
+        "controllers.CustomerResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "A custodial customer's deposit address. Their orders are delivered to it unless they say otherwise; send ether\nhere so the service can pay for them.",
+                    "type": "string",
+                    "format": "address"
+                },
+                "addresses": {
+                    "description": "the customer's address book",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.AddressResponse"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "custodial": {
+                    "description": "whether the service holds the customer's keys",
+                    "type": "boolean"
+                },
+                "customerId": {
+                    "type": "string"
+                },
+                "derivationPath": {
+                    "description": "where the deposit address's key is in the service's HD wallet",
+                    "type": "string",
+                    "example": "m/44'/60'/0'/0/0"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "shippingAddress": {
+                    "$ref": "#/definitions/controllers.ShippingAddressBody"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.DeliveryEvidenceRequest": {
+            "type": "object",
+            "properties": {
+                "reference": {
+                    "description": "e.g. the courier's drop-off record or a photo's URL, up to 512 characters",
+                    "type": "string",
+                    "example": "courier-scan-0042"
+                }
+            }
+        },
+        "controllers.DeliveryEvidenceResponse": {
+            "type": "object",
+            "properties": {
+                "hash": {
+                    "description": "the keccak256 hash of the reference, which goes on chain when the vendor claims the escrow",
+                    "type": "string"
+                },
+                "orderId": {
+                    "description": "the unique ID of the order",
+                    "type": "string"
+                },
+                "recordedAt": {
+                    "description": "when it was recorded",
+                    "type": "string"
+                },
+                "recordedBy": {
+                    "description": "the API key or address that recorded it",
+                    "type": "string"
+                },
+                "reference": {
+                    "description": "where the proof is kept",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.NonceResponse": {
+            "type": "object",
+            "properties": {
+                "chainId": {
+                    "description": "the chain ID the message must be for",
+                    "type": "integer"
+                },
+                "domain": {
+                    "description": "the domain the message must be addressed to",
+                    "type": "string"
+                },
+                "nonce": {
+                    "description": "put this in the message's Nonce field",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.OrderHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "history": {
+                    "description": "every status change, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.StatusChangeResponse"
+                    }
+                },
+                "orderId": {
+                    "description": "the unique ID of the order",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "the current status of the order",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.OrderListResponse": {
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "description": "pass this as the cursor to get the next page. Omitted on the last page.",
+                    "type": "string"
+                },
+                "orders": {
+                    "description": "the orders on this page",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.OrderResponse"
+                    }
+                }
+            }
+        },
+        "controllers.OrderResponse": {
+            "type": "object",
+            "properties": {
+                "buyerAddress": {
+                    "description": "the customer who is allowed to accept delivery",
+                    "type": "string",
+                    "format": "address"
+                },
+                "createdAt": {
+                    "description": "when the order was placed",
+                    "type": "string"
+                },
+                "deliveryPrice": {
+                    "description": "the price of shipping, in wei",
+                    "type": "integer"
+                },
+                "itemId": {
+                    "description": "the ID of the product that was ordered",
+                    "type": "string"
+                },
+                "itemName": {
+                    "description": "the name of the product that was ordered",
+                    "type": "string"
+                },
+                "orderId": {
+                    "description": "the unique ID of the order",
+                    "type": "string"
+                },
+                "price": {
+                    "description": "the price of the goods, in wei",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "the current status of the order",
+                    "type": "string"
+                },
+                "tokenAddress": {
+                    "description": "the address of the contract that manages the delivery token",
+                    "type": "string",
+                    "format": "address"
+                },
+                "tokenId": {
+                    "description": "the ID of the delivery token. Zero if it was never minted.",
+                    "type": "integer"
+                },
+                "vendorId": {
+                    "description": "the vendor who sells the item",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.OrderStatusResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "description": "indicates the actual resulting status of the order",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.OrderUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "description": "indicates the desired new status of the order",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.PrincipalResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "the ethereum address the caller proved they control. Only set for customers.",
+                    "type": "string",
+                    "format": "address"
+                },
+                "role": {
+                    "description": "one of ('vendor_admin', 'customer', 'courier', 'auditor')",
+                    "type": "string"
+                },
+                "subject": {
+                    "description": "the API key ID or the customer's address",
+                    "type": "string"
+                },
+                "vendorId": {
+                    "description": "the only vendor the caller can act for. Empty if they can act for any vendor.",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ProductRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "description": "A longer description of the product",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "The display name of the product",
+                    "type": "string"
+                },
+                "price": {
+                    "description": "The price of the goods, in wei",
+                    "type": "integer"
+                },
+                "productId": {
+                    "description": "The unique ID of the product. Optional when creating; one is generated if omitted.\nIgnored when updating, since the ID comes from the path.",
+                    "type": "string"
+                },
+                "shippingPrice": {
+                    "description": "The price of shipping the goods, in wei",
+                    "type": "integer"
+                },
+                "vendorId": {
+                    "description": "The vendor who sells the product. Only when creating, and only for the marketplace's own keys; a vendor's\nkeys always add to their own catalog. Defaults to the X-Vendor-Id header, or else the default vendor.",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ProductResponse": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "description": "A longer description of the product",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "The display name of the product",
+                    "type": "string"
+                },
+                "price": {
+                    "description": "The price of the goods, in wei",
+                    "type": "integer"
+                },
+                "productId": {
+                    "description": "The unique ID of the product. This is the itemId used when placing an order.",
+                    "type": "string"
+                },
+                "shippingPrice": {
+                    "description": "The price of shipping the goods, in wei",
+                    "type": "integer"
+                },
+                "vendorId": {
+                    "description": "The vendor who sells the product",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.SessionResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "the address the customer proved they control",
+                    "type": "string",
+                    "format": "address"
+                },
+                "expiresAt": {
+                    "description": "when the session ends",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "send this as a bearer token: \"Authorization: Bearer \u003ctoken\u003e\"",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ShippingAddressBody": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "description": "ISO 3166-1 alpha-2",
+                    "type": "string",
+                    "example": "GB"
+                },
+                "line1": {
+                    "type": "string"
+                },
+                "line2": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "who the package is for, if not the customer",
+                    "type": "string"
+                },
+                "postalCode": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.SiweRequest": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "description": "the EIP-4361 message, exactly as it was signed",
+                    "type": "string"
+                },
+                "signature": {
+                    "description": "the signature over the message produced by personal_sign, hex encoded",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.StatusChangeResponse": {
+            "type": "object",
+            "properties": {
+                "actorAddress": {
+                    "description": "the ethereum address of whoever caused the change",
+                    "type": "string",
+                    "format": "address"
+                },
+                "changedAt": {
+                    "description": "when the change happened",
+                    "type": "string"
+                },
+                "fromStatus": {
+                    "description": "the status the order moved out of. Empty when the order was first created.",
+                    "type": "string"
+                },
+                "toStatus": {
+                    "description": "the status the order moved into",
+                    "type": "string"
+                },
+                "txHash": {
+                    "description": "the hash of the transaction that caused the change, if it happened on chain",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.TokenOwnerResponse": {
+            "type": "object",
+            "properties": {
+                "owner": {
+                    "description": "The ethereum address of the token holder",
+                    "type": "string",
+                    "format": "address"
+                }
+            }
+        },
+        "controllers.VendorRequest": {
+            "type": "object",
+            "properties": {
+                "coldWallet": {
+                    "description": "where the vendor's takings are swept to. Optional.",
+                    "type": "string",
+                    "format": "address"
+                },
+                "name": {
+                    "description": "who the vendor is",
+                    "type": "string"
+                },
+                "vendorId": {
+                    "description": "Only when adding the vendor, and optional; one is generated if it is left out. Lower case letters, digits and\ndashes.",
+                    "type": "string",
+                    "example": "acme"
+                }
+            }
+        },
+        "controllers.VendorResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "The address the vendor signs their tokens and contract with. It needs ether before the contract can be\ndeployed, and for every order after that.",
+                    "type": "string",
+                    "format": "address"
+                },
+                "coldWallet": {
+                    "description": "where the vendor's takings are swept to",
+                    "type": "string",
+                    "format": "address"
+                },
+                "contractAddress": {
+                    "description": "the vendor's delivery contract. Empty until it has been deployed.",
+                    "type": "string",
+                    "format": "address"
+                },
+                "createdAt": {
                     "type": "string"
                 },
-                "message": {
-                    "description": "The ID of the delivery token. A tokenId is unique within a given contract.",
+                "derivationPath": {
+                    "description": "where the vendor's key is in the service's HD wallet. Empty for the default vendor, whose key is configured.",
+                    "type": "string",
+                    "example": "m/44'/60'/1'/0/0"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "ready": {
+                    "description": "whether the vendor's contract is deployed, so they can take orders",
+                    "type": "boolean"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "vendorId": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.VerifyAddressRequest": {
+            "type": "object",
+            "properties": {
+                "signature": {
+                    "description": "the address's challenge, signed by its key with personal_sign",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.WebhookDeliveryResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deliveryId": {
+                    "description": "identifies the delivery",
+                    "type": "integer"
+                },
+                "eventId": {
+                    "description": "identifies the event; it is the same across retries",
+                    "type": "string"
+                },
+                "eventType": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "description": "why the last attempt failed",
+                    "type": "string"
+                },
+                "nextAttemptAt": {
                     "type": "string"
                 },
                 "orderId": {
-                    "description": "The unique ID of the order",
+                    "type": "string"
+                },
+                "payload": {
+                    "description": "the request body that is sent",
+                    "type": "string"
+                },
+                "responseCode": {
+                    "description": "the HTTP status the subscriber last responded with, or 0 if it couldn't be reached",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "one of ('pending', 'delivered', 'dead')",
                     "type": "string"
                 }
             }
         },
-        "controllers.OrderStatusResponse": {
+        "controllers.WebhookRequest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "whether to send events. Defaults to true when creating.",
+                    "type": "boolean"
+                },
+                "events": {
+                    "description": "the events to send, e.g. [\"order.minted\", \"order.paid\"]",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "The key used to sign payloads. Optional; one is generated when creating if omitted, and the\nexisting one is kept when updating if omitted.",
+                    "type": "string"
+                },
+                "url": {
+                    "description": "where events are POSTed to. Must be an http or https URL.",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.WebhookResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "whether events are being sent",
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "events": {
+                    "description": "the events that are sent",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "the key that payloads are signed with. Keep it safe; it cannot be retrieved again.",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "where events are POSTed to",
+                    "type": "string"
+                },
+                "webhookId": {
+                    "description": "identifies the subscription",
+                    "type": "string"
+                }
+            }
+        },
+        "reconcile.Discrepancy": {
             "type": "object",
             "properties": {
+                "chainTokenId": {
+                    "description": "the token the chain has for the order",
+                    "type": "integer"
+                },
+                "description": {
+                    "description": "what is wrong, for people",
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "unrecorded_delivery"
+                },
+                "orderId": {
+                    "description": "empty for orphan tokens, since the contract can't say which order a token was minted for",
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "who owns the token on chain, as a hex string",
+                    "type": "string"
+                },
+                "repairError": {
+                    "description": "why the repair didn't work",
+                    "type": "string"
+                },
+                "repairStatus": {
+                    "description": "the status the database was, or would be, repaired to. Empty if it can't be repaired automatically.",
+                    "type": "string",
+                    "example": "delivered"
+                },
+                "repaired": {
+                    "description": "whether the database was repaired",
+                    "type": "boolean"
+                },
                 "status": {
-                    "description": "indicates the actual resulting status of the order",
+                    "description": "the order's status in the database",
+                    "type": "string",
+                    "example": "paid"
+                },
+                "tokenId": {
+                    "description": "the token the database has for the order",
+                    "type": "integer"
+                }
+            }
+        },
+        "reconcile.Report": {
+            "type": "object",
+            "properties": {
+                "discrepancies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/reconcile.Discrepancy"
+                    }
+                },
+                "errors": {
+                    "description": "orders or lookups that couldn't be checked, and why",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "ordersChecked": {
+                    "description": "how many orders were compared with the chain",
+                    "type": "integer"
+                },
+                "ordersSkipped": {
+                    "description": "orders that were left alone because a chain operation for them is still in flight, or they belong\nto a different contract",
+                    "type": "integer"
+                },
+                "repair": {
+                    "description": "whether discrepancies that could be repaired were",
+                    "type": "boolean"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "tokensOnChain": {
+                    "description": "how many tokens exist on chain. -1 if they couldn't be listed.",
+                    "type": "integer"
+                },
+                "vendorId": {
+                    "description": "the vendor whose orders and contract were compared",
                     "type": "string"
                 }
             }
         },
-        "controllers.OrderUpdateRequest": {
+        "tracking.Update": {
             "type": "object",
             "properties": {
+                "confirmations": {
+                    "description": "how many blocks include the transaction or were built on top of it. 0 until it is mined.",
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "why the operation failed",
+                    "type": "string"
+                },
+                "fromStatus": {
+                    "description": "for status updates, the status the order moved out of",
+                    "type": "string"
+                },
+                "operation": {
+                    "description": "the chain operation the update is about: one of ('mint', 'pay', 'deliver', 'burn')",
+                    "type": "string"
+                },
+                "orderId": {
+                    "type": "string"
+                },
                 "status": {
-                    "description": "indicates the desired new status of the order",
+                    "description": "the order's status after the update",
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "txHash": {
+                    "type": "string"
+                },
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "controllers.TokenOwnerResponse": {
+        "treasury.BalanceReport": {
             "type": "object",
             "properties": {
-                "owner": {
-                    "description": "The ethereum address of the token holder",
+                "at": {
+                    "type": "string"
+                },
+                "contractAddress": {
+                    "type": "string"
+                },
+                "contractBalance": {
+                    "description": "everything the contract holds, which is what customers have paid for orders that haven't been delivered",
                     "type": "string",
-                    "format": "address"
+                    "example": "150"
+                },
+                "customers": {
+                    "description": "the customers with orders in escrow, by address",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/treasury.CustomerBalance"
+                    }
+                },
+                "escrowed": {
+                    "description": "the prices of the orders that are paid for and whose token the vendor still holds on chain",
+                    "type": "string",
+                    "example": "150"
+                },
+                "sweep": {
+                    "$ref": "#/definitions/treasury.SweepPolicy"
+                },
+                "unaccounted": {
+                    "description": "ContractBalance minus Escrowed. Anything but zero means the database and the chain disagree about\nwhich orders are paid for; a reconciliation will say which.",
+                    "type": "string",
+                    "example": "0"
+                },
+                "vendorAddress": {
+                    "type": "string"
+                },
+                "vendorBalance": {
+                    "type": "string",
+                    "example": "1000000000000000000"
+                },
+                "vendorId": {
+                    "type": "string"
+                }
+            }
+        },
+        "treasury.CustomerBalance": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "balance": {
+                    "description": "what the customer's account holds",
+                    "type": "string",
+                    "example": "5000000000000000000"
+                },
+                "escrowed": {
+                    "description": "the prices of the customer's orders that are paid for but not delivered",
+                    "type": "string",
+                    "example": "150"
+                },
+                "orderIds": {
+                    "description": "the orders the escrowed amount is for",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
+        },
+        "treasury.SweepPolicy": {
+            "type": "object",
+            "properties": {
+                "coldWallet": {
+                    "description": "where the funds go",
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "interval": {
+                    "description": "how often a sweep is attempted; empty if only on request",
+                    "type": "string",
+                    "example": "24h0m0s"
+                },
+                "keep": {
+                    "description": "what is left in the vendor's account, on top of the sweep's own gas",
+                    "type": "string",
+                    "example": "100000000000000000"
+                },
+                "threshold": {
+                    "description": "the vendor's balance has to be above this for a sweep to happen",
+                    "type": "string",
+                    "example": "1000000000000000000"
+                }
+            }
+        },
+        "treasury.SweepResult": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "what was sent to the cold wallet",
+                    "type": "string"
+                },
+                "coldWallet": {
+                    "type": "string"
+                },
+                "mined": {
+                    "description": "whether the transfer has been mined. If it hasn't, it may still be.",
+                    "type": "boolean"
+                },
+                "reason": {
+                    "description": "why nothing was sent",
+                    "type": "string",
+                    "example": "the vendor's balance is not above the threshold"
+                },
+                "swept": {
+                    "description": "whether any funds were sent",
+                    "type": "boolean"
+                },
+                "txHash": {
+                    "type": "string"
+                },
+                "vendorBalance": {
+                    "description": "the vendor's balance before the sweep, counting transactions that haven't been mined yet",
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "An API key issued to a back-office system",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "\"Bearer \" followed by the session token from signing in with Ethereum",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -312,7 +4398,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/api/v1",
 	Schemes:          []string{},
 	Title:            "Vendor API",
-	Description:      "These APIs allow the client to order items from the vendor",
+	Description:      "These APIs allow the client to order items from the vendor\n\nErrors come back as an ApiError. Its `code` is one of these, and won't change between versions:\n\n| Code | HTTP status | Meaning |\n| --- | --- | --- |\n| ADDRESS_ALREADY_CLAIMED | 409 | Another customer has already verified the address |\n| ADDRESS_NOT_VERIFIED | 409 | The customer hasn't proven they hold the address. They verify it by signing its challenge |\n| API_KEY_NOT_FOUND | 404 | The API key doesn't exist |\n| CHAIN_ERROR | 502 | The blockchain node refused the request for some other reason |\n| CHAIN_UNAVAILABLE | 503 | The blockchain node can't be reached. Try again later |\n| CHALLENGE_EXPIRED | 409 | The challenge to sign has expired. Adding the address again gives a new one |\n| CONTRACT_ALREADY_DEPLOYED | 409 | The vendor already has a delivery contract. Replacing it has to be asked for, and the default vendor's can't be |\n| CUSTODY_NOT_CONFIGURED | 409 | The service has no HD wallet seed, so it can't hold keys for custodial customers or new vendors |\n| CUSTOMER_ADDRESS_NOT_FOUND | 404 | The address isn't in the customer's address book |\n| CUSTOMER_NOT_FOUND | 404 | The customer doesn't exist |\n| DELIVERY_EVIDENCE_MISSING | 409 | The vendor can only claim an expired escrow once evidence of delivery has been recorded |\n| DELIVERY_TOKEN_NOT_FOUND | 404 | The order's delivery token was never minted |\n| ESCROW_NOT_EXPIRED | 409 | The order's escrow can't be released or refunded until it expires. details.expiresAt says when |\n| ESCROW_UNSUPPORTED | 409 | The vendor's delivery contract was built before escrow could expire |\n| FORBIDDEN | 403 | The caller's role doesn't allow the request |\n| IDEMPOTENCY_KEY_IN_PROGRESS | 409 | A request with the same Idempotency-Key is still being handled, or only just finished |\n| IDEMPOTENCY_KEY_REUSED | 422 | The Idempotency-Key was already used for a different request |\n| INSUFFICIENT_FUNDS | 400 | The account signing the transaction can't cover its value and gas |\n| INTERNAL | 500 | Something broke on the server. The correlation ID will help track it down |\n| INVALID_ADDRESS | 400 | An address isn't 0x and 40 hex digits, has a bad EIP-55 checksum, or can't receive deliveries. details.fields names it |\n| INVALID_CREDENTIALS | 401 | The API key, session token, or signed sign-in message is not valid |\n| INVALID_CURSOR | 400 | The paging cursor is corrupt or belongs to a differently sorted or filtered list |\n| INVALID_PARAMETER | 400 | A parameter has a value that isn't allowed, or several are wrong in different ways. details.fields names them |\n| INVALID_PRIVATE_KEY | 400 | The customer's private key isn't 32 bytes of hex, or isn't a valid secp256k1 key |\n| INVALID_REQUEST | 400 | The request couldn't be understood, e.g. the body isn't valid JSON |\n| INVALID_SIGNATURE | 400 | The signature isn't 65 bytes of hex, or wasn't made with the address's key |\n| INVALID_SIGN_IN_MESSAGE | 400 | The Sign-In with Ethereum message isn't a well formed EIP-4361 message |\n| MISSING_PARAMETER | 400 | Required parameters are missing. details.fields lists them |\n| ORDER_ALREADY_PAID | 409 | The order has already been paid for. details.currentStatus says how far it has got |\n| ORDER_NOT_FOUND | 404 | The order doesn't exist, or belongs to somebody else |\n| ORDER_OPERATION_IN_PROGRESS | 409 | The order's last chain operation hasn't finished yet. Try again once it has |\n| ORDER_STATUS_CONFLICT | 409 | The order's status doesn't allow the request. details has the currentStatus and the requestedStatus |\n| PRODUCT_ALREADY_EXISTS | 409 | A product with that ID is already in the catalog |\n| PRODUCT_NOT_FOUND | 404 | The product isn't in the catalog |\n| RECONCILIATION_IN_PROGRESS | 409 | Another reconciliation is still running. Try again once it has finished |\n| RECONCILIATION_NOT_FOUND | 404 | No reconciliation has finished since the service started |\n| SWEEP_NOT_CONFIGURED | 409 | Sweeping is off because the service has no cold wallet to sweep to |\n| TX_REVERTED | 400 | The contract rejected the transaction |\n| UNAUTHENTICATED | 401 | The endpoint needs credentials and none were sent |\n| VENDOR_ALREADY_EXISTS | 409 | A vendor with that ID already exists |\n| VENDOR_NOT_FOUND | 404 | The vendor doesn't exist, or the caller can't act for it |\n| VENDOR_NOT_READY | 409 | The vendor's delivery contract hasn't been deployed yet, so it can't take orders |\n| WEBHOOK_DELIVERY_NOT_DEAD | 409 | Only deliveries that were given up on can be retried |\n| WEBHOOK_DELIVERY_NOT_FOUND | 404 | The webhook delivery doesn't exist |\n| WEBHOOK_NOT_FOUND | 404 | The webhook subscription doesn't exist |\n\nEvery response carries an X-Correlation-ID header, which is also in the error body. Send your own to tie requests together.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 }
@@ -0,0 +1,189 @@
+package escrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/service"
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// Settles escrow that has expired because the customer paid for an order but never bought its token.
+//
+// Without this, the customer's payment would sit in the contract forever. Once an order's escrow expires,
+// the settler releases it to the vendor if evidence of delivery was recorded, and refunds the customer if
+// it wasn't. Either way it goes through the outbox like any other chain operation, so the settlement ends up
+// in the order's status history along with its transaction.
+//
+// Every vendor has their own contract, so each has a settler of their own.
+type Settler struct {
+	repository orders.OrderRepository
+	// the vendor whose orders are settled, and the executor for their contract
+	vendorId string
+	executor *contract.DeliveryContractExecutor
+	service  *service.OrderService
+
+	// how often Run looks for expired escrow
+	Interval time.Duration
+	// how many orders to read from the database at once
+	BatchSize int
+
+	// settlements are made one run at a time, so two runs don't settle the same order
+	running sync.Mutex
+}
+
+// What one run of the settler did
+type Summary struct {
+	// the paid orders that were looked at
+	Checked  int
+	Released int
+	Refunded int
+	// settlements that were sent but haven't been mined yet; the outbox will finish them
+	Pending int
+	Failed  int
+}
+
+// Constructs a new settler with reasonable defaults
+func NewSettler(
+	repository orders.OrderRepository,
+	vendorId string,
+	executor *contract.DeliveryContractExecutor,
+	svc *service.OrderService,
+) *Settler {
+	return &Settler{
+		repository: repository,
+		vendorId:   vendorId,
+		executor:   executor,
+		service:    svc,
+		Interval:   time.Hour,
+		BatchSize:  100,
+	}
+}
+
+// Settles expired escrow every Interval until the context is canceled. Run this in its own goroutine.
+func (_settler *Settler) Run(ctx context.Context) {
+	log.Infof("Escrow settler for vendor [%s] started, running every %v", _settler.vendorId, _settler.Interval)
+
+	ticker := time.NewTicker(_settler.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("Escrow settler for vendor [%s] stopped", _settler.vendorId)
+			return
+		case <-ticker.C:
+		}
+
+		summary, err := _settler.SettleExpired(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Errorf("Settling expired escrow failed: %v", err)
+		} else if err == nil && summary.Released+summary.Refunded+summary.Pending+summary.Failed != 0 {
+			log.Infof("Escrow settlement released [%d], refunded [%d], left [%d] pending and failed [%d] of [%d] paid orders",
+				summary.Released, summary.Refunded, summary.Pending, summary.Failed, summary.Checked)
+		}
+	}
+}
+
+// Looks through the paid orders for escrow that has expired, and releases or refunds it. An order that
+// can't be settled doesn't stop the others; it is counted as failed and tried again next time.
+func (_settler *Settler) SettleExpired(ctx context.Context) (_ *Summary, err error) {
+	_settler.running.Lock()
+	defer _settler.running.Unlock()
+
+	ctx, span := tracing.Start(ctx, "escrow.SettleExpired")
+	defer tracing.End(span, &err)
+
+	summary := &Summary{}
+	query := &orders.OrderQuery{
+		VendorId: _settler.vendorId,
+		Statuses: []orders.OrderStatus{orders.StatusPaid},
+		SortBy:   orders.SortByCreatedAt,
+		Limit:    _settler.BatchSize,
+	}
+	for {
+		page, next, err := _settler.repository.ListOrders(ctx, query)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("could not list the paid orders: %v", err))
+		}
+		for _, order := range page {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			summary.Checked++
+			if err := _settler.settle(ctx, order, summary); err != nil {
+				summary.Failed++
+				log.Errorf("Could not settle the escrow for order [%s]: %v", order.OrderId, err)
+			}
+		}
+		if next == nil {
+			return summary, nil
+		}
+		query.After = next
+	}
+}
+
+// Releases or refunds the order's escrow if it has expired, and counts what happened
+func (_settler *Settler) settle(ctx context.Context, order *orders.Order, summary *Summary) error {
+	// the orders of a contract we have since replaced can't be settled through this one
+	if common.HexToAddress(order.TokenAddress) != *_settler.executor.ContractAddress {
+		return nil
+	}
+
+	expiresAt, err := _settler.executor.EscrowExpiresAt(ctx, order.TokenId)
+	if err != nil {
+		return err
+	} else if expiresAt.IsZero() || time.Now().Before(expiresAt) {
+		return nil
+	}
+
+	// something is already happening to the order, e.g. the customer is accepting delivery
+	inFlight, err := _settler.repository.HasUnfinishedOutboxEntry(ctx, order.OrderId)
+	if err != nil {
+		return err
+	} else if inFlight {
+		return nil
+	}
+
+	// if the customer has the token, the database is behind and the reconciler will catch it up
+	owner, err := _settler.executor.GetOwner(ctx, order.TokenId)
+	if err != nil {
+		return err
+	} else if common.HexToAddress(owner) != *_settler.executor.VendorAddress {
+		return nil
+	}
+
+	evidence, err := _settler.repository.GetDeliveryEvidence(ctx, order.OrderId)
+	if err != nil {
+		return err
+	}
+
+	var result *service.OperationResult
+	if evidence != nil {
+		log.Infof("The escrow for order [%s] expired at %v and the package was dropped off; releasing it", order.OrderId, expiresAt)
+		result, err = _settler.service.ReleaseEscrow(ctx, nil, order.OrderId)
+	} else {
+		log.Infof("The escrow for order [%s] expired at %v with no evidence of delivery; refunding it", order.OrderId, expiresAt)
+		result, err = _settler.service.RefundEscrow(ctx, nil, &service.CustomerOperationInput{OrderId: order.OrderId})
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case result.Pending:
+		summary.Pending++
+	case evidence != nil:
+		summary.Released++
+	default:
+		summary.Refunded++
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/deliverypb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// the metadata back-office systems put their API key in. gRPC metadata keys are always lower case.
+var apiKeyMetadata = "x-api-key"
+
+// the same role groups as the REST routes
+var (
+	orderReaders = []auth.Role{auth.RoleVendorAdmin, auth.RoleCustomer, auth.RoleCourier, auth.RoleAuditor}
+	orderWriters = []auth.Role{auth.RoleVendorAdmin, auth.RoleCustomer, auth.RoleCourier}
+	buyers       = []auth.Role{auth.RoleVendorAdmin, auth.RoleCustomer}
+)
+
+// who may call each method. Methods that aren't listed can't be called by anyone.
+var methodRoles = map[string][]auth.Role{
+	fullMethod("CreateOrder"):   buyers,
+	fullMethod("PayForOrder"):   buyers,
+	fullMethod("DeliverOrder"):  orderWriters,
+	fullMethod("BurnToken"):     orderWriters,
+	fullMethod("GetTokenOwner"): orderReaders,
+	fullMethod("ListOrders"):    orderReaders,
+	fullMethod("WatchOrder"):    orderReaders,
+}
+
+// the name gRPC gives the interceptors for a method of the delivery service
+func fullMethod(method string) string {
+	return "/" + deliverypb.DeliveryService_ServiceDesc.ServiceName + "/" + method
+}
+
+type principalKey struct{}
+
+// Works out who is calling, the same way the REST API does, and whether they may call the method.
+// Back-office systems send their API key in the x-api-key metadata; customers send their session
+// token in the authorization metadata as "Bearer <token>".
+type Authenticator struct {
+	// checks the credentials
+	Verifier *auth.Verifier
+}
+
+// The interceptor that authenticates unary calls
+func (_auth *Authenticator) Unary(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	principal, err := _auth.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(context.WithValue(ctx, principalKey{}, principal), req)
+}
+
+// The interceptor that authenticates streaming calls
+func (_auth *Authenticator) Stream(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	principal, err := _auth.authorize(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{
+		ServerStream: stream,
+		ctx:          context.WithValue(stream.Context(), principalKey{}, principal),
+	})
+}
+
+// Identifies the caller and makes sure their role allows the method
+func (_auth *Authenticator) authorize(ctx context.Context, method string) (*auth.Principal, error) {
+	principal, err := _auth.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	} else if principal == nil {
+		return nil, errorStatus(apierrors.New(apierrors.CodeUnauthenticated, "Authentication required"))
+	}
+
+	if !principal.HasRole(methodRoles[method]...) {
+		return nil, errorStatus(apierrors.New(apierrors.CodeForbidden, "Not allowed"))
+	}
+	return principal, nil
+}
+
+// Returns the caller, or nil if they didn't send any credentials
+func (_auth *Authenticator) authenticate(ctx context.Context) (*auth.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if apiKey := first(md, apiKeyMetadata); len(apiKey) != 0 {
+		principal, err := _auth.Verifier.VerifyApiKey(apiKey)
+		if err != nil {
+			return nil, errorStatus(err)
+		} else if principal == nil {
+			return nil, errorStatus(apierrors.New(apierrors.CodeInvalidCredentials, "Invalid API key"))
+		}
+		return principal, nil
+	}
+
+	scheme, token, found := strings.Cut(first(md, "authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") && len(token) != 0 {
+		principal, err := _auth.Verifier.VerifySessionToken(token)
+		if err != nil {
+			return nil, errorStatus(err)
+		} else if principal == nil {
+			return nil, errorStatus(apierrors.New(apierrors.CodeInvalidCredentials, "Invalid or expired session"))
+		}
+		return principal, nil
+	}
+
+	return nil, nil
+}
+
+// Returns the authenticated caller. The interceptors make sure there always is one, but the service treats
+// a nil principal as trusted, so it's checked again here in case the server was built without them.
+func principalFrom(ctx context.Context) (*auth.Principal, error) {
+	principal, _ := ctx.Value(principalKey{}).(*auth.Principal)
+	if principal == nil {
+		return nil, errorStatus(apierrors.New(apierrors.CodeUnauthenticated, "Authentication required"))
+	}
+	return principal, nil
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) != 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// A server stream whose context carries the principal
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *authenticatedStream) Context() context.Context {
+	return stream.ctx
+}
@@ -0,0 +1,366 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/deliverypb"
+	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/service"
+	"github.com/bdunton9323/blockchain-playground/tracking"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Serves the DeliveryService over gRPC. This is only a translation layer; the order flows themselves
+// live in the service package, where the REST controllers use them too.
+type DeliveryServer struct {
+	deliverypb.UnimplementedDeliveryServiceServer
+
+	Service *service.OrderService
+}
+
+// Builds a gRPC server with the delivery service and its authentication registered
+func NewServer(orderService *service.OrderService, verifier *auth.Verifier) *grpc.Server {
+	authenticator := &Authenticator{Verifier: verifier}
+	// tracing comes first so that a rejected call still shows up in the caller's trace
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor(), authenticator.Unary),
+		grpc.ChainStreamInterceptor(otelgrpc.StreamServerInterceptor(), authenticator.Stream),
+	)
+	deliverypb.RegisterDeliveryServiceServer(server, &DeliveryServer{Service: orderService})
+	return server
+}
+
+// Listens on the address and serves until the server is stopped
+func Serve(server *grpc.Server, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	log.Infof("Serving gRPC on [%s]", address)
+	return server.Serve(listener)
+}
+
+func (server *DeliveryServer) CreateOrder(
+	ctx context.Context,
+	req *deliverypb.CreateOrderRequest,
+) (*deliverypb.CreateOrderResponse, error) {
+	principal, err := principalFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = required("item_id", req.ItemId, "buyer_address", req.BuyerAddress); err != nil {
+		return nil, err
+	}
+
+	result, err := server.Service.CreateOrder(ctx, principal, &service.CreateOrderInput{
+		ItemId:       req.ItemId,
+		BuyerAddress: req.BuyerAddress,
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+	return &deliverypb.CreateOrderResponse{
+		Order:   toOrder(result.Order),
+		Pending: result.Pending,
+	}, nil
+}
+
+func (server *DeliveryServer) PayForOrder(
+	ctx context.Context,
+	req *deliverypb.PayForOrderRequest,
+) (*deliverypb.OperationResponse, error) {
+	principal, err := principalFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = required("order_id", req.OrderId, "customer_key", req.CustomerKey); err != nil {
+		return nil, err
+	}
+
+	result, err := server.Service.PayForOrder(ctx, principal, &service.CustomerOperationInput{
+		OrderId:     req.OrderId,
+		CustomerKey: req.CustomerKey,
+	})
+	return operationResponse(result, err)
+}
+
+func (server *DeliveryServer) DeliverOrder(
+	ctx context.Context,
+	req *deliverypb.DeliverOrderRequest,
+) (*deliverypb.OperationResponse, error) {
+	principal, err := principalFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = required("order_id", req.OrderId, "customer_key", req.CustomerKey); err != nil {
+		return nil, err
+	}
+
+	result, err := server.Service.DeliverOrder(ctx, principal, &service.CustomerOperationInput{
+		OrderId:     req.OrderId,
+		CustomerKey: req.CustomerKey,
+	})
+	return operationResponse(result, err)
+}
+
+func (server *DeliveryServer) BurnToken(
+	ctx context.Context,
+	req *deliverypb.BurnTokenRequest,
+) (*deliverypb.OperationResponse, error) {
+	principal, err := principalFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = required("order_id", req.OrderId); err != nil {
+		return nil, err
+	}
+
+	result, err := server.Service.BurnToken(ctx, principal, req.OrderId)
+	return operationResponse(result, err)
+}
+
+func (server *DeliveryServer) GetTokenOwner(
+	ctx context.Context,
+	req *deliverypb.GetTokenOwnerRequest,
+) (*deliverypb.GetTokenOwnerResponse, error) {
+	principal, err := principalFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = required("order_id", req.OrderId); err != nil {
+		return nil, err
+	}
+
+	owner, err := server.Service.GetTokenOwner(ctx, principal, req.OrderId)
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+	return &deliverypb.GetTokenOwnerResponse{
+		Owner: owner,
+	}, nil
+}
+
+func (server *DeliveryServer) ListOrders(
+	ctx context.Context,
+	req *deliverypb.ListOrdersRequest,
+) (*deliverypb.ListOrdersResponse, error) {
+	principal, err := principalFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := toOrderQuery(req)
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	page, err := server.Service.ListOrders(ctx, principal, query)
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	response := &deliverypb.ListOrdersResponse{}
+	for _, order := range page.Orders {
+		response.Orders = append(response.Orders, toOrder(order))
+	}
+	if page.Next != nil {
+		response.NextCursor = page.Next.Encode()
+	}
+	return response, nil
+}
+
+func (server *DeliveryServer) WatchOrder(
+	req *deliverypb.WatchOrderRequest,
+	stream deliverypb.DeliveryService_WatchOrderServer,
+) error {
+	principal, err := principalFrom(stream.Context())
+	if err != nil {
+		return err
+	}
+	if err = required("order_id", req.OrderId); err != nil {
+		return err
+	}
+
+	err = server.Service.WatchOrder(stream.Context(), principal, req.OrderId, &streamSink{stream: stream})
+	if err != nil {
+		return errorStatus(err)
+	}
+	return nil
+}
+
+// Sends updates down a WatchOrder stream
+type streamSink struct {
+	stream deliverypb.DeliveryService_WatchOrderServer
+}
+
+func (sink *streamSink) Send(update *tracking.Update) error {
+	return sink.stream.Send(&deliverypb.OrderUpdate{
+		OrderId:       update.OrderId,
+		Type:          string(update.Type),
+		Status:        update.Status,
+		FromStatus:    update.FromStatus,
+		Operation:     update.Operation,
+		TxHash:        update.TxHash,
+		Confirmations: update.Confirmations,
+		Error:         update.Error,
+		Time:          timestamppb.New(update.Time),
+	})
+}
+
+func (sink *streamSink) Heartbeat() error {
+	// HTTP/2 keeps the connection alive on its own
+	return nil
+}
+
+// Translates the outcome of a chain operation. One that is still in flight is reported as pending.
+func operationResponse(result *service.OperationResult, err error) (*deliverypb.OperationResponse, error) {
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+	return &deliverypb.OperationResponse{
+		OrderId: result.Order.OrderId,
+		Status:  string(result.Order.Status),
+		Pending: result.Pending,
+	}, nil
+}
+
+// the domain the ErrorInfo details on failed calls name
+const errorDomain = "blockchain-playground"
+
+// the gRPC code for each HTTP status the error catalog uses
+var statusCodes = map[int]codes.Code{
+	400: codes.InvalidArgument,
+	401: codes.Unauthenticated,
+	403: codes.PermissionDenied,
+	404: codes.NotFound,
+	409: codes.FailedPrecondition,
+	422: codes.FailedPrecondition,
+	502: codes.Unavailable,
+	503: codes.Unavailable,
+}
+
+// Converts an error into a gRPC status. The error's code goes in an ErrorInfo detail, so gRPC clients
+// can tell errors apart the same way REST clients do.
+func errorStatus(err error) error {
+	apiErr := apierrors.From(err)
+	code, ok := statusCodes[apiErr.Code.HttpStatus()]
+	if apiErr.Code == apierrors.CodeTxReverted || apiErr.Code == apierrors.CodeInsufficientFunds {
+		// nothing wrong with the arguments; the chain just won't take the transaction
+		code = codes.FailedPrecondition
+	} else if !ok {
+		code = codes.Internal
+		log.Errorf("gRPC call failed with %s: %v", apiErr.Code, apiErr.Err)
+	}
+
+	info := &errdetails.ErrorInfo{
+		Reason:   string(apiErr.Code),
+		Domain:   errorDomain,
+		Metadata: map[string]string{},
+	}
+	for key, value := range apiErr.Details {
+		// metadata can only hold strings, so anything more involved is sent as JSON
+		if text, ok := value.(string); ok {
+			info.Metadata[key] = text
+		} else if encoded, err := json.Marshal(value); err == nil {
+			info.Metadata[key] = string(encoded)
+		}
+	}
+
+	st, detailErr := status.New(code, apiErr.Message).WithDetails(info)
+	if detailErr != nil {
+		return status.Error(code, apiErr.Message)
+	}
+	return st.Err()
+}
+
+// Makes sure each of the named fields is set. Takes pairs of field names and values.
+func required(fieldsAndValues ...string) error {
+	missing := []string{}
+	for i := 0; i+1 < len(fieldsAndValues); i += 2 {
+		if len(fieldsAndValues[i+1]) == 0 {
+			missing = append(missing, fieldsAndValues[i])
+		}
+	}
+	if len(missing) != 0 {
+		return errorStatus(apierrors.New(apierrors.CodeMissingParameter, "Missing %s", strings.Join(missing, ", ")).
+			With("fields", missing))
+	}
+	return nil
+}
+
+func toOrder(order *orders.Order) *deliverypb.Order {
+	converted := &deliverypb.Order{
+		OrderId:       order.OrderId,
+		ItemId:        order.ItemId,
+		ItemName:      order.ItemName,
+		Price:         order.Price,
+		DeliveryPrice: order.DeliveryPrice,
+		BuyerAddress:  order.BuyerAddress,
+		TokenAddress:  order.TokenAddress,
+		TokenId:       order.TokenId,
+		Status:        string(order.Status),
+	}
+	if !order.CreatedAt.IsZero() {
+		converted.CreatedAt = timestamppb.New(order.CreatedAt)
+	}
+	return converted
+}
+
+// Builds the search from the request, with the same defaults and limits as the REST API
+func toOrderQuery(req *deliverypb.ListOrdersRequest) (*orders.OrderQuery, error) {
+	// the service checks the addresses
+	query := &orders.OrderQuery{
+		BuyerAddress: req.BuyerAddress,
+		TokenAddress: req.TokenAddress,
+		ItemId:       req.ItemId,
+		SortBy:       orders.SortByCreatedAt,
+		Descending:   !req.Ascending,
+		// the service fills in the default and checks the range
+		Limit: int(req.Limit),
+	}
+
+	for _, name := range req.Statuses {
+		orderStatus, ok := orders.ParseOrderStatus(name)
+		if !ok {
+			return nil, apierrors.New(apierrors.CodeInvalidParameter, "Unknown status [%s]", name).With("fields", []string{"statuses"})
+		}
+		query.Statuses = append(query.Statuses, orderStatus)
+	}
+
+	if req.CreatedAfter != nil {
+		query.CreatedAfter = req.CreatedAfter.AsTime()
+	}
+	if req.CreatedBefore != nil {
+		query.CreatedBefore = req.CreatedBefore.AsTime()
+	}
+
+	switch req.SortBy {
+	case "", string(orders.SortByCreatedAt):
+	case string(orders.SortByPrice):
+		query.SortBy = orders.SortByPrice
+	default:
+		return nil, apierrors.New(apierrors.CodeInvalidParameter,
+			"Cannot sort by [%s]. Expected 'createdAt' or 'price'", req.SortBy).With("fields", []string{"sort_by"})
+	}
+
+	if len(req.Cursor) != 0 {
+		cursor, err := orders.DecodeOrderCursor(req.Cursor)
+		if err != nil {
+			return nil, apierrors.Wrap(err, apierrors.CodeInvalidCursor, "The cursor is not valid")
+		}
+		query.After = cursor
+	}
+
+	return query, nil
+}
@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Anything that can tell whether its database connection works
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Checks that the database answers
+func DatabaseCheck(database Pinger) Check {
+	return Check{
+		Name: "database",
+		Run: func(ctx context.Context) (string, error) {
+			return "", database.Ping(ctx)
+		},
+	}
+}
+
+// Checks that the ethereum node answers, and reports the block it is on
+func NodeCheck(node *ethclient.Client) Check {
+	return Check{
+		Name: "node",
+		Run: func(ctx context.Context) (string, error) {
+			block, err := node.BlockNumber(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("at block %d", block), nil
+		},
+	}
+}
+
+// Checks that the node is still on the chain the service started on. Sign-in messages name the chain, so
+// a node that was swapped for one on another network would turn every customer away.
+func ChainIdCheck(node *ethclient.Client, expected *big.Int) Check {
+	return Check{
+		Name: "chainId",
+		Run: func(ctx context.Context) (string, error) {
+			chainId, err := node.ChainID(ctx)
+			if err != nil {
+				return "", err
+			} else if chainId.Cmp(expected) != 0 {
+				return "", errors.New(fmt.Sprintf("the node is on chain %s but the service expects %s", chainId, expected))
+			}
+			return fmt.Sprintf("chain %s", chainId), nil
+		},
+	}
+}
+
+// Checks that there is a contract at the address, which there won't be if the chain was reset
+func ContractCheck(node *ethclient.Client, address common.Address) Check {
+	return Check{
+		Name: "contract",
+		Run: func(ctx context.Context) (string, error) {
+			code, err := node.CodeAt(ctx, address, nil)
+			if err != nil {
+				return "", err
+			} else if len(code) == 0 {
+				return "", errors.New(fmt.Sprintf("there is no contract at [%s]", address.Hex()))
+			}
+			return fmt.Sprintf("%d bytes of code at [%s]", len(code), address.Hex()), nil
+		},
+	}
+}
+
+// Checks that the vendor has at least the given balance, in wei, to mint and burn tokens with
+func VendorBalanceCheck(node *ethclient.Client, vendor common.Address, minimum *big.Int) Check {
+	return Check{
+		Name: "vendorBalance",
+		Run: func(ctx context.Context) (string, error) {
+			balance, err := node.BalanceAt(ctx, vendor, nil)
+			if err != nil {
+				return "", err
+			}
+			detail := fmt.Sprintf("%s wei", balance)
+			if balance.Cmp(minimum) < 0 {
+				return detail, errors.New(fmt.Sprintf("the vendor's balance is below the minimum of %s wei", minimum))
+			}
+			return detail, nil
+		},
+	}
+}
@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var StatusOk = "ok"
+var StatusFailing = "failing"
+
+// One thing the service needs in order to do its job, like the database or the ethereum node
+type Check struct {
+	Name string
+	// Returns an error if the dependency isn't usable. The string says something useful about it for whoever is
+	// looking, e.g. the chain ID or a balance, and may be empty.
+	Run func(ctx context.Context) (string, error)
+	// Reported, but its failing doesn't make the service unready. For things only part of the service needs,
+	// like one vendor's account in a marketplace of them.
+	Optional bool
+}
+
+// How one check went
+type Result struct {
+	Name   string `json:"name"`
+	Status string `json:"status" example:"ok"`
+	// how long the check took
+	LatencyMs int64  `json:"latencyMs"`
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// whether the service is ready even if this check fails
+	Optional bool `json:"optional,omitempty"`
+}
+
+// How every check went. The service is ready if all of them passed, apart from optional ones.
+type Report struct {
+	Status string   `json:"status" example:"ok"`
+	Checks []Result `json:"checks"`
+}
+
+// Runs the checks that decide whether the service is ready to take requests
+type Checker struct {
+	Checks []Check
+	// how long a check may take before it counts as failed
+	Timeout time.Duration
+
+	// guards Checks, which Put can change while they run
+	mutex sync.Mutex
+}
+
+// Constructs a checker with reasonable defaults
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{
+		Checks:  checks,
+		Timeout: 2 * time.Second,
+	}
+}
+
+// Adds the checks, in place of any with the same name
+func (_checker *Checker) Put(checks ...Check) {
+	_checker.mutex.Lock()
+	defer _checker.mutex.Unlock()
+
+	for _, check := range checks {
+		replaced := false
+		for i := range _checker.Checks {
+			if _checker.Checks[i].Name == check.Name {
+				_checker.Checks[i] = check
+				replaced = true
+			}
+		}
+		if !replaced {
+			_checker.Checks = append(_checker.Checks, check)
+		}
+	}
+}
+
+// Runs every check at once, so one that hangs can't hold up the others, and reports on all of them
+func (_checker *Checker) Run(ctx context.Context) *Report {
+	_checker.mutex.Lock()
+	checks := append([]Check{}, _checker.Checks...)
+	_checker.mutex.Unlock()
+
+	report := &Report{
+		Status: StatusOk,
+		Checks: make([]Result, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			report.Checks[i] = _checker.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOk && !result.Optional {
+			report.Status = StatusFailing
+		}
+	}
+	return report
+}
+
+func (_checker *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, _checker.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	detail, err := check.Run(ctx)
+	result := Result{
+		Name:      check.Name,
+		Status:    StatusOk,
+		LatencyMs: time.Since(started).Milliseconds(),
+		Detail:    detail,
+		Optional:  check.Optional,
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,173 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+)
+
+// Where a request made with an idempotency key is in its lifecycle
+type KeyStatus string
+
+const (
+	// the first request with the key is still being handled
+	KeyInProgress KeyStatus = "in_progress"
+	// the first request finished and its response was stored
+	KeyCompleted KeyStatus = "completed"
+)
+
+// A DTO object representing a row in the database
+type KeyRecord struct {
+	Key string
+	// identifies the request that was made with the key, so a different request under the same key can be caught
+	Fingerprint         string
+	Status              KeyStatus
+	ResponseCode        int
+	ResponseContentType string
+	ResponseBody        []byte
+	CreatedAt           time.Time
+	ExpiresAt           time.Time
+}
+
+type KeyRepository interface {
+	// Stores a new in-progress record. Returns false if an unexpired record already exists for the key.
+	Reserve(key string, fingerprint string, retention time.Duration) (bool, error)
+	// Returns the record for the key, or nil if there isn't an unexpired one
+	Get(key string) (*KeyRecord, error)
+	// Stores the response for a reserved key
+	Complete(key string, code int, contentType string, body []byte) error
+	// Forgets a reserved key so that the request can be tried again
+	Release(key string) error
+	// Deletes every expired record. Returns how many were deleted.
+	DeleteExpired() (int64, error)
+}
+
+type MariaDBKeyRepository struct {
+	KeyRepository
+
+	conn *sql.DB
+}
+
+var keysTable = "idempotency_keys"
+var allFields = "idempotency_key, fingerprint, status, response_code, response_content_type, response_body, created_at, expires_at"
+
+// the MySQL error number for a duplicate primary key
+var duplicateEntryError uint16 = 1062
+
+// Construct a new repository that uses the given connection pool to MariaDB
+func NewMariaDBKeyRepository(db *sql.DB) *MariaDBKeyRepository {
+	return &MariaDBKeyRepository{conn: db}
+}
+
+// Stores a new in-progress record for the key, replacing an expired one if there is one.
+// Returns false if an unexpired record already exists.
+func (repo *MariaDBKeyRepository) Reserve(key string, fingerprint string, retention time.Duration) (bool, error) {
+	now := time.Now().UTC()
+
+	// an expired record doesn't count, so clear it out of the way
+	_, err := repo.conn.Exec(
+		fmt.Sprintf("delete from %s where idempotency_key = ? and expires_at <= ?", keysTable), key, now)
+	if err != nil {
+		return false, err
+	}
+
+	query := fmt.Sprintf(
+		"insert into %s (idempotency_key, fingerprint, status, created_at, expires_at) values (?, ?, ?, ?, ?)",
+		keysTable)
+	log.Debugf("running query [%s]", query)
+	_, err = repo.conn.Exec(query, key, fingerprint, KeyInProgress, now, now.Add(retention))
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == duplicateEntryError {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Returns the unexpired record for the key, or nil if there isn't one
+func (repo *MariaDBKeyRepository) Get(key string) (*KeyRecord, error) {
+	query := fmt.Sprintf("select %s from %s where idempotency_key = ? and expires_at > ?", allFields, keysTable)
+	log.Debugf("running query [%s]", query)
+
+	results, err := repo.conn.Query(query, key, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	if !results.Next() {
+		return nil, nil
+	}
+
+	var record KeyRecord
+	var code sql.NullInt64
+	var contentType sql.NullString
+	err = results.Scan(
+		&record.Key,
+		&record.Fingerprint,
+		&record.Status,
+		&code,
+		&contentType,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	record.ResponseCode = int(code.Int64)
+	record.ResponseContentType = contentType.String
+	return &record, nil
+}
+
+// Stores the response that the request with the key produced
+func (repo *MariaDBKeyRepository) Complete(key string, code int, contentType string, body []byte) error {
+	query := fmt.Sprintf(
+		"update %s set status = ?, response_code = ?, response_content_type = ?, response_body = ? "+
+			"where idempotency_key = ?",
+		keysTable)
+	_, err := repo.conn.Exec(query, KeyCompleted, code, contentType, body, key)
+	return err
+}
+
+// Forgets the key so that the request can be tried again
+func (repo *MariaDBKeyRepository) Release(key string) error {
+	_, err := repo.conn.Exec(fmt.Sprintf("delete from %s where idempotency_key = ?", keysTable), key)
+	return err
+}
+
+// Deletes every record that is past its retention window
+func (repo *MariaDBKeyRepository) DeleteExpired() (int64, error) {
+	result, err := repo.conn.Exec(
+		fmt.Sprintf("delete from %s where expires_at <= ?", keysTable), time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Deletes expired records on an interval until the context is canceled. Run this in its own goroutine.
+func (repo *MariaDBKeyRepository) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := repo.DeleteExpired()
+			if err != nil {
+				log.Errorf("Could not delete expired idempotency keys: %v", err)
+			} else if deleted > 0 {
+				log.Infof("Deleted [%d] expired idempotency keys", deleted)
+			}
+		}
+	}
+}
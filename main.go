@@ -1,49 +1,496 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"flag"
-	"strings"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/config"
 	"github.com/bdunton9323/blockchain-playground/contract"
 	"github.com/bdunton9323/blockchain-playground/controllers"
+	"github.com/bdunton9323/blockchain-playground/customers"
+	"github.com/bdunton9323/blockchain-playground/escrow"
+	"github.com/bdunton9323/blockchain-playground/grpcserver"
+	"github.com/bdunton9323/blockchain-playground/health"
+	"github.com/bdunton9323/blockchain-playground/idempotency"
+	"github.com/bdunton9323/blockchain-playground/metrics"
 	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/outbox"
+	"github.com/bdunton9323/blockchain-playground/products"
+	"github.com/bdunton9323/blockchain-playground/reconcile"
+	"github.com/bdunton9323/blockchain-playground/service"
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/bdunton9323/blockchain-playground/tracking"
+	"github.com/bdunton9323/blockchain-playground/treasury"
+	"github.com/bdunton9323/blockchain-playground/vendors"
+	"github.com/bdunton9323/blockchain-playground/wallet"
+	"github.com/bdunton9323/blockchain-playground/webhooks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 )
 
-// I'll just hard code these here for now
+func main() {
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	} else if err != nil {
+		log.Fatal(err.Error())
+	}
+	if cfg.PrintAndExit {
+		fmt.Println(cfg.Redacted())
+		return
+	}
+	log.Infof("Starting with this configuration:\n%s", cfg.Redacted())
 
-// the URL of the ethereum node (I used Quorum running locally)
-var ethNodeUrl = "http://172.13.3.1:8545"
-var dbHost = "127.0.0.1:3306"
-var dbName = "orderdb"
-var dbUser = "db_user"
-var dbPassword = "mysqlPassword"
+	contract.MiningTimeout = time.Duration(cfg.Chain.MiningTimeout)
 
-func main() {
-	privateKey := flag.String("privatekey", "", "The private key of the microservice (i.e. the vendor)")
-	contractAddress := flag.String("contractAddress", "", "The address of an existing delivery contract. If omitted, will deploy a new one")
-	flag.Parse()
+	stopTracing, err := tracing.Setup(context.Background(), cfg.Tracing.OtlpEndpoint, cfg.Tracing.OtlpInsecure)
+	if err != nil {
+		log.Fatalf("Could not set up tracing: %s", err.Error())
+	}
+
+	// the background workers stop when this is canceled
+	background, stopBackground := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	// runs the worker until ctx, which must come from background, is canceled
+	runInBackgroundUntil := func(ctx context.Context, run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+	runInBackground := func(run func(ctx context.Context)) {
+		runInBackgroundUntil(background, run)
+	}
+
+	// every repository shares one pool, which is the one /readyz checks
+	db, err := openDatabase(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Could not connect to database: %s", err.Error())
+	}
+	orderRepo := orders.NewMariaDBOrderRepository(db, cfg.Database.Name)
+	productRepo := products.NewMariaDBProductRepository(db)
+	idempotencyKeys := idempotency.NewMariaDBKeyRepository(db)
+	runInBackground(func(ctx context.Context) {
+		idempotencyKeys.RunCleanup(ctx, time.Duration(cfg.Server.IdempotencyCleanup))
+	})
+	authRepo := auth.NewMariaDBAuthRepository(db)
+	webhookRepo := webhooks.NewMariaDBWebhookRepository(db)
+	customerRepo := customers.NewMariaDBCustomerRepository(db)
+	vendorRepo := vendors.NewMariaDBVendorRepository(db)
 
-	// the signature code expects the bare hex string
-	if strings.HasPrefix(*privateKey, "0x") {
-		log.Fatal("Private key must not start with 0x")
+	// custodial customers, who don't have wallets of their own, and vendors other than the default one have
+	// their keys derived from this
+	var hdWallet *wallet.HDWallet
+	if len(cfg.Wallet.SeedFile) != 0 {
+		if hdWallet, err = wallet.LoadHDWallet(cfg.Wallet.SeedFile, cfg.Wallet.Passphrase); err != nil {
+			log.Fatalf("Could not load the HD wallet: %s", err.Error())
+		}
+	} else {
+		log.Info("No HD wallet seed is configured, so custodial customers and vendors other than the default one are off")
 	}
+	customerRegistry := customers.NewRegistry(customerRepo, hdWallet)
+	customerRegistry.Domain = cfg.Server.SiweDomain
 
-	orderRepo, err := orders.NewMariaDBOrderRepository(dbHost, dbName, dbUser, dbPassword)
+	contractExecutor, err := contract.NewDeliveryContractExecutor(cfg.Chain.NodeUrl, cfg.Chain.PrivateKey, &cfg.Chain.ContractAddress)
 	if err != nil {
-		log.Fatal("Could not connect to database: %s", err.Error())
+		log.Fatalf("Could not build the contract executor: %s", err.Error())
 	}
 
-	contractExecutor, err := contract.NewDeliveryContractExecutor(ethNodeUrl, *privateKey, contractAddress)
+	// sign-in messages have to name the chain, so customers can't replay one meant for another network
+	chainId, err := contractExecutor.Client.ChainID(context.Background())
 	if err != nil {
-		log.Fatal("Could not build the contract executor: %s", err.Error())
+		log.Fatalf("Could not get the chain ID: %s", err.Error())
+	}
+
+	// every vendor in the marketplace signs with their own key, on their own contract. The default vendor
+	// signs with the configured key.
+	vendorRegistry := vendors.NewRegistry(vendorRepo, hdWallet, cfg.Chain.NodeUrl, contractExecutor)
+
+	// passes order updates to clients watching them
+	tracker := tracking.NewHub()
+
+	// picks up any chain operations that were interrupted by the last shutdown, and retries failed ones
+	dispatcher := outbox.NewDispatcher(orderRepo, vendorRegistry)
+	dispatcher.Tracker = tracker
+
+	// tells subscribers about order changes
+	runInBackground(webhooks.NewSender(webhookRepo).Run)
+
+	// streams order updates to clients
+	follower := tracking.NewFollower(tracker, orderRepo, contractExecutor)
+
+	// the order flows, shared by the REST and gRPC APIs
+	var orderService = &service.OrderService{
+		Orders:     orderRepo,
+		Products:   productRepo,
+		Vendors:    vendorRegistry,
+		Dispatcher: dispatcher,
+		Tracker:    tracker,
+		Follower:   follower,
+		Customers:  customerRegistry,
+	}
+
+	// what /readyz checks. Every vendor's contract and balance are added as the vendor is started.
+	checker := health.NewChecker(
+		health.DatabaseCheck(orderRepo),
+		health.NodeCheck(contractExecutor.Client),
+		health.ChainIdCheck(contractExecutor.Client, chainId),
+	)
+
+	// every vendor's balances and the outbox backlog, for /metrics
+	chainCollector := metrics.NewChainCollector(contractExecutor.Client, orderRepo.CountUnfinishedOutboxEntries)
+
+	// each vendor's orders are reconciled, and their takings swept and escrow settled, on their own
+	perVendor := &vendorWorkers{
+		reconcilers: map[string]*reconcile.Reconciler{},
+		treasuries:  map[string]*treasury.Treasury{},
+		stops:       map[string]context.CancelFunc{},
+		escrow:      map[string]bool{},
+	}
+	// lets payments that sit in escrow because the customer never accepted delivery be settled
+	orderService.EscrowSupported = perVendor.escrowSupported
+	vendorRegistry.OnReady = func(vendor *vendors.Vendor, executor *contract.DeliveryContractExecutor) {
+		// stopped if the vendor's contract is replaced, since the workers are tied to this one
+		vendorBackground, stopVendor := context.WithCancel(background)
+
+		// a replaced contract's checks and balances give way to the new one's
+		checker.Put(vendorChecks(vendor.VendorId, executor, cfg.MinVendorBalanceWei())...)
+		chainCollector.PutVendor(vendor.VendorId, *executor.VendorAddress, *executor.ContractAddress)
+
+		// a contract deployed by an older build can't settle escrow, which configureEscrow logs
+		escrowSupported := configureEscrow(vendor.VendorId, executor, time.Duration(cfg.Escrow.Window))
+
+		// catches the database up with the chain when the two drift apart
+		reconciler := reconcile.NewReconciler(orderRepo, vendor.VendorId, executor)
+		reconciler.Interval = time.Duration(cfg.Reconciler.Interval)
+		reconciler.Repair = cfg.Reconciler.Repair
+		if reconciler.Interval > 0 {
+			runInBackgroundUntil(vendorBackground, reconciler.Run)
+		}
+
+		// balances, and sweeping the vendor's takings to cold storage
+		vendorTreasury := treasury.NewTreasury(orderRepo, vendor.VendorId, executor)
+		vendorTreasury.SweepThreshold = cfg.SweepThresholdWei()
+		vendorTreasury.SweepKeep = cfg.SweepKeepWei()
+		vendorTreasury.SweepInterval = time.Duration(cfg.Treasury.SweepInterval)
+		coldWallet := vendor.ColdWallet
+		if vendor.VendorId == vendors.DefaultVendorId {
+			coldWallet = cfg.Treasury.ColdWallet
+		}
+		if len(coldWallet) != 0 {
+			address := common.HexToAddress(coldWallet)
+			vendorTreasury.ColdWallet = &address
+			if vendorTreasury.SweepInterval > 0 {
+				runInBackgroundUntil(vendorBackground, vendorTreasury.Run)
+			}
+		}
+
+		if escrowSupported && cfg.Escrow.SettleInterval > 0 {
+			settler := escrow.NewSettler(orderRepo, vendor.VendorId, executor, orderService)
+			settler.Interval = time.Duration(cfg.Escrow.SettleInterval)
+			runInBackgroundUntil(vendorBackground, settler.Run)
+		}
+
+		perVendor.add(vendor.VendorId, reconciler, vendorTreasury, escrowSupported, stopVendor)
 	}
+	if err = vendorRegistry.Load(); err != nil {
+		log.Fatalf("Could not load the vendors: %s", err.Error())
+	}
+	runInBackground(dispatcher.Run)
+	var verifier = &auth.Verifier{
+		Repository:        authRepo,
+		BootstrapAdminKey: cfg.Auth.AdminApiKey,
+	}
+
+	grpcServer := grpcserver.NewServer(orderService, verifier)
+	go func() {
+		if err := grpcserver.Serve(grpcServer, cfg.Server.GrpcAddress); err != nil {
+			log.Fatalf("Could not serve gRPC: %s", err.Error())
+		}
+	}()
 
 	var orderController = &controllers.OrderController{
-		ServerPrivateKey: *privateKey,
-		NodeUrl:          ethNodeUrl,
-		OrderRepository:  orderRepo,
-		ContractExecutor: contractExecutor,
+		Service: orderService,
+	}
+	var productController = &controllers.ProductController{
+		ProductRepository: productRepo,
+		Vendors:           vendorRegistry,
+	}
+	var idempotencyMiddleware = &controllers.IdempotencyMiddleware{
+		KeyRepository: idempotencyKeys,
+		Retention:     time.Duration(cfg.Server.IdempotencyRetention),
+	}
+	var webhookController = &controllers.WebhookController{
+		WebhookRepository: webhookRepo,
+	}
+	var authController = &controllers.AuthController{
+		Repository: authRepo,
+		Domain:     cfg.Server.SiweDomain,
+		ChainId:    chainId.Int64(),
+		NonceTtl:   time.Duration(cfg.Auth.NonceTtl),
+		SessionTtl: time.Duration(cfg.Auth.SessionTtl),
+		Vendors:    vendorRegistry,
+	}
+	var authenticator = &controllers.Authenticator{
+		Verifier: verifier,
+		Vendors:  vendorRegistry,
+	}
+
+	var healthController = &controllers.HealthController{
+		Checker: checker,
+	}
+
+	var reconciliationController = &controllers.ReconciliationController{
+		Reconciler: func(vendorId string) (*reconcile.Reconciler, error) {
+			// the vendor's workers are started along with their executor
+			if _, err := vendorRegistry.Executor(vendorId); err != nil {
+				return nil, err
+			}
+			reconciler, _, err := perVendor.get(vendorId)
+			return reconciler, err
+		},
+	}
+
+	var treasuryController = &controllers.TreasuryController{
+		Treasury: func(vendorId string) (*treasury.Treasury, error) {
+			if _, err := vendorRegistry.Executor(vendorId); err != nil {
+				return nil, err
+			}
+			_, vendorTreasury, err := perVendor.get(vendorId)
+			return vendorTreasury, err
+		},
+	}
+
+	var vendorController = &controllers.VendorController{
+		Registry: vendorRegistry,
+	}
+
+	var customerController = &controllers.CustomerController{
+		Registry: customerRegistry,
+		Service:  orderService,
+	}
+
+	prometheus.MustRegister(chainCollector)
+
+	var router = &controllers.ApiRouter{
+		Address:                  cfg.Server.HttpAddress,
+		OrderController:          orderController,
+		ProductController:        productController,
+		AuthController:           authController,
+		WebhookController:        webhookController,
+		HealthController:         healthController,
+		ReconciliationController: reconciliationController,
+		TreasuryController:       treasuryController,
+		CustomerController:       customerController,
+		VendorController:         vendorController,
+		Authenticator:            authenticator,
+		Idempotency:              idempotencyMiddleware,
+	}
+	httpServer := router.Server()
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Could not serve HTTP: %s", err.Error())
+		}
+	}()
+
+	// Rolling deploys and `docker stop` send SIGTERM; Ctrl-C sends SIGINT
+	signals, stopListening := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	<-signals.Done()
+	// a second signal kills the process straight away
+	stopListening()
+
+	timeout := time.Duration(cfg.Server.ShutdownTimeout)
+	log.Infof("Shutting down; waiting up to %v for requests and transactions in flight", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Requests that are waiting for a transaction to be mined give up a little before the deadline, so they
+	// have time to release their outbox entries. The transaction hashes are already in the outbox, so the next
+	// instance to start waits for those transactions rather than sending them again.
+	stopWaiting := time.AfterFunc(timeout*4/5, dispatcher.StopWaiting)
+	defer stopWaiting.Stop()
+
+	// order update streams would otherwise keep both servers from ever finishing
+	follower.Stop()
+	stopBackground()
+
+	var servers sync.WaitGroup
+	servers.Add(2)
+	go func() {
+		defer servers.Done()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			log.Warnf("Gave up waiting for HTTP requests to finish: %v", err)
+			httpServer.Close()
+		}
+	}()
+	go func() {
+		defer servers.Done()
+		stopGrpc(ctx, grpcServer)
+	}()
+	servers.Wait()
+
+	if !waitUntil(ctx, &workers) {
+		log.Warn("Gave up waiting for the background workers to finish")
+	}
+
+	// nothing is using these any more
+	vendorRegistry.Close()
+	contractExecutor.Client.Close()
+	if err := db.Close(); err != nil {
+		log.Warnf("Could not close the database connections: %v", err)
+	}
+
+	if err := stopTracing(ctx); err != nil {
+		log.Warnf("Could not send the last spans: %v", err)
+	}
+	log.Info("Shut down")
+}
+
+// Stops the gRPC server, letting the calls in flight finish unless ctx is done first
+func stopGrpc(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Warnf("Gave up waiting for gRPC calls to finish: %v", ctx.Err())
+		server.Stop()
+	}
+}
+
+// Waits for the group to finish. Returns false if ctx was done first.
+func waitUntil(ctx context.Context, group *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		group.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Opens the pool of connections to MariaDB that every repository shares, and checks that it can connect
+func openDatabase(cfg *config.DatabaseConfig) (*sql.DB, error) {
+	// parseTime lets the driver scan DATETIME columns into time.Time
+	connUrl := fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true", cfg.User, cfg.Password, cfg.Host, cfg.Name)
+
+	db, err := sql.Open(metrics.DriverName, connUrl)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("could not connect to database %s: %v", cfg.Name, err.Error()))
+	}
+
+	// sql.Open doesn't connect, so a wrong host or password would otherwise only show up on the first request
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, errors.New(fmt.Sprintf("could not connect to database %s: %v", cfg.Name, err.Error()))
+	}
+	return db, nil
+}
+
+// The reconciler and treasury of every vendor whose executor has been built, by vendor ID
+type vendorWorkers struct {
+	mutex       sync.Mutex
+	reconcilers map[string]*reconcile.Reconciler
+	treasuries  map[string]*treasury.Treasury
+	// whether the vendor's contract can settle expired escrow
+	escrow map[string]bool
+	// stops the vendor's background work
+	stops map[string]context.CancelFunc
+}
+
+// Adds the vendor's workers, stopping the ones they replace
+func (workers *vendorWorkers) add(
+	vendorId string,
+	reconciler *reconcile.Reconciler,
+	vendorTreasury *treasury.Treasury,
+	escrowSupported bool,
+	stop context.CancelFunc,
+) {
+	workers.mutex.Lock()
+	defer workers.mutex.Unlock()
+	if previous, found := workers.stops[vendorId]; found {
+		previous()
+	}
+	workers.reconcilers[vendorId] = reconciler
+	workers.treasuries[vendorId] = vendorTreasury
+	workers.escrow[vendorId] = escrowSupported
+	workers.stops[vendorId] = stop
+}
+
+// Whether the vendor's contract can settle expired escrow. False until their workers have been added.
+func (workers *vendorWorkers) escrowSupported(vendorId string) bool {
+	workers.mutex.Lock()
+	defer workers.mutex.Unlock()
+	return workers.escrow[vendorId]
+}
+
+// Fails if the vendor's executor was only just built and their workers haven't been added yet
+func (workers *vendorWorkers) get(vendorId string) (*reconcile.Reconciler, *treasury.Treasury, error) {
+	workers.mutex.Lock()
+	defer workers.mutex.Unlock()
+	reconciler, found := workers.reconcilers[vendorId]
+	if !found {
+		return nil, nil, apierrors.New(apierrors.CodeVendorNotReady, "Vendor [%s] is still starting up", vendorId).
+			With("vendorId", vendorId)
+	}
+	return reconciler, workers.treasuries[vendorId], nil
+}
+
+// Checks that the vendor's contract is deployed and that they have at least the minimum balance, in wei. Only the
+// default vendor's decide whether the service is ready, so one vendor running low doesn't take the whole
+// marketplace out; the others' are named after the vendor, e.g. vendorBalance:acme.
+func vendorChecks(vendorId string, executor *contract.DeliveryContractExecutor, minimum *big.Int) []health.Check {
+	checks := []health.Check{
+		health.ContractCheck(executor.Client, *executor.ContractAddress),
+		health.VendorBalanceCheck(executor.Client, *executor.VendorAddress, minimum),
+	}
+	if vendorId != vendors.DefaultVendorId {
+		for i := range checks {
+			checks[i].Name += ":" + vendorId
+			checks[i].Optional = true
+		}
+	}
+	return checks
+}
+
+// Makes sure the vendor's contract has the configured escrow window. Returns false if the contract was built
+// before escrow could expire, in which case expired escrow can't be settled.
+func configureEscrow(vendorId string, executor *contract.DeliveryContractExecutor, window time.Duration) bool {
+	current, err := executor.EscrowWindow(context.Background())
+	if err != nil {
+		log.Warnf("Expired escrow can't be settled for vendor [%s]; their contract at [%s] was deployed by an older build: %v",
+			vendorId, executor.ContractAddress.Hex(), err)
+		return false
+	}
+	if current != window {
+		log.Infof("Changing the escrow window of vendor [%s] from %v to %v", vendorId, current, window)
+		if err = executor.SetEscrowWindow(context.Background(), window); err != nil {
+			log.Errorf("Could not change the escrow window of vendor [%s]; it stays at %v: %v", vendorId, current, err)
+		}
 	}
-	controllers.NewApiRouter(orderController).Start()
+	return true
 }
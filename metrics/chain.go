@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var vendorBalance = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "vendor_balance_wei"),
+	"The vendor account's balance, which pays for minting and burning",
+	[]string{"vendor"}, nil)
+
+var contractBalance = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "contract_balance_wei"),
+	"The ether the vendor's delivery contract holds in escrow",
+	[]string{"vendor"}, nil)
+
+var pendingTransactions = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "pending_transactions"),
+	"Chain operations in the outbox that haven't been sent or haven't been mined yet",
+	nil, nil)
+
+// Reports each vendor's balances and the size of the outbox. They are looked up when Prometheus scrapes, so
+// they are never stale; if a lookup fails, that gauge is left out of the scrape rather than reported as zero.
+type ChainCollector struct {
+	Node *ethclient.Client
+	// counts the unfinished outbox entries
+	PendingTransactions func(ctx context.Context) (int, error)
+	// how long the lookups may take altogether
+	Timeout time.Duration
+
+	// guards accounts
+	mutex sync.Mutex
+	// the accounts whose balances are reported, by vendor ID
+	accounts map[string]vendorAccounts
+}
+
+// The addresses a vendor's balances are looked up at
+type vendorAccounts struct {
+	vendor   common.Address
+	contract common.Address
+}
+
+// Constructs a new collector with a reasonable timeout. Add the vendors with PutVendor, and register it with
+// prometheus.MustRegister.
+func NewChainCollector(
+	node *ethclient.Client,
+	pendingTransactions func(ctx context.Context) (int, error),
+) *ChainCollector {
+	return &ChainCollector{
+		Node:                node,
+		PendingTransactions: pendingTransactions,
+		Timeout:             2 * time.Second,
+		accounts:            map[string]vendorAccounts{},
+	}
+}
+
+// Reports the balances of the vendor's account and contract from now on, in place of any they had before
+func (_collector *ChainCollector) PutVendor(vendorId string, vendorAddress common.Address, contractAddress common.Address) {
+	_collector.mutex.Lock()
+	defer _collector.mutex.Unlock()
+	_collector.accounts[vendorId] = vendorAccounts{
+		vendor:   vendorAddress,
+		contract: contractAddress,
+	}
+}
+
+func (_collector *ChainCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- vendorBalance
+	ch <- contractBalance
+	ch <- pendingTransactions
+}
+
+func (_collector *ChainCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), _collector.Timeout)
+	defer cancel()
+
+	_collector.mutex.Lock()
+	accounts := map[string]vendorAccounts{}
+	for vendorId, account := range _collector.accounts {
+		accounts[vendorId] = account
+	}
+	_collector.mutex.Unlock()
+
+	for vendorId, account := range accounts {
+		_collector.collectBalance(ctx, ch, vendorBalance, vendorId, account.vendor)
+		_collector.collectBalance(ctx, ch, contractBalance, vendorId, account.contract)
+	}
+
+	pending, err := _collector.PendingTransactions(ctx)
+	if err != nil {
+		log.Warnf("Could not count the pending transactions for the metrics: %v", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(pendingTransactions, prometheus.GaugeValue, float64(pending))
+}
+
+func (_collector *ChainCollector) collectBalance(
+	ctx context.Context,
+	ch chan<- prometheus.Metric,
+	desc *prometheus.Desc,
+	vendorId string,
+	address common.Address,
+) {
+	balance, err := _collector.Node.BalanceAt(ctx, address, nil)
+	if err != nil {
+		log.Warnf("Could not get the balance of [%s] for the metrics: %v", address.Hex(), err)
+		return
+	}
+	// a float loses the last few digits of a large balance, which doesn't matter for a graph or an alert
+	wei, _ := new(big.Float).SetInt(balance).Float64()
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, wei, vendorId)
+}
@@ -0,0 +1,179 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Open database connections with this driver name instead of "mysql" to have every statement timed. It is the
+// MySQL driver with a stopwatch around it; the connection string is the same.
+var DriverName = "mysql+metrics"
+
+func init() {
+	sql.Register(DriverName, &timedDriver{&mysql.MySQLDriver{}})
+}
+
+// picks the table out of a statement, e.g. the 'orders' in "select ... from orders where ..."
+var tablePattern = regexp.MustCompile(`(?i)\b(?:from|into|update)\s+` + "`?" + `([a-z_][a-z0-9_]*)`)
+
+// Records how long a statement took, labelled by its table and what kind of statement it was
+func observeQuery(query string, started time.Time, err error) {
+	statement := "other"
+	if fields := strings.Fields(query); len(fields) != 0 {
+		statement = strings.ToLower(fields[0])
+	}
+	table := "none"
+	if match := tablePattern.FindStringSubmatch(query); match != nil {
+		table = strings.ToLower(match[1])
+	}
+
+	outcome := OutcomeOk
+	if err != nil {
+		outcome = OutcomeError
+	}
+	dbQueries.WithLabelValues(table, statement, outcome).Observe(time.Since(started).Seconds())
+}
+
+type timedDriver struct {
+	driver driver.Driver
+}
+
+func (d *timedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{conn}, nil
+}
+
+// Wraps a connection. Statements that are prepared and then run, which is what database/sql does with
+// statements that have arguments, are timed by timedStmt instead.
+type timedConn struct {
+	driver.Conn
+}
+
+func (c *timedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &timedStmt{stmt, query}, nil
+}
+
+func (c *timedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	started := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		observeQuery(query, started, err)
+	}
+	return result, err
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	started := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		observeQuery(query, started, err)
+	}
+	return rows, err
+}
+
+func (c *timedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// the MySQL driver accepts a few more argument types than database/sql does on its own, like uint64s
+// with the high bit set
+func (c *timedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+type timedStmt struct {
+	driver.Stmt
+	query string
+}
+
+func (s *timedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	started := time.Now()
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(namedToValues(args))
+	}
+	observeQuery(s.query, started, err)
+	return result, err
+}
+
+func (s *timedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	started := time.Now()
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedToValues(args))
+	}
+	observeQuery(s.query, started, err)
+	return rows, err
+}
+
+func namedToValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
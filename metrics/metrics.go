@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// every metric's name starts with this
+var namespace = "delivery"
+
+// Outcomes of a contract operation or a database query
+const (
+	OutcomeOk = "ok"
+	// the contract rejected the transaction
+	OutcomeReverted = "reverted"
+	OutcomeError    = "error"
+)
+
+// Outcomes of waiting for a transaction to be mined
+const (
+	OutcomeMined = "mined"
+	// the transaction wasn't mined before we stopped waiting. It may still be mined later.
+	OutcomeTimeout = "timeout"
+)
+
+// blocks come every few seconds, and waiting for one can take up to half a minute
+var chainBuckets = []float64{0.1, 0.25, 0.5, 1, 2, 4, 8, 15, 30, 60}
+
+var dbBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+var httpRequests = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "http_request_duration_seconds",
+	Help:      "How long the REST API took to answer, by route and status",
+	Buckets:   chainBuckets,
+}, []string{"method", "route", "status"})
+
+var contractOperations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "contract_operation_duration_seconds",
+	Help:      "How long it took to send a transaction to the delivery contract, by operation and outcome",
+	Buckets:   chainBuckets,
+}, []string{"operation", "outcome"})
+
+var timeToMine = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "transaction_mine_duration_seconds",
+	Help:      "How long we waited for a transaction to be mined, by outcome",
+	Buckets:   chainBuckets,
+}, []string{"outcome"})
+
+var dbQueries = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "db_query_duration_seconds",
+	Help:      "How long database statements took, by table, kind of statement and outcome",
+	Buckets:   dbBuckets,
+}, []string{"table", "statement", "outcome"})
+
+var nonceConflicts = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "nonce_conflicts_total",
+	Help:      "Transactions the node turned away because their nonce was already used. The outbox sends them again with a fresh nonce.",
+}, []string{"operation"})
+
+// The gin middleware that times each request. Requests are labelled by the route they matched rather than
+// their path, so that order IDs don't each get their own series.
+func Middleware(ctx *gin.Context) {
+	started := time.Now()
+	ctx.Next()
+
+	route := ctx.FullPath()
+	if len(route) == 0 {
+		route = "unmatched"
+	}
+	httpRequests.WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).
+		Observe(time.Since(started).Seconds())
+}
+
+// Records how long a contract operation (mint, pay, buy, burn, deploy or transfer) took and how it went. reverted tells
+// whether err means the contract rejected the transaction.
+func ObserveContractOperation(operation string, started time.Time, err error, reverted bool) {
+	outcome := OutcomeOk
+	if reverted {
+		outcome = OutcomeReverted
+	} else if err != nil {
+		outcome = OutcomeError
+		if isNonceConflict(err) {
+			nonceConflicts.WithLabelValues(operation).Inc()
+		}
+	}
+	contractOperations.WithLabelValues(operation, outcome).Observe(time.Since(started).Seconds())
+}
+
+// Records how long we waited for a transaction to be mined. outcome is OutcomeMined, OutcomeReverted or
+// OutcomeTimeout.
+func ObserveMining(started time.Time, outcome string) {
+	timeToMine.WithLabelValues(outcome).Observe(time.Since(started).Seconds())
+}
+
+// The node says this when a transaction reuses the nonce of one it has already seen, which happens when two
+// transactions from the same account are sent at once
+func isNonceConflict(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "nonce too low") ||
+		strings.Contains(message, "replacement transaction underpriced") ||
+		strings.Contains(message, "already known")
+}
+
+var reconciliationDiscrepancies = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "reconciliation_discrepancies",
+	Help:      "How many disagreements between the database and the chain the last reconciliation found, by vendor and kind",
+}, []string{"vendor", "kind"})
+
+var reconciliationFinished = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "reconciliation_last_run_timestamp_seconds",
+	Help:      "When the last reconciliation of each vendor finished, as a Unix time",
+}, []string{"vendor"})
+
+// Records what a reconciliation of the vendor's orders found, by kind of discrepancy. Every kind should be
+// included, so that ones that have been cleared go back to zero.
+func ObserveReconciliation(vendorId string, finished time.Time, discrepancies map[string]int) {
+	for kind, count := range discrepancies {
+		reconciliationDiscrepancies.WithLabelValues(vendorId, kind).Set(float64(count))
+	}
+	reconciliationFinished.WithLabelValues(vendorId).Set(float64(finished.Unix()))
+}
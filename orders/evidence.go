@@ -0,0 +1,85 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/tracing"
+)
+
+// Proof that an order's package was dropped off, for when the customer never accepts delivery. The vendor
+// needs it to claim the escrow once it expires; the hash is what goes on chain.
+type DeliveryEvidence struct {
+	OrderId string
+	// where the proof is kept, e.g. the courier's drop-off record or a photo's URL
+	Reference string
+	// the keccak256 hash of the reference, as hex
+	Hash string
+	// who recorded it: the caller's API key ID or address, or the vendor's address if the service did
+	RecordedBy string
+	RecordedAt time.Time
+}
+
+// Storage for delivery evidence
+type EvidenceRepository interface {
+	// Records the evidence for the order, replacing any that was recorded before
+	RecordDeliveryEvidence(ctx context.Context, evidence *DeliveryEvidence) error
+	// Returns the order's evidence, or nil if there isn't any
+	GetDeliveryEvidence(ctx context.Context, orderId string) (*DeliveryEvidence, error)
+}
+
+var evidenceTable = "delivery_evidence"
+var evidenceFields = "order_id, reference, evidence_hash, recorded_by, recorded_at"
+
+// Writes the evidence for the order. An order only has one piece of evidence; recording another replaces it.
+func (repo *MariaDBOrderRepository) RecordDeliveryEvidence(ctx context.Context, evidence *DeliveryEvidence) (err error) {
+	ctx, span := repo.startSpan(ctx, "RecordDeliveryEvidence", tracing.OrderId.String(evidence.OrderId))
+	defer tracing.End(span, &err)
+
+	if evidence.RecordedAt.IsZero() {
+		evidence.RecordedAt = time.Now().UTC()
+	}
+
+	query := fmt.Sprintf(
+		"insert into %s (%s) values (?, ?, ?, ?, ?) on duplicate key update "+
+			"reference = values(reference), evidence_hash = values(evidence_hash), "+
+			"recorded_by = values(recorded_by), recorded_at = values(recorded_at)",
+		evidenceTable, evidenceFields)
+	_, err = repo.conn.ExecContext(ctx, query,
+		evidence.OrderId,
+		evidence.Reference,
+		evidence.Hash,
+		evidence.RecordedBy,
+		evidence.RecordedAt)
+	return err
+}
+
+// Returns the evidence recorded for the order. If there is none, then nil.
+func (repo *MariaDBOrderRepository) GetDeliveryEvidence(ctx context.Context, orderId string) (_ *DeliveryEvidence, err error) {
+	ctx, span := repo.startSpan(ctx, "GetDeliveryEvidence", tracing.OrderId.String(orderId))
+	defer tracing.End(span, &err)
+
+	query := fmt.Sprintf("select %s from %s where order_id = ?", evidenceFields, evidenceTable)
+	results, err := repo.runQuery(ctx, query, orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	if !results.Next() {
+		return nil, nil
+	}
+
+	var evidence DeliveryEvidence
+	err = results.Scan(
+		&evidence.OrderId,
+		&evidence.Reference,
+		&evidence.Hash,
+		&evidence.RecordedBy,
+		&evidence.RecordedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &evidence, nil
+}
@@ -0,0 +1,239 @@
+package orders
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The fields that a list of orders can be sorted by
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "createdAt"
+	SortByPrice     SortField = "price"
+)
+
+// the column behind each sort field
+var sortColumns = map[SortField]string{
+	SortByCreatedAt: "created_at",
+	SortByPrice:     "price",
+}
+
+// Filters, sorting and paging for listing orders. Empty fields don't filter anything.
+type OrderQuery struct {
+	BuyerAddress string
+	// orders for any of these addresses
+	BuyerAddresses []string
+	TokenAddress   string
+	ItemId         string
+	VendorId       string
+	Statuses       []OrderStatus
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+
+	SortBy     SortField
+	Descending bool
+	// the maximum number of orders to return
+	Limit int
+	// where the previous page left off. Nil for the first page.
+	After *OrderCursor
+}
+
+// Marks a position in a sorted list of orders. The order ID breaks ties between orders
+// with the same sort value so that no order is skipped or repeated between pages.
+type OrderCursor struct {
+	SortBy     SortField `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	// a hash of the query's filters, so that the cursor can't be used to page through a different list
+	Filters   string `json:"f"`
+	SortValue string `json:"v"`
+	OrderId   string `json:"o"`
+}
+
+// Builds a cursor that points just past the given order in the query's results
+func NewOrderCursor(order *Order, query *OrderQuery) *OrderCursor {
+	sortBy := query.sortField()
+	cursor := &OrderCursor{
+		SortBy:     sortBy,
+		Descending: query.Descending,
+		Filters:    query.filterHash(),
+		OrderId:    order.OrderId,
+	}
+	if sortBy == SortByPrice {
+		cursor.SortValue = strconv.FormatInt(order.Price, 10)
+	} else {
+		cursor.SortValue = order.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+// Encodes the cursor into an opaque string that can be handed to clients
+func (cursor *OrderCursor) Encode() string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Decodes a cursor that was produced by Encode
+func DecodeOrderCursor(encoded string) (*OrderCursor, error) {
+	invalid := errors.New("invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var cursor OrderCursor
+	if err = json.Unmarshal(raw, &cursor); err != nil {
+		return nil, invalid
+	}
+	if _, ok := sortColumns[cursor.SortBy]; !ok || len(cursor.OrderId) == 0 || len(cursor.Filters) == 0 {
+		return nil, invalid
+	}
+	if _, err = cursor.sortArg(); err != nil {
+		return nil, invalid
+	}
+	return &cursor, nil
+}
+
+// Converts the sort value back into something the database can compare against
+func (cursor *OrderCursor) sortArg() (interface{}, error) {
+	if cursor.SortBy == SortByPrice {
+		return strconv.ParseInt(cursor.SortValue, 10, 64)
+	}
+	return time.Parse(time.RFC3339Nano, cursor.SortValue)
+}
+
+// The field the query sorts by, which is the creation time unless it says otherwise
+func (query *OrderQuery) sortField() SortField {
+	if query.SortBy == "" {
+		return SortByCreatedAt
+	}
+	return query.SortBy
+}
+
+// Hashes the filters, ignoring the order that addresses and statuses were given in
+func (query *OrderQuery) filterHash() string {
+	buyerAddresses := append([]string{}, query.BuyerAddresses...)
+	sort.Strings(buyerAddresses)
+	statuses := []string{}
+	for _, status := range query.Statuses {
+		statuses = append(statuses, string(status))
+	}
+	sort.Strings(statuses)
+
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	raw, _ := json.Marshal([]interface{}{
+		query.BuyerAddress, buyerAddresses, query.TokenAddress, query.ItemId, query.VendorId, statuses,
+		formatTime(query.CreatedAfter), formatTime(query.CreatedBefore),
+	})
+	hash := sha256.Sum256(raw)
+	return hex.EncodeToString(hash[:8])
+}
+
+// Checks that the cursor, if there is one, came from a page of the same list: one sorted the same way, in the same
+// direction, with the same filters. Paging on from somewhere in a different list would skip or repeat orders.
+func (query *OrderQuery) CheckCursor() error {
+	if query.After == nil {
+		return nil
+	}
+	if query.After.SortBy != query.sortField() || query.After.Descending != query.Descending {
+		return errors.New("cursor was created with a different sort order")
+	}
+	if query.After.Filters != query.filterHash() {
+		return errors.New("cursor was created with different filters")
+	}
+	return nil
+}
+
+// Builds the where clause, order by clause and arguments for the query
+func (query *OrderQuery) toSql() (string, []interface{}, error) {
+	sortBy := query.sortField()
+	sortColumn, ok := sortColumns[sortBy]
+	if !ok {
+		return "", nil, errors.New(fmt.Sprintf("cannot sort by [%s]", sortBy))
+	}
+
+	conditions := []string{}
+	args := []interface{}{}
+
+	addCondition := func(condition string, values ...interface{}) {
+		conditions = append(conditions, condition)
+		args = append(args, values...)
+	}
+
+	if len(query.BuyerAddress) != 0 {
+		addCondition("buyer_address = ?", query.BuyerAddress)
+	}
+	if len(query.BuyerAddresses) != 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(query.BuyerAddresses)), ", ")
+		values := []interface{}{}
+		for _, address := range query.BuyerAddresses {
+			values = append(values, address)
+		}
+		addCondition(fmt.Sprintf("buyer_address in (%s)", placeholders), values...)
+	}
+	if len(query.TokenAddress) != 0 {
+		addCondition("token_address = ?", query.TokenAddress)
+	}
+	if len(query.ItemId) != 0 {
+		addCondition("item_id = ?", query.ItemId)
+	}
+	if len(query.VendorId) != 0 {
+		addCondition("vendor_id = ?", query.VendorId)
+	}
+	if len(query.Statuses) != 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(query.Statuses)), ", ")
+		values := []interface{}{}
+		for _, status := range query.Statuses {
+			values = append(values, status)
+		}
+		addCondition(fmt.Sprintf("status in (%s)", placeholders), values...)
+	}
+	if !query.CreatedAfter.IsZero() {
+		addCondition("created_at >= ?", query.CreatedAfter)
+	}
+	if !query.CreatedBefore.IsZero() {
+		addCondition("created_at < ?", query.CreatedBefore)
+	}
+
+	direction := "asc"
+	comparison := ">"
+	if query.Descending {
+		direction = "desc"
+		comparison = "<"
+	}
+
+	if query.After != nil {
+		if err := query.CheckCursor(); err != nil {
+			return "", nil, err
+		}
+		sortValue, err := query.After.sortArg()
+		if err != nil {
+			return "", nil, err
+		}
+		addCondition(
+			fmt.Sprintf("(%s %s ? or (%s = ? and order_id %s ?))", sortColumn, comparison, sortColumn, comparison),
+			sortValue, sortValue, query.After.OrderId)
+	}
+
+	where := ""
+	if len(conditions) != 0 {
+		where = "where " + strings.Join(conditions, " and ")
+	}
+
+	orderBy := fmt.Sprintf("order by %s %s, order_id %s", sortColumn, direction, direction)
+	return fmt.Sprintf("%s %s limit %d", where, orderBy, query.Limit+1), args, nil
+}
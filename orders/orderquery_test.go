@@ -0,0 +1,196 @@
+package orders
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var createdAt = time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+
+func testOrder(orderId string) *Order {
+	return &Order{OrderId: orderId, Price: 51000, CreatedAt: createdAt}
+}
+
+func TestOrderCursorRoundTrip(t *testing.T) {
+	queries := []*OrderQuery{
+		{},
+		{SortBy: SortByPrice, Descending: true},
+		{BuyerAddress: "0x70997970C51812dc3A010C7d01b50e0d17dc79C8", Statuses: []OrderStatus{StatusMinted}},
+	}
+
+	for _, query := range queries {
+		cursor := NewOrderCursor(testOrder("order-1"), query)
+		decoded, err := DecodeOrderCursor(cursor.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, cursor) {
+			t.Errorf("expected %+v, got %+v", cursor, decoded)
+		}
+		query.After = decoded
+		if err = query.CheckCursor(); err != nil {
+			t.Errorf("expected the cursor to carry on the list it came from, got %v", err)
+		}
+	}
+
+	// the creation time keeps its nanoseconds, or orders created in the same second would be skipped
+	cursor := NewOrderCursor(testOrder("order-1"), &OrderQuery{})
+	if cursor.SortValue != "2024-03-01T12:30:00.123456789Z" {
+		t.Errorf("expected the exact creation time, got %s", cursor.SortValue)
+	}
+	if cursor = NewOrderCursor(testOrder("order-1"), &OrderQuery{SortBy: SortByPrice}); cursor.SortValue != "51000" {
+		t.Errorf("expected the price, got %s", cursor.SortValue)
+	}
+}
+
+func TestDecodeOrderCursorErrors(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"that isn't base64", "not a cursor!"},
+		{"that isn't JSON", encode("not json")},
+		{"with an unknown sort field", encode(`{"s":"name","f":"abc","v":"socks","o":"order-1"}`)},
+		{"without an order ID", encode(`{"s":"price","f":"abc","v":"100"}`)},
+		{"without the filters", encode(`{"s":"price","v":"100","o":"order-1"}`)},
+		{"with a price that isn't a number", encode(`{"s":"price","f":"abc","v":"cheap","o":"order-1"}`)},
+		{"with a time that isn't a time", encode(`{"s":"createdAt","f":"abc","v":"yesterday","o":"order-1"}`)},
+		{"that is empty", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if cursor, err := DecodeOrderCursor(test.encoded); err == nil {
+				t.Errorf("expected the cursor to be refused, got %+v", cursor)
+			}
+		})
+	}
+}
+
+func TestCheckCursor(t *testing.T) {
+	buyer := "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+	original := OrderQuery{
+		BuyerAddresses: []string{buyer, "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"},
+		Statuses:       []OrderStatus{StatusMinted, StatusDelivered},
+		CreatedAfter:   createdAt,
+		SortBy:         SortByPrice,
+		Limit:          10,
+	}
+	cursor := NewOrderCursor(testOrder("order-1"), &original)
+
+	tests := []struct {
+		name string
+		// changes the original query
+		change   func(query *OrderQuery)
+		expected string
+	}{
+		{"that is unchanged", func(query *OrderQuery) {}, ""},
+		{"with a different limit", func(query *OrderQuery) { query.Limit = 50 }, ""},
+		{"with the addresses in another order", func(query *OrderQuery) {
+			query.BuyerAddresses = []string{query.BuyerAddresses[1], query.BuyerAddresses[0]}
+		}, ""},
+		{"with the statuses in another order", func(query *OrderQuery) {
+			query.Statuses = []OrderStatus{StatusDelivered, StatusMinted}
+		}, ""},
+		{"with the time in another zone", func(query *OrderQuery) {
+			query.CreatedAfter = createdAt.In(time.FixedZone("EST", -5*60*60))
+		}, ""},
+		{"sorted by something else", func(query *OrderQuery) { query.SortBy = SortByCreatedAt }, "sort order"},
+		{"sorted the other way", func(query *OrderQuery) { query.Descending = true }, "sort order"},
+		{"with another buyer", func(query *OrderQuery) { query.BuyerAddresses = []string{buyer} }, "filters"},
+		{"with a single buyer instead", func(query *OrderQuery) {
+			query.BuyerAddresses = nil
+			query.BuyerAddress = buyer
+		}, "filters"},
+		{"with another status", func(query *OrderQuery) { query.Statuses = []OrderStatus{StatusMinted} }, "filters"},
+		{"with an item", func(query *OrderQuery) { query.ItemId = "socks" }, "filters"},
+		{"with a vendor", func(query *OrderQuery) { query.VendorId = "acme" }, "filters"},
+		{"with a token", func(query *OrderQuery) { query.TokenAddress = buyer }, "filters"},
+		{"created after another time", func(query *OrderQuery) { query.CreatedAfter = createdAt.Add(time.Second) }, "filters"},
+		{"created before a time", func(query *OrderQuery) { query.CreatedBefore = createdAt }, "filters"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := original
+			test.change(&query)
+			query.After = cursor
+
+			err := query.CheckCursor()
+			if len(test.expected) == 0 && err != nil {
+				t.Errorf("expected the cursor to be accepted, got %v", err)
+			} else if len(test.expected) != 0 && (err == nil || !strings.Contains(err.Error(), test.expected)) {
+				t.Errorf("expected the cursor to be refused for its %s, got %v", test.expected, err)
+			}
+
+			// the database mustn't be asked for a page of a different list either
+			if _, _, sqlErr := query.toSql(); (sqlErr == nil) != (err == nil) {
+				t.Errorf("expected toSql to agree with CheckCursor [%v], got %v", err, sqlErr)
+			}
+		})
+	}
+}
+
+func TestToSqlBreaksTiesOnTheOrderId(t *testing.T) {
+	tests := []struct {
+		name       string
+		descending bool
+		clause     string
+		orderBy    string
+	}{
+		{"ascending", false, "(created_at > ? or (created_at = ? and order_id > ?))", "order by created_at asc, order_id asc"},
+		{"descending", true, "(created_at < ? or (created_at = ? and order_id < ?))", "order by created_at desc, order_id desc"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := &OrderQuery{VendorId: "default", Descending: test.descending, Limit: 2}
+			query.After = NewOrderCursor(testOrder("order-2"), query)
+
+			sql, args, err := query.toSql()
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := "where vendor_id = ? and " + test.clause + " " + test.orderBy + " limit 3"
+			if sql != expected {
+				t.Errorf("expected [%s], got [%s]", expected, sql)
+			}
+			// orders created at the same moment as the cursor's are only skipped up to and including its own
+			expectedArgs := []interface{}{"default", createdAt, createdAt, "order-2"}
+			if !reflect.DeepEqual(args, expectedArgs) {
+				t.Errorf("expected %v, got %v", expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestToSqlWithoutACursor(t *testing.T) {
+	query := &OrderQuery{
+		BuyerAddresses: []string{"0xa", "0xb"},
+		Statuses:       []OrderStatus{StatusMinted},
+		SortBy:         SortByPrice,
+		Limit:          20,
+	}
+	sql, args, err := query.toSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "where buyer_address in (?, ?) and status in (?) order by price asc, order_id asc limit 21"
+	if sql != expected {
+		t.Errorf("expected [%s], got [%s]", expected, sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"0xa", "0xb", StatusMinted}) {
+		t.Errorf("expected the addresses and status, got %v", args)
+	}
+
+	if _, _, err = (&OrderQuery{SortBy: "name"}).toSql(); err == nil {
+		t.Error("expected an unknown sort field to be refused")
+	}
+}
@@ -1,13 +1,18 @@
 package orders
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"strings"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/bdunton9323/blockchain-playground/webhooks"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // A DTO object representing a row in the database
@@ -19,63 +24,343 @@ type Order struct {
 	DeliveryPrice int64
 	TokenAddress  string
 	TokenId       int64
-	Delivered     bool
+	Status        OrderStatus
+	// the ethereum address of the customer who is allowed to accept delivery, as a hex string
+	BuyerAddress string
+	// the vendor selling the item, whose contract the delivery token is minted in
+	VendorId  string
+	CreatedAt time.Time
+}
+
+// A DTO object representing a row in the status history table. Every time an order changes
+// status, one of these is recorded.
+type StatusChange struct {
+	OrderId string
+	// empty when the order was first created
+	FromStatus OrderStatus
+	ToStatus   OrderStatus
+	// the ethereum address of whoever caused the change, as a hex string
+	ActorAddress string
+	// the hash of the transaction that caused the change, if it happened on chain
+	TxHash    string
+	ChangedAt time.Time
 }
 
 type OrderRepository interface {
-	GetOrder(orderId string) (*Order, error)
-	CreateOrder(order *Order) (string, error)
-	MarkOrderDelivered(orderId string) error
+	OutboxRepository
+	EvidenceRepository
+
+	GetOrder(ctx context.Context, orderId string) (*Order, error)
+	CreateOrder(ctx context.Context, order *Order, actorAddress string) error
+	TransitionOrder(ctx context.Context, orderId string, status OrderStatus, actorAddress string, txHash string) error
+	GetStatusHistory(ctx context.Context, orderId string) ([]*StatusChange, error)
+	ListOrders(ctx context.Context, query *OrderQuery) ([]*Order, *OrderCursor, error)
+	RepairOrder(ctx context.Context, repair *Repair) error
 }
 
+// Makes an order match what the chain says happened to it
+type Repair struct {
+	OrderId string
+	// the status the order was seen in. The repair is refused if it has moved since.
+	FromStatus OrderStatus
+	ToStatus   OrderStatus
+	// the order's delivery token, if it is changing
+	TokenAddress string
+	TokenId      int64
+	// the ethereum address recorded as making the change, as a hex string
+	ActorAddress string
+}
+
+// Returned when a repair finds the order in a different status from the one it was based on
+var ErrOrderChanged = errors.New("the order changed while it was being checked")
+
 type MariaDBOrderRepository struct {
 	OrderRepository
 
-	host     string
-	dbName   string
-	username string
-	password string
-	conn     *sql.DB
+	// the database's name, for tracing
+	dbName string
+	conn   *sql.DB
 }
 
 var ordersTable = "orders"
-var allFields = "order_id, item_id, item_name, price, delivery_price, token_address, token_id, delivered"
+var historyTable = "order_status_history"
+var allFields = "order_id, item_id, item_name, price, delivery_price, token_address, token_id, status, buyer_address, vendor_id, created_at"
+var historyFields = "order_id, from_status, to_status, actor_address, tx_hash, changed_at"
 
-// Construct a new repository connected to MariaDB
-func NewMariaDBOrderRepository(host string, dbName string, username string, password string) (*MariaDBOrderRepository, error) {
-	r := new(MariaDBOrderRepository)
-	r.host = host
-	r.dbName = dbName
-	r.username = username
-	r.password = password
+// Construct a new repository that uses the given connection pool to MariaDB. dbName is only for tracing.
+func NewMariaDBOrderRepository(db *sql.DB, dbName string) *MariaDBOrderRepository {
+	return &MariaDBOrderRepository{dbName: dbName, conn: db}
+}
 
-	connUrl := fmt.Sprintf("%s:%s@tcp(%s)/%s", username, password, host, dbName)
+// Checks that the database is still reachable
+func (repo *MariaDBOrderRepository) Ping(ctx context.Context) error {
+	return repo.conn.PingContext(ctx)
+}
+
+// Returns the order with the given ID from the database. If not found, then nil.
+func (repo *MariaDBOrderRepository) GetOrder(ctx context.Context, orderId string) (_ *Order, err error) {
+	ctx, span := repo.startSpan(ctx, "GetOrder", tracing.OrderId.String(orderId))
+	defer tracing.End(span, &err)
 
-	db, err := sql.Open("mysql", connUrl)
+	query := fmt.Sprintf("select %s from %s where order_id = ?", allFields, ordersTable)
+
+	results, err := repo.runQuery(ctx, query, orderId)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("could not connect to database %s: %v", dbName, err.Error()))
+		return nil, err
+	}
+	defer results.Close()
+
+	if !results.Next() {
+		return nil, nil
 	}
-	r.conn = db
-	return r, nil
+
+	return scanOrder(results)
 }
 
-// Returns the order with the given ID from the database. If not found, then nil.
-func (repo *MariaDBOrderRepository) GetOrder(orderId string) (*Order, error) {
-	// I know this is vulnerable to SQL injection, but it's fine for a demo
-	query := fmt.Sprintf("select %s from %s where order_id = '%s'",
-		allFields, ordersTable, orderId)
+// Writes the given order to the database, along with the first entry in its status history.
+func (repo *MariaDBOrderRepository) CreateOrder(ctx context.Context, order *Order, actorAddress string) (err error) {
+	ctx, span := repo.startSpan(ctx, "CreateOrder", tracing.OrderId.String(order.OrderId))
+	defer tracing.End(span, &err)
+
+	return repo.inTransaction(ctx, func(tx *sql.Tx) error {
+		return insertOrder(ctx, tx, order, actorAddress)
+	})
+}
 
-	results, err := repo.runQuery(query)
+// Moves the order to the given status and records the change in the status history. Returns an
+// InvalidTransitionError if the order's current status does not allow it.
+func (repo *MariaDBOrderRepository) TransitionOrder(
+	ctx context.Context,
+	orderId string,
+	status OrderStatus,
+	actorAddress string,
+	txHash string,
+) (err error) {
+	ctx, span := repo.startSpan(ctx, "TransitionOrder", tracing.OrderId.String(orderId))
+	defer tracing.End(span, &err)
+
+	return repo.inTransaction(ctx, func(tx *sql.Tx) error {
+		return transition(ctx, tx, orderId, status, actorAddress, txHash)
+	})
+}
+
+// Returns every status change the order has gone through, oldest first
+func (repo *MariaDBOrderRepository) GetStatusHistory(ctx context.Context, orderId string) (_ []*StatusChange, err error) {
+	ctx, span := repo.startSpan(ctx, "GetStatusHistory", tracing.OrderId.String(orderId))
+	defer tracing.End(span, &err)
+
+	query := fmt.Sprintf("select %s from %s where order_id = ? order by id", historyFields, historyTable)
+
+	results, err := repo.runQuery(ctx, query, orderId)
 	if err != nil {
 		return nil, err
 	}
+	defer results.Close()
+
+	history := []*StatusChange{}
+	for results.Next() {
+		var change StatusChange
+		var fromStatus sql.NullString
+		err = results.Scan(
+			&change.OrderId,
+			&fromStatus,
+			&change.ToStatus,
+			&change.ActorAddress,
+			&change.TxHash,
+			&change.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		change.FromStatus = OrderStatus(fromStatus.String)
+		history = append(history, &change)
+	}
+
+	return history, results.Err()
+}
+
+// Returns one page of the orders that match the query, along with a cursor pointing at the
+// next page. The cursor is nil when there are no more orders.
+func (repo *MariaDBOrderRepository) ListOrders(ctx context.Context, query *OrderQuery) (_ []*Order, _ *OrderCursor, err error) {
+	ctx, span := repo.startSpan(ctx, "ListOrders")
+	defer tracing.End(span, &err)
+
+	clauses, args, err := query.toSql()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results, err := repo.runQuery(ctx, fmt.Sprintf("select %s from %s %s", allFields, ordersTable, clauses), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer results.Close()
+
+	page := []*Order{}
+	for results.Next() {
+		order, err := scanOrder(results)
+		if err != nil {
+			return nil, nil, err
+		}
+		page = append(page, order)
+	}
+	if err = results.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// one extra row was requested to find out whether there is another page
+	if len(page) <= query.Limit {
+		return page, nil, nil
+	}
+	page = page[:query.Limit]
+	return page, NewOrderCursor(page[len(page)-1], query), nil
+}
+
+// Applies the repair and records the change in the status history, without going through the state machine:
+// the chain has already moved the order on, possibly by more than one step. Returns ErrOrderChanged if the
+// order is no longer in repair.FromStatus.
+func (repo *MariaDBOrderRepository) RepairOrder(ctx context.Context, repair *Repair) (err error) {
+	ctx, span := repo.startSpan(ctx, "RepairOrder", tracing.OrderId.String(repair.OrderId))
+	defer tracing.End(span, &err)
+
+	return repo.inTransaction(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("select status from %s where order_id = ? for update", ordersTable)
+		var status OrderStatus
+		err := tx.QueryRowContext(ctx, query, repair.OrderId).Scan(&status)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(fmt.Sprintf("order [%s] does not exist", repair.OrderId))
+		} else if err != nil {
+			return err
+		} else if status != repair.FromStatus {
+			return ErrOrderChanged
+		}
+
+		if repair.TokenId != 0 {
+			query = fmt.Sprintf("update %s set token_address = ?, token_id = ? where order_id = ?", ordersTable)
+			_, err = tx.ExecContext(ctx, query, repair.TokenAddress, repair.TokenId, repair.OrderId)
+			if err != nil {
+				return err
+			}
+		}
+		if repair.ToStatus == repair.FromStatus {
+			return nil
+		}
+
+		query = fmt.Sprintf("update %s set status = ? where order_id = ?", ordersTable)
+		_, err = tx.ExecContext(ctx, query, repair.ToStatus, repair.OrderId)
+		if err != nil {
+			return err
+		}
+
+		log.Warnf("Order [%s] repaired from [%s] to [%s] to match the chain", repair.OrderId, repair.FromStatus, repair.ToStatus)
+		return insertStatusChange(ctx, tx, &StatusChange{
+			OrderId:      repair.OrderId,
+			FromStatus:   repair.FromStatus,
+			ToStatus:     repair.ToStatus,
+			ActorAddress: repair.ActorAddress,
+		})
+	})
+}
+
+func insertOrder(ctx context.Context, tx *sql.Tx, order *Order, actorAddress string) error {
+	if order.Status == "" {
+		order.Status = StatusCreated
+	}
+	if order.CreatedAt.IsZero() {
+		order.CreatedAt = time.Now().UTC()
+	}
+
+	query := fmt.Sprintf("insert into %s (%s) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", ordersTable, allFields)
+	_, err := tx.ExecContext(ctx, query,
+		order.OrderId,
+		order.ItemId,
+		order.ItemName,
+		order.Price,
+		order.DeliveryPrice,
+		order.TokenAddress,
+		order.TokenId,
+		order.Status,
+		order.BuyerAddress,
+		order.VendorId,
+		order.CreatedAt)
+	if err != nil {
+		log.Errorf("query returned error: %v", err)
+		return err
+	}
+
+	return insertStatusChange(ctx, tx, &StatusChange{
+		OrderId:      order.OrderId,
+		ToStatus:     order.Status,
+		ActorAddress: actorAddress,
+	})
+}
+
+// Locks the order's row, checks the state machine, and applies the new status
+func transition(ctx context.Context, tx *sql.Tx, orderId string, status OrderStatus, actorAddress string, txHash string) error {
+	query := fmt.Sprintf("select %s from %s where order_id = ? for update", allFields, ordersTable)
+	results, err := tx.QueryContext(ctx, query, orderId)
+	if err != nil {
+		return err
+	}
 
 	if !results.Next() {
-		return nil, nil
+		results.Close()
+		return errors.New(fmt.Sprintf("order [%s] does not exist", orderId))
+	}
+	order, err := scanOrder(results)
+	results.Close()
+	if err != nil {
+		return err
+	}
+
+	err = order.ValidateTransition(status)
+	if err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf("update %s set status = ? where order_id = ?", ordersTable)
+	_, err = tx.ExecContext(ctx, query, status, orderId)
+	if err != nil {
+		return err
 	}
 
+	log.Infof("Order [%s] moved from [%s] to [%s]", orderId, order.Status, status)
+	return insertStatusChange(ctx, tx, &StatusChange{
+		OrderId:      orderId,
+		FromStatus:   order.Status,
+		ToStatus:     status,
+		ActorAddress: actorAddress,
+		TxHash:       txHash,
+	})
+}
+
+func insertStatusChange(ctx context.Context, tx *sql.Tx, change *StatusChange) error {
+	var fromStatus sql.NullString
+	if change.FromStatus != "" {
+		fromStatus = sql.NullString{String: string(change.FromStatus), Valid: true}
+	}
+
+	query := fmt.Sprintf(
+		"insert into %s (order_id, from_status, to_status, actor_address, tx_hash) values (?, ?, ?, ?, ?)",
+		historyTable)
+	_, err := tx.ExecContext(ctx, query, change.OrderId, fromStatus, change.ToStatus, change.ActorAddress, change.TxHash)
+	if err != nil {
+		return err
+	}
+
+	// let subscribers know, as part of the same transaction so the news can't get lost
+	return webhooks.EnqueueEvent(ctx, tx, &webhooks.Event{
+		Type:         webhooks.EventTypeForStatus(string(change.ToStatus)),
+		OrderId:      change.OrderId,
+		FromStatus:   string(change.FromStatus),
+		ToStatus:     string(change.ToStatus),
+		ActorAddress: change.ActorAddress,
+		TxHash:       change.TxHash,
+	})
+}
+
+func scanOrder(results *sql.Rows) (*Order, error) {
 	var order Order
-	err = results.Scan(
+	err := results.Scan(
 		&order.OrderId,
 		&order.ItemId,
 		&order.ItemName,
@@ -83,58 +368,43 @@ func (repo *MariaDBOrderRepository) GetOrder(orderId string) (*Order, error) {
 		&order.DeliveryPrice,
 		&order.TokenAddress,
 		&order.TokenId,
-		&order.Delivered)
+		&order.Status,
+		&order.BuyerAddress,
+		&order.VendorId,
+		&order.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
-
 	return &order, nil
 }
 
-// Writes the given order to the database.
-func (repo *MariaDBOrderRepository) CreateOrder(order *Order) error {
-	values := []string{
-		fmt.Sprint(order.OrderId),
-		order.ItemId,
-		order.ItemName,
-		fmt.Sprint(order.Price),
-		fmt.Sprint(order.DeliveryPrice),
-		order.TokenAddress,
-		fmt.Sprint(order.TokenId),
-		func() string {
-			if order.Delivered {
-				return "1"
-			} else {
-				return "0"
-			}
-		}(),
-	}
-
-	query := fmt.Sprintf("insert into orders (%s) values('%s')",
-		allFields, strings.Join(values, "','"))
-
-	insert, err := repo.runQuery(query)
+// Runs the given function in a database transaction, committing if it succeeds and rolling back otherwise
+func (repo *MariaDBOrderRepository) inTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := repo.conn.BeginTx(ctx, nil)
 	if err != nil {
-		log.Errorf("query returned error: %v", err)
 		return err
 	}
-	insert.Close()
-
-	return nil
-}
 
-// Sets the 'delivered' field for the given order ID. Returns an error if the order doesn't exist.
-func (repo *MariaDBOrderRepository) MarkOrderDelivered(orderId string) error {
-	query := fmt.Sprintf("update orders set delivered = '1' where order_id = '%s'", orderId)
-	_, err := repo.runQuery(query)
+	err = fn(tx)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
-	return nil
+	return tx.Commit()
 }
 
 // Runs the given query against the database
-func (repo *MariaDBOrderRepository) runQuery(query string) (*sql.Rows, error) {
+func (repo *MariaDBOrderRepository) runQuery(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 	log.Debugf("running query [%s]", query)
-	return repo.conn.Query(query)
+	return repo.conn.QueryContext(ctx, query, args...)
+}
+
+// Starts a span for one of the repository's methods. The statements it runs use the span's context.
+func (repo *MariaDBOrderRepository) startSpan(
+	ctx context.Context,
+	method string,
+	attributes ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	attributes = append(attributes, semconv.DBSystemMySQL, semconv.DBNameKey.String(repo.dbName))
+	return tracing.Start(ctx, "orders."+method, attributes...)
 }
@@ -0,0 +1,427 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	log "github.com/sirupsen/logrus"
+)
+
+// A chain operation that the service intends to perform on behalf of an order
+type Operation string
+
+const (
+	OperationMint    Operation = "mint"
+	OperationPay     Operation = "pay"
+	OperationDeliver Operation = "deliver"
+	OperationBurn    Operation = "burn"
+	// the vendor claims an expired escrow for an order with delivery evidence
+	OperationRelease Operation = "release"
+	// the vendor gives the customer their money back from an expired escrow
+	OperationRefund Operation = "refund"
+	// the customer takes their money back from an expired escrow themselves
+	OperationReclaim Operation = "reclaim"
+)
+
+// The status each operation moves the order into once it is mined
+var operationResults = map[Operation]OrderStatus{
+	OperationMint:    StatusMinted,
+	OperationPay:     StatusPaid,
+	OperationDeliver: StatusDelivered,
+	OperationBurn:    StatusBurned,
+	OperationRelease: StatusReleased,
+	OperationRefund:  StatusRefunded,
+	OperationReclaim: StatusRefunded,
+}
+
+// Whether the customer, rather than the vendor, has to sign the operation's transaction.
+// The customer's key is never stored, so these can only be submitted while the request
+// that carried the key is still being handled.
+func (op Operation) SignedByCustomer() bool {
+	return op == OperationPay || op == OperationDeliver || op == OperationReclaim
+}
+
+// The status the order moves into once the operation is mined
+func (op Operation) ResultingStatus() OrderStatus {
+	return operationResults[op]
+}
+
+// Where an outbox entry is in its lifecycle
+type OutboxStatus string
+
+const (
+	// recorded but no transaction has been sent yet
+	OutboxPending OutboxStatus = "pending"
+	// the transaction was sent and we are waiting for it to be mined
+	OutboxSubmitted OutboxStatus = "submitted"
+	// the transaction was mined and the order was updated
+	OutboxCompleted OutboxStatus = "completed"
+	// gave up on the operation
+	OutboxFailed OutboxStatus = "failed"
+)
+
+// A DTO object representing a row in the outbox table
+type OutboxEntry struct {
+	Id        int64
+	OrderId   string
+	Operation Operation
+	Status    OutboxStatus
+	// the ethereum address of whoever the operation is performed on behalf of, as a hex string
+	ActorAddress string
+	// set once the transaction has been sent
+	TxHash        string
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	// the W3C traceparent of the request that recorded the entry. Later attempts are traced as part of it.
+	TraceParent string
+}
+
+// Whether the entry is finished, one way or the other
+func (entry *OutboxEntry) IsDone() bool {
+	return entry.Status == OutboxCompleted || entry.Status == OutboxFailed
+}
+
+// Storage for the outbox. The outbox lives next to the orders so that an order change and the
+// chain operation it calls for are written in the same transaction.
+type OutboxRepository interface {
+	// Writes the order along with an entry to mint its token
+	CreateOrderWithMint(ctx context.Context, order *Order, actorAddress string) (*OutboxEntry, error)
+	// Records the intent to perform an operation for an existing order
+	EnqueueOperation(ctx context.Context, orderId string, op Operation, actorAddress string) (*OutboxEntry, error)
+	GetOutboxEntry(ctx context.Context, id int64) (*OutboxEntry, error)
+	// Returns unfinished entries that are due for another attempt and aren't claimed
+	ListDueOutboxEntries(ctx context.Context, limit int) ([]*OutboxEntry, error)
+	// Counts the entries whose transaction hasn't been sent or hasn't been mined yet
+	CountUnfinishedOutboxEntries(ctx context.Context) (int, error)
+	// Whether an operation for the order hasn't finished yet, so the chain may not have caught up with it
+	HasUnfinishedOutboxEntry(ctx context.Context, orderId string) (bool, error)
+	// Takes an exclusive lease on the entry so that only one worker processes it at a time.
+	// New entries start out leased to whoever created them.
+	ClaimOutboxEntry(ctx context.Context, id int64) (bool, error)
+	ReleaseOutboxEntry(ctx context.Context, id int64) error
+	RecordSubmission(ctx context.Context, id int64, txHash string) error
+	// Marks the entry completed and applies its result to the order in the same transaction
+	CompleteOutboxEntry(ctx context.Context, entry *OutboxEntry, tokenAddress string, tokenId int64) error
+	// Records a failed attempt. If final, the entry is failed for good; otherwise it is retried at nextAttempt.
+	FailOutboxEntry(ctx context.Context, entry *OutboxEntry, cause error, final bool, nextAttempt time.Time) error
+}
+
+var outboxTable = "outbox"
+
+// Returned when an operation is enqueued for an order that already has one in flight
+var ErrOperationInProgress = errors.New("another operation on the order hasn't finished yet")
+var outboxFields = "id, order_id, operation, status, actor_address, tx_hash, attempts, last_error, next_attempt_at, created_at, trace_parent"
+
+// How long a worker may hold an entry before somebody else is allowed to pick it up. This needs to be
+// comfortably longer than it takes to send a transaction and wait for it to be mined.
+var OutboxLease = 2 * time.Minute
+
+// Writes the given order to the database in the 'created' status, along with an outbox entry to
+// mint its delivery token. Either both are written or neither is.
+func (repo *MariaDBOrderRepository) CreateOrderWithMint(
+	ctx context.Context,
+	order *Order,
+	actorAddress string,
+) (entry *OutboxEntry, err error) {
+	ctx, span := repo.startSpan(ctx, "CreateOrderWithMint", tracing.OrderId.String(order.OrderId))
+	defer tracing.End(span, &err)
+
+	err = repo.inTransaction(ctx, func(tx *sql.Tx) error {
+		err := insertOrder(ctx, tx, order, actorAddress)
+		if err != nil {
+			return err
+		}
+		entry, err = insertOutboxEntry(ctx, tx, order.OrderId, OperationMint, actorAddress)
+		return err
+	})
+	return entry, err
+}
+
+// Records that an operation should be performed for the order. Returns ErrOperationInProgress if an earlier
+// one hasn't finished yet.
+func (repo *MariaDBOrderRepository) EnqueueOperation(
+	ctx context.Context,
+	orderId string,
+	op Operation,
+	actorAddress string,
+) (entry *OutboxEntry, err error) {
+	ctx, span := repo.startSpan(ctx, "EnqueueOperation", tracing.OrderId.String(orderId))
+	defer tracing.End(span, &err)
+
+	err = repo.inTransaction(ctx, func(tx *sql.Tx) error {
+		// holding the order's row lock keeps two requests from both finding nothing in flight
+		query := fmt.Sprintf("select order_id from %s where order_id = ? for update", ordersTable)
+		var locked string
+		err := tx.QueryRowContext(ctx, query, orderId).Scan(&locked)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(fmt.Sprintf("order [%s] does not exist", orderId))
+		} else if err != nil {
+			return err
+		}
+
+		query = fmt.Sprintf("select count(*) from %s where order_id = ? and status in (?, ?)", outboxTable)
+		var unfinished int
+		err = tx.QueryRowContext(ctx, query, orderId, OutboxPending, OutboxSubmitted).Scan(&unfinished)
+		if err != nil {
+			return err
+		} else if unfinished != 0 {
+			return ErrOperationInProgress
+		}
+
+		entry, err = insertOutboxEntry(ctx, tx, orderId, op, actorAddress)
+		return err
+	})
+	return entry, err
+}
+
+// Returns the outbox entry with the given ID. If not found, then nil.
+func (repo *MariaDBOrderRepository) GetOutboxEntry(ctx context.Context, id int64) (_ *OutboxEntry, err error) {
+	ctx, span := repo.startSpan(ctx, "GetOutboxEntry")
+	defer tracing.End(span, &err)
+
+	query := fmt.Sprintf("select %s from %s where id = ?", outboxFields, outboxTable)
+	results, err := repo.runQuery(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	if !results.Next() {
+		return nil, nil
+	}
+	return scanOutboxEntry(results)
+}
+
+// Returns up to limit unfinished entries whose next attempt is due, oldest first
+func (repo *MariaDBOrderRepository) ListDueOutboxEntries(ctx context.Context, limit int) (_ []*OutboxEntry, err error) {
+	ctx, span := repo.startSpan(ctx, "ListDueOutboxEntries")
+	defer tracing.End(span, &err)
+
+	query := fmt.Sprintf(
+		"select %s from %s where status in (?, ?) and next_attempt_at <= ? "+
+			"and (locked_until is null or locked_until < ?) order by id limit %d",
+		outboxFields, outboxTable, limit)
+
+	now := time.Now().UTC()
+	results, err := repo.runQuery(ctx, query, OutboxPending, OutboxSubmitted, now, now)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	entries := []*OutboxEntry{}
+	for results.Next() {
+		entry, err := scanOutboxEntry(results)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, results.Err()
+}
+
+// Counts the entries that are still pending or submitted, whether or not they are due
+func (repo *MariaDBOrderRepository) CountUnfinishedOutboxEntries(ctx context.Context) (int, error) {
+	query := fmt.Sprintf("select count(*) from %s where status in (?, ?)", outboxTable)
+
+	var count int
+	err := repo.conn.QueryRowContext(ctx, query, OutboxPending, OutboxSubmitted).Scan(&count)
+	return count, err
+}
+
+// Whether the order has an entry that is still pending or submitted
+func (repo *MariaDBOrderRepository) HasUnfinishedOutboxEntry(ctx context.Context, orderId string) (_ bool, err error) {
+	ctx, span := repo.startSpan(ctx, "HasUnfinishedOutboxEntry", tracing.OrderId.String(orderId))
+	defer tracing.End(span, &err)
+
+	query := fmt.Sprintf("select count(*) from %s where order_id = ? and status in (?, ?)", outboxTable)
+
+	var count int
+	err = repo.conn.QueryRowContext(ctx, query, orderId, OutboxPending, OutboxSubmitted).Scan(&count)
+	return count != 0, err
+}
+
+// Takes a lease on the entry. Returns false if somebody else holds an unexpired lease or the entry is done.
+func (repo *MariaDBOrderRepository) ClaimOutboxEntry(ctx context.Context, id int64) (_ bool, err error) {
+	ctx, span := repo.startSpan(ctx, "ClaimOutboxEntry")
+	defer tracing.End(span, &err)
+
+	query := fmt.Sprintf(
+		"update %s set locked_until = ? where id = ? and status in (?, ?) "+
+			"and (locked_until is null or locked_until < ?)",
+		outboxTable)
+
+	now := time.Now().UTC()
+	result, err := repo.conn.ExecContext(ctx, query, now.Add(OutboxLease), id, OutboxPending, OutboxSubmitted, now)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := result.RowsAffected()
+	return claimed == 1, err
+}
+
+// Gives up the lease on the entry so that it can be picked up again right away
+func (repo *MariaDBOrderRepository) ReleaseOutboxEntry(ctx context.Context, id int64) (err error) {
+	ctx, span := repo.startSpan(ctx, "ReleaseOutboxEntry")
+	defer tracing.End(span, &err)
+
+	query := fmt.Sprintf("update %s set locked_until = null where id = ?", outboxTable)
+	_, err = repo.conn.ExecContext(ctx, query, id)
+	return err
+}
+
+// Records the hash of the transaction that was sent for the entry. From here on, a restart will wait
+// for this transaction rather than sending another one.
+func (repo *MariaDBOrderRepository) RecordSubmission(ctx context.Context, id int64, txHash string) (err error) {
+	ctx, span := repo.startSpan(ctx, "RecordSubmission", tracing.TxHash.String(txHash))
+	defer tracing.End(span, &err)
+
+	query := fmt.Sprintf("update %s set status = ?, tx_hash = ?, updated_at = ? where id = ?", outboxTable)
+	_, err = repo.conn.ExecContext(ctx, query, OutboxSubmitted, txHash, time.Now().UTC(), id)
+	return err
+}
+
+// Marks the entry completed and moves the order into the status that the operation results in.
+// For mints, the token details are recorded on the order too.
+func (repo *MariaDBOrderRepository) CompleteOutboxEntry(
+	ctx context.Context,
+	entry *OutboxEntry,
+	tokenAddress string,
+	tokenId int64,
+) (err error) {
+	ctx, span := repo.startSpan(ctx, "CompleteOutboxEntry",
+		tracing.OrderId.String(entry.OrderId), tracing.TokenId.Int64(tokenId), tracing.TxHash.String(entry.TxHash))
+	defer tracing.End(span, &err)
+
+	return repo.inTransaction(ctx, func(tx *sql.Tx) error {
+		lastError := ""
+
+		err := transition(ctx, tx, entry.OrderId, entry.Operation.ResultingStatus(), entry.ActorAddress, entry.TxHash)
+		var transitionErr *InvalidTransitionError
+		if errors.As(err, &transitionErr) {
+			// The order moved on while the operation was in flight (e.g. it was canceled while
+			// the token was being minted). What happened on chain still happened, so finish the
+			// entry and leave the discrepancy for somebody to look at.
+			log.Warnf("Operation [%s] for order [%s] finished but the order could not follow: %v",
+				entry.Operation, entry.OrderId, err)
+			lastError = err.Error()
+		} else if err != nil {
+			return err
+		}
+
+		if entry.Operation == OperationMint {
+			query := fmt.Sprintf("update %s set token_address = ?, token_id = ? where order_id = ?", ordersTable)
+			_, err = tx.ExecContext(ctx, query, tokenAddress, tokenId, entry.OrderId)
+			if err != nil {
+				return err
+			}
+		}
+
+		query := fmt.Sprintf(
+			"update %s set status = ?, last_error = ?, locked_until = null, updated_at = ? where id = ?",
+			outboxTable)
+		_, err = tx.ExecContext(ctx, query, OutboxCompleted, lastError, time.Now().UTC(), entry.Id)
+		if err == nil {
+			entry.Status = OutboxCompleted
+			entry.LastError = lastError
+		}
+		return err
+	})
+}
+
+// Records a failed attempt at the entry. A final failure of a mint also fails the order, since the
+// order can never move forward without its token.
+func (repo *MariaDBOrderRepository) FailOutboxEntry(
+	ctx context.Context,
+	entry *OutboxEntry,
+	cause error,
+	final bool,
+	nextAttempt time.Time,
+) (err error) {
+	ctx, span := repo.startSpan(ctx, "FailOutboxEntry", tracing.OrderId.String(entry.OrderId))
+	defer tracing.End(span, &err)
+
+	return repo.inTransaction(ctx, func(tx *sql.Tx) error {
+		status := entry.Status
+		if final {
+			status = OutboxFailed
+			if entry.Operation == OperationMint {
+				err := transition(ctx, tx, entry.OrderId, StatusFailed, entry.ActorAddress, entry.TxHash)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		query := fmt.Sprintf(
+			"update %s set status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = ?, "+
+				"locked_until = null, updated_at = ? where id = ?",
+			outboxTable)
+		_, err := tx.ExecContext(ctx, query, status, cause.Error(), nextAttempt.UTC(), time.Now().UTC(), entry.Id)
+		if err != nil {
+			return err
+		}
+
+		entry.Status = status
+		entry.Attempts++
+		entry.LastError = cause.Error()
+		entry.NextAttemptAt = nextAttempt
+		return nil
+	})
+}
+
+func insertOutboxEntry(ctx context.Context, tx *sql.Tx, orderId string, op Operation, actorAddress string) (*OutboxEntry, error) {
+	now := time.Now().UTC()
+	entry := &OutboxEntry{
+		OrderId:       orderId,
+		Operation:     op,
+		Status:        OutboxPending,
+		ActorAddress:  actorAddress,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		TraceParent:   tracing.TraceParent(ctx),
+	}
+
+	// the entry starts out leased so that the background dispatcher leaves it to whoever created it
+	query := fmt.Sprintf(
+		"insert into %s (order_id, operation, status, actor_address, tx_hash, attempts, last_error, "+
+			"next_attempt_at, locked_until, created_at, updated_at, trace_parent) values (?, ?, ?, ?, '', 0, '', ?, ?, ?, ?, ?)",
+		outboxTable)
+	result, err := tx.ExecContext(ctx, query,
+		orderId, op, OutboxPending, actorAddress, now, now.Add(OutboxLease), now, now, entry.TraceParent)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Id, err = result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func scanOutboxEntry(results *sql.Rows) (*OutboxEntry, error) {
+	var entry OutboxEntry
+	err := results.Scan(
+		&entry.Id,
+		&entry.OrderId,
+		&entry.Operation,
+		&entry.Status,
+		&entry.ActorAddress,
+		&entry.TxHash,
+		&entry.Attempts,
+		&entry.LastError,
+		&entry.NextAttemptAt,
+		&entry.CreatedAt,
+		&entry.TraceParent)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
@@ -0,0 +1,209 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// the provider can only be set up once per test binary
+var exporter = tracing.SetupInMemory()
+
+// A statement the repository ran, and what it was run with
+type recordedStatement struct {
+	query string
+	args  []driver.NamedValue
+}
+
+// A database/sql driver that accepts every statement and remembers it. Queries find nothing.
+type recordingDriver struct {
+	mu         sync.Mutex
+	statements []recordedStatement
+	lastId     int64
+}
+
+func (drv *recordingDriver) Open(name string) (driver.Conn, error) {
+	return &recordingConn{drv: drv}, nil
+}
+
+// The driver is its own connector, so it can be handed to sql.OpenDB without being registered
+func (drv *recordingDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return drv.Open("")
+}
+
+func (drv *recordingDriver) Driver() driver.Driver {
+	return drv
+}
+
+// The statements whose query starts with the prefix
+func (drv *recordingDriver) find(prefix string) []recordedStatement {
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+	found := []recordedStatement{}
+	for _, statement := range drv.statements {
+		if strings.HasPrefix(statement.query, prefix) {
+			found = append(found, statement)
+		}
+	}
+	return found
+}
+
+type recordingConn struct {
+	drv *recordingDriver
+}
+
+func (conn *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{conn: conn, query: query}, nil
+}
+
+func (conn *recordingConn) Close() error {
+	return nil
+}
+
+func (conn *recordingConn) Begin() (driver.Tx, error) {
+	return conn, nil
+}
+
+func (conn *recordingConn) Commit() error {
+	return nil
+}
+
+func (conn *recordingConn) Rollback() error {
+	return nil
+}
+
+func (conn *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	conn.drv.mu.Lock()
+	defer conn.drv.mu.Unlock()
+	conn.drv.statements = append(conn.drv.statements, recordedStatement{query: query, args: args})
+	conn.drv.lastId++
+	return recordedResult(conn.drv.lastId), nil
+}
+
+// The result of a statement: one row, with the next ID
+type recordedResult int64
+
+func (result recordedResult) LastInsertId() (int64, error) {
+	return int64(result), nil
+}
+
+func (result recordedResult) RowsAffected() (int64, error) {
+	return 1, nil
+}
+
+func (conn *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	conn.drv.mu.Lock()
+	defer conn.drv.mu.Unlock()
+	conn.drv.statements = append(conn.drv.statements, recordedStatement{query: query, args: args})
+	return emptyRows{}, nil
+}
+
+type recordingStmt struct {
+	conn  *recordingConn
+	query string
+}
+
+func (stmt *recordingStmt) Close() error {
+	return nil
+}
+
+func (stmt *recordingStmt) NumInput() int {
+	return -1
+}
+
+func (stmt *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return stmt.conn.ExecContext(context.Background(), stmt.query, namedValues(args))
+}
+
+func (stmt *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return stmt.conn.QueryContext(context.Background(), stmt.query, namedValues(args))
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := []driver.NamedValue{}
+	for i, arg := range args {
+		named = append(named, driver.NamedValue{Ordinal: i + 1, Value: arg})
+	}
+	return named
+}
+
+type emptyRows struct{}
+
+func (rows emptyRows) Columns() []string {
+	return []string{}
+}
+
+func (rows emptyRows) Close() error {
+	return nil
+}
+
+func (rows emptyRows) Next(dest []driver.Value) error {
+	return io.EOF
+}
+
+// Makes a repository whose statements go to a recording driver instead of MariaDB
+func newRecordingRepository(t *testing.T) (*MariaDBOrderRepository, *recordingDriver) {
+	drv := &recordingDriver{}
+	conn := sql.OpenDB(drv)
+	t.Cleanup(func() { conn.Close() })
+	return &MariaDBOrderRepository{dbName: "orderdb", conn: conn}, drv
+}
+
+// Whether the statement was given a traceparent belonging to the trace
+func hasTraceParent(statement recordedStatement, traceId string) bool {
+	for _, arg := range statement.args {
+		if value, ok := arg.Value.(string); ok && strings.HasPrefix(value, "00-"+traceId+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCreateOrderWithMintCarriesTheTrace(t *testing.T) {
+	exporter.Reset()
+	repo, drv := newRecordingRepository(t)
+
+	ctx, request := tracing.Start(context.Background(), "request")
+	entry, err := repo.CreateOrderWithMint(ctx, &Order{OrderId: "order-1", ItemId: "item-1", VendorId: "default"}, "0xvendor")
+	request.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+	traceId := request.SpanContext().TraceID().String()
+
+	var created *tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "orders.CreateOrderWithMint" {
+			copied := span
+			created = &copied
+		}
+	}
+	if created == nil {
+		t.Fatal("expected an orders.CreateOrderWithMint span")
+	}
+	if created.Parent.SpanID() != request.SpanContext().SpanID() {
+		t.Errorf("expected orders.CreateOrderWithMint to be a child of the request's span, got parent [%s]", created.Parent.SpanID())
+	}
+
+	// the entry remembers the span that recorded it, so a later attempt shows up underneath it
+	expected := "00-" + traceId + "-" + created.SpanContext.SpanID().String() + "-01"
+	if entry.TraceParent != expected {
+		t.Errorf("expected the entry's traceparent to be [%s], got [%s]", expected, entry.TraceParent)
+	}
+
+	outbox := drv.find("insert into outbox")
+	if len(outbox) != 1 || !hasTraceParent(outbox[0], traceId) {
+		t.Errorf("expected the outbox entry to be written with a traceparent in trace [%s], got %+v", traceId, outbox)
+	}
+	deliveries := drv.find("insert into webhook_deliveries")
+	if len(deliveries) != 1 || !hasTraceParent(deliveries[0], traceId) {
+		t.Errorf("expected the order.created deliveries to be queued with a traceparent in trace [%s], got %+v", traceId, deliveries)
+	}
+}
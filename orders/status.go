@@ -0,0 +1,103 @@
+package orders
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The lifecycle status of an order
+type OrderStatus string
+
+const (
+	// the order has been recorded but the delivery token has not been minted yet
+	StatusCreated OrderStatus = "created"
+	// the delivery token exists and is held by the vendor
+	StatusMinted OrderStatus = "minted"
+	// the customer has paid for the goods, which are held in escrow by the contract
+	StatusPaid OrderStatus = "paid"
+	// the customer bought the delivery token, thereby accepting delivery
+	StatusDelivered OrderStatus = "delivered"
+	// the customer's delivery token was destroyed after delivery
+	StatusBurned OrderStatus = "burned"
+	// the order was called off before the customer paid for it
+	StatusCanceled OrderStatus = "canceled"
+	// the delivery token could not be minted
+	StatusFailed OrderStatus = "failed"
+	// the customer never bought the token, so once the escrow expired the vendor claimed it on the strength
+	// of the delivery evidence and the token was handed to the customer
+	StatusReleased OrderStatus = "released"
+	// the customer never bought the token and there was no evidence of delivery, so once the escrow expired
+	// the customer's payment was returned. The token stays with the vendor.
+	StatusRefunded OrderStatus = "refunded"
+)
+
+// The set of statuses an order is allowed to move to from each status. Statuses that
+// are not keys in this map are terminal.
+var allowedTransitions = map[OrderStatus][]OrderStatus{
+	StatusCreated:   {StatusMinted, StatusFailed, StatusCanceled},
+	StatusMinted:    {StatusPaid, StatusCanceled},
+	StatusPaid:      {StatusDelivered, StatusReleased, StatusRefunded},
+	StatusDelivered: {StatusBurned},
+	StatusReleased:  {StatusBurned},
+}
+
+// All of the known statuses, in lifecycle order
+var AllStatuses = []OrderStatus{
+	StatusCreated,
+	StatusMinted,
+	StatusPaid,
+	StatusDelivered,
+	StatusReleased,
+	StatusBurned,
+	StatusRefunded,
+	StatusCanceled,
+	StatusFailed,
+}
+
+// Returned when an order is asked to move to a status that the state machine does not allow
+type InvalidTransitionError struct {
+	OrderId string
+	From    OrderStatus
+	To      OrderStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("order [%s] cannot move from [%s] to [%s]", e.OrderId, e.From, e.To)
+}
+
+// Parses a status name, ignoring case. Returns false if the status is not known.
+func ParseOrderStatus(status string) (OrderStatus, bool) {
+	for _, s := range AllStatuses {
+		if strings.EqualFold(string(s), status) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// Whether the state machine allows an order in this status to move to the next one
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range allowedTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether no further transitions are possible from this status
+func (s OrderStatus) IsTerminal() bool {
+	return len(allowedTransitions[s]) == 0
+}
+
+// Returns an InvalidTransitionError if the order is not allowed to move to the given status
+func (order *Order) ValidateTransition(next OrderStatus) error {
+	if !order.Status.CanTransitionTo(next) {
+		return &InvalidTransitionError{
+			OrderId: order.OrderId,
+			From:    order.Status,
+			To:      next,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package orders
+
+import (
+	"errors"
+	"testing"
+)
+
+// Every transition the state machine allows. Anything not listed here must be refused.
+var expectedTransitions = map[OrderStatus][]OrderStatus{
+	StatusCreated:   {StatusMinted, StatusFailed, StatusCanceled},
+	StatusMinted:    {StatusPaid, StatusCanceled},
+	StatusPaid:      {StatusDelivered, StatusReleased, StatusRefunded},
+	StatusDelivered: {StatusBurned},
+	StatusReleased:  {StatusBurned},
+}
+
+func isExpected(from OrderStatus, to OrderStatus) bool {
+	for _, allowed := range expectedTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateTransitionWalksTheWholeTable(t *testing.T) {
+	for _, from := range AllStatuses {
+		for _, to := range AllStatuses {
+			order := &Order{OrderId: "order-1", Status: from}
+			err := order.ValidateTransition(to)
+
+			if isExpected(from, to) {
+				if err != nil {
+					t.Errorf("expected %s -> %s to be allowed, got %v", from, to, err)
+				}
+				continue
+			}
+
+			var invalid *InvalidTransitionError
+			if !errors.As(err, &invalid) {
+				t.Errorf("expected %s -> %s to be refused with an InvalidTransitionError, got %v", from, to, err)
+				continue
+			}
+			if invalid.OrderId != "order-1" || invalid.From != from || invalid.To != to {
+				t.Errorf("expected the error to name order-1 moving from %s to %s, got %+v", from, to, invalid)
+			}
+		}
+	}
+}
+
+type statusChange struct {
+	from OrderStatus
+	to   OrderStatus
+}
+
+func TestInvalidTransitions(t *testing.T) {
+	cases := []statusChange{
+		// the token has to exist before it can be paid for
+		{StatusCreated, StatusPaid},
+		// the customer accepted delivery, so there is nothing to refund
+		{StatusDelivered, StatusRefunded},
+		{StatusCanceled, StatusMinted},
+		{StatusFailed, StatusMinted},
+		{StatusRefunded, StatusReleased},
+	}
+	// nothing happens to an order once its token is burned
+	for _, to := range AllStatuses {
+		cases = append(cases, statusChange{StatusBurned, to})
+	}
+
+	for _, c := range cases {
+		order := &Order{OrderId: "order-1", Status: c.from}
+		var invalid *InvalidTransitionError
+		if err := order.ValidateTransition(c.to); !errors.As(err, &invalid) {
+			t.Errorf("expected %s -> %s to be refused, got %v", c.from, c.to, err)
+		}
+	}
+}
+
+func TestTerminalStatuses(t *testing.T) {
+	for _, status := range AllStatuses {
+		_, hasNext := expectedTransitions[status]
+		if status.IsTerminal() == hasNext {
+			t.Errorf("expected %s to be terminal: %v, got %v", status, !hasNext, status.IsTerminal())
+		}
+	}
+}
+
+func TestParseOrderStatus(t *testing.T) {
+	for _, status := range AllStatuses {
+		parsed, ok := ParseOrderStatus(string(status))
+		if !ok || parsed != status {
+			t.Errorf("expected %s to parse, got %s %v", status, parsed, ok)
+		}
+	}
+	if parsed, ok := ParseOrderStatus("MINTED"); !ok || parsed != StatusMinted {
+		t.Errorf("expected the status to be parsed ignoring case, got %s %v", parsed, ok)
+	}
+	if _, ok := ParseOrderStatus("shipped"); ok {
+		t.Error("expected an unknown status to be refused")
+	}
+}
@@ -0,0 +1,396 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/bdunton9323/blockchain-playground/tracking"
+	"github.com/bdunton9323/blockchain-playground/vendors"
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Returned when a customer-signed operation has to be submitted but the customer's key is gone
+var ErrSigningKeyUnavailable = errors.New("the customer's signing key is no longer available; the request must be resubmitted")
+
+// Executes the chain operations recorded in the outbox and writes their results back to the database.
+//
+// Request handlers hand freshly recorded entries to Execute, which makes a single attempt right away.
+// Anything that doesn't finish there (a node that is down, a transaction that is slow to mine, or a
+// crash in the middle) is picked up by Run, which keeps retrying until the entry completes or runs out
+// of attempts. Since the transaction hash is recorded as soon as it is sent, a restart waits for the
+// existing transaction instead of sending a second one.
+type Dispatcher struct {
+	repository orders.OrderRepository
+	// signs for the vendor each order belongs to
+	vendors *vendors.Registry
+
+	// how often to look for entries that are due
+	PollInterval time.Duration
+	// how many entries to pick up per poll
+	BatchSize int
+	// how many times to try an entry before giving up on it
+	MaxAttempts int
+	// the delay before the first retry. It doubles with each attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// told about transactions as they are sent and mined, for clients watching their orders. Optional.
+	Tracker *tracking.Hub
+
+	// closed by StopWaiting
+	stopWaiting chan struct{}
+	stopOnce    sync.Once
+}
+
+// Constructs a new dispatcher with reasonable defaults
+func NewDispatcher(repository orders.OrderRepository, vendors *vendors.Registry) *Dispatcher {
+	return &Dispatcher{
+		repository:     repository,
+		vendors:        vendors,
+		PollInterval:   5 * time.Second,
+		BatchSize:      20,
+		MaxAttempts:    10,
+		InitialBackoff: 5 * time.Second,
+		MaxBackoff:     5 * time.Minute,
+		stopWaiting:    make(chan struct{}),
+	}
+}
+
+// Makes every attempt that is waiting for a transaction to be mined give up waiting and release its entry,
+// for when the service is shutting down. The transaction hashes are already recorded, so the next dispatcher
+// to pick the entries up waits for the same transactions instead of sending new ones.
+func (_disp *Dispatcher) StopWaiting() {
+	_disp.stopOnce.Do(func() {
+		log.Info("Outbox dispatcher no longer waiting for transactions to be mined")
+		close(_disp.stopWaiting)
+	})
+}
+
+// Makes one attempt at an entry that the caller just recorded (and therefore still holds the lease on).
+// signingKey is the customer's private key for operations the customer signs; it is only held in memory.
+// Returns the entry in its new state, along with the error from the attempt if it didn't complete.
+//
+// The attempt is traced as part of ctx, but carries on if ctx is canceled: a client that hangs up shouldn't
+// leave a transaction sent but not recorded.
+func (_disp *Dispatcher) Execute(ctx context.Context, entry *orders.OutboxEntry, signingKey string) (*orders.OutboxEntry, error) {
+	err := _disp.process(tracing.Detach(ctx), entry, signingKey, true)
+	return entry, err
+}
+
+// Processes due entries until the context is canceled. Run this in its own goroutine. An entry that is
+// being processed when the context is canceled is finished first, or left for later by StopWaiting.
+func (_disp *Dispatcher) Run(ctx context.Context) {
+	log.Infof("Outbox dispatcher started, polling every %v", _disp.PollInterval)
+
+	ticker := time.NewTicker(_disp.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		_disp.dispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Info("Outbox dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Processes one batch of entries that are due, oldest first and one at a time. Requests can send transactions
+// for the same vendor meanwhile; the executor hands out nonces under a lock per signing address, so each
+// transaction reaches the node in the order it was numbered in.
+func (_disp *Dispatcher) dispatchDue(ctx context.Context) {
+	entries, err := _disp.repository.ListDueOutboxEntries(ctx, _disp.BatchSize)
+	if err != nil {
+		log.Errorf("Could not list outbox entries: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		claimed, err := _disp.repository.ClaimOutboxEntry(ctx, entry.Id)
+		if err != nil {
+			log.Errorf("Could not claim outbox entry [%d]: %v", entry.Id, err)
+			continue
+		} else if !claimed {
+			// somebody else got to it first
+			continue
+		}
+
+		// Once the entry is claimed, its outcome has to be written down even if we are told to stop. The
+		// attempt shows up in the trace of the request that recorded the entry.
+		err = _disp.process(tracing.ContinueTrace(context.Background(), entry.TraceParent), entry, "", false)
+		if err != nil {
+			log.Warnf("Outbox entry [%d] (%s for order [%s]) did not complete: %v",
+				entry.Id, entry.Operation, entry.OrderId, err)
+		}
+	}
+}
+
+// Moves the entry forward as far as it will go: sends its transaction if that hasn't happened yet,
+// waits for it to be mined, and records the outcome. The caller must hold the lease on the entry.
+func (_disp *Dispatcher) process(ctx context.Context, entry *orders.OutboxEntry, signingKey string, fresh bool) (err error) {
+	ctx, span := tracing.Start(ctx, "outbox.Process",
+		tracing.OrderId.String(entry.OrderId),
+		attribute.String("outbox.operation", string(entry.Operation)),
+		attribute.Int64("outbox.entry", entry.Id))
+	defer tracing.End(span, &err)
+
+	order, err := _disp.repository.GetOrder(ctx, entry.OrderId)
+	if err != nil {
+		_disp.release(ctx, entry)
+		return err
+	} else if order == nil {
+		return _disp.fail(ctx, entry, errors.New(fmt.Sprintf("order [%s] does not exist", entry.OrderId)), true)
+	}
+
+	// every vendor has their own contract, and their own key to sign with
+	executor, err := _disp.vendors.Executor(order.VendorId)
+	if err != nil {
+		return _disp.fail(ctx, entry, err, false)
+	}
+
+	if entry.Status == orders.OutboxPending {
+		// If we crashed after sending the transaction but before recording it, the operation may have
+		// happened already. Sending it again would either fail or, worse, do it twice.
+		if !fresh {
+			done, err := _disp.alreadyApplied(ctx, executor, entry, order)
+			if err != nil {
+				return _disp.fail(ctx, entry, err, false)
+			} else if done {
+				log.Infof("Outbox entry [%d] was already applied on chain", entry.Id)
+				return _disp.complete(ctx, executor, entry)
+			}
+		}
+
+		if entry.Operation.SignedByCustomer() && len(signingKey) == 0 {
+			return _disp.fail(ctx, entry, ErrSigningKeyUnavailable, true)
+		}
+
+		txHash, err := _disp.submit(ctx, executor, entry, order, signingKey)
+		if err != nil {
+			// Nothing was sent, so the customer can simply try again with their key. The vendor's own
+			// operations are retried in the background, unless the contract rejected them outright.
+			final := entry.Operation.SignedByCustomer() || errors.Is(err, contract.ErrTransactionReverted)
+			return _disp.fail(ctx, entry, err, final)
+		}
+
+		err = _disp.repository.RecordSubmission(ctx, entry.Id, txHash)
+		if err != nil {
+			// The transaction is out there but we couldn't write that down. The lease will expire and
+			// a later attempt will find the result on chain (or, for payments, fail loudly).
+			log.Errorf("Could not record transaction [%s] for outbox entry [%d]: %v", txHash, entry.Id, err)
+			return err
+		}
+		entry.Status = orders.OutboxSubmitted
+		entry.TxHash = txHash
+
+		_disp.Tracker.Publish(&tracking.Update{
+			OrderId:   entry.OrderId,
+			Type:      tracking.UpdateSubmitted,
+			Status:    string(order.Status),
+			Operation: string(entry.Operation),
+			TxHash:    txHash,
+		})
+	}
+
+	err = _disp.waitForTransaction(ctx, executor, entry.TxHash)
+	if err != nil && _disp.stopping() {
+		// not the transaction's fault, so it doesn't count as an attempt
+		log.Infof("Left outbox entry [%d] waiting for transaction [%s] because of shutdown", entry.Id, entry.TxHash)
+		_disp.release(ctx, entry)
+		return err
+	} else if errors.Is(err, contract.ErrTransactionReverted) {
+		return _disp.fail(ctx, entry, err, true)
+	} else if err != nil {
+		// probably just slow to mine; wait for the same transaction again later
+		return _disp.fail(ctx, entry, err, false)
+	}
+
+	return _disp.complete(ctx, executor, entry)
+}
+
+// Waits for the transaction to be mined, unless StopWaiting is called first
+func (_disp *Dispatcher) waitForTransaction(ctx context.Context, executor *contract.DeliveryContractExecutor, txHash string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-_disp.stopWaiting:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return executor.WaitForTransaction(ctx, txHash)
+}
+
+func (_disp *Dispatcher) stopping() bool {
+	select {
+	case <-_disp.stopWaiting:
+		return true
+	default:
+		return false
+	}
+}
+
+// Sends the entry's transaction and returns its hash
+func (_disp *Dispatcher) submit(
+	ctx context.Context,
+	executor *contract.DeliveryContractExecutor,
+	entry *orders.OutboxEntry,
+	order *orders.Order,
+	signingKey string,
+) (string, error) {
+	switch entry.Operation {
+	case orders.OperationMint:
+		return executor.SubmitMint(ctx, &contract.Purchase{
+			OrderId:          order.OrderId,
+			PurchasePrice:    big.NewInt(order.Price),
+			DeliveryPrice:    big.NewInt(order.DeliveryPrice),
+			RecipientAddress: order.BuyerAddress,
+		})
+	case orders.OperationPay:
+		return executor.SubmitPayment(ctx, order.TokenId, signingKey, order.Price)
+	case orders.OperationDeliver:
+		return executor.SubmitDelivery(ctx, order.TokenId, signingKey, order.DeliveryPrice)
+	case orders.OperationBurn:
+		return executor.SubmitBurn(ctx, order.OrderId)
+	case orders.OperationRelease:
+		evidence, err := _disp.repository.GetDeliveryEvidence(ctx, order.OrderId)
+		if err != nil {
+			return "", err
+		} else if evidence == nil {
+			// the contract would reject it anyway
+			return "", fmt.Errorf("%w: order [%s] has no delivery evidence", contract.ErrTransactionReverted, order.OrderId)
+		}
+		return executor.SubmitRelease(ctx, order.TokenId, common.HexToHash(evidence.Hash))
+	case orders.OperationRefund:
+		return executor.SubmitRefund(ctx, order.TokenId, "")
+	case orders.OperationReclaim:
+		return executor.SubmitRefund(ctx, order.TokenId, signingKey)
+	}
+	return "", errors.New(fmt.Sprintf("unknown operation [%s]", entry.Operation))
+}
+
+// Checks the contract to see whether the entry's operation already took effect
+func (_disp *Dispatcher) alreadyApplied(
+	ctx context.Context,
+	executor *contract.DeliveryContractExecutor,
+	entry *orders.OutboxEntry,
+	order *orders.Order,
+) (bool, error) {
+	switch entry.Operation {
+	case orders.OperationMint:
+		tokenId, err := executor.GetTokenIdForOrder(ctx, order.OrderId)
+		return tokenId != 0, err
+	case orders.OperationBurn:
+		// the contract forgets the order's token when it is burned
+		tokenId, err := executor.GetTokenIdForOrder(ctx, order.OrderId)
+		return tokenId == 0, err
+	case orders.OperationDeliver, orders.OperationRelease:
+		owner, err := executor.GetOwner(ctx, order.TokenId)
+		return err == nil && owner == order.BuyerAddress, nil
+	case orders.OperationRefund, orders.OperationReclaim:
+		return executor.IsEscrowSettled(ctx, order.TokenId)
+	}
+	// the contract doesn't expose whether an order was paid for
+	return false, nil
+}
+
+// Records that the entry finished successfully
+func (_disp *Dispatcher) complete(ctx context.Context, executor *contract.DeliveryContractExecutor, entry *orders.OutboxEntry) error {
+	tokenAddress := ""
+	var tokenId int64
+	if entry.Operation == orders.OperationMint {
+		var err error
+		tokenId, err = executor.GetTokenIdForOrder(ctx, entry.OrderId)
+		if err != nil {
+			return _disp.fail(ctx, entry, err, false)
+		}
+		tokenAddress = executor.ContractAddress.Hex()
+	}
+
+	err := _disp.repository.CompleteOutboxEntry(ctx, entry, tokenAddress, tokenId)
+	if err != nil {
+		log.Errorf("Could not complete outbox entry [%d]: %v", entry.Id, err)
+		_disp.release(ctx, entry)
+		return err
+	}
+
+	log.Infof("Outbox entry [%d] (%s for order [%s]) completed", entry.Id, entry.Operation, entry.OrderId)
+	if len(entry.LastError) != 0 {
+		// the order had already moved on, so its status didn't change
+		return nil
+	}
+	_disp.Tracker.Publish(&tracking.Update{
+		OrderId:       entry.OrderId,
+		Type:          tracking.UpdateStatus,
+		Status:        string(entry.Operation.ResultingStatus()),
+		Operation:     string(entry.Operation),
+		TxHash:        entry.TxHash,
+		Confirmations: 1,
+	})
+	return nil
+}
+
+// Records a failed attempt and schedules the next one. Returns the cause so callers can pass it along.
+func (_disp *Dispatcher) fail(ctx context.Context, entry *orders.OutboxEntry, cause error, final bool) error {
+	if entry.Attempts+1 >= _disp.MaxAttempts {
+		final = true
+	}
+
+	err := _disp.repository.FailOutboxEntry(ctx, entry, cause, final, time.Now().Add(_disp.backoff(entry.Attempts)))
+	if err != nil {
+		log.Errorf("Could not record failure of outbox entry [%d]: %v", entry.Id, err)
+		_disp.release(ctx, entry)
+	}
+
+	if final {
+		log.Errorf("Giving up on outbox entry [%d] (%s for order [%s]): %v",
+			entry.Id, entry.Operation, entry.OrderId, cause)
+
+		update := &tracking.Update{
+			OrderId:   entry.OrderId,
+			Type:      tracking.UpdateFailed,
+			Operation: string(entry.Operation),
+			TxHash:    entry.TxHash,
+			Error:     cause.Error(),
+		}
+		if entry.Operation == orders.OperationMint {
+			// the order can't go anywhere without its token
+			update.Status = string(orders.StatusFailed)
+		}
+		_disp.Tracker.Publish(update)
+	}
+	return cause
+}
+
+// Lets go of the entry without recording anything, so it can be retried right away
+func (_disp *Dispatcher) release(ctx context.Context, entry *orders.OutboxEntry) {
+	if err := _disp.repository.ReleaseOutboxEntry(ctx, entry.Id); err != nil {
+		log.Errorf("Could not release outbox entry [%d]: %v", entry.Id, err)
+	}
+}
+
+// How long to wait before the next attempt, given how many attempts have been made already
+func (_disp *Dispatcher) backoff(attempts int) time.Duration {
+	delay := _disp.InitialBackoff
+	for i := 0; i < attempts && delay < _disp.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > _disp.MaxBackoff {
+		delay = _disp.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,165 @@
+package products
+
+import (
+	"database/sql"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// A DTO object representing a row in the database. Prices are in wei.
+type Product struct {
+	ProductId     string
+	Name          string
+	Description   string
+	Price         int64
+	ShippingPrice int64
+	// the vendor selling the product. It never changes.
+	VendorId string
+}
+
+type ProductRepository interface {
+	GetProduct(productId string) (*Product, error)
+	// Lists one vendor's catalog, or every vendor's if vendorId is empty
+	ListProducts(vendorId string) ([]*Product, error)
+	CreateProduct(product *Product) error
+	UpdateProduct(product *Product) (bool, error)
+	DeleteProduct(productId string) (bool, error)
+}
+
+type MariaDBProductRepository struct {
+	ProductRepository
+
+	conn *sql.DB
+}
+
+var productsTable = "products"
+var allFields = "product_id, name, description, price, shipping_price, vendor_id"
+
+// Construct a new repository that uses the given connection pool to MariaDB
+func NewMariaDBProductRepository(db *sql.DB) *MariaDBProductRepository {
+	return &MariaDBProductRepository{conn: db}
+}
+
+// Returns the product with the given ID from the database. If not found, then nil.
+func (repo *MariaDBProductRepository) GetProduct(productId string) (*Product, error) {
+	query := fmt.Sprintf("select %s from %s where product_id = ?", allFields, productsTable)
+
+	results, err := repo.runQuery(query, productId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	if !results.Next() {
+		return nil, nil
+	}
+	return scanProduct(results)
+}
+
+// Returns every product in the vendor's catalog, or in every catalog if vendorId is empty, ordered by name
+func (repo *MariaDBProductRepository) ListProducts(vendorId string) ([]*Product, error) {
+	where := ""
+	args := []interface{}{}
+	if len(vendorId) != 0 {
+		where = "where vendor_id = ?"
+		args = append(args, vendorId)
+	}
+	query := fmt.Sprintf("select %s from %s %s order by name, product_id", allFields, productsTable, where)
+
+	results, err := repo.runQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	products := []*Product{}
+	for results.Next() {
+		product, err := scanProduct(results)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, results.Err()
+}
+
+// Writes the given product to the database
+func (repo *MariaDBProductRepository) CreateProduct(product *Product) error {
+	query := fmt.Sprintf("insert into %s (%s) values (?, ?, ?, ?, ?, ?)", productsTable, allFields)
+	log.Debugf("running query [%s]", query)
+
+	_, err := repo.conn.Exec(query,
+		product.ProductId,
+		product.Name,
+		product.Description,
+		product.Price,
+		product.ShippingPrice,
+		product.VendorId)
+	if err != nil {
+		log.Errorf("query returned error: %v", err)
+	}
+	return err
+}
+
+// Overwrites the stored product with the given one, apart from its vendor. Returns false if the product doesn't exist.
+func (repo *MariaDBProductRepository) UpdateProduct(product *Product) (bool, error) {
+	existing, err := repo.GetProduct(product.ProductId)
+	if err != nil || existing == nil {
+		return false, err
+	}
+
+	query := fmt.Sprintf(
+		"update %s set name = ?, description = ?, price = ?, shipping_price = ? where product_id = ?",
+		productsTable)
+	log.Debugf("running query [%s]", query)
+
+	_, err = repo.conn.Exec(query,
+		product.Name,
+		product.Description,
+		product.Price,
+		product.ShippingPrice,
+		product.ProductId)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Removes the product from the catalog. Returns false if the product doesn't exist.
+func (repo *MariaDBProductRepository) DeleteProduct(productId string) (bool, error) {
+	query := fmt.Sprintf("delete from %s where product_id = ?", productsTable)
+	log.Debugf("running query [%s]", query)
+
+	result, err := repo.conn.Exec(query, productId)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return deleted > 0, nil
+}
+
+func scanProduct(results *sql.Rows) (*Product, error) {
+	var product Product
+	err := results.Scan(
+		&product.ProductId,
+		&product.Name,
+		&product.Description,
+		&product.Price,
+		&product.ShippingPrice,
+		&product.VendorId)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// Runs the given query against the database
+func (repo *MariaDBProductRepository) runQuery(query string, args ...interface{}) (*sql.Rows, error) {
+	log.Debugf("running query [%s]", query)
+	return repo.conn.Query(query, args...)
+}
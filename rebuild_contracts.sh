@@ -4,8 +4,8 @@
 rm contract/build/*
 
 # compile the contracts
-solc --allow-paths "$PWD/node_modules/@openzeppelin/" --abi contract/DeliveryContract.sol -o contract/build
-solc --allow-paths "$PWD/node_modules/@openzeppelin/" --bin contract/DeliveryContract.sol -o contract/build
+solc --allow-paths "$PWD/node_modules/@openzeppelin/" --evm-version istanbul --abi contract/DeliveryContract.sol -o contract/build
+solc --allow-paths "$PWD/node_modules/@openzeppelin/" --evm-version istanbul --bin contract/DeliveryContract.sol -o contract/build
 
 # build the Go bindings
 abigen --abi contract/build/DeliveryContract.abi \
@@ -0,0 +1,345 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/metrics"
+	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Returned when a reconciliation is asked for while another one is still going
+var ErrAlreadyRunning = errors.New("a reconciliation is already running")
+
+// Compares every order in the database with what the delivery contract says about it, and optionally
+// repairs the database to match.
+//
+// Database writes and chain transactions can't happen atomically, so the two drift apart when something
+// goes wrong between them: a crash, an order that was canceled while its token was being minted, or a
+// customer who called the contract directly. The outbox covers the usual cases; this catches whatever it
+// misses. The chain is taken to be right. Only discrepancies that the chain fully explains are repaired;
+// the rest are reported for somebody to look at.
+//
+// Every vendor has their own contract, so each has a reconciler of their own.
+type Reconciler struct {
+	repository orders.OrderRepository
+	// the vendor whose orders are checked, and their contract
+	vendorId        string
+	chain           TokenReader
+	contractAddress common.Address
+	// the vendor's own address, which holds tokens until they are delivered
+	vendorAddress common.Address
+
+	// how often Run reconciles
+	Interval time.Duration
+	// whether Run repairs what it can
+	Repair bool
+	// how many orders to read from the database at once
+	BatchSize int
+
+	// held while a reconciliation is running
+	running sync.Mutex
+	// guards lastReport
+	mutex      sync.Mutex
+	lastReport *Report
+}
+
+// What the reconciler reads from a vendor's delivery contract
+type TokenReader interface {
+	GetTokenIdForOrder(ctx context.Context, orderId string) (int64, error)
+	GetOwner(ctx context.Context, tokenId int64) (string, error)
+	ListTokenIds(ctx context.Context) ([]int64, error)
+}
+
+// Constructs a new reconciler with reasonable defaults. The executor's contract must already be deployed.
+func NewReconciler(repository orders.OrderRepository, vendorId string, executor *contract.DeliveryContractExecutor) *Reconciler {
+	return &Reconciler{
+		repository:      repository,
+		vendorId:        vendorId,
+		chain:           executor,
+		contractAddress: *executor.ContractAddress,
+		vendorAddress:   *executor.VendorAddress,
+		Interval:        time.Hour,
+		BatchSize:       100,
+	}
+}
+
+// Reconciles every Interval until the context is canceled. Run this in its own goroutine.
+func (_rec *Reconciler) Run(ctx context.Context) {
+	log.Infof("Reconciler for vendor [%s] started, running every %v", _rec.vendorId, _rec.Interval)
+
+	ticker := time.NewTicker(_rec.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("Reconciler for vendor [%s] stopped", _rec.vendorId)
+			return
+		case <-ticker.C:
+		}
+
+		_, err := _rec.Reconcile(ctx, _rec.Repair)
+		if err != nil && ctx.Err() == nil {
+			log.Errorf("Reconciliation failed: %v", err)
+		}
+	}
+}
+
+// The report from the last reconciliation that finished, or nil if there hasn't been one yet
+func (_rec *Reconciler) LastReport() *Report {
+	_rec.mutex.Lock()
+	defer _rec.mutex.Unlock()
+	return _rec.lastReport
+}
+
+// Compares every order with the chain, then looks for tokens that no order accounts for. If repair is true,
+// orders the chain has moved on are brought up to date. Returns ErrAlreadyRunning if another reconciliation
+// hasn't finished yet.
+func (_rec *Reconciler) Reconcile(ctx context.Context, repair bool) (_ *Report, err error) {
+	if !_rec.running.TryLock() {
+		return nil, ErrAlreadyRunning
+	}
+	defer _rec.running.Unlock()
+
+	ctx, span := tracing.Start(ctx, "reconcile.Reconcile", attribute.Bool("reconcile.repair", repair))
+	defer tracing.End(span, &err)
+
+	report := &Report{
+		VendorId:      _rec.vendorId,
+		StartedAt:     time.Now().UTC(),
+		Repair:        repair,
+		TokensOnChain: -1,
+		Discrepancies: []*Discrepancy{},
+		Errors:        []string{},
+	}
+	// the tokens that belong to an order
+	accounted := map[int64]bool{}
+
+	query := &orders.OrderQuery{
+		VendorId: _rec.vendorId,
+		SortBy:   orders.SortByCreatedAt,
+		Limit:    _rec.BatchSize,
+	}
+	for {
+		page, next, err := _rec.repository.ListOrders(ctx, query)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("could not list the orders: %v", err))
+		}
+		for _, order := range page {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			_rec.reconcileOrder(ctx, order, repair, report, accounted)
+		}
+		if next == nil {
+			break
+		}
+		query.After = next
+	}
+
+	tokenIds, err := _rec.chain.ListTokenIds(ctx)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("could not list the tokens on chain: %v", err))
+	} else {
+		report.TokensOnChain = len(tokenIds)
+		for _, tokenId := range tokenIds {
+			if !accounted[tokenId] {
+				report.Discrepancies = append(report.Discrepancies, &Discrepancy{
+					Kind:         KindOrphanToken,
+					ChainTokenId: tokenId,
+					Description:  fmt.Sprintf("token [%d] exists but no order has it", tokenId),
+				})
+			}
+		}
+	}
+	report.FinishedAt = time.Now().UTC()
+
+	counts := map[string]int{}
+	for kind, count := range report.CountByKind() {
+		counts[string(kind)] = count
+	}
+	metrics.ObserveReconciliation(_rec.vendorId, report.FinishedAt, counts)
+
+	_rec.mutex.Lock()
+	_rec.lastReport = report
+	_rec.mutex.Unlock()
+
+	log.Infof("Reconciliation of vendor [%s] checked [%d] orders and found [%d] discrepancies (repair: %v)",
+		_rec.vendorId, report.OrdersChecked, len(report.Discrepancies), repair)
+	return report, nil
+}
+
+// Compares one order with the chain and adds what it finds to the report
+func (_rec *Reconciler) reconcileOrder(
+	ctx context.Context,
+	order *orders.Order,
+	repair bool,
+	report *Report,
+	accounted map[int64]bool,
+) {
+	// tokens from a contract we have since replaced can't be looked up in this one
+	if len(order.TokenAddress) != 0 && !sameAddress(order.TokenAddress, _rec.contractAddress.Hex()) {
+		report.OrdersSkipped++
+		return
+	}
+
+	chainTokenId, err := _rec.chain.GetTokenIdForOrder(ctx, order.OrderId)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("order [%s]: could not get its token: %v", order.OrderId, err))
+		return
+	}
+	if chainTokenId != 0 {
+		accounted[chainTokenId] = true
+	}
+
+	// the chain is allowed to be ahead while an operation is in flight; the outbox will catch the database up
+	inFlight, err := _rec.repository.HasUnfinishedOutboxEntry(ctx, order.OrderId)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("order [%s]: could not check the outbox: %v", order.OrderId, err))
+		return
+	} else if inFlight {
+		report.OrdersSkipped++
+		return
+	}
+
+	found, fix, err := _rec.compare(ctx, order, chainTokenId)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("order [%s]: %v", order.OrderId, err))
+		return
+	}
+	report.OrdersChecked++
+	report.Discrepancies = append(report.Discrepancies, found...)
+
+	if fix == nil || !repair {
+		return
+	}
+	err = _rec.repository.RepairOrder(ctx, fix)
+	for _, discrepancy := range found {
+		if len(discrepancy.RepairStatus) == 0 {
+			continue
+		}
+		if err != nil {
+			discrepancy.RepairError = err.Error()
+		} else {
+			discrepancy.Repaired = true
+		}
+	}
+	if err != nil {
+		log.Errorf("Could not repair order [%s]: %v", order.OrderId, err)
+	}
+}
+
+// Works out where the order disagrees with the chain, and the repair that would make the database match.
+// The repair is nil if there is nothing the chain can fix.
+func (_rec *Reconciler) compare(ctx context.Context, order *orders.Order, chainTokenId int64) ([]*Discrepancy, *orders.Repair, error) {
+	found := []*Discrepancy{}
+	// the discrepancies the repair fixes
+	repairable := []*Discrepancy{}
+	add := func(kind Kind, format string, args ...interface{}) *Discrepancy {
+		discrepancy := &Discrepancy{
+			Kind:         kind,
+			OrderId:      order.OrderId,
+			Status:       string(order.Status),
+			TokenId:      order.TokenId,
+			ChainTokenId: chainTokenId,
+			Description:  fmt.Sprintf(format, args...),
+		}
+		found = append(found, discrepancy)
+		return discrepancy
+	}
+
+	fix := &orders.Repair{
+		OrderId:      order.OrderId,
+		FromStatus:   order.Status,
+		ToStatus:     order.Status,
+		ActorAddress: _rec.vendorAddress.Hex(),
+	}
+
+	if chainTokenId == 0 {
+		switch order.Status {
+		case orders.StatusDelivered, orders.StatusReleased:
+			// only the customer can burn the token, and only once they have it
+			repairable = append(repairable, add(KindUnrecordedBurn, "the order's token has been burned"))
+			fix.ToStatus = orders.StatusBurned
+		case orders.StatusMinted, orders.StatusPaid, orders.StatusRefunded:
+			add(KindMissingToken, "the order is %s but the contract has no token for it", order.Status)
+		case orders.StatusCanceled:
+			if order.TokenId != 0 {
+				add(KindMissingToken, "the order has token [%d] but the contract has none for it", order.TokenId)
+			}
+		}
+		return found, repairFor(fix, repairable), nil
+	}
+
+	if order.Status == orders.StatusBurned {
+		add(KindBurnedTokenExists, "the order is burned but its token [%d] still exists", chainTokenId)
+		return found, nil, nil
+	}
+
+	if order.TokenId != chainTokenId {
+		kind := KindTokenMismatch
+		description := fmt.Sprintf("the order has token [%d] but the contract has token [%d] for it", order.TokenId, chainTokenId)
+		if order.TokenId == 0 {
+			kind = KindUnrecordedMint
+			description = fmt.Sprintf("token [%d] was minted for the order but never recorded", chainTokenId)
+		}
+		repairable = append(repairable, add(kind, description))
+		fix.TokenAddress = _rec.contractAddress.Hex()
+		fix.TokenId = chainTokenId
+		if order.Status == orders.StatusCreated || order.Status == orders.StatusFailed {
+			fix.ToStatus = orders.StatusMinted
+		}
+	}
+
+	owner, err := _rec.chain.GetOwner(ctx, chainTokenId)
+	if err != nil {
+		return nil, nil, errors.New(fmt.Sprintf("could not get the owner of token [%d]: %v", chainTokenId, err))
+	}
+
+	switch {
+	case sameAddress(owner, _rec.vendorAddress.Hex()):
+		if fix.ToStatus == orders.StatusDelivered || fix.ToStatus == orders.StatusReleased {
+			add(KindUnexpectedOwner, "the order is %s but the vendor still owns its token", fix.ToStatus).Owner = owner
+		}
+	case sameAddress(owner, order.BuyerAddress):
+		switch fix.ToStatus {
+		case orders.StatusMinted, orders.StatusPaid:
+			// the contract only lets the customer buy the token once they have paid
+			discrepancy := add(KindUnrecordedDelivery, "the customer owns the order's token")
+			discrepancy.Owner = owner
+			repairable = append(repairable, discrepancy)
+			fix.ToStatus = orders.StatusDelivered
+		case orders.StatusCanceled, orders.StatusRefunded:
+			add(KindUnexpectedOwner, "the order is %s but the customer owns its token", fix.ToStatus).Owner = owner
+		}
+	default:
+		add(KindUnexpectedOwner, "the token is owned by neither the vendor nor the customer").Owner = owner
+	}
+
+	return found, repairFor(fix, repairable), nil
+}
+
+// Returns the repair, and marks the discrepancies it fixes with the status it leaves the order in. Returns
+// nil if nothing can be fixed.
+func repairFor(fix *orders.Repair, repairable []*Discrepancy) *orders.Repair {
+	if len(repairable) == 0 {
+		return nil
+	}
+	for _, discrepancy := range repairable {
+		discrepancy.RepairStatus = string(fix.ToStatus)
+	}
+	return fix
+}
+
+func sameAddress(a string, b string) bool {
+	return common.HexToAddress(a) == common.HexToAddress(b)
+}
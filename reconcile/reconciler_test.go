@@ -0,0 +1,353 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var contractAddress = common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+var oldContractAddress = common.HexToAddress("0xe7f1725E7734CE288F8367e1Bb143E90bb3F0512")
+var vendorAddress = common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+var buyerAddress = "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+var strangerAddress = "0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"
+
+// A contract whose tokens and owners are set up by the test
+type fakeChain struct {
+	// the token minted for each order
+	tokens map[string]int64
+	owners map[int64]string
+	// tokens that no order has
+	orphans []int64
+}
+
+func (chain *fakeChain) GetTokenIdForOrder(ctx context.Context, orderId string) (int64, error) {
+	return chain.tokens[orderId], nil
+}
+
+func (chain *fakeChain) GetOwner(ctx context.Context, tokenId int64) (string, error) {
+	owner, found := chain.owners[tokenId]
+	if !found {
+		return "", errors.New("no such token")
+	}
+	return owner, nil
+}
+
+func (chain *fakeChain) ListTokenIds(ctx context.Context) ([]int64, error) {
+	tokenIds := append([]int64{}, chain.orphans...)
+	for _, tokenId := range chain.tokens {
+		tokenIds = append(tokenIds, tokenId)
+	}
+	sort.Slice(tokenIds, func(i, j int) bool { return tokenIds[i] < tokenIds[j] })
+	return tokenIds, nil
+}
+
+// Orders in memory, which records the repairs made to them
+type fakeOrderRepository struct {
+	orders.OrderRepository
+
+	orders []*orders.Order
+	// orders with an outbox entry that hasn't finished
+	inFlight map[string]bool
+	repairs  []*orders.Repair
+	// what RepairOrder returns
+	repairErr error
+}
+
+func (repo *fakeOrderRepository) ListOrders(ctx context.Context, query *orders.OrderQuery) ([]*orders.Order, *orders.OrderCursor, error) {
+	return repo.orders, nil, nil
+}
+
+func (repo *fakeOrderRepository) HasUnfinishedOutboxEntry(ctx context.Context, orderId string) (bool, error) {
+	return repo.inFlight[orderId], nil
+}
+
+func (repo *fakeOrderRepository) RepairOrder(ctx context.Context, repair *orders.Repair) error {
+	repo.repairs = append(repo.repairs, repair)
+	return repo.repairErr
+}
+
+func newTestReconciler(repo *fakeOrderRepository, chain *fakeChain) *Reconciler {
+	return &Reconciler{
+		repository:      repo,
+		vendorId:        "default",
+		chain:           chain,
+		contractAddress: contractAddress,
+		vendorAddress:   vendorAddress,
+		BatchSize:       100,
+	}
+}
+
+// Builds an order of the default vendor's on the current contract. A token ID of 0 means it has no token.
+func testOrder(status orders.OrderStatus, tokenId int64) *orders.Order {
+	order := &orders.Order{
+		OrderId:      "order-1",
+		Status:       status,
+		BuyerAddress: buyerAddress,
+		VendorId:     "default",
+		TokenId:      tokenId,
+	}
+	if tokenId != 0 {
+		order.TokenAddress = contractAddress.Hex()
+	}
+	return order
+}
+
+// The kinds of the discrepancies, in the order they were found
+func kindsOf(report *Report) []Kind {
+	kinds := []Kind{}
+	for _, discrepancy := range report.Discrepancies {
+		kinds = append(kinds, discrepancy.Kind)
+	}
+	return kinds
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name  string
+		order *orders.Order
+		// the token the contract has for the order, if any, and who owns it
+		chainTokenId int64
+		owner        string
+		expected     []Kind
+		// nil if nothing can be repaired
+		repair *orders.Repair
+	}{
+		{
+			name:  "in step",
+			order: testOrder(orders.StatusPaid, 7), chainTokenId: 7, owner: vendorAddress.Hex(),
+			expected: []Kind{},
+		},
+		{
+			name:  "unrecorded mint",
+			order: testOrder(orders.StatusCreated, 0), chainTokenId: 7, owner: vendorAddress.Hex(),
+			expected: []Kind{KindUnrecordedMint},
+			repair: &orders.Repair{FromStatus: orders.StatusCreated, ToStatus: orders.StatusMinted,
+				TokenAddress: contractAddress.Hex(), TokenId: 7},
+		},
+		{
+			name:  "unrecorded mint of a failed order",
+			order: testOrder(orders.StatusFailed, 0), chainTokenId: 7, owner: vendorAddress.Hex(),
+			expected: []Kind{KindUnrecordedMint},
+			repair: &orders.Repair{FromStatus: orders.StatusFailed, ToStatus: orders.StatusMinted,
+				TokenAddress: contractAddress.Hex(), TokenId: 7},
+		},
+		{
+			name:  "token mismatch",
+			order: testOrder(orders.StatusMinted, 6), chainTokenId: 7, owner: vendorAddress.Hex(),
+			expected: []Kind{KindTokenMismatch},
+			repair: &orders.Repair{FromStatus: orders.StatusMinted, ToStatus: orders.StatusMinted,
+				TokenAddress: contractAddress.Hex(), TokenId: 7},
+		},
+		{
+			name:  "unrecorded delivery",
+			order: testOrder(orders.StatusPaid, 7), chainTokenId: 7, owner: buyerAddress,
+			expected: []Kind{KindUnrecordedDelivery},
+			repair:   &orders.Repair{FromStatus: orders.StatusPaid, ToStatus: orders.StatusDelivered},
+		},
+		{
+			name:  "unrecorded mint and delivery",
+			order: testOrder(orders.StatusCreated, 0), chainTokenId: 7, owner: buyerAddress,
+			expected: []Kind{KindUnrecordedMint, KindUnrecordedDelivery},
+			repair: &orders.Repair{FromStatus: orders.StatusCreated, ToStatus: orders.StatusDelivered,
+				TokenAddress: contractAddress.Hex(), TokenId: 7},
+		},
+		{
+			name:     "unrecorded burn",
+			order:    testOrder(orders.StatusDelivered, 7),
+			expected: []Kind{KindUnrecordedBurn},
+			repair:   &orders.Repair{FromStatus: orders.StatusDelivered, ToStatus: orders.StatusBurned},
+		},
+		{
+			name:     "unrecorded burn after the escrow was released",
+			order:    testOrder(orders.StatusReleased, 7),
+			expected: []Kind{KindUnrecordedBurn},
+			repair:   &orders.Repair{FromStatus: orders.StatusReleased, ToStatus: orders.StatusBurned},
+		},
+		{
+			name:     "missing token",
+			order:    testOrder(orders.StatusMinted, 7),
+			expected: []Kind{KindMissingToken},
+		},
+		{
+			name:     "missing token of a canceled order",
+			order:    testOrder(orders.StatusCanceled, 7),
+			expected: []Kind{KindMissingToken},
+		},
+		{
+			name:     "canceled before it was minted",
+			order:    testOrder(orders.StatusCanceled, 0),
+			expected: []Kind{},
+		},
+		{
+			name:  "burned token exists",
+			order: testOrder(orders.StatusBurned, 7), chainTokenId: 7, owner: buyerAddress,
+			expected: []Kind{KindBurnedTokenExists},
+		},
+		{
+			name:  "owned by a stranger",
+			order: testOrder(orders.StatusPaid, 7), chainTokenId: 7, owner: strangerAddress,
+			expected: []Kind{KindUnexpectedOwner},
+		},
+		{
+			name:  "delivered but still the vendor's",
+			order: testOrder(orders.StatusDelivered, 7), chainTokenId: 7, owner: vendorAddress.Hex(),
+			expected: []Kind{KindUnexpectedOwner},
+		},
+		{
+			name:  "refunded but the customer's",
+			order: testOrder(orders.StatusRefunded, 7), chainTokenId: 7, owner: buyerAddress,
+			expected: []Kind{KindUnexpectedOwner},
+		},
+	}
+
+	for _, test := range tests {
+		for _, repair := range []bool{true, false} {
+			name := test.name
+			if !repair {
+				name += " without repairing"
+			}
+			t.Run(name, func(t *testing.T) {
+				chain := &fakeChain{tokens: map[string]int64{}, owners: map[int64]string{}}
+				if test.chainTokenId != 0 {
+					chain.tokens[test.order.OrderId] = test.chainTokenId
+					chain.owners[test.chainTokenId] = test.owner
+				}
+				repo := &fakeOrderRepository{orders: []*orders.Order{test.order}}
+
+				report, err := newTestReconciler(repo, chain).Reconcile(context.Background(), repair)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(report.Errors) != 0 || report.OrdersChecked != 1 {
+					t.Fatalf("expected the order to be checked, got %+v", report)
+				}
+				if kinds := kindsOf(report); !reflect.DeepEqual(kinds, test.expected) {
+					t.Errorf("expected %v, got %v", test.expected, kinds)
+				}
+
+				for _, discrepancy := range report.Discrepancies {
+					if discrepancy.OrderId != test.order.OrderId {
+						t.Errorf("expected the discrepancy to be for the order, got %+v", discrepancy)
+					}
+					// what the repair would be is reported either way, but only made when asked
+					canRepair := len(discrepancy.RepairStatus) != 0
+					if canRepair != (test.repair != nil) {
+						t.Errorf("expected repairable to be %v, got %+v", test.repair != nil, discrepancy)
+					} else if canRepair && discrepancy.RepairStatus != string(test.repair.ToStatus) {
+						t.Errorf("expected the repair to leave the order %s, got %+v", test.repair.ToStatus, discrepancy)
+					}
+					if discrepancy.Repaired != (canRepair && repair) {
+						t.Errorf("expected repaired to be %v, got %+v", canRepair && repair, discrepancy)
+					}
+				}
+
+				if test.repair == nil || !repair {
+					if len(repo.repairs) != 0 {
+						t.Errorf("expected the database to be left alone, got %+v", repo.repairs[0])
+					}
+					return
+				}
+				expected := *test.repair
+				expected.OrderId = test.order.OrderId
+				expected.ActorAddress = vendorAddress.Hex()
+				if len(repo.repairs) != 1 || !reflect.DeepEqual(repo.repairs[0], &expected) {
+					t.Errorf("expected one repair %+v, got %+v", expected, repo.repairs)
+				}
+			})
+		}
+	}
+}
+
+func TestReconcileSkipsOrdersWithAnOperationInFlight(t *testing.T) {
+	// the outbox is still minting the token, so the database is allowed to be behind
+	chain := &fakeChain{tokens: map[string]int64{"order-1": 7}, owners: map[int64]string{7: vendorAddress.Hex()}}
+	repo := &fakeOrderRepository{
+		orders:   []*orders.Order{testOrder(orders.StatusCreated, 0)},
+		inFlight: map[string]bool{"order-1": true},
+	}
+
+	report, err := newTestReconciler(repo, chain).Reconcile(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OrdersSkipped != 1 || report.OrdersChecked != 0 || len(report.Discrepancies) != 0 {
+		t.Errorf("expected the order to be skipped, got %+v", report)
+	}
+	if len(repo.repairs) != 0 {
+		t.Errorf("expected the database to be left alone, got %+v", repo.repairs[0])
+	}
+}
+
+func TestReconcileSkipsOrdersOnAnotherContract(t *testing.T) {
+	order := testOrder(orders.StatusMinted, 7)
+	order.TokenAddress = oldContractAddress.Hex()
+	repo := &fakeOrderRepository{orders: []*orders.Order{order}}
+
+	report, err := newTestReconciler(repo, &fakeChain{}).Reconcile(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OrdersSkipped != 1 || len(report.Discrepancies) != 0 || len(repo.repairs) != 0 {
+		t.Errorf("expected the order to be skipped, got %+v", report)
+	}
+}
+
+func TestReconcileFindsOrphanTokens(t *testing.T) {
+	chain := &fakeChain{
+		tokens:  map[string]int64{"order-1": 7},
+		owners:  map[int64]string{7: vendorAddress.Hex()},
+		orphans: []int64{9},
+	}
+	repo := &fakeOrderRepository{orders: []*orders.Order{testOrder(orders.StatusMinted, 7)}}
+
+	report, err := newTestReconciler(repo, chain).Reconcile(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.TokensOnChain != 2 {
+		t.Errorf("expected 2 tokens on chain, got %d", report.TokensOnChain)
+	}
+	if len(report.Discrepancies) != 1 {
+		t.Fatalf("expected one orphan token, got %v", kindsOf(report))
+	}
+	orphan := report.Discrepancies[0]
+	if orphan.Kind != KindOrphanToken || orphan.ChainTokenId != 9 || len(orphan.OrderId) != 0 || len(orphan.RepairStatus) != 0 {
+		t.Errorf("expected token 9 to be an orphan that can't be repaired, got %+v", orphan)
+	}
+	if len(repo.repairs) != 0 {
+		t.Errorf("expected the database to be left alone, got %+v", repo.repairs[0])
+	}
+}
+
+func TestReconcileReportsFailedRepairs(t *testing.T) {
+	chain := &fakeChain{tokens: map[string]int64{"order-1": 7}, owners: map[int64]string{7: buyerAddress}}
+	repo := &fakeOrderRepository{
+		orders:    []*orders.Order{testOrder(orders.StatusPaid, 7)},
+		repairErr: orders.ErrOrderChanged,
+	}
+
+	report, err := newTestReconciler(repo, chain).Reconcile(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	discrepancy := report.Discrepancies[0]
+	if discrepancy.Repaired || discrepancy.RepairError != orders.ErrOrderChanged.Error() {
+		t.Errorf("expected the repair to have failed, got %+v", discrepancy)
+	}
+}
+
+func TestReconcileIsNotReentrant(t *testing.T) {
+	reconciler := newTestReconciler(&fakeOrderRepository{}, &fakeChain{})
+	reconciler.running.Lock()
+	defer reconciler.running.Unlock()
+
+	if _, err := reconciler.Reconcile(context.Background(), false); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("expected ErrAlreadyRunning, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package reconcile
+
+import (
+	"time"
+)
+
+// What kind of disagreement there is between the database and the chain
+type Kind string
+
+const (
+	// the chain minted a token for the order, but the database never recorded it
+	KindUnrecordedMint Kind = "unrecorded_mint"
+	// the database and the chain have different tokens for the order
+	KindTokenMismatch Kind = "token_mismatch"
+	// the database says the order has a token, but the chain has none for it
+	KindMissingToken Kind = "missing_token"
+	// the customer owns the order's token, but the database doesn't have the order as delivered
+	KindUnrecordedDelivery Kind = "unrecorded_delivery"
+	// the order's token was burned, but the database doesn't have the order as burned
+	KindUnrecordedBurn Kind = "unrecorded_burn"
+	// the database has the order as burned, but its token still exists
+	KindBurnedTokenExists Kind = "burned_token_exists"
+	// the token is owned by somebody the order's status doesn't allow for
+	KindUnexpectedOwner Kind = "unexpected_owner"
+	// a token exists that no order accounts for
+	KindOrphanToken Kind = "orphan_token"
+)
+
+// Every kind of discrepancy, for reporting a count of each
+var AllKinds = []Kind{
+	KindUnrecordedMint,
+	KindTokenMismatch,
+	KindMissingToken,
+	KindUnrecordedDelivery,
+	KindUnrecordedBurn,
+	KindBurnedTokenExists,
+	KindUnexpectedOwner,
+	KindOrphanToken,
+}
+
+// One place where the database and the chain disagree
+type Discrepancy struct {
+	Kind Kind `json:"kind" example:"unrecorded_delivery"`
+	// empty for orphan tokens, since the contract can't say which order a token was minted for
+	OrderId string `json:"orderId,omitempty"`
+	// the order's status in the database
+	Status string `json:"status,omitempty" example:"paid"`
+	// the token the database has for the order
+	TokenId int64 `json:"tokenId,omitempty"`
+	// the token the chain has for the order
+	ChainTokenId int64 `json:"chainTokenId,omitempty"`
+	// who owns the token on chain, as a hex string
+	Owner string `json:"owner,omitempty"`
+	// what is wrong, for people
+	Description string `json:"description"`
+	// the status the database was, or would be, repaired to. Empty if it can't be repaired automatically.
+	RepairStatus string `json:"repairStatus,omitempty" example:"delivered"`
+	// whether the database was repaired
+	Repaired bool `json:"repaired"`
+	// why the repair didn't work
+	RepairError string `json:"repairError,omitempty"`
+}
+
+// The outcome of comparing every one of a vendor's orders with the chain
+type Report struct {
+	// the vendor whose orders and contract were compared
+	VendorId   string    `json:"vendorId"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	// whether discrepancies that could be repaired were
+	Repair bool `json:"repair"`
+	// how many orders were compared with the chain
+	OrdersChecked int `json:"ordersChecked"`
+	// orders that were left alone because a chain operation for them is still in flight, or they belong
+	// to a different contract
+	OrdersSkipped int `json:"ordersSkipped"`
+	// how many tokens exist on chain. -1 if they couldn't be listed.
+	TokensOnChain int            `json:"tokensOnChain"`
+	Discrepancies []*Discrepancy `json:"discrepancies"`
+	// orders or lookups that couldn't be checked, and why
+	Errors []string `json:"errors"`
+}
+
+// Counts the discrepancies of each kind, including the kinds that weren't found
+func (report *Report) CountByKind() map[Kind]int {
+	counts := map[Kind]int{}
+	for _, kind := range AllKinds {
+		counts[kind] = 0
+	}
+	for _, discrepancy := range report.Discrepancies {
+		counts[discrepancy.Kind]++
+	}
+	return counts
+}
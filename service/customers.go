@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/orders"
+)
+
+// Lists the orders placed for a customer's account, i.e. those delivered to any address the customer has verified.
+// The query's buyer address narrows it down to one of them.
+func (svc *OrderService) ListCustomerOrders(
+	ctx context.Context,
+	principal *auth.Principal,
+	customerId string,
+	query *orders.OrderQuery,
+) (*OrderPage, error) {
+	// customers who signed in see their orders through ListOrders
+	if principal != nil && principal.IsCustomer() {
+		return nil, forbidden("customers can't look up other customers' accounts")
+	} else if svc.Customers == nil {
+		return nil, apierrors.New(apierrors.CodeCustomerNotFound, "Customer ID [%s] does not exist", customerId).
+			With("customerId", customerId)
+	}
+
+	if _, err := svc.Customers.GetCustomer(customerId); err != nil {
+		return nil, apierrors.From(err)
+	}
+	verified, err := svc.Customers.VerifiedAddresses(customerId)
+	if err != nil {
+		return nil, apierrors.Internal(err)
+	}
+
+	if len(query.BuyerAddress) != 0 {
+		// it has to be one of the customer's verified addresses
+		if _, err = svc.Customers.ResolveRecipient(customerId, query.BuyerAddress); err != nil {
+			return nil, apierrors.From(err)
+		}
+	} else if len(verified) == 0 {
+		// no filter at all would list everybody's orders
+		return &OrderPage{Orders: []*orders.Order{}}, nil
+	} else {
+		query.BuyerAddresses = verified
+	}
+	return svc.ListOrders(ctx, principal, query)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/orders"
+)
+
+func orderNotFound(orderId string) *apierrors.Error {
+	return apierrors.New(apierrors.CodeOrderNotFound, "Order ID [%s] does not exist", orderId).
+		With("orderId", orderId)
+}
+
+func forbidden(reason string) *apierrors.Error {
+	return apierrors.New(apierrors.CodeForbidden, reason)
+}
+
+// Describes an order that isn't in a status that allows what was asked for
+func transitionError(err *orders.InvalidTransitionError) *apierrors.Error {
+	code := apierrors.CodeOrderStatusConflict
+	if err.To == orders.StatusPaid && (err.From == orders.StatusPaid || err.From == orders.StatusDelivered ||
+		err.From == orders.StatusReleased || err.From == orders.StatusBurned || err.From == orders.StatusRefunded) {
+		code = apierrors.CodeOrderAlreadyPaid
+	}
+	return apierrors.Wrap(err, code, err.Error()).
+		With("currentStatus", err.From).
+		With("requestedStatus", err.To)
+}
+
+// Describes an error from the blockchain node without passing on what the node said, which
+// means nothing to the client and says too much about our setup
+func chainError(err error) *apierrors.Error {
+	message := err.Error()
+	switch {
+	case errors.Is(err, contract.ErrTransactionReverted), strings.Contains(message, "execution reverted"):
+		return apierrors.Wrap(err, apierrors.CodeTxReverted, "The contract rejected the transaction")
+	case strings.Contains(message, "insufficient funds"):
+		return apierrors.Wrap(err, apierrors.CodeInsufficientFunds,
+			"The account doesn't have enough ether to pay for the transaction and its gas")
+	case unreachable(err):
+		return apierrors.Wrap(err, apierrors.CodeChainUnavailable, "The blockchain node can't be reached; try again later")
+	default:
+		return apierrors.Wrap(err, apierrors.CodeChainError, "The blockchain node refused the request")
+	}
+}
+
+// Whether the error means the node couldn't be reached at all
+func unreachable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
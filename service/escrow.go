@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/bdunton9323/blockchain-playground/validation"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	log "github.com/sirupsen/logrus"
+)
+
+// the longest evidence reference that can be stored
+var MaxEvidenceReferenceLength = 512
+
+// What's needed to record that an order's package was dropped off
+type EvidenceInput struct {
+	OrderId string
+	// where the proof is kept, e.g. the courier's drop-off record or a photo's URL
+	Reference string
+}
+
+// Records proof that the order's package was dropped off, so that the vendor can claim the escrow if the
+// customer never accepts delivery. Only orders that are paid for and not yet delivered need it.
+func (svc *OrderService) RecordDeliveryEvidence(
+	ctx context.Context,
+	principal *auth.Principal,
+	input *EvidenceInput,
+) (_ *orders.DeliveryEvidence, err error) {
+	ctx, span := tracing.Start(ctx, "service.RecordDeliveryEvidence", tracing.OrderId.String(input.OrderId))
+	defer tracing.End(span, &err)
+
+	if principal != nil && principal.IsCustomer() {
+		return nil, forbidden("customers accept delivery by buying the token")
+	}
+
+	v := &validation.Validator{}
+	v.OrderId("orderId", input.OrderId)
+	if v.Required("reference", input.Reference) && len(input.Reference) > MaxEvidenceReferenceLength {
+		v.Add("reference", apierrors.CodeInvalidParameter, "reference can be at most %d characters", MaxEvidenceReferenceLength)
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	order, err := svc.GetOrder(ctx, principal, input.OrderId)
+	if err != nil {
+		return nil, err
+	} else if order.Status != orders.StatusPaid {
+		return nil, apierrors.New(apierrors.CodeOrderStatusConflict,
+			"Evidence of delivery is only needed for orders that are paid for but not delivered").
+			With("currentStatus", order.Status)
+	}
+
+	evidence := &orders.DeliveryEvidence{
+		OrderId:   order.OrderId,
+		Reference: input.Reference,
+		Hash:      crypto.Keccak256Hash([]byte(input.Reference)).Hex(),
+	}
+	if principal != nil {
+		evidence.RecordedBy = principal.Subject
+	} else {
+		executor, err := svc.executorFor(order.VendorId)
+		if err != nil {
+			return nil, err
+		}
+		evidence.RecordedBy = executor.VendorAddress.Hex()
+	}
+	if err = svc.Orders.RecordDeliveryEvidence(ctx, evidence); err != nil {
+		return nil, apierrors.Internal(err)
+	}
+	log.Infof("Recorded evidence of delivery for order [%s]", order.OrderId)
+	return evidence, nil
+}
+
+// Claims the expired escrow for an order the customer never accepted. The vendor is paid the order's price
+// and the token goes to the customer, as if they had bought it. Needs evidence of delivery.
+func (svc *OrderService) ReleaseEscrow(ctx context.Context, principal *auth.Principal, orderId string) (_ *OperationResult, err error) {
+	ctx, span := tracing.Start(ctx, "service.ReleaseEscrow", tracing.OrderId.String(orderId))
+	defer tracing.End(span, &err)
+
+	if principal != nil && !principal.HasRole(auth.RoleVendorAdmin) {
+		return nil, forbidden("only the vendor can claim an expired escrow")
+	}
+
+	order, err := svc.GetOrder(ctx, principal, orderId)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateTransition(order, orders.StatusReleased); err != nil {
+		return nil, err
+	}
+	executor, err := svc.checkEscrowExpired(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	evidence, err := svc.Orders.GetDeliveryEvidence(ctx, order.OrderId)
+	if err != nil {
+		return nil, apierrors.Internal(err)
+	} else if evidence == nil {
+		return nil, apierrors.New(apierrors.CodeDeliveryEvidenceMissing,
+			"Order ID [%s] has no evidence of delivery", order.OrderId).With("orderId", order.OrderId)
+	}
+
+	log.Infof("Releasing the expired escrow for order [%s]", order.OrderId)
+	return svc.runOperation(ctx, order, orders.OperationRelease, executor.VendorAddress.Hex(), "")
+}
+
+// Returns the customer's payment from an expired escrow for an order that was never delivered. Customers
+// sign the refund with their own key; the vendor can refund them without it.
+func (svc *OrderService) RefundEscrow(ctx context.Context, principal *auth.Principal, input *CustomerOperationInput) (_ *OperationResult, err error) {
+	ctx, span := tracing.Start(ctx, "service.RefundEscrow", tracing.OrderId.String(input.OrderId))
+	defer tracing.End(span, &err)
+
+	if principal != nil && principal.HasRole(auth.RoleCourier) {
+		return nil, forbidden("couriers can't refund orders")
+	}
+
+	customerSigns := len(input.CustomerKey) != 0 || (principal != nil && principal.IsCustomer())
+	customerKey := ""
+	if customerSigns {
+		if customerKey, err = validateCustomerOperation(input); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err := svc.GetOrder(ctx, principal, input.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateTransition(order, orders.StatusRefunded); err != nil {
+		return nil, err
+	}
+	executor, err := svc.checkEscrowExpired(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Refunding the expired escrow for order [%s]", order.OrderId)
+	if !customerSigns {
+		return svc.runOperation(ctx, order, orders.OperationRefund, executor.VendorAddress.Hex(), "")
+	}
+	customerAddress, err := customerAddress(customerKey)
+	if err != nil {
+		return nil, err
+	}
+	return svc.runOperation(ctx, order, orders.OperationReclaim, customerAddress.Hex(), customerKey)
+}
+
+// Makes sure the contract can settle the order's escrow without the customer, and that the escrow has expired.
+// Returns the executor for the order's vendor, who signs the settlement.
+func (svc *OrderService) checkEscrowExpired(ctx context.Context, order *orders.Order) (*contract.DeliveryContractExecutor, error) {
+	executor, err := svc.executorFor(order.VendorId)
+	if err != nil {
+		return nil, err
+	} else if svc.EscrowSupported == nil || !svc.EscrowSupported(order.VendorId) {
+		return nil, apierrors.New(apierrors.CodeEscrowUnsupported,
+			"The delivery contract of vendor [%s] doesn't support escrow expiry", order.VendorId).
+			With("vendorId", order.VendorId)
+	} else if common.HexToAddress(order.TokenAddress) != *executor.ContractAddress {
+		return nil, apierrors.New(apierrors.CodeEscrowUnsupported,
+			"Order ID [%s] belongs to a delivery contract that has since been replaced", order.OrderId).
+			With("orderId", order.OrderId)
+	}
+
+	expiresAt, err := executor.EscrowExpiresAt(ctx, order.TokenId)
+	if err != nil {
+		return nil, chainError(err)
+	} else if expiresAt.IsZero() {
+		return nil, apierrors.New(apierrors.CodeOrderStatusConflict, "The contract has no payment for order ID [%s]", order.OrderId).
+			With("currentStatus", order.Status)
+	} else if time.Now().Before(expiresAt) {
+		return nil, apierrors.New(apierrors.CodeEscrowNotExpired, "The escrow for order ID [%s] expires at %s",
+			order.OrderId, expiresAt.Format(time.RFC3339)).
+			With("expiresAt", expiresAt)
+	}
+	return executor, nil
+}
@@ -0,0 +1,565 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/auth"
+	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/customers"
+	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/outbox"
+	"github.com/bdunton9323/blockchain-playground/products"
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/bdunton9323/blockchain-playground/tracking"
+	"github.com/bdunton9323/blockchain-playground/validation"
+	"github.com/bdunton9323/blockchain-playground/vendors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// how many orders a page holds when the caller doesn't say
+var DefaultOrderPageSize = 50
+
+// the most orders a caller can ask for in one page
+var MaxOrderPageSize = 200
+
+// What's needed to place an order
+type CreateOrderInput struct {
+	// the ID of the product to order
+	ItemId string
+	// the ethereum address of the customer who can accept the delivery
+	BuyerAddress string
+	// A customer account to deliver to instead. BuyerAddress then picks one of the addresses the customer has
+	// verified; without it, the order goes to a custodial customer's deposit address, or the first address anyone
+	// else verified.
+	CustomerId string
+}
+
+// What's needed for an operation the customer signs, i.e. paying for an order or accepting its delivery
+type CustomerOperationInput struct {
+	OrderId string
+	// The customer's private key, as hex. It signs the transaction and is only held in memory. This would be a
+	// terrible idea in real life, but it demonstrates the functionality of the contract. Leave it out for
+	// custodial customers; the service signs for them.
+	CustomerKey string
+}
+
+// The outcome of a chain operation on an order
+type OperationResult struct {
+	// The order, with the status the operation left it in. If the operation is still pending the status is unchanged.
+	Order *orders.Order
+	// true if the order was recorded but the transaction hasn't been mined yet. The outbox dispatcher
+	// will keep at it in the background.
+	Pending bool
+	// the transaction that carries out the operation, if it has been sent
+	TxHash string
+	// the vendor's delivery contract, which holds the order's token. Only set for new orders.
+	ContractAddress string
+}
+
+// One page of a search
+type OrderPage struct {
+	Orders []*orders.Order
+	// where the next page starts. Nil if this is the last page.
+	Next *orders.OrderCursor
+}
+
+// An order along with every status it has moved through, oldest first
+type OrderHistory struct {
+	Order   *orders.Order
+	Changes []*orders.StatusChange
+}
+
+// The order flows, independent of how they are called. The REST and gRPC APIs are both thin layers on top of this.
+//
+// Every method takes the authenticated caller. Customers only get to see and act on their own orders; to them,
+// anybody else's order doesn't exist. A nil principal is a trusted caller inside the service and can do anything.
+//
+// Errors are always *apierrors.Error, so callers can tell what went wrong without looking at the message.
+type OrderService struct {
+	Orders   orders.OrderRepository
+	Products products.ProductRepository
+	// the vendors in the marketplace, and the executors that sign for them on their delivery contracts
+	Vendors *vendors.Registry
+	// carries out the chain operations that are recorded in the outbox
+	Dispatcher *outbox.Dispatcher
+	// tells clients watching an order about changes that don't go through the dispatcher
+	Tracker *tracking.Hub
+	// streams an order's updates to clients watching it
+	Follower *tracking.Follower
+	// whether the vendor's delivery contract lets expired escrow be released or refunded. If nil, no vendor's does.
+	EscrowSupported func(vendorId string) bool
+	// customers' accounts and address books, and the keys of custodial customers
+	Customers *customers.Registry
+}
+
+// Places an order for the item, to be delivered to the buyer, and mints its delivery token in the contract of the
+// vendor who sells the item
+func (svc *OrderService) CreateOrder(ctx context.Context, principal *auth.Principal, input *CreateOrderInput) (_ *OperationResult, err error) {
+	ctx, span := tracing.Start(ctx, "service.CreateOrder")
+	defer tracing.End(span, &err)
+
+	v := &validation.Validator{}
+	if !v.Required("itemId", input.ItemId) {
+		return nil, v.Err()
+	}
+
+	// the vendor decides who can't receive deliveries, so the product has to be looked up first
+	product, err := svc.Products.GetProduct(input.ItemId)
+	if err != nil {
+		return nil, apierrors.Internal(err)
+	} else if product == nil || !principal.CanActFor(product.VendorId) {
+		return nil, apierrors.New(apierrors.CodeProductNotFound, "Product ID [%s] does not exist", input.ItemId).
+			With("itemId", input.ItemId)
+	}
+	executor, err := svc.executorFor(product.VendorId)
+	if err != nil {
+		return nil, err
+	}
+
+	buyer := ""
+	if len(input.CustomerId) == 0 {
+		buyer = v.Recipient("buyerAddress", input.BuyerAddress, *executor.VendorAddress)
+	} else if len(input.BuyerAddress) != 0 {
+		// picks one of the customer's addresses
+		v.Recipient("buyerAddress", input.BuyerAddress, *executor.VendorAddress)
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(input.CustomerId) != 0 {
+		if svc.Customers == nil {
+			return nil, apierrors.New(apierrors.CodeCustomerNotFound, "Customer ID [%s] does not exist", input.CustomerId).
+				With("customerId", input.CustomerId)
+		}
+		if buyer, err = svc.Customers.ResolveRecipient(input.CustomerId, input.BuyerAddress); err != nil {
+			return nil, apierrors.From(err)
+		}
+	}
+
+	// customers can only order things to be delivered to themselves
+	if principal != nil && principal.IsCustomer() && principal.Address != buyer {
+		return nil, forbidden("customers can only place orders for their own address")
+	}
+
+	// record the order before minting so that a failed mint is still visible
+	order := &orders.Order{
+		OrderId:       uuid.New().String(),
+		ItemId:        product.ProductId,
+		ItemName:      product.Name,
+		Price:         product.Price,
+		DeliveryPrice: product.ShippingPrice,
+		Status:        orders.StatusCreated,
+		BuyerAddress:  buyer,
+		VendorId:      product.VendorId,
+	}
+	trace.SpanFromContext(ctx).SetAttributes(tracing.OrderId.String(order.OrderId))
+
+	// the order and the intent to mint its token are recorded together, so a crash can't leave
+	// a token on chain without an order in the database
+	entry, err := svc.Orders.CreateOrderWithMint(ctx, order, executor.VendorAddress.Hex())
+	if err != nil {
+		log.Errorf("Could not write order [%s] to the database: %v", order.OrderId, err)
+		return nil, apierrors.Internal(err)
+	}
+
+	entry, err = svc.Dispatcher.Execute(ctx, entry, "")
+	if entry.Status != orders.OutboxCompleted {
+		if entry.Status != orders.OutboxFailed {
+			// the dispatcher will keep trying in the background
+			log.Warnf("Minting for order [%s] is delayed: %v", order.OrderId, err)
+		}
+		return operationResult(order, entry, err)
+	}
+
+	// Pick up the token the mint produced. The token exists whether or not this works, so failing here would
+	// only make the client retry, and a retry with the same Idempotency-Key would mint another one.
+	minted, lookupErr := svc.Orders.GetOrder(ctx, order.OrderId)
+	if lookupErr != nil || minted == nil {
+		log.Warnf("Order [%s] was minted, but could not be read back: %v", order.OrderId, lookupErr)
+		minted = order
+		minted.Status = entry.Operation.ResultingStatus()
+	}
+	return &OperationResult{
+		Order:           minted,
+		TxHash:          entry.TxHash,
+		ContractAddress: executor.ContractAddress.Hex(),
+	}, nil
+}
+
+// Pays the price of the goods from the customer to the delivery contract
+func (svc *OrderService) PayForOrder(ctx context.Context, principal *auth.Principal, input *CustomerOperationInput) (_ *OperationResult, err error) {
+	ctx, span := tracing.Start(ctx, "service.PayForOrder", tracing.OrderId.String(input.OrderId))
+	defer tracing.End(span, &err)
+
+	order, customerKey, err := svc.customerOperation(ctx, principal, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// don't bother the blockchain if the order can't be paid for anyway
+	if err = validateTransition(order, orders.StatusPaid); err != nil {
+		return nil, err
+	}
+
+	customerAddress, err := customerAddress(customerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Paying [%d] wei for order [%v]", order.Price, order.OrderId)
+	return svc.runOperation(ctx, order, orders.OperationPay, customerAddress.Hex(), customerKey)
+}
+
+// Delivers the order to the customer. This is represented by transferring the token from the vendor to
+// the customer, and transferring Ether from the customer to the vendor to pay for shipping.
+func (svc *OrderService) DeliverOrder(ctx context.Context, principal *auth.Principal, input *CustomerOperationInput) (_ *OperationResult, err error) {
+	ctx, span := tracing.Start(ctx, "service.DeliverOrder", tracing.OrderId.String(input.OrderId))
+	defer tracing.End(span, &err)
+
+	log.Infof("Delivering order [%v]", input.OrderId)
+
+	order, customerKey, err := svc.customerOperation(ctx, principal, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validateTransition(order, orders.StatusDelivered); err != nil {
+		return nil, err
+	}
+
+	customerAddress, err := customerAddress(customerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// buy the token from the vendor, thereby accepting delivery of the package
+	return svc.runOperation(ctx, order, orders.OperationDeliver, customerAddress.Hex(), customerKey)
+}
+
+// Destroys the token that represents the delivery. The contract only allows this after delivery.
+func (svc *OrderService) BurnToken(ctx context.Context, principal *auth.Principal, orderId string) (_ *OperationResult, err error) {
+	ctx, span := tracing.Start(ctx, "service.BurnToken", tracing.OrderId.String(orderId))
+	defer tracing.End(span, &err)
+
+	// couriers hand over packages; what happens to the receipt afterwards is up to the vendor and the customer
+	if principal != nil && principal.HasRole(auth.RoleCourier) {
+		return nil, forbidden("couriers can only mark orders as delivered")
+	}
+
+	order, err := svc.GetOrder(ctx, principal, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validateTransition(order, orders.StatusBurned); err != nil {
+		return nil, err
+	}
+
+	executor, err := svc.executorFor(order.VendorId)
+	if err != nil {
+		return nil, err
+	}
+	// either the token ID is invalid or it was already burned
+	if _, err = executor.IsDelivered(ctx, order.TokenId); err != nil {
+		return nil, chainError(err)
+	}
+
+	return svc.runOperation(ctx, order, orders.OperationBurn, executor.VendorAddress.Hex(), "")
+}
+
+// Calls off an order that has not been paid for yet. Nothing happens on chain; the token
+// (if it was minted) stays with the vendor.
+func (svc *OrderService) CancelOrder(ctx context.Context, principal *auth.Principal, orderId string) (_ *orders.Order, err error) {
+	ctx, span := tracing.Start(ctx, "service.CancelOrder", tracing.OrderId.String(orderId))
+	defer tracing.End(span, &err)
+
+	if principal != nil && principal.HasRole(auth.RoleCourier) {
+		return nil, forbidden("couriers can only mark orders as delivered")
+	}
+
+	order, err := svc.GetOrder(ctx, principal, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	executor, err := svc.executorFor(order.VendorId)
+	if err != nil {
+		return nil, err
+	}
+	err = svc.Orders.TransitionOrder(ctx, orderId, orders.StatusCanceled, executor.VendorAddress.Hex(), "")
+	var transitionErr *orders.InvalidTransitionError
+	if errors.As(err, &transitionErr) {
+		return nil, transitionError(transitionErr)
+	} else if err != nil {
+		return nil, apierrors.Internal(err)
+	}
+
+	svc.Tracker.Publish(&tracking.Update{
+		OrderId:    orderId,
+		Type:       tracking.UpdateStatus,
+		Status:     string(orders.StatusCanceled),
+		FromStatus: string(order.Status),
+	})
+	order.Status = orders.StatusCanceled
+	return order, nil
+}
+
+// Looks up an order the caller is allowed to see
+func (svc *OrderService) GetOrder(ctx context.Context, principal *auth.Principal, orderId string) (*orders.Order, error) {
+	v := &validation.Validator{}
+	v.OrderId("orderId", orderId)
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	// so the request's trace can be found by order
+	trace.SpanFromContext(ctx).SetAttributes(tracing.OrderId.String(orderId))
+
+	order, err := svc.Orders.GetOrder(ctx, orderId)
+	if err != nil {
+		return nil, apierrors.Internal(err)
+	} else if order == nil || !canSee(principal, order) {
+		return nil, orderNotFound(orderId)
+	}
+	return order, nil
+}
+
+// Determines who currently owns the order's delivery token - the vendor or the customer.
+// This asks the contract rather than trusting the database.
+func (svc *OrderService) GetTokenOwner(ctx context.Context, principal *auth.Principal, orderId string) (string, error) {
+	order, err := svc.GetOrder(ctx, principal, orderId)
+	if err != nil {
+		return "", err
+	} else if order.TokenId == 0 {
+		return "", apierrors.New(apierrors.CodeDeliveryTokenNotFound, "Order ID [%s] does not have a delivery token", orderId).
+			With("orderId", orderId)
+	}
+
+	executor, err := svc.executorFor(order.VendorId)
+	if err != nil {
+		return "", err
+	}
+	owner, err := executor.GetOwner(ctx, order.TokenId)
+	if err != nil {
+		return "", chainError(err)
+	}
+	return owner, nil
+}
+
+// Returns the order along with every status it has moved through
+func (svc *OrderService) GetOrderHistory(ctx context.Context, principal *auth.Principal, orderId string) (*OrderHistory, error) {
+	order, err := svc.GetOrder(ctx, principal, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := svc.Orders.GetStatusHistory(ctx, orderId)
+	if err != nil {
+		return nil, apierrors.Internal(err)
+	}
+	return &OrderHistory{
+		Order:   order,
+		Changes: changes,
+	}, nil
+}
+
+// Searches the orders. Customers only get to search their own. A zero limit gets the default page size.
+func (svc *OrderService) ListOrders(ctx context.Context, principal *auth.Principal, query *orders.OrderQuery) (*OrderPage, error) {
+	// addresses are stored in their checksummed form
+	v := &validation.Validator{}
+	if len(query.BuyerAddress) != 0 {
+		query.BuyerAddress = v.Address("buyerAddress", query.BuyerAddress)
+	}
+	if len(query.TokenAddress) != 0 {
+		query.TokenAddress = v.Address("tokenAddress", query.TokenAddress)
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	if principal != nil && principal.IsCustomer() {
+		if len(query.BuyerAddress) != 0 && query.BuyerAddress != principal.Address {
+			return nil, forbidden("customers can only list their own orders")
+		}
+		query.BuyerAddress = principal.Address
+	}
+	// a vendor's staff only get to see the vendor's own orders
+	if principal != nil && principal.ScopedToVendor() {
+		if len(query.VendorId) != 0 && query.VendorId != principal.VendorId {
+			return nil, forbidden("only the vendor's own orders can be listed")
+		}
+		query.VendorId = principal.VendorId
+	}
+
+	if query.SortBy == "" {
+		query.SortBy = orders.SortByCreatedAt
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultOrderPageSize
+	} else if query.Limit < 1 || query.Limit > MaxOrderPageSize {
+		return nil, apierrors.New(apierrors.CodeInvalidParameter, "limit must be between 1 and %d", MaxOrderPageSize).
+			With("fields", []string{"limit"})
+	}
+	if err := query.CheckCursor(); err != nil {
+		return nil, apierrors.Wrap(err, apierrors.CodeInvalidCursor, "The cursor belongs to a differently sorted or filtered list")
+	}
+
+	page, next, err := svc.Orders.ListOrders(ctx, query)
+	if err != nil {
+		return nil, apierrors.Internal(err)
+	}
+	return &OrderPage{
+		Orders: page,
+		Next:   next,
+	}, nil
+}
+
+// Sends the order's updates to the sink until the context is canceled or the sink fails
+func (svc *OrderService) WatchOrder(ctx context.Context, principal *auth.Principal, orderId string, sink tracking.Sink) error {
+	order, err := svc.GetOrder(ctx, principal, orderId)
+	if err != nil {
+		return err
+	}
+	return svc.Follow(ctx, order, sink)
+}
+
+// Sends the updates of an order the caller has already looked up. Lets transports check that the order can be
+// watched before they commit to streaming it.
+func (svc *OrderService) Follow(ctx context.Context, order *orders.Order, sink tracking.Sink) error {
+	if err := svc.Follower.Follow(ctx, order, sink); err != nil {
+		return apierrors.Internal(err)
+	}
+	return nil
+}
+
+// Records the intent to perform a chain operation and makes the first attempt at it
+func (svc *OrderService) runOperation(
+	ctx context.Context,
+	order *orders.Order,
+	op orders.Operation,
+	actorAddress string,
+	signingKey string,
+) (*OperationResult, error) {
+	entry, err := svc.Orders.EnqueueOperation(ctx, order.OrderId, op, actorAddress)
+	if errors.Is(err, orders.ErrOperationInProgress) {
+		return nil, apierrors.New(apierrors.CodeOrderOperationInProgress,
+			"Order [%s] already has an operation in progress", order.OrderId).
+			With("orderId", order.OrderId)
+	} else if err != nil {
+		return nil, apierrors.Internal(err)
+	}
+
+	entry, err = svc.Dispatcher.Execute(ctx, entry, signingKey)
+	return operationResult(order, entry, err)
+}
+
+// Describes where the outbox entry for an operation on the order ended up after its first attempt
+func operationResult(order *orders.Order, entry *orders.OutboxEntry, err error) (*OperationResult, error) {
+	switch entry.Status {
+	case orders.OutboxCompleted:
+		order.Status = entry.Operation.ResultingStatus()
+		return &OperationResult{
+			Order:  order,
+			TxHash: entry.TxHash,
+		}, nil
+	case orders.OutboxFailed:
+		return nil, chainError(err)
+	default:
+		return &OperationResult{
+			Order:   order,
+			Pending: true,
+			TxHash:  entry.TxHash,
+		}, nil
+	}
+}
+
+// Makes sure the order can move to the given status
+func validateTransition(order *orders.Order, next orders.OrderStatus) error {
+	var transitionErr *orders.InvalidTransitionError
+	if errors.As(order.ValidateTransition(next), &transitionErr) {
+		return transitionError(transitionErr)
+	}
+	return nil
+}
+
+// Checks the order ID and the customer's key together, so a client that got both wrong hears about both.
+// Returns the key in the form the contract executor wants.
+func validateCustomerOperation(input *CustomerOperationInput) (string, error) {
+	v := &validation.Validator{}
+	v.OrderId("orderId", input.OrderId)
+	customerKey := v.PrivateKey("customerKey", input.CustomerKey)
+	return customerKey, v.Err()
+}
+
+// Looks up the order a customer operation is for, and the key that signs it. That's the key the caller sent,
+// or if they left it out and the order is for a custodial customer, the key the service derives for them.
+func (svc *OrderService) customerOperation(
+	ctx context.Context,
+	principal *auth.Principal,
+	input *CustomerOperationInput,
+) (*orders.Order, string, error) {
+	// customers who signed in have wallets of their own
+	custodial := len(input.CustomerKey) == 0 && svc.Customers != nil && svc.Customers.Enabled() &&
+		(principal == nil || !principal.IsCustomer())
+	if !custodial {
+		customerKey, err := validateCustomerOperation(input)
+		if err != nil {
+			return nil, "", err
+		}
+		order, err := svc.GetOrder(ctx, principal, input.OrderId)
+		return order, customerKey, err
+	}
+
+	v := &validation.Validator{}
+	v.OrderId("orderId", input.OrderId)
+	if err := v.Err(); err != nil {
+		return nil, "", err
+	}
+	order, err := svc.GetOrder(ctx, principal, input.OrderId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	customerKey, ok, err := svc.Customers.PrivateKeyFor(order.BuyerAddress)
+	if err != nil {
+		return nil, "", apierrors.Internal(err)
+	} else if !ok {
+		// not one of ours, so the customer has to sign
+		_, err = validateCustomerOperation(input)
+		return nil, "", err
+	}
+	return order, customerKey, nil
+}
+
+// Works out the customer's address from their private key
+func customerAddress(customerKey string) (*common.Address, error) {
+	address, err := contract.AddressFromPrivateKey(customerKey)
+	if err != nil {
+		return nil, apierrors.Wrap(err, apierrors.CodeInvalidPrivateKey, "customerKey is not a valid private key").
+			With("fields", []string{"customerKey"})
+	}
+	return address, nil
+}
+
+// Returns the executor that signs for the vendor on their contract
+func (svc *OrderService) executorFor(vendorId string) (*contract.DeliveryContractExecutor, error) {
+	executor, err := svc.Vendors.Executor(vendorId)
+	if err != nil {
+		return nil, apierrors.From(err)
+	}
+	return executor, nil
+}
+
+// Customers may only see orders that are to be delivered to them, and a vendor's staff only the vendor's orders
+func canSee(principal *auth.Principal, order *orders.Order) bool {
+	if !principal.CanActFor(order.VendorId) {
+		return false
+	}
+	return principal == nil || !principal.IsCustomer() || order.BuyerAddress == principal.Address
+}
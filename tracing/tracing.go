@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// what the service calls itself in traces
+var ServiceName = "blockchain-playground"
+
+// Attributes that tie a span to the order it was working on
+var (
+	OrderId = attribute.Key("order.id")
+	TokenId = attribute.Key("token.id")
+	TxHash  = attribute.Key("tx.hash")
+)
+
+// the header W3C trace context travels in
+var traceParentHeader = "traceparent"
+
+var tracer = otel.Tracer("github.com/bdunton9323/blockchain-playground")
+
+// Sends spans to an OpenTelemetry collector at endpoint (host:port) over OTLP/gRPC, and makes incoming and
+// outgoing requests carry W3C traceparent headers. Call the returned function on the way out so the last
+// spans aren't lost.
+//
+// If endpoint is empty, no spans are recorded, but trace context is still passed along so that the services
+// on either side of this one can still join up their traces.
+func Setup(ctx context.Context, endpoint string, insecure bool) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if len(endpoint) == 0 {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+
+	options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(5*time.Second)),
+		sdktrace.WithResource(serviceResource()),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Records spans in memory instead of sending them anywhere, so tests can look at what was traced
+func SetupInMemory() *tracetest.InMemoryExporter {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithResource(serviceResource()),
+	))
+	return exporter
+}
+
+func serviceResource() *resource.Resource {
+	return resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(ServiceName))
+}
+
+// Starts a span as a child of whatever span is in the context
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// Ends the span, marking it failed if there was an error. err is a pointer so that this can be deferred and
+// see the error that was finally returned.
+func End(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+// Returns a context that carries the same span but is never canceled. Work that has to finish even if the
+// request that started it goes away, like waiting for a transaction to be mined, still shows up in the
+// request's trace.
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
+
+// Returns the W3C traceparent of the span in the context, or "" if there isn't one. Work that is written down to
+// be done later keeps this, so that whoever picks it up can carry on the same trace with ContinueTrace.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get(traceParentHeader)
+}
+
+// Returns a context whose spans are part of the trace that traceParent (as returned by TraceParent) came from.
+// If traceParent is empty or malformed, spans started from the context begin a trace of their own.
+func ContinueTrace(ctx context.Context, traceParent string) context.Context {
+	if len(traceParent) == 0 {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{traceParentHeader: traceParent})
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// the provider can only be set up once per test binary
+var exporter = SetupInMemory()
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	exporter.Reset()
+
+	ctx, request := Start(context.Background(), "request")
+	traceParent := TraceParent(ctx)
+	request.End()
+
+	requestContext := request.SpanContext()
+	expected := fmt.Sprintf("00-%s-%s-01", requestContext.TraceID(), requestContext.SpanID())
+	if traceParent != expected {
+		t.Fatalf("expected traceparent [%s], got [%s]", expected, traceParent)
+	}
+
+	// picked up later, with none of the request's context left
+	_, later := Start(ContinueTrace(context.Background(), traceParent), "later")
+	later.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	continued := spans[1]
+	if continued.SpanContext.TraceID() != requestContext.TraceID() {
+		t.Errorf("expected the later span to be in trace [%s], got [%s]", requestContext.TraceID(), continued.SpanContext.TraceID())
+	}
+	if continued.Parent.SpanID() != requestContext.SpanID() || !continued.Parent.IsRemote() {
+		t.Errorf("expected the later span to be a child of [%s], got [%s]", requestContext.SpanID(), continued.Parent.SpanID())
+	}
+}
+
+func TestContinueTraceWithoutTraceParent(t *testing.T) {
+	if traceParent := TraceParent(context.Background()); traceParent != "" {
+		t.Errorf("expected no traceparent outside a span, got [%s]", traceParent)
+	}
+
+	for _, traceParent := range []string{"", "not-a-traceparent"} {
+		ctx := ContinueTrace(context.Background(), traceParent)
+		if trace.SpanContextFromContext(ctx).IsValid() {
+			t.Errorf("expected [%s] to leave the context without a parent", traceParent)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package tracking
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/orders"
+	log "github.com/sirupsen/logrus"
+)
+
+// Where a watcher's updates go, e.g. a Server-Sent Events stream or a WebSocket
+type Sink interface {
+	Send(update *Update) error
+	// Lets the client (and anything in between) know the connection is still alive
+	Heartbeat() error
+}
+
+// Looks up how deeply a transaction is buried in the chain
+type ConfirmationCounter interface {
+	GetConfirmations(ctx context.Context, txHash string) (uint64, error)
+}
+
+// Streams an order's updates to a client: first where the order is now, then every change as it happens.
+// Transactions are followed until they are buried deep enough that the client can stop worrying about them.
+type Follower struct {
+	hub        *Hub
+	repository orders.OrderRepository
+	chain      ConfirmationCounter
+
+	// how many confirmations to report before a transaction is considered settled
+	TargetConfirmations uint64
+	// how often to check on the transactions being followed
+	PollInterval time.Duration
+	// how often to let the client know the connection is still alive
+	HeartbeatInterval time.Duration
+
+	// closed by Stop
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// Constructs a new follower with reasonable defaults
+func NewFollower(hub *Hub, repository orders.OrderRepository, chain ConfirmationCounter) *Follower {
+	return &Follower{
+		hub:                 hub,
+		repository:          repository,
+		chain:               chain,
+		TargetConfirmations: 12,
+		PollInterval:        3 * time.Second,
+		HeartbeatInterval:   15 * time.Second,
+		stopped:             make(chan struct{}),
+	}
+}
+
+// Ends every stream, now and from then on, for when the service is shutting down. Streams never finish on
+// their own, so the servers would otherwise wait for them forever. Clients are expected to reconnect, and
+// will get a fresh snapshot from whichever instance they reach.
+func (_follower *Follower) Stop() {
+	_follower.stopOnce.Do(func() {
+		close(_follower.stopped)
+	})
+}
+
+// Sends the order's updates to the sink until the context is canceled, the sink fails or the follower is
+// stopped
+func (_follower *Follower) Follow(ctx context.Context, order *orders.Order, sink Sink) error {
+	// subscribe before looking at the current state so nothing falls in the gap
+	updates, unsubscribe := _follower.hub.Subscribe(order.OrderId)
+	defer unsubscribe()
+
+	// the transactions still being followed, and how many confirmations the client has heard about
+	following := map[string]uint64{}
+
+	current, err := _follower.snapshot(ctx, order)
+	if err != nil {
+		return err
+	}
+	if err = sink.Send(current); err != nil {
+		return err
+	}
+	if len(current.TxHash) != 0 {
+		following[current.TxHash] = 0
+	}
+
+	poll := time.NewTicker(_follower.PollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(_follower.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		// report confirmations straight away, so the client doesn't wait a poll to hear about them
+		if err = _follower.checkConfirmations(ctx, order.OrderId, following, sink); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-_follower.stopped:
+			return nil
+		case update := <-updates:
+			if err = sink.Send(update); err != nil {
+				return err
+			}
+			if len(update.TxHash) != 0 && update.Type != UpdateFailed {
+				if _, known := following[update.TxHash]; !known {
+					following[update.TxHash] = update.Confirmations
+				}
+			}
+		case <-poll.C:
+		case <-heartbeat.C:
+			if err = sink.Heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Describes where the order is now, along with the transaction that got it there
+func (_follower *Follower) snapshot(ctx context.Context, order *orders.Order) (*Update, error) {
+	history, err := _follower.repository.GetStatusHistory(ctx, order.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	update := &Update{
+		OrderId: order.OrderId,
+		Type:    UpdateStatus,
+		Status:  string(order.Status),
+		Time:    order.CreatedAt,
+	}
+	if len(history) != 0 {
+		latest := history[len(history)-1]
+		update.FromStatus = string(latest.FromStatus)
+		update.TxHash = latest.TxHash
+		update.Time = latest.ChangedAt
+	}
+	return update, nil
+}
+
+// Reports any new confirmations of the transactions being followed, and stops following the settled ones
+func (_follower *Follower) checkConfirmations(ctx context.Context, orderId string, following map[string]uint64, sink Sink) error {
+	for txHash, reported := range following {
+		confirmations, err := _follower.chain.GetConfirmations(ctx, txHash)
+		if err != nil {
+			// the node is probably having a moment; try again next time
+			log.Warnf("Could not count confirmations of [%s]: %v", txHash, err)
+			continue
+		} else if confirmations <= reported {
+			continue
+		}
+
+		following[txHash] = confirmations
+		if confirmations >= _follower.TargetConfirmations {
+			delete(following, txHash)
+		}
+
+		err = sink.Send(&Update{
+			OrderId:       orderId,
+			Type:          UpdateConfirmation,
+			TxHash:        txHash,
+			Confirmations: confirmations,
+			Time:          time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package tracking
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// What kind of thing happened to an order
+type UpdateType string
+
+const (
+	// the order moved into a new status
+	UpdateStatus UpdateType = "status"
+	// a transaction was sent for the order and is waiting to be mined
+	UpdateSubmitted UpdateType = "submitted"
+	// another block was built on top of the order's latest transaction
+	UpdateConfirmation UpdateType = "confirmation"
+	// a chain operation for the order was given up on
+	UpdateFailed UpdateType = "failed"
+)
+
+// Something that happened to an order, as pushed to clients that are watching it
+type Update struct {
+	OrderId string     `json:"orderId"`
+	Type    UpdateType `json:"type"`
+	// the order's status after the update
+	Status string `json:"status,omitempty"`
+	// for status updates, the status the order moved out of
+	FromStatus string `json:"fromStatus,omitempty"`
+	// the chain operation the update is about: one of ('mint', 'pay', 'deliver', 'burn')
+	Operation string `json:"operation,omitempty"`
+	TxHash    string `json:"txHash,omitempty"`
+	// how many blocks include the transaction or were built on top of it. 0 until it is mined.
+	Confirmations uint64 `json:"confirmations"`
+	// why the operation failed
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// how many updates can queue up for a watcher before it is considered too slow and updates are dropped
+var subscriberBuffer = 32
+
+// Passes order updates from wherever they happen to whoever is watching the order. Only watchers in
+// this process hear about updates made in this process; the order's history in the database remains
+// the source of truth. A nil hub silently drops everything, so publishers don't have to check for one.
+type Hub struct {
+	mutex       sync.Mutex
+	subscribers map[string]map[chan *Update]struct{}
+}
+
+// Constructs an empty hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: map[string]map[chan *Update]struct{}{},
+	}
+}
+
+// Starts watching the order. The returned function stops watching and must be called when done.
+func (hub *Hub) Subscribe(orderId string) (<-chan *Update, func()) {
+	updates := make(chan *Update, subscriberBuffer)
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+	if hub.subscribers[orderId] == nil {
+		hub.subscribers[orderId] = map[chan *Update]struct{}{}
+	}
+	hub.subscribers[orderId][updates] = struct{}{}
+
+	return updates, func() {
+		hub.mutex.Lock()
+		defer hub.mutex.Unlock()
+		delete(hub.subscribers[orderId], updates)
+		if len(hub.subscribers[orderId]) == 0 {
+			delete(hub.subscribers, orderId)
+		}
+	}
+}
+
+// Tells everyone watching the order about the update. This never blocks; a watcher that has fallen
+// too far behind misses the update rather than holding up the publisher.
+func (hub *Hub) Publish(update *Update) {
+	if hub == nil {
+		return
+	}
+	if update.Time.IsZero() {
+		update.Time = time.Now().UTC()
+	}
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+	for subscriber := range hub.subscribers[update.OrderId] {
+		select {
+		case subscriber <- update:
+		default:
+			log.Warnf("Dropped a %s update for order [%s] because a watcher is too slow", update.Type, update.OrderId)
+		}
+	}
+}
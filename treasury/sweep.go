@@ -0,0 +1,145 @@
+package treasury
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	log "github.com/sirupsen/logrus"
+)
+
+// Returned when a sweep is asked for but there is no cold wallet to sweep to
+var ErrSweepingDisabled = errors.New("sweeping is off because no cold wallet is configured")
+
+// When the vendor's funds are moved to the cold wallet. Amounts are in wei.
+type SweepPolicy struct {
+	Enabled bool `json:"enabled"`
+	// where the funds go
+	ColdWallet string `json:"coldWallet,omitempty"`
+	// the vendor's balance has to be above this for a sweep to happen
+	Threshold string `json:"threshold" example:"1000000000000000000"`
+	// what is left in the vendor's account, on top of the sweep's own gas
+	Keep string `json:"keep" example:"100000000000000000"`
+	// how often a sweep is attempted; empty if only on request
+	Interval string `json:"interval,omitempty" example:"24h0m0s"`
+}
+
+// What a sweep did
+type SweepResult struct {
+	// whether any funds were sent
+	Swept bool `json:"swept"`
+	// why nothing was sent
+	Reason string `json:"reason,omitempty" example:"the vendor's balance is not above the threshold"`
+	// the vendor's balance before the sweep, counting transactions that haven't been mined yet
+	VendorBalance string `json:"vendorBalance"`
+	// what was sent to the cold wallet
+	Amount     string `json:"amount,omitempty"`
+	ColdWallet string `json:"coldWallet,omitempty"`
+	TxHash     string `json:"txHash,omitempty"`
+	// whether the transfer has been mined. If it hasn't, it may still be.
+	Mined bool `json:"mined"`
+}
+
+// Describes when sweeps happen
+func (_treasury *Treasury) Policy() SweepPolicy {
+	policy := SweepPolicy{
+		Enabled:   _treasury.ColdWallet != nil,
+		Threshold: _treasury.SweepThreshold.String(),
+		Keep:      _treasury.SweepKeep.String(),
+	}
+	if _treasury.ColdWallet != nil {
+		policy.ColdWallet = _treasury.ColdWallet.Hex()
+		if _treasury.SweepInterval > 0 {
+			policy.Interval = _treasury.SweepInterval.String()
+		}
+	}
+	return policy
+}
+
+// Sweeps every SweepInterval until the context is canceled. Run this in its own goroutine.
+func (_treasury *Treasury) Run(ctx context.Context) {
+	log.Infof("Treasury sweeping vendor [%s] to [%s] every %v", _treasury.vendorId, _treasury.ColdWallet.Hex(), _treasury.SweepInterval)
+
+	ticker := time.NewTicker(_treasury.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("Treasury sweeping of vendor [%s] stopped", _treasury.vendorId)
+			return
+		case <-ticker.C:
+		}
+
+		result, err := _treasury.Sweep(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Errorf("Sweep of vendor [%s] failed: %v", _treasury.vendorId, err)
+		} else if err == nil && !result.Swept {
+			log.Infof("Nothing swept for vendor [%s]: %s", _treasury.vendorId, result.Reason)
+		}
+	}
+}
+
+// Sends everything in the vendor's account above SweepKeep to the cold wallet, if the balance is above
+// SweepThreshold. Waits for the transfer to be mined, but returns the result without an error if it isn't
+// mined in time. Returns ErrSweepingDisabled if there is no cold wallet.
+func (_treasury *Treasury) Sweep(ctx context.Context) (_ *SweepResult, err error) {
+	if _treasury.ColdWallet == nil {
+		return nil, ErrSweepingDisabled
+	}
+
+	_treasury.sweeping.Lock()
+	defer _treasury.sweeping.Unlock()
+
+	ctx, span := tracing.Start(ctx, "treasury.Sweep")
+	defer tracing.End(span, &err)
+
+	// counting pending transactions, so a sweep that hasn't been mined yet isn't sent again
+	balance, err := _treasury.executor.Client.PendingBalanceAt(ctx, *_treasury.executor.VendorAddress)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("could not get the vendor's balance: %v", err))
+	}
+	result := &SweepResult{VendorBalance: balance.String()}
+
+	if balance.Cmp(_treasury.SweepThreshold) <= 0 {
+		result.Reason = fmt.Sprintf("the vendor's balance is not above the threshold of %s wei", _treasury.SweepThreshold)
+		return result, nil
+	}
+
+	gasPrice, err := _treasury.executor.Client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("could not get the gas price: %v", err))
+	}
+	gas := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(contract.TransferGas))
+
+	amount := new(big.Int).Sub(balance, _treasury.SweepKeep)
+	amount.Sub(amount, gas)
+	if amount.Sign() <= 0 {
+		result.Reason = fmt.Sprintf("nothing is left above the %s wei to keep once gas is paid", _treasury.SweepKeep)
+		return result, nil
+	}
+
+	txHash, err := _treasury.executor.SubmitTransfer(ctx, *_treasury.ColdWallet, amount, gasPrice)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("could not send the sweep: %v", err))
+	}
+	result.Swept = true
+	result.Amount = amount.String()
+	result.ColdWallet = _treasury.ColdWallet.Hex()
+	result.TxHash = txHash
+	log.Infof("Swept [%s] wei of vendor [%s] to [%s] in [%s]", amount, _treasury.vendorId, _treasury.ColdWallet.Hex(), txHash)
+
+	err = _treasury.executor.WaitForTransaction(ctx, txHash)
+	if errors.Is(err, contract.ErrTransactionNotMined) {
+		log.Warnf("Sweep [%s] hasn't been mined yet: %v", txHash, err)
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+	result.Mined = true
+	return result, nil
+}
@@ -0,0 +1,212 @@
+package treasury
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/orders"
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Where the vendor's money is: in the vendor's account, or held by the delivery contract until orders are
+// delivered. Amounts are in wei, as decimal strings, since they don't fit in 64 bits.
+type BalanceReport struct {
+	At              time.Time `json:"at"`
+	VendorId        string    `json:"vendorId"`
+	VendorAddress   string    `json:"vendorAddress"`
+	VendorBalance   string    `json:"vendorBalance" example:"1000000000000000000"`
+	ContractAddress string    `json:"contractAddress"`
+	// everything the contract holds, which is what customers have paid for orders that haven't been delivered
+	ContractBalance string `json:"contractBalance" example:"150"`
+	// the prices of the orders that are paid for and whose token the vendor still holds on chain
+	Escrowed string `json:"escrowed" example:"150"`
+	// ContractBalance minus Escrowed. Anything but zero means the database and the chain disagree about
+	// which orders are paid for; a reconciliation will say which.
+	Unaccounted string `json:"unaccounted" example:"0"`
+	// the customers with orders in escrow, by address
+	Customers []*CustomerBalance `json:"customers"`
+	Sweep     SweepPolicy        `json:"sweep"`
+}
+
+// A customer's account, and what they have paid into escrow
+type CustomerBalance struct {
+	Address string `json:"address"`
+	// what the customer's account holds
+	Balance string `json:"balance" example:"5000000000000000000"`
+	// the prices of the customer's orders that are paid for but not delivered
+	Escrowed string `json:"escrowed" example:"150"`
+	// the orders the escrowed amount is for
+	OrderIds []string `json:"orderIds"`
+}
+
+// Reports the vendor's balances, and moves the vendor's takings to a cold wallet
+//
+// Every vendor has their own account and contract, so each has a treasury of their own.
+type Treasury struct {
+	repository orders.OrderRepository
+	// the vendor whose money this is, and the executor that signs for them
+	vendorId string
+	executor *contract.DeliveryContractExecutor
+
+	// where sweeps send the vendor's funds. Sweeping is off without one.
+	ColdWallet *common.Address
+	// sweep once the vendor's balance goes above this, in wei
+	SweepThreshold *big.Int
+	// what a sweep leaves in the vendor's account to pay for gas, in wei
+	SweepKeep *big.Int
+	// how often Run sweeps
+	SweepInterval time.Duration
+	// how many orders to read from the database at once
+	BatchSize int
+
+	// sweeps are made one at a time, so a second one sees what the first sent
+	sweeping sync.Mutex
+}
+
+// Constructs a new treasury that doesn't sweep
+func NewTreasury(repository orders.OrderRepository, vendorId string, executor *contract.DeliveryContractExecutor) *Treasury {
+	return &Treasury{
+		repository:     repository,
+		vendorId:       vendorId,
+		executor:       executor,
+		SweepThreshold: new(big.Int),
+		SweepKeep:      new(big.Int),
+		SweepInterval:  24 * time.Hour,
+		BatchSize:      100,
+	}
+}
+
+// Looks up the vendor's and the contract's balances, and works out who the escrowed funds belong to
+func (_treasury *Treasury) Balances(ctx context.Context) (_ *BalanceReport, err error) {
+	ctx, span := tracing.Start(ctx, "treasury.Balances")
+	defer tracing.End(span, &err)
+
+	vendorBalance, err := _treasury.executor.Client.BalanceAt(ctx, *_treasury.executor.VendorAddress, nil)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("could not get the vendor's balance: %v", err))
+	}
+	contractBalance, err := _treasury.executor.Client.BalanceAt(ctx, *_treasury.executor.ContractAddress, nil)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("could not get the contract's balance: %v", err))
+	}
+
+	inEscrow, err := _treasury.ordersInEscrow(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	escrowed := new(big.Int)
+	customers := map[string]*CustomerBalance{}
+	customerEscrow := map[string]*big.Int{}
+	for _, order := range inEscrow {
+		escrowed.Add(escrowed, big.NewInt(order.Price))
+
+		customer := customers[order.BuyerAddress]
+		if customer == nil {
+			customer = &CustomerBalance{Address: order.BuyerAddress, OrderIds: []string{}}
+			customers[order.BuyerAddress] = customer
+			customerEscrow[order.BuyerAddress] = new(big.Int)
+		}
+		customer.OrderIds = append(customer.OrderIds, order.OrderId)
+		customerEscrow[order.BuyerAddress].Add(customerEscrow[order.BuyerAddress], big.NewInt(order.Price))
+	}
+
+	report := &BalanceReport{
+		At:              time.Now().UTC(),
+		VendorId:        _treasury.vendorId,
+		VendorAddress:   _treasury.executor.VendorAddress.Hex(),
+		VendorBalance:   vendorBalance.String(),
+		ContractAddress: _treasury.executor.ContractAddress.Hex(),
+		ContractBalance: contractBalance.String(),
+		Escrowed:        escrowed.String(),
+		Unaccounted:     new(big.Int).Sub(contractBalance, escrowed).String(),
+		Customers:       []*CustomerBalance{},
+		Sweep:           _treasury.Policy(),
+	}
+	for address, customer := range customers {
+		balance, err := _treasury.executor.Client.BalanceAt(ctx, common.HexToAddress(address), nil)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("could not get the balance of [%s]: %v", address, err))
+		}
+		customer.Balance = balance.String()
+		customer.Escrowed = customerEscrow[address].String()
+		report.Customers = append(report.Customers, customer)
+	}
+	sort.Slice(report.Customers, func(i, j int) bool {
+		return report.Customers[i].Address < report.Customers[j].Address
+	})
+	return report, nil
+}
+
+// Looks up one customer's account and what they have in escrow, whether or not they have any orders
+func (_treasury *Treasury) CustomerBalance(ctx context.Context, address common.Address) (_ *CustomerBalance, err error) {
+	ctx, span := tracing.Start(ctx, "treasury.CustomerBalance")
+	defer tracing.End(span, &err)
+
+	balance, err := _treasury.executor.Client.BalanceAt(ctx, address, nil)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("could not get the balance of [%s]: %v", address.Hex(), err))
+	}
+	inEscrow, err := _treasury.ordersInEscrow(ctx, address.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	escrowed := new(big.Int)
+	customer := &CustomerBalance{
+		Address:  address.Hex(),
+		Balance:  balance.String(),
+		OrderIds: []string{},
+	}
+	for _, order := range inEscrow {
+		escrowed.Add(escrowed, big.NewInt(order.Price))
+		customer.OrderIds = append(customer.OrderIds, order.OrderId)
+	}
+	customer.Escrowed = escrowed.String()
+	return customer, nil
+}
+
+// Returns the orders whose price the contract is holding: the ones that are paid for and whose token the
+// vendor still owns. The contract doesn't say whether a token is paid for, so that comes from the database;
+// the chain says whether it has been delivered. buyerAddress narrows it down to one customer if it isn't
+// empty.
+func (_treasury *Treasury) ordersInEscrow(ctx context.Context, buyerAddress string) ([]*orders.Order, error) {
+	inEscrow := []*orders.Order{}
+	query := &orders.OrderQuery{
+		BuyerAddress: buyerAddress,
+		VendorId:     _treasury.vendorId,
+		Statuses:     []orders.OrderStatus{orders.StatusPaid},
+		SortBy:       orders.SortByCreatedAt,
+		Limit:        _treasury.BatchSize,
+	}
+	for {
+		page, next, err := _treasury.repository.ListOrders(ctx, query)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("could not list the paid orders: %v", err))
+		}
+		for _, order := range page {
+			// another contract's escrow isn't in this one's balance
+			if common.HexToAddress(order.TokenAddress) != *_treasury.executor.ContractAddress {
+				continue
+			}
+			owner, err := _treasury.executor.GetOwner(ctx, order.TokenId)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("could not get the owner of token [%d]: %v", order.TokenId, err))
+			}
+			if common.HexToAddress(owner) == *_treasury.executor.VendorAddress {
+				inEscrow = append(inEscrow, order)
+			}
+		}
+		if next == nil {
+			return inEscrow, nil
+		}
+		query.After = next
+	}
+}
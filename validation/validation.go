@@ -0,0 +1,176 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+)
+
+var addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+var privateKeyPattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// What went wrong with one field
+type FieldError struct {
+	Field   string         `json:"field"`
+	Code    apierrors.Code `json:"code"`
+	Message string         `json:"error"`
+}
+
+// Checks the fields of a request and collects everything that's wrong with them, so the client hears about
+// all of their mistakes at once rather than one per request. The zero value is ready to use.
+//
+// Each check returns the value in its canonical form, or the zero value if it isn't valid. Once the checks are
+// done, Err says whether any of them failed.
+type Validator struct {
+	problems []FieldError
+}
+
+// Records a problem with a field
+func (v *Validator) Add(field string, code apierrors.Code, format string, args ...interface{}) {
+	v.problems = append(v.problems, FieldError{
+		Field:   field,
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Makes sure the field has a value. Returns false if it doesn't.
+func (v *Validator) Required(field string, value string) bool {
+	if len(value) == 0 {
+		v.Add(field, apierrors.CodeMissingParameter, "%s is required", field)
+		return false
+	}
+	return true
+}
+
+// Checks that the value is an ethereum address: 0x followed by 40 hex digits. If the digits are mixed case
+// they have to carry a valid EIP-55 checksum; all lower or all upper case means there is no checksum to check.
+// Returns the address in its checksummed form.
+func (v *Validator) Address(field string, value string) string {
+	if !v.Required(field, value) {
+		return ""
+	}
+
+	address, err := ParseAddress(value)
+	if err != nil {
+		v.Add(field, apierrors.CodeInvalidAddress, "%s %s", field, err.Error())
+		return ""
+	}
+	return address.Hex()
+}
+
+// Checks that the value is an address that can be sent a delivery. On top of being a valid address, it can't
+// be the zero address, which nobody holds the key for, or the vendor's own address.
+func (v *Validator) Recipient(field string, value string, vendor common.Address) string {
+	address := v.Address(field, value)
+	if len(address) == 0 {
+		return ""
+	}
+
+	switch address {
+	case (common.Address{}).Hex():
+		v.Add(field, apierrors.CodeInvalidAddress, "%s cannot be the zero address", field)
+		return ""
+	case vendor.Hex():
+		v.Add(field, apierrors.CodeInvalidAddress, "%s cannot be the vendor's own address", field)
+		return ""
+	}
+	return address
+}
+
+// Checks that the value is a secp256k1 private key: 32 bytes of hex, with or without a 0x prefix.
+// Returns it without the prefix, which is how the contract executor wants it.
+func (v *Validator) PrivateKey(field string, value string) string {
+	if !v.Required(field, value) {
+		return ""
+	}
+
+	key := strings.TrimPrefix(value, "0x")
+	if !privateKeyPattern.MatchString(key) {
+		v.Add(field, apierrors.CodeInvalidPrivateKey, "%s must be 32 bytes of hex", field)
+		return ""
+	}
+	// not every 32 byte number is a key on the curve
+	if _, err := crypto.HexToECDSA(key); err != nil {
+		v.Add(field, apierrors.CodeInvalidPrivateKey, "%s is not a valid private key", field)
+		return ""
+	}
+	return key
+}
+
+// Checks that the value is an order ID, which is a UUID. Returns it in its canonical lower case form.
+func (v *Validator) OrderId(field string, value string) string {
+	if !v.Required(field, value) {
+		return ""
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil || len(value) != 36 {
+		v.Add(field, apierrors.CodeInvalidParameter, "%s must be a UUID", field)
+		return ""
+	}
+	return id.String()
+}
+
+// Checks that the value is a delivery token ID, which is a positive whole number
+func (v *Validator) TokenId(field string, value string) int64 {
+	if !v.Required(field, value) {
+		return 0
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 1 {
+		v.Add(field, apierrors.CodeInvalidParameter, "%s must be a positive whole number", field)
+		return 0
+	}
+	return id
+}
+
+// Returns an error describing every problem that was found, or nil if there weren't any.
+//
+// details.fields lists the fields that were wrong and details.errors says what was wrong with each. If every
+// problem has the same code, the error has that code too; otherwise it is INVALID_PARAMETER.
+func (v *Validator) Err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+
+	code := v.problems[0].Code
+	fields := []string{}
+	messages := []string{}
+	for _, problem := range v.problems {
+		if problem.Code != code {
+			code = apierrors.CodeInvalidParameter
+		}
+		fields = append(fields, problem.Field)
+		messages = append(messages, problem.Message)
+	}
+
+	return apierrors.New(code, "%s", strings.Join(messages, "; ")).
+		With("fields", fields).
+		With("errors", v.problems)
+}
+
+// Parses an ethereum address, insisting on the 0x prefix and, if the address is mixed case, a valid EIP-55
+// checksum. common.HexToAddress on its own takes anything and quietly makes an address out of it.
+func ParseAddress(value string) (common.Address, error) {
+	if !addressPattern.MatchString(value) {
+		return common.Address{}, errors.New("must be 0x followed by 40 hex digits")
+	}
+
+	address := common.HexToAddress(value)
+	digits := value[2:]
+	mixedCase := strings.ToLower(digits) != digits && strings.ToUpper(digits) != digits
+	if mixedCase && address.Hex() != value {
+		return common.Address{}, errors.New("has an invalid EIP-55 checksum")
+	}
+	return address, nil
+}
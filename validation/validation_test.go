@@ -0,0 +1,263 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// the EIP-55 test vector from https://eips.ethereum.org/EIPS/eip-55
+var checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+// a well-known development key and the address it controls
+var vendorKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+var vendorAddress = common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+
+// Returns the problems the validator found, failing the test if Err isn't the API error it should be
+func problemsOf(t *testing.T, v *Validator) []FieldError {
+	t.Helper()
+	err := v.Err()
+	if err == nil {
+		return nil
+	}
+	var apiErr *apierrors.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an API error, got %v", err)
+	}
+	return apiErr.Details["errors"].([]FieldError)
+}
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		// the checksummed address, or empty if the value should be refused
+		expected string
+		code     apierrors.Code
+	}{
+		{"with a valid checksum", checksummed, checksummed, ""},
+		{"in lower case", strings.ToLower(checksummed), checksummed, ""},
+		{"in upper case", "0x" + strings.ToUpper(checksummed[2:]), checksummed, ""},
+		{"with a bad checksum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", "", apierrors.CodeInvalidAddress},
+		{"with an upper case 0X", "0X" + checksummed[2:], "", apierrors.CodeInvalidAddress},
+		{"without the 0x", checksummed[2:], "", apierrors.CodeInvalidAddress},
+		{"that is too short", checksummed[:41], "", apierrors.CodeInvalidAddress},
+		{"that is too long", checksummed + "0", "", apierrors.CodeInvalidAddress},
+		{"that isn't hex", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg", "", apierrors.CodeInvalidAddress},
+		{"with spaces around it", " " + checksummed + " ", "", apierrors.CodeInvalidAddress},
+		{"that is missing", "", "", apierrors.CodeMissingParameter},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := &Validator{}
+			address := v.Address("buyerAddress", test.value)
+			problems := problemsOf(t, v)
+
+			if len(test.expected) != 0 {
+				if address != test.expected || len(problems) != 0 {
+					t.Errorf("expected %s, got [%s] with %+v", test.expected, address, problems)
+				}
+				return
+			}
+			if address != "" {
+				t.Errorf("expected no address, got %s", address)
+			}
+			if len(problems) != 1 || problems[0].Field != "buyerAddress" || problems[0].Code != test.code {
+				t.Errorf("expected a %s problem with buyerAddress, got %+v", test.code, problems)
+			}
+		})
+	}
+}
+
+func TestRecipient(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"that is somebody else", checksummed, checksummed},
+		{"that is the zero address", "0x0000000000000000000000000000000000000000", ""},
+		{"that is the vendor", vendorAddress.Hex(), ""},
+		{"that is the vendor in lower case", strings.ToLower(vendorAddress.Hex()), ""},
+		{"that isn't an address", "0x1234", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := &Validator{}
+			recipient := v.Recipient("buyerAddress", test.value, vendorAddress)
+			problems := problemsOf(t, v)
+
+			if recipient != test.expected {
+				t.Errorf("expected [%s], got [%s]", test.expected, recipient)
+			}
+			if len(test.expected) == 0 && (len(problems) != 1 || problems[0].Code != apierrors.CodeInvalidAddress) {
+				t.Errorf("expected one INVALID_ADDRESS problem, got %+v", problems)
+			} else if len(test.expected) != 0 && len(problems) != 0 {
+				t.Errorf("expected no problems, got %+v", problems)
+			}
+		})
+	}
+}
+
+func TestPrivateKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		code     apierrors.Code
+	}{
+		{"without the 0x", vendorKey, vendorKey, ""},
+		{"with the 0x", "0x" + vendorKey, vendorKey, ""},
+		{"in upper case", strings.ToUpper(vendorKey), strings.ToUpper(vendorKey), ""},
+		{"that is too short", vendorKey[:62], "", apierrors.CodeInvalidPrivateKey},
+		{"that is too long", vendorKey + "00", "", apierrors.CodeInvalidPrivateKey},
+		{"that isn't hex", "zz" + vendorKey[2:], "", apierrors.CodeInvalidPrivateKey},
+		{"with the 0x twice", "0x0x" + vendorKey, "", apierrors.CodeInvalidPrivateKey},
+		// zero isn't a key on the curve
+		{"that is zero", strings.Repeat("0", 64), "", apierrors.CodeInvalidPrivateKey},
+		// nor is anything at or above the order of the curve
+		{"that is beyond the curve", strings.Repeat("f", 64), "", apierrors.CodeInvalidPrivateKey},
+		{"that is missing", "", "", apierrors.CodeMissingParameter},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := &Validator{}
+			key := v.PrivateKey("privateKey", test.value)
+			problems := problemsOf(t, v)
+
+			if key != test.expected {
+				t.Errorf("expected [%s], got [%s]", test.expected, key)
+			}
+			if len(test.code) == 0 && len(problems) != 0 {
+				t.Errorf("expected no problems, got %+v", problems)
+			} else if len(test.code) != 0 && (len(problems) != 1 || problems[0].Code != test.code) {
+				t.Errorf("expected a %s problem, got %+v", test.code, problems)
+			}
+			// the key is a secret, so it mustn't be repeated back
+			for _, problem := range problems {
+				if len(test.value) != 0 && strings.Contains(problem.Message, test.value) {
+					t.Errorf("expected the message not to contain the key, got %s", problem.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderId(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"that is a UUID", "3f6f1a6e-5d1f-4bb5-9c53-9fb39c4dbb0e", "3f6f1a6e-5d1f-4bb5-9c53-9fb39c4dbb0e"},
+		{"in upper case", "3F6F1A6E-5D1F-4BB5-9C53-9FB39C4DBB0E", "3f6f1a6e-5d1f-4bb5-9c53-9fb39c4dbb0e"},
+		{"without dashes", "3f6f1a6e5d1f4bb59c539fb39c4dbb0e", ""},
+		{"in braces", "{3f6f1a6e-5d1f-4bb5-9c53-9fb39c4dbb0e}", ""},
+		{"as a URN", "urn:uuid:3f6f1a6e-5d1f-4bb5-9c53-9fb39c4dbb0e", ""},
+		{"that is too short", "3f6f1a6e-5d1f-4bb5-9c53-9fb39c4dbb0", ""},
+		{"that isn't a UUID", "order-1", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := &Validator{}
+			orderId := v.OrderId("orderId", test.value)
+			problems := problemsOf(t, v)
+
+			if orderId != test.expected {
+				t.Errorf("expected [%s], got [%s]", test.expected, orderId)
+			}
+			if len(test.expected) == 0 && (len(problems) != 1 || problems[0].Code != apierrors.CodeInvalidParameter) {
+				t.Errorf("expected an INVALID_PARAMETER problem, got %+v", problems)
+			}
+		})
+	}
+}
+
+func TestTokenId(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int64
+	}{
+		{"1", 1},
+		{"9223372036854775807", 9223372036854775807},
+		{"0", 0},
+		{"-1", 0},
+		{"1.5", 0},
+		{"0x10", 0},
+		{"9223372036854775808", 0},
+		{"", 0},
+	}
+
+	for _, test := range tests {
+		v := &Validator{}
+		tokenId := v.TokenId("tokenId", test.value)
+		if tokenId != test.expected {
+			t.Errorf("expected [%s] to give %d, got %d", test.value, test.expected, tokenId)
+		}
+		if failed := v.Err() != nil; failed != (test.expected == 0) {
+			t.Errorf("expected [%s] to fail: %v, got %v", test.value, test.expected == 0, v.Err())
+		}
+	}
+}
+
+func TestErrCollectsEveryField(t *testing.T) {
+	v := &Validator{}
+	if v.Err() != nil {
+		t.Fatalf("expected no error before anything is checked, got %v", v.Err())
+	}
+
+	v.Recipient("buyerAddress", "0x1234", vendorAddress)
+	v.PrivateKey("privateKey", "abc")
+	v.OrderId("orderId", "order-1")
+	v.Address("contractAddress", checksummed)
+
+	var apiErr *apierrors.Error
+	if !errors.As(v.Err(), &apiErr) {
+		t.Fatalf("expected an API error, got %v", v.Err())
+	}
+	// the fields were wrong in different ways
+	if apiErr.Code != apierrors.CodeInvalidParameter {
+		t.Errorf("expected %s, got %s", apierrors.CodeInvalidParameter, apiErr.Code)
+	}
+	expected := []string{"buyerAddress", "privateKey", "orderId"}
+	if fields := apiErr.Details["fields"]; !reflect.DeepEqual(fields, expected) {
+		t.Errorf("expected fields %v, got %v", expected, fields)
+	}
+	problems := apiErr.Details["errors"].([]FieldError)
+	codes := []apierrors.Code{apierrors.CodeInvalidAddress, apierrors.CodeInvalidPrivateKey, apierrors.CodeInvalidParameter}
+	for i, problem := range problems {
+		if problem.Code != codes[i] || !strings.HasPrefix(problem.Message, expected[i]) {
+			t.Errorf("expected a %s problem with %s, got %+v", codes[i], expected[i], problem)
+		}
+	}
+	for _, field := range expected {
+		if !strings.Contains(apiErr.Message, field) {
+			t.Errorf("expected the message to mention %s, got %s", field, apiErr.Message)
+		}
+	}
+}
+
+func TestErrKeepsASharedCode(t *testing.T) {
+	v := &Validator{}
+	v.Required("itemId", "")
+	v.Required("buyerAddress", "")
+
+	var apiErr *apierrors.Error
+	if !errors.As(v.Err(), &apiErr) {
+		t.Fatalf("expected an API error, got %v", v.Err())
+	}
+	if apiErr.Code != apierrors.CodeMissingParameter {
+		t.Errorf("expected every field to be missing, got %s", apiErr.Code)
+	}
+	if fields := apiErr.Details["fields"]; !reflect.DeepEqual(fields, []string{"itemId", "buyerAddress"}) {
+		t.Errorf("expected both fields, got %v", fields)
+	}
+}
@@ -0,0 +1,372 @@
+package vendors
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/bdunton9323/blockchain-playground/apierrors"
+	"github.com/bdunton9323/blockchain-playground/contract"
+	"github.com/bdunton9323/blockchain-playground/validation"
+	"github.com/bdunton9323/blockchain-playground/wallet"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Returned when a vendor is added but the service has no HD wallet to derive their signing key from
+var ErrKeysDisabled = errors.New("vendors can't be added because no HD wallet seed is configured")
+
+// how many times to look for a free address index when vendors are added at the same time
+var maxCreateAttempts = 5
+
+// the longest name the database holds
+var maxNameLength = 128
+
+// vendor IDs end up in URLs and headers, so they are kept plain
+var vendorIdPattern = regexp.MustCompile("^[a-z0-9][a-z0-9-]{0,63}$")
+
+// What a vendor's account says about them
+type VendorInput struct {
+	// Only when creating. Optional; one is generated if it is left out.
+	VendorId string
+	Name     string
+	// where the vendor's takings are swept to. Leave it empty to not sweep them.
+	ColdWallet string
+}
+
+// Looks after the marketplace's vendors and the contract executors that sign for them.
+//
+// Every vendor signs with a key of their own and has their own delivery contract, so one vendor's orders,
+// tokens and takings never mix with another's. The default vendor is the one the service is configured with;
+// everyone else's key is derived from the service's HD wallet. Executors are built the first time a vendor
+// is needed and kept for as long as the service runs.
+//
+// Errors the caller can do something about are *apierrors.Error; anything else is internal.
+type Registry struct {
+	repository VendorRepository
+	// nil if no vendors can be added. The default vendor still works.
+	wallet *wallet.HDWallet
+	// the ethereum node every executor talks to
+	nodeUrl string
+	// signs for the default vendor, with the configured key
+	defaultExecutor *contract.DeliveryContractExecutor
+
+	// Called once for every vendor's executor, including the default vendor's, the first time it is built,
+	// and again when the vendor's contract is replaced. Lets the service start the vendor's background work,
+	// and stop the work on the old contract. Optional.
+	OnReady func(vendor *Vendor, executor *contract.DeliveryContractExecutor)
+
+	// guards executors and retired
+	mutex     sync.Mutex
+	executors map[string]*contract.DeliveryContractExecutor
+	// executors whose contract was replaced. Requests that were already using them may still be, so they
+	// are only disconnected by Close.
+	retired []*contract.DeliveryContractExecutor
+}
+
+// Constructs a new registry. hdWallet can be nil, in which case only the default vendor, which signs with
+// defaultExecutor, can take orders.
+func NewRegistry(
+	repository VendorRepository,
+	hdWallet *wallet.HDWallet,
+	nodeUrl string,
+	defaultExecutor *contract.DeliveryContractExecutor,
+) *Registry {
+	return &Registry{
+		repository:      repository,
+		wallet:          hdWallet,
+		nodeUrl:         nodeUrl,
+		defaultExecutor: defaultExecutor,
+		executors:       map[string]*contract.DeliveryContractExecutor{},
+	}
+}
+
+// Builds the executors of every vendor whose contract is deployed, so their background work starts with the
+// service. A vendor whose executor can't be built is logged and tried again the first time it is needed.
+func (_reg *Registry) Load() error {
+	vendors, err := _reg.repository.ListVendors()
+	if err != nil {
+		return err
+	}
+	for _, vendor := range vendors {
+		if vendor.VendorId != DefaultVendorId && !vendor.Ready() {
+			continue
+		}
+		if _, err = _reg.Executor(vendor.VendorId); err != nil {
+			log.Errorf("Could not start vendor [%s]: %v", vendor.VendorId, err)
+		}
+	}
+	return nil
+}
+
+// Adds a vendor to the marketplace, with the next free signing key in the wallet. They can't take orders
+// until their contract is deployed with DeployContract, which their address needs ether for.
+func (_reg *Registry) CreateVendor(input *VendorInput) (*Vendor, error) {
+	if err := validateVendor(input, true); err != nil {
+		return nil, err
+	}
+	if _reg.wallet == nil {
+		return nil, apierrors.Wrap(ErrKeysDisabled, apierrors.CodeCustodyNotConfigured,
+			"Vendors can't be added: no HD wallet seed is configured")
+	}
+
+	vendorId := input.VendorId
+	if len(vendorId) == 0 {
+		vendorId = uuid.New().String()
+	}
+	existing, err := _reg.repository.GetVendor(vendorId)
+	if err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, apierrors.New(apierrors.CodeVendorAlreadyExists, "Vendor ID [%s] already exists", vendorId).
+			With("vendorId", vendorId)
+	}
+
+	for attempt := 0; attempt < maxCreateAttempts; attempt++ {
+		index, err := _reg.repository.NextAddressIndex()
+		if err != nil {
+			return nil, err
+		}
+
+		path := wallet.VendorPath(index)
+		address, err := _reg.wallet.Address(path)
+		if err != nil {
+			return nil, err
+		}
+
+		vendor := &Vendor{
+			VendorId:       vendorId,
+			Name:           input.Name,
+			Address:        address.Hex(),
+			DerivationPath: path.String(),
+			AddressIndex:   index,
+			ColdWallet:     input.ColdWallet,
+		}
+		err = _reg.repository.CreateVendor(vendor)
+		if errors.Is(err, ErrAddressIndexTaken) {
+			// somebody else added a vendor in the meantime
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		log.Infof("Added vendor [%s] signing with [%s] at %s", vendor.VendorId, vendor.Address, vendor.DerivationPath)
+		return vendor, nil
+	}
+	return nil, errors.New(fmt.Sprintf("could not find a free address index after %d attempts", maxCreateAttempts))
+}
+
+// Changes the vendor's name and cold wallet. Their key never changes.
+func (_reg *Registry) UpdateVendor(vendorId string, input *VendorInput) (*Vendor, error) {
+	if err := validateVendor(input, false); err != nil {
+		return nil, err
+	}
+
+	vendor, err := _reg.GetVendor(vendorId)
+	if err != nil {
+		return nil, err
+	}
+	vendor.Name = input.Name
+	vendor.ColdWallet = input.ColdWallet
+
+	updated, err := _reg.repository.UpdateVendor(vendor)
+	if err != nil {
+		return nil, err
+	} else if !updated {
+		return nil, vendorNotFound(vendorId)
+	}
+	return vendor, nil
+}
+
+// Returns the vendor with the given ID
+func (_reg *Registry) GetVendor(vendorId string) (*Vendor, error) {
+	vendor, err := _reg.repository.GetVendor(vendorId)
+	if err != nil {
+		return nil, err
+	} else if vendor == nil {
+		return nil, vendorNotFound(vendorId)
+	}
+	return _reg.withDefaults(vendor), nil
+}
+
+// Returns every vendor, oldest first
+func (_reg *Registry) ListVendors() ([]*Vendor, error) {
+	vendors, err := _reg.repository.ListVendors()
+	if err != nil {
+		return nil, err
+	}
+	for _, vendor := range vendors {
+		_reg.withDefaults(vendor)
+	}
+	return vendors, nil
+}
+
+// Deploys the vendor's delivery contract, signed with their key, and waits for it to be mined. Their address
+// needs enough ether for the gas. A vendor who already has a contract only gets a new one if replace is set;
+// the orders on the old contract stay there, and can't be taken any further through the new one.
+func (_reg *Registry) DeployContract(vendorId string, replace bool) (*Vendor, error) {
+	vendor, err := _reg.GetVendor(vendorId)
+	if err != nil {
+		return nil, err
+	} else if vendor.VendorId == DefaultVendorId {
+		return nil, apierrors.New(apierrors.CodeContractAlreadyDeployed,
+			"The default vendor's contract is configured, not deployed by the service").
+			With("vendorId", vendorId).With("contractAddress", vendor.ContractAddress)
+	} else if vendor.Ready() && !replace {
+		return nil, apierrors.New(apierrors.CodeContractAlreadyDeployed,
+			"Vendor [%s] already has a contract at [%s]; ask to replace it to deploy another", vendorId, vendor.ContractAddress).
+			With("vendorId", vendorId).With("contractAddress", vendor.ContractAddress)
+	}
+	previous := vendor.ContractAddress
+
+	// orders keep going to the old contract, if there is one, until the new address is recorded, so there is
+	// nothing to race with except another deployment, which would only waste the vendor's gas
+	vendor.ContractAddress = ""
+	executor, err := _reg.buildExecutor(vendor)
+	var apiErr *apierrors.Error
+	if errors.As(err, &apiErr) {
+		return nil, err
+	} else if err != nil {
+		// most likely the vendor's address can't pay for the gas, which whoever asked can fix
+		return nil, apierrors.Wrap(err, apierrors.CodeChainError, "Could not deploy the contract for vendor [%s] from [%s]: %v",
+			vendorId, vendor.Address, err).With("vendorId", vendorId)
+	}
+	vendor.ContractAddress = executor.ContractAddress.Hex()
+	if err = _reg.repository.SetContractAddress(vendorId, vendor.ContractAddress); err != nil {
+		executor.Client.Close()
+		return nil, err
+	}
+	if len(previous) == 0 {
+		log.Infof("Deployed the contract for vendor [%s] at [%s]", vendorId, vendor.ContractAddress)
+		_reg.keep(vendor, executor)
+	} else {
+		log.Warnf("Replaced the contract for vendor [%s] at [%s] with [%s]", vendorId, previous, vendor.ContractAddress)
+		_reg.swap(vendor, executor)
+	}
+	return vendor, nil
+}
+
+// Returns the executor that signs for the vendor, building it the first time
+func (_reg *Registry) Executor(vendorId string) (*contract.DeliveryContractExecutor, error) {
+	_reg.mutex.Lock()
+	executor, found := _reg.executors[vendorId]
+	_reg.mutex.Unlock()
+	if found {
+		return executor, nil
+	}
+
+	vendor, err := _reg.GetVendor(vendorId)
+	if err != nil {
+		return nil, err
+	} else if vendor.VendorId == DefaultVendorId {
+		return _reg.keep(vendor, _reg.defaultExecutor), nil
+	} else if !vendor.Ready() {
+		return nil, apierrors.New(apierrors.CodeVendorNotReady, "Vendor [%s] has no delivery contract yet", vendorId).
+			With("vendorId", vendorId)
+	}
+
+	if executor, err = _reg.buildExecutor(vendor); err != nil {
+		return nil, err
+	}
+	return _reg.keep(vendor, executor), nil
+}
+
+// The executor of the default vendor, i.e. the one the service is configured with
+func (_reg *Registry) Default() *contract.DeliveryContractExecutor {
+	return _reg.defaultExecutor
+}
+
+// Disconnects every vendor's executor but the default vendor's, which belongs to whoever built the registry.
+// Only call this once nothing is using them any more.
+func (_reg *Registry) Close() {
+	_reg.mutex.Lock()
+	defer _reg.mutex.Unlock()
+	for _, executor := range _reg.executors {
+		if executor != _reg.defaultExecutor {
+			executor.Client.Close()
+		}
+	}
+	for _, executor := range _reg.retired {
+		executor.Client.Close()
+	}
+}
+
+// Connects to the vendor's contract with their key. Deploys the contract if it hasn't been yet.
+func (_reg *Registry) buildExecutor(vendor *Vendor) (*contract.DeliveryContractExecutor, error) {
+	if _reg.wallet == nil {
+		return nil, apierrors.Wrap(ErrKeysDisabled, apierrors.CodeCustodyNotConfigured,
+			"Vendor [%s] can't sign: no HD wallet seed is configured", vendor.VendorId)
+	}
+
+	privateKey, err := _reg.wallet.PrivateKeyHex(wallet.VendorPath(vendor.AddressIndex))
+	if err != nil {
+		return nil, err
+	}
+	return contract.NewDeliveryContractExecutor(_reg.nodeUrl, privateKey, &vendor.ContractAddress)
+}
+
+// Remembers the executor, unless another one was built for the vendor in the meantime, and returns the one
+// that is kept. OnReady hears about it the first time.
+func (_reg *Registry) keep(vendor *Vendor, executor *contract.DeliveryContractExecutor) *contract.DeliveryContractExecutor {
+	_reg.mutex.Lock()
+	if existing, found := _reg.executors[vendor.VendorId]; found {
+		_reg.mutex.Unlock()
+		if existing != executor {
+			executor.Client.Close()
+		}
+		return existing
+	}
+	_reg.executors[vendor.VendorId] = executor
+	_reg.mutex.Unlock()
+
+	// outside the lock, so it can use the registry
+	if _reg.OnReady != nil {
+		_reg.OnReady(vendor, executor)
+	}
+	return executor
+}
+
+// Puts the executor in place of the vendor's current one, whose contract it replaces, and tells OnReady
+func (_reg *Registry) swap(vendor *Vendor, executor *contract.DeliveryContractExecutor) {
+	_reg.mutex.Lock()
+	if existing, found := _reg.executors[vendor.VendorId]; found {
+		_reg.retired = append(_reg.retired, existing)
+	}
+	_reg.executors[vendor.VendorId] = executor
+	_reg.mutex.Unlock()
+
+	if _reg.OnReady != nil {
+		_reg.OnReady(vendor, executor)
+	}
+}
+
+// The default vendor signs with the configured key, so the database doesn't know their address or contract
+func (_reg *Registry) withDefaults(vendor *Vendor) *Vendor {
+	if vendor.VendorId == DefaultVendorId && _reg.defaultExecutor != nil {
+		vendor.Address = _reg.defaultExecutor.VendorAddress.Hex()
+		vendor.ContractAddress = _reg.defaultExecutor.ContractAddress.Hex()
+	}
+	return vendor
+}
+
+// Checks what the vendor's account says. The ID is only checked when creating, since it can't change.
+func validateVendor(input *VendorInput, creating bool) error {
+	v := &validation.Validator{}
+	if creating && len(input.VendorId) != 0 && !vendorIdPattern.MatchString(input.VendorId) {
+		v.Add("vendorId", apierrors.CodeInvalidParameter,
+			"vendorId can only have lower case letters, digits and dashes, and at most 64 of them")
+	}
+	if v.Required("name", input.Name) && len(input.Name) > maxNameLength {
+		v.Add("name", apierrors.CodeInvalidParameter, "name can be at most %d characters", maxNameLength)
+	}
+	if len(input.ColdWallet) != 0 {
+		input.ColdWallet = v.Address("coldWallet", input.ColdWallet)
+	}
+	return v.Err()
+}
+
+func vendorNotFound(vendorId string) error {
+	return apierrors.New(apierrors.CodeVendorNotFound, "Vendor ID [%s] does not exist", vendorId).
+		With("vendorId", vendorId)
+}
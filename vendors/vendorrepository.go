@@ -0,0 +1,192 @@
+package vendors
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+)
+
+// The vendor the service was configured with, before there could be more than one. Orders, products and API keys
+// from before then belong to it.
+var DefaultVendorId = "default"
+
+// A DTO object representing a row in the database. One of the sellers in the marketplace.
+type Vendor struct {
+	VendorId string
+	Name     string
+
+	// The default vendor signs with the configured key, so the database doesn't know these. The registry fills
+	// them in.
+	//
+	// the address the vendor signs with, as a checksummed hex string
+	Address string
+	// where the vendor's key is in the HD wallet, e.g. m/44'/60'/1'/0/3
+	DerivationPath string
+	// the last part of the derivation path. Every vendor has their own.
+	AddressIndex uint32
+
+	// the vendor's delivery contract. Empty until it has been deployed.
+	ContractAddress string
+	// where the vendor's takings are swept to. Empty if they aren't.
+	ColdWallet string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Whether the vendor's delivery contract has been deployed, so orders can be placed with them
+func (vendor *Vendor) Ready() bool {
+	return len(vendor.ContractAddress) != 0
+}
+
+type VendorRepository interface {
+	// Returns ErrAddressIndexTaken if another vendor already has the address index
+	CreateVendor(vendor *Vendor) error
+	// Changes the vendor's name and cold wallet. Returns false if the vendor doesn't exist.
+	UpdateVendor(vendor *Vendor) (bool, error)
+	// Records where the vendor's contract was deployed
+	SetContractAddress(vendorId string, contractAddress string) error
+	// Returns nil if the vendor doesn't exist
+	GetVendor(vendorId string) (*Vendor, error)
+	// Returns every vendor, oldest first
+	ListVendors() ([]*Vendor, error)
+	// The address index after the highest one in use, i.e. the one the next vendor should get
+	NextAddressIndex() (uint32, error)
+}
+
+// Returned when two vendors are created at once and end up with the same address index
+var ErrAddressIndexTaken = errors.New("the address index is already taken")
+
+type MariaDBVendorRepository struct {
+	VendorRepository
+
+	conn *sql.DB
+}
+
+var vendorsTable = "vendors"
+var allFields = "vendor_id, name, address, derivation_path, address_index, contract_address, cold_wallet, created_at, updated_at"
+
+// the MySQL error number for a duplicate key
+var duplicateEntryError uint16 = 1062
+
+// Construct a new repository that uses the given connection pool to MariaDB
+func NewMariaDBVendorRepository(db *sql.DB) *MariaDBVendorRepository {
+	return &MariaDBVendorRepository{conn: db}
+}
+
+// Writes the given vendor to the database
+func (repo *MariaDBVendorRepository) CreateVendor(vendor *Vendor) error {
+	if vendor.CreatedAt.IsZero() {
+		vendor.CreatedAt = time.Now().UTC()
+	}
+	vendor.UpdatedAt = vendor.CreatedAt
+
+	query := fmt.Sprintf("insert into %s (%s) values (?, ?, ?, ?, ?, ?, ?, ?, ?)", vendorsTable, allFields)
+	log.Debugf("running query [%s]", query)
+	_, err := repo.conn.Exec(query,
+		vendor.VendorId,
+		vendor.Name,
+		nullIfEmpty(vendor.Address),
+		nullIfEmpty(vendor.DerivationPath),
+		sql.NullInt64{Int64: int64(vendor.AddressIndex), Valid: len(vendor.DerivationPath) != 0},
+		nullIfEmpty(vendor.ContractAddress),
+		nullIfEmpty(vendor.ColdWallet),
+		vendor.CreatedAt,
+		vendor.UpdatedAt)
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == duplicateEntryError {
+		return ErrAddressIndexTaken
+	}
+	return err
+}
+
+// Changes the vendor's name and cold wallet. Their key and contract never change.
+func (repo *MariaDBVendorRepository) UpdateVendor(vendor *Vendor) (bool, error) {
+	vendor.UpdatedAt = time.Now().UTC()
+
+	query := fmt.Sprintf("update %s set name = ?, cold_wallet = ?, updated_at = ? where vendor_id = ?", vendorsTable)
+	log.Debugf("running query [%s]", query)
+	result, err := repo.conn.Exec(query, vendor.Name, nullIfEmpty(vendor.ColdWallet), vendor.UpdatedAt, vendor.VendorId)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// Records the address of the vendor's newly deployed contract
+func (repo *MariaDBVendorRepository) SetContractAddress(vendorId string, contractAddress string) error {
+	query := fmt.Sprintf("update %s set contract_address = ?, updated_at = ? where vendor_id = ?", vendorsTable)
+	log.Debugf("running query [%s]", query)
+	_, err := repo.conn.Exec(query, contractAddress, time.Now().UTC(), vendorId)
+	return err
+}
+
+// Returns the vendor with the given ID. If not found, then nil.
+func (repo *MariaDBVendorRepository) GetVendor(vendorId string) (*Vendor, error) {
+	vendors, err := repo.query(fmt.Sprintf("select %s from %s where vendor_id = ?", allFields, vendorsTable), vendorId)
+	if err != nil || len(vendors) == 0 {
+		return nil, err
+	}
+	return vendors[0], nil
+}
+
+// Returns every vendor, in the order they were created
+func (repo *MariaDBVendorRepository) ListVendors() ([]*Vendor, error) {
+	return repo.query(fmt.Sprintf("select %s from %s order by created_at, vendor_id", allFields, vendorsTable))
+}
+
+// Returns one more than the highest address index in use, or 0 if no vendor has been given a key yet
+func (repo *MariaDBVendorRepository) NextAddressIndex() (uint32, error) {
+	query := fmt.Sprintf("select coalesce(max(address_index) + 1, 0) from %s", vendorsTable)
+
+	var index uint32
+	err := repo.conn.QueryRow(query).Scan(&index)
+	return index, err
+}
+
+func (repo *MariaDBVendorRepository) query(query string, args ...interface{}) ([]*Vendor, error) {
+	log.Debugf("running query [%s]", query)
+	results, err := repo.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	vendors := []*Vendor{}
+	for results.Next() {
+		var vendor Vendor
+		var address, derivationPath, contractAddress, coldWallet sql.NullString
+		var addressIndex sql.NullInt64
+		err = results.Scan(
+			&vendor.VendorId,
+			&vendor.Name,
+			&address,
+			&derivationPath,
+			&addressIndex,
+			&contractAddress,
+			&coldWallet,
+			&vendor.CreatedAt,
+			&vendor.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		vendor.Address = address.String
+		vendor.DerivationPath = derivationPath.String
+		vendor.AddressIndex = uint32(addressIndex.Int64)
+		vendor.ContractAddress = contractAddress.String
+		vendor.ColdWallet = coldWallet.String
+		vendors = append(vendors, &vendor)
+	}
+	return vendors, results.Err()
+}
+
+// Stores empty strings as null, so they don't collide in unique indexes
+func nullIfEmpty(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: len(value) != 0}
+}
@@ -0,0 +1,133 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// A BIP-32 hierarchical deterministic wallet. Every key it hands out is derived from one seed, so the seed
+// is all that has to be kept safe and backed up; the keys themselves are never stored.
+type HDWallet struct {
+	master *hdkeychain.ExtendedKey
+}
+
+// Builds a wallet from a BIP-39 seed, i.e. what a mnemonic turns into
+func NewHDWallet(seed []byte) (*HDWallet, error) {
+	// the network only matters for serializing extended keys, which we never do
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, err
+	}
+	return &HDWallet{master: master}, nil
+}
+
+// Reads the seed from a file written by WriteSeedFile and builds a wallet from it
+func LoadHDWallet(seedFile string, passphrase string) (*HDWallet, error) {
+	encrypted, err := os.ReadFile(seedFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var cryptoJson keystore.CryptoJSON
+	if err = json.Unmarshal(encrypted, &cryptoJson); err != nil {
+		return nil, errors.New(fmt.Sprintf("%s is not an encrypted seed: %v", seedFile, err))
+	}
+	seed, err := keystore.DecryptDataV3(cryptoJson, passphrase)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("could not decrypt the seed in %s: %v", seedFile, err))
+	}
+	return NewHDWallet(seed)
+}
+
+// Turns the mnemonic into a seed and writes it to the file, encrypted with the passphrase the same way
+// ethereum keystore files are. The file is only readable by its owner.
+func WriteSeedFile(seedFile string, mnemonic string, passphrase string) error {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+	if err != nil {
+		return err
+	}
+
+	cryptoJson, err := keystore.EncryptDataV3(seed, []byte(passphrase), keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return err
+	}
+	encrypted, err := json.MarshalIndent(cryptoJson, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(seedFile, encrypted, 0600)
+}
+
+// Makes up a new 24 word mnemonic. Whoever has it can rebuild every key in the wallet.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(256)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// The BIP-44 path of the index'th ethereum address, m/44'/60'/0'/0/index. This is where wallets like
+// MetaMask put their accounts, so importing the seed into one shows the same addresses.
+func AddressPath(index uint32) accounts.DerivationPath {
+	path := make(accounts.DerivationPath, len(accounts.DefaultRootDerivationPath), len(accounts.DefaultRootDerivationPath)+1)
+	copy(path, accounts.DefaultRootDerivationPath)
+	return append(path, index)
+}
+
+// The BIP-44 path of the index'th vendor signing key, m/44'/60'/1'/0/index. Vendors get an account of their own
+// so their keys never collide with customers'.
+func VendorPath(index uint32) accounts.DerivationPath {
+	path := make(accounts.DerivationPath, len(accounts.DefaultRootDerivationPath), len(accounts.DefaultRootDerivationPath)+1)
+	copy(path, accounts.DefaultRootDerivationPath)
+	// the account is the third part of the root, hardened
+	path[2] = 0x80000000 + 1
+	return append(path, index)
+}
+
+// Derives the private key at the path
+func (wallet *HDWallet) PrivateKey(path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	key := wallet.master
+	for _, index := range path {
+		var err error
+		if key, err = key.Derive(index); err != nil {
+			return nil, errors.New(fmt.Sprintf("could not derive %s: %v", path, err))
+		}
+	}
+
+	privateKey, err := key.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	return privateKey.ToECDSA(), nil
+}
+
+// Derives the private key at the path, as hex without the 0x, which is how the contract executor takes keys
+func (wallet *HDWallet) PrivateKeyHex(path accounts.DerivationPath) (string, error) {
+	privateKey, err := wallet.PrivateKey(path)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(crypto.FromECDSA(privateKey)), nil
+}
+
+// Derives the address at the path
+func (wallet *HDWallet) Address(path accounts.DerivationPath) (common.Address, error) {
+	privateKey, err := wallet.PrivateKey(path)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(privateKey.PublicKey), nil
+}
@@ -0,0 +1,164 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// The development mnemonic Hardhat and Anvil use. The accounts it gives are published in their docs and printed
+// by every `npx hardhat node`, so they are what any other wallet derives from it too.
+var testMnemonic = "test test test test test test test test test test test junk"
+
+// the accounts at m/44'/60'/0'/0/0, /1 and /2
+var publishedAccounts = []struct {
+	address    string
+	privateKey string
+}{
+	{"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"},
+	{"0x70997970C51812dc3A010C7d01b50e0d17dc79C8", "59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"},
+	{"0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC", "5de4111afa1a4b94908f83103eb1f1706367c2e68ca870fc3fb9a804cdab365a"},
+}
+
+// The vendor keys at m/44'/60'/1'/0/0 and /1. Nothing publishes these, so they were worked out when the path was
+// chosen. Vendors' signing keys live here, so they must never change.
+var vendorAddresses = []string{
+	"0x8C8d35429F74ec245F8Ef2f4Fd1e551cFF97d650",
+	"0x40FBBE484b8Ee6139Af08446950B088e10b2306A",
+}
+
+func newTestWallet(t *testing.T) *HDWallet {
+	wallet, err := NewHDWallet(bip39.NewSeed(testMnemonic, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wallet
+}
+
+func TestAddressPathDerivesThePublishedAccounts(t *testing.T) {
+	wallet := newTestWallet(t)
+
+	if path := AddressPath(0).String(); path != "m/44'/60'/0'/0/0" {
+		t.Errorf("expected m/44'/60'/0'/0/0, got %s", path)
+	}
+	for i, account := range publishedAccounts {
+		address, err := wallet.Address(AddressPath(uint32(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if address.Hex() != account.address {
+			t.Errorf("expected account %d to be %s, got %s", i, account.address, address.Hex())
+		}
+
+		privateKey, err := wallet.PrivateKeyHex(AddressPath(uint32(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if privateKey != account.privateKey {
+			t.Errorf("expected account %d's key to be %s, got %s", i, account.privateKey, privateKey)
+		}
+	}
+}
+
+func TestVendorPathDerivesItsOwnKeys(t *testing.T) {
+	wallet := newTestWallet(t)
+
+	if path := VendorPath(3).String(); path != "m/44'/60'/1'/0/3" {
+		t.Errorf("expected m/44'/60'/1'/0/3, got %s", path)
+	}
+	// building a vendor path mustn't change the customers' root
+	if path := AddressPath(3).String(); path != "m/44'/60'/0'/0/3" {
+		t.Errorf("expected m/44'/60'/0'/0/3, got %s", path)
+	}
+
+	for i, expected := range vendorAddresses {
+		address, err := wallet.Address(VendorPath(uint32(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if address.Hex() != expected {
+			t.Errorf("expected vendor key %d to be %s, got %s", i, expected, address.Hex())
+		}
+		if address.Hex() == publishedAccounts[i].address {
+			t.Errorf("expected vendor key %d not to be customer address %d", i, i)
+		}
+	}
+}
+
+func TestSeedFileRoundTrip(t *testing.T) {
+	seedFile := filepath.Join(t.TempDir(), "seed.json")
+	if err := WriteSeedFile(seedFile, testMnemonic, "correct horse battery staple"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(seedFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("expected the seed file to only be readable by its owner, got %o", mode)
+	}
+
+	contents, err := os.ReadFile(seedFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(contents), hex.EncodeToString(bip39.NewSeed(testMnemonic, ""))) {
+		t.Error("expected the seed to be encrypted")
+	}
+
+	wallet, err := LoadHDWallet(seedFile, "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := wallet.Address(AddressPath(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.Hex() != publishedAccounts[0].address {
+		t.Errorf("expected the loaded wallet to derive %s, got %s", publishedAccounts[0].address, address.Hex())
+	}
+
+	if _, err = LoadHDWallet(seedFile, "wrong passphrase"); err == nil {
+		t.Error("expected the wrong passphrase to be refused")
+	}
+}
+
+func TestSeedFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	// the last word is the checksum
+	badMnemonic := "test test test test test test test test test test test test"
+	if err := WriteSeedFile(filepath.Join(dir, "bad.json"), badMnemonic, "passphrase"); err == nil {
+		t.Error("expected a mnemonic with a bad checksum to be refused")
+	}
+
+	if _, err := LoadHDWallet(filepath.Join(dir, "missing.json"), "passphrase"); err == nil {
+		t.Error("expected a missing seed file to be refused")
+	}
+
+	notSeed := filepath.Join(dir, "not-a-seed.json")
+	if err := os.WriteFile(notSeed, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadHDWallet(notSeed, "passphrase"); err == nil {
+		t.Error("expected a file that isn't an encrypted seed to be refused")
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if words := strings.Fields(mnemonic); len(words) != 24 {
+		t.Errorf("expected 24 words, got %d", len(words))
+	}
+	if !bip39.IsMnemonicValid(mnemonic) {
+		t.Errorf("expected [%s] to be a valid mnemonic", mnemonic)
+	}
+}
@@ -0,0 +1,158 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"github.com/google/uuid"
+)
+
+// Something that happened to an order that subscribers can be told about
+type EventType string
+
+const (
+	EventOrderCreated   EventType = "order.created"
+	EventOrderMinted    EventType = "order.minted"
+	EventOrderPaid      EventType = "order.paid"
+	EventOrderDelivered EventType = "order.delivered"
+	EventOrderReleased  EventType = "order.released"
+	EventOrderBurned    EventType = "order.burned"
+	EventOrderRefunded  EventType = "order.refunded"
+	EventOrderCanceled  EventType = "order.canceled"
+	EventOrderFailed    EventType = "order.failed"
+)
+
+// All of the events a subscription can ask for
+var AllEventTypes = []EventType{
+	EventOrderCreated,
+	EventOrderMinted,
+	EventOrderPaid,
+	EventOrderDelivered,
+	EventOrderReleased,
+	EventOrderBurned,
+	EventOrderRefunded,
+	EventOrderCanceled,
+	EventOrderFailed,
+}
+
+// Parses an event type name. Returns false if the event is not known.
+func ParseEventType(name string) (EventType, bool) {
+	for _, eventType := range AllEventTypes {
+		if string(eventType) == name {
+			return eventType, true
+		}
+	}
+	return "", false
+}
+
+// The event that is sent when an order moves into the given status
+func EventTypeForStatus(status string) EventType {
+	return EventType("order." + status)
+}
+
+// The body of a webhook request
+type Event struct {
+	// unique per event. The same event sent to several subscribers, or retried, keeps its ID,
+	// so receivers can use it to ignore duplicates.
+	EventId    string    `json:"eventId"`
+	Type       EventType `json:"type"`
+	OrderId    string    `json:"orderId"`
+	FromStatus string    `json:"fromStatus,omitempty"`
+	ToStatus   string    `json:"toStatus"`
+	// the ethereum address of whoever made the change
+	ActorAddress string `json:"actorAddress"`
+	// the transaction that made the change, if it happened on chain
+	TxHash     string    `json:"txHash,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// Queues a delivery of the event for every active subscription that wants it. This takes the
+// transaction that made the change, so that the change and the news of it are written together.
+// The deliveries are traced as part of whatever trace ctx belongs to.
+func EnqueueEvent(ctx context.Context, tx *sql.Tx, event *Event) error {
+	if len(event.EventId) == 0 {
+		event.EventId = uuid.New().String()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	query := fmt.Sprintf(
+		"insert into %s (subscription_id, event_id, event_type, order_id, payload, status, attempts, "+
+			"last_error, response_code, next_attempt_at, created_at, updated_at, trace_parent) "+
+			"select subscription_id, ?, ?, ?, ?, ?, 0, '', 0, ?, ?, ?, ? from %s where active and find_in_set(?, events)",
+		deliveriesTable, subscriptionsTable)
+	_, err = tx.ExecContext(ctx, query, event.EventId, event.Type, event.OrderId, string(payload), DeliveryPending,
+		now, now, now, tracing.TraceParent(ctx), event.Type)
+	return err
+}
+
+// the request headers a webhook is sent with
+var SignatureHeader = "X-Webhook-Signature"
+var EventIdHeader = "X-Webhook-Event-Id"
+var EventTypeHeader = "X-Webhook-Event"
+
+// Produces the value of the signature header for a payload: the time it was signed, and an HMAC-SHA256
+// of that time and the payload keyed with the subscription's secret. Including the time stops somebody
+// who captured a request from replaying it much later.
+func Sign(secret string, timestamp time.Time, payload []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", timestamp.Unix(), signature(secret, timestamp.Unix(), payload))
+}
+
+// Checks a signature header produced by Sign. Receivers can use this to make sure a webhook came from
+// us and wasn't tampered with. Signatures older than the tolerance are rejected.
+func VerifySignature(secret string, header string, payload []byte, tolerance time.Duration, now time.Time) error {
+	var timestamp int64
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch name {
+		case "t":
+			timestamp, _ = strconv.ParseInt(value, 10, 64)
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	if timestamp == 0 || len(signatures) == 0 {
+		return errors.New("malformed signature header")
+	}
+	if age := now.Sub(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return errors.New("the signature is too old")
+	}
+
+	expected := signature(secret, timestamp, payload)
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+	return errors.New("the signature does not match")
+}
+
+func signature(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,187 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Sends queued webhook deliveries to their subscribers.
+//
+// Deliveries are queued in the same transaction as the order change they describe, so nothing is lost
+// if the service stops before they go out. A delivery counts as sent once the subscriber responds with
+// a 2xx. Anything else is retried with exponential backoff until it runs out of attempts, at which point
+// it is marked dead and left for somebody to look at and retry by hand.
+type Sender struct {
+	repository WebhookRepository
+	client     *http.Client
+
+	// how often to look for deliveries that are due
+	PollInterval time.Duration
+	// how many deliveries to pick up per poll
+	BatchSize int
+	// how many times to try a delivery before giving up on it
+	MaxAttempts int
+	// the delay before the first retry. It doubles with each attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Constructs a new sender with reasonable defaults
+func NewSender(repository WebhookRepository) *Sender {
+	return &Sender{
+		repository:     repository,
+		client:         &http.Client{Timeout: 10 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		PollInterval:   5 * time.Second,
+		BatchSize:      20,
+		MaxAttempts:    12,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     time.Hour,
+	}
+}
+
+// Sends due deliveries until the context is canceled. Run this in its own goroutine.
+func (_sender *Sender) Run(ctx context.Context) {
+	log.Infof("Webhook sender started, polling every %v", _sender.PollInterval)
+
+	ticker := time.NewTicker(_sender.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		_sender.sendDue()
+
+		select {
+		case <-ctx.Done():
+			log.Info("Webhook sender stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sends one batch of deliveries that are due
+func (_sender *Sender) sendDue() {
+	deliveries, err := _sender.repository.ListDueDeliveries(_sender.BatchSize)
+	if err != nil {
+		log.Errorf("Could not list webhook deliveries: %v", err)
+		return
+	}
+
+	// subscriptions are looked up once per batch, since most batches are for a handful of subscribers
+	subscriptions := map[string]*Subscription{}
+	for _, delivery := range deliveries {
+		claimed, err := _sender.repository.ClaimDelivery(delivery.Id)
+		if err != nil {
+			log.Errorf("Could not claim webhook delivery [%d]: %v", delivery.Id, err)
+			continue
+		} else if !claimed {
+			// somebody else got to it first
+			continue
+		}
+
+		sub, found := subscriptions[delivery.SubscriptionId]
+		if !found {
+			sub, err = _sender.repository.GetSubscription(delivery.SubscriptionId)
+			if err != nil {
+				_sender.fail(delivery, 0, err)
+				continue
+			}
+			subscriptions[delivery.SubscriptionId] = sub
+		}
+		if sub == nil {
+			// the subscription was deleted after the delivery was listed; its deliveries went with it
+			continue
+		}
+
+		_sender.send(sub, delivery)
+	}
+}
+
+// Makes one attempt at the delivery and records the outcome. The caller must hold the lease on it.
+//
+// The attempt is traced as part of the request that made the change, and the request to the subscriber carries
+// a traceparent header so that the subscriber can join the trace too.
+func (_sender *Sender) send(sub *Subscription, delivery *Delivery) {
+	var err error
+	ctx, span := tracing.Start(tracing.ContinueTrace(context.Background(), delivery.TraceParent), "webhooks.Send",
+		tracing.OrderId.String(delivery.OrderId),
+		attribute.String("webhook.event", string(delivery.EventType)),
+		attribute.Int64("webhook.delivery", delivery.Id))
+	defer tracing.End(span, &err)
+
+	payload := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Url, bytes.NewReader(payload))
+	if err != nil {
+		_sender.fail(delivery, 0, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIdHeader, delivery.EventId)
+	req.Header.Set(EventTypeHeader, string(delivery.EventType))
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, time.Now(), payload))
+
+	resp, err := _sender.client.Do(req)
+	if err != nil {
+		_sender.fail(delivery, 0, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// a little of what the subscriber said helps whoever ends up looking at a dead delivery
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err = errors.New(fmt.Sprintf("subscriber responded with %d: %s", resp.StatusCode, string(body)))
+		_sender.fail(delivery, resp.StatusCode, err)
+		return
+	}
+
+	err = _sender.repository.CompleteDelivery(delivery, resp.StatusCode)
+	if err != nil {
+		// the lease will run out and the subscriber will get it again, which the event ID lets them ignore
+		log.Errorf("Could not complete webhook delivery [%d]: %v", delivery.Id, err)
+		return
+	}
+	log.Infof("Webhook delivery [%d] (%s for order [%s]) sent to [%s]",
+		delivery.Id, delivery.EventType, delivery.OrderId, sub.Url)
+}
+
+// Records a failed attempt and schedules the next one
+func (_sender *Sender) fail(delivery *Delivery, responseCode int, cause error) {
+	final := delivery.Attempts+1 >= _sender.MaxAttempts
+	nextAttempt := time.Now().Add(_sender.backoff(delivery.Attempts))
+
+	err := _sender.repository.FailDelivery(delivery, responseCode, cause, final, nextAttempt)
+	if err != nil {
+		log.Errorf("Could not record failure of webhook delivery [%d]: %v", delivery.Id, err)
+		return
+	}
+
+	if final {
+		log.Errorf("Giving up on webhook delivery [%d] (%s for order [%s]): %v",
+			delivery.Id, delivery.EventType, delivery.OrderId, cause)
+	} else {
+		log.Warnf("Webhook delivery [%d] failed, retrying at %v: %v", delivery.Id, nextAttempt, cause)
+	}
+}
+
+// How long to wait before the next attempt, given how many attempts have been made already
+func (_sender *Sender) backoff(attempts int) time.Duration {
+	delay := _sender.InitialBackoff
+	for i := 0; i < attempts && delay < _sender.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > _sender.MaxBackoff {
+		delay = _sender.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,432 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bdunton9323/blockchain-playground/tracing"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// the provider can only be set up once per test binary
+var exporter = tracing.SetupInMemory()
+
+// An in-memory WebhookRepository. It keeps deliveries in a map and records every attempt the sender
+// reports, so tests can check what was retried and when.
+type fakeRepository struct {
+	mu            sync.Mutex
+	subscriptions map[string]*Subscription
+	deliveries    map[int64]*Delivery
+	// the nextAttempt the sender asked for on each failure, in order
+	nextAttempts []time.Time
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		subscriptions: map[string]*Subscription{},
+		deliveries:    map[int64]*Delivery{},
+	}
+}
+
+func (repo *fakeRepository) CreateSubscription(sub *Subscription) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.subscriptions[sub.SubscriptionId] = sub
+	return nil
+}
+
+func (repo *fakeRepository) GetSubscription(subscriptionId string) (*Subscription, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return repo.subscriptions[subscriptionId], nil
+}
+
+func (repo *fakeRepository) ListSubscriptions() ([]*Subscription, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	subs := []*Subscription{}
+	for _, sub := range repo.subscriptions {
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+func (repo *fakeRepository) UpdateSubscription(sub *Subscription) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, found := repo.subscriptions[sub.SubscriptionId]; !found {
+		return false, nil
+	}
+	repo.subscriptions[sub.SubscriptionId] = sub
+	return true, nil
+}
+
+func (repo *fakeRepository) DeleteSubscription(subscriptionId string) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, found := repo.subscriptions[subscriptionId]; !found {
+		return false, nil
+	}
+	delete(repo.subscriptions, subscriptionId)
+	return true, nil
+}
+
+func (repo *fakeRepository) ListDeliveries(subscriptionId string, status DeliveryStatus, limit int) ([]*Delivery, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	deliveries := []*Delivery{}
+	for _, delivery := range repo.deliveries {
+		if delivery.SubscriptionId == subscriptionId && (status == "" || delivery.Status == status) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (repo *fakeRepository) GetDelivery(id int64) (*Delivery, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return repo.deliveries[id], nil
+}
+
+// Everything pending is due; the tests drive time themselves by calling sendDue
+func (repo *fakeRepository) ListDueDeliveries(limit int) ([]*Delivery, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	deliveries := []*Delivery{}
+	for _, delivery := range repo.deliveries {
+		if delivery.Status == DeliveryPending && len(deliveries) < limit {
+			copied := *delivery
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	return deliveries, nil
+}
+
+func (repo *fakeRepository) ClaimDelivery(id int64) (bool, error) {
+	return true, nil
+}
+
+func (repo *fakeRepository) CompleteDelivery(delivery *Delivery, responseCode int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored := repo.deliveries[delivery.Id]
+	stored.Status = DeliveryDelivered
+	stored.Attempts++
+	stored.ResponseCode = responseCode
+	stored.LastError = ""
+	return nil
+}
+
+func (repo *fakeRepository) FailDelivery(
+	delivery *Delivery, responseCode int, cause error, final bool, nextAttempt time.Time) error {
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored := repo.deliveries[delivery.Id]
+	stored.Attempts++
+	stored.ResponseCode = responseCode
+	stored.LastError = cause.Error()
+	stored.NextAttemptAt = nextAttempt
+	if final {
+		stored.Status = DeliveryDead
+	}
+	repo.nextAttempts = append(repo.nextAttempts, nextAttempt)
+	return nil
+}
+
+func (repo *fakeRepository) RetryDelivery(id int64) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored := repo.deliveries[id]
+	if stored == nil || stored.Status != DeliveryDead {
+		return false, nil
+	}
+	stored.Status = DeliveryPending
+	stored.Attempts = 0
+	stored.NextAttemptAt = time.Now()
+	return true, nil
+}
+
+// A subscriber that checks each request's signature and answers with whatever status it is told to
+type fakeSubscriber struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+	// why each request's signature was rejected, or nil if it was fine
+	verified []error
+}
+
+func newFakeSubscriber(t *testing.T, secret string, status int) *fakeSubscriber {
+	subscriber := &fakeSubscriber{status: status}
+	subscriber.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		subscriber.mu.Lock()
+		defer subscriber.mu.Unlock()
+		subscriber.requests = append(subscriber.requests, r)
+		subscriber.bodies = append(subscriber.bodies, body)
+		subscriber.verified = append(subscriber.verified,
+			VerifySignature(secret, r.Header.Get(SignatureHeader), body, 5*time.Minute, time.Now()))
+		w.WriteHeader(subscriber.status)
+		w.Write([]byte("subscriber says no"))
+	}))
+	t.Cleanup(subscriber.Close)
+	return subscriber
+}
+
+func (subscriber *fakeSubscriber) setStatus(status int) {
+	subscriber.mu.Lock()
+	defer subscriber.mu.Unlock()
+	subscriber.status = status
+}
+
+func (subscriber *fakeSubscriber) count() int {
+	subscriber.mu.Lock()
+	defer subscriber.mu.Unlock()
+	return len(subscriber.requests)
+}
+
+// Sets up a subscription pointed at the subscriber with one pending delivery
+func newDelivery(t *testing.T, repo *fakeRepository, url string, secret string) *Delivery {
+	err := repo.CreateSubscription(&Subscription{
+		SubscriptionId: "sub-1",
+		Url:            url,
+		Secret:         secret,
+		Events:         []EventType{EventOrderMinted},
+		Active:         true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, _ := json.Marshal(&Event{
+		EventId:  "event-1",
+		Type:     EventOrderMinted,
+		OrderId:  "order-1",
+		ToStatus: "minted",
+	})
+	delivery := &Delivery{
+		Id:             1,
+		SubscriptionId: "sub-1",
+		EventId:        "event-1",
+		EventType:      EventOrderMinted,
+		OrderId:        "order-1",
+		Payload:        string(payload),
+		Status:         DeliveryPending,
+	}
+	repo.deliveries[delivery.Id] = delivery
+	return delivery
+}
+
+func TestSignatureRoundTrip(t *testing.T) {
+	payload := []byte(`{"eventId":"event-1"}`)
+	now := time.Now()
+	header := Sign("a-very-secret-key", now, payload)
+
+	if err := VerifySignature("a-very-secret-key", header, payload, time.Minute, now); err != nil {
+		t.Errorf("a fresh signature should verify: %v", err)
+	}
+	if err := VerifySignature("some-other-secret", header, payload, time.Minute, now); err == nil {
+		t.Error("a signature made with a different secret should not verify")
+	}
+	if err := VerifySignature("a-very-secret-key", header, []byte(`{"eventId":"event-2"}`), time.Minute, now); err == nil {
+		t.Error("a signature over a different payload should not verify")
+	}
+	if err := VerifySignature("a-very-secret-key", header, payload, time.Minute, now.Add(2*time.Minute)); err == nil {
+		t.Error("a signature older than the tolerance should not verify")
+	}
+	if err := VerifySignature("a-very-secret-key", "v1=abc", payload, time.Minute, now); err == nil {
+		t.Error("a header without a timestamp should not verify")
+	}
+}
+
+func TestSendSignsRequests(t *testing.T) {
+	secret := "a-very-secret-key"
+	subscriber := newFakeSubscriber(t, secret, http.StatusNoContent)
+	repo := newFakeRepository()
+	delivery := newDelivery(t, repo, subscriber.URL, secret)
+
+	NewSender(repo).sendDue()
+
+	if subscriber.count() != 1 {
+		t.Fatalf("expected 1 request, got %d", subscriber.count())
+	}
+	if err := subscriber.verified[0]; err != nil {
+		t.Errorf("the subscriber could not verify the signature: %v", err)
+	}
+	if string(subscriber.bodies[0]) != delivery.Payload {
+		t.Errorf("expected the stored payload to be sent, got %s", subscriber.bodies[0])
+	}
+	if got := subscriber.requests[0].Header.Get(EventIdHeader); got != "event-1" {
+		t.Errorf("expected event ID header event-1, got %q", got)
+	}
+	if got := subscriber.requests[0].Header.Get(EventTypeHeader); got != string(EventOrderMinted) {
+		t.Errorf("expected event type header %s, got %q", EventOrderMinted, got)
+	}
+
+	stored, _ := repo.GetDelivery(delivery.Id)
+	if stored.Status != DeliveryDelivered || stored.Attempts != 1 || stored.ResponseCode != http.StatusNoContent {
+		t.Errorf("expected the delivery to be delivered after 1 attempt with 204, got %s after %d with %d",
+			stored.Status, stored.Attempts, stored.ResponseCode)
+	}
+}
+
+func TestSendRetriesServerErrorsWithBackoff(t *testing.T) {
+	secret := "a-very-secret-key"
+	subscriber := newFakeSubscriber(t, secret, http.StatusServiceUnavailable)
+	repo := newFakeRepository()
+	delivery := newDelivery(t, repo, subscriber.URL, secret)
+
+	sender := NewSender(repo)
+	sender.InitialBackoff = time.Minute
+	sender.MaxBackoff = 5 * time.Minute
+
+	expected := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute}
+	for attempt, backoff := range expected {
+		before := time.Now()
+		sender.sendDue()
+		after := time.Now()
+
+		stored, _ := repo.GetDelivery(delivery.Id)
+		if stored.Status != DeliveryPending {
+			t.Fatalf("attempt %d: expected the delivery to still be pending, got %s", attempt+1, stored.Status)
+		}
+		if stored.Attempts != attempt+1 {
+			t.Errorf("attempt %d: expected %d attempts to be recorded, got %d", attempt+1, attempt+1, stored.Attempts)
+		}
+		if stored.ResponseCode != http.StatusServiceUnavailable {
+			t.Errorf("attempt %d: expected response code 503, got %d", attempt+1, stored.ResponseCode)
+		}
+		next := repo.nextAttempts[attempt]
+		if next.Before(before.Add(backoff)) || next.After(after.Add(backoff)) {
+			t.Errorf("attempt %d: expected the next attempt about %v away, got %v", attempt+1, backoff, next.Sub(before))
+		}
+	}
+	if subscriber.count() != len(expected) {
+		t.Errorf("expected %d requests, got %d", len(expected), subscriber.count())
+	}
+
+	// once the subscriber recovers, the next attempt goes through
+	subscriber.setStatus(http.StatusOK)
+	sender.sendDue()
+	stored, _ := repo.GetDelivery(delivery.Id)
+	if stored.Status != DeliveryDelivered {
+		t.Errorf("expected the delivery to be delivered once the subscriber recovered, got %s", stored.Status)
+	}
+}
+
+func TestSendDeadLettersAfterMaxAttempts(t *testing.T) {
+	secret := "a-very-secret-key"
+	subscriber := newFakeSubscriber(t, secret, http.StatusInternalServerError)
+	repo := newFakeRepository()
+	delivery := newDelivery(t, repo, subscriber.URL, secret)
+
+	sender := NewSender(repo)
+	sender.MaxAttempts = 3
+
+	for i := 0; i < 5; i++ {
+		sender.sendDue()
+	}
+
+	if subscriber.count() != 3 {
+		t.Errorf("expected the sender to give up after 3 requests, got %d", subscriber.count())
+	}
+	stored, _ := repo.GetDelivery(delivery.Id)
+	if stored.Status != DeliveryDead {
+		t.Fatalf("expected the delivery to be dead, got %s", stored.Status)
+	}
+	if stored.Attempts != 3 || stored.ResponseCode != http.StatusInternalServerError {
+		t.Errorf("expected 3 attempts ending in 500, got %d ending in %d", stored.Attempts, stored.ResponseCode)
+	}
+	if stored.LastError != "subscriber responded with 500: subscriber says no" {
+		t.Errorf("expected the subscriber's response in the last error, got %q", stored.LastError)
+	}
+
+	// a retry by hand starts it over
+	retried, _ := repo.RetryDelivery(delivery.Id)
+	if !retried {
+		t.Fatal("expected the dead delivery to be retried")
+	}
+	subscriber.setStatus(http.StatusOK)
+	sender.sendDue()
+	stored, _ = repo.GetDelivery(delivery.Id)
+	if stored.Status != DeliveryDelivered || subscriber.count() != 4 {
+		t.Errorf("expected the retried delivery to be sent, got %s after %d requests", stored.Status, subscriber.count())
+	}
+}
+
+func TestSendFailsWhenSubscriberIsUnreachable(t *testing.T) {
+	subscriber := newFakeSubscriber(t, "a-very-secret-key", http.StatusOK)
+	subscriber.Close()
+	repo := newFakeRepository()
+	delivery := newDelivery(t, repo, subscriber.URL, "a-very-secret-key")
+
+	NewSender(repo).sendDue()
+
+	stored, _ := repo.GetDelivery(delivery.Id)
+	if stored.Status != DeliveryPending || stored.Attempts != 1 || stored.ResponseCode != 0 {
+		t.Errorf("expected a pending delivery with 1 attempt and no response code, got %s, %d and %d",
+			stored.Status, stored.Attempts, stored.ResponseCode)
+	}
+}
+
+func TestSendContinuesTheTraceOfTheChange(t *testing.T) {
+	exporter.Reset()
+	subscriber := newFakeSubscriber(t, "a-very-secret-key", http.StatusOK)
+	repo := newFakeRepository()
+	delivery := newDelivery(t, repo, subscriber.URL, "a-very-secret-key")
+
+	// the request that changed the order, long gone by the time the delivery is sent
+	ctx, change := tracing.Start(context.Background(), "change")
+	delivery.TraceParent = tracing.TraceParent(ctx)
+	change.End()
+	changeContext := change.SpanContext()
+
+	NewSender(repo).sendDue()
+
+	if subscriber.count() != 1 {
+		t.Fatalf("expected 1 request, got %d", subscriber.count())
+	}
+	traceParent := subscriber.requests[0].Header.Get("traceparent")
+	if !strings.HasPrefix(traceParent, "00-"+changeContext.TraceID().String()+"-") {
+		t.Errorf("expected the subscriber to be sent a traceparent in trace [%s], got [%s]", changeContext.TraceID(), traceParent)
+	}
+
+	var send *tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "webhooks.Send" {
+			copied := span
+			send = &copied
+		}
+	}
+	if send == nil {
+		t.Fatal("expected a webhooks.Send span")
+	}
+	if send.SpanContext.TraceID() != changeContext.TraceID() || send.Parent.SpanID() != changeContext.SpanID() {
+		t.Errorf("expected webhooks.Send to be a child of the change's span [%s], got [%s] in trace [%s]",
+			changeContext.SpanID(), send.Parent.SpanID(), send.SpanContext.TraceID())
+	}
+	// the request to the subscriber is traced underneath the send, and its span is what the subscriber joins
+	for _, span := range exporter.GetSpans() {
+		if span.Parent.SpanID() == send.SpanContext.SpanID() && span.SpanKind == trace.SpanKindClient {
+			expected := "00-" + span.SpanContext.TraceID().String() + "-" + span.SpanContext.SpanID().String() + "-01"
+			if traceParent != expected {
+				t.Errorf("expected the traceparent [%s], got [%s]", expected, traceParent)
+			}
+			return
+		}
+	}
+	t.Error("expected the request to the subscriber to be traced under webhooks.Send")
+}
@@ -0,0 +1,370 @@
+package webhooks
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// A DTO object representing a row in the webhook_subscriptions table
+type Subscription struct {
+	SubscriptionId string
+	// where events are POSTed to
+	Url string
+	// the key that payloads are signed with
+	Secret string
+	// the events the subscriber wants to hear about
+	Events []EventType
+	// paused subscriptions keep their queued deliveries but nothing is sent until they are resumed
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Where a delivery is in its lifecycle
+type DeliveryStatus string
+
+const (
+	// waiting to be sent, or to be retried
+	DeliveryPending DeliveryStatus = "pending"
+	// the subscriber accepted it
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// gave up after too many attempts. These can be looked at and retried by hand.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// Parses a delivery status name. Returns false if the status is not known.
+func ParseDeliveryStatus(name string) (DeliveryStatus, bool) {
+	for _, status := range []DeliveryStatus{DeliveryPending, DeliveryDelivered, DeliveryDead} {
+		if string(status) == name {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// A DTO object representing a row in the webhook_deliveries table. One event sent to one subscriber.
+type Delivery struct {
+	Id             int64
+	SubscriptionId string
+	EventId        string
+	EventType      EventType
+	OrderId        string
+	// the request body, exactly as it is signed and sent
+	Payload   string
+	Status    DeliveryStatus
+	Attempts  int
+	LastError string
+	// the HTTP status the subscriber last responded with, or 0 if it couldn't be reached
+	ResponseCode  int
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	// the W3C traceparent of the request that made the change. Sending the delivery is traced as part of it.
+	TraceParent string
+}
+
+type WebhookRepository interface {
+	CreateSubscription(sub *Subscription) error
+	// Returns nil if the subscription doesn't exist
+	GetSubscription(subscriptionId string) (*Subscription, error)
+	ListSubscriptions() ([]*Subscription, error)
+	// Returns false if the subscription doesn't exist
+	UpdateSubscription(sub *Subscription) (bool, error)
+	// Deletes the subscription along with its deliveries. Returns false if it doesn't exist.
+	DeleteSubscription(subscriptionId string) (bool, error)
+
+	// Returns the subscription's deliveries, newest first. An empty status means all of them.
+	ListDeliveries(subscriptionId string, status DeliveryStatus, limit int) ([]*Delivery, error)
+	// Returns nil if the delivery doesn't exist
+	GetDelivery(id int64) (*Delivery, error)
+	// Returns pending deliveries for active subscriptions that are due and aren't claimed
+	ListDueDeliveries(limit int) ([]*Delivery, error)
+	// Takes an exclusive lease on the delivery so that only one worker sends it at a time
+	ClaimDelivery(id int64) (bool, error)
+	CompleteDelivery(delivery *Delivery, responseCode int) error
+	// Records a failed attempt. If final, the delivery is dead; otherwise it is retried at nextAttempt.
+	FailDelivery(delivery *Delivery, responseCode int, cause error, final bool, nextAttempt time.Time) error
+	// Puts a dead delivery back in the queue with a fresh set of attempts. Returns false if it isn't dead.
+	RetryDelivery(id int64) (bool, error)
+}
+
+type MariaDBWebhookRepository struct {
+	WebhookRepository
+
+	conn *sql.DB
+}
+
+var subscriptionsTable = "webhook_subscriptions"
+var deliveriesTable = "webhook_deliveries"
+var subscriptionFields = "subscription_id, url, secret, events, active, created_at, updated_at"
+var deliveryFields = "id, subscription_id, event_id, event_type, order_id, payload, status, attempts, " +
+	"last_error, response_code, next_attempt_at, created_at, updated_at, trace_parent"
+
+// How long a worker may hold a delivery before somebody else is allowed to pick it up. This needs to be
+// comfortably longer than the HTTP timeout.
+var DeliveryLease = time.Minute
+
+// Construct a new repository that uses the given connection pool to MariaDB
+func NewMariaDBWebhookRepository(db *sql.DB) *MariaDBWebhookRepository {
+	return &MariaDBWebhookRepository{conn: db}
+}
+
+// Writes the given subscription to the database
+func (repo *MariaDBWebhookRepository) CreateSubscription(sub *Subscription) error {
+	now := time.Now().UTC()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+
+	query := fmt.Sprintf("insert into %s (%s) values (?, ?, ?, ?, ?, ?, ?)", subscriptionsTable, subscriptionFields)
+	_, err := repo.exec(query,
+		sub.SubscriptionId, sub.Url, sub.Secret, joinEvents(sub.Events), sub.Active, sub.CreatedAt, sub.UpdatedAt)
+	return err
+}
+
+// Returns the subscription with the given ID. If not found, then nil.
+func (repo *MariaDBWebhookRepository) GetSubscription(subscriptionId string) (*Subscription, error) {
+	query := fmt.Sprintf("select %s from %s where subscription_id = ?", subscriptionFields, subscriptionsTable)
+	subs, err := repo.querySubscriptions(query, subscriptionId)
+	if err != nil || len(subs) == 0 {
+		return nil, err
+	}
+	return subs[0], nil
+}
+
+// Returns every subscription, oldest first
+func (repo *MariaDBWebhookRepository) ListSubscriptions() ([]*Subscription, error) {
+	query := fmt.Sprintf("select %s from %s order by created_at", subscriptionFields, subscriptionsTable)
+	return repo.querySubscriptions(query)
+}
+
+// Replaces the subscription's URL, secret, events and active flag. Returns false if it doesn't exist.
+func (repo *MariaDBWebhookRepository) UpdateSubscription(sub *Subscription) (bool, error) {
+	sub.UpdatedAt = time.Now().UTC()
+
+	query := fmt.Sprintf(
+		"update %s set url = ?, secret = ?, events = ?, active = ?, updated_at = ? where subscription_id = ?",
+		subscriptionsTable)
+	result, err := repo.exec(query,
+		sub.Url, sub.Secret, joinEvents(sub.Events), sub.Active, sub.UpdatedAt, sub.SubscriptionId)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	return updated == 1, err
+}
+
+// Deletes the subscription. Its deliveries go with it.
+func (repo *MariaDBWebhookRepository) DeleteSubscription(subscriptionId string) (bool, error) {
+	query := fmt.Sprintf("delete from %s where subscription_id = ?", subscriptionsTable)
+	result, err := repo.exec(query, subscriptionId)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := result.RowsAffected()
+	return deleted == 1, err
+}
+
+// Returns up to limit of the subscription's deliveries in the given status, newest first
+func (repo *MariaDBWebhookRepository) ListDeliveries(subscriptionId string, status DeliveryStatus, limit int) ([]*Delivery, error) {
+	conditions := "subscription_id = ?"
+	args := []interface{}{subscriptionId}
+	if status != "" {
+		conditions += " and status = ?"
+		args = append(args, status)
+	}
+
+	query := fmt.Sprintf("select %s from %s where %s order by id desc limit %d",
+		deliveryFields, deliveriesTable, conditions, limit)
+	return repo.queryDeliveries(query, args...)
+}
+
+// Returns the delivery with the given ID. If not found, then nil.
+func (repo *MariaDBWebhookRepository) GetDelivery(id int64) (*Delivery, error) {
+	query := fmt.Sprintf("select %s from %s where id = ?", deliveryFields, deliveriesTable)
+	deliveries, err := repo.queryDeliveries(query, id)
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+	return deliveries[0], nil
+}
+
+// Returns up to limit pending deliveries whose next attempt is due, oldest first. Deliveries for
+// paused subscriptions are left where they are.
+func (repo *MariaDBWebhookRepository) ListDueDeliveries(limit int) ([]*Delivery, error) {
+	query := fmt.Sprintf(
+		"select %s from %s where status = ? and next_attempt_at <= ? and (locked_until is null or locked_until < ?) "+
+			"and subscription_id in (select subscription_id from %s where active) order by id limit %d",
+		deliveryFields, deliveriesTable, subscriptionsTable, limit)
+
+	now := time.Now().UTC()
+	return repo.queryDeliveries(query, DeliveryPending, now, now)
+}
+
+// Takes a lease on the delivery. Returns false if somebody else holds an unexpired lease or it isn't pending.
+func (repo *MariaDBWebhookRepository) ClaimDelivery(id int64) (bool, error) {
+	query := fmt.Sprintf(
+		"update %s set locked_until = ? where id = ? and status = ? and (locked_until is null or locked_until < ?)",
+		deliveriesTable)
+
+	now := time.Now().UTC()
+	result, err := repo.exec(query, now.Add(DeliveryLease), id, DeliveryPending, now)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := result.RowsAffected()
+	return claimed == 1, err
+}
+
+// Records that the subscriber accepted the delivery
+func (repo *MariaDBWebhookRepository) CompleteDelivery(delivery *Delivery, responseCode int) error {
+	query := fmt.Sprintf(
+		"update %s set status = ?, attempts = attempts + 1, last_error = '', response_code = ?, "+
+			"locked_until = null, updated_at = ? where id = ?",
+		deliveriesTable)
+	_, err := repo.exec(query, DeliveryDelivered, responseCode, time.Now().UTC(), delivery.Id)
+	if err != nil {
+		return err
+	}
+
+	delivery.Status = DeliveryDelivered
+	delivery.Attempts++
+	delivery.LastError = ""
+	delivery.ResponseCode = responseCode
+	return nil
+}
+
+// Records a failed attempt at the delivery
+func (repo *MariaDBWebhookRepository) FailDelivery(
+	delivery *Delivery,
+	responseCode int,
+	cause error,
+	final bool,
+	nextAttempt time.Time,
+) error {
+	status := DeliveryPending
+	if final {
+		status = DeliveryDead
+	}
+
+	// the column is only so wide, and the subscriber's response could say anything
+	lastError := cause.Error()
+	if len(lastError) > 1024 {
+		lastError = lastError[:1024]
+	}
+
+	query := fmt.Sprintf(
+		"update %s set status = ?, attempts = attempts + 1, last_error = ?, response_code = ?, "+
+			"next_attempt_at = ?, locked_until = null, updated_at = ? where id = ?",
+		deliveriesTable)
+	_, err := repo.exec(query, status, lastError, responseCode, nextAttempt.UTC(), time.Now().UTC(), delivery.Id)
+	if err != nil {
+		return err
+	}
+
+	delivery.Status = status
+	delivery.Attempts++
+	delivery.LastError = lastError
+	delivery.ResponseCode = responseCode
+	delivery.NextAttemptAt = nextAttempt
+	return nil
+}
+
+// Puts a dead delivery back in the queue to be sent right away
+func (repo *MariaDBWebhookRepository) RetryDelivery(id int64) (bool, error) {
+	now := time.Now().UTC()
+	query := fmt.Sprintf(
+		"update %s set status = ?, attempts = 0, next_attempt_at = ?, updated_at = ? where id = ? and status = ?",
+		deliveriesTable)
+	result, err := repo.exec(query, DeliveryPending, now, now, id, DeliveryDead)
+	if err != nil {
+		return false, err
+	}
+	retried, err := result.RowsAffected()
+	return retried == 1, err
+}
+
+func (repo *MariaDBWebhookRepository) querySubscriptions(query string, args ...interface{}) ([]*Subscription, error) {
+	log.Debugf("running query [%s]", query)
+	results, err := repo.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	subs := []*Subscription{}
+	for results.Next() {
+		var sub Subscription
+		var events string
+		err = results.Scan(
+			&sub.SubscriptionId, &sub.Url, &sub.Secret, &events, &sub.Active, &sub.CreatedAt, &sub.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		sub.Events = splitEvents(events)
+		subs = append(subs, &sub)
+	}
+	return subs, results.Err()
+}
+
+func (repo *MariaDBWebhookRepository) queryDeliveries(query string, args ...interface{}) ([]*Delivery, error) {
+	log.Debugf("running query [%s]", query)
+	results, err := repo.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	deliveries := []*Delivery{}
+	for results.Next() {
+		var delivery Delivery
+		err = results.Scan(
+			&delivery.Id,
+			&delivery.SubscriptionId,
+			&delivery.EventId,
+			&delivery.EventType,
+			&delivery.OrderId,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.LastError,
+			&delivery.ResponseCode,
+			&delivery.NextAttemptAt,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt,
+			&delivery.TraceParent)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, results.Err()
+}
+
+// Runs the given statement against the database
+func (repo *MariaDBWebhookRepository) exec(query string, args ...interface{}) (sql.Result, error) {
+	log.Debugf("running query [%s]", query)
+	return repo.conn.Exec(query, args...)
+}
+
+// Events are stored comma separated so that the database can match them with find_in_set
+func joinEvents(events []EventType) string {
+	names := []string{}
+	for _, event := range events {
+		names = append(names, string(event))
+	}
+	return strings.Join(names, ",")
+}
+
+func splitEvents(events string) []EventType {
+	parsed := []EventType{}
+	for _, name := range strings.Split(events, ",") {
+		if len(name) != 0 {
+			parsed = append(parsed, EventType(name))
+		}
+	}
+	return parsed
+}